@@ -0,0 +1,287 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/bellatrix"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
+	"github.com/wealdtech/chaind/util"
+)
+
+// benchmarkStages are the stages of processing a block that are timed, in order.
+var benchmarkStages = []string{"fetch", "decode", "write", "commit"}
+
+// runBenchmark processes a number of historical epochs of blocks in to a
+// throwaway schema, and reports the time spent in each stage of processing.
+func runBenchmark(ctx context.Context) error {
+	epochs := viper.GetUint64("benchmark.epochs")
+	if epochs == 0 {
+		return errors.New("benchmark requires at least one epoch")
+	}
+
+	monitor := &nullmetrics.Service{}
+	eth2Client, err := fetchEth2Client(ctx, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start Ethereum 2 client service")
+	}
+	chainTime, err := eth2ClientChainTime(ctx, eth2Client)
+	if err != nil {
+		return err
+	}
+
+	fromEpoch := viper.GetInt64("from-epoch")
+	if fromEpoch < 0 {
+		// Default to the most recent complete epochs.
+		if uint64(chainTime.CurrentEpoch()) < epochs {
+			return errors.New("not enough epochs in the chain to benchmark")
+		}
+		fromEpoch = int64(uint64(chainTime.CurrentEpoch()) - epochs)
+	}
+	toEpoch := phase0.Epoch(uint64(fromEpoch) + epochs - 1)
+	if toEpoch >= chainTime.CurrentEpoch() {
+		return errors.New("benchmark epochs must be before the current epoch")
+	}
+
+	chainDB, err := startBenchmarkDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := chainDB.DropSchema(context.Background()); err != nil {
+			log.Warn().Err(err).Msg("Failed to drop benchmark schema")
+		}
+	}()
+	if err := upgradeDatabase(ctx, chainDB); err != nil {
+		return err
+	}
+
+	// Beacon committees are required to store attestations; they are
+	// obtained before the benchmark starts so that they are not timed.
+	log.Info().Int64("from_epoch", fromEpoch).Uint64("to_epoch", uint64(toEpoch)).Msg("Obtaining beacon committees")
+	committees, err := reindexEpochsService(ctx, "beaconcommittees", eth2Client, chainDB, chainTime)
+	if err != nil {
+		return err
+	}
+	if err := committees.ReindexEpochs(ctx, phase0.Epoch(fromEpoch), toEpoch); err != nil {
+		return errors.Wrap(err, "failed to obtain beacon committees")
+	}
+
+	blocks, err := reindexBlocksService(ctx, eth2Client, chainDB, chainTime)
+	if err != nil {
+		return err
+	}
+
+	address, err := benchmarkAddress(ctx)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout: viper.GetDuration("eth2client.timeout"),
+	}
+
+	log.Info().Int64("from_epoch", fromEpoch).Uint64("to_epoch", uint64(toEpoch)).Msg("Starting benchmark")
+	timings := make(map[string]time.Duration)
+	processed := 0
+	started := time.Now()
+	for slot := chainTime.FirstSlotOfEpoch(phase0.Epoch(fromEpoch)); slot < chainTime.FirstSlotOfEpoch(toEpoch+1); slot++ {
+		stageStarted := time.Now()
+		data, err := fetchBenchmarkBlock(ctx, client, address, slot)
+		if err != nil {
+			return err
+		}
+		timings["fetch"] += time.Since(stageStarted)
+		if data == nil {
+			// Empty slot.
+			continue
+		}
+
+		stageStarted = time.Now()
+		block, err := decodeBenchmarkBlock(data)
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode block at slot %d", slot)
+		}
+		timings["decode"] += time.Since(stageStarted)
+
+		stageStarted = time.Now()
+		dbCtx, cancel, err := chainDB.BeginTx(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to begin transaction")
+		}
+		if err := blocks.OnBlock(dbCtx, block); err != nil {
+			cancel()
+			return errors.Wrapf(err, "failed to write block at slot %d", slot)
+		}
+		timings["write"] += time.Since(stageStarted)
+
+		stageStarted = time.Now()
+		if err := chainDB.CommitTx(dbCtx); err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to commit transaction")
+		}
+		timings["commit"] += time.Since(stageStarted)
+		processed++
+	}
+	elapsed := time.Since(started)
+
+	fmt.Printf("Processed %d blocks from epochs %d to %d in %s (%.2f blocks/s)\n",
+		processed,
+		fromEpoch,
+		toEpoch,
+		elapsed.Round(time.Millisecond),
+		float64(processed)/elapsed.Seconds(),
+	)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "STAGE\tTOTAL\tPER BLOCK\tSHARE\n")
+	for _, stage := range benchmarkStages {
+		perBlock := time.Duration(0)
+		if processed > 0 {
+			perBlock = timings[stage] / time.Duration(processed)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%.1f%%\n",
+			stage,
+			timings[stage].Round(time.Millisecond),
+			perBlock.Round(time.Microsecond),
+			100*timings[stage].Seconds()/elapsed.Seconds(),
+		)
+	}
+
+	return w.Flush()
+}
+
+// startBenchmarkDatabase starts a chain database service that uses a
+// throwaway schema, leaving existing data untouched.
+func startBenchmarkDatabase(ctx context.Context) (*postgresqlchaindb.Service, error) {
+	connectionURL, err := chainDBConnectionURL("chaindb")
+	if err != nil {
+		return nil, err
+	}
+	chainDB, err := postgresqlchaindb.New(ctx,
+		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
+		postgresqlchaindb.WithConnectionURL(connectionURL),
+		postgresqlchaindb.WithPasswordFile(chainDBPasswordFile("chaindb")),
+		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.max-connections")),
+		postgresqlchaindb.WithIsolationLevel(viper.GetString("chaindb.isolation-level")),
+		postgresqlchaindb.WithCompressBitfields(viper.GetBool("chaindb.compress-bitfields")),
+		postgresqlchaindb.WithSchema(fmt.Sprintf("chaind_benchmark_%d", time.Now().Unix())),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain database service")
+	}
+
+	return chainDB, nil
+}
+
+// benchmarkAddress returns the address of the beacon node from which blocks
+// are fetched.  If multiple beacon nodes are configured the first is used.
+// Beacon nodes that require authentication or are reached over a unix socket
+// are connected to through a proxy, as they are by the client.
+func benchmarkAddress(ctx context.Context) (string, error) {
+	address := viper.GetString("eth2client.address")
+	if addresses := viper.GetStringSlice("eth2client.addresses"); len(addresses) > 0 {
+		address = addresses[0]
+	}
+	if address == "" {
+		return "", errors.New("no beacon node address supplied")
+	}
+	if needsProxy(address) {
+		proxyAddress, _, err := startProxy(ctx, address)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to start proxy")
+		}
+		address = proxyAddress
+	}
+	if !strings.HasPrefix(address, "http://") && !strings.HasPrefix(address, "https://") {
+		address = fmt.Sprintf("http://%s", address)
+	}
+
+	return strings.TrimSuffix(address, "/"), nil
+}
+
+// fetchBenchmarkBlock fetches the raw JSON of the block at the given slot,
+// returning nil if the slot is empty.  The block is fetched directly rather
+// than through the client, so that fetching and decoding can be timed apart.
+func fetchBenchmarkBlock(ctx context.Context, client *http.Client, address string, slot phase0.Slot) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/eth/v2/beacon/blocks/%d", address, slot), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch block at slot %d", slot)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("beacon node returned status %d for block at slot %d", resp.StatusCode, slot)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read block at slot %d", slot)
+	}
+
+	return data, nil
+}
+
+// decodeBenchmarkBlock decodes the JSON of a block as returned by the beacon node.
+func decodeBenchmarkBlock(data []byte) (*spec.VersionedSignedBeaconBlock, error) {
+	var resp struct {
+		Version spec.DataVersion `json:"version"`
+		Data    json.RawMessage  `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse response")
+	}
+
+	block := &spec.VersionedSignedBeaconBlock{
+		Version: resp.Version,
+	}
+	var err error
+	switch resp.Version {
+	case spec.DataVersionPhase0:
+		block.Phase0 = &phase0.SignedBeaconBlock{}
+		err = json.Unmarshal(resp.Data, block.Phase0)
+	case spec.DataVersionAltair:
+		block.Altair = &altair.SignedBeaconBlock{}
+		err = json.Unmarshal(resp.Data, block.Altair)
+	case spec.DataVersionBellatrix:
+		block.Bellatrix = &bellatrix.SignedBeaconBlock{}
+		err = json.Unmarshal(resp.Data, block.Bellatrix)
+	default:
+		return nil, fmt.Errorf("unsupported block version %v", resp.Version)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse block")
+	}
+
+	return block, nil
+}
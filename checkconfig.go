@@ -0,0 +1,334 @@
+// Copyright © 2020 - 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
+)
+
+// Status of a configuration check.
+const (
+	checkOK      = "ok"
+	checkWarning = "warning"
+	checkError   = "error"
+)
+
+// configCheck is the result of a configuration check.
+type configCheck struct {
+	name   string
+	status string
+	detail string
+}
+
+// moduleDependencies are the modules that each module requires to be enabled.
+var moduleDependencies = []struct {
+	module   string
+	requires string
+}{
+	{module: "summarizer", requires: "blocks"},
+	{module: "finalizer", requires: "blocks"},
+	{module: "summarizer.epochs", requires: "blocks.attestations"},
+	{module: "summarizer.epochs", requires: "blocks.proposer-slashings"},
+	{module: "summarizer.epochs", requires: "blocks.attester-slashings"},
+	{module: "summarizer.epochs", requires: "blocks.deposits"},
+	{module: "summarizer.blocks", requires: "blocks.attestations"},
+	{module: "summarizer.validators", requires: "proposer-duties"},
+	{module: "summarizer.validators", requires: "blocks.attestations"},
+	{module: "validators.balances", requires: "validators"},
+	{module: "depositreconciler", requires: "eth1deposits"},
+	{module: "depositreconciler", requires: "blocks.deposits"},
+}
+
+// runCheckConfig checks the configuration, without starting any modules or
+// changing the database, and reports the results.  It returns an error if any
+// check fails.
+func runCheckConfig(ctx context.Context) error {
+	checks := make([]*configCheck, 0)
+	checks = append(checks, checkModules()...)
+	checks = append(checks, checkChain(ctx)...)
+
+	failures := 0
+	for _, check := range checks {
+		fmt.Printf("%-8s %-20s %s\n", check.status, check.name, check.detail)
+		if check.status == checkError {
+			failures++
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("configuration has %d problem(s)", failures)
+	}
+
+	return nil
+}
+
+// moduleEnabled returns true if the module, and any module of which it is a
+// part, is enabled.
+func moduleEnabled(module string) bool {
+	parts := strings.Split(module, ".")
+	for i := range parts {
+		if !viper.GetBool(fmt.Sprintf("%s.enable", strings.Join(parts[:i+1], "."))) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkModules checks that the modules required by enabled modules are also
+// enabled, and that they have the configuration they need.
+func checkModules() []*configCheck {
+	checks := make([]*configCheck, 0)
+	for _, dependency := range moduleDependencies {
+		if moduleEnabled(dependency.module) && !moduleEnabled(dependency.requires) {
+			checks = append(checks, &configCheck{
+				name:   "modules",
+				status: checkError,
+				detail: fmt.Sprintf("%s requires %s to be enabled", dependency.module, dependency.requires),
+			})
+		}
+	}
+	for _, module := range []string{"eth1deposits", "executionenricher"} {
+		if moduleEnabled(module) && viper.GetString("eth1client.address") == "" {
+			checks = append(checks, &configCheck{
+				name:   "modules",
+				status: checkError,
+				detail: fmt.Sprintf("%s requires eth1client.address", module),
+			})
+		}
+	}
+	if len(checks) == 0 {
+		checks = append(checks, &configCheck{
+			name:   "modules",
+			status: checkOK,
+			detail: "enabled modules have their dependencies",
+		})
+	}
+
+	return checks
+}
+
+// checkBlockDatasets returns an error if an enabled module requires a dataset
+// contained in blocks that is disabled.  It is run at startup, as modules would
+// otherwise silently build their data from nothing.
+func checkBlockDatasets() error {
+	if !moduleEnabled("blocks") {
+		// Covered by the dependencies on blocks as a whole.
+		return nil
+	}
+	for _, dependency := range moduleDependencies {
+		if !strings.HasPrefix(dependency.requires, "blocks.") {
+			continue
+		}
+		if moduleEnabled(dependency.module) && !moduleEnabled(dependency.requires) {
+			return fmt.Errorf("%s requires %s to be enabled", dependency.module, dependency.requires)
+		}
+	}
+
+	return nil
+}
+
+// checkChain checks the database and beacon node, and that they are for the
+// same chain.
+func checkChain(ctx context.Context) []*configCheck {
+	checks := make([]*configCheck, 0)
+
+	chainDB, dbCheck := checkDatabase(ctx)
+	checks = append(checks, dbCheck)
+	version := uint64(0)
+	if chainDB != nil {
+		var schemaCheck *configCheck
+		version, schemaCheck = checkSchema(ctx, chainDB)
+		checks = append(checks, schemaCheck)
+	}
+
+	eth2Client, nodeCheck := checkBeaconNode(ctx)
+	checks = append(checks, nodeCheck)
+
+	if chainDB == nil || eth2Client == nil || version == 0 {
+		// Nothing stored to compare with the beacon node.
+		return checks
+	}
+	checks = append(checks, checkGenesis(ctx, chainDB, eth2Client))
+	checks = append(checks, checkForkSchedule(ctx, chainDB, eth2Client))
+
+	return checks
+}
+
+// checkDatabase checks that the database can be reached.
+func checkDatabase(ctx context.Context) (*postgresqlchaindb.Service, *configCheck) {
+	check := &configCheck{name: "database"}
+
+	chainDB, err := startReadOnlyDatabase(ctx)
+	if err != nil {
+		check.status = checkError
+		check.detail = err.Error()
+		return nil, check
+	}
+	check.status = checkOK
+	check.detail = "connected"
+
+	return chainDB, check
+}
+
+// checkSchema checks the version of the database schema, returning it along
+// with the result of the check.
+func checkSchema(ctx context.Context, chainDB *postgresqlchaindb.Service) (uint64, *configCheck) {
+	check := &configCheck{name: "schema"}
+
+	version, required, err := chainDB.SchemaVersions(ctx)
+	switch {
+	case err != nil:
+		check.status = checkError
+		check.detail = err.Error()
+	case version == 0:
+		check.status = checkWarning
+		check.detail = fmt.Sprintf("database is empty; schema version %d will be created on start", required)
+	case version < required:
+		check.status = checkWarning
+		check.detail = fmt.Sprintf("schema version %d will be upgraded to version %d on start", version, required)
+	case version > required:
+		check.status = checkError
+		check.detail = fmt.Sprintf("schema version %d is newer than version %d supported by this release", version, required)
+	default:
+		check.status = checkOK
+		check.detail = fmt.Sprintf("schema version %d", version)
+	}
+
+	return version, check
+}
+
+// checkBeaconNode checks that the beacon node can be reached, and reports its
+// sync state.
+func checkBeaconNode(ctx context.Context) (eth2client.Service, *configCheck) {
+	check := &configCheck{name: "beacon node"}
+
+	eth2Client, err := fetchEth2Client(ctx, &nullmetrics.Service{})
+	if err != nil {
+		check.status = checkError
+		check.detail = err.Error()
+		return nil, check
+	}
+	version := "unknown version"
+	if provider, isProvider := eth2Client.(eth2client.NodeVersionProvider); isProvider {
+		if nodeVersion, err := provider.NodeVersion(ctx); err == nil {
+			version = nodeVersion
+		}
+	}
+	syncState, err := eth2Client.(eth2client.NodeSyncingProvider).NodeSyncing(ctx)
+	switch {
+	case err != nil:
+		check.status = checkError
+		check.detail = fmt.Sprintf("%s: failed to obtain sync state: %v", eth2Client.Address(), err)
+		return nil, check
+	case syncState != nil && syncState.IsSyncing:
+		check.status = checkWarning
+		check.detail = fmt.Sprintf("%s (%s) is syncing; chaind will wait for it to sync", eth2Client.Address(), version)
+	default:
+		check.status = checkOK
+		check.detail = fmt.Sprintf("%s (%s) is synced", eth2Client.Address(), version)
+	}
+
+	return eth2Client, check
+}
+
+// checkGenesis checks that the database and the beacon node are for the same chain.
+func checkGenesis(ctx context.Context, chainDB *postgresqlchaindb.Service, eth2Client eth2client.Service) *configCheck {
+	check := &configCheck{name: "genesis"}
+
+	stored, err := chainDB.Genesis(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			check.status = checkOK
+			check.detail = "no genesis stored yet"
+			return check
+		}
+		check.status = checkError
+		check.detail = errors.Wrap(err, "failed to obtain stored genesis").Error()
+		return check
+	}
+	provider, isProvider := eth2Client.(eth2client.GenesisProvider)
+	if !isProvider {
+		check.status = checkWarning
+		check.detail = "beacon node does not provide genesis"
+		return check
+	}
+	genesis, err := provider.Genesis(ctx)
+	if err != nil {
+		check.status = checkError
+		check.detail = errors.Wrap(err, "failed to obtain genesis from beacon node").Error()
+		return check
+	}
+	if genesis.GenesisValidatorsRoot != stored.GenesisValidatorsRoot {
+		check.status = checkError
+		check.detail = fmt.Sprintf("database is for chain with genesis validators root %#x but beacon node is for %#x", stored.GenesisValidatorsRoot, genesis.GenesisValidatorsRoot)
+		return check
+	}
+	check.status = checkOK
+	check.detail = "database and beacon node are for the same chain"
+
+	return check
+}
+
+// checkForkSchedule checks that the fork schedule stored in the database is
+// compatible with that of the beacon node.  The beacon node can have forks
+// that are not yet stored, but not different versions or epochs for forks
+// that are.
+func checkForkSchedule(ctx context.Context, chainDB *postgresqlchaindb.Service, eth2Client eth2client.Service) *configCheck {
+	check := &configCheck{name: "fork schedule"}
+
+	stored, err := chainDB.ForkSchedule(ctx)
+	if err != nil {
+		check.status = checkError
+		check.detail = errors.Wrap(err, "failed to obtain stored fork schedule").Error()
+		return check
+	}
+	forkSchedule, err := eth2Client.(eth2client.ForkScheduleProvider).ForkSchedule(ctx)
+	if err != nil {
+		check.status = checkError
+		check.detail = errors.Wrap(err, "failed to obtain fork schedule from beacon node").Error()
+		return check
+	}
+
+	epochs := make(map[phase0.Version]phase0.Epoch, len(forkSchedule))
+	for _, fork := range forkSchedule {
+		epochs[fork.CurrentVersion] = fork.Epoch
+	}
+	for _, fork := range stored {
+		epoch, exists := epochs[fork.CurrentVersion]
+		if !exists {
+			check.status = checkError
+			check.detail = fmt.Sprintf("fork version %#x in database is not known to beacon node", fork.CurrentVersion)
+			return check
+		}
+		if epoch != fork.Epoch {
+			check.status = checkError
+			check.detail = fmt.Sprintf("fork version %#x is at epoch %d in database but epoch %d on beacon node", fork.CurrentVersion, fork.Epoch, epoch)
+			return check
+		}
+	}
+	check.status = checkOK
+	check.detail = fmt.Sprintf("%d forks compatible", len(forkSchedule))
+
+	return check
+}
@@ -0,0 +1,242 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// checkpointMetadataKey is the key for the metadata of the checkpoint from which the database was started.
+var checkpointMetadataKey = "checkpoint"
+
+// checkpointMetadata is the metadata of the checkpoint from which the database was started.
+type checkpointMetadata struct {
+	Epoch phase0.Epoch `json:"epoch"`
+	Root  string       `json:"root"`
+}
+
+// initFromCheckpoint initialises an empty database from the finalized checkpoint
+// in the configuration, if any, and sets the points from which the services start.
+func initFromCheckpoint(ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) error {
+	if viper.GetInt64("checkpoint.epoch") < 0 && viper.GetString("checkpoint.root") == "" {
+		// No checkpoint.
+		return nil
+	}
+	if viper.GetInt64("checkpoint.epoch") < 0 || viper.GetString("checkpoint.root") == "" {
+		return errors.New("checkpoint requires both epoch and root")
+	}
+	epoch := phase0.Epoch(viper.GetInt64("checkpoint.epoch"))
+	data, err := hex.DecodeString(strings.TrimPrefix(viper.GetString("checkpoint.root"), "0x"))
+	if err != nil {
+		return errors.Wrap(err, "invalid checkpoint root")
+	}
+	if len(data) != phase0.RootLength {
+		return errors.New("checkpoint root has incorrect length")
+	}
+	var root phase0.Root
+	copy(root[:], data)
+	log := log.With().Uint64("epoch", uint64(epoch)).Str("root", fmt.Sprintf("%#x", root)).Logger()
+
+	mdJSON, err := chainDB.Metadata(ctx, checkpointMetadataKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain checkpoint metadata")
+	}
+	if mdJSON != nil {
+		md := &checkpointMetadata{}
+		if err := json.Unmarshal(mdJSON, md); err != nil {
+			return errors.Wrap(err, "failed to unmarshal checkpoint metadata")
+		}
+		if md.Epoch != epoch || md.Root != fmt.Sprintf("%#x", root) {
+			return fmt.Errorf("database was started from a different checkpoint (epoch %d, root %s)", md.Epoch, md.Root)
+		}
+		log.Trace().Msg("Database already started from checkpoint")
+		return nil
+	}
+
+	latestBlocks, err := chainDB.(chaindb.BlocksProvider).LatestBlocks(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest blocks")
+	}
+	if len(latestBlocks) > 0 {
+		return errors.New("database already contains blocks; cannot start from checkpoint")
+	}
+
+	slot, err := verifyCheckpoint(ctx, eth2Client, chainTime, epoch, root)
+	if err != nil {
+		return errors.Wrap(err, "failed to verify checkpoint")
+	}
+
+	log.Info().Uint64("slot", uint64(slot)).Msg("Starting from checkpoint")
+	if err := storeCheckpointValidators(ctx, eth2Client, chainDB, chainTime, epoch, root); err != nil {
+		return err
+	}
+
+	// Start services from the checkpoint.  Validator balances and summaries for the
+	// checkpoint epoch rely on data from before the checkpoint, so start after it.
+	viper.Set("blocks.start-slot", int64(slot))
+	viper.Set("finalizer.start-slot", int64(slot))
+	viper.Set("sync-committees.start-period", int64(chainTime.EpochToSyncCommitteePeriod(epoch)))
+	viper.Set("validators.start-epoch", int64(epoch)+1)
+	viper.Set("beacon-committees.start-epoch", int64(epoch))
+	viper.Set("proposer-duties.start-epoch", int64(epoch))
+	viper.Set("summarizer.start-epoch", int64(epoch)+1)
+
+	return nil
+}
+
+// verifyCheckpoint verifies that the checkpoint is finalized and canonical according
+// to the beacon node, returning the slot of the checkpoint block.
+func verifyCheckpoint(ctx context.Context,
+	eth2Client eth2client.Service,
+	chainTime chaintime.Service,
+	epoch phase0.Epoch,
+	root phase0.Root,
+) (
+	phase0.Slot,
+	error,
+) {
+	finality, err := eth2Client.(eth2client.FinalityProvider).Finality(ctx, "head")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain finality")
+	}
+	if finality.Finalized.Epoch < epoch {
+		return 0, fmt.Errorf("checkpoint epoch %d is not finalized", epoch)
+	}
+
+	blocksProvider := eth2Client.(eth2client.SignedBeaconBlockProvider)
+	block, err := blocksProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%#x", root))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain checkpoint block")
+	}
+	if block == nil {
+		return 0, errors.New("checkpoint block not found")
+	}
+	slot, err := block.Slot()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain checkpoint block slot")
+	}
+	if slot > chainTime.FirstSlotOfEpoch(epoch) {
+		return 0, fmt.Errorf("checkpoint block at slot %d is after the start of epoch %d", slot, epoch)
+	}
+
+	// The checkpoint block must be canonical, and the latest block at or before the start of the epoch.
+	canonicalBlock, err := blocksProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain canonical block")
+	}
+	if canonicalBlock == nil {
+		return 0, errors.New("checkpoint block is not canonical")
+	}
+	canonicalRoot, err := canonicalBlock.Root()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain canonical block root")
+	}
+	if !bytes.Equal(canonicalRoot[:], root[:]) {
+		return 0, errors.New("checkpoint block is not canonical")
+	}
+	for laterSlot := slot + 1; laterSlot <= chainTime.FirstSlotOfEpoch(epoch); laterSlot++ {
+		laterBlock, err := blocksProvider.SignedBeaconBlock(ctx, fmt.Sprintf("%d", laterSlot))
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to obtain later block")
+		}
+		if laterBlock != nil {
+			return 0, fmt.Errorf("checkpoint block is not the block for epoch %d", epoch)
+		}
+	}
+
+	return slot, nil
+}
+
+// storeCheckpointValidators stores the validators, and their balances if enabled,
+// from the checkpoint state, and marks the database as started from the checkpoint.
+func storeCheckpointValidators(ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	epoch phase0.Epoch,
+	root phase0.Root,
+) error {
+	validatorsSetter := chainDB.(chaindb.ValidatorsSetter)
+
+	var validators map[phase0.ValidatorIndex]*api.Validator
+	if viper.GetBool("validators.enable") {
+		var err error
+		validators, err = eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, fmt.Sprintf("%d", chainTime.FirstSlotOfEpoch(epoch)), nil)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain checkpoint validators")
+		}
+	}
+
+	return util.RunTx(ctx, chainDB, func(ctx context.Context) error {
+		if viper.GetBool("validators.enable") {
+			balances := make([]*chaindb.ValidatorBalance, 0, len(validators))
+			for index, validator := range validators {
+				if err := validatorsSetter.SetValidator(ctx, &chaindb.Validator{
+					PublicKey:                  validator.Validator.PublicKey,
+					Index:                      index,
+					EffectiveBalance:           validator.Validator.EffectiveBalance,
+					Slashed:                    validator.Validator.Slashed,
+					ActivationEligibilityEpoch: validator.Validator.ActivationEligibilityEpoch,
+					ActivationEpoch:            validator.Validator.ActivationEpoch,
+					ExitEpoch:                  validator.Validator.ExitEpoch,
+					WithdrawableEpoch:          validator.Validator.WithdrawableEpoch,
+					WithdrawalCredentials:      validator.Validator.WithdrawalCredentials,
+				}); err != nil {
+					return errors.Wrap(err, "failed to set checkpoint validator")
+				}
+				balances = append(balances, &chaindb.ValidatorBalance{
+					Index:            index,
+					Epoch:            epoch,
+					Balance:          validator.Balance,
+					EffectiveBalance: validator.Validator.EffectiveBalance,
+				})
+			}
+			if viper.GetBool("validators.balances.enable") {
+				if err := validatorsSetter.SetValidatorBalances(ctx, balances); err != nil {
+					return errors.Wrap(err, "failed to set checkpoint validator balances")
+				}
+			}
+		}
+
+		mdJSON, err := json.Marshal(&checkpointMetadata{
+			Epoch: epoch,
+			Root:  fmt.Sprintf("%#x", root),
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal checkpoint metadata")
+		}
+		if err := chainDB.SetMetadata(ctx, checkpointMetadataKey, mdJSON); err != nil {
+			return errors.Wrap(err, "failed to set checkpoint metadata")
+		}
+		return nil
+	})
+}
@@ -15,18 +15,54 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	autoclient "github.com/attestantio/go-eth2-client/auto"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/eth2client/diskcache"
+	"github.com/wealdtech/chaind/services/eth2client/failover"
+	"github.com/wealdtech/chaind/services/eth2client/network"
+	"github.com/wealdtech/chaind/services/eth2client/proxy"
+	"github.com/wealdtech/chaind/services/eth2client/ratelimited"
+	"github.com/wealdtech/chaind/services/eth2client/statecache"
+	"github.com/wealdtech/chaind/services/metrics"
 	"github.com/wealdtech/chaind/util"
 )
 
 var clients map[string]eth2client.Service
 var clientsMu sync.Mutex
 
+// sharedRateLimiter limits the combined rate of requests to beacon nodes.
+var sharedRateLimiter = util.NewRateLimiter(0, 1)
+
+// rateLimiter returns the limiter for requests to beacon nodes, updated from
+// the current configuration.
+func rateLimiter() *util.RateLimiter {
+	sharedRateLimiter.Update(
+		viper.GetFloat64("eth2client.rate-limit.requests-per-second"),
+		viper.GetInt("eth2client.rate-limit.burst"),
+	)
+	return sharedRateLimiter
+}
+
+// requestTimeouts returns the timeouts configured for types of beacon node request.
+func requestTimeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for _, requestType := range ratelimited.RequestTypes() {
+		if timeout := viper.GetDuration(fmt.Sprintf("eth2client.timeouts.%s", requestType)); timeout > 0 {
+			timeouts[requestType] = timeout
+		}
+	}
+	return timeouts
+}
+
 // fetchClient fetches a client service, instantiating it if required.
 func fetchClient(ctx context.Context, address string) (eth2client.Service, error) {
 	clientsMu.Lock()
@@ -38,11 +74,35 @@ func fetchClient(ctx context.Context, address string) (eth2client.Service, error
 	var client eth2client.Service
 	var exists bool
 	if client, exists = clients[address]; !exists {
+		// Timeouts are applied to each request by the rate-limited client, so the
+		// underlying client's timeout only needs to allow the longest of them.
+		timeout := viper.GetDuration("eth2client.timeout")
+		timeouts := requestTimeouts()
+		for _, requestTimeout := range timeouts {
+			if requestTimeout > timeout {
+				timeout = requestTimeout
+			}
+		}
+
+		// The client cannot authenticate with the beacon node or connect to
+		// it over a unix socket itself, so connects through a local proxy
+		// that does so.
+		clientAddress := address
+		reportedAddress := ""
+		if needsProxy(address) {
+			proxyAddress, redactedAddress, err := startProxy(ctx, address)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to start proxy")
+			}
+			clientAddress = proxyAddress
+			reportedAddress = redactedAddress
+		}
+
 		var err error
 		client, err = autoclient.New(ctx,
 			autoclient.WithLogLevel(util.LogLevel("eth2client")),
-			autoclient.WithTimeout(viper.GetDuration("eth2client.timeout")),
-			autoclient.WithAddress(address))
+			autoclient.WithTimeout(timeout),
+			autoclient.WithAddress(clientAddress))
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to initiate client")
 		}
@@ -50,12 +110,173 @@ func fetchClient(ctx context.Context, address string) (eth2client.Service, error
 		if err := confirmClientInterfaces(client); err != nil {
 			return nil, errors.Wrap(err, "missing required interface")
 		}
+		// The chain configuration can be overridden, for beacon nodes that do
+		// not report it correctly.
+		configFile := viper.GetString("eth2client.config-file")
+		if configFile != "" {
+			configFile = resolvePath(configFile)
+		}
+		if viper.GetString("eth2client.network") != "" || configFile != "" {
+			client, err = network.New(ctx,
+				network.WithLogLevel(util.LogLevel("eth2client")),
+				network.WithLogLevelSampler(util.LogLevelSampler("eth2client")),
+				network.WithClient(client),
+				network.WithNetwork(viper.GetString("eth2client.network")),
+				network.WithConfigFile(configFile),
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create network client")
+			}
+		}
+		// All clients share a rate limiter, so that the combined rate of
+		// requests from all modules is limited.
+		client, err = ratelimited.New(ctx,
+			ratelimited.WithLogLevel(util.LogLevel("eth2client")),
+			ratelimited.WithLogLevelSampler(util.LogLevelSampler("eth2client")),
+			ratelimited.WithClient(client),
+			ratelimited.WithAddress(reportedAddress),
+			ratelimited.WithRateLimiter(rateLimiter()),
+			ratelimited.WithTimeout(viper.GetDuration("eth2client.timeout")),
+			ratelimited.WithTimeouts(timeouts),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create rate-limited client")
+		}
+		// Responses derived from the same state can be shared between modules,
+		// so that each is fetched only once.
+		if size := viper.GetInt("eth2client.state-cache.size"); size > 0 {
+			client, err = statecache.New(ctx,
+				statecache.WithLogLevel(util.LogLevel("eth2client")),
+				statecache.WithLogLevelSampler(util.LogLevelSampler("eth2client")),
+				statecache.WithClient(client),
+				statecache.WithSize(size),
+				statecache.WithPrefetch(viper.GetBool("eth2client.state-cache.prefetch")),
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create state caching client")
+			}
+		}
+		// Responses can be cached on disk, so that they are not fetched again
+		// after a restart.
+		if cacheDir := viper.GetString("eth2client.cache.dir"); cacheDir != "" {
+			client, err = diskcache.New(ctx,
+				diskcache.WithLogLevel(util.LogLevel("eth2client")),
+				diskcache.WithLogLevelSampler(util.LogLevelSampler("eth2client")),
+				diskcache.WithClient(client),
+				diskcache.WithDir(resolvePath(cacheDir)),
+			)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to create caching client")
+			}
+		}
 		clients[address] = client
 	}
 
 	return client, nil
 }
 
+// needsProxy returns true if the beacon node at the given address must be
+// connected to through a proxy.
+func needsProxy(address string) bool {
+	if strings.HasPrefix(address, "unix://") || strings.Contains(address, "@") {
+		return true
+	}
+	return len(viper.GetStringMapString("eth2client.headers")) > 0 ||
+		viper.GetString("eth2client.auth.bearer-token") != "" ||
+		viper.GetString("eth2client.auth.bearer-token-file") != "" ||
+		viper.GetString("eth2client.auth.username") != ""
+}
+
+// startProxy starts a proxy to the beacon node at the given address, returning
+// the address of the proxy and the address of the beacon node with any
+// credentials removed.
+func startProxy(ctx context.Context, address string) (string, string, error) {
+	bearerToken := viper.GetString("eth2client.auth.bearer-token")
+	if tokenFile := viper.GetString("eth2client.auth.bearer-token-file"); tokenFile != "" {
+		token, err := os.ReadFile(resolvePath(tokenFile))
+		if err != nil {
+			return "", "", errors.Wrap(err, "failed to read bearer token file")
+		}
+		bearerToken = strings.TrimSpace(string(token))
+	}
+
+	proxyService, err := proxy.New(ctx,
+		proxy.WithLogLevel(util.LogLevel("eth2client")),
+		proxy.WithLogLevelSampler(util.LogLevelSampler("eth2client")),
+		proxy.WithAddress(address),
+		proxy.WithHeaders(viper.GetStringMapString("eth2client.headers")),
+		proxy.WithBearerToken(bearerToken),
+		proxy.WithBasicAuth(viper.GetString("eth2client.auth.username"), viper.GetString("eth2client.auth.password")),
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	return proxyService.ListenAddress(), proxyService.Address(), nil
+}
+
+// fetchEth2Client fetches the main Ethereum 2 client.  If multiple beacon node
+// addresses are configured the client fails over between them.
+func fetchEth2Client(ctx context.Context, monitor metrics.Service) (eth2client.Service, error) {
+	addresses := viper.GetStringSlice("eth2client.addresses")
+	if len(addresses) == 0 {
+		return fetchClient(ctx, viper.GetString("eth2client.address"))
+	}
+	if len(addresses) == 1 {
+		return fetchClient(ctx, addresses[0])
+	}
+
+	failoverClients := make([]eth2client.Service, 0, len(addresses))
+	for _, address := range addresses {
+		client, err := fetchClient(ctx, address)
+		if err != nil {
+			// Carry on with the remaining beacon nodes.
+			log.Warn().Str("address", address).Err(err).Msg("Failed to fetch client; not using it for failover")
+			continue
+		}
+		failoverClients = append(failoverClients, client)
+	}
+	if len(failoverClients) == 0 {
+		return nil, errors.New("failed to fetch any client")
+	}
+
+	client, err := failover.New(ctx,
+		failover.WithLogLevel(util.LogLevel("eth2client")),
+		failover.WithLogLevelSampler(util.LogLevelSampler("eth2client")),
+		failover.WithMonitor(monitor),
+		failover.WithClients(failoverClients),
+		failover.WithCheckInterval(viper.GetDuration("eth2client.failover.check-interval")),
+		failover.WithMaxSyncDistance(phase0.Slot(viper.GetUint64("eth2client.failover.max-sync-distance"))),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create failover client")
+	}
+
+	return client, nil
+}
+
+// fetchCatchupClients fetches a client for each configured beacon node, for
+// services to spread their requests across when catching up.  It returns nil
+// if there are not multiple beacon nodes configured.
+func fetchCatchupClients(ctx context.Context) []eth2client.Service {
+	addresses := viper.GetStringSlice("eth2client.addresses")
+	if len(addresses) < 2 {
+		return nil
+	}
+
+	catchupClients := make([]eth2client.Service, 0, len(addresses))
+	for _, address := range addresses {
+		client, err := fetchClient(ctx, address)
+		if err != nil {
+			log.Debug().Str("address", address).Err(err).Msg("Failed to fetch client; not using it for catchup")
+			continue
+		}
+		catchupClients = append(catchupClients, client)
+	}
+
+	return catchupClients
+}
+
 func confirmClientInterfaces(client eth2client.Service) error {
 	if _, isProvider := client.(eth2client.GenesisTimeProvider); !isProvider {
 		return errors.New("client is not a GenesisTimeProvider")
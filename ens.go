@@ -0,0 +1,218 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// ensRegistry is the address of the ENS registry.
+const ensRegistry = "0x00000000000c2e074ec69a0dfb2997ba6c7d2e1e"
+
+// ensResolver carries out ENS resolution against an execution client.
+type ensResolver struct {
+	address string
+	client  *http.Client
+}
+
+// newENSResolver creates a resolver that makes calls to the execution client at the given address.
+func newENSResolver(address string, timeout time.Duration) *ensResolver {
+	return &ensResolver{
+		address: address,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+// ReverseResolve returns the primary ENS name of the address, or an empty
+// string if it does not have one.  The name is only returned if it also
+// resolves to the address.
+func (r *ensResolver) ReverseResolve(ctx context.Context, address [20]byte) (string, error) {
+	node := ensNamehash(fmt.Sprintf("%x.addr.reverse", address))
+	resolver, err := r.resolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	if resolver == ([20]byte{}) {
+		return "", nil
+	}
+	data, err := r.call(ctx, resolver, "name(bytes32)", node[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain name")
+	}
+	name, err := decodeABIString(data)
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	// Anyone can set the reverse record of an address they control to any
+	// name, so confirm that the name resolves to the address.
+	node = ensNamehash(name)
+	resolver, err = r.resolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	if resolver == ([20]byte{}) {
+		return "", nil
+	}
+	data, err = r.call(ctx, resolver, "addr(bytes32)", node[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to obtain address")
+	}
+	if len(data) != 32 || !bytes.Equal(data[12:], address[:]) {
+		return "", nil
+	}
+
+	return name, nil
+}
+
+// resolver returns the resolver for the given node from the ENS registry.
+func (r *ensResolver) resolver(ctx context.Context, node [32]byte) ([20]byte, error) {
+	var registry [20]byte
+	if _, err := hex.Decode(registry[:], []byte(strings.TrimPrefix(ensRegistry, "0x"))); err != nil {
+		return [20]byte{}, errors.Wrap(err, "invalid registry address")
+	}
+	data, err := r.call(ctx, registry, "resolver(bytes32)", node[:])
+	if err != nil {
+		return [20]byte{}, errors.Wrap(err, "failed to obtain resolver")
+	}
+	if len(data) != 32 {
+		return [20]byte{}, fmt.Errorf("resolver response of unexpected length %d", len(data))
+	}
+
+	var res [20]byte
+	copy(res[:], data[12:])
+
+	return res, nil
+}
+
+// call makes an eth_call of the given single-argument function on a contract.
+func (r *ensResolver) call(ctx context.Context, to [20]byte, function string, arg []byte) ([]byte, error) {
+	hash := sha3.NewLegacyKeccak256()
+	hash.Write([]byte(function))
+	input := append(hash.Sum(nil)[:4], arg...)
+
+	reqBody, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "eth_call",
+		"params": []interface{}{
+			map[string]string{
+				"to":   fmt.Sprintf("%#x", to),
+				"data": fmt.Sprintf("%#x", input),
+			},
+			"latest",
+		},
+		"id": 1,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.address, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call execution client")
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("execution client returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("eth_call failed with code %d: %s", response.Error.Code, response.Error.Message)
+	}
+
+	res, err := hex.DecodeString(strings.TrimPrefix(response.Result, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid result")
+	}
+
+	return res, nil
+}
+
+// ensNamehash returns the ENS namehash of a name.
+func ensNamehash(name string) [32]byte {
+	var node [32]byte
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		labelHash := sha3.NewLegacyKeccak256()
+		labelHash.Write([]byte(labels[i]))
+		hash := sha3.NewLegacyKeccak256()
+		hash.Write(node[:])
+		hash.Write(labelHash.Sum(nil))
+		copy(node[:], hash.Sum(nil))
+	}
+
+	return node
+}
+
+// decodeABIString decodes an ABI-encoded string returned from a contract call.
+func decodeABIString(data []byte) (string, error) {
+	if len(data) == 0 {
+		// No resolver code, or a resolver without a name function.
+		return "", nil
+	}
+	if len(data) < 64 {
+		return "", fmt.Errorf("string response of unexpected length %d", len(data))
+	}
+	offset := new(big.Int).SetBytes(data[:32])
+	if !offset.IsUint64() || offset.Uint64() > uint64(len(data)-32) {
+		return "", errors.New("string offset out of range")
+	}
+	start := offset.Uint64() + 32
+	length := new(big.Int).SetBytes(data[offset.Uint64():start])
+	if !length.IsUint64() || length.Uint64() > uint64(len(data))-start {
+		return "", errors.New("string length out of range")
+	}
+
+	return string(data[start : start+length.Uint64()]), nil
+}
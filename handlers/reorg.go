@@ -0,0 +1,28 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// ReorgHandler provides interfaces for handling chain reorganizations.
+type ReorgHandler interface {
+	// OnReorg is called when the finalizer has changed the canonical status of blocks
+	// that were previously finalized, starting from the given epoch.  Implementations
+	// should discard any derived data they hold from this epoch onwards.
+	OnReorg(ctx context.Context, firstEpoch phase0.Epoch)
+}
@@ -0,0 +1,370 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// labelsSourceENS is the source recorded for labels obtained by ENS reverse resolution.
+const labelsSourceENS = "ens"
+
+// addressLabelJSON is the JSON representation of an address label in an import file.
+type addressLabelJSON struct {
+	Address string `json:"address"`
+	Label   string `json:"label"`
+}
+
+// validatorLabelJSON is the JSON representation of a validator label in an import file.
+type validatorLabelJSON struct {
+	PublicKey string `json:"public_key"`
+	Label     string `json:"label"`
+}
+
+// runLabels runs a labels command.
+func runLabels(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("labels requires a command: import, ens or validators")
+	}
+
+	switch args[0] {
+	case "import":
+		if len(args) != 2 {
+			return errors.New("usage: labels import <file>")
+		}
+		return importLabels(ctx, args[1])
+	case "ens":
+		if len(args) != 1 {
+			return errors.New("usage: labels ens")
+		}
+		return resolveENSLabels(ctx)
+	case "validators":
+		if len(args) != 2 {
+			return errors.New("usage: labels validators <file>")
+		}
+		return importValidatorLabels(ctx, args[1])
+	default:
+		return fmt.Errorf("unknown labels command %q", args[0])
+	}
+}
+
+// importLabels imports address labels from a CSV or JSON file.
+func importLabels(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open labels file")
+	}
+	defer f.Close()
+
+	source := viper.GetString("labels.source")
+	if source == "" {
+		source = filepath.Base(path)
+	}
+
+	var labels []*chaindb.AddressLabel
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		labels, err = parseJSONLabels(f, source)
+	} else {
+		labels, err = parseCSVLabels(f, source)
+	}
+	if err != nil {
+		return err
+	}
+
+	chainDB, err := startDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	setter, isSetter := chainDB.(chaindb.AddressLabelsSetter)
+	if !isSetter {
+		return errors.New("chain database does not support setting address labels")
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := setter.SetAddressLabels(ctx, labels); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set address labels")
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Info().Int("labels", len(labels)).Str("source", source).Msg("Imported address labels")
+
+	return nil
+}
+
+// parseCSVLabels parses address labels from CSV with rows of address and label.
+// A header row is ignored.
+func parseCSVLabels(r io.Reader, source string) ([]*chaindb.AddressLabel, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read labels")
+	}
+
+	labels := make([]*chaindb.AddressLabel, 0, len(records))
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "address") {
+			continue
+		}
+		label, err := parseLabel(record[0], record[1], source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid label on line %d", i+1)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// parseJSONLabels parses address labels from a JSON array of objects with
+// address and label fields.
+func parseJSONLabels(r io.Reader, source string) ([]*chaindb.AddressLabel, error) {
+	var data []*addressLabelJSON
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "failed to read labels")
+	}
+
+	labels := make([]*chaindb.AddressLabel, 0, len(data))
+	for i := range data {
+		label, err := parseLabel(data[i].Address, data[i].Label, source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid label at index %d", i)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// parseLabel parses an address label.
+func parseLabel(address string, label string, source string) (*chaindb.AddressLabel, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(address), "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid address")
+	}
+	if len(data) != 20 {
+		return nil, fmt.Errorf("address %s is not 20 bytes", address)
+	}
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, fmt.Errorf("address %s has an empty label", address)
+	}
+
+	res := &chaindb.AddressLabel{
+		Label:  label,
+		Source: source,
+	}
+	copy(res.Address[:], data)
+
+	return res, nil
+}
+
+// resolveENSLabels labels fee recipient and withdrawal addresses that do not
+// have a label with their ENS primary names.
+func resolveENSLabels(ctx context.Context) error {
+	if viper.GetString("eth1client.address") == "" {
+		return errors.New("ENS resolution requires eth1client.address")
+	}
+	resolver := newENSResolver(viper.GetString("eth1client.address"), viper.GetDuration("labels.timeout"))
+
+	chainDB, err := startDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	provider, isProvider := chainDB.(chaindb.AddressLabelsProvider)
+	if !isProvider {
+		return errors.New("chain database does not provide address labels")
+	}
+	setter, isSetter := chainDB.(chaindb.AddressLabelsSetter)
+	if !isSetter {
+		return errors.New("chain database does not support setting address labels")
+	}
+
+	addresses, err := provider.UnlabeledAddresses(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain unlabeled addresses")
+	}
+	log.Info().Int("addresses", len(addresses)).Msg("Resolving ENS names for unlabeled addresses")
+
+	labels := make([]*chaindb.AddressLabel, 0)
+	for _, address := range addresses {
+		name, err := resolver.ReverseResolve(ctx, address)
+		if err != nil {
+			log.Warn().Str("address", fmt.Sprintf("%#x", address)).Err(err).Msg("Failed to resolve ENS name")
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		labels = append(labels, &chaindb.AddressLabel{
+			Address: address,
+			Label:   name,
+			Source:  labelsSourceENS,
+		})
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := setter.SetAddressLabels(ctx, labels); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set address labels")
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Info().Int("labels", len(labels)).Msg("Resolved ENS names")
+
+	return nil
+}
+
+// importValidatorLabels imports validator labels from a CSV or JSON file.
+func importValidatorLabels(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open labels file")
+	}
+	defer f.Close()
+
+	source := viper.GetString("labels.source")
+	if source == "" {
+		source = filepath.Base(path)
+	}
+
+	var labels []*chaindb.ValidatorLabel
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		labels, err = parseJSONValidatorLabels(f, source)
+	} else {
+		labels, err = parseCSVValidatorLabels(f, source)
+	}
+	if err != nil {
+		return err
+	}
+
+	chainDB, err := startDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	setter, isSetter := chainDB.(chaindb.ValidatorLabelsSetter)
+	if !isSetter {
+		return errors.New("chain database does not support setting validator labels")
+	}
+
+	ctx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := setter.SetValidatorLabels(ctx, labels); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to set validator labels")
+	}
+	if err := chainDB.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+	log.Info().Int("labels", len(labels)).Str("source", source).Msg("Imported validator labels")
+
+	return nil
+}
+
+// parseCSVValidatorLabels parses validator labels from CSV with rows of public key and label.
+// A header row is ignored.
+func parseCSVValidatorLabels(r io.Reader, source string) ([]*chaindb.ValidatorLabel, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read labels")
+	}
+
+	labels := make([]*chaindb.ValidatorLabel, 0, len(records))
+	for i, record := range records {
+		if i == 0 && strings.EqualFold(record[0], "public_key") {
+			continue
+		}
+		label, err := parseValidatorLabel(record[0], record[1], source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid label on line %d", i+1)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// parseJSONValidatorLabels parses validator labels from a JSON array of objects
+// with public_key and label fields.
+func parseJSONValidatorLabels(r io.Reader, source string) ([]*chaindb.ValidatorLabel, error) {
+	var data []*validatorLabelJSON
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "failed to read labels")
+	}
+
+	labels := make([]*chaindb.ValidatorLabel, 0, len(data))
+	for i := range data {
+		label, err := parseValidatorLabel(data[i].PublicKey, data[i].Label, source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid label at index %d", i)
+		}
+		labels = append(labels, label)
+	}
+
+	return labels, nil
+}
+
+// parseValidatorLabel parses a validator label.
+func parseValidatorLabel(publicKey string, label string, source string) (*chaindb.ValidatorLabel, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(publicKey), "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid public key")
+	}
+	if len(data) != phase0.PublicKeyLength {
+		return nil, fmt.Errorf("public key %s is not %d bytes", publicKey, phase0.PublicKeyLength)
+	}
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, fmt.Errorf("public key %s has an empty label", publicKey)
+	}
+
+	res := &chaindb.ValidatorLabel{
+		Label:  label,
+		Source: source,
+	}
+	copy(res.PublicKey[:], data)
+
+	return res, nil
+}
@@ -15,45 +15,84 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
+	"expvar"
 	"fmt"
 	"net/http"
-
-	// #nosec G108
-	_ "net/http/pprof"
+	"net/http/pprof"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/wealdtech/chaind/handlers"
+	"github.com/wealdtech/chaind/services/admin"
+	standardadmin "github.com/wealdtech/chaind/services/admin/standard"
+	beaconapi "github.com/wealdtech/chaind/services/api/beacon"
+	graphqlapi "github.com/wealdtech/chaind/services/api/graphql"
+	grpcapi "github.com/wealdtech/chaind/services/api/grpc"
+	standardapi "github.com/wealdtech/chaind/services/api/standard"
+	standardaudit "github.com/wealdtech/chaind/services/audit/standard"
+	"github.com/wealdtech/chaind/services/beaconcommittees"
 	standardbeaconcommittees "github.com/wealdtech/chaind/services/beaconcommittees/standard"
 	"github.com/wealdtech/chaind/services/blocks"
 	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
 	"github.com/wealdtech/chaind/services/chaindb"
+	dualwritechaindb "github.com/wealdtech/chaind/services/chaindb/dualwrite"
 	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	sinkschaindb "github.com/wealdtech/chaind/services/chaindb/sinks"
+	watchlistchaindb "github.com/wealdtech/chaind/services/chaindb/watchlist"
+	standardchainstats "github.com/wealdtech/chaind/services/chainstats/standard"
 	"github.com/wealdtech/chaind/services/chaintime"
 	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/checksums"
+	standardchecksums "github.com/wealdtech/chaind/services/checksums/standard"
+	standarddbstats "github.com/wealdtech/chaind/services/dbstats/standard"
+	standarddepositreconciler "github.com/wealdtech/chaind/services/depositreconciler/standard"
 	getlogseth1deposits "github.com/wealdtech/chaind/services/eth1deposits/getlogs"
+	"github.com/wealdtech/chaind/services/eth2client/network"
+	"github.com/wealdtech/chaind/services/events"
+	standardevents "github.com/wealdtech/chaind/services/events/standard"
+	standardexecutionenricher "github.com/wealdtech/chaind/services/executionenricher/standard"
 	standardfinalizer "github.com/wealdtech/chaind/services/finalizer/standard"
+	standardgaps "github.com/wealdtech/chaind/services/gaps/standard"
+	standardhealth "github.com/wealdtech/chaind/services/health/standard"
 	"github.com/wealdtech/chaind/services/metrics"
 	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
 	prometheusmetrics "github.com/wealdtech/chaind/services/metrics/prometheus"
+	standardnotifier "github.com/wealdtech/chaind/services/notifier/standard"
+	standardprogress "github.com/wealdtech/chaind/services/progress/standard"
+	"github.com/wealdtech/chaind/services/proposerduties"
 	standardproposerduties "github.com/wealdtech/chaind/services/proposerduties/standard"
+	"github.com/wealdtech/chaind/services/publisher"
+	standardpublisher "github.com/wealdtech/chaind/services/publisher/standard"
+	standardreporter "github.com/wealdtech/chaind/services/reporter/standard"
+	"github.com/wealdtech/chaind/services/scheduler"
+	standardscheduler "github.com/wealdtech/chaind/services/scheduler/standard"
 	standardspec "github.com/wealdtech/chaind/services/spec/standard"
+	"github.com/wealdtech/chaind/services/stateroots"
+	standardstateroots "github.com/wealdtech/chaind/services/stateroots/standard"
 	"github.com/wealdtech/chaind/services/summarizer"
 	standardsummarizer "github.com/wealdtech/chaind/services/summarizer/standard"
 	standardsynccommittees "github.com/wealdtech/chaind/services/synccommittees/standard"
+	"github.com/wealdtech/chaind/services/validators"
 	standardvalidators "github.com/wealdtech/chaind/services/validators/standard"
+	"github.com/wealdtech/chaind/services/validatorset"
+	standardvalidatorset "github.com/wealdtech/chaind/services/validatorset/standard"
+	"github.com/wealdtech/chaind/services/views"
+	standardviews "github.com/wealdtech/chaind/services/views/standard"
 	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
@@ -99,6 +138,17 @@ func main2() int {
 		return 1
 	}
 
+	shutdownTracing, err := initTracing(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to initialise tracing")
+		return 1
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownTracing(ctx)
+	}()
+
 	runtime.GOMAXPROCS(runtime.NumCPU() * 8)
 
 	log.Trace().Msg("Starting metrics service")
@@ -114,7 +164,19 @@ func main2() int {
 	setRelease(ctx, ReleaseVersion)
 	setReady(ctx, false)
 
-	if err := startServices(ctx, monitor); err != nil {
+	chainDB, err := startDatabase(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to start chain database")
+		return 1
+	}
+
+	leadershipLost, err := awaitLeadership(ctx, chainDB)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to obtain leadership")
+		return 1
+	}
+
+	if err := startServices(ctx, monitor, chainDB); err != nil {
 		log.Error().Err(err).Msg("Failed to initialise services")
 		return 1
 	}
@@ -122,42 +184,255 @@ func main2() int {
 
 	log.Info().Msg("All services operational")
 
-	// Wait for signal.
+	watchConfig(ctx)
+
+	// Wait for signal, or loss of leadership.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	for {
-		sig := <-sigCh
-		if sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill {
-			break
+	res := 0
+	for stopping := false; !stopping; {
+		select {
+		case sig := <-sigCh:
+			stopping = sig == syscall.SIGINT || sig == syscall.SIGTERM || sig == os.Interrupt || sig == os.Kill
+		case <-leadershipLost:
+			// Another instance may already be indexing, so stop rather than risk
+			// both writing to the database.
+			log.Error().Msg("Lost leadership")
+			res = 1
+			stopping = true
 		}
 	}
 
 	log.Info().Msg("Stopping chaind")
-	return 0
+	setReady(ctx, false)
+	if err := shutdown(chainDB, sigCh); err != nil {
+		log.Warn().Err(err).Msg("Failed to shut down cleanly")
+		return 1
+	}
+
+	return res
+}
+
+// awaitLeadership blocks until this instance is the leader of the instances
+// sharing the database, if leader election is enabled.  The returned channel
+// is closed if leadership is lost; it is nil if leader election is disabled.
+func awaitLeadership(ctx context.Context, chainDB chaindb.Service) (<-chan struct{}, error) {
+	if !viper.GetBool("leader-election.enable") {
+		return nil, nil
+	}
+	elector, isElector := chainDB.(chaindb.LeaderElector)
+	if !isElector {
+		return nil, errors.New("chain database does not support leader election")
+	}
+
+	log.Info().Msg("Waiting to become leader")
+	lost, err := elector.AwaitLeadership(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Msg("Became leader")
+
+	return lost, nil
+}
+
+// shutdown stops the chain database accepting new transactions and waits
+// for those in progress to commit, so that data and metadata written by
+// services is consistent on restart.  A further signal skips the wait.
+func shutdown(chainDB chaindb.Service, sigCh <-chan os.Signal) error {
+	closer, isCloser := chainDB.(chaindb.Closer)
+	if !isCloser {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("shutdown-timeout"))
+	defer cancel()
+	go func() {
+		select {
+		case <-sigCh:
+			log.Info().Msg("Received second signal; not waiting for transactions to finish")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	log.Info().Msg("Waiting for in-progress transactions to finish")
+	if err := closer.Close(ctx); err != nil {
+		return err
+	}
+	log.Info().Msg("Chain database closed")
+
+	return nil
 }
 
 // fetchConfig fetches configuration from various sources.
 func fetchConfig() error {
 	pflag.String("base-dir", "", "base directory for configuration files")
 	pflag.Bool("version", false, "show version and exit")
+	pflag.String("export-snapshot", "", "export a snapshot of finalized data to the given file and exit")
+	pflag.String("import-snapshot", "", "import a snapshot from the given file and exit")
+	pflag.Int64("snapshot-epoch", -1, "last epoch to include when exporting a snapshot (defaults to the latest finalized epoch)")
+	pflag.Uint64("epochs", 100, "number of epochs of summaries to return when querying a validator")
+	pflag.String("service", "", "service for which to reindex data")
+	pflag.Int64("from-epoch", -1, "first epoch to reindex or benchmark")
+	pflag.Int64("to-epoch", -1, "last epoch to reindex")
+	pflag.Int64("from-slot", -1, "first slot to reindex")
+	pflag.Uint64("benchmark.epochs", 10, "number of epochs to process when benchmarking")
+	pflag.Int64("to-slot", -1, "last slot to reindex")
+	pflag.String("labels.source", "", "source recorded for imported address labels (defaults to the name of the imported file)")
+	pflag.Duration("labels.timeout", 30*time.Second, "timeout for execution client requests when resolving ENS names for address labels")
 	pflag.String("log-level", "info", "minimum level of messsages to log")
+	pflag.Bool("leader-election.enable", false, "Only index when holding the leader lock in the database, allowing a standby instance to take over")
+	pflag.Duration("shutdown-timeout", 30*time.Second, "Time to wait for in-progress database transactions to finish when stopping")
+	pflag.Bool("strict", false, "Halt the affected module on an inconsistency in the data rather than logging it and continuing")
 	pflag.String("log-file", "", "redirect log output to a file")
 	pflag.String("profile-address", "", "Address on which to run Go profile server")
-	pflag.String("tracing-address", "", "Address to which to send tracing data")
+	pflag.Int("profile-block-rate", 0, "Nanoseconds of blocking sampled by the block profile (0 to disable block profiling)")
+	pflag.String("tracing-address", "", "OTLP/HTTP endpoint to which to send tracing data, for example http://localhost:4318/v1/traces")
+	pflag.Float64("tracing-sample-ratio", 1, "Fraction of traces to sample")
 	pflag.String("eth2client.address", "", "Address for beacon node")
+	pflag.StringSlice("eth2client.addresses", nil, "Addresses for beacon nodes in order of preference, with failover between them (overrides eth2client.address)")
 	pflag.Duration("eth2client.timeout", 2*time.Minute, "Timeout for beacon node requests")
+	pflag.Duration("eth2client.timeouts.blocks", 0, "Timeout for beacon node block requests (defaults to eth2client.timeout)")
+	pflag.Duration("eth2client.timeouts.beacon-committees", 0, "Timeout for beacon node beacon committee requests (defaults to eth2client.timeout)")
+	pflag.Duration("eth2client.timeouts.proposer-duties", 0, "Timeout for beacon node proposer duty requests (defaults to eth2client.timeout)")
+	pflag.Duration("eth2client.timeouts.sync-committees", 0, "Timeout for beacon node sync committee requests (defaults to eth2client.timeout)")
+	pflag.Duration("eth2client.timeouts.validators", 0, "Timeout for beacon node validator requests (defaults to eth2client.timeout)")
+	pflag.StringToString("eth2client.headers", nil, "Additional headers sent with requests to beacon nodes")
+	pflag.String("eth2client.auth.bearer-token", "", "Bearer token, for example a JWT, sent with requests to beacon nodes")
+	pflag.String("eth2client.auth.bearer-token-file", "", "File containing the bearer token sent with requests to beacon nodes")
+	pflag.String("eth2client.auth.username", "", "Username for basic authentication with beacon nodes")
+	pflag.String("eth2client.auth.password", "", "Password for basic authentication with beacon nodes")
+	pflag.String("eth2client.network", "", fmt.Sprintf("Preset network whose chain configuration overrides that of the beacon node (one of %s)", strings.Join(network.Networks(), ", ")))
+	pflag.String("eth2client.config-file", "", "config.yaml file whose chain configuration overrides that of the beacon node and any preset network")
+	pflag.String("eth2client.cache.dir", "", "Directory in which to cache beacon committees, proposer duties and validators fetched for finalized epochs (disabled if empty)")
+	pflag.Int("eth2client.state-cache.size", 0, "Number of beacon committee, proposer duty and validator responses held in memory, keyed by state ID, and shared between modules (0 to disable)")
+	pflag.Bool("eth2client.state-cache.prefetch", false, "Fetch beacon committees, proposer duties and validators for an epoch concurrently when any of them is requested (requires eth2client.state-cache.size of at least 3)")
+	pflag.Duration("eth2client.failover.check-interval", 30*time.Second, "Interval at which the health of beacon nodes is checked for failover")
+	pflag.Uint64("eth2client.failover.max-sync-distance", 8, "Maximum number of slots a beacon node can be behind and be considered healthy for failover")
+	pflag.Float64("eth2client.rate-limit.requests-per-second", 0, "Maximum combined rate of requests to beacon nodes (0 for no limit)")
+	pflag.Int("eth2client.rate-limit.burst", 10, "Number of requests to beacon nodes that can be made in a burst above the rate limit")
+	pflag.Duration("eth2client.events.stall-timeout", 2*time.Minute, "Time without events from the beacon node after which the event stream is re-established (0 to disable)")
+	pflag.Int("retry.retries", 3, "Number of times a failed beacon node fetch is retried before being recorded as missed")
+	pflag.Duration("retry.base-delay", time.Second, "Delay before the first retry of a failed beacon node fetch; doubles with each retry")
+	pflag.Duration("retry.max-delay", 30*time.Second, "Maximum delay between retries of a failed beacon node fetch")
+	pflag.Bool("backfill.enable", false, "Follow the chain from the current slot on start, and fill in earlier data in reverse order in the background")
+	pflag.Uint64("backfill.head-first-distance", 0, "Number of epochs a module can be behind the chain on start before it follows the chain and backfills, even if backfill is not enabled (0 to disable)")
+	pflag.Duration("backfill.throttle.max-commit-latency", 0, "Average database commit latency above which catchup and backfill are held back (0 to disable)")
+	pflag.Float64("backfill.throttle.max-connection-usage", 0, "Fraction of database connections in use above which catchup and backfill are held back (0 to disable)")
+	pflag.Duration("backfill.throttle.max-replication-lag", 0, "Database replication lag above which catchup and backfill are held back (0 to disable)")
+	pflag.Int64("checkpoint.epoch", -1, "Epoch of the finalized checkpoint from which to start an empty database")
+	pflag.String("checkpoint.root", "", "Block root of the finalized checkpoint from which to start an empty database")
+	pflag.Duration("chaintime.slot-duration", 0, "Duration of a slot (defaults to SECONDS_PER_SLOT in the beacon node's spec)")
+	pflag.Uint64("chaintime.slots-per-epoch", 0, "Number of slots in an epoch (defaults to SLOTS_PER_EPOCH in the beacon node's spec)")
+	pflag.Uint64("chaintime.epochs-per-sync-committee-period", 0, "Number of epochs in a sync committee period (defaults to EPOCHS_PER_SYNC_COMMITTEE_PERIOD in the beacon node's spec)")
 	pflag.Bool("blocks.enable", true, "Enable fetching of block-related information")
 	pflag.Int32("blocks.start-slot", -1, "Slot from which to start fetching blocks")
 	pflag.Bool("blocks.refetch", false, "Refetch all blocks even if they are already in the database")
+	pflag.Bool("blocks.attestations.enable", true, "Store attestations contained in blocks")
+	pflag.Bool("blocks.proposer-slashings.enable", true, "Store proposer slashings contained in blocks")
+	pflag.Bool("blocks.attester-slashings.enable", true, "Store attester slashings contained in blocks")
+	pflag.Bool("blocks.deposits.enable", true, "Store deposits contained in blocks")
+	pflag.Bool("blocks.voluntary-exits.enable", true, "Store voluntary exits contained in blocks")
+	pflag.Bool("blocks.sync-aggregates.enable", true, "Store sync aggregates contained in blocks")
 	pflag.Bool("finalizer.enable", true, "Enable additional information on receipt of finality checkpoint")
+	pflag.Int32("finalizer.start-slot", -1, "Slot from which to start canonicalizing blocks")
+	pflag.Int("finalizer.workers", 1, "Number of concurrent workers used to canonicalize blocks and update attestations on finality")
 	pflag.Bool("summarizer.enable", true, "Enable summary information")
+	pflag.Int32("summarizer.start-epoch", -1, "Epoch from which to start summarizing")
 	pflag.Bool("summarizer.epochs.enable", true, "Enable summary information for epochs")
 	pflag.Bool("summarizer.blocks.enable", true, "Enable summary information for blocks")
 	pflag.Bool("summarizer.validators.enable", false, "Enable summary information for validators (warning: creates a lot of data)")
+	pflag.Bool("api.enable", false, "Enable the REST API server")
+	pflag.String("api.listen-address", "0.0.0.0:8085", "Address on which the REST API server listens")
+	pflag.Uint64("api.max-slot-range", 1024, "Maximum number of slots in a single REST API range request")
+	pflag.Bool("graphql.enable", false, "Enable the GraphQL server")
+	pflag.String("graphql.listen-address", "0.0.0.0:8086", "Address on which the GraphQL server listens")
+	pflag.Uint64("graphql.max-slot-range", 1024, "Maximum number of slots in a single GraphQL range field")
+	pflag.Bool("grpc.enable", false, "Enable the gRPC server")
+	pflag.String("grpc.listen-address", "0.0.0.0:8087", "Address on which the gRPC server listens")
+	pflag.Uint64("grpc.batch-size", 32, "Number of slots or epochs fetched from the database at a time when streaming gRPC responses")
+	pflag.Bool("beacon-api.enable", false, "Enable the Beacon API server")
+	pflag.String("beacon-api.listen-address", "0.0.0.0:8089", "Address on which the Beacon API server listens")
+	pflag.Bool("events.enable", false, "Enable the events server")
+	pflag.String("events.listen-address", "0.0.0.0:8088", "Address on which the events server listens")
+	pflag.Int("events.buffer-size", 64, "Number of events buffered for each subscriber before it is disconnected")
+	pflag.Duration("events.progress-interval", 12*time.Second, "Interval at which service progress is checked for progress events")
+	pflag.Bool("publisher.enable", false, "Enable publishing of indexed data to Kafka or NATS")
+	pflag.String("publisher.backend", "", "Backend to which indexed data is published (kafka or nats)")
+	pflag.StringSlice("publisher.addresses", nil, "Addresses of the Kafka brokers or NATS servers")
+	pflag.String("publisher.topic-prefix", "chaind", "Prefix for the topics to which indexed data is published")
+	pflag.String("publisher.encoding", "json", "Encoding of published messages (json or protobuf)")
+	pflag.Int("publisher.buffer-size", 1024, "Number of indexing updates that can be queued for publishing")
+	pflag.Bool("views.enable", false, "Enable management of materialized views")
+	pflag.Bool("state-roots.enable", false, "Enable recording of the state root of each finalized slot")
+	pflag.String("state-roots.archive-location", "", "Template for the file path or URL of archived states")
+	pflag.Uint64("state-roots.archive-interval", 0, "Interval in slots between archived states (defaults to SLOTS_PER_HISTORICAL_ROOT)")
+	pflag.Int64("state-roots.start-slot", -1, "Slot from which to start recording state roots")
+	pflag.Bool("checksums.enable", false, "Enable checksums of the data of each epoch")
+	pflag.StringSlice("checksums.datasets", nil, "Datasets for which checksums are computed (defaults to all)")
+	pflag.Int64("checksums.start-epoch", -1, "Epoch from which to start computing checksums")
+	pflag.Bool("admin.enable", false, "Enable the admin server")
+	pflag.String("admin.listen-address", "127.0.0.1:8091", "Address on which the admin server listens")
+	pflag.String("admin.token", "", "Bearer token required to access the admin server (optional if auth.admin-keys or auth.jwt-secret is set)")
+	pflag.StringSlice("auth.read-keys", nil, "API keys granting read access to the HTTP servers")
+	pflag.StringSlice("auth.admin-keys", nil, "API keys granting read and admin access to the HTTP servers")
+	pflag.String("auth.jwt-secret", "", "Secret with which JSON web tokens granting access to the HTTP servers are signed")
+	pflag.Bool("health.enable", false, "Enable the health server")
+	pflag.String("health.listen-address", "0.0.0.0:8090", "Address on which the health server listens")
+	pflag.Uint64("health.max-lag", 4, "Maximum number of epochs a service can lag the chain and be considered ready")
+	pflag.Duration("health.stall-timeout", 15*time.Minute, "Time a lagging service can fail to make progress before it is considered stalled")
+	pflag.Bool("chainstats.enable", false, "Enable export of chain statistics as metrics")
+	pflag.Duration("chainstats.interval", time.Minute, "Interval at which chain statistics are recalculated")
+	pflag.Uint64("chainstats.epochs", 10, "Number of recent finalized epochs over which missed blocks are counted")
+	pflag.Bool("dbstats.enable", false, "Enable export of database table sizes and row counts as metrics")
+	pflag.Duration("dbstats.interval", 5*time.Minute, "Interval at which database statistics are collected")
+	pflag.Bool("progress.enable", true, "Enable reporting of catchup progress")
+	pflag.Duration("progress.interval", time.Minute, "Interval at which catchup progress is reported")
+	pflag.Bool("gaps.enable", false, "Enable detection and repair of gaps in the database")
+	pflag.Duration("gaps.interval", time.Hour, "Interval at which the database is scanned for gaps")
+	pflag.Uint64("gaps.max-repairs", 100, "Maximum number of epochs repaired for each dataset in a single scan")
+	pflag.Bool("depositreconciler.enable", false, "Enable reconciliation of Ethereum 1 deposits with beacon chain deposits")
+	pflag.Duration("depositreconciler.interval", time.Hour, "Interval at which deposits are reconciled")
+	pflag.Duration("depositreconciler.window", 24*time.Hour, "Time after its Ethereum 1 block within which a deposit is expected to be included in the beacon chain")
+	pflag.Bool("executionenricher.enable", false, "Enable enrichment of execution payloads with transactions and receipts from the execution client at eth1client.address")
+	pflag.Duration("executionenricher.interval", time.Minute, "Interval at which new canonical blocks are enriched")
+	pflag.Uint64("executionenricher.start-slot", 0, "Slot from which to start enriching execution payloads")
+	pflag.Duration("executionenricher.timeout", 30*time.Second, "Timeout for requests to the execution client")
+	pflag.Int("scheduler.concurrency", 4, "Maximum number of scheduled jobs that run at the same time")
+	pflag.Int("scheduler.repair-concurrency", 1, "Maximum number of repair jobs that run at the same time")
+	pflag.Int("scheduler.max-queued", 0, "Maximum number of scheduled jobs waiting to run (0 for no limit)")
+	pflag.Bool("audit.enable", false, "Enable auditing of the database against the beacon node")
+	pflag.Duration("audit.interval", time.Hour, "Interval at which finalized epochs are audited")
+	pflag.Uint64("audit.epochs", 1, "Number of finalized epochs sampled at each interval")
+	pflag.Uint64("audit.validators", 64, "Number of validators sampled in each audited epoch")
+	pflag.Bool("audit.recompute-committees", false, "Recompute beacon committees from the beacon state for audited epochs (warning: fetches full beacon states)")
+	pflag.Bool("notifier.enable", false, "Enable webhook notifications for validator events")
+	pflag.Duration("notifier.interval", time.Minute, "Interval at which the database is checked for validator events")
+	pflag.Uint64("notifier.offline-epochs", 2, "Number of consecutive epochs without an included attestation before a validator is considered offline")
+	pflag.Duration("notifier.timeout", 10*time.Second, "Timeout for webhook requests")
+	pflag.Uint64("notifier.min-effectiveness", 0, "Attestation effectiveness percentage below which a validator's attestation is notified; 0 to disable")
+	pflag.Uint64("notifier.finality-delay-epochs", 4, "Number of epochs the chain can go without finalizing before it is notified")
+	pflag.Bool("reporter.enable", false, "Enable periodic performance reports")
+	pflag.String("reporter.period", "daily", "Period covered by each report (daily or weekly)")
+	pflag.StringSlice("reporter.formats", []string{"json"}, "Formats in which reports are written to the directory (json, csv, html)")
+	pflag.String("reporter.directory", "", "Directory to which reports are written")
+	pflag.String("reporter.webhook", "", "URL to which reports are POSTed as JSON")
+	pflag.Duration("reporter.interval", 10*time.Minute, "Interval at which the database is checked for a period to report")
+	pflag.Duration("reporter.timeout", 30*time.Second, "Timeout for webhook requests")
 	pflag.Bool("validators.enable", true, "Enable fetching of validator-related information")
 	pflag.Bool("validators.balances.enable", false, "Enable fetching of validator balances (warning: creates a lot of data)")
+	pflag.Int32("validators.start-epoch", -1, "Epoch from which to start fetching validator balances")
+	pflag.Uint64("validators.full-update-interval", 256, "Number of epochs between writes of the full validator set, with only changed validators written in between (0 to write the full set every epoch)")
 	pflag.Bool("beacon-committees.enable", true, "Enable fetching of beacon committee-related information")
+	pflag.Int32("beacon-committees.start-epoch", -1, "Epoch from which to start fetching beacon committees")
+	pflag.Int("beacon-committees.catchup-workers", 1, "Number of epochs of beacon committees processed concurrently when catching up")
+	pflag.Int("beacon-committees.catchup-epochs-per-tx", 1, "Number of epochs of beacon committees stored in each database transaction when catching up")
+	pflag.Int64("beacon-committees.concurrency", 1, "Number of beacon committee handlers that can be active at the same time")
 	pflag.Bool("proposer-duties.enable", true, "Enable fetching of proposer duty-related information")
+	pflag.Int32("proposer-duties.start-epoch", -1, "Epoch from which to start fetching proposer duties")
+	pflag.Int("proposer-duties.catchup-workers", 1, "Number of epochs of proposer duties processed concurrently when catching up")
+	pflag.Int("proposer-duties.catchup-epochs-per-tx", 1, "Number of epochs of proposer duties stored in each database transaction when catching up")
+	pflag.Int64("proposer-duties.concurrency", 1, "Number of proposer duty handlers that can be active at the same time")
 	pflag.Bool("sync-committees.enable", true, "Enable fetching of sync committee-related information")
 	pflag.Int32("sync-committees.start-period", -1, "Period from which to start fetching sync committees")
 	pflag.Bool("eth1deposits.enable", false, "Enable fetching of Ethereum 1 deposit information")
@@ -165,6 +440,18 @@ func fetchConfig() error {
 	pflag.String("eth1client.address", "", "Address for Ethereum 1 node")
 	pflag.String("chaindb.url", "", "URL for database")
 	pflag.Uint("chaindb.max-connections", 16, "maximum number of concurrent database connections")
+	pflag.String("chaindb.isolation-level", "read committed", "isolation level of database transactions (read committed, repeatable read or serializable)")
+	pflag.Uint64("chaindb.async-commit-distance", 0, "Number of epochs behind the head of the chain beyond which catchup transactions are committed asynchronously (0 to disable)")
+	pflag.Bool("chaindb.compress-bitfields", false, "Store the aggregation bits of attestations and the bits of sync aggregates compressed")
+	pflag.String("chaindb.url-file", "", "File containing the URL for database (overrides chaindb.url)")
+	pflag.String("chaindb.password-file", "", "File containing the password for database, re-read for each new connection (overrides any password in the URL)")
+	pflag.String("chaindb.secondary.url", "", "URL for secondary database; if set all writes also go to this database")
+	pflag.String("chaindb.secondary.url-file", "", "File containing the URL for secondary database (overrides chaindb.secondary.url)")
+	pflag.String("chaindb.secondary.password-file", "", "File containing the password for secondary database, re-read for each new connection (overrides any password in the URL)")
+	pflag.Uint("chaindb.secondary.max-connections", 16, "maximum number of concurrent secondary database connections")
+	pflag.StringSlice("chaindb.sinks", nil, "names of registered sinks to which all writes also go")
+	pflag.StringSlice("watchlist.validators", nil, "indices or public keys of validators to watch; if set only per-validator data for these validators is stored")
+	pflag.Int("chaindb.batch-size", 10000, "Maximum number of validator balances or summaries written to the database at a time")
 	pflag.Parse()
 	if err := viper.BindPFlags(pflag.CommandLine); err != nil {
 		return errors.Wrap(err, "failed to bind pflags to viper")
@@ -199,13 +486,34 @@ func fetchConfig() error {
 }
 
 // initProfiling initialises the profiling server.
+// The server has its own handler, so that profiles and runtime variables are
+// only available on the profiling address and not alongside metrics.  If
+// credentials are configured they are required with the admin role.
 func initProfiling() error {
 	profileAddress := viper.GetString("profile-address")
 	if profileAddress != "" {
+		expvar.NewString("version").Set(ReleaseVersion)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+		mux.Handle("/debug/vars", expvar.Handler())
+		server := &http.Server{
+			Addr:              profileAddress,
+			Handler:           authenticator().Require(util.RoleAdmin, mux),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+
 		go func() {
 			log.Info().Str("profile_address", profileAddress).Msg("Starting profile server")
 			runtime.SetMutexProfileFraction(1)
-			if err := http.ListenAndServe(profileAddress, nil); err != nil {
+			if rate := viper.GetInt("profile-block-rate"); rate > 0 {
+				runtime.SetBlockProfileRate(rate)
+			}
+			if err := server.ListenAndServe(); err != nil {
 				log.Warn().Str("profile_address", profileAddress).Err(err).Msg("Failed to run profile server")
 			}
 		}()
@@ -219,6 +527,7 @@ func startMonitor(ctx context.Context) (metrics.Service, error) {
 		var err error
 		monitor, err = prometheusmetrics.New(ctx,
 			prometheusmetrics.WithLogLevel(util.LogLevel("metrics.prometheus")),
+			prometheusmetrics.WithLogLevelSampler(util.LogLevelSampler("metrics.prometheus")),
 			prometheusmetrics.WithAddress(viper.GetString("metrics.prometheus.listen-address")),
 		)
 		if err != nil {
@@ -234,42 +543,231 @@ func startMonitor(ctx context.Context) (metrics.Service, error) {
 
 func startDatabase(ctx context.Context) (chaindb.Service, error) {
 	log.Trace().Msg("Starting chain database service")
+	connectionURL, err := chainDBConnectionURL("chaindb")
+	if err != nil {
+		return nil, err
+	}
 	chainDB, err := postgresqlchaindb.New(ctx,
 		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
-		postgresqlchaindb.WithConnectionURL(viper.GetString("chaindb.url")),
+		postgresqlchaindb.WithLogLevelSampler(util.LogLevelSampler("chaindb")),
+		postgresqlchaindb.WithConnectionURL(connectionURL),
+		postgresqlchaindb.WithPasswordFile(chainDBPasswordFile("chaindb")),
 		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.max-connections")),
+		postgresqlchaindb.WithIsolationLevel(viper.GetString("chaindb.isolation-level")),
+		postgresqlchaindb.WithAsyncCommitDistance(phase0.Epoch(viper.GetUint64("chaindb.async-commit-distance"))),
+		postgresqlchaindb.WithCompressBitfields(viper.GetBool("chaindb.compress-bitfields")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to start chain database service")
 	}
-	return chainDB, err
+	if err := upgradeDatabase(ctx, chainDB); err != nil {
+		return nil, err
+	}
+
+	secondaryConnectionURL, err := chainDBConnectionURL("chaindb.secondary")
+	if err != nil {
+		return nil, err
+	}
+	if secondaryConnectionURL == "" {
+		return wrapDatabase(ctx, chainDB)
+	}
+
+	log.Trace().Msg("Starting secondary chain database service")
+	secondaryChainDB, err := postgresqlchaindb.New(ctx,
+		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
+		postgresqlchaindb.WithLogLevelSampler(util.LogLevelSampler("chaindb")),
+		postgresqlchaindb.WithConnectionURL(secondaryConnectionURL),
+		postgresqlchaindb.WithPasswordFile(chainDBPasswordFile("chaindb.secondary")),
+		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.secondary.max-connections")),
+		postgresqlchaindb.WithIsolationLevel(viper.GetString("chaindb.isolation-level")),
+		postgresqlchaindb.WithAsyncCommitDistance(phase0.Epoch(viper.GetUint64("chaindb.async-commit-distance"))),
+		postgresqlchaindb.WithCompressBitfields(viper.GetBool("chaindb.compress-bitfields")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start secondary chain database service")
+	}
+	if err := upgradeDatabase(ctx, secondaryChainDB); err != nil {
+		return nil, err
+	}
+
+	log.Info().Msg("Writing to both primary and secondary chain databases")
+	dualWriteChainDB, err := dualwritechaindb.New(ctx,
+		dualwritechaindb.WithLogLevel(util.LogLevel("chaindb")),
+		dualwritechaindb.WithLogLevelSampler(util.LogLevelSampler("chaindb")),
+		dualwritechaindb.WithPrimary(chainDB),
+		dualwritechaindb.WithSecondary(secondaryChainDB),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start dual-write chain database service")
+	}
+
+	return wrapDatabase(ctx, dualWriteChainDB)
 }
 
-func startServices(ctx context.Context, monitor metrics.Service) error {
-	log.Trace().Msg("Checking for schema upgrades")
-	chainDB, err := startDatabase(ctx)
+// chainDBConnectionURL returns the connection URL for the database with the
+// given configuration prefix, reading it from a file if one is configured.
+// The URL can also be supplied through the environment, for example
+// CHAIND_CHAINDB_URL.
+func chainDBConnectionURL(prefix string) (string, error) {
+	urlFile := viper.GetString(fmt.Sprintf("%s.url-file", prefix))
+	if urlFile == "" {
+		return viper.GetString(fmt.Sprintf("%s.url", prefix)), nil
+	}
+	data, err := os.ReadFile(resolvePath(urlFile))
 	if err != nil {
-		return err
+		return "", errors.Wrap(err, "failed to read database URL file")
 	}
 
-	if _, isUpgrader := chainDB.(*postgresqlchaindb.Service); isUpgrader {
-		requiresRefetch, err := chainDB.(*postgresqlchaindb.Service).Upgrade(ctx)
-		if err != nil {
-			return errors.Wrap(err, "failed to upgrade chain database")
+	return strings.TrimSpace(string(data)), nil
+}
+
+// startReadOnlyDatabase starts the chain database for commands that only
+// read from it.  The schema is not upgraded.
+func startReadOnlyDatabase(ctx context.Context) (*postgresqlchaindb.Service, error) {
+	connectionURL, err := chainDBConnectionURL("chaindb")
+	if err != nil {
+		return nil, err
+	}
+	chainDB, err := postgresqlchaindb.New(ctx,
+		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
+		postgresqlchaindb.WithConnectionURL(connectionURL),
+		postgresqlchaindb.WithPasswordFile(chainDBPasswordFile("chaindb")),
+		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.max-connections")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain database service")
+	}
+
+	return chainDB, nil
+}
+
+// chainDBPasswordFile returns the path of the password file for the database
+// with the given configuration prefix, or an empty string if there is none.
+func chainDBPasswordFile(prefix string) string {
+	passwordFile := viper.GetString(fmt.Sprintf("%s.password-file", prefix))
+	if passwordFile == "" {
+		return ""
+	}
+
+	return resolvePath(passwordFile)
+}
+
+// wrapDatabase wraps the chain database with any configured sinks and watchlist.
+// The watchlist is outermost, so that sinks only receive the data that is stored.
+func wrapDatabase(ctx context.Context, chainDB chaindb.Service) (chaindb.Service, error) {
+	chainDB, err := startSinks(ctx, chainDB)
+	if err != nil {
+		return nil, err
+	}
+
+	return startWatchlist(ctx, chainDB)
+}
+
+// startSinks wraps the chain database so that writes also go to any configured sinks.
+func startSinks(ctx context.Context, chainDB chaindb.Service) (chaindb.Service, error) {
+	names := viper.GetStringSlice("chaindb.sinks")
+	if len(names) == 0 {
+		return chainDB, nil
+	}
+
+	sinks, err := sinkschaindb.Create(ctx, names)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sinks")
+	}
+
+	log.Info().Strs("sinks", names).Msg("Writing to sinks in addition to the chain database")
+	sinksChainDB, err := sinkschaindb.New(ctx,
+		sinkschaindb.WithLogLevel(util.LogLevel("chaindb")),
+		sinkschaindb.WithLogLevelSampler(util.LogLevelSampler("chaindb")),
+		sinkschaindb.WithChainDB(chainDB),
+		sinkschaindb.WithSinks(sinks),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start sinks chain database service")
+	}
+
+	return sinksChainDB, nil
+}
+
+// startWatchlist wraps the chain database so that only per-validator data for
+// watched validators is stored.
+func startWatchlist(ctx context.Context, chainDB chaindb.Service) (chaindb.Service, error) {
+	validators := viper.GetStringSlice("watchlist.validators")
+	if len(validators) == 0 {
+		return chainDB, nil
+	}
+
+	// Network-level summaries would be built from the attestations of watched
+	// validators alone, so are not meaningful with a watchlist.
+	for _, module := range []string{"summarizer.epochs", "summarizer.blocks"} {
+		if viper.GetBool(fmt.Sprintf("%s.enable", module)) {
+			log.Warn().Str("module", module).Msg("Network-level summaries are not available with a watchlist; disabling")
+			viper.Set(fmt.Sprintf("%s.enable", module), false)
+		}
+	}
+
+	indices := make([]phase0.ValidatorIndex, 0)
+	publicKeys := make([]phase0.BLSPubKey, 0)
+	for _, validator := range validators {
+		if strings.HasPrefix(validator, "0x") {
+			data, err := hex.DecodeString(strings.TrimPrefix(validator, "0x"))
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid watchlist public key %s", validator)
+			}
+			if len(data) != phase0.PublicKeyLength {
+				return nil, fmt.Errorf("watchlist public key %s is not %d bytes", validator, phase0.PublicKeyLength)
+			}
+			var publicKey phase0.BLSPubKey
+			copy(publicKey[:], data)
+			publicKeys = append(publicKeys, publicKey)
+			continue
 		}
-		if requiresRefetch {
-			// The upgrade requires us to refetch blocks, so set up the options accordingly.
-			// These will be picked up by the blocks service.
-			viper.Set("blocks.start-slot", 0)
-			viper.Set("blocks.refetch", true)
+		index, err := strconv.ParseUint(validator, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid watchlist validator index %s", validator)
 		}
+		indices = append(indices, phase0.ValidatorIndex(index))
 	}
 
-	log.Trace().Msg("Starting Ethereum 2 client service")
-	eth2Client, err := fetchClient(ctx, viper.GetString("eth2client.address"))
+	log.Info().Int("validators", len(validators)).Msg("Only storing per-validator data for watched validators")
+	watchlistChainDB, err := watchlistchaindb.New(ctx,
+		watchlistchaindb.WithLogLevel(util.LogLevel("chaindb")),
+		watchlistchaindb.WithLogLevelSampler(util.LogLevelSampler("chaindb")),
+		watchlistchaindb.WithChainDB(chainDB),
+		watchlistchaindb.WithIndices(indices),
+		watchlistchaindb.WithPublicKeys(publicKeys),
+	)
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("eth2client.address")))
+		return nil, errors.Wrap(err, "failed to start watchlist chain database service")
 	}
+
+	return watchlistChainDB, nil
+}
+
+// upgradeDatabase upgrades the schema of the given chain database if required.
+func upgradeDatabase(ctx context.Context, chainDB *postgresqlchaindb.Service) error {
+	log.Trace().Msg("Checking for schema upgrades")
+	requiresRefetch, err := chainDB.Upgrade(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to upgrade chain database")
+	}
+	if requiresRefetch {
+		// The upgrade requires us to refetch blocks, so set up the options accordingly.
+		// These will be picked up by the blocks service.
+		viper.Set("blocks.start-slot", 0)
+		viper.Set("blocks.refetch", true)
+	}
+
+	return nil
+}
+
+func startServices(ctx context.Context, monitor metrics.Service, chainDB chaindb.Service) error {
+	if err := checkBlockDatasets(); err != nil {
+		return errors.Wrap(err, "invalid block dataset configuration")
+	}
+
+	log.Trace().Msg("Starting Ethereum 2 client service")
+	eth2Client, err := fetchEth2Client(ctx, monitor)
 	if err != nil {
 		return errors.Wrap(err, "failed to start Ethereum 2 client service")
 	}
@@ -277,9 +775,13 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 	log.Trace().Msg("Starting chain time service")
 	chainTime, err := standardchaintime.New(ctx,
 		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithLogLevelSampler(util.LogLevelSampler("chaintime")),
 		standardchaintime.WithGenesisTimeProvider(eth2Client.(eth2client.GenesisTimeProvider)),
 		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
 		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+		standardchaintime.WithSlotDuration(viper.GetDuration("chaintime.slot-duration")),
+		standardchaintime.WithSlotsPerEpoch(viper.GetUint64("chaintime.slots-per-epoch")),
+		standardchaintime.WithEpochsPerSyncCommitteePeriod(viper.GetUint64("chaintime.epochs-per-sync-committee-period")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to start chain time service")
@@ -330,51 +832,137 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 		}
 	}
 
+	// Checkpoint initialisation sets the start points of the services that follow.
+	log.Trace().Msg("Initialising from checkpoint")
+	if err := initFromCheckpoint(ctx, eth2Client, chainDB, chainTime); err != nil {
+		return errors.Wrap(err, "failed to initialise from checkpoint")
+	}
+
 	// Sync committees service is needed by blocks service.
 	log.Trace().Msg("Starting sync committees service")
 	if err := startSyncCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
 		return errors.Wrap(err, "failed to start sync committees service")
 	}
 
+	// Events service is needed by the services that generate events.
+	log.Trace().Msg("Starting events service")
+	eventsSvc, err := startEvents(ctx, chainDB, chainTime, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start events service")
+	}
+	blockHandlers := make([]handlers.BlockHandler, 0)
+	epochHandlers := make([]handlers.EpochHandler, 0)
+	if eventsSvc != nil {
+		blockHandlers = append(blockHandlers, eventsSvc.(handlers.BlockHandler))
+		epochHandlers = append(epochHandlers, eventsSvc.(handlers.EpochHandler))
+	}
+
+	// Validator set service is needed by the services that read validators.
+	log.Trace().Msg("Starting validator set service")
+	validatorSet, err := startValidatorSet(ctx, chainDB, chainTime, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start validator set service")
+	}
+	// Validator set must be the first validator handler, so that later handlers see updated validators.
+	validatorHandlers := []handlers.ValidatorHandler{validatorSet.(handlers.ValidatorHandler)}
+
+	// Publisher service is needed by the services that generate indexed data.
+	log.Trace().Msg("Starting publisher service")
+	publisherSvc, err := startPublisher(ctx, chainDB, validatorSet, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start publisher service")
+	}
+	if publisherSvc != nil {
+		blockHandlers = append(blockHandlers, publisherSvc.(handlers.BlockHandler))
+		validatorHandlers = append(validatorHandlers, publisherSvc.(handlers.ValidatorHandler))
+	}
+
+	log.Trace().Msg("Starting scheduler service")
+	schedulerSvc, err := startScheduler(ctx, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start scheduler service")
+	}
+
+	throttle, err := startThrottle(chainDB)
+	if err != nil {
+		return err
+	}
+
 	// Shared activity sempahore for blocks and finalizer, to avoid potential deadlock.
 	activitySem := semaphore.NewWeighted(1)
 
 	log.Trace().Msg("Starting blocks service")
-	blocks, err := startBlocks(ctx, eth2Client, chainDB, chainTime, monitor, activitySem)
+	blocks, err := startBlocks(ctx, eth2Client, chainDB, chainTime, monitor, schedulerSvc, throttle, activitySem, blockHandlers)
 	if err != nil {
 		return errors.Wrap(err, "failed to start blocks service")
 	}
 
+	log.Trace().Msg("Starting checksums service")
+	checksumsSvc, err := startChecksums(ctx, chainDB, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start checksums service")
+	}
+	if checksumsSvc != nil {
+		epochHandlers = append(epochHandlers, checksumsSvc.(handlers.EpochHandler))
+	}
+
 	var summarizerSvc summarizer.Service
 	if blocks != nil {
 		log.Trace().Msg("Starting summarizer service")
-		summarizerSvc, err = startSummarizer(ctx, eth2Client, chainDB, chainTime, monitor)
+		summarizerSvc, err = startSummarizer(ctx, eth2Client, chainDB, validatorSet, chainTime, monitor, schedulerSvc, epochHandlers)
 		if err != nil {
 			return errors.Wrap(err, "failed to start summarizer service")
 		}
 	}
 
+	log.Trace().Msg("Starting views service")
+	viewsSvc, err := startViews(ctx, chainDB, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start views service")
+	}
+
+	log.Trace().Msg("Starting state roots service")
+	stateRootsSvc, err := startStateRoots(ctx, eth2Client, chainDB, chainTime, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start state roots service")
+	}
+
 	log.Trace().Msg("Starting finalizer service")
 	finalityHandlers := make([]handlers.FinalityHandler, 0)
 	if summarizerSvc != nil {
 		finalityHandlers = append(finalityHandlers, summarizerSvc.(handlers.FinalityHandler))
 	}
+	if viewsSvc != nil {
+		finalityHandlers = append(finalityHandlers, viewsSvc.(handlers.FinalityHandler))
+	}
+	if eventsSvc != nil {
+		finalityHandlers = append(finalityHandlers, eventsSvc.(handlers.FinalityHandler))
+	}
+	if publisherSvc != nil {
+		finalityHandlers = append(finalityHandlers, publisherSvc.(handlers.FinalityHandler))
+	}
+	if stateRootsSvc != nil {
+		finalityHandlers = append(finalityHandlers, stateRootsSvc.(handlers.FinalityHandler))
+	}
 	if err := startFinalizer(ctx, eth2Client, chainDB, chainTime, blocks, monitor, finalityHandlers, activitySem); err != nil {
 		return errors.Wrap(err, "failed to start finalizer service")
 	}
 
 	log.Trace().Msg("Starting validators service")
-	if err := startValidators(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
+	validatorsSvc, err := startValidators(ctx, eth2Client, chainDB, validatorSet, chainTime, monitor, throttle, validatorHandlers)
+	if err != nil {
 		return errors.Wrap(err, "failed to start validators service")
 	}
 
 	log.Trace().Msg("Starting beacon committees service")
-	if err := startBeaconCommittees(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
+	beaconCommitteesSvc, err := startBeaconCommittees(ctx, eth2Client, chainDB, chainTime, monitor, schedulerSvc, throttle)
+	if err != nil {
 		return errors.Wrap(err, "failed to start beacon committees service")
 	}
 
 	log.Trace().Msg("Starting proposer duties service")
-	if err := startProposerDuties(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
+	proposerDutiesSvc, err := startProposerDuties(ctx, eth2Client, chainDB, chainTime, monitor, schedulerSvc, throttle)
+	if err != nil {
 		return errors.Wrap(err, "failed to start proposer duties service")
 	}
 
@@ -383,6 +971,97 @@ func startServices(ctx context.Context, monitor metrics.Service) error {
 		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
 	}
 
+	log.Trace().Msg("Starting chain statistics service")
+	if err := startChainStats(ctx, chainDB, chainTime, monitor); err != nil {
+		return errors.Wrap(err, "failed to start chain statistics service")
+	}
+
+	log.Trace().Msg("Starting database statistics service")
+	if err := startDBStats(ctx, chainDB, monitor); err != nil {
+		return errors.Wrap(err, "failed to start database statistics service")
+	}
+
+	if err := startProgress(ctx, chainDB, chainTime, monitor); err != nil {
+		return errors.Wrap(err, "failed to start progress service")
+	}
+
+	log.Trace().Msg("Starting notifier service")
+	if err := startNotifier(ctx, eth2Client, chainDB, chainTime, validatorSet, monitor); err != nil {
+		return errors.Wrap(err, "failed to start notifier service")
+	}
+
+	log.Trace().Msg("Starting reporter service")
+	if err := startReporter(ctx, chainDB, chainTime, monitor); err != nil {
+		return errors.Wrap(err, "failed to start reporter service")
+	}
+
+	log.Trace().Msg("Starting health service")
+	if err := startHealth(ctx, chainDB, chainTime); err != nil {
+		return errors.Wrap(err, "failed to start health service")
+	}
+
+	log.Trace().Msg("Starting admin service")
+	slotReindexers := make(map[string]admin.SlotReindexer)
+	if blocks != nil {
+		slotReindexers["blocks"] = blocks.(admin.SlotReindexer)
+	}
+	epochReindexers := make(map[string]admin.EpochReindexer)
+	if summarizerSvc != nil {
+		epochReindexers["summarizer"] = summarizerSvc.(admin.EpochReindexer)
+	}
+	if validatorsSvc != nil && viper.GetBool("validators.balances.enable") {
+		epochReindexers["validators.balances"] = validatorsSvc.(admin.EpochReindexer)
+	}
+	if beaconCommitteesSvc != nil {
+		epochReindexers["beaconcommittees"] = beaconCommitteesSvc.(admin.EpochReindexer)
+	}
+	if proposerDutiesSvc != nil {
+		epochReindexers["proposerduties"] = proposerDutiesSvc.(admin.EpochReindexer)
+	}
+	if err := startAdmin(ctx, schedulerSvc, slotReindexers, epochReindexers); err != nil {
+		return errors.Wrap(err, "failed to start admin service")
+	}
+
+	log.Trace().Msg("Starting gaps service")
+	if err := startGaps(ctx, chainDB, chainTime, monitor, schedulerSvc, epochReindexers); err != nil {
+		return errors.Wrap(err, "failed to start gaps service")
+	}
+
+	log.Trace().Msg("Starting deposit reconciler service")
+	if err := startDepositReconciler(ctx, chainDB, chainTime, monitor); err != nil {
+		return errors.Wrap(err, "failed to start deposit reconciler service")
+	}
+
+	log.Trace().Msg("Starting execution enricher service")
+	if err := startExecutionEnricher(ctx, chainDB, monitor); err != nil {
+		return errors.Wrap(err, "failed to start execution enricher service")
+	}
+
+	log.Trace().Msg("Starting audit service")
+	if err := startAudit(ctx, eth2Client, chainDB, chainTime, monitor); err != nil {
+		return errors.Wrap(err, "failed to start audit service")
+	}
+
+	log.Trace().Msg("Starting API service")
+	if err := startAPI(ctx, chainDB, validatorSet, monitor); err != nil {
+		return errors.Wrap(err, "failed to start API service")
+	}
+
+	log.Trace().Msg("Starting GraphQL service")
+	if err := startGraphQL(ctx, chainDB, validatorSet, monitor); err != nil {
+		return errors.Wrap(err, "failed to start GraphQL service")
+	}
+
+	log.Trace().Msg("Starting gRPC service")
+	if err := startGRPC(ctx, chainDB, validatorSet, monitor); err != nil {
+		return errors.Wrap(err, "failed to start gRPC service")
+	}
+
+	log.Trace().Msg("Starting Beacon API service")
+	if err := startBeaconAPI(ctx, chainDB, validatorSet, chainTime, monitor); err != nil {
+		return errors.Wrap(err, "failed to start Beacon API service")
+	}
+
 	return nil
 }
 
@@ -402,6 +1081,60 @@ func logModules() {
 	}
 }
 
+// sharedRetryPolicy is the policy for retrying failed beacon node fetches.
+// It is shared by all modules so that it can be updated when the configuration
+// is reloaded.
+var sharedRetryPolicy = &util.RetryPolicy{}
+
+// retryPolicy returns the policy for retrying failed beacon node fetches,
+// updated from the current configuration.
+func retryPolicy() *util.RetryPolicy {
+	sharedRetryPolicy.Update(
+		viper.GetInt("retry.retries"),
+		viper.GetDuration("retry.base-delay"),
+		viper.GetDuration("retry.max-delay"),
+	)
+	return sharedRetryPolicy
+}
+
+// authenticator returns the authenticator for requests to the API servers,
+// or nil if no credentials are configured.
+func authenticator() *util.Authenticator {
+	readKeys := viper.GetStringSlice("auth.read-keys")
+	adminKeys := viper.GetStringSlice("auth.admin-keys")
+	jwtSecret := viper.GetString("auth.jwt-secret")
+	if len(readKeys) == 0 && len(adminKeys) == 0 && jwtSecret == "" {
+		return nil
+	}
+
+	return util.NewAuthenticator(readKeys, adminKeys, jwtSecret)
+}
+
+// startThrottle creates the throttle that holds back catchup and backfill while
+// the database is under load, or nil if no limits are configured.
+func startThrottle(chainDB chaindb.Service) (*util.Throttle, error) {
+	maxCommitLatency := viper.GetDuration("backfill.throttle.max-commit-latency")
+	maxConnectionUsage := viper.GetFloat64("backfill.throttle.max-connection-usage")
+	maxReplicationLag := viper.GetDuration("backfill.throttle.max-replication-lag")
+	if maxCommitLatency == 0 && maxConnectionUsage == 0 && maxReplicationLag == 0 {
+		return nil, nil
+	}
+	if maxConnectionUsage < 0 || maxConnectionUsage > 1 {
+		return nil, errors.New("backfill.throttle.max-connection-usage must be between 0 and 1")
+	}
+	provider, isProvider := chainDB.(chaindb.DatabaseLoadProvider)
+	if !isProvider {
+		return nil, errors.New("chain database does not provide its load")
+	}
+
+	return util.NewThrottle(log.With().Str("service", "throttle").Logger(),
+		provider,
+		maxCommitLatency,
+		maxConnectionUsage,
+		maxReplicationLag,
+	), nil
+}
+
 // resolvePath resolves a potentially relative path to an absolute path.
 func resolvePath(path string) string {
 	if filepath.IsAbs(path) {
@@ -433,6 +1166,7 @@ func startSpec(
 
 	_, err = standardspec.New(ctx,
 		standardspec.WithLogLevel(util.LogLevel("spec")),
+		standardspec.WithLogLevelSampler(util.LogLevelSampler("spec")),
 		standardspec.WithETH2Client(eth2Client),
 		standardspec.WithChainDB(chainDB),
 	)
@@ -443,13 +1177,46 @@ func startSpec(
 	return nil
 }
 
+func startScheduler(
+	ctx context.Context,
+	monitor metrics.Service,
+) (
+	scheduler.Service,
+	error,
+) {
+	maintenanceWindows := make([]*standardscheduler.MaintenanceWindow, 0)
+	if err := viper.UnmarshalKey("scheduler.maintenance-windows", &maintenanceWindows); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain scheduler maintenance windows")
+	}
+
+	s, err := standardscheduler.New(ctx,
+		standardscheduler.WithLogLevel(util.LogLevel("scheduler")),
+		standardscheduler.WithLogLevelSampler(util.LogLevelSampler("scheduler")),
+		standardscheduler.WithMonitor(monitor),
+		standardscheduler.WithConcurrency(viper.GetInt("scheduler.concurrency")),
+		standardscheduler.WithPriorityConcurrency(map[scheduler.Priority]int{
+			scheduler.PriorityRepair: viper.GetInt("scheduler.repair-concurrency"),
+		}),
+		standardscheduler.WithMaxQueued(viper.GetInt("scheduler.max-queued")),
+		standardscheduler.WithMaintenanceWindows(maintenanceWindows),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create scheduler service")
+	}
+
+	return s, nil
+}
+
 func startBlocks(
 	ctx context.Context,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
+	scheduler scheduler.Service,
+	throttle *util.Throttle,
 	activitySem *semaphore.Weighted,
+	blockHandlers []handlers.BlockHandler,
 ) (
 	blocks.Service,
 	error,
@@ -468,13 +1235,27 @@ func startBlocks(
 
 	s, err := standardblocks.New(ctx,
 		standardblocks.WithLogLevel(util.LogLevel("blocks")),
+		standardblocks.WithLogLevelSampler(util.LogLevelSampler("blocks")),
+		standardblocks.WithEventsStallTimeout(viper.GetDuration("eth2client.events.stall-timeout")),
 		standardblocks.WithMonitor(monitor),
 		standardblocks.WithETH2Client(eth2Client),
 		standardblocks.WithChainTime(chainTime),
 		standardblocks.WithChainDB(chainDB),
 		standardblocks.WithStartSlot(viper.GetInt64("blocks.start-slot")),
 		standardblocks.WithRefetch(viper.GetBool("blocks.refetch")),
+		standardblocks.WithBackfill(viper.GetBool("backfill.enable")),
+		standardblocks.WithHeadFirstDistance(phase0.Epoch(viper.GetUint64("backfill.head-first-distance"))),
+		standardblocks.WithThrottle(throttle),
+		standardblocks.WithScheduler(scheduler),
 		standardblocks.WithActivitySem(activitySem),
+		standardblocks.WithBlockHandlers(blockHandlers),
+		standardblocks.WithAttestations(viper.GetBool("blocks.attestations.enable")),
+		standardblocks.WithProposerSlashings(viper.GetBool("blocks.proposer-slashings.enable")),
+		standardblocks.WithAttesterSlashings(viper.GetBool("blocks.attester-slashings.enable")),
+		standardblocks.WithDeposits(viper.GetBool("blocks.deposits.enable")),
+		standardblocks.WithVoluntaryExits(viper.GetBool("blocks.voluntary-exits.enable")),
+		standardblocks.WithSyncAggregates(viper.GetBool("blocks.sync-aggregates.enable")),
+		standardblocks.WithStrict(viper.GetBool("strict")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create blocks service")
@@ -507,6 +1288,8 @@ func startFinalizer(
 
 	_, err = standardfinalizer.New(ctx,
 		standardfinalizer.WithLogLevel(util.LogLevel("finalizer")),
+		standardfinalizer.WithLogLevelSampler(util.LogLevelSampler("finalizer")),
+		standardfinalizer.WithEventsStallTimeout(viper.GetDuration("eth2client.events.stall-timeout")),
 		standardfinalizer.WithMonitor(monitor),
 		standardfinalizer.WithETH2Client(eth2Client),
 		standardfinalizer.WithChainTime(chainTime),
@@ -514,6 +1297,9 @@ func startFinalizer(
 		standardfinalizer.WithBlocks(blocks),
 		standardfinalizer.WithFinalityHandlers(finalityHandlers),
 		standardfinalizer.WithActivitySem(activitySem),
+		standardfinalizer.WithStartSlot(viper.GetInt64("finalizer.start-slot")),
+		standardfinalizer.WithStrict(viper.GetBool("strict")),
+		standardfinalizer.WithWorkers(viper.GetInt("finalizer.workers")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to create finalizer service")
@@ -526,8 +1312,11 @@ func startSummarizer(
 	ctx context.Context,
 	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
+	validatorSet validatorset.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
+	scheduler scheduler.Service,
+	epochHandlers []handlers.EpochHandler,
 ) (
 	summarizer.Service,
 	error,
@@ -538,13 +1327,19 @@ func startSummarizer(
 
 	standardSummarizer, err := standardsummarizer.New(ctx,
 		standardsummarizer.WithLogLevel(util.LogLevel("summarizer")),
+		standardsummarizer.WithLogLevelSampler(util.LogLevelSampler("summarizer")),
 		standardsummarizer.WithMonitor(monitor),
 		standardsummarizer.WithETH2Client(eth2Client),
 		standardsummarizer.WithChainTime(chainTime),
+		standardsummarizer.WithScheduler(scheduler),
 		standardsummarizer.WithChainDB(chainDB),
+		standardsummarizer.WithValidatorSet(validatorSet),
 		standardsummarizer.WithEpochSummaries(viper.GetBool("summarizer.epochs.enable")),
 		standardsummarizer.WithBlockSummaries(viper.GetBool("summarizer.blocks.enable")),
 		standardsummarizer.WithValidatorSummaries(viper.GetBool("summarizer.validators.enable")),
+		standardsummarizer.WithEpochHandlers(epochHandlers),
+		standardsummarizer.WithStartEpoch(viper.GetInt64("summarizer.start-epoch")),
+		standardsummarizer.WithBatchSize(viper.GetInt("chaindb.batch-size")),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create summarizer service")
@@ -553,71 +1348,746 @@ func startSummarizer(
 	return standardSummarizer, nil
 }
 
-func startValidators(
+func startAPI(
 	ctx context.Context,
-	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
-	chainTime chaintime.Service,
+	validatorSet validatorset.Service,
 	monitor metrics.Service,
 ) error {
-	if !viper.GetBool("validators.enable") {
+	if !viper.GetBool("api.enable") {
 		return nil
 	}
 
-	var err error
-	if viper.GetString("validators.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("validators.address"))
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("validators.address")))
-		}
-	}
-
-	_, err = standardvalidators.New(ctx,
-		standardvalidators.WithLogLevel(util.LogLevel("validators")),
-		standardvalidators.WithMonitor(monitor),
-		standardvalidators.WithETH2Client(eth2Client),
-		standardvalidators.WithChainTime(chainTime),
-		standardvalidators.WithChainDB(chainDB),
-		standardvalidators.WithBalances(viper.GetBool("validators.balances.enable")),
+	_, err := standardapi.New(ctx,
+		standardapi.WithLogLevel(util.LogLevel("api")),
+		standardapi.WithLogLevelSampler(util.LogLevelSampler("api")),
+		standardapi.WithMonitor(monitor),
+		standardapi.WithChainDB(chainDB),
+		standardapi.WithValidatorSet(validatorSet),
+		standardapi.WithListenAddress(viper.GetString("api.listen-address")),
+		standardapi.WithAuthenticator(authenticator()),
+		standardapi.WithMaxSlotRange(viper.GetUint64("api.max-slot-range")),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create validators service")
+		return errors.Wrap(err, "failed to create API service")
 	}
 
 	return nil
 }
 
-func startBeaconCommittees(
+func startGraphQL(
 	ctx context.Context,
-	eth2Client eth2client.Service,
 	chainDB chaindb.Service,
-	chainTime chaintime.Service,
+	validatorSet validatorset.Service,
 	monitor metrics.Service,
 ) error {
-	if !viper.GetBool("beacon-committees.enable") {
+	if !viper.GetBool("graphql.enable") {
 		return nil
 	}
 
-	var err error
-	if viper.GetString("beacon-committees.address") != "" {
-		eth2Client, err = fetchClient(ctx, viper.GetString("beacon-committees.address"))
-		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("beacon-committees.address")))
-		}
-	}
+	_, err := graphqlapi.New(ctx,
+		graphqlapi.WithLogLevel(util.LogLevel("graphql")),
+		graphqlapi.WithLogLevelSampler(util.LogLevelSampler("graphql")),
+		graphqlapi.WithMonitor(monitor),
+		graphqlapi.WithChainDB(chainDB),
+		graphqlapi.WithValidatorSet(validatorSet),
+		graphqlapi.WithListenAddress(viper.GetString("graphql.listen-address")),
+		graphqlapi.WithAuthenticator(authenticator()),
+		graphqlapi.WithMaxSlotRange(viper.GetUint64("graphql.max-slot-range")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create GraphQL service")
+	}
+
+	return nil
+}
+
+func startGRPC(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	validatorSet validatorset.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("grpc.enable") {
+		return nil
+	}
+
+	_, err := grpcapi.New(ctx,
+		grpcapi.WithLogLevel(util.LogLevel("grpc")),
+		grpcapi.WithLogLevelSampler(util.LogLevelSampler("grpc")),
+		grpcapi.WithMonitor(monitor),
+		grpcapi.WithChainDB(chainDB),
+		grpcapi.WithValidatorSet(validatorSet),
+		grpcapi.WithListenAddress(viper.GetString("grpc.listen-address")),
+		grpcapi.WithBatchSize(viper.GetUint64("grpc.batch-size")),
+		grpcapi.WithAuthenticator(authenticator()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gRPC service")
+	}
+
+	return nil
+}
+
+func startBeaconAPI(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	validatorSet validatorset.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("beacon-api.enable") {
+		return nil
+	}
+
+	_, err := beaconapi.New(ctx,
+		beaconapi.WithLogLevel(util.LogLevel("beacon-api")),
+		beaconapi.WithLogLevelSampler(util.LogLevelSampler("beacon-api")),
+		beaconapi.WithMonitor(monitor),
+		beaconapi.WithChainDB(chainDB),
+		beaconapi.WithValidatorSet(validatorSet),
+		beaconapi.WithChainTime(chainTime),
+		beaconapi.WithListenAddress(viper.GetString("beacon-api.listen-address")),
+		beaconapi.WithAuthenticator(authenticator()),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create Beacon API service")
+	}
+
+	return nil
+}
+
+func startEvents(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) (
+	events.Service,
+	error,
+) {
+	if !viper.GetBool("events.enable") {
+		return nil, nil
+	}
+
+	standardEvents, err := standardevents.New(ctx,
+		standardevents.WithLogLevel(util.LogLevel("events")),
+		standardevents.WithLogLevelSampler(util.LogLevelSampler("events")),
+		standardevents.WithMonitor(monitor),
+		standardevents.WithChainDB(chainDB),
+		standardevents.WithChainTime(chainTime),
+		standardevents.WithListenAddress(viper.GetString("events.listen-address")),
+		standardevents.WithAuthenticator(authenticator()),
+		standardevents.WithBufferSize(viper.GetInt("events.buffer-size")),
+		standardevents.WithProgressInterval(viper.GetDuration("events.progress-interval")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create events service")
+	}
+
+	return standardEvents, nil
+}
+
+func startPublisher(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	validatorSet validatorset.Service,
+	monitor metrics.Service,
+) (
+	publisher.Service,
+	error,
+) {
+	if !viper.GetBool("publisher.enable") {
+		return nil, nil
+	}
+
+	standardPublisher, err := standardpublisher.New(ctx,
+		standardpublisher.WithLogLevel(util.LogLevel("publisher")),
+		standardpublisher.WithLogLevelSampler(util.LogLevelSampler("publisher")),
+		standardpublisher.WithMonitor(monitor),
+		standardpublisher.WithChainDB(chainDB),
+		standardpublisher.WithValidatorSet(validatorSet),
+		standardpublisher.WithBackend(viper.GetString("publisher.backend")),
+		standardpublisher.WithAddresses(viper.GetStringSlice("publisher.addresses")),
+		standardpublisher.WithTopicPrefix(viper.GetString("publisher.topic-prefix")),
+		standardpublisher.WithEncoding(viper.GetString("publisher.encoding")),
+		standardpublisher.WithBufferSize(viper.GetInt("publisher.buffer-size")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create publisher service")
+	}
+
+	return standardPublisher, nil
+}
+
+func startChainStats(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("chainstats.enable") {
+		return nil
+	}
+
+	_, err := standardchainstats.New(ctx,
+		standardchainstats.WithLogLevel(util.LogLevel("chainstats")),
+		standardchainstats.WithLogLevelSampler(util.LogLevelSampler("chainstats")),
+		standardchainstats.WithMonitor(monitor),
+		standardchainstats.WithChainDB(chainDB),
+		standardchainstats.WithChainTime(chainTime),
+		standardchainstats.WithInterval(viper.GetDuration("chainstats.interval")),
+		standardchainstats.WithEpochs(viper.GetUint64("chainstats.epochs")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create chain statistics service")
+	}
+
+	return nil
+}
+
+func startDBStats(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("dbstats.enable") {
+		return nil
+	}
+
+	_, err := standarddbstats.New(ctx,
+		standarddbstats.WithLogLevel(util.LogLevel("dbstats")),
+		standarddbstats.WithLogLevelSampler(util.LogLevelSampler("dbstats")),
+		standarddbstats.WithMonitor(monitor),
+		standarddbstats.WithChainDB(chainDB),
+		standarddbstats.WithInterval(viper.GetDuration("dbstats.interval")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create database statistics service")
+	}
+
+	return nil
+}
+
+func startProgress(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("progress.enable") {
+		return nil
+	}
+
+	lagThresholds := make([]*standardprogress.LagThreshold, 0)
+	if err := viper.UnmarshalKey("progress.lag-thresholds", &lagThresholds); err != nil {
+		return errors.Wrap(err, "failed to obtain progress lag thresholds")
+	}
+
+	_, err := standardprogress.New(ctx,
+		standardprogress.WithLogLevel(util.LogLevel("progress")),
+		standardprogress.WithLogLevelSampler(util.LogLevelSampler("progress")),
+		standardprogress.WithMonitor(monitor),
+		standardprogress.WithChainDB(chainDB),
+		standardprogress.WithChainTime(chainTime),
+		standardprogress.WithInterval(viper.GetDuration("progress.interval")),
+		standardprogress.WithLagThresholds(lagThresholds),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create progress service")
+	}
+
+	return nil
+}
+
+func startGaps(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+	scheduler scheduler.Service,
+	reindexers map[string]admin.EpochReindexer,
+) error {
+	if !viper.GetBool("gaps.enable") {
+		return nil
+	}
+
+	_, err := standardgaps.New(ctx,
+		standardgaps.WithLogLevel(util.LogLevel("gaps")),
+		standardgaps.WithLogLevelSampler(util.LogLevelSampler("gaps")),
+		standardgaps.WithMonitor(monitor),
+		standardgaps.WithChainDB(chainDB),
+		standardgaps.WithChainTime(chainTime),
+		standardgaps.WithInterval(viper.GetDuration("gaps.interval")),
+		standardgaps.WithMaxRepairs(viper.GetUint64("gaps.max-repairs")),
+		standardgaps.WithReindexers(reindexers),
+		standardgaps.WithScheduler(scheduler),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create gaps service")
+	}
+
+	return nil
+}
+
+func startDepositReconciler(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("depositreconciler.enable") {
+		return nil
+	}
+
+	_, err := standarddepositreconciler.New(ctx,
+		standarddepositreconciler.WithLogLevel(util.LogLevel("depositreconciler")),
+		standarddepositreconciler.WithLogLevelSampler(util.LogLevelSampler("depositreconciler")),
+		standarddepositreconciler.WithMonitor(monitor),
+		standarddepositreconciler.WithChainDB(chainDB),
+		standarddepositreconciler.WithChainTime(chainTime),
+		standarddepositreconciler.WithInterval(viper.GetDuration("depositreconciler.interval")),
+		standarddepositreconciler.WithWindow(viper.GetDuration("depositreconciler.window")),
+		standarddepositreconciler.WithStrict(viper.GetBool("strict")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create deposit reconciler service")
+	}
+
+	return nil
+}
+
+func startExecutionEnricher(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("executionenricher.enable") {
+		return nil
+	}
+
+	_, err := standardexecutionenricher.New(ctx,
+		standardexecutionenricher.WithLogLevel(util.LogLevel("executionenricher")),
+		standardexecutionenricher.WithLogLevelSampler(util.LogLevelSampler("executionenricher")),
+		standardexecutionenricher.WithMonitor(monitor),
+		standardexecutionenricher.WithChainDB(chainDB),
+		standardexecutionenricher.WithConnectionURL(viper.GetString("eth1client.address")),
+		standardexecutionenricher.WithTimeout(viper.GetDuration("executionenricher.timeout")),
+		standardexecutionenricher.WithInterval(viper.GetDuration("executionenricher.interval")),
+		standardexecutionenricher.WithStartSlot(phase0.Slot(viper.GetUint64("executionenricher.start-slot"))),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create execution enricher service")
+	}
+
+	return nil
+}
+
+func startAudit(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("audit.enable") {
+		return nil
+	}
+
+	_, err := standardaudit.New(ctx,
+		standardaudit.WithLogLevel(util.LogLevel("audit")),
+		standardaudit.WithLogLevelSampler(util.LogLevelSampler("audit")),
+		standardaudit.WithMonitor(monitor),
+		standardaudit.WithETH2Client(eth2Client),
+		standardaudit.WithChainDB(chainDB),
+		standardaudit.WithChainTime(chainTime),
+		standardaudit.WithInterval(viper.GetDuration("audit.interval")),
+		standardaudit.WithEpochs(viper.GetUint64("audit.epochs")),
+		standardaudit.WithValidators(viper.GetUint64("audit.validators")),
+		standardaudit.WithRecomputeCommittees(viper.GetBool("audit.recompute-committees")),
+		standardaudit.WithStrict(viper.GetBool("strict")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create audit service")
+	}
+
+	return nil
+}
+
+func startNotifier(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	validatorSet validatorset.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("notifier.enable") {
+		return nil
+	}
+
+	validators := make([]phase0.ValidatorIndex, 0)
+	if err := viper.UnmarshalKey("notifier.validators", &validators); err != nil {
+		return errors.Wrap(err, "failed to obtain notifier validators")
+	}
+	webhooks := make([]*standardnotifier.Webhook, 0)
+	if err := viper.UnmarshalKey("notifier.webhooks", &webhooks); err != nil {
+		return errors.Wrap(err, "failed to obtain notifier webhooks")
+	}
+
+	_, err := standardnotifier.New(ctx,
+		standardnotifier.WithLogLevel(util.LogLevel("notifier")),
+		standardnotifier.WithLogLevelSampler(util.LogLevelSampler("notifier")),
+		standardnotifier.WithMonitor(monitor),
+		standardnotifier.WithChainDB(chainDB),
+		standardnotifier.WithValidatorSet(validatorSet),
+		standardnotifier.WithValidators(validators),
+		standardnotifier.WithWebhooks(webhooks),
+		standardnotifier.WithOfflineEpochs(viper.GetUint64("notifier.offline-epochs")),
+		standardnotifier.WithInterval(viper.GetDuration("notifier.interval")),
+		standardnotifier.WithTimeout(viper.GetDuration("notifier.timeout")),
+		standardnotifier.WithETH2Client(eth2Client),
+		standardnotifier.WithChainTime(chainTime),
+		standardnotifier.WithMinEffectiveness(viper.GetUint64("notifier.min-effectiveness")),
+		standardnotifier.WithFinalityDelayEpochs(viper.GetUint64("notifier.finality-delay-epochs")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create notifier service")
+	}
+
+	return nil
+}
+
+func startReporter(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) error {
+	if !viper.GetBool("reporter.enable") {
+		return nil
+	}
+
+	validators := make([]phase0.ValidatorIndex, 0)
+	if err := viper.UnmarshalKey("reporter.validators", &validators); err != nil {
+		return errors.Wrap(err, "failed to obtain reporter validators")
+	}
+
+	_, err := standardreporter.New(ctx,
+		standardreporter.WithLogLevel(util.LogLevel("reporter")),
+		standardreporter.WithLogLevelSampler(util.LogLevelSampler("reporter")),
+		standardreporter.WithMonitor(monitor),
+		standardreporter.WithChainDB(chainDB),
+		standardreporter.WithChainTime(chainTime),
+		standardreporter.WithPeriod(viper.GetString("reporter.period")),
+		standardreporter.WithFormats(viper.GetStringSlice("reporter.formats")),
+		standardreporter.WithDirectory(viper.GetString("reporter.directory")),
+		standardreporter.WithWebhook(viper.GetString("reporter.webhook")),
+		standardreporter.WithValidators(validators),
+		standardreporter.WithInterval(viper.GetDuration("reporter.interval")),
+		standardreporter.WithTimeout(viper.GetDuration("reporter.timeout")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create reporter service")
+	}
+
+	return nil
+}
+
+func startHealth(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) error {
+	if !viper.GetBool("health.enable") {
+		return nil
+	}
+
+	// Only check services that are running.
+	services := make([]string, 0)
+	for _, service := range []struct {
+		flag string
+		name string
+	}{
+		{flag: "blocks.enable", name: "blocks"},
+		{flag: "finalizer.enable", name: "finalizer"},
+		{flag: "summarizer.enable", name: "summarizer"},
+		{flag: "validators.enable", name: "validators"},
+		{flag: "validators.balances.enable", name: "validators.balances"},
+		{flag: "beacon-committees.enable", name: "beaconcommittees"},
+		{flag: "proposer-duties.enable", name: "proposerduties"},
+		{flag: "views.enable", name: "views"},
+	} {
+		if viper.GetBool(service.flag) {
+			services = append(services, service.name)
+		}
+	}
+
+	_, err := standardhealth.New(ctx,
+		standardhealth.WithLogLevel(util.LogLevel("health")),
+		standardhealth.WithLogLevelSampler(util.LogLevelSampler("health")),
+		standardhealth.WithChainDB(chainDB),
+		standardhealth.WithChainTime(chainTime),
+		standardhealth.WithListenAddress(viper.GetString("health.listen-address")),
+		standardhealth.WithAuthenticator(authenticator()),
+		standardhealth.WithServices(services),
+		standardhealth.WithMaxLag(viper.GetUint64("health.max-lag")),
+		standardhealth.WithStallTimeout(viper.GetDuration("health.stall-timeout")),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create health service")
+	}
+
+	return nil
+}
+
+func startAdmin(
+	ctx context.Context,
+	scheduler scheduler.Service,
+	slotReindexers map[string]admin.SlotReindexer,
+	epochReindexers map[string]admin.EpochReindexer,
+) error {
+	if !viper.GetBool("admin.enable") {
+		return nil
+	}
+
+	_, err := standardadmin.New(ctx,
+		standardadmin.WithLogLevel(util.LogLevel("admin")),
+		standardadmin.WithLogLevelSampler(util.LogLevelSampler("admin")),
+		standardadmin.WithListenAddress(viper.GetString("admin.listen-address")),
+		standardadmin.WithAuthenticator(authenticator()),
+		standardadmin.WithToken(viper.GetString("admin.token")),
+		standardadmin.WithSlotReindexers(slotReindexers),
+		standardadmin.WithEpochReindexers(epochReindexers),
+		standardadmin.WithScheduler(scheduler),
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to create admin service")
+	}
+
+	return nil
+}
+
+func startViews(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) (
+	views.Service,
+	error,
+) {
+	if !viper.GetBool("views.enable") {
+		return nil, nil
+	}
+
+	definitions := make([]*standardviews.View, 0)
+	if err := viper.UnmarshalKey("views.definitions", &definitions); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain view definitions")
+	}
+
+	standardViews, err := standardviews.New(ctx,
+		standardviews.WithLogLevel(util.LogLevel("views")),
+		standardviews.WithLogLevelSampler(util.LogLevelSampler("views")),
+		standardviews.WithMonitor(monitor),
+		standardviews.WithChainDB(chainDB),
+		standardviews.WithViews(definitions),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create views service")
+	}
+
+	return standardViews, nil
+}
+
+func startStateRoots(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) (
+	stateroots.Service,
+	error,
+) {
+	if !viper.GetBool("state-roots.enable") {
+		return nil, nil
+	}
+
+	standardStateRoots, err := standardstateroots.New(ctx,
+		standardstateroots.WithLogLevel(util.LogLevel("state-roots")),
+		standardstateroots.WithLogLevelSampler(util.LogLevelSampler("state-roots")),
+		standardstateroots.WithMonitor(monitor),
+		standardstateroots.WithETH2Client(eth2Client),
+		standardstateroots.WithChainDB(chainDB),
+		standardstateroots.WithChainTime(chainTime),
+		standardstateroots.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+		standardstateroots.WithArchiveLocation(viper.GetString("state-roots.archive-location")),
+		standardstateroots.WithArchiveInterval(viper.GetUint64("state-roots.archive-interval")),
+		standardstateroots.WithStartSlot(viper.GetInt64("state-roots.start-slot")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create state roots service")
+	}
+
+	return standardStateRoots, nil
+}
+
+func startChecksums(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	monitor metrics.Service,
+) (
+	checksums.Service,
+	error,
+) {
+	if !viper.GetBool("checksums.enable") {
+		return nil, nil
+	}
+
+	standardChecksums, err := standardchecksums.New(ctx,
+		standardchecksums.WithLogLevel(util.LogLevel("checksums")),
+		standardchecksums.WithLogLevelSampler(util.LogLevelSampler("checksums")),
+		standardchecksums.WithMonitor(monitor),
+		standardchecksums.WithChainDB(chainDB),
+		standardchecksums.WithDatasets(viper.GetStringSlice("checksums.datasets")),
+		standardchecksums.WithStartEpoch(viper.GetInt64("checksums.start-epoch")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create checksums service")
+	}
 
-	_, err = standardbeaconcommittees.New(ctx,
+	return standardChecksums, nil
+}
+
+func startValidatorSet(
+	ctx context.Context,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+) (
+	validatorset.Service,
+	error,
+) {
+	standardValidatorSet, err := standardvalidatorset.New(ctx,
+		standardvalidatorset.WithLogLevel(util.LogLevel("validatorset")),
+		standardvalidatorset.WithLogLevelSampler(util.LogLevelSampler("validatorset")),
+		standardvalidatorset.WithMonitor(monitor),
+		standardvalidatorset.WithChainDB(chainDB),
+		standardvalidatorset.WithChainTime(chainTime),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create validator set service")
+	}
+
+	return standardValidatorSet, nil
+}
+
+func startValidators(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	validatorSet validatorset.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+	throttle *util.Throttle,
+	validatorHandlers []handlers.ValidatorHandler,
+) (
+	validators.Service,
+	error,
+) {
+	if !viper.GetBool("validators.enable") {
+		return nil, nil
+	}
+
+	var err error
+	var catchupClients []eth2client.Service
+	if viper.GetString("validators.address") != "" {
+		eth2Client, err = fetchClient(ctx, viper.GetString("validators.address"))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("validators.address")))
+		}
+	} else {
+		catchupClients = fetchCatchupClients(ctx)
+	}
+
+	standardValidators, err := standardvalidators.New(ctx,
+		standardvalidators.WithLogLevel(util.LogLevel("validators")),
+		standardvalidators.WithLogLevelSampler(util.LogLevelSampler("validators")),
+		standardvalidators.WithEventsStallTimeout(viper.GetDuration("eth2client.events.stall-timeout")),
+		standardvalidators.WithMonitor(monitor),
+		standardvalidators.WithETH2Client(eth2Client),
+		standardvalidators.WithCatchupClients(catchupClients),
+		standardvalidators.WithRetryPolicy(retryPolicy()),
+		standardvalidators.WithChainTime(chainTime),
+		standardvalidators.WithChainDB(chainDB),
+		standardvalidators.WithValidatorSet(validatorSet),
+		standardvalidators.WithBalances(viper.GetBool("validators.balances.enable")),
+		standardvalidators.WithStartEpoch(viper.GetInt64("validators.start-epoch")),
+		standardvalidators.WithBackfill(viper.GetBool("backfill.enable")),
+		standardvalidators.WithHeadFirstDistance(phase0.Epoch(viper.GetUint64("backfill.head-first-distance"))),
+		standardvalidators.WithThrottle(throttle),
+		standardvalidators.WithValidatorHandlers(validatorHandlers),
+		standardvalidators.WithBatchSize(viper.GetInt("chaindb.batch-size")),
+		standardvalidators.WithFullUpdateInterval(phase0.Epoch(viper.GetUint64("validators.full-update-interval"))),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create validators service")
+	}
+
+	return standardValidators, nil
+}
+
+func startBeaconCommittees(
+	ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+	monitor metrics.Service,
+	scheduler scheduler.Service,
+	throttle *util.Throttle,
+) (
+	beaconcommittees.Service,
+	error,
+) {
+	if !viper.GetBool("beacon-committees.enable") {
+		return nil, nil
+	}
+
+	var err error
+	var catchupClients []eth2client.Service
+	if viper.GetString("beacon-committees.address") != "" {
+		eth2Client, err = fetchClient(ctx, viper.GetString("beacon-committees.address"))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("beacon-committees.address")))
+		}
+	} else {
+		catchupClients = fetchCatchupClients(ctx)
+	}
+
+	standardBeaconCommittees, err := standardbeaconcommittees.New(ctx,
 		standardbeaconcommittees.WithLogLevel(util.LogLevel("beacon-committees")),
+		standardbeaconcommittees.WithLogLevelSampler(util.LogLevelSampler("beacon-committees")),
+		standardbeaconcommittees.WithEventsStallTimeout(viper.GetDuration("eth2client.events.stall-timeout")),
 		standardbeaconcommittees.WithMonitor(monitor),
 		standardbeaconcommittees.WithETH2Client(eth2Client),
+		standardbeaconcommittees.WithCatchupClients(catchupClients),
+		standardbeaconcommittees.WithRetryPolicy(retryPolicy()),
 		standardbeaconcommittees.WithChainTime(chainTime),
 		standardbeaconcommittees.WithChainDB(chainDB),
+		standardbeaconcommittees.WithScheduler(scheduler),
+		standardbeaconcommittees.WithStartEpoch(viper.GetInt64("beacon-committees.start-epoch")),
+		standardbeaconcommittees.WithCatchupWorkers(viper.GetInt("beacon-committees.catchup-workers")),
+		standardbeaconcommittees.WithCatchupEpochsPerTx(viper.GetInt("beacon-committees.catchup-epochs-per-tx")),
+		standardbeaconcommittees.WithConcurrency(viper.GetInt64("beacon-committees.concurrency")),
+		standardbeaconcommittees.WithBackfill(viper.GetBool("backfill.enable")),
+		standardbeaconcommittees.WithHeadFirstDistance(phase0.Epoch(viper.GetUint64("backfill.head-first-distance"))),
+		standardbeaconcommittees.WithThrottle(throttle),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create beacon committees service")
+		return nil, errors.Wrap(err, "failed to create beacon committees service")
 	}
 
-	return nil
+	return standardBeaconCommittees, nil
 }
 
 func startProposerDuties(
@@ -626,31 +2096,51 @@ func startProposerDuties(
 	chainDB chaindb.Service,
 	chainTime chaintime.Service,
 	monitor metrics.Service,
-) error {
+	scheduler scheduler.Service,
+	throttle *util.Throttle,
+) (
+	proposerduties.Service,
+	error,
+) {
 	if !viper.GetBool("proposer-duties.enable") {
-		return nil
+		return nil, nil
 	}
 
 	var err error
+	var catchupClients []eth2client.Service
 	if viper.GetString("proposer-duties.address") != "" {
 		eth2Client, err = fetchClient(ctx, viper.GetString("proposer-duties.address"))
 		if err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("proposer-duties.address")))
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to fetch client %q", viper.GetString("proposer-duties.address")))
 		}
+	} else {
+		catchupClients = fetchCatchupClients(ctx)
 	}
 
-	_, err = standardproposerduties.New(ctx,
+	standardProposerDuties, err := standardproposerduties.New(ctx,
 		standardproposerduties.WithLogLevel(util.LogLevel("proposer-duties")),
+		standardproposerduties.WithLogLevelSampler(util.LogLevelSampler("proposer-duties")),
+		standardproposerduties.WithEventsStallTimeout(viper.GetDuration("eth2client.events.stall-timeout")),
 		standardproposerduties.WithMonitor(monitor),
 		standardproposerduties.WithETH2Client(eth2Client),
+		standardproposerduties.WithCatchupClients(catchupClients),
+		standardproposerduties.WithRetryPolicy(retryPolicy()),
 		standardproposerduties.WithChainTime(chainTime),
 		standardproposerduties.WithChainDB(chainDB),
+		standardproposerduties.WithScheduler(scheduler),
+		standardproposerduties.WithStartEpoch(viper.GetInt64("proposer-duties.start-epoch")),
+		standardproposerduties.WithCatchupWorkers(viper.GetInt("proposer-duties.catchup-workers")),
+		standardproposerduties.WithCatchupEpochsPerTx(viper.GetInt("proposer-duties.catchup-epochs-per-tx")),
+		standardproposerduties.WithConcurrency(viper.GetInt64("proposer-duties.concurrency")),
+		standardproposerduties.WithBackfill(viper.GetBool("backfill.enable")),
+		standardproposerduties.WithHeadFirstDistance(phase0.Epoch(viper.GetUint64("backfill.head-first-distance"))),
+		standardproposerduties.WithThrottle(throttle),
 	)
 	if err != nil {
-		return errors.Wrap(err, "failed to create proposer duties service")
+		return nil, errors.Wrap(err, "failed to create proposer duties service")
 	}
 
-	return nil
+	return standardProposerDuties, nil
 }
 
 func startETH1Deposits(
@@ -665,12 +2155,14 @@ func startETH1Deposits(
 	log.Trace().Msg("Starting Ethereum 1 deposits service")
 	_, err := getlogseth1deposits.New(ctx,
 		getlogseth1deposits.WithLogLevel(util.LogLevel("eth1deposits.log-level")),
+		getlogseth1deposits.WithLogLevelSampler(util.LogLevelSampler("eth1deposits")),
 		getlogseth1deposits.WithMonitor(monitor),
 		getlogseth1deposits.WithChainDB(chainDB),
 		getlogseth1deposits.WithConnectionURL(viper.GetString("eth1client.address")),
 		getlogseth1deposits.WithStartBlock(viper.GetString("eth1deposits.start-block")),
 		getlogseth1deposits.WithETH1DepositsSetter(chainDB.(chaindb.ETH1DepositsSetter)),
 		getlogseth1deposits.WithETH1Confirmations(viper.GetUint64("eth1deposits.confirmations")),
+		getlogseth1deposits.WithStrict(viper.GetBool("strict")),
 	)
 	if err != nil {
 		return errors.Wrap(err, "failed to start Ethereum 1 deposits service")
@@ -700,6 +2192,8 @@ func startSyncCommittees(
 
 	_, err = standardsynccommittees.New(ctx,
 		standardsynccommittees.WithLogLevel(util.LogLevel("sync-committees")),
+		standardsynccommittees.WithLogLevelSampler(util.LogLevelSampler("sync-committees")),
+		standardsynccommittees.WithEventsStallTimeout(viper.GetDuration("eth2client.events.stall-timeout")),
 		standardsynccommittees.WithMonitor(monitor),
 		standardsynccommittees.WithETH2Client(eth2Client),
 		standardsynccommittees.WithChainTime(chainTime),
@@ -716,11 +2210,43 @@ func startSyncCommittees(
 
 // runCommands runs commands if required.
 // Returns true if an exit is required.
-func runCommands(_ context.Context) (bool, error) {
+func runCommands(ctx context.Context) (bool, error) {
 	if viper.GetBool("version") {
 		fmt.Printf("%s\n", ReleaseVersion)
 		return true, nil
 	}
 
+	if viper.GetString("export-snapshot") != "" {
+		return true, exportSnapshot(ctx, viper.GetString("export-snapshot"))
+	}
+
+	if viper.GetString("import-snapshot") != "" {
+		return true, importSnapshot(ctx, viper.GetString("import-snapshot"))
+	}
+
+	if pflag.Arg(0) == "query" {
+		return true, runQuery(ctx, pflag.Args()[1:])
+	}
+
+	if pflag.Arg(0) == "reindex" {
+		return true, runReindex(ctx)
+	}
+
+	if pflag.Arg(0) == "labels" {
+		return true, runLabels(ctx, pflag.Args()[1:])
+	}
+
+	if pflag.Arg(0) == "check-config" {
+		return true, runCheckConfig(ctx)
+	}
+
+	if pflag.Arg(0) == "stats" {
+		return true, runStats(ctx)
+	}
+
+	if pflag.Arg(0) == "benchmark" {
+		return true, runBenchmark(ctx)
+	}
+
 	return false, nil
 }
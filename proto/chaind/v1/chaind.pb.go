@@ -0,0 +1,1855 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.0
+// 	protoc        (unknown)
+// source: proto/chaind/v1/chaind.proto
+
+package chaindv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetBlockRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Root []byte `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+}
+
+func (x *GetBlockRequest) Reset() {
+	*x = GetBlockRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetBlockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBlockRequest) ProtoMessage() {}
+
+func (x *GetBlockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBlockRequest.ProtoReflect.Descriptor instead.
+func (*GetBlockRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetBlockRequest) GetRoot() []byte {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+type SlotRangeRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FromSlot uint64 `protobuf:"varint,1,opt,name=from_slot,json=fromSlot,proto3" json:"from_slot,omitempty"`
+	ToSlot   uint64 `protobuf:"varint,2,opt,name=to_slot,json=toSlot,proto3" json:"to_slot,omitempty"`
+}
+
+func (x *SlotRangeRequest) Reset() {
+	*x = SlotRangeRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SlotRangeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SlotRangeRequest) ProtoMessage() {}
+
+func (x *SlotRangeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SlotRangeRequest.ProtoReflect.Descriptor instead.
+func (*SlotRangeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SlotRangeRequest) GetFromSlot() uint64 {
+	if x != nil {
+		return x.FromSlot
+	}
+	return 0
+}
+
+func (x *SlotRangeRequest) GetToSlot() uint64 {
+	if x != nil {
+		return x.ToSlot
+	}
+	return 0
+}
+
+type ListValidatorsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// indices are the validator indices to return; if empty all validators are returned.
+	Indices []uint64 `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+}
+
+func (x *ListValidatorsRequest) Reset() {
+	*x = ListValidatorsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListValidatorsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListValidatorsRequest) ProtoMessage() {}
+
+func (x *ListValidatorsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListValidatorsRequest.ProtoReflect.Descriptor instead.
+func (*ListValidatorsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListValidatorsRequest) GetIndices() []uint64 {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+type ListValidatorBalancesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Indices   []uint64 `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	FromEpoch uint64   `protobuf:"varint,2,opt,name=from_epoch,json=fromEpoch,proto3" json:"from_epoch,omitempty"`
+	ToEpoch   uint64   `protobuf:"varint,3,opt,name=to_epoch,json=toEpoch,proto3" json:"to_epoch,omitempty"`
+}
+
+func (x *ListValidatorBalancesRequest) Reset() {
+	*x = ListValidatorBalancesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListValidatorBalancesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListValidatorBalancesRequest) ProtoMessage() {}
+
+func (x *ListValidatorBalancesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListValidatorBalancesRequest.ProtoReflect.Descriptor instead.
+func (*ListValidatorBalancesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListValidatorBalancesRequest) GetIndices() []uint64 {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *ListValidatorBalancesRequest) GetFromEpoch() uint64 {
+	if x != nil {
+		return x.FromEpoch
+	}
+	return 0
+}
+
+func (x *ListValidatorBalancesRequest) GetToEpoch() uint64 {
+	if x != nil {
+		return x.ToEpoch
+	}
+	return 0
+}
+
+type ListAttesterDutiesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Indices  []uint64 `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	FromSlot uint64   `protobuf:"varint,2,opt,name=from_slot,json=fromSlot,proto3" json:"from_slot,omitempty"`
+	ToSlot   uint64   `protobuf:"varint,3,opt,name=to_slot,json=toSlot,proto3" json:"to_slot,omitempty"`
+}
+
+func (x *ListAttesterDutiesRequest) Reset() {
+	*x = ListAttesterDutiesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAttesterDutiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAttesterDutiesRequest) ProtoMessage() {}
+
+func (x *ListAttesterDutiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAttesterDutiesRequest.ProtoReflect.Descriptor instead.
+func (*ListAttesterDutiesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListAttesterDutiesRequest) GetIndices() []uint64 {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *ListAttesterDutiesRequest) GetFromSlot() uint64 {
+	if x != nil {
+		return x.FromSlot
+	}
+	return 0
+}
+
+func (x *ListAttesterDutiesRequest) GetToSlot() uint64 {
+	if x != nil {
+		return x.ToSlot
+	}
+	return 0
+}
+
+type ListValidatorEpochSummariesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// indices are the validator indices to return; if empty summaries for all validators are returned.
+	Indices   []uint64 `protobuf:"varint,1,rep,packed,name=indices,proto3" json:"indices,omitempty"`
+	FromEpoch uint64   `protobuf:"varint,2,opt,name=from_epoch,json=fromEpoch,proto3" json:"from_epoch,omitempty"`
+	ToEpoch   uint64   `protobuf:"varint,3,opt,name=to_epoch,json=toEpoch,proto3" json:"to_epoch,omitempty"`
+}
+
+func (x *ListValidatorEpochSummariesRequest) Reset() {
+	*x = ListValidatorEpochSummariesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListValidatorEpochSummariesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListValidatorEpochSummariesRequest) ProtoMessage() {}
+
+func (x *ListValidatorEpochSummariesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListValidatorEpochSummariesRequest.ProtoReflect.Descriptor instead.
+func (*ListValidatorEpochSummariesRequest) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListValidatorEpochSummariesRequest) GetIndices() []uint64 {
+	if x != nil {
+		return x.Indices
+	}
+	return nil
+}
+
+func (x *ListValidatorEpochSummariesRequest) GetFromEpoch() uint64 {
+	if x != nil {
+		return x.FromEpoch
+	}
+	return 0
+}
+
+func (x *ListValidatorEpochSummariesRequest) GetToEpoch() uint64 {
+	if x != nil {
+		return x.ToEpoch
+	}
+	return 0
+}
+
+type Block struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot             uint64 `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ProposerIndex    uint64 `protobuf:"varint,2,opt,name=proposer_index,json=proposerIndex,proto3" json:"proposer_index,omitempty"`
+	Root             []byte `protobuf:"bytes,3,opt,name=root,proto3" json:"root,omitempty"`
+	Graffiti         []byte `protobuf:"bytes,4,opt,name=graffiti,proto3" json:"graffiti,omitempty"`
+	RandaoReveal     []byte `protobuf:"bytes,5,opt,name=randao_reveal,json=randaoReveal,proto3" json:"randao_reveal,omitempty"`
+	BodyRoot         []byte `protobuf:"bytes,6,opt,name=body_root,json=bodyRoot,proto3" json:"body_root,omitempty"`
+	ParentRoot       []byte `protobuf:"bytes,7,opt,name=parent_root,json=parentRoot,proto3" json:"parent_root,omitempty"`
+	StateRoot        []byte `protobuf:"bytes,8,opt,name=state_root,json=stateRoot,proto3" json:"state_root,omitempty"`
+	Canonical        *bool  `protobuf:"varint,9,opt,name=canonical,proto3,oneof" json:"canonical,omitempty"`
+	Eth1BlockHash    []byte `protobuf:"bytes,10,opt,name=eth1_block_hash,json=eth1BlockHash,proto3" json:"eth1_block_hash,omitempty"`
+	Eth1DepositCount uint64 `protobuf:"varint,11,opt,name=eth1_deposit_count,json=eth1DepositCount,proto3" json:"eth1_deposit_count,omitempty"`
+	Eth1DepositRoot  []byte `protobuf:"bytes,12,opt,name=eth1_deposit_root,json=eth1DepositRoot,proto3" json:"eth1_deposit_root,omitempty"`
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Block) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Block) GetProposerIndex() uint64 {
+	if x != nil {
+		return x.ProposerIndex
+	}
+	return 0
+}
+
+func (x *Block) GetRoot() []byte {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+func (x *Block) GetGraffiti() []byte {
+	if x != nil {
+		return x.Graffiti
+	}
+	return nil
+}
+
+func (x *Block) GetRandaoReveal() []byte {
+	if x != nil {
+		return x.RandaoReveal
+	}
+	return nil
+}
+
+func (x *Block) GetBodyRoot() []byte {
+	if x != nil {
+		return x.BodyRoot
+	}
+	return nil
+}
+
+func (x *Block) GetParentRoot() []byte {
+	if x != nil {
+		return x.ParentRoot
+	}
+	return nil
+}
+
+func (x *Block) GetStateRoot() []byte {
+	if x != nil {
+		return x.StateRoot
+	}
+	return nil
+}
+
+func (x *Block) GetCanonical() bool {
+	if x != nil && x.Canonical != nil {
+		return *x.Canonical
+	}
+	return false
+}
+
+func (x *Block) GetEth1BlockHash() []byte {
+	if x != nil {
+		return x.Eth1BlockHash
+	}
+	return nil
+}
+
+func (x *Block) GetEth1DepositCount() uint64 {
+	if x != nil {
+		return x.Eth1DepositCount
+	}
+	return 0
+}
+
+func (x *Block) GetEth1DepositRoot() []byte {
+	if x != nil {
+		return x.Eth1DepositRoot
+	}
+	return nil
+}
+
+type Validator struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index                      uint64 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	PublicKey                  []byte `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	EffectiveBalance           uint64 `protobuf:"varint,3,opt,name=effective_balance,json=effectiveBalance,proto3" json:"effective_balance,omitempty"`
+	Slashed                    bool   `protobuf:"varint,4,opt,name=slashed,proto3" json:"slashed,omitempty"`
+	ActivationEligibilityEpoch uint64 `protobuf:"varint,5,opt,name=activation_eligibility_epoch,json=activationEligibilityEpoch,proto3" json:"activation_eligibility_epoch,omitempty"`
+	ActivationEpoch            uint64 `protobuf:"varint,6,opt,name=activation_epoch,json=activationEpoch,proto3" json:"activation_epoch,omitempty"`
+	ExitEpoch                  uint64 `protobuf:"varint,7,opt,name=exit_epoch,json=exitEpoch,proto3" json:"exit_epoch,omitempty"`
+	WithdrawableEpoch          uint64 `protobuf:"varint,8,opt,name=withdrawable_epoch,json=withdrawableEpoch,proto3" json:"withdrawable_epoch,omitempty"`
+	WithdrawalCredentials      []byte `protobuf:"bytes,9,opt,name=withdrawal_credentials,json=withdrawalCredentials,proto3" json:"withdrawal_credentials,omitempty"`
+}
+
+func (x *Validator) Reset() {
+	*x = Validator{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Validator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Validator) ProtoMessage() {}
+
+func (x *Validator) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Validator.ProtoReflect.Descriptor instead.
+func (*Validator) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Validator) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Validator) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *Validator) GetEffectiveBalance() uint64 {
+	if x != nil {
+		return x.EffectiveBalance
+	}
+	return 0
+}
+
+func (x *Validator) GetSlashed() bool {
+	if x != nil {
+		return x.Slashed
+	}
+	return false
+}
+
+func (x *Validator) GetActivationEligibilityEpoch() uint64 {
+	if x != nil {
+		return x.ActivationEligibilityEpoch
+	}
+	return 0
+}
+
+func (x *Validator) GetActivationEpoch() uint64 {
+	if x != nil {
+		return x.ActivationEpoch
+	}
+	return 0
+}
+
+func (x *Validator) GetExitEpoch() uint64 {
+	if x != nil {
+		return x.ExitEpoch
+	}
+	return 0
+}
+
+func (x *Validator) GetWithdrawableEpoch() uint64 {
+	if x != nil {
+		return x.WithdrawableEpoch
+	}
+	return 0
+}
+
+func (x *Validator) GetWithdrawalCredentials() []byte {
+	if x != nil {
+		return x.WithdrawalCredentials
+	}
+	return nil
+}
+
+type ValidatorBalance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index            uint64 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Epoch            uint64 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Balance          uint64 `protobuf:"varint,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	EffectiveBalance uint64 `protobuf:"varint,4,opt,name=effective_balance,json=effectiveBalance,proto3" json:"effective_balance,omitempty"`
+}
+
+func (x *ValidatorBalance) Reset() {
+	*x = ValidatorBalance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidatorBalance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatorBalance) ProtoMessage() {}
+
+func (x *ValidatorBalance) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatorBalance.ProtoReflect.Descriptor instead.
+func (*ValidatorBalance) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ValidatorBalance) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ValidatorBalance) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *ValidatorBalance) GetBalance() uint64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *ValidatorBalance) GetEffectiveBalance() uint64 {
+	if x != nil {
+		return x.EffectiveBalance
+	}
+	return 0
+}
+
+type Attestation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	InclusionSlot      uint64   `protobuf:"varint,1,opt,name=inclusion_slot,json=inclusionSlot,proto3" json:"inclusion_slot,omitempty"`
+	InclusionBlockRoot []byte   `protobuf:"bytes,2,opt,name=inclusion_block_root,json=inclusionBlockRoot,proto3" json:"inclusion_block_root,omitempty"`
+	InclusionIndex     uint64   `protobuf:"varint,3,opt,name=inclusion_index,json=inclusionIndex,proto3" json:"inclusion_index,omitempty"`
+	Slot               uint64   `protobuf:"varint,4,opt,name=slot,proto3" json:"slot,omitempty"`
+	CommitteeIndex     uint64   `protobuf:"varint,5,opt,name=committee_index,json=committeeIndex,proto3" json:"committee_index,omitempty"`
+	AggregationBits    []byte   `protobuf:"bytes,6,opt,name=aggregation_bits,json=aggregationBits,proto3" json:"aggregation_bits,omitempty"`
+	AggregationIndices []uint64 `protobuf:"varint,7,rep,packed,name=aggregation_indices,json=aggregationIndices,proto3" json:"aggregation_indices,omitempty"`
+	BeaconBlockRoot    []byte   `protobuf:"bytes,8,opt,name=beacon_block_root,json=beaconBlockRoot,proto3" json:"beacon_block_root,omitempty"`
+	SourceEpoch        uint64   `protobuf:"varint,9,opt,name=source_epoch,json=sourceEpoch,proto3" json:"source_epoch,omitempty"`
+	SourceRoot         []byte   `protobuf:"bytes,10,opt,name=source_root,json=sourceRoot,proto3" json:"source_root,omitempty"`
+	TargetEpoch        uint64   `protobuf:"varint,11,opt,name=target_epoch,json=targetEpoch,proto3" json:"target_epoch,omitempty"`
+	TargetRoot         []byte   `protobuf:"bytes,12,opt,name=target_root,json=targetRoot,proto3" json:"target_root,omitempty"`
+	Canonical          *bool    `protobuf:"varint,13,opt,name=canonical,proto3,oneof" json:"canonical,omitempty"`
+	TargetCorrect      *bool    `protobuf:"varint,14,opt,name=target_correct,json=targetCorrect,proto3,oneof" json:"target_correct,omitempty"`
+	HeadCorrect        *bool    `protobuf:"varint,15,opt,name=head_correct,json=headCorrect,proto3,oneof" json:"head_correct,omitempty"`
+}
+
+func (x *Attestation) Reset() {
+	*x = Attestation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Attestation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attestation) ProtoMessage() {}
+
+func (x *Attestation) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attestation.ProtoReflect.Descriptor instead.
+func (*Attestation) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Attestation) GetInclusionSlot() uint64 {
+	if x != nil {
+		return x.InclusionSlot
+	}
+	return 0
+}
+
+func (x *Attestation) GetInclusionBlockRoot() []byte {
+	if x != nil {
+		return x.InclusionBlockRoot
+	}
+	return nil
+}
+
+func (x *Attestation) GetInclusionIndex() uint64 {
+	if x != nil {
+		return x.InclusionIndex
+	}
+	return 0
+}
+
+func (x *Attestation) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Attestation) GetCommitteeIndex() uint64 {
+	if x != nil {
+		return x.CommitteeIndex
+	}
+	return 0
+}
+
+func (x *Attestation) GetAggregationBits() []byte {
+	if x != nil {
+		return x.AggregationBits
+	}
+	return nil
+}
+
+func (x *Attestation) GetAggregationIndices() []uint64 {
+	if x != nil {
+		return x.AggregationIndices
+	}
+	return nil
+}
+
+func (x *Attestation) GetBeaconBlockRoot() []byte {
+	if x != nil {
+		return x.BeaconBlockRoot
+	}
+	return nil
+}
+
+func (x *Attestation) GetSourceEpoch() uint64 {
+	if x != nil {
+		return x.SourceEpoch
+	}
+	return 0
+}
+
+func (x *Attestation) GetSourceRoot() []byte {
+	if x != nil {
+		return x.SourceRoot
+	}
+	return nil
+}
+
+func (x *Attestation) GetTargetEpoch() uint64 {
+	if x != nil {
+		return x.TargetEpoch
+	}
+	return 0
+}
+
+func (x *Attestation) GetTargetRoot() []byte {
+	if x != nil {
+		return x.TargetRoot
+	}
+	return nil
+}
+
+func (x *Attestation) GetCanonical() bool {
+	if x != nil && x.Canonical != nil {
+		return *x.Canonical
+	}
+	return false
+}
+
+func (x *Attestation) GetTargetCorrect() bool {
+	if x != nil && x.TargetCorrect != nil {
+		return *x.TargetCorrect
+	}
+	return false
+}
+
+func (x *Attestation) GetHeadCorrect() bool {
+	if x != nil && x.HeadCorrect != nil {
+		return *x.HeadCorrect
+	}
+	return false
+}
+
+type ProposerDuty struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot           uint64 `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	ValidatorIndex uint64 `protobuf:"varint,2,opt,name=validator_index,json=validatorIndex,proto3" json:"validator_index,omitempty"`
+}
+
+func (x *ProposerDuty) Reset() {
+	*x = ProposerDuty{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ProposerDuty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProposerDuty) ProtoMessage() {}
+
+func (x *ProposerDuty) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProposerDuty.ProtoReflect.Descriptor instead.
+func (*ProposerDuty) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ProposerDuty) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *ProposerDuty) GetValidatorIndex() uint64 {
+	if x != nil {
+		return x.ValidatorIndex
+	}
+	return 0
+}
+
+type AttesterDuty struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot           uint64 `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	Committee      uint64 `protobuf:"varint,2,opt,name=committee,proto3" json:"committee,omitempty"`
+	ValidatorIndex uint64 `protobuf:"varint,3,opt,name=validator_index,json=validatorIndex,proto3" json:"validator_index,omitempty"`
+	// committee_index is the index of the validator in the committee.
+	CommitteeIndex uint64 `protobuf:"varint,4,opt,name=committee_index,json=committeeIndex,proto3" json:"committee_index,omitempty"`
+}
+
+func (x *AttesterDuty) Reset() {
+	*x = AttesterDuty{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AttesterDuty) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AttesterDuty) ProtoMessage() {}
+
+func (x *AttesterDuty) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AttesterDuty.ProtoReflect.Descriptor instead.
+func (*AttesterDuty) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *AttesterDuty) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *AttesterDuty) GetCommittee() uint64 {
+	if x != nil {
+		return x.Committee
+	}
+	return 0
+}
+
+func (x *AttesterDuty) GetValidatorIndex() uint64 {
+	if x != nil {
+		return x.ValidatorIndex
+	}
+	return 0
+}
+
+func (x *AttesterDuty) GetCommitteeIndex() uint64 {
+	if x != nil {
+		return x.CommitteeIndex
+	}
+	return 0
+}
+
+type BlockSummary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot                          uint64 `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	AttestationsForBlock          int64  `protobuf:"varint,2,opt,name=attestations_for_block,json=attestationsForBlock,proto3" json:"attestations_for_block,omitempty"`
+	DuplicateAttestationsForBlock int64  `protobuf:"varint,3,opt,name=duplicate_attestations_for_block,json=duplicateAttestationsForBlock,proto3" json:"duplicate_attestations_for_block,omitempty"`
+	VotesForBlock                 int64  `protobuf:"varint,4,opt,name=votes_for_block,json=votesForBlock,proto3" json:"votes_for_block,omitempty"`
+	ParentDistance                int64  `protobuf:"varint,5,opt,name=parent_distance,json=parentDistance,proto3" json:"parent_distance,omitempty"`
+}
+
+func (x *BlockSummary) Reset() {
+	*x = BlockSummary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockSummary) ProtoMessage() {}
+
+func (x *BlockSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockSummary.ProtoReflect.Descriptor instead.
+func (*BlockSummary) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BlockSummary) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *BlockSummary) GetAttestationsForBlock() int64 {
+	if x != nil {
+		return x.AttestationsForBlock
+	}
+	return 0
+}
+
+func (x *BlockSummary) GetDuplicateAttestationsForBlock() int64 {
+	if x != nil {
+		return x.DuplicateAttestationsForBlock
+	}
+	return 0
+}
+
+func (x *BlockSummary) GetVotesForBlock() int64 {
+	if x != nil {
+		return x.VotesForBlock
+	}
+	return 0
+}
+
+func (x *BlockSummary) GetParentDistance() int64 {
+	if x != nil {
+		return x.ParentDistance
+	}
+	return 0
+}
+
+type ValidatorEpochSummary struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Index                     uint64 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Epoch                     uint64 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	ProposerDuties            int64  `protobuf:"varint,3,opt,name=proposer_duties,json=proposerDuties,proto3" json:"proposer_duties,omitempty"`
+	ProposalsIncluded         int64  `protobuf:"varint,4,opt,name=proposals_included,json=proposalsIncluded,proto3" json:"proposals_included,omitempty"`
+	AttestationIncluded       bool   `protobuf:"varint,5,opt,name=attestation_included,json=attestationIncluded,proto3" json:"attestation_included,omitempty"`
+	AttestationTargetCorrect  *bool  `protobuf:"varint,6,opt,name=attestation_target_correct,json=attestationTargetCorrect,proto3,oneof" json:"attestation_target_correct,omitempty"`
+	AttestationHeadCorrect    *bool  `protobuf:"varint,7,opt,name=attestation_head_correct,json=attestationHeadCorrect,proto3,oneof" json:"attestation_head_correct,omitempty"`
+	AttestationInclusionDelay *int64 `protobuf:"varint,8,opt,name=attestation_inclusion_delay,json=attestationInclusionDelay,proto3,oneof" json:"attestation_inclusion_delay,omitempty"`
+	AttestationSourceTimely   *bool  `protobuf:"varint,9,opt,name=attestation_source_timely,json=attestationSourceTimely,proto3,oneof" json:"attestation_source_timely,omitempty"`
+	AttestationTargetTimely   *bool  `protobuf:"varint,10,opt,name=attestation_target_timely,json=attestationTargetTimely,proto3,oneof" json:"attestation_target_timely,omitempty"`
+	AttestationHeadTimely     *bool  `protobuf:"varint,11,opt,name=attestation_head_timely,json=attestationHeadTimely,proto3,oneof" json:"attestation_head_timely,omitempty"`
+}
+
+func (x *ValidatorEpochSummary) Reset() {
+	*x = ValidatorEpochSummary{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidatorEpochSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatorEpochSummary) ProtoMessage() {}
+
+func (x *ValidatorEpochSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatorEpochSummary.ProtoReflect.Descriptor instead.
+func (*ValidatorEpochSummary) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ValidatorEpochSummary) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *ValidatorEpochSummary) GetEpoch() uint64 {
+	if x != nil {
+		return x.Epoch
+	}
+	return 0
+}
+
+func (x *ValidatorEpochSummary) GetProposerDuties() int64 {
+	if x != nil {
+		return x.ProposerDuties
+	}
+	return 0
+}
+
+func (x *ValidatorEpochSummary) GetProposalsIncluded() int64 {
+	if x != nil {
+		return x.ProposalsIncluded
+	}
+	return 0
+}
+
+func (x *ValidatorEpochSummary) GetAttestationIncluded() bool {
+	if x != nil {
+		return x.AttestationIncluded
+	}
+	return false
+}
+
+func (x *ValidatorEpochSummary) GetAttestationTargetCorrect() bool {
+	if x != nil && x.AttestationTargetCorrect != nil {
+		return *x.AttestationTargetCorrect
+	}
+	return false
+}
+
+func (x *ValidatorEpochSummary) GetAttestationHeadCorrect() bool {
+	if x != nil && x.AttestationHeadCorrect != nil {
+		return *x.AttestationHeadCorrect
+	}
+	return false
+}
+
+func (x *ValidatorEpochSummary) GetAttestationInclusionDelay() int64 {
+	if x != nil && x.AttestationInclusionDelay != nil {
+		return *x.AttestationInclusionDelay
+	}
+	return 0
+}
+
+func (x *ValidatorEpochSummary) GetAttestationSourceTimely() bool {
+	if x != nil && x.AttestationSourceTimely != nil {
+		return *x.AttestationSourceTimely
+	}
+	return false
+}
+
+func (x *ValidatorEpochSummary) GetAttestationTargetTimely() bool {
+	if x != nil && x.AttestationTargetTimely != nil {
+		return *x.AttestationTargetTimely
+	}
+	return false
+}
+
+func (x *ValidatorEpochSummary) GetAttestationHeadTimely() bool {
+	if x != nil && x.AttestationHeadTimely != nil {
+		return *x.AttestationHeadTimely
+	}
+	return false
+}
+
+// BlockAttestations are the attestations included in a block.
+type BlockAttestations struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slot         uint64         `protobuf:"varint,1,opt,name=slot,proto3" json:"slot,omitempty"`
+	BlockRoot    []byte         `protobuf:"bytes,2,opt,name=block_root,json=blockRoot,proto3" json:"block_root,omitempty"`
+	Attestations []*Attestation `protobuf:"bytes,3,rep,name=attestations,proto3" json:"attestations,omitempty"`
+}
+
+func (x *BlockAttestations) Reset() {
+	*x = BlockAttestations{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *BlockAttestations) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BlockAttestations) ProtoMessage() {}
+
+func (x *BlockAttestations) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BlockAttestations.ProtoReflect.Descriptor instead.
+func (*BlockAttestations) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *BlockAttestations) GetSlot() uint64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *BlockAttestations) GetBlockRoot() []byte {
+	if x != nil {
+		return x.BlockRoot
+	}
+	return nil
+}
+
+func (x *BlockAttestations) GetAttestations() []*Attestation {
+	if x != nil {
+		return x.Attestations
+	}
+	return nil
+}
+
+// FinalityUpdate is sent when finality has been updated.
+type FinalityUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	FinalizedEpoch uint64 `protobuf:"varint,1,opt,name=finalized_epoch,json=finalizedEpoch,proto3" json:"finalized_epoch,omitempty"`
+}
+
+func (x *FinalityUpdate) Reset() {
+	*x = FinalityUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_proto_chaind_v1_chaind_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FinalityUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FinalityUpdate) ProtoMessage() {}
+
+func (x *FinalityUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_chaind_v1_chaind_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FinalityUpdate.ProtoReflect.Descriptor instead.
+func (*FinalityUpdate) Descriptor() ([]byte, []int) {
+	return file_proto_chaind_v1_chaind_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FinalityUpdate) GetFinalizedEpoch() uint64 {
+	if x != nil {
+		return x.FinalizedEpoch
+	}
+	return 0
+}
+
+var File_proto_chaind_v1_chaind_proto protoreflect.FileDescriptor
+
+var file_proto_chaind_v1_chaind_proto_rawDesc = []byte{
+	0x0a, 0x1c, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2f, 0x76,
+	0x31, 0x2f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x22, 0x25, 0x0a, 0x0f, 0x47, 0x65, 0x74,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04,
+	0x72, 0x6f, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x72, 0x6f, 0x6f, 0x74,
+	0x22, 0x48, 0x0a, 0x10, 0x53, 0x6c, 0x6f, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x73, 0x6c, 0x6f,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x66, 0x72, 0x6f, 0x6d, 0x53, 0x6c, 0x6f,
+	0x74, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x6f, 0x5f, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x06, 0x74, 0x6f, 0x53, 0x6c, 0x6f, 0x74, 0x22, 0x31, 0x0a, 0x15, 0x4c, 0x69,
+	0x73, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x04, 0x52, 0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x22, 0x72, 0x0a,
+	0x1c, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x42, 0x61,
+	0x6c, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x04, 0x52, 0x07,
+	0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x5f,
+	0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x66, 0x72, 0x6f,
+	0x6d, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x6f, 0x5f, 0x65, 0x70, 0x6f,
+	0x63, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x74, 0x6f, 0x45, 0x70, 0x6f, 0x63,
+	0x68, 0x22, 0x6b, 0x0a, 0x19, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x65,
+	0x72, 0x44, 0x75, 0x74, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x04, 0x52,
+	0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x66, 0x72, 0x6f, 0x6d,
+	0x5f, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x66, 0x72, 0x6f,
+	0x6d, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x6f, 0x5f, 0x73, 0x6c, 0x6f, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x06, 0x74, 0x6f, 0x53, 0x6c, 0x6f, 0x74, 0x22, 0x78,
+	0x0a, 0x22, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x45,
+	0x70, 0x6f, 0x63, 0x68, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x18,
+	0x01, 0x20, 0x03, 0x28, 0x04, 0x52, 0x07, 0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x12, 0x1d,
+	0x0a, 0x0a, 0x66, 0x72, 0x6f, 0x6d, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x09, 0x66, 0x72, 0x6f, 0x6d, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x19, 0x0a,
+	0x08, 0x74, 0x6f, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x07, 0x74, 0x6f, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x22, 0xa7, 0x03, 0x0a, 0x05, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73,
+	0x65, 0x72, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d,
+	0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a,
+	0x04, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x72, 0x6f, 0x6f,
+	0x74, 0x12, 0x1a, 0x0a, 0x08, 0x67, 0x72, 0x61, 0x66, 0x66, 0x69, 0x74, 0x69, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x08, 0x67, 0x72, 0x61, 0x66, 0x66, 0x69, 0x74, 0x69, 0x12, 0x23, 0x0a,
+	0x0d, 0x72, 0x61, 0x6e, 0x64, 0x61, 0x6f, 0x5f, 0x72, 0x65, 0x76, 0x65, 0x61, 0x6c, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x72, 0x61, 0x6e, 0x64, 0x61, 0x6f, 0x52, 0x65, 0x76, 0x65,
+	0x61, 0x6c, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x6f, 0x64, 0x79, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x08, 0x62, 0x6f, 0x64, 0x79, 0x52, 0x6f, 0x6f, 0x74, 0x12,
+	0x1f, 0x0a, 0x0b, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x52, 0x6f, 0x6f, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x74, 0x65, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x73, 0x74, 0x61, 0x74, 0x65, 0x52, 0x6f, 0x6f, 0x74, 0x12,
+	0x21, 0x0a, 0x09, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x08, 0x48, 0x00, 0x52, 0x09, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x88,
+	0x01, 0x01, 0x12, 0x26, 0x0a, 0x0f, 0x65, 0x74, 0x68, 0x31, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0d, 0x65, 0x74, 0x68,
+	0x31, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x48, 0x61, 0x73, 0x68, 0x12, 0x2c, 0x0a, 0x12, 0x65, 0x74,
+	0x68, 0x31, 0x5f, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x65, 0x74, 0x68, 0x31, 0x44, 0x65, 0x70, 0x6f,
+	0x73, 0x69, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2a, 0x0a, 0x11, 0x65, 0x74, 0x68, 0x31,
+	0x5f, 0x64, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x0f, 0x65, 0x74, 0x68, 0x31, 0x44, 0x65, 0x70, 0x6f, 0x73, 0x69, 0x74,
+	0x52, 0x6f, 0x6f, 0x74, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63,
+	0x61, 0x6c, 0x22, 0xf9, 0x02, 0x0a, 0x09, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72,
+	0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63,
+	0x5f, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c,
+	0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x2b, 0x0a, 0x11, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69,
+	0x76, 0x65, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x10, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x42, 0x61, 0x6c, 0x61, 0x6e,
+	0x63, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x65, 0x64, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x07, 0x73, 0x6c, 0x61, 0x73, 0x68, 0x65, 0x64, 0x12, 0x40, 0x0a, 0x1c,
+	0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x65, 0x6c, 0x69, 0x67, 0x69,
+	0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x05, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x1a, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x45, 0x6c,
+	0x69, 0x67, 0x69, 0x62, 0x69, 0x6c, 0x69, 0x74, 0x79, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x29,
+	0x0a, 0x10, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x65, 0x70, 0x6f,
+	0x63, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0f, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x78, 0x69,
+	0x74, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x09, 0x65,
+	0x78, 0x69, 0x74, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x2d, 0x0a, 0x12, 0x77, 0x69, 0x74, 0x68,
+	0x64, 0x72, 0x61, 0x77, 0x61, 0x62, 0x6c, 0x65, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x08,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x11, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61, 0x77, 0x61, 0x62,
+	0x6c, 0x65, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x35, 0x0a, 0x16, 0x77, 0x69, 0x74, 0x68, 0x64,
+	0x72, 0x61, 0x77, 0x61, 0x6c, 0x5f, 0x63, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c,
+	0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x15, 0x77, 0x69, 0x74, 0x68, 0x64, 0x72, 0x61,
+	0x77, 0x61, 0x6c, 0x43, 0x72, 0x65, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x61, 0x6c, 0x73, 0x22, 0x85,
+	0x01, 0x0a, 0x10, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x42, 0x61, 0x6c, 0x61,
+	0x6e, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x04, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f,
+	0x63, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12,
+	0x18, 0x0a, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x07, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x65, 0x66, 0x66,
+	0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x62, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x10, 0x65, 0x66, 0x66, 0x65, 0x63, 0x74, 0x69, 0x76, 0x65, 0x42,
+	0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x85, 0x05, 0x0a, 0x0b, 0x41, 0x74, 0x74, 0x65, 0x73,
+	0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x25, 0x0a, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73,
+	0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0d,
+	0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x53, 0x6c, 0x6f, 0x74, 0x12, 0x30, 0x0a,
+	0x14, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b,
+	0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x12, 0x69, 0x6e, 0x63,
+	0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x6f, 0x6f, 0x74, 0x12,
+	0x27, 0x0a, 0x0f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x64,
+	0x65, 0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73,
+	0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x12, 0x27, 0x0a, 0x0f,
+	0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65,
+	0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x29, 0x0a, 0x10, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x62, 0x69, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52,
+	0x0f, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x69, 0x74, 0x73,
+	0x12, 0x2f, 0x0a, 0x13, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x69, 0x6e, 0x64, 0x69, 0x63, 0x65, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x04, 0x52, 0x12, 0x61,
+	0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x64, 0x69, 0x63, 0x65,
+	0x73, 0x12, 0x2a, 0x0a, 0x11, 0x62, 0x65, 0x61, 0x63, 0x6f, 0x6e, 0x5f, 0x62, 0x6c, 0x6f, 0x63,
+	0x6b, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0f, 0x62, 0x65,
+	0x61, 0x63, 0x6f, 0x6e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x21, 0x0a,
+	0x0c, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x45, 0x70, 0x6f, 0x63, 0x68,
+	0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x6f, 0x6f,
+	0x74, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x65, 0x70, 0x6f, 0x63,
+	0x68, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x45,
+	0x70, 0x6f, 0x63, 0x68, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x72,
+	0x6f, 0x6f, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x74, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x21, 0x0a, 0x09, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63,
+	0x61, 0x6c, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x09, 0x63, 0x61, 0x6e, 0x6f,
+	0x6e, 0x69, 0x63, 0x61, 0x6c, 0x88, 0x01, 0x01, 0x12, 0x2a, 0x0a, 0x0e, 0x74, 0x61, 0x72, 0x67,
+	0x65, 0x74, 0x5f, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x08,
+	0x48, 0x01, 0x52, 0x0d, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x43, 0x6f, 0x72, 0x72, 0x65, 0x63,
+	0x74, 0x88, 0x01, 0x01, 0x12, 0x26, 0x0a, 0x0c, 0x68, 0x65, 0x61, 0x64, 0x5f, 0x63, 0x6f, 0x72,
+	0x72, 0x65, 0x63, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x08, 0x48, 0x02, 0x52, 0x0b, 0x68, 0x65,
+	0x61, 0x64, 0x43, 0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a,
+	0x5f, 0x63, 0x61, 0x6e, 0x6f, 0x6e, 0x69, 0x63, 0x61, 0x6c, 0x42, 0x11, 0x0a, 0x0f, 0x5f, 0x74,
+	0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x42, 0x0f, 0x0a,
+	0x0d, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x5f, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x22, 0x4b,
+	0x0a, 0x0c, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x44, 0x75, 0x74, 0x79, 0x12, 0x12,
+	0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x6c,
+	0x6f, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x5f,
+	0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x76, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x22, 0x92, 0x01, 0x0a, 0x0c,
+	0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x65, 0x72, 0x44, 0x75, 0x74, 0x79, 0x12, 0x12, 0x0a, 0x04,
+	0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x6c, 0x6f, 0x74,
+	0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x12, 0x27,
+	0x0a, 0x0f, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x18, 0x03, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0e, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74,
+	0x6f, 0x72, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x27, 0x0a, 0x0f, 0x63, 0x6f, 0x6d, 0x6d, 0x69,
+	0x74, 0x74, 0x65, 0x65, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0e, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x65, 0x49, 0x6e, 0x64, 0x65, 0x78,
+	0x22, 0xf2, 0x01, 0x0a, 0x0c, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72,
+	0x79, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x04, 0x73, 0x6c, 0x6f, 0x74, 0x12, 0x34, 0x0a, 0x16, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x66, 0x6f, 0x72, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x14, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x46, 0x6f, 0x72, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x47, 0x0a, 0x20, 0x64,
+	0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x5f, 0x66, 0x6f, 0x72, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x1d, 0x64, 0x75, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x74, 0x65,
+	0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x46, 0x6f, 0x72, 0x42,
+	0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x26, 0x0a, 0x0f, 0x76, 0x6f, 0x74, 0x65, 0x73, 0x5f, 0x66, 0x6f,
+	0x72, 0x5f, 0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x76,
+	0x6f, 0x74, 0x65, 0x73, 0x46, 0x6f, 0x72, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x27, 0x0a, 0x0f,
+	0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x64, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x44, 0x69, 0x73,
+	0x74, 0x61, 0x6e, 0x63, 0x65, 0x22, 0x88, 0x06, 0x0a, 0x15, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x6f, 0x72, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12,
+	0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
+	0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x12, 0x27, 0x0a, 0x0f, 0x70,
+	0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x5f, 0x64, 0x75, 0x74, 0x69, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x44, 0x75,
+	0x74, 0x69, 0x65, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c,
+	0x73, 0x5f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x11, 0x70, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x61, 0x6c, 0x73, 0x49, 0x6e, 0x63, 0x6c, 0x75,
+	0x64, 0x65, 0x64, 0x12, 0x31, 0x0a, 0x14, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x13, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e,
+	0x63, 0x6c, 0x75, 0x64, 0x65, 0x64, 0x12, 0x41, 0x0a, 0x1a, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x63, 0x6f, 0x72,
+	0x72, 0x65, 0x63, 0x74, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x18, 0x61, 0x74,
+	0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x43,
+	0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x88, 0x01, 0x01, 0x12, 0x3d, 0x0a, 0x18, 0x61, 0x74, 0x74,
+	0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x5f, 0x63, 0x6f,
+	0x72, 0x72, 0x65, 0x63, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x48, 0x01, 0x52, 0x16, 0x61,
+	0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48, 0x65, 0x61, 0x64, 0x43, 0x6f,
+	0x72, 0x72, 0x65, 0x63, 0x74, 0x88, 0x01, 0x01, 0x12, 0x43, 0x0a, 0x1b, 0x61, 0x74, 0x74, 0x65,
+	0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69, 0x6f,
+	0x6e, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x48, 0x02, 0x52,
+	0x19, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x63, 0x6c,
+	0x75, 0x73, 0x69, 0x6f, 0x6e, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x88, 0x01, 0x01, 0x12, 0x3f, 0x0a,
+	0x19, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x6f, 0x75,
+	0x72, 0x63, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6c, 0x79, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08,
+	0x48, 0x03, 0x52, 0x17, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53,
+	0x6f, 0x75, 0x72, 0x63, 0x65, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x79, 0x88, 0x01, 0x01, 0x12, 0x3f,
+	0x0a, 0x19, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6c, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x08, 0x48, 0x04, 0x52, 0x17, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x54, 0x61, 0x72, 0x67, 0x65, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x79, 0x88, 0x01, 0x01, 0x12,
+	0x3b, 0x0a, 0x17, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x68,
+	0x65, 0x61, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6c, 0x79, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x08,
+	0x48, 0x05, 0x52, 0x15, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x48,
+	0x65, 0x61, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x6c, 0x79, 0x88, 0x01, 0x01, 0x42, 0x1d, 0x0a, 0x1b,
+	0x5f, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x61, 0x72,
+	0x67, 0x65, 0x74, 0x5f, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x42, 0x1b, 0x0a, 0x19, 0x5f,
+	0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x68, 0x65, 0x61, 0x64,
+	0x5f, 0x63, 0x6f, 0x72, 0x72, 0x65, 0x63, 0x74, 0x42, 0x1e, 0x0a, 0x1c, 0x5f, 0x61, 0x74, 0x74,
+	0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x73, 0x69,
+	0x6f, 0x6e, 0x5f, 0x64, 0x65, 0x6c, 0x61, 0x79, 0x42, 0x1c, 0x0a, 0x1a, 0x5f, 0x61, 0x74, 0x74,
+	0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x5f,
+	0x74, 0x69, 0x6d, 0x65, 0x6c, 0x79, 0x42, 0x1c, 0x0a, 0x1a, 0x5f, 0x61, 0x74, 0x74, 0x65, 0x73,
+	0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x6c, 0x79, 0x42, 0x1a, 0x0a, 0x18, 0x5f, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x68, 0x65, 0x61, 0x64, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6c, 0x79,
+	0x22, 0x82, 0x01, 0x0a, 0x11, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x6c, 0x6f, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x62, 0x6c,
+	0x6f, 0x63, 0x6b, 0x5f, 0x72, 0x6f, 0x6f, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
+	0x62, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x6f, 0x6f, 0x74, 0x12, 0x3a, 0x0a, 0x0c, 0x61, 0x74, 0x74,
+	0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x16, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x74, 0x74, 0x65,
+	0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x61, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x39, 0x0a, 0x0e, 0x46, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x74,
+	0x79, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x27, 0x0a, 0x0f, 0x66, 0x69, 0x6e, 0x61, 0x6c,
+	0x69, 0x7a, 0x65, 0x64, 0x5f, 0x65, 0x70, 0x6f, 0x63, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x0e, 0x66, 0x69, 0x6e, 0x61, 0x6c, 0x69, 0x7a, 0x65, 0x64, 0x45, 0x70, 0x6f, 0x63, 0x68,
+	0x32, 0xde, 0x05, 0x0a, 0x06, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x12, 0x38, 0x0a, 0x08, 0x47,
+	0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x1a, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64,
+	0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e,
+	0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x12, 0x3d, 0x0a, 0x0a, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x73, 0x12, 0x1b, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x6c, 0x6f, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x10, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6c, 0x6f,
+	0x63, 0x6b, 0x30, 0x01, 0x12, 0x4a, 0x0a, 0x0e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x20, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e,
+	0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e,
+	0x64, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x30, 0x01,
+	0x12, 0x5f, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f,
+	0x72, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12, 0x27, 0x2e, 0x63, 0x68, 0x61, 0x69,
+	0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x6f, 0x72, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x56,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x42, 0x61, 0x6c, 0x61, 0x6e, 0x63, 0x65, 0x30,
+	0x01, 0x12, 0x49, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1b, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76,
+	0x31, 0x2e, 0x53, 0x6c, 0x6f, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x16, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x41,
+	0x74, 0x74, 0x65, 0x73, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x30, 0x01, 0x12, 0x4c, 0x0a, 0x12,
+	0x4c, 0x69, 0x73, 0x74, 0x50, 0x72, 0x6f, 0x70, 0x6f, 0x73, 0x65, 0x72, 0x44, 0x75, 0x74, 0x69,
+	0x65, 0x73, 0x12, 0x1b, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53,
+	0x6c, 0x6f, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
+	0x17, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f, 0x70,
+	0x6f, 0x73, 0x65, 0x72, 0x44, 0x75, 0x74, 0x79, 0x30, 0x01, 0x12, 0x55, 0x0a, 0x12, 0x4c, 0x69,
+	0x73, 0x74, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x65, 0x72, 0x44, 0x75, 0x74, 0x69, 0x65, 0x73,
+	0x12, 0x24, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73,
+	0x74, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x65, 0x72, 0x44, 0x75, 0x74, 0x69, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e,
+	0x76, 0x31, 0x2e, 0x41, 0x74, 0x74, 0x65, 0x73, 0x74, 0x65, 0x72, 0x44, 0x75, 0x74, 0x79, 0x30,
+	0x01, 0x12, 0x4c, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x75,
+	0x6d, 0x6d, 0x61, 0x72, 0x69, 0x65, 0x73, 0x12, 0x1b, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6c, 0x6f, 0x74, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31,
+	0x2e, 0x42, 0x6c, 0x6f, 0x63, 0x6b, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x30, 0x01, 0x12,
+	0x70, 0x0a, 0x1b, 0x4c, 0x69, 0x73, 0x74, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72,
+	0x45, 0x70, 0x6f, 0x63, 0x68, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x69, 0x65, 0x73, 0x12, 0x2d,
+	0x2e, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x56,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x69, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x6f, 0x72, 0x45, 0x70, 0x6f, 0x63, 0x68, 0x53, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x30,
+	0x01, 0x42, 0x36, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x77, 0x65, 0x61, 0x6c, 0x64, 0x74, 0x65, 0x63, 0x68, 0x2f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64,
+	0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x2f, 0x76, 0x31,
+	0x3b, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x64, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_proto_chaind_v1_chaind_proto_rawDescOnce sync.Once
+	file_proto_chaind_v1_chaind_proto_rawDescData = file_proto_chaind_v1_chaind_proto_rawDesc
+)
+
+func file_proto_chaind_v1_chaind_proto_rawDescGZIP() []byte {
+	file_proto_chaind_v1_chaind_proto_rawDescOnce.Do(func() {
+		file_proto_chaind_v1_chaind_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_chaind_v1_chaind_proto_rawDescData)
+	})
+	return file_proto_chaind_v1_chaind_proto_rawDescData
+}
+
+var file_proto_chaind_v1_chaind_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_proto_chaind_v1_chaind_proto_goTypes = []interface{}{
+	(*GetBlockRequest)(nil),                    // 0: chaind.v1.GetBlockRequest
+	(*SlotRangeRequest)(nil),                   // 1: chaind.v1.SlotRangeRequest
+	(*ListValidatorsRequest)(nil),              // 2: chaind.v1.ListValidatorsRequest
+	(*ListValidatorBalancesRequest)(nil),       // 3: chaind.v1.ListValidatorBalancesRequest
+	(*ListAttesterDutiesRequest)(nil),          // 4: chaind.v1.ListAttesterDutiesRequest
+	(*ListValidatorEpochSummariesRequest)(nil), // 5: chaind.v1.ListValidatorEpochSummariesRequest
+	(*Block)(nil),                              // 6: chaind.v1.Block
+	(*Validator)(nil),                          // 7: chaind.v1.Validator
+	(*ValidatorBalance)(nil),                   // 8: chaind.v1.ValidatorBalance
+	(*Attestation)(nil),                        // 9: chaind.v1.Attestation
+	(*ProposerDuty)(nil),                       // 10: chaind.v1.ProposerDuty
+	(*AttesterDuty)(nil),                       // 11: chaind.v1.AttesterDuty
+	(*BlockSummary)(nil),                       // 12: chaind.v1.BlockSummary
+	(*ValidatorEpochSummary)(nil),              // 13: chaind.v1.ValidatorEpochSummary
+	(*BlockAttestations)(nil),                  // 14: chaind.v1.BlockAttestations
+	(*FinalityUpdate)(nil),                     // 15: chaind.v1.FinalityUpdate
+}
+var file_proto_chaind_v1_chaind_proto_depIdxs = []int32{
+	9,  // 0: chaind.v1.BlockAttestations.attestations:type_name -> chaind.v1.Attestation
+	0,  // 1: chaind.v1.Chaind.GetBlock:input_type -> chaind.v1.GetBlockRequest
+	1,  // 2: chaind.v1.Chaind.ListBlocks:input_type -> chaind.v1.SlotRangeRequest
+	2,  // 3: chaind.v1.Chaind.ListValidators:input_type -> chaind.v1.ListValidatorsRequest
+	3,  // 4: chaind.v1.Chaind.ListValidatorBalances:input_type -> chaind.v1.ListValidatorBalancesRequest
+	1,  // 5: chaind.v1.Chaind.ListAttestations:input_type -> chaind.v1.SlotRangeRequest
+	1,  // 6: chaind.v1.Chaind.ListProposerDuties:input_type -> chaind.v1.SlotRangeRequest
+	4,  // 7: chaind.v1.Chaind.ListAttesterDuties:input_type -> chaind.v1.ListAttesterDutiesRequest
+	1,  // 8: chaind.v1.Chaind.ListBlockSummaries:input_type -> chaind.v1.SlotRangeRequest
+	5,  // 9: chaind.v1.Chaind.ListValidatorEpochSummaries:input_type -> chaind.v1.ListValidatorEpochSummariesRequest
+	6,  // 10: chaind.v1.Chaind.GetBlock:output_type -> chaind.v1.Block
+	6,  // 11: chaind.v1.Chaind.ListBlocks:output_type -> chaind.v1.Block
+	7,  // 12: chaind.v1.Chaind.ListValidators:output_type -> chaind.v1.Validator
+	8,  // 13: chaind.v1.Chaind.ListValidatorBalances:output_type -> chaind.v1.ValidatorBalance
+	9,  // 14: chaind.v1.Chaind.ListAttestations:output_type -> chaind.v1.Attestation
+	10, // 15: chaind.v1.Chaind.ListProposerDuties:output_type -> chaind.v1.ProposerDuty
+	11, // 16: chaind.v1.Chaind.ListAttesterDuties:output_type -> chaind.v1.AttesterDuty
+	12, // 17: chaind.v1.Chaind.ListBlockSummaries:output_type -> chaind.v1.BlockSummary
+	13, // 18: chaind.v1.Chaind.ListValidatorEpochSummaries:output_type -> chaind.v1.ValidatorEpochSummary
+	10, // [10:19] is the sub-list for method output_type
+	1,  // [1:10] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_proto_chaind_v1_chaind_proto_init() }
+func file_proto_chaind_v1_chaind_proto_init() {
+	if File_proto_chaind_v1_chaind_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_proto_chaind_v1_chaind_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetBlockRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SlotRangeRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListValidatorsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListValidatorBalancesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListAttesterDutiesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListValidatorEpochSummariesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Block); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Validator); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidatorBalance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Attestation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ProposerDuty); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*AttesterDuty); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidatorEpochSummary); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BlockAttestations); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_proto_chaind_v1_chaind_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FinalityUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_proto_chaind_v1_chaind_proto_msgTypes[6].OneofWrappers = []interface{}{}
+	file_proto_chaind_v1_chaind_proto_msgTypes[9].OneofWrappers = []interface{}{}
+	file_proto_chaind_v1_chaind_proto_msgTypes[13].OneofWrappers = []interface{}{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_chaind_v1_chaind_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_chaind_v1_chaind_proto_goTypes,
+		DependencyIndexes: file_proto_chaind_v1_chaind_proto_depIdxs,
+		MessageInfos:      file_proto_chaind_v1_chaind_proto_msgTypes,
+	}.Build()
+	File_proto_chaind_v1_chaind_proto = out.File
+	file_proto_chaind_v1_chaind_proto_rawDesc = nil
+	file_proto_chaind_v1_chaind_proto_goTypes = nil
+	file_proto_chaind_v1_chaind_proto_depIdxs = nil
+}
@@ -0,0 +1,628 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: proto/chaind/v1/chaind.proto
+
+package chaindv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ChaindClient is the client API for Chaind service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ChaindClient interface {
+	// GetBlock obtains a single block by its root.
+	GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error)
+	// ListBlocks streams the blocks in a slot range.
+	ListBlocks(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListBlocksClient, error)
+	// ListValidators streams validators, optionally restricted to a set of indices.
+	ListValidators(ctx context.Context, in *ListValidatorsRequest, opts ...grpc.CallOption) (Chaind_ListValidatorsClient, error)
+	// ListValidatorBalances streams validator balances in an epoch range.
+	ListValidatorBalances(ctx context.Context, in *ListValidatorBalancesRequest, opts ...grpc.CallOption) (Chaind_ListValidatorBalancesClient, error)
+	// ListAttestations streams the attestations for a slot range.
+	ListAttestations(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListAttestationsClient, error)
+	// ListProposerDuties streams the proposer duties in a slot range.
+	ListProposerDuties(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListProposerDutiesClient, error)
+	// ListAttesterDuties streams the attester duties in a slot range for a set of validators.
+	ListAttesterDuties(ctx context.Context, in *ListAttesterDutiesRequest, opts ...grpc.CallOption) (Chaind_ListAttesterDutiesClient, error)
+	// ListBlockSummaries streams the block summaries in a slot range.
+	ListBlockSummaries(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListBlockSummariesClient, error)
+	// ListValidatorEpochSummaries streams validator epoch summaries in an epoch range.
+	ListValidatorEpochSummaries(ctx context.Context, in *ListValidatorEpochSummariesRequest, opts ...grpc.CallOption) (Chaind_ListValidatorEpochSummariesClient, error)
+}
+
+type chaindClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewChaindClient(cc grpc.ClientConnInterface) ChaindClient {
+	return &chaindClient{cc}
+}
+
+func (c *chaindClient) GetBlock(ctx context.Context, in *GetBlockRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, "/chaind.v1.Chaind/GetBlock", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chaindClient) ListBlocks(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListBlocksClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[0], "/chaind.v1.Chaind/ListBlocks", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListBlocksClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListBlocksClient interface {
+	Recv() (*Block, error)
+	grpc.ClientStream
+}
+
+type chaindListBlocksClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListBlocksClient) Recv() (*Block, error) {
+	m := new(Block)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chaindClient) ListValidators(ctx context.Context, in *ListValidatorsRequest, opts ...grpc.CallOption) (Chaind_ListValidatorsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[1], "/chaind.v1.Chaind/ListValidators", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListValidatorsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListValidatorsClient interface {
+	Recv() (*Validator, error)
+	grpc.ClientStream
+}
+
+type chaindListValidatorsClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListValidatorsClient) Recv() (*Validator, error) {
+	m := new(Validator)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chaindClient) ListValidatorBalances(ctx context.Context, in *ListValidatorBalancesRequest, opts ...grpc.CallOption) (Chaind_ListValidatorBalancesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[2], "/chaind.v1.Chaind/ListValidatorBalances", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListValidatorBalancesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListValidatorBalancesClient interface {
+	Recv() (*ValidatorBalance, error)
+	grpc.ClientStream
+}
+
+type chaindListValidatorBalancesClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListValidatorBalancesClient) Recv() (*ValidatorBalance, error) {
+	m := new(ValidatorBalance)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chaindClient) ListAttestations(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListAttestationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[3], "/chaind.v1.Chaind/ListAttestations", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListAttestationsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListAttestationsClient interface {
+	Recv() (*Attestation, error)
+	grpc.ClientStream
+}
+
+type chaindListAttestationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListAttestationsClient) Recv() (*Attestation, error) {
+	m := new(Attestation)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chaindClient) ListProposerDuties(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListProposerDutiesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[4], "/chaind.v1.Chaind/ListProposerDuties", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListProposerDutiesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListProposerDutiesClient interface {
+	Recv() (*ProposerDuty, error)
+	grpc.ClientStream
+}
+
+type chaindListProposerDutiesClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListProposerDutiesClient) Recv() (*ProposerDuty, error) {
+	m := new(ProposerDuty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chaindClient) ListAttesterDuties(ctx context.Context, in *ListAttesterDutiesRequest, opts ...grpc.CallOption) (Chaind_ListAttesterDutiesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[5], "/chaind.v1.Chaind/ListAttesterDuties", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListAttesterDutiesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListAttesterDutiesClient interface {
+	Recv() (*AttesterDuty, error)
+	grpc.ClientStream
+}
+
+type chaindListAttesterDutiesClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListAttesterDutiesClient) Recv() (*AttesterDuty, error) {
+	m := new(AttesterDuty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chaindClient) ListBlockSummaries(ctx context.Context, in *SlotRangeRequest, opts ...grpc.CallOption) (Chaind_ListBlockSummariesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[6], "/chaind.v1.Chaind/ListBlockSummaries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListBlockSummariesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListBlockSummariesClient interface {
+	Recv() (*BlockSummary, error)
+	grpc.ClientStream
+}
+
+type chaindListBlockSummariesClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListBlockSummariesClient) Recv() (*BlockSummary, error) {
+	m := new(BlockSummary)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *chaindClient) ListValidatorEpochSummaries(ctx context.Context, in *ListValidatorEpochSummariesRequest, opts ...grpc.CallOption) (Chaind_ListValidatorEpochSummariesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Chaind_ServiceDesc.Streams[7], "/chaind.v1.Chaind/ListValidatorEpochSummaries", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &chaindListValidatorEpochSummariesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Chaind_ListValidatorEpochSummariesClient interface {
+	Recv() (*ValidatorEpochSummary, error)
+	grpc.ClientStream
+}
+
+type chaindListValidatorEpochSummariesClient struct {
+	grpc.ClientStream
+}
+
+func (x *chaindListValidatorEpochSummariesClient) Recv() (*ValidatorEpochSummary, error) {
+	m := new(ValidatorEpochSummary)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ChaindServer is the server API for Chaind service.
+// All implementations must embed UnimplementedChaindServer
+// for forward compatibility
+type ChaindServer interface {
+	// GetBlock obtains a single block by its root.
+	GetBlock(context.Context, *GetBlockRequest) (*Block, error)
+	// ListBlocks streams the blocks in a slot range.
+	ListBlocks(*SlotRangeRequest, Chaind_ListBlocksServer) error
+	// ListValidators streams validators, optionally restricted to a set of indices.
+	ListValidators(*ListValidatorsRequest, Chaind_ListValidatorsServer) error
+	// ListValidatorBalances streams validator balances in an epoch range.
+	ListValidatorBalances(*ListValidatorBalancesRequest, Chaind_ListValidatorBalancesServer) error
+	// ListAttestations streams the attestations for a slot range.
+	ListAttestations(*SlotRangeRequest, Chaind_ListAttestationsServer) error
+	// ListProposerDuties streams the proposer duties in a slot range.
+	ListProposerDuties(*SlotRangeRequest, Chaind_ListProposerDutiesServer) error
+	// ListAttesterDuties streams the attester duties in a slot range for a set of validators.
+	ListAttesterDuties(*ListAttesterDutiesRequest, Chaind_ListAttesterDutiesServer) error
+	// ListBlockSummaries streams the block summaries in a slot range.
+	ListBlockSummaries(*SlotRangeRequest, Chaind_ListBlockSummariesServer) error
+	// ListValidatorEpochSummaries streams validator epoch summaries in an epoch range.
+	ListValidatorEpochSummaries(*ListValidatorEpochSummariesRequest, Chaind_ListValidatorEpochSummariesServer) error
+	mustEmbedUnimplementedChaindServer()
+}
+
+// UnimplementedChaindServer must be embedded to have forward compatible implementations.
+type UnimplementedChaindServer struct {
+}
+
+func (UnimplementedChaindServer) GetBlock(context.Context, *GetBlockRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedChaindServer) ListBlocks(*SlotRangeRequest, Chaind_ListBlocksServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListBlocks not implemented")
+}
+func (UnimplementedChaindServer) ListValidators(*ListValidatorsRequest, Chaind_ListValidatorsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListValidators not implemented")
+}
+func (UnimplementedChaindServer) ListValidatorBalances(*ListValidatorBalancesRequest, Chaind_ListValidatorBalancesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListValidatorBalances not implemented")
+}
+func (UnimplementedChaindServer) ListAttestations(*SlotRangeRequest, Chaind_ListAttestationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListAttestations not implemented")
+}
+func (UnimplementedChaindServer) ListProposerDuties(*SlotRangeRequest, Chaind_ListProposerDutiesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListProposerDuties not implemented")
+}
+func (UnimplementedChaindServer) ListAttesterDuties(*ListAttesterDutiesRequest, Chaind_ListAttesterDutiesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListAttesterDuties not implemented")
+}
+func (UnimplementedChaindServer) ListBlockSummaries(*SlotRangeRequest, Chaind_ListBlockSummariesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListBlockSummaries not implemented")
+}
+func (UnimplementedChaindServer) ListValidatorEpochSummaries(*ListValidatorEpochSummariesRequest, Chaind_ListValidatorEpochSummariesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListValidatorEpochSummaries not implemented")
+}
+func (UnimplementedChaindServer) mustEmbedUnimplementedChaindServer() {}
+
+// UnsafeChaindServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ChaindServer will
+// result in compilation errors.
+type UnsafeChaindServer interface {
+	mustEmbedUnimplementedChaindServer()
+}
+
+func RegisterChaindServer(s grpc.ServiceRegistrar, srv ChaindServer) {
+	s.RegisterService(&Chaind_ServiceDesc, srv)
+}
+
+func _Chaind_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChaindServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chaind.v1.Chaind/GetBlock",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChaindServer).GetBlock(ctx, req.(*GetBlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chaind_ListBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SlotRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListBlocks(m, &chaindListBlocksServer{stream})
+}
+
+type Chaind_ListBlocksServer interface {
+	Send(*Block) error
+	grpc.ServerStream
+}
+
+type chaindListBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListBlocksServer) Send(m *Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chaind_ListValidators_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListValidatorsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListValidators(m, &chaindListValidatorsServer{stream})
+}
+
+type Chaind_ListValidatorsServer interface {
+	Send(*Validator) error
+	grpc.ServerStream
+}
+
+type chaindListValidatorsServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListValidatorsServer) Send(m *Validator) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chaind_ListValidatorBalances_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListValidatorBalancesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListValidatorBalances(m, &chaindListValidatorBalancesServer{stream})
+}
+
+type Chaind_ListValidatorBalancesServer interface {
+	Send(*ValidatorBalance) error
+	grpc.ServerStream
+}
+
+type chaindListValidatorBalancesServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListValidatorBalancesServer) Send(m *ValidatorBalance) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chaind_ListAttestations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SlotRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListAttestations(m, &chaindListAttestationsServer{stream})
+}
+
+type Chaind_ListAttestationsServer interface {
+	Send(*Attestation) error
+	grpc.ServerStream
+}
+
+type chaindListAttestationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListAttestationsServer) Send(m *Attestation) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chaind_ListProposerDuties_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SlotRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListProposerDuties(m, &chaindListProposerDutiesServer{stream})
+}
+
+type Chaind_ListProposerDutiesServer interface {
+	Send(*ProposerDuty) error
+	grpc.ServerStream
+}
+
+type chaindListProposerDutiesServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListProposerDutiesServer) Send(m *ProposerDuty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chaind_ListAttesterDuties_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListAttesterDutiesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListAttesterDuties(m, &chaindListAttesterDutiesServer{stream})
+}
+
+type Chaind_ListAttesterDutiesServer interface {
+	Send(*AttesterDuty) error
+	grpc.ServerStream
+}
+
+type chaindListAttesterDutiesServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListAttesterDutiesServer) Send(m *AttesterDuty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chaind_ListBlockSummaries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SlotRangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListBlockSummaries(m, &chaindListBlockSummariesServer{stream})
+}
+
+type Chaind_ListBlockSummariesServer interface {
+	Send(*BlockSummary) error
+	grpc.ServerStream
+}
+
+type chaindListBlockSummariesServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListBlockSummariesServer) Send(m *BlockSummary) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Chaind_ListValidatorEpochSummaries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListValidatorEpochSummariesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChaindServer).ListValidatorEpochSummaries(m, &chaindListValidatorEpochSummariesServer{stream})
+}
+
+type Chaind_ListValidatorEpochSummariesServer interface {
+	Send(*ValidatorEpochSummary) error
+	grpc.ServerStream
+}
+
+type chaindListValidatorEpochSummariesServer struct {
+	grpc.ServerStream
+}
+
+func (x *chaindListValidatorEpochSummariesServer) Send(m *ValidatorEpochSummary) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Chaind_ServiceDesc is the grpc.ServiceDesc for Chaind service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Chaind_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "chaind.v1.Chaind",
+	HandlerType: (*ChaindServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBlock",
+			Handler:    _Chaind_GetBlock_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListBlocks",
+			Handler:       _Chaind_ListBlocks_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListValidators",
+			Handler:       _Chaind_ListValidators_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListValidatorBalances",
+			Handler:       _Chaind_ListValidatorBalances_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListAttestations",
+			Handler:       _Chaind_ListAttestations_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListProposerDuties",
+			Handler:       _Chaind_ListProposerDuties_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListAttesterDuties",
+			Handler:       _Chaind_ListAttesterDuties_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListBlockSummaries",
+			Handler:       _Chaind_ListBlockSummaries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListValidatorEpochSummaries",
+			Handler:       _Chaind_ListValidatorEpochSummaries_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/chaind/v1/chaind.proto",
+}
@@ -0,0 +1,169 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	standardapi "github.com/wealdtech/chaind/services/api/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// runQuery runs a read-only query against the chain database, printing the result as JSON.
+func runQuery(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("query requires a type: block or validator")
+	}
+
+	var query func(context.Context, *postgresqlchaindb.Service) (interface{}, error)
+	switch args[0] {
+	case "block":
+		if len(args) != 2 {
+			return errors.New("usage: query block <slot>")
+		}
+		slot, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid slot")
+		}
+		query = func(ctx context.Context, chainDB *postgresqlchaindb.Service) (interface{}, error) {
+			return queryBlock(ctx, chainDB, phase0.Slot(slot))
+		}
+	case "validator":
+		if len(args) != 2 {
+			return errors.New("usage: query validator <index> [--epochs <epochs>]")
+		}
+		index, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid validator index")
+		}
+		epochs := viper.GetUint64("epochs")
+		if epochs == 0 {
+			return errors.New("epochs must be greater than 0")
+		}
+		query = func(ctx context.Context, chainDB *postgresqlchaindb.Service) (interface{}, error) {
+			return queryValidator(ctx, chainDB, phase0.ValidatorIndex(index), epochs)
+		}
+	default:
+		return fmt.Errorf("unknown query type %q", args[0])
+	}
+
+	chainDB, err := startReadOnlyDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := query(ctx, chainDB)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal result")
+	}
+	fmt.Fprintf(os.Stdout, "%s\n", string(data))
+
+	return nil
+}
+
+// queryBlock obtains the blocks at the given slot.
+// There can be more than one block at a slot if the chain has forked.
+func queryBlock(ctx context.Context, chainDB *postgresqlchaindb.Service, slot phase0.Slot) (interface{}, error) {
+	blocks, err := chainDB.BlocksBySlot(ctx, slot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("no block found at slot %d", slot)
+	}
+
+	labels, err := chainDB.AddressLabels(ctx, standardapi.BlockAddresses(blocks))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain address labels")
+	}
+
+	res := make([]interface{}, len(blocks))
+	for i := range blocks {
+		res[i] = standardapi.BlockJSON(blocks[i], labels)
+	}
+
+	return res, nil
+}
+
+type validatorQueryJSON struct {
+	Validator   interface{}   `json:"validator"`
+	Performance interface{}   `json:"performance,omitempty"`
+	Summaries   []interface{} `json:"summaries"`
+}
+
+// queryValidator obtains a validator, along with its summaries and performance
+// over the latest summarized epochs.
+func queryValidator(ctx context.Context, chainDB *postgresqlchaindb.Service, index phase0.ValidatorIndex, epochs uint64) (interface{}, error) {
+	validators, err := chainDB.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{index})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator")
+	}
+	validator, exists := validators[index]
+	if !exists {
+		return nil, fmt.Errorf("no validator found with index %d", index)
+	}
+
+	labels, err := chainDB.AddressLabels(ctx, standardapi.ValidatorAddresses([]*chaindb.Validator{validator}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain address labels")
+	}
+
+	res := &validatorQueryJSON{
+		Validator: standardapi.ValidatorJSON(validator, labels),
+		Summaries: make([]interface{}, 0),
+	}
+
+	summaries, err := chainDB.ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+		Limit:            uint32(epochs),
+		Order:            chaindb.OrderLatest,
+		ValidatorIndices: &[]phase0.ValidatorIndex{index},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator summaries")
+	}
+	if len(summaries) == 0 {
+		return res, nil
+	}
+	for _, summary := range summaries {
+		res.Summaries = append(res.Summaries, standardapi.ValidatorEpochSummaryJSON(summary))
+	}
+
+	// Summaries are in epoch order, so the range covers the first to the last.
+	performances, err := chainDB.ValidatorPerformanceForEpochRange(ctx,
+		[]phase0.ValidatorIndex{index},
+		summaries[0].Epoch,
+		summaries[len(summaries)-1].Epoch+1,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator performance")
+	}
+	if len(performances) > 0 {
+		res.Performance = standardapi.ValidatorPerformanceJSON(performances[0])
+	}
+
+	return res, nil
+}
@@ -0,0 +1,201 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/admin"
+	standardbeaconcommittees "github.com/wealdtech/chaind/services/beaconcommittees/standard"
+	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	nullmetrics "github.com/wealdtech/chaind/services/metrics/null"
+	standardproposerduties "github.com/wealdtech/chaind/services/proposerduties/standard"
+	standardvalidators "github.com/wealdtech/chaind/services/validators/standard"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// runReindex deletes and re-ingests a range of data for a single service.
+func runReindex(ctx context.Context) error {
+	service := viper.GetString("service")
+	if service == "" {
+		return errors.New("reindex requires a service: blocks, beaconcommittees, proposerduties or validators.balances")
+	}
+	fromEpoch := viper.GetInt64("from-epoch")
+	toEpoch := viper.GetInt64("to-epoch")
+	fromSlot := viper.GetInt64("from-slot")
+	toSlot := viper.GetInt64("to-slot")
+	byEpoch := fromEpoch >= 0 || toEpoch >= 0
+	bySlot := fromSlot >= 0 || toSlot >= 0
+	switch {
+	case byEpoch && bySlot:
+		return errors.New("cannot reindex by both epoch and slot")
+	case byEpoch && (fromEpoch < 0 || toEpoch < 0):
+		return errors.New("reindex requires both from-epoch and to-epoch")
+	case bySlot && (fromSlot < 0 || toSlot < 0):
+		return errors.New("reindex requires both from-slot and to-slot")
+	case !byEpoch && !bySlot:
+		return errors.New("reindex requires either from-epoch and to-epoch, or from-slot and to-slot")
+	case byEpoch && toEpoch < fromEpoch:
+		return errors.New("to-epoch cannot be before from-epoch")
+	case bySlot && toSlot < fromSlot:
+		return errors.New("to-slot cannot be before from-slot")
+	}
+
+	chainDB, err := startDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	monitor := &nullmetrics.Service{}
+	eth2Client, err := fetchEth2Client(ctx, monitor)
+	if err != nil {
+		return errors.Wrap(err, "failed to start Ethereum 2 client service")
+	}
+	chainTime, err := eth2ClientChainTime(ctx, eth2Client)
+	if err != nil {
+		return err
+	}
+
+	if service == "blocks" {
+		reindexer, err := reindexBlocksService(ctx, eth2Client, chainDB, chainTime)
+		if err != nil {
+			return err
+		}
+		if byEpoch {
+			fromSlot = int64(chainTime.FirstSlotOfEpoch(phase0.Epoch(fromEpoch)))
+			toSlot = int64(chainTime.FirstSlotOfEpoch(phase0.Epoch(toEpoch+1))) - 1
+		}
+		return reindexer.ReindexSlots(ctx, phase0.Slot(fromSlot), phase0.Slot(toSlot))
+	}
+
+	if bySlot {
+		return fmt.Errorf("service %q reindexes by epoch; use from-epoch and to-epoch", service)
+	}
+	reindexer, err := reindexEpochsService(ctx, service, eth2Client, chainDB, chainTime)
+	if err != nil {
+		return err
+	}
+	return reindexer.ReindexEpochs(ctx, phase0.Epoch(fromEpoch), phase0.Epoch(toEpoch))
+}
+
+// eth2ClientChainTime creates a chain time service from the beacon node.
+func eth2ClientChainTime(ctx context.Context, eth2Client eth2client.Service) (chaintime.Service, error) {
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisTimeProvider(eth2Client.(eth2client.GenesisTimeProvider)),
+		standardchaintime.WithSpecProvider(eth2Client.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(eth2Client.(eth2client.ForkScheduleProvider)),
+		standardchaintime.WithSlotDuration(viper.GetDuration("chaintime.slot-duration")),
+		standardchaintime.WithSlotsPerEpoch(viper.GetUint64("chaintime.slots-per-epoch")),
+		standardchaintime.WithEpochsPerSyncCommitteePeriod(viper.GetUint64("chaintime.epochs-per-sync-committee-period")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain time service")
+	}
+
+	return chainTime, nil
+}
+
+// reindexBlocksService creates a passive blocks service for reindexing.
+func reindexBlocksService(ctx context.Context,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) (
+	*standardblocks.Service,
+	error,
+) {
+	s, err := standardblocks.New(ctx,
+		standardblocks.WithLogLevel(util.LogLevel("blocks")),
+		standardblocks.WithETH2Client(eth2Client),
+		standardblocks.WithChainTime(chainTime),
+		standardblocks.WithChainDB(chainDB),
+		standardblocks.WithActivitySem(semaphore.NewWeighted(1)),
+		standardblocks.WithPassive(true),
+		standardblocks.WithAttestations(viper.GetBool("blocks.attestations.enable")),
+		standardblocks.WithProposerSlashings(viper.GetBool("blocks.proposer-slashings.enable")),
+		standardblocks.WithAttesterSlashings(viper.GetBool("blocks.attester-slashings.enable")),
+		standardblocks.WithDeposits(viper.GetBool("blocks.deposits.enable")),
+		standardblocks.WithVoluntaryExits(viper.GetBool("blocks.voluntary-exits.enable")),
+		standardblocks.WithSyncAggregates(viper.GetBool("blocks.sync-aggregates.enable")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create blocks service")
+	}
+
+	return s, nil
+}
+
+// reindexEpochsService creates a passive epoch-based service for reindexing.
+func reindexEpochsService(ctx context.Context,
+	service string,
+	eth2Client eth2client.Service,
+	chainDB chaindb.Service,
+	chainTime chaintime.Service,
+) (
+	admin.EpochReindexer,
+	error,
+) {
+	var reindexer admin.EpochReindexer
+	var err error
+	switch service {
+	case "beaconcommittees":
+		reindexer, err = standardbeaconcommittees.New(ctx,
+			standardbeaconcommittees.WithLogLevel(util.LogLevel("beacon-committees")),
+			standardbeaconcommittees.WithETH2Client(eth2Client),
+			standardbeaconcommittees.WithCatchupClients(fetchCatchupClients(ctx)),
+			standardbeaconcommittees.WithRetryPolicy(retryPolicy()),
+			standardbeaconcommittees.WithChainTime(chainTime),
+			standardbeaconcommittees.WithChainDB(chainDB),
+			standardbeaconcommittees.WithPassive(true),
+		)
+	case "proposerduties":
+		reindexer, err = standardproposerduties.New(ctx,
+			standardproposerduties.WithLogLevel(util.LogLevel("proposer-duties")),
+			standardproposerduties.WithETH2Client(eth2Client),
+			standardproposerduties.WithCatchupClients(fetchCatchupClients(ctx)),
+			standardproposerduties.WithRetryPolicy(retryPolicy()),
+			standardproposerduties.WithChainTime(chainTime),
+			standardproposerduties.WithChainDB(chainDB),
+			standardproposerduties.WithPassive(true),
+		)
+	case "validators.balances":
+		reindexer, err = standardvalidators.New(ctx,
+			standardvalidators.WithLogLevel(util.LogLevel("validators")),
+			standardvalidators.WithETH2Client(eth2Client),
+			standardvalidators.WithCatchupClients(fetchCatchupClients(ctx)),
+			standardvalidators.WithRetryPolicy(retryPolicy()),
+			standardvalidators.WithChainTime(chainTime),
+			standardvalidators.WithChainDB(chainDB),
+			standardvalidators.WithBalances(true),
+			standardvalidators.WithBatchSize(viper.GetInt("chaindb.batch-size")),
+			standardvalidators.WithPassive(true),
+		)
+	default:
+		return nil, fmt.Errorf("unknown service %q", service)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s service", service)
+	}
+
+	return reindexer, nil
+}
@@ -0,0 +1,86 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/util"
+)
+
+// reloadMu ensures that only one reload of the configuration runs at a time.
+var reloadMu sync.Mutex
+
+// watchConfig reloads the configuration that can be changed at runtime when
+// chaind receives SIGHUP, or when the configuration file changes.
+func watchConfig(ctx context.Context) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				signal.Stop(sigCh)
+				return
+			case <-sigCh:
+				log.Info().Msg("Received SIGHUP; reloading configuration")
+				if err := reloadConfig(true); err != nil {
+					log.Warn().Err(err).Msg("Failed to reload configuration")
+				}
+			}
+		}
+	}()
+
+	if viper.ConfigFileUsed() == "" {
+		log.Trace().Msg("No configuration file; not watching for changes")
+		return
+	}
+	viper.OnConfigChange(func(_ fsnotify.Event) {
+		log.Info().Str("file", viper.ConfigFileUsed()).Msg("Configuration file changed; reloading configuration")
+		// The file has already been re-read by viper.
+		if err := reloadConfig(false); err != nil {
+			log.Warn().Err(err).Msg("Failed to reload configuration")
+		}
+	})
+	viper.WatchConfig()
+}
+
+// reloadConfig applies the configuration that can be changed without a restart:
+// log levels, the retry policy and the beacon node rate limit.  Other configuration is only read on start.
+func reloadConfig(readFile bool) error {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	if readFile {
+		if err := viper.ReadInConfig(); err != nil {
+			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
+				return errors.Wrap(err, "failed to read configuration file")
+			}
+		}
+	}
+
+	util.ReloadLogLevels()
+	retryPolicy()
+	rateLimiter()
+	log.Info().Msg("Configuration reloaded")
+
+	return nil
+}
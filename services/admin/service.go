@@ -0,0 +1,37 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Service is an admin service.
+type Service interface{}
+
+// SlotReindexer is implemented by services that can re-process a range of slots on request.
+type SlotReindexer interface {
+	// ReindexSlots re-processes the slots from start to end inclusive.
+	// This blocks until any other activity of the service has completed.
+	ReindexSlots(ctx context.Context, start phase0.Slot, end phase0.Slot) error
+}
+
+// EpochReindexer is implemented by services that can re-process a range of epochs on request.
+type EpochReindexer interface {
+	// ReindexEpochs re-processes the epochs from start to end inclusive.
+	// This blocks until any other activity of the service has completed.
+	ReindexEpochs(ctx context.Context, start phase0.Epoch, end phase0.Epoch) error
+}
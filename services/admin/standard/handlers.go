@@ -0,0 +1,152 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+)
+
+// reindexRequest is the body of a reindex request.
+type reindexRequest struct {
+	Service string `json:"service"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// authenticated wraps a handler, requiring the request to supply the service's
+// bearer token or credentials for the admin role.
+func (s *Service) authenticated(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			log.Warn().Str("remote_addr", r.RemoteAddr).Msg("Unauthorized admin request")
+			writeError(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		fn(w, r)
+	}
+}
+
+// authorized returns true if the request supplies the service's bearer token
+// or credentials for the admin role.
+func (s *Service) authorized(r *http.Request) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if len(s.token) > 0 && subtle.ConstantTimeCompare([]byte(token), s.token) == 1 {
+		return true
+	}
+
+	return s.authenticator.Role(r) == util.RoleAdmin
+}
+
+// handleReindex handles a request to reindex a range of slots or epochs for a service.
+// The reindex runs in the background; the request returns once it has started.
+func (s *Service) handleReindex(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req reindexRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	start, err := strconv.ParseUint(req.Start, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid start")
+		return
+	}
+	end, err := strconv.ParseUint(req.End, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid end")
+		return
+	}
+	if start > end {
+		writeError(w, http.StatusBadRequest, "start must not be after end")
+		return
+	}
+
+	var reindex func(ctx context.Context) error
+	if reindexer, exists := s.slotReindexers[req.Service]; exists {
+		reindex = func(ctx context.Context) error {
+			return reindexer.ReindexSlots(ctx, phase0.Slot(start), phase0.Slot(end))
+		}
+	} else if reindexer, exists := s.epochReindexers[req.Service]; exists {
+		reindex = func(ctx context.Context) error {
+			return reindexer.ReindexEpochs(ctx, phase0.Epoch(start), phase0.Epoch(end))
+		}
+	} else {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("service %q does not support reindexing", req.Service))
+		return
+	}
+
+	s.activeMu.Lock()
+	if s.active[req.Service] {
+		s.activeMu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("service %q is already reindexing", req.Service))
+		return
+	}
+	s.active[req.Service] = true
+	s.activeMu.Unlock()
+
+	// Reindexing is repair work, so runs behind anything that keeps the
+	// database up to date with the chain.
+	if err := s.scheduler.Submit(s.ctx, "reindex "+req.Service, scheduler.PriorityRepair, func(ctx context.Context) {
+		log := log.With().Str("service", req.Service).Uint64("start", start).Uint64("end", end).Logger()
+		log.Info().Msg("Reindexing")
+		if err := reindex(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to reindex")
+		} else {
+			log.Info().Msg("Reindexed")
+		}
+		s.activeMu.Lock()
+		delete(s.active, req.Service)
+		s.activeMu.Unlock()
+	}); err != nil {
+		s.activeMu.Lock()
+		delete(s.active, req.Service)
+		s.activeMu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("failed to schedule reindex: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(&req); err != nil {
+		log.Debug().Err(err).Msg("Failed to write response")
+	}
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(&errorResponse{Code: status, Message: message}); err != nil {
+		log.Debug().Err(err).Msg("Failed to write error response")
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/admin"
+	mockscheduler "github.com/wealdtech/chaind/services/scheduler/mock"
+	"github.com/wealdtech/chaind/util"
+)
+
+type epochReindexer struct {
+	ch chan phase0.Epoch
+}
+
+func (r *epochReindexer) ReindexEpochs(_ context.Context, start phase0.Epoch, end phase0.Epoch) error {
+	for epoch := start; epoch <= end; epoch++ {
+		r.ch <- epoch
+	}
+	return nil
+}
+
+func TestReindex(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reindexer := &epochReindexer{ch: make(chan phase0.Epoch, 16)}
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithListenAddress("127.0.0.1:0"),
+		WithToken("secret"),
+		WithEpochReindexers(map[string]admin.EpochReindexer{"proposerduties": reindexer}),
+		WithScheduler(mockscheduler.New()),
+	)
+	require.NoError(t, err)
+	handler := s.authenticated(s.handleReindex)
+
+	tests := []struct {
+		name   string
+		method string
+		token  string
+		body   string
+		status int
+	}{
+		{
+			name:   "Unauthorized",
+			method: http.MethodPost,
+			token:  "wrong",
+			body:   `{"service":"proposerduties","start":"1","end":"2"}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "MethodNotAllowed",
+			method: http.MethodGet,
+			token:  "secret",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "InvalidBody",
+			method: http.MethodPost,
+			token:  "secret",
+			body:   `{`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "StartAfterEnd",
+			method: http.MethodPost,
+			token:  "secret",
+			body:   `{"service":"proposerduties","start":"3","end":"2"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "UnknownService",
+			method: http.MethodPost,
+			token:  "secret",
+			body:   `{"service":"blocks","start":"1","end":"2"}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Good",
+			method: http.MethodPost,
+			token:  "secret",
+			body:   `{"service":"proposerduties","start":"1","end":"2"}`,
+			status: http.StatusAccepted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, "/v1/reindex", strings.NewReader(test.body))
+			req.Header.Set("Authorization", "Bearer "+test.token)
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			require.Equal(t, test.status, rec.Code)
+		})
+	}
+
+	for _, expected := range []phase0.Epoch{1, 2} {
+		select {
+		case epoch := <-reindexer.ch:
+			require.Equal(t, expected, epoch)
+		case <-time.After(time.Second):
+			require.Fail(t, "reindex did not run")
+		}
+	}
+}
+
+func TestReindexAuthenticator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithListenAddress("127.0.0.1:0"),
+		WithAuthenticator(util.NewAuthenticator([]string{"readkey"}, []string{"adminkey"}, "")),
+		WithEpochReindexers(map[string]admin.EpochReindexer{"proposerduties": &epochReindexer{ch: make(chan phase0.Epoch, 16)}}),
+		WithScheduler(mockscheduler.New()),
+	)
+	require.NoError(t, err)
+	handler := s.authenticated(s.handleReindex)
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{
+			name:   "Missing",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "ReadRole",
+			token:  "readkey",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "AdminRole",
+			token:  "adminkey",
+			status: http.StatusAccepted,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/reindex", strings.NewReader(`{"service":"proposerduties","start":"1","end":"2"}`))
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			require.Equal(t, test.status, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/admin"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	listenAddress   string
+	authenticator   *util.Authenticator
+	token           string
+	slotReindexers  map[string]admin.SlotReindexer
+	epochReindexers map[string]admin.EpochReindexer
+	scheduler       scheduler.Service
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithListenAddress sets the listen address for the service.
+func WithListenAddress(listenAddress string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.listenAddress = listenAddress
+	})
+}
+
+// WithAuthenticator sets the authenticator for requests to the server.
+// Requests with credentials for the admin role are accepted in addition to
+// those with the token.
+func WithAuthenticator(authenticator *util.Authenticator) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.authenticator = authenticator
+	})
+}
+
+// WithToken sets the bearer token required to access the service.
+func WithToken(token string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.token = token
+	})
+}
+
+// WithSlotReindexers sets the services that can reindex slots, keyed by name.
+func WithSlotReindexers(reindexers map[string]admin.SlotReindexer) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotReindexers = reindexers
+	})
+}
+
+// WithEpochReindexers sets the services that can reindex epochs, keyed by name.
+func WithEpochReindexers(reindexers map[string]admin.EpochReindexer) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.epochReindexers = reindexers
+	})
+}
+
+// WithScheduler sets the scheduler for jobs run by this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:        zerolog.GlobalLevel(),
+		slotReindexers:  make(map[string]admin.SlotReindexer),
+		epochReindexers: make(map[string]admin.EpochReindexer),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.listenAddress == "" {
+		return nil, errors.New("no listen address specified")
+	}
+	if parameters.token == "" && parameters.authenticator == nil {
+		return nil, errors.New("no token or authenticator specified")
+	}
+	if parameters.slotReindexers == nil {
+		return nil, errors.New("no slot reindexers specified")
+	}
+	if parameters.epochReindexers == nil {
+		return nil, errors.New("no epoch reindexers specified")
+	}
+
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+
+	return &parameters, nil
+}
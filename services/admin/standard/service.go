@@ -0,0 +1,98 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/admin"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is an admin service, allowing operators to request reindexing
+// of data by individual services.
+type Service struct {
+	// ctx is the service context, used for reindexing that outlives the request.
+	ctx             context.Context
+	token           []byte
+	authenticator   *util.Authenticator
+	slotReindexers  map[string]admin.SlotReindexer
+	epochReindexers map[string]admin.EpochReindexer
+	scheduler       scheduler.Service
+	server          *http.Server
+
+	activeMu sync.Mutex
+	active   map[string]bool
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "admin").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	s := &Service{
+		ctx:             ctx,
+		token:           []byte(parameters.token),
+		authenticator:   parameters.authenticator,
+		slotReindexers:  parameters.slotReindexers,
+		epochReindexers: parameters.epochReindexers,
+		scheduler:       parameters.scheduler,
+		active:          make(map[string]bool),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/reindex", s.authenticated(s.handleReindex))
+	s.server = &http.Server{
+		Addr:              parameters.listenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("listen_address", parameters.listenAddress).Msg("Starting admin server")
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run admin server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; shutting down admin server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down admin server")
+		}
+	}()
+
+	return s, nil
+}
@@ -0,0 +1,101 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/admin/standard"
+	mockscheduler "github.com/wealdtech/chaind/services/scheduler/mock"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ListenAddressMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithToken("secret"),
+			},
+			err: "problem with parameters: no listen address specified",
+		},
+		{
+			name: "TokenMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+			},
+			err: "problem with parameters: no token or authenticator specified",
+		},
+		{
+			name: "SlotReindexersNil",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+				standard.WithToken("secret"),
+				standard.WithSlotReindexers(nil),
+			},
+			err: "problem with parameters: no slot reindexers specified",
+		},
+		{
+			name: "EpochReindexersNil",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+				standard.WithToken("secret"),
+				standard.WithEpochReindexers(nil),
+			},
+			err: "problem with parameters: no epoch reindexers specified",
+		},
+		{
+			name: "SchedulerMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+				standard.WithToken("secret"),
+			},
+			err: "problem with parameters: no scheduler specified",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithListenAddress("127.0.0.1:0"),
+				standard.WithToken("secret"),
+				standard.WithScheduler(mockscheduler.New()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,421 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const statesPrefix = "/eth/v1/beacon/states/"
+
+var farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// statusFilters are the values accepted by the status filter for validators,
+// being the individual validator states and their general categories.
+var statusFilters = map[string]bool{
+	"pending":    true,
+	"active":     true,
+	"exited":     true,
+	"withdrawal": true,
+}
+
+func init() {
+	for state := apiv1.ValidatorStatePendingInitialized; state <= apiv1.ValidatorStateWithdrawalDone; state++ {
+		statusFilters[state.String()] = true
+	}
+}
+
+// getGenesis handles /eth/v1/beacon/genesis
+func (s *Service) getGenesis(ctx context.Context, _ *http.Request) (interface{}, error) {
+	genesis, err := s.genesisProvider.Genesis(ctx)
+	if isNotFound(err) || (err == nil && genesis == nil) {
+		return nil, notFound("genesis not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain genesis")
+	}
+
+	return &genesisJSON{
+		GenesisTime:           fmt.Sprintf("%d", genesis.GenesisTime.Unix()),
+		GenesisValidatorsRoot: fmt.Sprintf("%#x", genesis.GenesisValidatorsRoot),
+		GenesisForkVersion:    fmt.Sprintf("%#x", genesis.GenesisForkVersion),
+	}, nil
+}
+
+// getHeaders handles /eth/v1/beacon/headers?slot=&parent_root=
+func (s *Service) getHeaders(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	slot, err := uint64Param(query, "slot")
+	if err != nil {
+		return nil, err
+	}
+	var parentRoot *phase0.Root
+	if query.Get("parent_root") != "" {
+		root, err := parseRoot(query.Get("parent_root"))
+		if err != nil {
+			return nil, err
+		}
+		parentRoot = &root
+	}
+
+	var blocks []*chaindb.Block
+	switch {
+	case slot != nil:
+		blocks, err = s.blocksProvider.BlocksBySlot(ctx, phase0.Slot(*slot))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain blocks")
+		}
+		if parentRoot != nil {
+			filtered := make([]*chaindb.Block, 0, len(blocks))
+			for _, block := range blocks {
+				if block.ParentRoot == *parentRoot {
+					filtered = append(filtered, block)
+				}
+			}
+			blocks = filtered
+		}
+	case parentRoot != nil:
+		blocks, err = s.blocksProvider.BlocksByParentRoot(ctx, *parentRoot)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain blocks")
+		}
+	default:
+		block, err := s.blockForID(ctx, "head")
+		if err != nil {
+			return nil, err
+		}
+		blocks = []*chaindb.Block{block}
+	}
+
+	res := make([]*headerJSON, len(blocks))
+	for i := range blocks {
+		res[i] = headerToJSON(blocks[i])
+	}
+	return res, nil
+}
+
+// getHeader handles /eth/v1/beacon/headers/{block_id}
+func (s *Service) getHeader(ctx context.Context, r *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/headers/")
+	if strings.Contains(id, "/") {
+		return nil, notFound("unknown endpoint")
+	}
+
+	block, err := s.blockForID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return headerToJSON(block), nil
+}
+
+// getBlockRoot handles /eth/v1/beacon/blocks/{block_id}/root
+func (s *Service) getBlockRoot(ctx context.Context, r *http.Request) (interface{}, error) {
+	path := strings.TrimPrefix(r.URL.Path, "/eth/v1/beacon/blocks/")
+	if !strings.HasSuffix(path, "/root") {
+		// Full blocks are not available, as the chain database does not hold
+		// all of their contents.
+		return nil, notFound("unknown endpoint")
+	}
+	id := strings.TrimSuffix(path, "/root")
+	if strings.Contains(id, "/") {
+		return nil, notFound("unknown endpoint")
+	}
+
+	block, err := s.blockForID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &rootJSON{Root: fmt.Sprintf("%#x", block.Root)}, nil
+}
+
+// statesHandler routes requests for /eth/v1/beacon/states/{state_id}/...
+func (s *Service) statesHandler() http.HandlerFunc {
+	validators := s.handler("validators", s.getValidators)
+	validator := s.handler("validator", s.getValidator)
+	committees := s.handler("committees", s.getCommittees)
+	unknown := s.handler("unknown", func(_ context.Context, _ *http.Request) (interface{}, error) {
+		return nil, notFound("unknown endpoint")
+	})
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, statesPrefix), "/")
+		switch {
+		case len(parts) == 2 && parts[1] == "validators":
+			validators(w, r)
+		case len(parts) == 3 && parts[1] == "validators":
+			validator(w, r)
+		case len(parts) == 2 && parts[1] == "committees":
+			committees(w, r)
+		default:
+			unknown(w, r)
+		}
+	}
+}
+
+// stateEpoch obtains the epoch of the state referenced in a states request.
+func (s *Service) stateEpoch(ctx context.Context, r *http.Request) (phase0.Epoch, error) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, statesPrefix), "/")
+	slot, err := s.slotForStateID(ctx, parts[0])
+	if err != nil {
+		return 0, err
+	}
+	return s.chainTime.SlotToEpoch(slot), nil
+}
+
+// getValidators handles /eth/v1/beacon/states/{state_id}/validators?id=&status=
+func (s *Service) getValidators(ctx context.Context, r *http.Request) (interface{}, error) {
+	epoch, err := s.stateEpoch(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	statuses := listParam(query, "status")
+	for _, status := range statuses {
+		if !statusFilters[status] {
+			return nil, badRequest("invalid status %q", status)
+		}
+	}
+
+	validators, err := s.validatorsForIDs(ctx, listParam(query, "id"))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.validatorsToJSON(ctx, validators, epoch)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) > 0 {
+		filtered := make([]*validatorJSON, 0, len(res))
+		for _, validator := range res {
+			if statusMatches(validator.Status, statuses) {
+				filtered = append(filtered, validator)
+			}
+		}
+		res = filtered
+	}
+	return res, nil
+}
+
+// getValidator handles /eth/v1/beacon/states/{state_id}/validators/{validator_id}
+func (s *Service) getValidator(ctx context.Context, r *http.Request) (interface{}, error) {
+	epoch, err := s.stateEpoch(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, statesPrefix), "/")
+
+	validators, err := s.validatorsForIDs(ctx, []string{parts[2]})
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, notFound("validator not found")
+	}
+
+	res, err := s.validatorsToJSON(ctx, validators, epoch)
+	if err != nil {
+		return nil, err
+	}
+	return res[0], nil
+}
+
+// getCommittees handles /eth/v1/beacon/states/{state_id}/committees?epoch=&index=&slot=
+func (s *Service) getCommittees(ctx context.Context, r *http.Request) (interface{}, error) {
+	epoch, err := s.stateEpoch(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	query := r.URL.Query()
+	epochParam, err := uint64Param(query, "epoch")
+	if err != nil {
+		return nil, err
+	}
+	if epochParam != nil {
+		epoch = phase0.Epoch(*epochParam)
+	}
+	index, err := uint64Param(query, "index")
+	if err != nil {
+		return nil, err
+	}
+	slot, err := uint64Param(query, "slot")
+	if err != nil {
+		return nil, err
+	}
+
+	startSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	endSlot := s.chainTime.FirstSlotOfEpoch(epoch + 1)
+	if slot != nil {
+		if phase0.Slot(*slot) < startSlot || phase0.Slot(*slot) >= endSlot {
+			return nil, badRequest("slot %d is not in epoch %d", *slot, epoch)
+		}
+		startSlot = phase0.Slot(*slot)
+		endSlot = startSlot + 1
+	}
+
+	res := make([]*committeeJSON, 0)
+	for committeeSlot := startSlot; committeeSlot < endSlot; committeeSlot++ {
+		if index != nil {
+			committee, err := s.beaconCommitteesProvider.BeaconCommitteeBySlotAndIndex(ctx, committeeSlot, phase0.CommitteeIndex(*index))
+			if isNotFound(err) || (err == nil && committee == nil) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain beacon committee")
+			}
+			res = append(res, committeeToJSON(committee))
+			continue
+		}
+		// The number of committees per slot is not stored, so fetch until
+		// there are no more.
+		for committeeIndex := phase0.CommitteeIndex(0); ; committeeIndex++ {
+			committee, err := s.beaconCommitteesProvider.BeaconCommitteeBySlotAndIndex(ctx, committeeSlot, committeeIndex)
+			if isNotFound(err) || (err == nil && committee == nil) {
+				break
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain beacon committee")
+			}
+			res = append(res, committeeToJSON(committee))
+		}
+	}
+	if len(res) == 0 {
+		return nil, notFound("beacon committees not found")
+	}
+	return res, nil
+}
+
+// validatorsForIDs obtains the validators for a list of IDs, each of which is
+// either a validator index or a 0x-prefixed public key.  If no IDs are supplied
+// all validators are returned.
+func (s *Service) validatorsForIDs(ctx context.Context, ids []string) ([]*chaindb.Validator, error) {
+	if len(ids) == 0 {
+		validators, err := s.validatorSet.Validators(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		return validators, nil
+	}
+
+	indices := make([]phase0.ValidatorIndex, 0, len(ids))
+	pubKeys := make([]phase0.BLSPubKey, 0, len(ids))
+	for _, id := range ids {
+		if strings.HasPrefix(id, "0x") {
+			data, err := hex.DecodeString(strings.TrimPrefix(id, "0x"))
+			if err != nil || len(data) != phase0.PublicKeyLength {
+				return nil, badRequest("invalid validator ID %q", id)
+			}
+			var pubKey phase0.BLSPubKey
+			copy(pubKey[:], data)
+			pubKeys = append(pubKeys, pubKey)
+			continue
+		}
+		index, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, badRequest("invalid validator ID %q", id)
+		}
+		indices = append(indices, phase0.ValidatorIndex(index))
+	}
+
+	validatorsMap := make(map[phase0.ValidatorIndex]*chaindb.Validator)
+	if len(indices) > 0 {
+		validators, err := s.validatorSet.ValidatorsByIndex(ctx, indices)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		for index, validator := range validators {
+			validatorsMap[index] = validator
+		}
+	}
+	if len(pubKeys) > 0 {
+		validators, err := s.validatorSet.ValidatorsByPublicKey(ctx, pubKeys)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		for _, validator := range validators {
+			validatorsMap[validator.Index] = validator
+		}
+	}
+
+	res := make([]*chaindb.Validator, 0, len(validatorsMap))
+	for _, validator := range validatorsMap {
+		res = append(res, validator)
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].Index < res[j].Index
+	})
+	return res, nil
+}
+
+// validatorsToJSON converts validators to their JSON representation at the given epoch.
+// Validator balances are used if present in the chain database; otherwise the
+// validator's effective balance is returned in its place.
+func (s *Service) validatorsToJSON(ctx context.Context, validators []*chaindb.Validator, epoch phase0.Epoch) ([]*validatorJSON, error) {
+	indices := make([]phase0.ValidatorIndex, len(validators))
+	for i := range validators {
+		indices[i] = validators[i].Index
+	}
+	balances, err := s.validatorsProvider.ValidatorBalancesByIndexAndEpoch(ctx, indices, epoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator balances")
+	}
+
+	res := make([]*validatorJSON, len(validators))
+	for i, validator := range validators {
+		balance := validator.EffectiveBalance
+		if validatorBalance, exists := balances[validator.Index]; exists {
+			balance = validatorBalance.Balance
+		}
+		res[i] = validatorToJSON(validator, balance, validatorState(validator, balance, epoch))
+	}
+	return res, nil
+}
+
+// statusMatches returns true if the status matches any of the filters, either
+// directly or by its general category.
+func statusMatches(status string, filters []string) bool {
+	for _, filter := range filters {
+		if status == filter || strings.HasPrefix(status, fmt.Sprintf("%s_", filter)) {
+			return true
+		}
+	}
+	return false
+}
+
+// listParam obtains a list query parameter, which can be supplied either as
+// repeated parameters or as a single comma-separated parameter.
+func listParam(query url.Values, name string) []string {
+	res := make([]string, 0)
+	for _, value := range query[name] {
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				res = append(res, item)
+			}
+		}
+	}
+	return res
+}
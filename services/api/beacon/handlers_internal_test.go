@@ -0,0 +1,163 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestRouter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(mockchaindb.New()),
+		WithChainTime(mockchaintime.New()),
+		WithListenAddress("localhost:0"),
+	)
+	require.NoError(t, err)
+	router := s.router()
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		status int
+	}{
+		{
+			name:   "MethodNotAllowed",
+			method: http.MethodPost,
+			url:    "/eth/v1/beacon/genesis",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "GenesisMissing",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/genesis",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "HeaderInvalidID",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/headers/invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "HeaderMissing",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/headers/12",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "BlockUnsupported",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/blocks/12",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "StateRootUnsupported",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/states/0x0000000000000000000000000000000000000000000000000000000000000000/validators",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ValidatorsInvalidStatus",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/states/genesis/validators?status=invalid",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ValidatorsInvalidID",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/states/genesis/validators?id=0x01",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "Validators",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/states/genesis/validators?status=active",
+			status: http.StatusOK,
+		},
+		{
+			name:   "ValidatorMissing",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/states/genesis/validators/1",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "CommitteesMissing",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/states/genesis/committees",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "StatesUnknownEndpoint",
+			method: http.MethodGet,
+			url:    "/eth/v1/beacon/states/genesis/fork",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(test.method, test.url, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			require.Equal(t, test.status, rec.Code)
+		})
+	}
+}
+
+func TestStatusMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		filters []string
+		matches bool
+	}{
+		{
+			name:    "Exact",
+			status:  "active_ongoing",
+			filters: []string{"active_ongoing"},
+			matches: true,
+		},
+		{
+			name:    "Category",
+			status:  "active_exiting",
+			filters: []string{"pending", "active"},
+			matches: true,
+		},
+		{
+			name:    "NoMatch",
+			status:  "exited_unslashed",
+			filters: []string{"active", "withdrawal_done"},
+			matches: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.matches, statusMatches(test.status, test.filters))
+		})
+	}
+}
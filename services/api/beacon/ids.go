@@ -0,0 +1,137 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// isNotFound returns true if the error from the chain database indicates that
+// the requested item does not exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// blockForID obtains the block for a block ID, which can be "head", "genesis",
+// "finalized", a slot or a 0x-prefixed block root.
+func (s *Service) blockForID(ctx context.Context, id string) (*chaindb.Block, error) {
+	switch {
+	case id == "head":
+		blocks, err := s.blocksProvider.LatestBlocks(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain latest blocks")
+		}
+		block := preferredBlock(blocks)
+		if block == nil {
+			return nil, notFound("block not found")
+		}
+		return block, nil
+	case id == "genesis":
+		return s.blockForSlot(ctx, 0)
+	case id == "finalized":
+		slot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain latest canonical block")
+		}
+		return s.blockForSlot(ctx, slot)
+	case strings.HasPrefix(id, "0x"):
+		root, err := parseRoot(id)
+		if err != nil {
+			return nil, err
+		}
+		block, err := s.blocksProvider.BlockByRoot(ctx, root)
+		if isNotFound(err) || (err == nil && block == nil) {
+			return nil, notFound("block not found")
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain block")
+		}
+		return block, nil
+	default:
+		slot, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return nil, badRequest("invalid block ID %q", id)
+		}
+		return s.blockForSlot(ctx, phase0.Slot(slot))
+	}
+}
+
+// blockForSlot obtains the block at the given slot.
+func (s *Service) blockForSlot(ctx context.Context, slot phase0.Slot) (*chaindb.Block, error) {
+	blocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	block := preferredBlock(blocks)
+	if block == nil {
+		return nil, notFound("block not found")
+	}
+	return block, nil
+}
+
+// preferredBlock selects a single block from those at a slot.  The canonical
+// block is preferred, followed by a block whose canonical status is not yet
+// known.  Blocks known to be non-canonical are never selected.
+func preferredBlock(blocks []*chaindb.Block) *chaindb.Block {
+	var res *chaindb.Block
+	for _, block := range blocks {
+		if block.Canonical == nil {
+			if res == nil {
+				res = block
+			}
+			continue
+		}
+		if *block.Canonical {
+			return block
+		}
+	}
+	return res
+}
+
+// slotForStateID obtains the slot for a state ID, which can be "head",
+// "genesis", "finalized" or a slot.  State roots are not supported, as
+// the chain database does not index blocks by state root.
+func (s *Service) slotForStateID(ctx context.Context, id string) (phase0.Slot, error) {
+	switch {
+	case id == "head":
+		block, err := s.blockForID(ctx, id)
+		if err != nil {
+			return 0, err
+		}
+		return block.Slot, nil
+	case id == "genesis":
+		return 0, nil
+	case id == "finalized":
+		slot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to obtain latest canonical block")
+		}
+		return slot, nil
+	case id == "justified" || strings.HasPrefix(id, "0x"):
+		return 0, badRequest("state ID %q is not supported", id)
+	default:
+		slot, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return 0, badRequest("invalid state ID %q", id)
+		}
+		return phase0.Slot(slot), nil
+	}
+}
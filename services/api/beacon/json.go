@@ -0,0 +1,152 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"fmt"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// The JSON representations follow the beacon node API specification.  Data
+// that is not held in the chain database, such as block signatures, is
+// returned as zero values.
+
+var (
+	zeroSignature             = fmt.Sprintf("%#x", phase0.BLSSignature{})
+	zeroWithdrawalCredentials = fmt.Sprintf("%#x", make([]byte, 32))
+)
+
+type genesisJSON struct {
+	GenesisTime           string `json:"genesis_time"`
+	GenesisValidatorsRoot string `json:"genesis_validators_root"`
+	GenesisForkVersion    string `json:"genesis_fork_version"`
+}
+
+type headerJSON struct {
+	Root      string            `json:"root"`
+	Canonical bool              `json:"canonical"`
+	Header    *signedHeaderJSON `json:"header"`
+}
+
+type signedHeaderJSON struct {
+	Message   *headerMessageJSON `json:"message"`
+	Signature string             `json:"signature"`
+}
+
+type headerMessageJSON struct {
+	Slot          string `json:"slot"`
+	ProposerIndex string `json:"proposer_index"`
+	ParentRoot    string `json:"parent_root"`
+	StateRoot     string `json:"state_root"`
+	BodyRoot      string `json:"body_root"`
+}
+
+type rootJSON struct {
+	Root string `json:"root"`
+}
+
+type validatorJSON struct {
+	Index     string             `json:"index"`
+	Balance   string             `json:"balance"`
+	Status    string             `json:"status"`
+	Validator *validatorInfoJSON `json:"validator"`
+}
+
+type validatorInfoJSON struct {
+	PublicKey                  string `json:"pubkey"`
+	WithdrawalCredentials      string `json:"withdrawal_credentials"`
+	EffectiveBalance           string `json:"effective_balance"`
+	Slashed                    bool   `json:"slashed"`
+	ActivationEligibilityEpoch string `json:"activation_eligibility_epoch"`
+	ActivationEpoch            string `json:"activation_epoch"`
+	ExitEpoch                  string `json:"exit_epoch"`
+	WithdrawableEpoch          string `json:"withdrawable_epoch"`
+}
+
+type committeeJSON struct {
+	Index      string   `json:"index"`
+	Slot       string   `json:"slot"`
+	Validators []string `json:"validators"`
+}
+
+func headerToJSON(block *chaindb.Block) *headerJSON {
+	return &headerJSON{
+		Root:      fmt.Sprintf("%#x", block.Root),
+		Canonical: block.Canonical != nil && *block.Canonical,
+		Header: &signedHeaderJSON{
+			Message: &headerMessageJSON{
+				Slot:          fmt.Sprintf("%d", block.Slot),
+				ProposerIndex: fmt.Sprintf("%d", block.ProposerIndex),
+				ParentRoot:    fmt.Sprintf("%#x", block.ParentRoot),
+				StateRoot:     fmt.Sprintf("%#x", block.StateRoot),
+				BodyRoot:      fmt.Sprintf("%#x", block.BodyRoot),
+			},
+			Signature: zeroSignature,
+		},
+	}
+}
+
+// validatorState calculates the state of a validator at the given epoch.
+func validatorState(validator *chaindb.Validator, balance phase0.Gwei, epoch phase0.Epoch) apiv1.ValidatorState {
+	state := apiv1.ValidatorToState(&phase0.Validator{
+		PublicKey:                  validator.PublicKey,
+		EffectiveBalance:           validator.EffectiveBalance,
+		Slashed:                    validator.Slashed,
+		ActivationEligibilityEpoch: validator.ActivationEligibilityEpoch,
+		ActivationEpoch:            validator.ActivationEpoch,
+		ExitEpoch:                  validator.ExitEpoch,
+		WithdrawableEpoch:          validator.WithdrawableEpoch,
+	}, epoch, farFutureEpoch)
+	if state == apiv1.ValidatorStateWithdrawalPossible && balance == 0 {
+		state = apiv1.ValidatorStateWithdrawalDone
+	}
+	return state
+}
+
+func validatorToJSON(validator *chaindb.Validator, balance phase0.Gwei, state apiv1.ValidatorState) *validatorJSON {
+	withdrawalCredentials := zeroWithdrawalCredentials
+	if validator.WithdrawalCredentials != nil {
+		withdrawalCredentials = fmt.Sprintf("%#x", validator.WithdrawalCredentials)
+	}
+	return &validatorJSON{
+		Index:   fmt.Sprintf("%d", validator.Index),
+		Balance: fmt.Sprintf("%d", balance),
+		Status:  state.String(),
+		Validator: &validatorInfoJSON{
+			PublicKey:                  fmt.Sprintf("%#x", validator.PublicKey),
+			WithdrawalCredentials:      withdrawalCredentials,
+			EffectiveBalance:           fmt.Sprintf("%d", validator.EffectiveBalance),
+			Slashed:                    validator.Slashed,
+			ActivationEligibilityEpoch: fmt.Sprintf("%d", validator.ActivationEligibilityEpoch),
+			ActivationEpoch:            fmt.Sprintf("%d", validator.ActivationEpoch),
+			ExitEpoch:                  fmt.Sprintf("%d", validator.ExitEpoch),
+			WithdrawableEpoch:          fmt.Sprintf("%d", validator.WithdrawableEpoch),
+		},
+	}
+}
+
+func committeeToJSON(committee *chaindb.BeaconCommittee) *committeeJSON {
+	validators := make([]string, len(committee.Committee))
+	for i := range committee.Committee {
+		validators[i] = fmt.Sprintf("%d", committee.Committee[i])
+	}
+	return &committeeJSON{
+		Index:      fmt.Sprintf("%d", committee.Index),
+		Slot:       fmt.Sprintf("%d", committee.Slot),
+		Validators: validators,
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	monitor         metrics.Service
+	chainDB         chaindb.Service
+	chainTime       chaintime.Service
+	listenAddress   string
+	authenticator   *util.Authenticator
+	validatorSet    validatorset.Service
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithListenAddress sets the address on which the Beacon API server listens.
+func WithListenAddress(listenAddress string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.listenAddress = listenAddress
+	})
+}
+
+// WithAuthenticator sets the authenticator for requests to the server.
+func WithAuthenticator(authenticator *util.Authenticator) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.authenticator = authenticator
+	})
+}
+
+// WithValidatorSet sets the validator set from which validators are obtained.
+// If not supplied validators are obtained from the chain database.
+func WithValidatorSet(validatorSet validatorset.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorSet = validatorSet
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.listenAddress == "" {
+		return nil, errors.New("no listen address specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,135 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// apiError is an error with an associated HTTP status code.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// badRequest returns an error for an invalid request.
+func badRequest(format string, args ...interface{}) error {
+	return &apiError{
+		status:  http.StatusBadRequest,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+// notFound returns an error for a missing item.
+func notFound(format string, args ...interface{}) error {
+	return &apiError{
+		status:  http.StatusNotFound,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+type dataResponse struct {
+	Data interface{} `json:"data"`
+}
+
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// handlerFunc is a function that handles a Beacon API request, returning the data to send back.
+type handlerFunc func(ctx context.Context, r *http.Request) (interface{}, error)
+
+// handler wraps a Beacon API handler function, handling method checks, encoding and metrics.
+func (s *Service) handler(endpoint string, fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		if r.Method != http.MethodGet {
+			writeError(w, &apiError{status: http.StatusMethodNotAllowed, message: "method not allowed"})
+			monitorRequest(endpoint, http.StatusMethodNotAllowed, time.Since(started))
+			return
+		}
+
+		data, err := fn(r.Context(), r)
+		if err != nil {
+			status := writeError(w, err)
+			if status == http.StatusInternalServerError {
+				log.Warn().Str("endpoint", endpoint).Str("url", r.URL.String()).Err(err).Msg("Failed to handle request")
+			}
+			monitorRequest(endpoint, status, time.Since(started))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(&dataResponse{Data: data}); err != nil {
+			log.Debug().Str("endpoint", endpoint).Err(err).Msg("Failed to write response")
+		}
+		monitorRequest(endpoint, http.StatusOK, time.Since(started))
+	}
+}
+
+// writeError writes an error response, returning the status code used.
+func writeError(w http.ResponseWriter, err error) int {
+	status := http.StatusInternalServerError
+	message := "internal error"
+	if apiErr, isAPIErr := err.(*apiError); isAPIErr {
+		status = apiErr.status
+		message = apiErr.message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(&errorResponse{Code: status, Message: message}); err != nil {
+		log.Debug().Err(err).Msg("Failed to write error response")
+	}
+	return status
+}
+
+// uint64Param obtains an optional unsigned integer query parameter.
+func uint64Param(query url.Values, name string) (*uint64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	res, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, badRequest("invalid value for %s", name)
+	}
+	return &res, nil
+}
+
+// parseRoot parses a 0x-prefixed hex root.
+func parseRoot(value string) (phase0.Root, error) {
+	var root phase0.Root
+	data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil || len(data) != len(root) {
+		return root, badRequest("invalid root %q", value)
+	}
+	copy(root[:], data)
+	return root, nil
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a server providing a subset of the standard beacon node API,
+// answering requests from the chain database rather than a beacon node.
+type Service struct {
+	chainTime                chaintime.Service
+	genesisProvider          chaindb.GenesisProvider
+	blocksProvider           chaindb.BlocksProvider
+	validatorsProvider       chaindb.ValidatorsProvider
+	validatorSet             validatorset.Service
+	beaconCommitteesProvider chaindb.BeaconCommitteesProvider
+	server                   *http.Server
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "api").Str("impl", "beacon").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	genesisProvider, isProvider := parameters.chainDB.(chaindb.GenesisProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide genesis")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorSet = validatorsProvider
+	}
+
+	beaconCommitteesProvider, isProvider := parameters.chainDB.(chaindb.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide beacon committees")
+	}
+
+	s := &Service{
+		chainTime:                parameters.chainTime,
+		genesisProvider:          genesisProvider,
+		blocksProvider:           blocksProvider,
+		validatorsProvider:       validatorsProvider,
+		validatorSet:             validatorSet,
+		beaconCommitteesProvider: beaconCommitteesProvider,
+	}
+
+	s.server = &http.Server{
+		Addr:              parameters.listenAddress,
+		Handler:           parameters.authenticator.Require(util.RoleRead, s.router()),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("listen_address", parameters.listenAddress).Msg("Starting Beacon API server")
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run Beacon API server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; shutting down Beacon API server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down Beacon API server")
+		}
+	}()
+
+	return s, nil
+}
+
+// router creates the router for the Beacon API server.
+func (s *Service) router() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/genesis", s.handler("genesis", s.getGenesis))
+	mux.HandleFunc("/eth/v1/beacon/headers", s.handler("headers", s.getHeaders))
+	mux.HandleFunc("/eth/v1/beacon/headers/", s.handler("header", s.getHeader))
+	mux.HandleFunc("/eth/v1/beacon/blocks/", s.handler("block_root", s.getBlockRoot))
+	mux.HandleFunc(statesPrefix, s.statesHandler())
+	return mux
+}
@@ -0,0 +1,76 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_graphql"
+
+var requests *prometheus.CounterVec
+var requestDuration prometheus.Histogram
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if requests != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Number of GraphQL requests",
+	}, []string{"result"})
+	if err := prometheus.Register(requests); err != nil {
+		return errors.Wrap(err, "failed to register requests_total")
+	}
+
+	requestDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to handle GraphQL requests",
+	})
+	if err := prometheus.Register(requestDuration); err != nil {
+		return errors.Wrap(err, "failed to register request_duration_seconds")
+	}
+
+	return nil
+}
+
+func monitorRequest(succeeded bool, duration time.Duration) {
+	if requests != nil {
+		if succeeded {
+			requests.WithLabelValues("succeeded").Inc()
+		} else {
+			requests.WithLabelValues("failed").Inc()
+		}
+		requestDuration.Observe(duration.Seconds())
+	}
+}
@@ -0,0 +1,490 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// maxEpochSummaries is the maximum number of validator epoch summaries returned for a single field.
+const maxEpochSummaries = 1000
+
+// resolver is the root resolver.
+type resolver struct {
+	s *Service
+}
+
+// isNotFound returns true if the error from the chain database indicates that
+// the requested item does not exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// checkSlotRange ensures that a slot range is valid.
+func (s *Service) checkSlotRange(from Uint64, to Uint64) error {
+	if to <= from {
+		return errors.New("toSlot must be greater than fromSlot")
+	}
+	if uint64(to-from) > s.maxSlotRange {
+		return fmt.Errorf("slot range cannot be greater than %d", s.maxSlotRange)
+	}
+	return nil
+}
+
+func parseRoot(value string) (phase0.Root, error) {
+	var root phase0.Root
+	data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil || len(data) != len(root) {
+		return root, fmt.Errorf("invalid root %q", value)
+	}
+	copy(root[:], data)
+	return root, nil
+}
+
+func (s *Service) blockResolvers(blocks []*chaindb.Block) []*blockResolver {
+	res := make([]*blockResolver, len(blocks))
+	for i := range blocks {
+		res[i] = &blockResolver{s: s, block: blocks[i]}
+	}
+	return res
+}
+
+func (s *Service) attestationResolvers(attestations []*chaindb.Attestation) []*attestationResolver {
+	res := make([]*attestationResolver, len(attestations))
+	for i := range attestations {
+		res[i] = &attestationResolver{attestation: attestations[i]}
+	}
+	return res
+}
+
+// validator obtains a single validator by index.
+func (s *Service) validator(ctx context.Context, index phase0.ValidatorIndex) (*validatorResolver, error) {
+	validators, err := s.validatorSet.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{index})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator")
+	}
+	validator, exists := validators[index]
+	if !exists {
+		return nil, nil
+	}
+	return &validatorResolver{s: s, validator: validator}, nil
+}
+
+// Blocks resolves the blocks query.
+func (r *resolver) Blocks(ctx context.Context, args struct {
+	Root *string
+	Slot *Uint64
+}) ([]*blockResolver, error) {
+	switch {
+	case args.Root != nil:
+		root, err := parseRoot(*args.Root)
+		if err != nil {
+			return nil, err
+		}
+		block, err := r.s.blocksProvider.BlockByRoot(ctx, root)
+		if isNotFound(err) || (err == nil && block == nil) {
+			return []*blockResolver{}, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain block")
+		}
+		return r.s.blockResolvers([]*chaindb.Block{block}), nil
+	case args.Slot != nil:
+		blocks, err := r.s.blocksProvider.BlocksBySlot(ctx, phase0.Slot(*args.Slot))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain blocks")
+		}
+		return r.s.blockResolvers(blocks), nil
+	default:
+		return nil, errors.New("either root or slot is required")
+	}
+}
+
+// BlockRange resolves the blockRange query.
+func (r *resolver) BlockRange(ctx context.Context, args struct {
+	FromSlot Uint64
+	ToSlot   Uint64
+}) ([]*blockResolver, error) {
+	if err := r.s.checkSlotRange(args.FromSlot, args.ToSlot); err != nil {
+		return nil, err
+	}
+	blocks, err := r.s.blocksProvider.BlocksForSlotRange(ctx, phase0.Slot(args.FromSlot), phase0.Slot(args.ToSlot))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	return r.s.blockResolvers(blocks), nil
+}
+
+// Validator resolves the validator query.
+func (r *resolver) Validator(ctx context.Context, args struct {
+	Index  *Uint64
+	Pubkey *string
+}) (*validatorResolver, error) {
+	switch {
+	case args.Index != nil:
+		return r.s.validator(ctx, phase0.ValidatorIndex(*args.Index))
+	case args.Pubkey != nil:
+		data, err := hex.DecodeString(strings.TrimPrefix(*args.Pubkey, "0x"))
+		if err != nil || len(data) != phase0.PublicKeyLength {
+			return nil, fmt.Errorf("invalid public key %q", *args.Pubkey)
+		}
+		var pubKey phase0.BLSPubKey
+		copy(pubKey[:], data)
+		validators, err := r.s.validatorSet.ValidatorsByPublicKey(ctx, []phase0.BLSPubKey{pubKey})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validator")
+		}
+		validator, exists := validators[pubKey]
+		if !exists {
+			return nil, nil
+		}
+		return &validatorResolver{s: r.s, validator: validator}, nil
+	default:
+		return nil, errors.New("either index or pubkey is required")
+	}
+}
+
+// Validators resolves the validators query.
+func (r *resolver) Validators(ctx context.Context, args struct {
+	Indices *[]Uint64
+}) ([]*validatorResolver, error) {
+	var validators []*chaindb.Validator
+	if args.Indices != nil {
+		indices := make([]phase0.ValidatorIndex, len(*args.Indices))
+		for i, index := range *args.Indices {
+			indices[i] = phase0.ValidatorIndex(index)
+		}
+		validatorsMap, err := r.s.validatorSet.ValidatorsByIndex(ctx, indices)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		for _, index := range indices {
+			if validator, exists := validatorsMap[index]; exists {
+				validators = append(validators, validator)
+			}
+		}
+	} else {
+		var err error
+		validators, err = r.s.validatorSet.Validators(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+	}
+
+	res := make([]*validatorResolver, len(validators))
+	for i := range validators {
+		res[i] = &validatorResolver{s: r.s, validator: validators[i]}
+	}
+	return res, nil
+}
+
+type blockResolver struct {
+	s     *Service
+	block *chaindb.Block
+}
+
+func (r *blockResolver) Slot() Uint64       { return Uint64(r.block.Slot) }
+func (r *blockResolver) Root() string       { return fmt.Sprintf("%#x", r.block.Root) }
+func (r *blockResolver) ParentRoot() string { return fmt.Sprintf("%#x", r.block.ParentRoot) }
+func (r *blockResolver) StateRoot() string  { return fmt.Sprintf("%#x", r.block.StateRoot) }
+func (r *blockResolver) BodyRoot() string   { return fmt.Sprintf("%#x", r.block.BodyRoot) }
+func (r *blockResolver) Graffiti() string   { return fmt.Sprintf("%#x", r.block.Graffiti) }
+func (r *blockResolver) Canonical() *bool   { return r.block.Canonical }
+
+func (r *blockResolver) Proposer(ctx context.Context) (*validatorResolver, error) {
+	return r.s.validator(ctx, r.block.ProposerIndex)
+}
+
+func (r *blockResolver) Attestations(ctx context.Context) ([]*attestationResolver, error) {
+	attestations, err := r.s.attestationsProvider.AttestationsInBlock(ctx, r.block.Root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	return r.s.attestationResolvers(attestations), nil
+}
+
+func (r *blockResolver) Summary(ctx context.Context) (*blockSummaryResolver, error) {
+	summary, err := r.s.blockSummariesProvider.BlockSummaryForSlot(ctx, r.block.Slot)
+	if isNotFound(err) || (err == nil && summary == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block summary")
+	}
+	return &blockSummaryResolver{summary: summary}, nil
+}
+
+type validatorResolver struct {
+	s         *Service
+	validator *chaindb.Validator
+}
+
+func (r *validatorResolver) Index() Uint64  { return Uint64(r.validator.Index) }
+func (r *validatorResolver) Pubkey() string { return fmt.Sprintf("%#x", r.validator.PublicKey) }
+func (r *validatorResolver) EffectiveBalance() Uint64 {
+	return Uint64(r.validator.EffectiveBalance)
+}
+func (r *validatorResolver) Slashed() bool { return r.validator.Slashed }
+func (r *validatorResolver) ActivationEligibilityEpoch() Uint64 {
+	return Uint64(r.validator.ActivationEligibilityEpoch)
+}
+func (r *validatorResolver) ActivationEpoch() Uint64 { return Uint64(r.validator.ActivationEpoch) }
+func (r *validatorResolver) ExitEpoch() Uint64       { return Uint64(r.validator.ExitEpoch) }
+func (r *validatorResolver) WithdrawableEpoch() Uint64 {
+	return Uint64(r.validator.WithdrawableEpoch)
+}
+
+func (r *validatorResolver) Balance(ctx context.Context, args struct {
+	Epoch Uint64
+}) (*validatorBalanceResolver, error) {
+	balances, err := r.s.validatorsProvider.ValidatorBalancesByIndexAndEpoch(ctx, []phase0.ValidatorIndex{r.validator.Index}, phase0.Epoch(args.Epoch))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator balance")
+	}
+	balance, exists := balances[r.validator.Index]
+	if !exists {
+		return nil, nil
+	}
+	return &validatorBalanceResolver{balance: balance}, nil
+}
+
+func (r *validatorResolver) ProposerDuties(ctx context.Context) ([]*proposerDutyResolver, error) {
+	duties, err := r.s.proposerDutiesProvider.ProposerDutiesForValidator(ctx, r.validator.Index)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain proposer duties")
+	}
+	res := make([]*proposerDutyResolver, len(duties))
+	for i := range duties {
+		res[i] = &proposerDutyResolver{s: r.s, duty: duties[i]}
+	}
+	return res, nil
+}
+
+func (r *validatorResolver) AttesterDuties(ctx context.Context, args struct {
+	FromSlot Uint64
+	ToSlot   Uint64
+}) ([]*attesterDutyResolver, error) {
+	if err := r.s.checkSlotRange(args.FromSlot, args.ToSlot); err != nil {
+		return nil, err
+	}
+	duties, err := r.s.beaconCommitteesProvider.AttesterDuties(ctx, phase0.Slot(args.FromSlot), phase0.Slot(args.ToSlot), []phase0.ValidatorIndex{r.validator.Index})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attester duties")
+	}
+	res := make([]*attesterDutyResolver, len(duties))
+	for i := range duties {
+		res[i] = &attesterDutyResolver{s: r.s, duty: duties[i]}
+	}
+	return res, nil
+}
+
+func (r *validatorResolver) EpochSummaries(ctx context.Context, args struct {
+	FromEpoch *Uint64
+	ToEpoch   *Uint64
+	Limit     *int32
+}) ([]*validatorEpochSummaryResolver, error) {
+	indices := []phase0.ValidatorIndex{r.validator.Index}
+	filter := &chaindb.ValidatorSummaryFilter{
+		Limit:            maxEpochSummaries,
+		Order:            chaindb.OrderLatest,
+		ValidatorIndices: &indices,
+	}
+	if args.FromEpoch != nil {
+		epoch := phase0.Epoch(*args.FromEpoch)
+		filter.From = &epoch
+	}
+	if args.ToEpoch != nil {
+		epoch := phase0.Epoch(*args.ToEpoch)
+		filter.To = &epoch
+	}
+	if args.Limit != nil {
+		if *args.Limit <= 0 || *args.Limit > maxEpochSummaries {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxEpochSummaries)
+		}
+		filter.Limit = uint32(*args.Limit)
+	}
+
+	summaries, err := r.s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator epoch summaries")
+	}
+	res := make([]*validatorEpochSummaryResolver, len(summaries))
+	for i := range summaries {
+		res[i] = &validatorEpochSummaryResolver{summary: summaries[i]}
+	}
+	return res, nil
+}
+
+type validatorBalanceResolver struct {
+	balance *chaindb.ValidatorBalance
+}
+
+func (r *validatorBalanceResolver) Epoch() Uint64   { return Uint64(r.balance.Epoch) }
+func (r *validatorBalanceResolver) Balance() Uint64 { return Uint64(r.balance.Balance) }
+func (r *validatorBalanceResolver) EffectiveBalance() Uint64 {
+	return Uint64(r.balance.EffectiveBalance)
+}
+
+type proposerDutyResolver struct {
+	s    *Service
+	duty *chaindb.ProposerDuty
+}
+
+func (r *proposerDutyResolver) Slot() Uint64           { return Uint64(r.duty.Slot) }
+func (r *proposerDutyResolver) ValidatorIndex() Uint64 { return Uint64(r.duty.ValidatorIndex) }
+
+func (r *proposerDutyResolver) Blocks(ctx context.Context) ([]*blockResolver, error) {
+	blocks, err := r.s.blocksProvider.BlocksBySlot(ctx, r.duty.Slot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	return r.s.blockResolvers(blocks), nil
+}
+
+type attesterDutyResolver struct {
+	s    *Service
+	duty *chaindb.AttesterDuty
+}
+
+func (r *attesterDutyResolver) Slot() Uint64           { return Uint64(r.duty.Slot) }
+func (r *attesterDutyResolver) Committee() Uint64      { return Uint64(r.duty.Committee) }
+func (r *attesterDutyResolver) CommitteeIndex() Uint64 { return Uint64(r.duty.CommitteeIndex) }
+func (r *attesterDutyResolver) ValidatorIndex() Uint64 { return Uint64(r.duty.ValidatorIndex) }
+
+func (r *attesterDutyResolver) Attestations(ctx context.Context) ([]*attestationResolver, error) {
+	attestations, err := r.s.attestationsProvider.AttestationsForSlotRange(ctx, r.duty.Slot, r.duty.Slot+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attestations")
+	}
+	matched := make([]*chaindb.Attestation, 0)
+	for _, attestation := range attestations {
+		if attestation.CommitteeIndex != r.duty.Committee {
+			continue
+		}
+		for _, index := range attestation.AggregationIndices {
+			if index == r.duty.ValidatorIndex {
+				matched = append(matched, attestation)
+				break
+			}
+		}
+	}
+	return r.s.attestationResolvers(matched), nil
+}
+
+type attestationResolver struct {
+	attestation *chaindb.Attestation
+}
+
+func (r *attestationResolver) InclusionSlot() Uint64 { return Uint64(r.attestation.InclusionSlot) }
+func (r *attestationResolver) InclusionBlockRoot() string {
+	return fmt.Sprintf("%#x", r.attestation.InclusionBlockRoot)
+}
+func (r *attestationResolver) InclusionIndex() Uint64 { return Uint64(r.attestation.InclusionIndex) }
+func (r *attestationResolver) Slot() Uint64           { return Uint64(r.attestation.Slot) }
+func (r *attestationResolver) CommitteeIndex() Uint64 { return Uint64(r.attestation.CommitteeIndex) }
+func (r *attestationResolver) AggregationIndices() []Uint64 {
+	res := make([]Uint64, len(r.attestation.AggregationIndices))
+	for i := range r.attestation.AggregationIndices {
+		res[i] = Uint64(r.attestation.AggregationIndices[i])
+	}
+	return res
+}
+func (r *attestationResolver) BeaconBlockRoot() string {
+	return fmt.Sprintf("%#x", r.attestation.BeaconBlockRoot)
+}
+func (r *attestationResolver) SourceEpoch() Uint64 { return Uint64(r.attestation.SourceEpoch) }
+func (r *attestationResolver) SourceRoot() string {
+	return fmt.Sprintf("%#x", r.attestation.SourceRoot)
+}
+func (r *attestationResolver) TargetEpoch() Uint64 { return Uint64(r.attestation.TargetEpoch) }
+func (r *attestationResolver) TargetRoot() string {
+	return fmt.Sprintf("%#x", r.attestation.TargetRoot)
+}
+func (r *attestationResolver) Canonical() *bool { return r.attestation.Canonical }
+func (r *attestationResolver) SourceCorrect() *bool {
+	return r.attestation.SourceCorrect
+}
+func (r *attestationResolver) TargetCorrect() *bool {
+	return r.attestation.TargetCorrect
+}
+func (r *attestationResolver) HeadCorrect() *bool { return r.attestation.HeadCorrect }
+func (r *attestationResolver) Duplicate() *bool   { return r.attestation.Duplicate }
+func (r *attestationResolver) Conflicting() *bool {
+	return r.attestation.Conflicting
+}
+
+type blockSummaryResolver struct {
+	summary *chaindb.BlockSummary
+}
+
+func (r *blockSummaryResolver) AttestationsForBlock() int32 {
+	return int32(r.summary.AttestationsForBlock)
+}
+func (r *blockSummaryResolver) DuplicateAttestationsForBlock() int32 {
+	return int32(r.summary.DuplicateAttestationsForBlock)
+}
+func (r *blockSummaryResolver) VotesForBlock() int32  { return int32(r.summary.VotesForBlock) }
+func (r *blockSummaryResolver) ParentDistance() int32 { return int32(r.summary.ParentDistance) }
+
+type validatorEpochSummaryResolver struct {
+	summary *chaindb.ValidatorEpochSummary
+}
+
+func (r *validatorEpochSummaryResolver) Epoch() Uint64 { return Uint64(r.summary.Epoch) }
+func (r *validatorEpochSummaryResolver) ProposerDuties() int32 {
+	return int32(r.summary.ProposerDuties)
+}
+func (r *validatorEpochSummaryResolver) ProposalsIncluded() int32 {
+	return int32(r.summary.ProposalsIncluded)
+}
+func (r *validatorEpochSummaryResolver) AttestationIncluded() bool {
+	return r.summary.AttestationIncluded
+}
+func (r *validatorEpochSummaryResolver) AttestationTargetCorrect() *bool {
+	return r.summary.AttestationTargetCorrect
+}
+func (r *validatorEpochSummaryResolver) AttestationHeadCorrect() *bool {
+	return r.summary.AttestationHeadCorrect
+}
+func (r *validatorEpochSummaryResolver) AttestationInclusionDelay() *int32 {
+	if r.summary.AttestationInclusionDelay == nil {
+		return nil
+	}
+	delay := int32(*r.summary.AttestationInclusionDelay)
+	return &delay
+}
+func (r *validatorEpochSummaryResolver) AttestationInclusionSlot() *Uint64 {
+	if r.summary.AttestationInclusionSlot == nil {
+		return nil
+	}
+	slot := Uint64(*r.summary.AttestationInclusionSlot)
+	return &slot
+}
+func (r *validatorEpochSummaryResolver) AttestationSourceTimely() *bool {
+	return r.summary.AttestationSourceTimely
+}
+func (r *validatorEpochSummaryResolver) AttestationTargetTimely() *bool {
+	return r.summary.AttestationTargetTimely
+}
+func (r *validatorEpochSummaryResolver) AttestationHeadTimely() *bool {
+	return r.summary.AttestationHeadTimely
+}
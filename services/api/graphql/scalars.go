@@ -0,0 +1,59 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Uint64 is a GraphQL scalar for unsigned 64-bit values such as slots and balances,
+// which do not fit in GraphQL's 32-bit Int.  It is presented as a string.
+type Uint64 uint64
+
+// ImplementsGraphQLType maps this type to the Uint64 scalar in the schema.
+func (Uint64) ImplementsGraphQLType(name string) bool {
+	return name == "Uint64"
+}
+
+// UnmarshalGraphQL unmarshals a Uint64 from a query or variable.
+func (u *Uint64) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case string:
+		val, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid Uint64 %q", v)
+		}
+		*u = Uint64(val)
+	case int32:
+		if v < 0 {
+			return fmt.Errorf("invalid Uint64 %d", v)
+		}
+		*u = Uint64(v)
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return fmt.Errorf("invalid Uint64 %v", v)
+		}
+		*u = Uint64(v)
+	default:
+		return fmt.Errorf("invalid Uint64 type %T", input)
+	}
+	return nil
+}
+
+// MarshalJSON marshals a Uint64 as a string.
+func (u Uint64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatUint(uint64(u), 10))
+}
@@ -0,0 +1,125 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+// schema is the GraphQL schema served by the service.
+// Slot ranges are inclusive of fromSlot and exclusive of toSlot.
+var schema = `
+scalar Uint64
+
+schema {
+  query: Query
+}
+
+type Query {
+  # blocks returns the blocks with the given root or slot.
+  blocks(root: String, slot: Uint64): [Block!]!
+  # blockRange returns the blocks in the given slot range.
+  blockRange(fromSlot: Uint64!, toSlot: Uint64!): [Block!]!
+  # validator returns the validator with the given index or public key.
+  validator(index: Uint64, pubkey: String): Validator
+  # validators returns the validators with the given indices, or all validators if none are supplied.
+  validators(indices: [Uint64!]): [Validator!]!
+}
+
+type Block {
+  slot: Uint64!
+  root: String!
+  parentRoot: String!
+  stateRoot: String!
+  bodyRoot: String!
+  graffiti: String!
+  canonical: Boolean
+  proposer: Validator
+  attestations: [Attestation!]!
+  summary: BlockSummary
+}
+
+type Validator {
+  index: Uint64!
+  pubkey: String!
+  effectiveBalance: Uint64!
+  slashed: Boolean!
+  activationEligibilityEpoch: Uint64!
+  activationEpoch: Uint64!
+  exitEpoch: Uint64!
+  withdrawableEpoch: Uint64!
+  balance(epoch: Uint64!): ValidatorBalance
+  proposerDuties: [ProposerDuty!]!
+  attesterDuties(fromSlot: Uint64!, toSlot: Uint64!): [AttesterDuty!]!
+  epochSummaries(fromEpoch: Uint64, toEpoch: Uint64, limit: Int): [ValidatorEpochSummary!]!
+}
+
+type ValidatorBalance {
+  epoch: Uint64!
+  balance: Uint64!
+  effectiveBalance: Uint64!
+}
+
+type ProposerDuty {
+  slot: Uint64!
+  validatorIndex: Uint64!
+  blocks: [Block!]!
+}
+
+type AttesterDuty {
+  slot: Uint64!
+  committee: Uint64!
+  committeeIndex: Uint64!
+  validatorIndex: Uint64!
+  # attestations are the attestations for this duty that include the validator.
+  attestations: [Attestation!]!
+}
+
+type Attestation {
+  inclusionSlot: Uint64!
+  inclusionBlockRoot: String!
+  inclusionIndex: Uint64!
+  slot: Uint64!
+  committeeIndex: Uint64!
+  aggregationIndices: [Uint64!]!
+  beaconBlockRoot: String!
+  sourceEpoch: Uint64!
+  sourceRoot: String!
+  targetEpoch: Uint64!
+  targetRoot: String!
+  canonical: Boolean
+  sourceCorrect: Boolean
+  targetCorrect: Boolean
+  headCorrect: Boolean
+  duplicate: Boolean
+  conflicting: Boolean
+}
+
+type BlockSummary {
+  attestationsForBlock: Int!
+  duplicateAttestationsForBlock: Int!
+  votesForBlock: Int!
+  parentDistance: Int!
+}
+
+type ValidatorEpochSummary {
+  epoch: Uint64!
+  proposerDuties: Int!
+  proposalsIncluded: Int!
+  attestationIncluded: Boolean!
+  attestationTargetCorrect: Boolean
+  attestationHeadCorrect: Boolean
+  attestationInclusionDelay: Int
+  attestationInclusionSlot: Uint64
+  attestationSourceTimely: Boolean
+  attestationTargetTimely: Boolean
+  attestationHeadTimely: Boolean
+}
+`
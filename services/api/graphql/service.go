@@ -0,0 +1,197 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graphql provides a GraphQL server over the chain database, allowing
+// related data to be fetched in a single request.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a GraphQL service exposing the contents of the chain database.
+type Service struct {
+	blocksProvider                  chaindb.BlocksProvider
+	validatorsProvider              chaindb.ValidatorsProvider
+	validatorSet                    validatorset.Service
+	attestationsProvider            chaindb.AttestationsProvider
+	beaconCommitteesProvider        chaindb.BeaconCommitteesProvider
+	proposerDutiesProvider          chaindb.ProposerDutiesProvider
+	blockSummariesProvider          chaindb.BlockSummariesProvider
+	validatorEpochSummariesProvider chaindb.ValidatorEpochSummariesProvider
+	maxSlotRange                    uint64
+	schema                          *graphqlgo.Schema
+	server                          *http.Server
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "api").Str("impl", "graphql").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorSet = validatorsProvider
+	}
+
+	attestationsProvider, isProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide attestations")
+	}
+
+	beaconCommitteesProvider, isProvider := parameters.chainDB.(chaindb.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide beacon committees")
+	}
+
+	proposerDutiesProvider, isProvider := parameters.chainDB.(chaindb.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide proposer duties")
+	}
+
+	blockSummariesProvider, isProvider := parameters.chainDB.(chaindb.BlockSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide block summaries")
+	}
+
+	validatorEpochSummariesProvider, isProvider := parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validator epoch summaries")
+	}
+
+	s := &Service{
+		blocksProvider:                  blocksProvider,
+		validatorsProvider:              validatorsProvider,
+		validatorSet:                    validatorSet,
+		attestationsProvider:            attestationsProvider,
+		beaconCommitteesProvider:        beaconCommitteesProvider,
+		proposerDutiesProvider:          proposerDutiesProvider,
+		blockSummariesProvider:          blockSummariesProvider,
+		validatorEpochSummariesProvider: validatorEpochSummariesProvider,
+		maxSlotRange:                    parameters.maxSlotRange,
+	}
+
+	s.schema, err = graphqlgo.ParseSchema(schema, &resolver{s: s})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse schema")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", s.handleQuery)
+	s.server = &http.Server{
+		Addr:              parameters.listenAddress,
+		Handler:           parameters.authenticator.Require(util.RoleRead, mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("listen_address", parameters.listenAddress).Msg("Starting GraphQL server")
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run GraphQL server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; shutting down GraphQL server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down GraphQL server")
+		}
+	}()
+
+	return s, nil
+}
+
+// queryRequest is a GraphQL request.
+type queryRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// handleQuery handles a GraphQL query.
+func (s *Service) handleQuery(w http.ResponseWriter, r *http.Request) {
+	started := time.Now()
+
+	req := &queryRequest{}
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				http.Error(w, "invalid variables", http.StatusBadRequest)
+				monitorRequest(false, time.Since(started))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			monitorRequest(false, time.Since(started))
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		monitorRequest(false, time.Since(started))
+		return
+	}
+
+	response := s.schema.Exec(r.Context(), req.Query, req.OperationName, req.Variables)
+	for _, err := range response.Errors {
+		log.Debug().Str("error", err.Message).Msg("GraphQL query returned error")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Debug().Err(err).Msg("Failed to write response")
+	}
+	monitorRequest(len(response.Errors) == 0, time.Since(started))
+}
@@ -0,0 +1,83 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/api/graphql"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+
+	tests := []struct {
+		name   string
+		params []graphql.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []graphql.Parameter{
+				graphql.WithLogLevel(zerolog.Disabled),
+				graphql.WithListenAddress("localhost:0"),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ListenAddressMissing",
+			params: []graphql.Parameter{
+				graphql.WithLogLevel(zerolog.Disabled),
+				graphql.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no listen address specified",
+		},
+		{
+			name: "MaxSlotRangeZero",
+			params: []graphql.Parameter{
+				graphql.WithLogLevel(zerolog.Disabled),
+				graphql.WithChainDB(chainDB),
+				graphql.WithListenAddress("localhost:0"),
+				graphql.WithMaxSlotRange(0),
+			},
+			err: "problem with parameters: max slot range must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []graphql.Parameter{
+				graphql.WithLogLevel(zerolog.Disabled),
+				graphql.WithChainDB(chainDB),
+				graphql.WithListenAddress("localhost:0"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := graphql.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
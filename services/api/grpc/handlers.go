@@ -0,0 +1,304 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	chaindv1 "github.com/wealdtech/chaind/proto/chaind/v1"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// internalError logs the underlying error and returns an opaque gRPC error.
+func internalError(err error, msg string) error {
+	log.Warn().Err(err).Msg(msg)
+	return status.Error(codes.Internal, msg)
+}
+
+// checkRange ensures that a range is valid.
+func checkRange(from uint64, to uint64) error {
+	if to <= from {
+		return status.Error(codes.InvalidArgument, "end of range must be greater than start of range")
+	}
+	return nil
+}
+
+// batches splits a range in to batches of at most the service's batch size,
+// calling the supplied function for each.
+func (s *Service) batches(ctx context.Context, from uint64, to uint64, fn func(start uint64, end uint64) error) error {
+	for start := from; start < to; start += s.batchSize {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+		end := start + s.batchSize
+		if end > to || end < start {
+			end = to
+		}
+		if err := fn(start, end); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatorIndices(indices []uint64) []phase0.ValidatorIndex {
+	res := make([]phase0.ValidatorIndex, len(indices))
+	for i := range indices {
+		res[i] = phase0.ValidatorIndex(indices[i])
+	}
+	return res
+}
+
+// GetBlock obtains a single block by its root.
+func (s *Service) GetBlock(ctx context.Context, req *chaindv1.GetBlockRequest) (*chaindv1.Block, error) {
+	if len(req.GetRoot()) != len(phase0.Root{}) {
+		return nil, status.Error(codes.InvalidArgument, "invalid root")
+	}
+	var root phase0.Root
+	copy(root[:], req.GetRoot())
+
+	block, err := s.blocksProvider.BlockByRoot(ctx, root)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && block == nil) {
+		return nil, status.Error(codes.NotFound, "block not found")
+	}
+	if err != nil {
+		return nil, internalError(err, "failed to obtain block")
+	}
+
+	return blockMessage(block), nil
+}
+
+// ListBlocks streams the blocks in a slot range.
+func (s *Service) ListBlocks(req *chaindv1.SlotRangeRequest, stream chaindv1.Chaind_ListBlocksServer) error {
+	if err := checkRange(req.GetFromSlot(), req.GetToSlot()); err != nil {
+		return err
+	}
+
+	return s.batches(stream.Context(), req.GetFromSlot(), req.GetToSlot(), func(start uint64, end uint64) error {
+		blocks, err := s.blocksProvider.BlocksForSlotRange(stream.Context(), phase0.Slot(start), phase0.Slot(end))
+		if err != nil {
+			return internalError(err, "failed to obtain blocks")
+		}
+		for _, block := range blocks {
+			if err := stream.Send(blockMessage(block)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListValidators streams validators, optionally restricted to a set of indices.
+func (s *Service) ListValidators(req *chaindv1.ListValidatorsRequest, stream chaindv1.Chaind_ListValidatorsServer) error {
+	var validators []*chaindb.Validator
+	if len(req.GetIndices()) > 0 {
+		indices := validatorIndices(req.GetIndices())
+		validatorsMap, err := s.validatorSet.ValidatorsByIndex(stream.Context(), indices)
+		if err != nil {
+			return internalError(err, "failed to obtain validators")
+		}
+		for _, index := range indices {
+			if validator, exists := validatorsMap[index]; exists {
+				validators = append(validators, validator)
+			}
+		}
+	} else {
+		var err error
+		validators, err = s.validatorSet.Validators(stream.Context())
+		if err != nil {
+			return internalError(err, "failed to obtain validators")
+		}
+	}
+
+	for _, validator := range validators {
+		if err := stream.Send(validatorMessage(validator)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListValidatorBalances streams validator balances in an epoch range.
+func (s *Service) ListValidatorBalances(req *chaindv1.ListValidatorBalancesRequest, stream chaindv1.Chaind_ListValidatorBalancesServer) error {
+	if len(req.GetIndices()) == 0 {
+		return status.Error(codes.InvalidArgument, "indices are required")
+	}
+	if err := checkRange(req.GetFromEpoch(), req.GetToEpoch()); err != nil {
+		return err
+	}
+	indices := validatorIndices(req.GetIndices())
+
+	return s.batches(stream.Context(), req.GetFromEpoch(), req.GetToEpoch(), func(start uint64, end uint64) error {
+		balances, err := s.validatorsProvider.ValidatorBalancesByIndexAndEpochRange(stream.Context(), indices, phase0.Epoch(start), phase0.Epoch(end))
+		if err != nil {
+			return internalError(err, "failed to obtain validator balances")
+		}
+		for _, index := range indices {
+			for _, balance := range balances[index] {
+				if err := stream.Send(validatorBalanceMessage(balance)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ListAttestations streams the attestations for a slot range.
+func (s *Service) ListAttestations(req *chaindv1.SlotRangeRequest, stream chaindv1.Chaind_ListAttestationsServer) error {
+	if err := checkRange(req.GetFromSlot(), req.GetToSlot()); err != nil {
+		return err
+	}
+
+	return s.batches(stream.Context(), req.GetFromSlot(), req.GetToSlot(), func(start uint64, end uint64) error {
+		attestations, err := s.attestationsProvider.AttestationsForSlotRange(stream.Context(), phase0.Slot(start), phase0.Slot(end))
+		if err != nil {
+			return internalError(err, "failed to obtain attestations")
+		}
+		for _, attestation := range attestations {
+			if err := stream.Send(attestationMessage(attestation)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListProposerDuties streams the proposer duties in a slot range.
+func (s *Service) ListProposerDuties(req *chaindv1.SlotRangeRequest, stream chaindv1.Chaind_ListProposerDutiesServer) error {
+	if err := checkRange(req.GetFromSlot(), req.GetToSlot()); err != nil {
+		return err
+	}
+
+	return s.batches(stream.Context(), req.GetFromSlot(), req.GetToSlot(), func(start uint64, end uint64) error {
+		duties, err := s.proposerDutiesProvider.ProposerDutiesForSlotRange(stream.Context(), phase0.Slot(start), phase0.Slot(end))
+		if err != nil {
+			return internalError(err, "failed to obtain proposer duties")
+		}
+		for _, duty := range duties {
+			if err := stream.Send(&chaindv1.ProposerDuty{
+				Slot:           uint64(duty.Slot),
+				ValidatorIndex: uint64(duty.ValidatorIndex),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListAttesterDuties streams the attester duties in a slot range for a set of validators.
+func (s *Service) ListAttesterDuties(req *chaindv1.ListAttesterDutiesRequest, stream chaindv1.Chaind_ListAttesterDutiesServer) error {
+	if len(req.GetIndices()) == 0 {
+		return status.Error(codes.InvalidArgument, "indices are required")
+	}
+	if err := checkRange(req.GetFromSlot(), req.GetToSlot()); err != nil {
+		return err
+	}
+	indices := validatorIndices(req.GetIndices())
+
+	return s.batches(stream.Context(), req.GetFromSlot(), req.GetToSlot(), func(start uint64, end uint64) error {
+		duties, err := s.beaconCommitteesProvider.AttesterDuties(stream.Context(), phase0.Slot(start), phase0.Slot(end), indices)
+		if err != nil {
+			return internalError(err, "failed to obtain attester duties")
+		}
+		for _, duty := range duties {
+			if err := stream.Send(&chaindv1.AttesterDuty{
+				Slot:           uint64(duty.Slot),
+				Committee:      uint64(duty.Committee),
+				ValidatorIndex: uint64(duty.ValidatorIndex),
+				CommitteeIndex: duty.CommitteeIndex,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListBlockSummaries streams the block summaries in a slot range.
+func (s *Service) ListBlockSummaries(req *chaindv1.SlotRangeRequest, stream chaindv1.Chaind_ListBlockSummariesServer) error {
+	if err := checkRange(req.GetFromSlot(), req.GetToSlot()); err != nil {
+		return err
+	}
+
+	return s.batches(stream.Context(), req.GetFromSlot(), req.GetToSlot(), func(start uint64, end uint64) error {
+		for slot := start; slot < end; slot++ {
+			summary, err := s.blockSummariesProvider.BlockSummaryForSlot(stream.Context(), phase0.Slot(slot))
+			if errors.Is(err, pgx.ErrNoRows) || (err == nil && summary == nil) {
+				continue
+			}
+			if err != nil {
+				return internalError(err, "failed to obtain block summary")
+			}
+			if err := stream.Send(&chaindv1.BlockSummary{
+				Slot:                          uint64(summary.Slot),
+				AttestationsForBlock:          int64(summary.AttestationsForBlock),
+				DuplicateAttestationsForBlock: int64(summary.DuplicateAttestationsForBlock),
+				VotesForBlock:                 int64(summary.VotesForBlock),
+				ParentDistance:                int64(summary.ParentDistance),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListValidatorEpochSummaries streams validator epoch summaries in an epoch range.
+func (s *Service) ListValidatorEpochSummaries(req *chaindv1.ListValidatorEpochSummariesRequest, stream chaindv1.Chaind_ListValidatorEpochSummariesServer) error {
+	if err := checkRange(req.GetFromEpoch(), req.GetToEpoch()); err != nil {
+		return err
+	}
+	indices := validatorIndices(req.GetIndices())
+
+	return s.batches(stream.Context(), req.GetFromEpoch(), req.GetToEpoch(), func(start uint64, end uint64) error {
+		var summaries []*chaindb.ValidatorEpochSummary
+		if len(indices) == 0 {
+			// No filter on validators, so fetch each epoch in turn to bound the size of the result.
+			for epoch := start; epoch < end; epoch++ {
+				epochSummaries, err := s.validatorEpochSummariesProvider.ValidatorSummariesForEpoch(stream.Context(), phase0.Epoch(epoch))
+				if err != nil {
+					return internalError(err, "failed to obtain validator epoch summaries")
+				}
+				summaries = append(summaries, epochSummaries...)
+			}
+		} else {
+			from := phase0.Epoch(start)
+			to := phase0.Epoch(end - 1)
+			var err error
+			summaries, err = s.validatorEpochSummariesProvider.ValidatorSummaries(stream.Context(), &chaindb.ValidatorSummaryFilter{
+				Limit:            uint32(uint64(len(indices)) * (end - start)),
+				Order:            chaindb.OrderEarliest,
+				From:             &from,
+				To:               &to,
+				ValidatorIndices: &indices,
+			})
+			if err != nil {
+				return internalError(err, "failed to obtain validator epoch summaries")
+			}
+		}
+		for _, summary := range summaries {
+			if err := stream.Send(validatorEpochSummaryMessage(summary)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+	"google.golang.org/grpc/codes"
+)
+
+var metricsNamespace = "chaind_grpc"
+
+var requests *prometheus.CounterVec
+var requestDuration *prometheus.HistogramVec
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if requests != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "requests_total",
+		Help:      "Number of gRPC requests",
+	}, []string{"method", "code"})
+	if err := prometheus.Register(requests); err != nil {
+		return errors.Wrap(err, "failed to register requests_total")
+	}
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to handle gRPC requests",
+	}, []string{"method"})
+	if err := prometheus.Register(requestDuration); err != nil {
+		return errors.Wrap(err, "failed to register request_duration_seconds")
+	}
+
+	return nil
+}
+
+func monitorRequest(method string, code codes.Code, duration time.Duration) {
+	if requests != nil {
+		requests.WithLabelValues(method, code.String()).Inc()
+		requestDuration.WithLabelValues(method).Observe(duration.Seconds())
+	}
+}
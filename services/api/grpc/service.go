@@ -0,0 +1,208 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpc provides a gRPC server over the chain database, streaming
+// results for large ranges.
+package grpc
+
+import (
+	"context"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	chaindv1 "github.com/wealdtech/chaind/proto/chaind/v1"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Service is a gRPC service exposing the contents of the chain database.
+type Service struct {
+	chaindv1.UnimplementedChaindServer
+	blocksProvider                  chaindb.BlocksProvider
+	validatorsProvider              chaindb.ValidatorsProvider
+	validatorSet                    validatorset.Service
+	attestationsProvider            chaindb.AttestationsProvider
+	beaconCommitteesProvider        chaindb.BeaconCommitteesProvider
+	proposerDutiesProvider          chaindb.ProposerDutiesProvider
+	blockSummariesProvider          chaindb.BlockSummariesProvider
+	validatorEpochSummariesProvider chaindb.ValidatorEpochSummariesProvider
+	batchSize                       uint64
+	authenticator                   *util.Authenticator
+	server                          *grpcgo.Server
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "api").Str("impl", "grpc").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorSet = validatorsProvider
+	}
+
+	attestationsProvider, isProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide attestations")
+	}
+
+	beaconCommitteesProvider, isProvider := parameters.chainDB.(chaindb.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide beacon committees")
+	}
+
+	proposerDutiesProvider, isProvider := parameters.chainDB.(chaindb.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide proposer duties")
+	}
+
+	blockSummariesProvider, isProvider := parameters.chainDB.(chaindb.BlockSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide block summaries")
+	}
+
+	validatorEpochSummariesProvider, isProvider := parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validator epoch summaries")
+	}
+
+	listener, err := net.Listen("tcp", parameters.listenAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen")
+	}
+
+	s := &Service{
+		blocksProvider:                  blocksProvider,
+		validatorsProvider:              validatorsProvider,
+		validatorSet:                    validatorSet,
+		attestationsProvider:            attestationsProvider,
+		beaconCommitteesProvider:        beaconCommitteesProvider,
+		proposerDutiesProvider:          proposerDutiesProvider,
+		blockSummariesProvider:          blockSummariesProvider,
+		validatorEpochSummariesProvider: validatorEpochSummariesProvider,
+		batchSize:                       parameters.batchSize,
+		authenticator:                   parameters.authenticator,
+	}
+	s.server = grpcgo.NewServer(
+		grpcgo.UnaryInterceptor(s.unaryInterceptor),
+		grpcgo.StreamInterceptor(s.streamInterceptor),
+	)
+	chaindv1.RegisterChaindServer(s.server, s)
+
+	go func() {
+		log.Info().Str("listen_address", parameters.listenAddress).Msg("Starting gRPC server")
+		if err := s.server.Serve(listener); err != nil {
+			log.Error().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run gRPC server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; shutting down gRPC server")
+		s.server.GracefulStop()
+	}()
+
+	return s, nil
+}
+
+func (s *Service) unaryInterceptor(ctx context.Context,
+	req interface{},
+	info *grpcgo.UnaryServerInfo,
+	handler grpcgo.UnaryHandler,
+) (
+	interface{},
+	error,
+) {
+	started := time.Now()
+	var res interface{}
+	err := s.authorize(ctx)
+	if err == nil {
+		res, err = handler(ctx, req)
+	}
+	monitorRequest(path.Base(info.FullMethod), status.Code(err), time.Since(started))
+	if err != nil {
+		log.Debug().Str("method", info.FullMethod).Err(err).Msg("Request failed")
+	}
+	return res, err
+}
+
+func (s *Service) streamInterceptor(srv interface{},
+	stream grpcgo.ServerStream,
+	info *grpcgo.StreamServerInfo,
+	handler grpcgo.StreamHandler,
+) error {
+	started := time.Now()
+	err := s.authorize(stream.Context())
+	if err == nil {
+		err = handler(srv, stream)
+	}
+	monitorRequest(path.Base(info.FullMethod), status.Code(err), time.Since(started))
+	if err != nil {
+		log.Debug().Str("method", info.FullMethod).Err(err).Msg("Request failed")
+	}
+	return err
+}
+
+// authorize checks that a request supplies credentials with the read role in
+// its authorization metadata, if the server requires authentication.
+func (s *Service) authorize(ctx context.Context) error {
+	if s.authenticator == nil {
+		return nil
+	}
+
+	token := ""
+	if md, exists := metadata.FromIncomingContext(ctx); exists {
+		if values := md.Get("authorization"); len(values) > 0 {
+			token = strings.TrimPrefix(values[0], "Bearer ")
+		}
+	}
+	if s.authenticator.TokenRole(token) < util.RoleRead {
+		return status.Error(codes.Unauthenticated, "unauthorized")
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// testStream is a server stream that carries a context.
+type testStream struct {
+	grpcgo.ServerStream
+	ctx context.Context
+}
+
+func (s *testStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthorize(t *testing.T) {
+	authenticator := util.NewAuthenticator([]string{"readkey"}, []string{"adminkey"}, "")
+
+	tests := []struct {
+		name          string
+		authenticator *util.Authenticator
+		md            metadata.MD
+		code          codes.Code
+	}{
+		{
+			name: "NoAuthenticator",
+			code: codes.OK,
+		},
+		{
+			name:          "NoCredentials",
+			authenticator: authenticator,
+			code:          codes.Unauthenticated,
+		},
+		{
+			name:          "BadCredentials",
+			authenticator: authenticator,
+			md:            metadata.Pairs("authorization", "Bearer otherkey"),
+			code:          codes.Unauthenticated,
+		},
+		{
+			name:          "ReadKey",
+			authenticator: authenticator,
+			md:            metadata.Pairs("authorization", "Bearer readkey"),
+			code:          codes.OK,
+		},
+		{
+			name:          "AdminKey",
+			authenticator: authenticator,
+			md:            metadata.Pairs("authorization", "Bearer adminkey"),
+			code:          codes.OK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{authenticator: test.authenticator}
+			ctx := context.Background()
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+
+			called := false
+			_, err := s.unaryInterceptor(ctx, nil, &grpcgo.UnaryServerInfo{FullMethod: "/chaind.v1.Chaind/GetBlock"},
+				func(context.Context, interface{}) (interface{}, error) {
+					called = true
+					return nil, nil
+				})
+			require.Equal(t, test.code, status.Code(err))
+			require.Equal(t, test.code == codes.OK, called)
+
+			called = false
+			err = s.streamInterceptor(nil, &testStream{ctx: ctx}, &grpcgo.StreamServerInfo{FullMethod: "/chaind.v1.Chaind/ListBlocks"},
+				func(interface{}, grpcgo.ServerStream) error {
+					called = true
+					return nil
+				})
+			require.Equal(t, test.code, status.Code(err))
+			require.Equal(t, test.code == codes.OK, called)
+		})
+	}
+}
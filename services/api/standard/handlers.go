@@ -0,0 +1,398 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// maxValidatorSummaries is the maximum number of validator summaries returned in a single request.
+const maxValidatorSummaries = 10000
+
+// maxPerformanceValidators is the maximum number of validators for which performance is returned in a single request.
+const maxPerformanceValidators = 1000
+
+// isNotFound returns true if the error from the chain database indicates that
+// the requested item does not exist.
+func isNotFound(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// getBlocks handles /v1/blocks?from_slot=&to_slot=
+func (s *Service) getBlocks(ctx context.Context, r *http.Request) (interface{}, error) {
+	from, to, err := s.slotRangeParams(r.URL.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, from, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	labels, err := s.addressLabels(ctx, BlockAddresses(blocks))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*blockJSON, len(blocks))
+	for i := range blocks {
+		res[i] = blockToJSON(blocks[i], labels)
+	}
+	return res, nil
+}
+
+// getBlock handles /v1/blocks/{slot|root}
+func (s *Service) getBlock(ctx context.Context, r *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/blocks/")
+
+	if strings.HasPrefix(id, "0x") {
+		root, err := parseRoot(id)
+		if err != nil {
+			return nil, err
+		}
+		block, err := s.blocksProvider.BlockByRoot(ctx, root)
+		if isNotFound(err) || (err == nil && block == nil) {
+			return nil, notFound("block not found")
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain block")
+		}
+		labels, err := s.addressLabels(ctx, BlockAddresses([]*chaindb.Block{block}))
+		if err != nil {
+			return nil, err
+		}
+		return []*blockJSON{blockToJSON(block, labels)}, nil
+	}
+
+	slot, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, badRequest("invalid block ID %q", id)
+	}
+	blocks, err := s.blocksProvider.BlocksBySlot(ctx, phase0.Slot(slot))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+	if len(blocks) == 0 {
+		return nil, notFound("block not found")
+	}
+	labels, err := s.addressLabels(ctx, BlockAddresses(blocks))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*blockJSON, len(blocks))
+	for i := range blocks {
+		res[i] = blockToJSON(blocks[i], labels)
+	}
+	return res, nil
+}
+
+// getValidators handles /v1/validators?indices=&pubkeys=
+func (s *Service) getValidators(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	indices, err := validatorIndicesParam(query, "indices")
+	if err != nil {
+		return nil, err
+	}
+	pubKeys, err := pubKeysParam(query, "pubkeys")
+	if err != nil {
+		return nil, err
+	}
+
+	var validators []*chaindb.Validator
+	switch {
+	case len(indices) > 0:
+		validatorsMap, err := s.validatorSet.ValidatorsByIndex(ctx, indices)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		for _, validator := range validatorsMap {
+			validators = append(validators, validator)
+		}
+	case len(pubKeys) > 0:
+		validatorsMap, err := s.validatorSet.ValidatorsByPublicKey(ctx, pubKeys)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+		for _, validator := range validatorsMap {
+			validators = append(validators, validator)
+		}
+	default:
+		validators, err = s.validatorSet.Validators(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators")
+		}
+	}
+	sort.Slice(validators, func(i int, j int) bool {
+		return validators[i].Index < validators[j].Index
+	})
+
+	labels, err := s.addressLabels(ctx, ValidatorAddresses(validators))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*validatorJSON, len(validators))
+	for i := range validators {
+		res[i] = validatorToJSON(validators[i], labels)
+	}
+	return res, nil
+}
+
+// getAttestations handles /v1/attestations?block_root= and /v1/attestations?from_slot=&to_slot=
+func (s *Service) getAttestations(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	var attestations []*chaindb.Attestation
+	if query.Get("block_root") != "" {
+		root, err := parseRoot(query.Get("block_root"))
+		if err != nil {
+			return nil, err
+		}
+		attestations, err = s.attestationsProvider.AttestationsInBlock(ctx, root)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain attestations")
+		}
+	} else {
+		from, to, err := s.slotRangeParams(query)
+		if err != nil {
+			return nil, err
+		}
+		attestations, err = s.attestationsProvider.AttestationsForSlotRange(ctx, from, to)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain attestations")
+		}
+	}
+
+	res := make([]*attestationJSON, len(attestations))
+	for i := range attestations {
+		res[i] = attestationToJSON(attestations[i])
+	}
+	return res, nil
+}
+
+// getBeaconCommittees handles /v1/beacon_committees?slot=&index=
+func (s *Service) getBeaconCommittees(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	slot, err := uint64Param(query, "slot")
+	if err != nil {
+		return nil, err
+	}
+	index, err := uint64Param(query, "index")
+	if err != nil {
+		return nil, err
+	}
+	if slot == nil || index == nil {
+		return nil, badRequest("slot and index are required")
+	}
+
+	committee, err := s.beaconCommitteesProvider.BeaconCommitteeBySlotAndIndex(ctx, phase0.Slot(*slot), phase0.CommitteeIndex(*index))
+	if isNotFound(err) || (err == nil && committee == nil) {
+		return nil, notFound("beacon committee not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain beacon committee")
+	}
+
+	return []*beaconCommitteeJSON{beaconCommitteeToJSON(committee)}, nil
+}
+
+// getProposerDuties handles /v1/proposer_duties?validator= and /v1/proposer_duties?from_slot=&to_slot=
+func (s *Service) getProposerDuties(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	var duties []*chaindb.ProposerDuty
+	validator, err := uint64Param(query, "validator")
+	if err != nil {
+		return nil, err
+	}
+	if validator != nil {
+		duties, err = s.proposerDutiesProvider.ProposerDutiesForValidator(ctx, phase0.ValidatorIndex(*validator))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain proposer duties")
+		}
+	} else {
+		from, to, err := s.slotRangeParams(query)
+		if err != nil {
+			return nil, err
+		}
+		duties, err = s.proposerDutiesProvider.ProposerDutiesForSlotRange(ctx, from, to)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain proposer duties")
+		}
+	}
+
+	res := make([]*proposerDutyJSON, len(duties))
+	for i := range duties {
+		res[i] = proposerDutyToJSON(duties[i])
+	}
+	return res, nil
+}
+
+// getAttesterDuties handles /v1/attester_duties?from_slot=&to_slot=&validators=
+func (s *Service) getAttesterDuties(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	from, to, err := s.slotRangeParams(query)
+	if err != nil {
+		return nil, err
+	}
+	validators, err := validatorIndicesParam(query, "validators")
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, badRequest("validators is required")
+	}
+
+	duties, err := s.beaconCommitteesProvider.AttesterDuties(ctx, from, to, validators)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain attester duties")
+	}
+
+	res := make([]*attesterDutyJSON, len(duties))
+	for i := range duties {
+		res[i] = attesterDutyToJSON(duties[i])
+	}
+	return res, nil
+}
+
+// getBlockSummary handles /v1/block_summaries/{slot}
+func (s *Service) getBlockSummary(ctx context.Context, r *http.Request) (interface{}, error) {
+	id := strings.TrimPrefix(r.URL.Path, "/v1/block_summaries/")
+	slot, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return nil, badRequest("invalid slot %q", id)
+	}
+
+	summary, err := s.blockSummariesProvider.BlockSummaryForSlot(ctx, phase0.Slot(slot))
+	if isNotFound(err) || (err == nil && summary == nil) {
+		return nil, notFound("block summary not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block summary")
+	}
+
+	return blockSummaryToJSON(summary), nil
+}
+
+// getValidatorSummaries handles /v1/validator_summaries?from_epoch=&to_epoch=&validators=&limit=&order=
+func (s *Service) getValidatorSummaries(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	filter := &chaindb.ValidatorSummaryFilter{
+		Limit: maxValidatorSummaries,
+	}
+	from, err := uint64Param(query, "from_epoch")
+	if err != nil {
+		return nil, err
+	}
+	if from != nil {
+		epoch := phase0.Epoch(*from)
+		filter.From = &epoch
+	}
+	to, err := uint64Param(query, "to_epoch")
+	if err != nil {
+		return nil, err
+	}
+	if to != nil {
+		epoch := phase0.Epoch(*to)
+		filter.To = &epoch
+	}
+	validators, err := validatorIndicesParam(query, "validators")
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) > 0 {
+		filter.ValidatorIndices = &validators
+	}
+	limit, err := uint64Param(query, "limit")
+	if err != nil {
+		return nil, err
+	}
+	if limit != nil {
+		if *limit == 0 || *limit > maxValidatorSummaries {
+			return nil, badRequest("limit must be between 1 and %d", maxValidatorSummaries)
+		}
+		filter.Limit = uint32(*limit)
+	}
+	switch query.Get("order") {
+	case "", "earliest":
+		filter.Order = chaindb.OrderEarliest
+	case "latest":
+		filter.Order = chaindb.OrderLatest
+	default:
+		return nil, badRequest("order must be earliest or latest")
+	}
+
+	summaries, err := s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator summaries")
+	}
+
+	res := make([]*validatorEpochSummaryJSON, len(summaries))
+	for i := range summaries {
+		res[i] = validatorEpochSummaryToJSON(summaries[i])
+	}
+	return res, nil
+}
+
+func (s *Service) getValidatorPerformance(ctx context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+
+	validators, err := validatorIndicesParam(query, "validators")
+	if err != nil {
+		return nil, err
+	}
+	if len(validators) == 0 {
+		return nil, badRequest("validators is required")
+	}
+	if len(validators) > maxPerformanceValidators {
+		return nil, badRequest("no more than %d validators can be requested", maxPerformanceValidators)
+	}
+	from, err := uint64Param(query, "from_epoch")
+	if err != nil {
+		return nil, err
+	}
+	if from == nil {
+		return nil, badRequest("from_epoch is required")
+	}
+	to, err := uint64Param(query, "to_epoch")
+	if err != nil {
+		return nil, err
+	}
+	if to == nil {
+		return nil, badRequest("to_epoch is required")
+	}
+	if *to <= *from {
+		return nil, badRequest("to_epoch must be after from_epoch")
+	}
+
+	performances, err := s.aggregatesProvider.ValidatorPerformanceForEpochRange(ctx, validators, phase0.Epoch(*from), phase0.Epoch(*to))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator performance")
+	}
+
+	res := make([]*validatorPerformanceJSON, len(performances))
+	for i := range performances {
+		res[i] = validatorPerformanceToJSON(performances[i])
+	}
+	return res, nil
+}
@@ -0,0 +1,347 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"fmt"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// The JSON representations follow the conventions of the beacon node API:
+// numbers are returned as strings and byte arrays as 0x-prefixed hex strings.
+
+type blockJSON struct {
+	Slot             string                `json:"slot"`
+	ProposerIndex    string                `json:"proposer_index"`
+	Root             string                `json:"root"`
+	Graffiti         string                `json:"graffiti"`
+	RANDAOReveal     string                `json:"randao_reveal"`
+	BodyRoot         string                `json:"body_root"`
+	ParentRoot       string                `json:"parent_root"`
+	StateRoot        string                `json:"state_root"`
+	Canonical        *bool                 `json:"canonical"`
+	ETH1BlockHash    string                `json:"eth1_block_hash"`
+	ETH1DepositCount string                `json:"eth1_deposit_count"`
+	ETH1DepositRoot  string                `json:"eth1_deposit_root"`
+	ExecutionPayload *executionPayloadJSON `json:"execution_payload,omitempty"`
+	SeenDelay        string                `json:"seen_delay,omitempty"`
+}
+
+type executionPayloadJSON struct {
+	ParentHash        string `json:"parent_hash"`
+	FeeRecipient      string `json:"fee_recipient"`
+	StateRoot         string `json:"state_root"`
+	ReceiptsRoot      string `json:"receipts_root"`
+	LogsBloom         string `json:"logs_bloom"`
+	PrevRandao        string `json:"prev_randao"`
+	BlockNumber       string `json:"block_number"`
+	GasLimit          string `json:"gas_limit"`
+	GasUsed           string `json:"gas_used"`
+	Timestamp         string `json:"timestamp"`
+	ExtraData         string `json:"extra_data"`
+	BaseFeePerGas     string `json:"base_fee_per_gas"`
+	BlockHash         string `json:"block_hash"`
+	FeeRecipientLabel string `json:"fee_recipient_label,omitempty"`
+}
+
+type validatorJSON struct {
+	PublicKey                  string `json:"pubkey"`
+	Index                      string `json:"index"`
+	EffectiveBalance           string `json:"effective_balance"`
+	Slashed                    bool   `json:"slashed"`
+	ActivationEligibilityEpoch string `json:"activation_eligibility_epoch"`
+	ActivationEpoch            string `json:"activation_epoch"`
+	ExitEpoch                  string `json:"exit_epoch"`
+	WithdrawableEpoch          string `json:"withdrawable_epoch"`
+	WithdrawalAddressLabel     string `json:"withdrawal_address_label,omitempty"`
+}
+
+type attestationJSON struct {
+	InclusionSlot      string   `json:"inclusion_slot"`
+	InclusionBlockRoot string   `json:"inclusion_block_root"`
+	InclusionIndex     string   `json:"inclusion_index"`
+	Slot               string   `json:"slot"`
+	CommitteeIndex     string   `json:"committee_index"`
+	AggregationBits    string   `json:"aggregation_bits"`
+	AggregationIndices []string `json:"aggregation_indices"`
+	BeaconBlockRoot    string   `json:"beacon_block_root"`
+	SourceEpoch        string   `json:"source_epoch"`
+	SourceRoot         string   `json:"source_root"`
+	TargetEpoch        string   `json:"target_epoch"`
+	TargetRoot         string   `json:"target_root"`
+	Canonical          *bool    `json:"canonical"`
+	SourceCorrect      *bool    `json:"source_correct"`
+	TargetCorrect      *bool    `json:"target_correct"`
+	HeadCorrect        *bool    `json:"head_correct"`
+	Duplicate          *bool    `json:"duplicate"`
+	Conflicting        *bool    `json:"conflicting"`
+}
+
+type beaconCommitteeJSON struct {
+	Slot      string   `json:"slot"`
+	Index     string   `json:"index"`
+	Committee []string `json:"committee"`
+}
+
+type proposerDutyJSON struct {
+	Slot           string `json:"slot"`
+	ValidatorIndex string `json:"validator_index"`
+}
+
+type attesterDutyJSON struct {
+	Slot           string `json:"slot"`
+	Committee      string `json:"committee"`
+	ValidatorIndex string `json:"validator_index"`
+	CommitteeIndex string `json:"committee_index"`
+}
+
+type blockSummaryJSON struct {
+	Slot                          string `json:"slot"`
+	AttestationsForBlock          int    `json:"attestations_for_block"`
+	DuplicateAttestationsForBlock int    `json:"duplicate_attestations_for_block"`
+	VotesForBlock                 int    `json:"votes_for_block"`
+	ParentDistance                int    `json:"parent_distance"`
+}
+
+type validatorEpochSummaryJSON struct {
+	Index                     string  `json:"index"`
+	Epoch                     string  `json:"epoch"`
+	ProposerDuties            int     `json:"proposer_duties"`
+	ProposalsIncluded         int     `json:"proposals_included"`
+	AttestationIncluded       bool    `json:"attestation_included"`
+	AttestationTargetCorrect  *bool   `json:"attestation_target_correct"`
+	AttestationHeadCorrect    *bool   `json:"attestation_head_correct"`
+	AttestationInclusionDelay *int    `json:"attestation_inclusion_delay"`
+	AttestationInclusionSlot  *string `json:"attestation_inclusion_slot"`
+	AttestationSourceTimely   *bool   `json:"attestation_source_timely"`
+	AttestationTargetTimely   *bool   `json:"attestation_target_timely"`
+	AttestationHeadTimely     *bool   `json:"attestation_head_timely"`
+}
+
+type validatorPerformanceJSON struct {
+	Index                     string  `json:"index"`
+	Epochs                    int     `json:"epochs"`
+	AttestationsIncluded      int     `json:"attestations_included"`
+	AttestationsTargetCorrect int     `json:"attestations_target_correct"`
+	AttestationsHeadCorrect   int     `json:"attestations_head_correct"`
+	AttestationsSourceTimely  int     `json:"attestations_source_timely"`
+	AttestationsTargetTimely  int     `json:"attestations_target_timely"`
+	AttestationsHeadTimely    int     `json:"attestations_head_timely"`
+	InclusionDelay            float64 `json:"inclusion_delay"`
+	ProposerDuties            int     `json:"proposer_duties"`
+	ProposalsIncluded         int     `json:"proposals_included"`
+	StartBalance              *string `json:"start_balance"`
+	EndBalance                *string `json:"end_balance"`
+	Deposits                  string  `json:"deposits"`
+	Income                    *string `json:"income"`
+}
+
+func blockToJSON(block *chaindb.Block, labels map[[20]byte]string) *blockJSON {
+	res := &blockJSON{
+		Slot:             fmt.Sprintf("%d", block.Slot),
+		ProposerIndex:    fmt.Sprintf("%d", block.ProposerIndex),
+		Root:             fmt.Sprintf("%#x", block.Root),
+		Graffiti:         fmt.Sprintf("%#x", block.Graffiti),
+		RANDAOReveal:     fmt.Sprintf("%#x", block.RANDAOReveal),
+		BodyRoot:         fmt.Sprintf("%#x", block.BodyRoot),
+		ParentRoot:       fmt.Sprintf("%#x", block.ParentRoot),
+		StateRoot:        fmt.Sprintf("%#x", block.StateRoot),
+		Canonical:        block.Canonical,
+		ETH1BlockHash:    fmt.Sprintf("%#x", block.ETH1BlockHash),
+		ETH1DepositCount: fmt.Sprintf("%d", block.ETH1DepositCount),
+		ETH1DepositRoot:  fmt.Sprintf("%#x", block.ETH1DepositRoot),
+	}
+	if block.SeenDelay != nil {
+		res.SeenDelay = fmt.Sprintf("%d", block.SeenDelay.Milliseconds())
+	}
+	if block.ExecutionPayload != nil {
+		payload := block.ExecutionPayload
+		res.ExecutionPayload = &executionPayloadJSON{
+			ParentHash:   fmt.Sprintf("%#x", payload.ParentHash),
+			FeeRecipient: fmt.Sprintf("%#x", payload.FeeRecipient),
+			StateRoot:    fmt.Sprintf("%#x", payload.StateRoot),
+			ReceiptsRoot: fmt.Sprintf("%#x", payload.ReceiptsRoot),
+			LogsBloom:    fmt.Sprintf("%#x", payload.LogsBloom),
+			PrevRandao:   fmt.Sprintf("%#x", payload.PrevRandao),
+			BlockNumber:  fmt.Sprintf("%d", payload.BlockNumber),
+			GasLimit:     fmt.Sprintf("%d", payload.GasLimit),
+			GasUsed:      fmt.Sprintf("%d", payload.GasUsed),
+			Timestamp:    fmt.Sprintf("%d", payload.Timestamp),
+			ExtraData:    fmt.Sprintf("%#x", payload.ExtraData),
+			BlockHash:    fmt.Sprintf("%#x", payload.BlockHash),
+		}
+		res.ExecutionPayload.FeeRecipientLabel = labels[payload.FeeRecipient]
+		if payload.BaseFeePerGas != nil {
+			res.ExecutionPayload.BaseFeePerGas = payload.BaseFeePerGas.String()
+		}
+	}
+	return res
+}
+
+func validatorToJSON(validator *chaindb.Validator, labels map[[20]byte]string) *validatorJSON {
+	res := &validatorJSON{
+		PublicKey:                  fmt.Sprintf("%#x", validator.PublicKey),
+		Index:                      fmt.Sprintf("%d", validator.Index),
+		EffectiveBalance:           fmt.Sprintf("%d", validator.EffectiveBalance),
+		Slashed:                    validator.Slashed,
+		ActivationEligibilityEpoch: fmt.Sprintf("%d", validator.ActivationEligibilityEpoch),
+		ActivationEpoch:            fmt.Sprintf("%d", validator.ActivationEpoch),
+		ExitEpoch:                  fmt.Sprintf("%d", validator.ExitEpoch),
+		WithdrawableEpoch:          fmt.Sprintf("%d", validator.WithdrawableEpoch),
+	}
+	if address, exists := withdrawalAddress(validator.WithdrawalCredentials); exists {
+		res.WithdrawalAddressLabel = labels[address]
+	}
+	return res
+}
+
+func attestationToJSON(attestation *chaindb.Attestation) *attestationJSON {
+	indices := make([]string, len(attestation.AggregationIndices))
+	for i := range attestation.AggregationIndices {
+		indices[i] = fmt.Sprintf("%d", attestation.AggregationIndices[i])
+	}
+	return &attestationJSON{
+		InclusionSlot:      fmt.Sprintf("%d", attestation.InclusionSlot),
+		InclusionBlockRoot: fmt.Sprintf("%#x", attestation.InclusionBlockRoot),
+		InclusionIndex:     fmt.Sprintf("%d", attestation.InclusionIndex),
+		Slot:               fmt.Sprintf("%d", attestation.Slot),
+		CommitteeIndex:     fmt.Sprintf("%d", attestation.CommitteeIndex),
+		AggregationBits:    fmt.Sprintf("%#x", attestation.AggregationBits),
+		AggregationIndices: indices,
+		BeaconBlockRoot:    fmt.Sprintf("%#x", attestation.BeaconBlockRoot),
+		SourceEpoch:        fmt.Sprintf("%d", attestation.SourceEpoch),
+		SourceRoot:         fmt.Sprintf("%#x", attestation.SourceRoot),
+		TargetEpoch:        fmt.Sprintf("%d", attestation.TargetEpoch),
+		TargetRoot:         fmt.Sprintf("%#x", attestation.TargetRoot),
+		Canonical:          attestation.Canonical,
+		SourceCorrect:      attestation.SourceCorrect,
+		TargetCorrect:      attestation.TargetCorrect,
+		HeadCorrect:        attestation.HeadCorrect,
+		Duplicate:          attestation.Duplicate,
+		Conflicting:        attestation.Conflicting,
+	}
+}
+
+func beaconCommitteeToJSON(committee *chaindb.BeaconCommittee) *beaconCommitteeJSON {
+	members := make([]string, len(committee.Committee))
+	for i := range committee.Committee {
+		members[i] = fmt.Sprintf("%d", committee.Committee[i])
+	}
+	return &beaconCommitteeJSON{
+		Slot:      fmt.Sprintf("%d", committee.Slot),
+		Index:     fmt.Sprintf("%d", committee.Index),
+		Committee: members,
+	}
+}
+
+func proposerDutyToJSON(duty *chaindb.ProposerDuty) *proposerDutyJSON {
+	return &proposerDutyJSON{
+		Slot:           fmt.Sprintf("%d", duty.Slot),
+		ValidatorIndex: fmt.Sprintf("%d", duty.ValidatorIndex),
+	}
+}
+
+func attesterDutyToJSON(duty *chaindb.AttesterDuty) *attesterDutyJSON {
+	return &attesterDutyJSON{
+		Slot:           fmt.Sprintf("%d", duty.Slot),
+		Committee:      fmt.Sprintf("%d", duty.Committee),
+		ValidatorIndex: fmt.Sprintf("%d", duty.ValidatorIndex),
+		CommitteeIndex: fmt.Sprintf("%d", duty.CommitteeIndex),
+	}
+}
+
+func blockSummaryToJSON(summary *chaindb.BlockSummary) *blockSummaryJSON {
+	return &blockSummaryJSON{
+		Slot:                          fmt.Sprintf("%d", summary.Slot),
+		AttestationsForBlock:          summary.AttestationsForBlock,
+		DuplicateAttestationsForBlock: summary.DuplicateAttestationsForBlock,
+		VotesForBlock:                 summary.VotesForBlock,
+		ParentDistance:                summary.ParentDistance,
+	}
+}
+
+func validatorEpochSummaryToJSON(summary *chaindb.ValidatorEpochSummary) *validatorEpochSummaryJSON {
+	res := &validatorEpochSummaryJSON{
+		Index:                     fmt.Sprintf("%d", summary.Index),
+		Epoch:                     fmt.Sprintf("%d", summary.Epoch),
+		ProposerDuties:            summary.ProposerDuties,
+		ProposalsIncluded:         summary.ProposalsIncluded,
+		AttestationIncluded:       summary.AttestationIncluded,
+		AttestationTargetCorrect:  summary.AttestationTargetCorrect,
+		AttestationHeadCorrect:    summary.AttestationHeadCorrect,
+		AttestationInclusionDelay: summary.AttestationInclusionDelay,
+		AttestationSourceTimely:   summary.AttestationSourceTimely,
+		AttestationTargetTimely:   summary.AttestationTargetTimely,
+		AttestationHeadTimely:     summary.AttestationHeadTimely,
+	}
+	if summary.AttestationInclusionSlot != nil {
+		inclusionSlot := fmt.Sprintf("%d", *summary.AttestationInclusionSlot)
+		res.AttestationInclusionSlot = &inclusionSlot
+	}
+
+	return res
+}
+
+func validatorPerformanceToJSON(performance *chaindb.ValidatorPerformance) *validatorPerformanceJSON {
+	res := &validatorPerformanceJSON{
+		Index:                     fmt.Sprintf("%d", performance.Index),
+		Epochs:                    performance.Epochs,
+		AttestationsIncluded:      performance.AttestationsIncluded,
+		AttestationsTargetCorrect: performance.AttestationsTargetCorrect,
+		AttestationsHeadCorrect:   performance.AttestationsHeadCorrect,
+		AttestationsSourceTimely:  performance.AttestationsSourceTimely,
+		AttestationsTargetTimely:  performance.AttestationsTargetTimely,
+		AttestationsHeadTimely:    performance.AttestationsHeadTimely,
+		InclusionDelay:            performance.InclusionDelay,
+		ProposerDuties:            performance.ProposerDuties,
+		ProposalsIncluded:         performance.ProposalsIncluded,
+		Deposits:                  fmt.Sprintf("%d", performance.Deposits),
+	}
+	if performance.StartBalance != nil {
+		startBalance := fmt.Sprintf("%d", *performance.StartBalance)
+		res.StartBalance = &startBalance
+	}
+	if performance.EndBalance != nil {
+		endBalance := fmt.Sprintf("%d", *performance.EndBalance)
+		res.EndBalance = &endBalance
+	}
+	if performance.Income != nil {
+		income := fmt.Sprintf("%d", *performance.Income)
+		res.Income = &income
+	}
+	return res
+}
+
+// BlockJSON returns the JSON representation of a block, as used by the REST API.
+// The fee recipient is labeled if it is present in labels.
+func BlockJSON(block *chaindb.Block, labels map[[20]byte]string) interface{} {
+	return blockToJSON(block, labels)
+}
+
+// ValidatorJSON returns the JSON representation of a validator, as used by the REST API.
+// The withdrawal address is labeled if it is present in labels.
+func ValidatorJSON(validator *chaindb.Validator, labels map[[20]byte]string) interface{} {
+	return validatorToJSON(validator, labels)
+}
+
+// ValidatorEpochSummaryJSON returns the JSON representation of a validator epoch summary, as used by the REST API.
+func ValidatorEpochSummaryJSON(summary *chaindb.ValidatorEpochSummary) interface{} {
+	return validatorEpochSummaryToJSON(summary)
+}
+
+// ValidatorPerformanceJSON returns the JSON representation of validator performance, as used by the REST API.
+func ValidatorPerformanceJSON(performance *chaindb.ValidatorPerformance) interface{} {
+	return validatorPerformanceToJSON(performance)
+}
@@ -0,0 +1,69 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// BlockAddresses returns the addresses in blocks that can have labels.
+func BlockAddresses(blocks []*chaindb.Block) [][20]byte {
+	addresses := make([][20]byte, 0, len(blocks))
+	for _, block := range blocks {
+		if block.ExecutionPayload != nil {
+			addresses = append(addresses, block.ExecutionPayload.FeeRecipient)
+		}
+	}
+	return addresses
+}
+
+// ValidatorAddresses returns the addresses of validators that can have labels.
+func ValidatorAddresses(validators []*chaindb.Validator) [][20]byte {
+	addresses := make([][20]byte, 0, len(validators))
+	for _, validator := range validators {
+		if address, exists := withdrawalAddress(validator.WithdrawalCredentials); exists {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// withdrawalAddress returns the execution address to which a validator
+// withdraws, if its withdrawal credentials have the 0x01 prefix.
+func withdrawalAddress(credentials []byte) ([20]byte, bool) {
+	var address [20]byte
+	if len(credentials) != 32 || credentials[0] != 0x01 {
+		return address, false
+	}
+	copy(address[:], credentials[12:])
+	return address, true
+}
+
+// addressLabels returns the labels for the given addresses.
+// It returns no labels if the chain database does not provide them.
+func (s *Service) addressLabels(ctx context.Context, addresses [][20]byte) (map[[20]byte]string, error) {
+	if s.addressLabelsProvider == nil || len(addresses) == 0 {
+		return nil, nil
+	}
+
+	labels, err := s.addressLabelsProvider.AddressLabels(ctx, addresses)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain address labels")
+	}
+
+	return labels, nil
+}
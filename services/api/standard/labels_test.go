@@ -0,0 +1,61 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/api/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestLabels(t *testing.T) {
+	address := [20]byte{0x01, 0x02, 0x03}
+	credentials := make([]byte, 32)
+	credentials[0] = 0x01
+	copy(credentials[12:], address[:])
+	labels := map[[20]byte]string{
+		address: "Test label",
+	}
+
+	validators := []*chaindb.Validator{
+		{Index: 1, WithdrawalCredentials: credentials},
+		// BLS withdrawal credentials do not have an address.
+		{Index: 2, WithdrawalCredentials: append([]byte{0x00}, credentials[1:]...)},
+		{Index: 3},
+	}
+	require.Equal(t, [][20]byte{address}, standard.ValidatorAddresses(validators))
+
+	data, err := json.Marshal(standard.ValidatorJSON(validators[0], labels))
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"withdrawal_address_label":"Test label"`)
+	data, err = json.Marshal(standard.ValidatorJSON(validators[1], labels))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), `withdrawal_address_label`)
+
+	blocks := []*chaindb.Block{
+		{Slot: 1},
+		{Slot: 2, ExecutionPayload: &chaindb.ExecutionPayload{FeeRecipient: address}},
+	}
+	require.Equal(t, [][20]byte{address}, standard.BlockAddresses(blocks))
+
+	data, err = json.Marshal(standard.BlockJSON(blocks[1], labels))
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"fee_recipient_label":"Test label"`)
+	data, err = json.Marshal(standard.BlockJSON(blocks[1], nil))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), `fee_recipient_label`)
+}
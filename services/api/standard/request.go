@@ -0,0 +1,196 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// apiError is an error with an associated HTTP status code.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// badRequest returns an error for an invalid request.
+func badRequest(format string, args ...interface{}) error {
+	return &apiError{
+		status:  http.StatusBadRequest,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+// notFound returns an error for a missing item.
+func notFound(format string, args ...interface{}) error {
+	return &apiError{
+		status:  http.StatusNotFound,
+		message: fmt.Sprintf(format, args...),
+	}
+}
+
+type dataResponse struct {
+	Data interface{} `json:"data"`
+}
+
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// handlerFunc is a function that handles an API request, returning the data to send back.
+type handlerFunc func(ctx context.Context, r *http.Request) (interface{}, error)
+
+// handler wraps an API handler function, handling method checks, encoding and metrics.
+func (s *Service) handler(endpoint string, fn handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		if r.Method != http.MethodGet {
+			writeError(w, &apiError{status: http.StatusMethodNotAllowed, message: "method not allowed"})
+			monitorRequest(endpoint, http.StatusMethodNotAllowed, time.Since(started))
+			return
+		}
+
+		data, err := fn(r.Context(), r)
+		if err != nil {
+			status := writeError(w, err)
+			if status == http.StatusInternalServerError {
+				log.Warn().Str("endpoint", endpoint).Str("url", r.URL.String()).Err(err).Msg("Failed to handle request")
+			}
+			monitorRequest(endpoint, status, time.Since(started))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(&dataResponse{Data: data}); err != nil {
+			log.Debug().Str("endpoint", endpoint).Err(err).Msg("Failed to write response")
+		}
+		monitorRequest(endpoint, http.StatusOK, time.Since(started))
+	}
+}
+
+// writeError writes an error response, returning the status code used.
+func writeError(w http.ResponseWriter, err error) int {
+	status := http.StatusInternalServerError
+	message := "internal error"
+	if apiErr, isAPIErr := err.(*apiError); isAPIErr {
+		status = apiErr.status
+		message = apiErr.message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(&errorResponse{Code: status, Message: message}); err != nil {
+		log.Debug().Err(err).Msg("Failed to write error response")
+	}
+	return status
+}
+
+// uint64Param obtains an optional unsigned integer query parameter.
+func uint64Param(query url.Values, name string) (*uint64, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	res, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, badRequest("invalid value for %s", name)
+	}
+	return &res, nil
+}
+
+// slotRangeParams obtains the from_slot and to_slot parameters, ensuring that
+// both are present and that the range is valid.
+func (s *Service) slotRangeParams(query url.Values) (phase0.Slot, phase0.Slot, error) {
+	from, err := uint64Param(query, "from_slot")
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err := uint64Param(query, "to_slot")
+	if err != nil {
+		return 0, 0, err
+	}
+	if from == nil || to == nil {
+		return 0, 0, badRequest("from_slot and to_slot are required")
+	}
+	if *to <= *from {
+		return 0, 0, badRequest("to_slot must be greater than from_slot")
+	}
+	if *to-*from > s.maxSlotRange {
+		return 0, 0, badRequest("slot range cannot be greater than %d", s.maxSlotRange)
+	}
+	return phase0.Slot(*from), phase0.Slot(*to), nil
+}
+
+// validatorIndicesParam obtains an optional comma-separated list of validator indices.
+func validatorIndicesParam(query url.Values, name string) ([]phase0.ValidatorIndex, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	items := strings.Split(value, ",")
+	res := make([]phase0.ValidatorIndex, 0, len(items))
+	for _, item := range items {
+		index, err := strconv.ParseUint(strings.TrimSpace(item), 10, 64)
+		if err != nil {
+			return nil, badRequest("invalid validator index %q", item)
+		}
+		res = append(res, phase0.ValidatorIndex(index))
+	}
+	return res, nil
+}
+
+// pubKeysParam obtains an optional comma-separated list of validator public keys.
+func pubKeysParam(query url.Values, name string) ([]phase0.BLSPubKey, error) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+	items := strings.Split(value, ",")
+	res := make([]phase0.BLSPubKey, 0, len(items))
+	for _, item := range items {
+		data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(item), "0x"))
+		if err != nil || len(data) != phase0.PublicKeyLength {
+			return nil, badRequest("invalid public key %q", item)
+		}
+		var pubKey phase0.BLSPubKey
+		copy(pubKey[:], data)
+		res = append(res, pubKey)
+	}
+	return res, nil
+}
+
+// parseRoot parses a 0x-prefixed hex root.
+func parseRoot(value string) (phase0.Root, error) {
+	var root phase0.Root
+	data, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil || len(data) != len(root) {
+		return root, badRequest("invalid root %q", value)
+	}
+	copy(root[:], data)
+	return root, nil
+}
@@ -0,0 +1,169 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a REST API service exposing the contents of the chain database.
+type Service struct {
+	blocksProvider                  chaindb.BlocksProvider
+	validatorsProvider              chaindb.ValidatorsProvider
+	validatorSet                    validatorset.Service
+	attestationsProvider            chaindb.AttestationsProvider
+	beaconCommitteesProvider        chaindb.BeaconCommitteesProvider
+	proposerDutiesProvider          chaindb.ProposerDutiesProvider
+	blockSummariesProvider          chaindb.BlockSummariesProvider
+	validatorEpochSummariesProvider chaindb.ValidatorEpochSummariesProvider
+	aggregatesProvider              chaindb.AggregatesProvider
+	addressLabelsProvider           chaindb.AddressLabelsProvider
+	maxSlotRange                    uint64
+	server                          *http.Server
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "api").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorSet = validatorsProvider
+	}
+
+	attestationsProvider, isProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide attestations")
+	}
+
+	beaconCommitteesProvider, isProvider := parameters.chainDB.(chaindb.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide beacon committees")
+	}
+
+	proposerDutiesProvider, isProvider := parameters.chainDB.(chaindb.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide proposer duties")
+	}
+
+	blockSummariesProvider, isProvider := parameters.chainDB.(chaindb.BlockSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide block summaries")
+	}
+
+	validatorEpochSummariesProvider, isProvider := parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validator epoch summaries")
+	}
+
+	aggregatesProvider, isProvider := parameters.chainDB.(chaindb.AggregatesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide aggregates")
+	}
+
+	// Address labels are optional.
+	addressLabelsProvider, isProvider := parameters.chainDB.(chaindb.AddressLabelsProvider)
+	if !isProvider {
+		log.Debug().Msg("Chain DB does not provide address labels; results will not be labeled")
+	}
+
+	s := &Service{
+		blocksProvider:                  blocksProvider,
+		validatorsProvider:              validatorsProvider,
+		validatorSet:                    validatorSet,
+		attestationsProvider:            attestationsProvider,
+		beaconCommitteesProvider:        beaconCommitteesProvider,
+		proposerDutiesProvider:          proposerDutiesProvider,
+		blockSummariesProvider:          blockSummariesProvider,
+		validatorEpochSummariesProvider: validatorEpochSummariesProvider,
+		aggregatesProvider:              aggregatesProvider,
+		addressLabelsProvider:           addressLabelsProvider,
+		maxSlotRange:                    parameters.maxSlotRange,
+	}
+
+	s.server = &http.Server{
+		Addr:              parameters.listenAddress,
+		Handler:           parameters.authenticator.Require(util.RoleRead, s.router()),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("listen_address", parameters.listenAddress).Msg("Starting API server")
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run API server")
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; shutting down API server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down API server")
+		}
+	}()
+
+	return s, nil
+}
+
+// router creates the router for the API server.
+func (s *Service) router() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/blocks", s.handler("blocks", s.getBlocks))
+	mux.HandleFunc("/v1/blocks/", s.handler("block", s.getBlock))
+	mux.HandleFunc("/v1/validators", s.handler("validators", s.getValidators))
+	mux.HandleFunc("/v1/attestations", s.handler("attestations", s.getAttestations))
+	mux.HandleFunc("/v1/beacon_committees", s.handler("beacon_committees", s.getBeaconCommittees))
+	mux.HandleFunc("/v1/proposer_duties", s.handler("proposer_duties", s.getProposerDuties))
+	mux.HandleFunc("/v1/attester_duties", s.handler("attester_duties", s.getAttesterDuties))
+	mux.HandleFunc("/v1/block_summaries/", s.handler("block_summary", s.getBlockSummary))
+	mux.HandleFunc("/v1/validator_summaries", s.handler("validator_summaries", s.getValidatorSummaries))
+	mux.HandleFunc("/v1/validator_performance", s.handler("validator_performance", s.getValidatorPerformance))
+	return mux
+}
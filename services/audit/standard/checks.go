@@ -0,0 +1,269 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+var farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// checkBlocks compares the blocks in the database for the slots of an epoch
+// with the canonical blocks from the beacon node, returning the number of
+// slots that diverge.
+func (s *Service) checkBlocks(ctx context.Context, epoch phase0.Epoch) (int, error) {
+	diverged := 0
+	for slot := s.chainTime.FirstSlotOfEpoch(epoch); slot < s.chainTime.FirstSlotOfEpoch(epoch+1); slot++ {
+		log := log.With().Uint64("slot", uint64(slot)).Logger()
+
+		signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+		if err != nil {
+			return diverged, errors.Wrap(err, "failed to obtain block from beacon node")
+		}
+		var root *phase0.Root
+		if signedBlock != nil {
+			blockRoot, err := signedBlock.Root()
+			if err != nil {
+				return diverged, errors.Wrap(err, "failed to calculate block root")
+			}
+			root = &blockRoot
+		}
+
+		dbBlocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
+		if err != nil {
+			return diverged, errors.Wrap(err, "failed to obtain blocks from database")
+		}
+		if root != nil && len(dbBlocks) == 0 {
+			log.Debug().Msg("Block not in database; not checking")
+			continue
+		}
+
+		found := false
+		reason := ""
+		for _, dbBlock := range dbBlocks {
+			if root != nil && dbBlock.Root == *root {
+				found = true
+				if dbBlock.Canonical != nil && !*dbBlock.Canonical {
+					reason = "canonical block marked as non-canonical"
+				}
+				continue
+			}
+			if dbBlock.Canonical != nil && *dbBlock.Canonical {
+				reason = "non-canonical block marked as canonical"
+			}
+		}
+		if root != nil && !found {
+			reason = "canonical block not in database"
+		}
+
+		monitorCheck("blocks", reason != "")
+		if reason != "" {
+			log.Warn().Str("reason", reason).Msg("Block diverges from beacon node")
+			diverged++
+		}
+	}
+
+	return diverged, nil
+}
+
+// checkBeaconCommittees compares the beacon committees in the database for an
+// epoch with those from the beacon node, returning the indices of the validators
+// in the committees and the number of committees that diverge.
+func (s *Service) checkBeaconCommittees(ctx context.Context, epoch phase0.Epoch) ([]phase0.ValidatorIndex, int, error) {
+	stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
+	beaconCommittees, err := s.eth2Client.(eth2client.BeaconCommitteesProvider).BeaconCommittees(ctx, stateID)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "failed to obtain beacon committees from beacon node")
+	}
+
+	validatorIndices := make([]phase0.ValidatorIndex, 0)
+	diverged := 0
+	for _, beaconCommittee := range beaconCommittees {
+		validatorIndices = append(validatorIndices, beaconCommittee.Validators...)
+		log := log.With().Uint64("slot", uint64(beaconCommittee.Slot)).Uint64("index", uint64(beaconCommittee.Index)).Logger()
+
+		dbBeaconCommittee, err := s.beaconCommitteesProvider.BeaconCommitteeBySlotAndIndex(ctx, beaconCommittee.Slot, beaconCommittee.Index)
+		if err != nil || dbBeaconCommittee == nil {
+			log.Debug().Err(err).Msg("Beacon committee not in database; not checking")
+			continue
+		}
+
+		matches := sameValidators(dbBeaconCommittee.Committee, beaconCommittee.Validators)
+		monitorCheck("beaconcommittees", !matches)
+		if !matches {
+			log.Warn().Msg("Beacon committee diverges from beacon node")
+			diverged++
+		}
+	}
+
+	return validatorIndices, diverged, nil
+}
+
+// checkComputedBeaconCommittees computes the beacon committees for an epoch
+// from the beacon state and compares them with those from the beacon node,
+// returning the number of committees that diverge.  This catches a beacon
+// node that returns incorrect committees, which would otherwise be stored
+// and used to attribute attestations.
+func (s *Service) checkComputedBeaconCommittees(ctx context.Context, epoch phase0.Epoch) (int, error) {
+	firstSlot := s.chainTime.FirstSlotOfEpoch(epoch)
+	stateID := fmt.Sprintf("%d", firstSlot)
+	state, err := s.eth2Client.(eth2client.BeaconStateProvider).BeaconState(ctx, stateID)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain beacon state from beacon node")
+	}
+	if state == nil {
+		return 0, errors.New("beacon state not available from beacon node")
+	}
+	if expected := s.chainTime.EpochDataVersion(epoch); state.Version != expected {
+		return 0, fmt.Errorf("beacon state has version %s but fork schedule requires %s", state.Version, expected)
+	}
+	validators, randaoMixes, err := stateShufflingData(state)
+	if err != nil {
+		return 0, err
+	}
+	computed, err := s.shufflingSpec.computeBeaconCommittees(epoch, validators, randaoMixes)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to compute beacon committees")
+	}
+
+	beaconCommittees, err := s.eth2Client.(eth2client.BeaconCommitteesProvider).BeaconCommittees(ctx, stateID)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain beacon committees from beacon node")
+	}
+
+	diverged := 0
+	for _, beaconCommittee := range beaconCommittees {
+		log := log.With().Uint64("slot", uint64(beaconCommittee.Slot)).Uint64("index", uint64(beaconCommittee.Index)).Logger()
+
+		// Slots before the epoch wrap around, so are also caught here.
+		offset := uint64(beaconCommittee.Slot - firstSlot)
+		reason := ""
+		switch {
+		case offset >= uint64(len(computed)):
+			reason = "slot not in epoch"
+		case uint64(beaconCommittee.Index) >= uint64(len(computed[offset])):
+			reason = "committee index out of range"
+		case !sameValidators(computed[offset][beaconCommittee.Index], beaconCommittee.Validators):
+			reason = "committee differs"
+		}
+
+		monitorCheck("computedcommittees", reason != "")
+		if reason != "" {
+			log.Warn().Str("reason", reason).Msg("Beacon committee from beacon node diverges from computed committee")
+			diverged++
+		}
+	}
+
+	expected := len(computed) * len(computed[0])
+	if len(beaconCommittees) != expected {
+		log.Warn().Uint64("epoch", uint64(epoch)).Int("expected", expected).Int("actual", len(beaconCommittees)).Msg("Number of beacon committees from beacon node differs from computed number")
+		monitorCheck("computedcommittees", true)
+		diverged++
+	}
+
+	return diverged, nil
+}
+
+// sameValidators returns true if the two lists of validators are the same.
+func sameValidators(a []phase0.ValidatorIndex, b []phase0.ValidatorIndex) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkValidators compares the given validators in the database with those
+// from the beacon node at the start of an epoch, returning the number of
+// validators that diverge.  As the database only holds the latest state of
+// each validator, only those fields that do not change once set are compared,
+// along with the balances for the epoch if present.
+func (s *Service) checkValidators(ctx context.Context, epoch phase0.Epoch, validatorIndices []phase0.ValidatorIndex) (int, error) {
+	if len(validatorIndices) == 0 {
+		return 0, nil
+	}
+
+	stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
+	validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, stateID, validatorIndices)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain validators from beacon node")
+	}
+	dbValidators, err := s.validatorsProvider.ValidatorsByIndex(ctx, validatorIndices)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain validators from database")
+	}
+	dbBalances, err := s.validatorsProvider.ValidatorBalancesByIndexAndEpoch(ctx, validatorIndices, epoch)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain validator balances from database")
+	}
+
+	diverged := 0
+	for _, index := range validatorIndices {
+		log := log.With().Uint64("validator_index", uint64(index)).Logger()
+		validator, exists := validators[index]
+		if !exists || validator.Validator == nil {
+			continue
+		}
+		dbValidator, exists := dbValidators[index]
+		if !exists {
+			log.Debug().Msg("Validator not in database; not checking")
+			continue
+		}
+
+		reason := ""
+		switch {
+		case dbValidator.PublicKey != validator.Validator.PublicKey:
+			reason = "public key differs"
+		case validator.Validator.ActivationEligibilityEpoch != farFutureEpoch &&
+			dbValidator.ActivationEligibilityEpoch != validator.Validator.ActivationEligibilityEpoch:
+			reason = "activation eligibility epoch differs"
+		case validator.Validator.ActivationEpoch != farFutureEpoch &&
+			dbValidator.ActivationEpoch != validator.Validator.ActivationEpoch:
+			reason = "activation epoch differs"
+		case validator.Validator.ExitEpoch != farFutureEpoch &&
+			dbValidator.ExitEpoch != validator.Validator.ExitEpoch:
+			reason = "exit epoch differs"
+		case validator.Validator.WithdrawableEpoch != farFutureEpoch &&
+			dbValidator.WithdrawableEpoch != validator.Validator.WithdrawableEpoch:
+			reason = "withdrawable epoch differs"
+		case validator.Validator.Slashed && !dbValidator.Slashed:
+			reason = "slashing not recorded"
+		}
+		if dbBalance, exists := dbBalances[index]; exists && reason == "" {
+			switch {
+			case dbBalance.Balance != validator.Balance:
+				reason = "balance differs"
+			case dbBalance.EffectiveBalance != validator.Validator.EffectiveBalance:
+				reason = "effective balance differs"
+			}
+		}
+
+		monitorCheck("validators", reason != "")
+		if reason != "" {
+			log.Warn().Str("reason", reason).Msg("Validator diverges from beacon node")
+			diverged++
+		}
+	}
+
+	return diverged, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_audit"
+
+var epochsAudited prometheus.Counter
+var latestEpoch prometheus.Gauge
+var checks *prometheus.CounterVec
+var divergences *prometheus.CounterVec
+var halted prometheus.Gauge
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if epochsAudited != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	epochsAudited = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "epochs_audited_total",
+		Help:      "Number of epochs audited",
+	})
+	if err := prometheus.Register(epochsAudited); err != nil {
+		return errors.Wrap(err, "failed to register epochs_audited_total")
+	}
+
+	latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_epoch",
+		Help:      "Latest epoch audited",
+	})
+	if err := prometheus.Register(latestEpoch); err != nil {
+		return errors.Wrap(err, "failed to register latest_epoch")
+	}
+
+	checks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "checks_total",
+		Help:      "Number of items compared with the beacon node",
+	}, []string{"dataset"})
+	if err := prometheus.Register(checks); err != nil {
+		return errors.Wrap(err, "failed to register checks_total")
+	}
+
+	divergences = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "divergences_total",
+		Help:      "Number of items that differ from the beacon node",
+	}, []string{"dataset"})
+	if err := prometheus.Register(divergences); err != nil {
+		return errors.Wrap(err, "failed to register divergences_total")
+	}
+
+	halted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "halted",
+		Help:      "1 if the service has halted due to an inconsistency in strict mode",
+	})
+	if err := prometheus.Register(halted); err != nil {
+		return errors.Wrap(err, "failed to register halted")
+	}
+
+	return nil
+}
+
+func monitorEpochAudited(epoch phase0.Epoch) {
+	if epochsAudited != nil {
+		epochsAudited.Inc()
+		latestEpoch.Set(float64(epoch))
+	}
+}
+
+func monitorCheck(dataset string, diverged bool) {
+	if checks != nil {
+		checks.WithLabelValues(dataset).Inc()
+		if diverged {
+			divergences.WithLabelValues(dataset).Inc()
+		}
+	}
+}
+
+func monitorHalted() {
+	if halted != nil {
+		halted.Set(1)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel            zerolog.Level
+	logLevelSampler     zerolog.Sampler
+	monitor             metrics.Service
+	eth2Client          eth2client.Service
+	chainDB             chaindb.Service
+	chainTime           chaintime.Service
+	interval            time.Duration
+	epochs              uint64
+	validators          uint64
+	recomputeCommittees bool
+	strict              bool
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithInterval sets the interval at which epochs are audited.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithEpochs sets the number of finalized epochs sampled at each interval.
+func WithEpochs(epochs uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.epochs = epochs
+	})
+}
+
+// WithValidators sets the number of validators sampled in each audited epoch.
+func WithValidators(validators uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validators = validators
+	})
+}
+
+// WithRecomputeCommittees sets whether beacon committees are recomputed from
+// the beacon state for audited epochs.
+func WithRecomputeCommittees(recomputeCommittees bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.recomputeCommittees = recomputeCommittees
+	})
+}
+
+// WithStrict sets whether an inconsistency in the data halts the service,
+// rather than being logged.
+func WithStrict(strict bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.strict = strict
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:   zerolog.GlobalLevel(),
+		interval:   time.Hour,
+		epochs:     1,
+		validators: 64,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	// Ensure the eth2client can handle our requirements.
+	if _, isProvider := parameters.eth2Client.(eth2client.FinalityProvider); !isProvider {
+		//nolint:stylecheck
+		return nil, errors.New("Ethereum 2 client does not provide finality information") // skipcq: SCC-ST1005
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.SignedBeaconBlockProvider); !isProvider {
+		//nolint:stylecheck
+		return nil, errors.New("Ethereum 2 client does not provide signed beacon blocks") // skipcq: SCC-ST1005
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.BeaconCommitteesProvider); !isProvider {
+		//nolint:stylecheck
+		return nil, errors.New("Ethereum 2 client does not provide beacon committee information") // skipcq: SCC-ST1005
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.ValidatorsProvider); !isProvider {
+		//nolint:stylecheck
+		return nil, errors.New("Ethereum 2 client does not provide validator information") // skipcq: SCC-ST1005
+	}
+	if parameters.recomputeCommittees {
+		if _, isProvider := parameters.eth2Client.(eth2client.BeaconStateProvider); !isProvider {
+			//nolint:stylecheck
+			return nil, errors.New("Ethereum 2 client does not provide beacon state") // skipcq: SCC-ST1005
+		}
+		if _, isProvider := parameters.eth2Client.(eth2client.SpecProvider); !isProvider {
+			//nolint:stylecheck
+			return nil, errors.New("Ethereum 2 client does not provide spec") // skipcq: SCC-ST1005
+		}
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.interval == 0 {
+		return nil, errors.New("interval must be greater than 0")
+	}
+	if parameters.epochs == 0 {
+		return nil, errors.New("epochs must be greater than 0")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,208 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a consistency checking service, periodically re-fetching the
+// data for sampled finalized epochs from the beacon node and comparing it
+// with the data in the chain database.
+type Service struct {
+	eth2Client               eth2client.Service
+	blocksProvider           chaindb.BlocksProvider
+	beaconCommitteesProvider chaindb.BeaconCommitteesProvider
+	validatorsProvider       chaindb.ValidatorsProvider
+	chainTime                chaintime.Service
+	interval                 time.Duration
+	epochs                   uint64
+	validators               uint64
+	shufflingSpec            *shufflingSpec
+	halt                     *util.Halt
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "audit").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+	beaconCommitteesProvider, isProvider := parameters.chainDB.(chaindb.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide beacon committees")
+	}
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+
+	var shufflingSpec *shufflingSpec
+	if parameters.recomputeCommittees {
+		chainSpec, err := parameters.eth2Client.(eth2client.SpecProvider).Spec(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain spec")
+		}
+		shufflingSpec, err = newShufflingSpec(chainSpec)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rand.Seed(time.Now().UnixNano())
+
+	s := &Service{
+		eth2Client:               parameters.eth2Client,
+		blocksProvider:           blocksProvider,
+		beaconCommitteesProvider: beaconCommitteesProvider,
+		validatorsProvider:       validatorsProvider,
+		chainTime:                parameters.chainTime,
+		interval:                 parameters.interval,
+		epochs:                   parameters.epochs,
+		validators:               parameters.validators,
+		shufflingSpec:            shufflingSpec,
+		halt:                     util.NewHalt(parameters.strict),
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically audits sampled epochs.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.audit(ctx)
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping audit")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// audit audits a random sample of finalized epochs.
+func (s *Service) audit(ctx context.Context) {
+	if s.halt.Halted() {
+		log.Trace().Msg("Halted; not auditing")
+		return
+	}
+
+	finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, "head")
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain finality")
+		return
+	}
+	if finality.Finalized == nil {
+		log.Trace().Msg("No finalized checkpoint; not auditing")
+		return
+	}
+
+	for i := uint64(0); i < s.epochs && !s.halt.Halted(); i++ {
+		epoch := phase0.Epoch(rand.Int63n(int64(finality.Finalized.Epoch) + 1))
+		s.auditEpoch(ctx, epoch)
+	}
+}
+
+// auditEpoch compares the data for an epoch in the database with that from
+// the beacon node.  Failure to check one dataset does not stop the others
+// from being checked.
+func (s *Service) auditEpoch(ctx context.Context, epoch phase0.Epoch) {
+	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log.Trace().Msg("Auditing epoch")
+
+	diverged := 0
+	count, err := s.checkBlocks(ctx, epoch)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check blocks")
+	}
+	diverged += count
+
+	validatorIndices, count, err := s.checkBeaconCommittees(ctx, epoch)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check beacon committees")
+	}
+	diverged += count
+
+	if s.shufflingSpec != nil {
+		count, err = s.checkComputedBeaconCommittees(ctx, epoch)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to check computed beacon committees")
+		}
+		diverged += count
+	}
+
+	count, err = s.checkValidators(ctx, epoch, s.sampleValidators(validatorIndices))
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check validators")
+	}
+	diverged += count
+
+	switch {
+	case diverged > 0 && s.halt.Inconsistent():
+		log.Error().Int("divergences", diverged).Msg("Database diverges from beacon node; halting")
+		monitorHalted()
+	case diverged > 0:
+		log.Warn().Int("divergences", diverged).Msg("Database diverges from beacon node")
+	default:
+		log.Debug().Msg("Database consistent with beacon node")
+	}
+	monitorEpochAudited(epoch)
+}
+
+// sampleValidators returns a random sample of the given validators.
+func (s *Service) sampleValidators(validatorIndices []phase0.ValidatorIndex) []phase0.ValidatorIndex {
+	if uint64(len(validatorIndices)) <= s.validators {
+		return validatorIndices
+	}
+
+	sample := make([]phase0.ValidatorIndex, s.validators)
+	for i, j := range rand.Perm(len(validatorIndices))[:s.validators] {
+		sample[i] = validatorIndices[j]
+	}
+
+	return sample
+}
@@ -0,0 +1,480 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/altair"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// testChainTime is a chain time service with 4 slots per epoch, on Altair.
+type testChainTime struct {
+	chaintime.Service
+}
+
+func (c *testChainTime) FirstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch * 4)
+}
+
+func (c *testChainTime) EpochDataVersion(_ phase0.Epoch) spec.DataVersion {
+	return spec.DataVersionAltair
+}
+
+// testClient is a beacon node with a block in every slot other than the empty slot.
+type testClient struct {
+	eth2client.Service
+	emptySlot   phase0.Slot
+	committees  []*api.BeaconCommittee
+	validators  map[phase0.ValidatorIndex]*api.Validator
+	state       *spec.VersionedBeaconState
+	unavailable bool
+}
+
+func (c *testClient) SignedBeaconBlock(_ context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	if c.unavailable {
+		return nil, errors.New("unavailable")
+	}
+	slot, err := strconv.ParseUint(blockID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if phase0.Slot(slot) == c.emptySlot {
+		return nil, nil
+	}
+	return testBlock(phase0.Slot(slot)), nil
+}
+
+func (c *testClient) BeaconCommittees(_ context.Context, _ string) ([]*api.BeaconCommittee, error) {
+	return c.committees, nil
+}
+
+func (c *testClient) BeaconCommitteesAtEpoch(_ context.Context, _ string, _ phase0.Epoch) ([]*api.BeaconCommittee, error) {
+	return c.committees, nil
+}
+
+func (c *testClient) BeaconState(_ context.Context, _ string) (*spec.VersionedBeaconState, error) {
+	return c.state, nil
+}
+
+func (c *testClient) Validators(_ context.Context, _ string, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	return c.validators, nil
+}
+
+func (c *testClient) ValidatorsByPubKey(_ context.Context, _ string, _ []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	return c.validators, nil
+}
+
+// testChainDB is a chain database holding fixed data.
+type testChainDB struct {
+	chaindb.BlocksProvider
+	chaindb.BeaconCommitteesProvider
+	chaindb.ValidatorsProvider
+	blocks     map[phase0.Slot][]*chaindb.Block
+	committees map[string]*chaindb.BeaconCommittee
+	validators map[phase0.ValidatorIndex]*chaindb.Validator
+	balances   map[phase0.ValidatorIndex]*chaindb.ValidatorBalance
+}
+
+func (d *testChainDB) BlocksBySlot(_ context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
+	return d.blocks[slot], nil
+}
+
+func (d *testChainDB) BeaconCommitteeBySlotAndIndex(_ context.Context, slot phase0.Slot, index phase0.CommitteeIndex) (*chaindb.BeaconCommittee, error) {
+	committee, exists := d.committees[fmt.Sprintf("%d:%d", slot, index)]
+	if !exists {
+		return nil, errors.New("no rows in result set")
+	}
+	return committee, nil
+}
+
+func (d *testChainDB) ValidatorsByIndex(_ context.Context, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	return d.validators, nil
+}
+
+func (d *testChainDB) ValidatorBalancesByIndexAndEpoch(_ context.Context, _ []phase0.ValidatorIndex, _ phase0.Epoch) (map[phase0.ValidatorIndex]*chaindb.ValidatorBalance, error) {
+	return d.balances, nil
+}
+
+func testBlock(slot phase0.Slot) *spec.VersionedSignedBeaconBlock {
+	return &spec.VersionedSignedBeaconBlock{
+		Version: spec.DataVersionPhase0,
+		Phase0: &phase0.SignedBeaconBlock{
+			Message: &phase0.BeaconBlock{
+				Slot: slot,
+				Body: &phase0.BeaconBlockBody{
+					ETH1Data: &phase0.ETH1Data{
+						BlockHash: make([]byte, 32),
+					},
+					Graffiti: make([]byte, 32),
+				},
+			},
+		},
+	}
+}
+
+func testBlockRoot(t *testing.T, slot phase0.Slot) phase0.Root {
+	t.Helper()
+	root, err := testBlock(slot).Root()
+	require.NoError(t, err)
+	return root
+}
+
+func TestCheckBlocks(t *testing.T) {
+	ctx := context.Background()
+	canonical := true
+	nonCanonical := false
+
+	tests := []struct {
+		name     string
+		client   *testClient
+		blocks   map[phase0.Slot][]*chaindb.Block
+		diverged int
+		err      string
+	}{
+		{
+			name:   "Unavailable",
+			client: &testClient{emptySlot: 5, unavailable: true},
+			err:    "failed to obtain block from beacon node: unavailable",
+		},
+		{
+			name:   "NotInDatabase",
+			client: &testClient{emptySlot: 5},
+		},
+		{
+			name:   "Consistent",
+			client: &testClient{emptySlot: 5},
+			blocks: map[phase0.Slot][]*chaindb.Block{
+				4: {{Slot: 4, Root: testBlockRoot(t, 4), Canonical: &canonical}},
+				6: {{Slot: 6, Root: testBlockRoot(t, 6)}},
+				7: {{Slot: 7, Root: testBlockRoot(t, 7), Canonical: &canonical}, {Slot: 7, Root: phase0.Root{0x01}, Canonical: &nonCanonical}},
+			},
+		},
+		{
+			name:   "Divergent",
+			client: &testClient{emptySlot: 5},
+			blocks: map[phase0.Slot][]*chaindb.Block{
+				// Canonical block not present.
+				4: {{Slot: 4, Root: phase0.Root{0x01}}},
+				// Block in empty slot marked canonical.
+				5: {{Slot: 5, Root: phase0.Root{0x02}, Canonical: &canonical}},
+				// Canonical block marked non-canonical.
+				6: {{Slot: 6, Root: testBlockRoot(t, 6), Canonical: &nonCanonical}},
+				7: {{Slot: 7, Root: testBlockRoot(t, 7), Canonical: &canonical}},
+			},
+			diverged: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{
+				eth2Client:     test.client,
+				blocksProvider: &testChainDB{blocks: test.blocks},
+				chainTime:      &testChainTime{},
+			}
+			diverged, err := s.checkBlocks(ctx, 1)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.diverged, diverged)
+			}
+		})
+	}
+}
+
+func TestCheckBeaconCommittees(t *testing.T) {
+	ctx := context.Background()
+
+	client := &testClient{
+		committees: []*api.BeaconCommittee{
+			{Slot: 4, Index: 0, Validators: []phase0.ValidatorIndex{1, 2}},
+			{Slot: 5, Index: 0, Validators: []phase0.ValidatorIndex{3, 4}},
+			{Slot: 6, Index: 0, Validators: []phase0.ValidatorIndex{5, 6}},
+		},
+	}
+	chainDB := &testChainDB{
+		committees: map[string]*chaindb.BeaconCommittee{
+			"4:0": {Slot: 4, Index: 0, Committee: []phase0.ValidatorIndex{1, 2}},
+			"5:0": {Slot: 5, Index: 0, Committee: []phase0.ValidatorIndex{4, 3}},
+		},
+	}
+
+	s := &Service{
+		eth2Client:               client,
+		beaconCommitteesProvider: chainDB,
+		chainTime:                &testChainTime{},
+	}
+	validatorIndices, diverged, err := s.checkBeaconCommittees(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 1, diverged)
+	require.Equal(t, []phase0.ValidatorIndex{1, 2, 3, 4, 5, 6}, validatorIndices)
+}
+
+func TestCheckValidators(t *testing.T) {
+	ctx := context.Background()
+
+	nodeValidator := func(index phase0.ValidatorIndex, exitEpoch phase0.Epoch) *api.Validator {
+		return &api.Validator{
+			Index:   index,
+			Balance: 32000000000,
+			Validator: &phase0.Validator{
+				PublicKey:                  phase0.BLSPubKey{byte(index)},
+				EffectiveBalance:           32000000000,
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  exitEpoch,
+				WithdrawableEpoch:          farFutureEpoch,
+			},
+		}
+	}
+	dbValidator := func(index phase0.ValidatorIndex, exitEpoch phase0.Epoch) *chaindb.Validator {
+		return &chaindb.Validator{
+			Index:             index,
+			PublicKey:         phase0.BLSPubKey{byte(index)},
+			EffectiveBalance:  32000000000,
+			ExitEpoch:         exitEpoch,
+			WithdrawableEpoch: farFutureEpoch,
+		}
+	}
+
+	client := &testClient{
+		validators: map[phase0.ValidatorIndex]*api.Validator{
+			1: nodeValidator(1, farFutureEpoch),
+			2: nodeValidator(2, farFutureEpoch),
+			3: nodeValidator(3, 10),
+			4: nodeValidator(4, farFutureEpoch),
+			5: nodeValidator(5, farFutureEpoch),
+		},
+	}
+	chainDB := &testChainDB{
+		validators: map[phase0.ValidatorIndex]*chaindb.Validator{
+			1: dbValidator(1, farFutureEpoch),
+			// Exit after the audited epoch is not a divergence.
+			2: dbValidator(2, 20),
+			// Exit epoch differs.
+			3: dbValidator(3, 11),
+			4: dbValidator(4, farFutureEpoch),
+		},
+		balances: map[phase0.ValidatorIndex]*chaindb.ValidatorBalance{
+			1: {Index: 1, Epoch: 1, Balance: 32000000000, EffectiveBalance: 32000000000},
+			// Balance differs.
+			4: {Index: 4, Epoch: 1, Balance: 31000000000, EffectiveBalance: 32000000000},
+		},
+	}
+
+	s := &Service{
+		eth2Client:         client,
+		validatorsProvider: chainDB,
+		chainTime:          &testChainTime{},
+	}
+	diverged, err := s.checkValidators(ctx, 1, []phase0.ValidatorIndex{1, 2, 3, 4, 5})
+	require.NoError(t, err)
+	require.Equal(t, 2, diverged)
+}
+
+func TestAuditEpochStrict(t *testing.T) {
+	ctx := context.Background()
+
+	// The database holds a block that is not canonical on the beacon node.
+	for _, strict := range []bool{false, true} {
+		chainDB := &testChainDB{
+			blocks: map[phase0.Slot][]*chaindb.Block{
+				4: {{Slot: 4, Root: phase0.Root{0x01}}},
+			},
+		}
+		s := &Service{
+			eth2Client:               &testClient{emptySlot: 5},
+			blocksProvider:           chainDB,
+			beaconCommitteesProvider: chainDB,
+			validatorsProvider:       chainDB,
+			chainTime:                &testChainTime{},
+			halt:                     util.NewHalt(strict),
+		}
+		s.auditEpoch(ctx, 1)
+		require.Equal(t, strict, s.halt.Halted())
+	}
+}
+
+func TestSampleValidators(t *testing.T) {
+	s := &Service{validators: 3}
+	require.Len(t, s.sampleValidators([]phase0.ValidatorIndex{1, 2}), 2)
+
+	sample := s.sampleValidators([]phase0.ValidatorIndex{1, 2, 3, 4, 5, 6})
+	require.Len(t, sample, 3)
+	seen := make(map[phase0.ValidatorIndex]bool)
+	for _, index := range sample {
+		require.False(t, seen[index])
+		seen[index] = true
+	}
+}
+
+// testShufflingSpec is a minimal shuffling spec with 4 slots per epoch.
+var testShufflingSpec = &shufflingSpec{
+	slotsPerEpoch:             4,
+	shuffleRoundCount:         10,
+	targetCommitteeSize:       4,
+	maxCommitteesPerSlot:      4,
+	epochsPerHistoricalVector: 64,
+	minSeedLookahead:          1,
+	domainBeaconAttester:      phase0.DomainType{0x01, 0x00, 0x00, 0x00},
+}
+
+// computeShuffledIndex is a direct implementation of the spec's compute_shuffled_index().
+func computeShuffledIndex(index uint64, count uint64, seed [32]byte, rounds uint64) uint64 {
+	for round := uint64(0); round < rounds; round++ {
+		pivotHash := sha256.Sum256(append(seed[:], byte(round)))
+		pivot := binary.LittleEndian.Uint64(pivotHash[:8]) % count
+		flip := (pivot + count - index) % count
+		position := index
+		if flip > position {
+			position = flip
+		}
+		positionBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(positionBytes, uint32(position/256))
+		source := sha256.Sum256(append(append(seed[:], byte(round)), positionBytes...))
+		if (source[(position%256)/8]>>(position%8))&1 == 1 {
+			index = flip
+		}
+	}
+	return index
+}
+
+func TestShuffle(t *testing.T) {
+	for _, count := range []uint64{0, 1, 2, 3, 10, 255, 256, 257, 600} {
+		for _, seed := range [][32]byte{{}, {0x01}, sha256.Sum256([]byte("seed"))} {
+			indices := make([]phase0.ValidatorIndex, count)
+			for i := range indices {
+				indices[i] = phase0.ValidatorIndex(1000 + i)
+			}
+			shuffled := testShufflingSpec.shuffle(indices, seed)
+			require.Len(t, shuffled, int(count))
+			for i := uint64(0); i < count; i++ {
+				require.Equal(t, indices[computeShuffledIndex(i, count, seed, testShufflingSpec.shuffleRoundCount)], shuffled[i], fmt.Sprintf("count %d index %d", count, i))
+			}
+		}
+	}
+}
+
+func TestNewShufflingSpec(t *testing.T) {
+	chainSpec := map[string]interface{}{
+		"SLOTS_PER_EPOCH":              uint64(32),
+		"SHUFFLE_ROUND_COUNT":          uint64(90),
+		"TARGET_COMMITTEE_SIZE":        uint64(128),
+		"MAX_COMMITTEES_PER_SLOT":      uint64(64),
+		"EPOCHS_PER_HISTORICAL_VECTOR": uint64(65536),
+		"MIN_SEED_LOOKAHEAD":           uint64(1),
+	}
+	_, err := newShufflingSpec(chainSpec)
+	require.EqualError(t, err, "DOMAIN_BEACON_ATTESTER not found in spec")
+
+	chainSpec["DOMAIN_BEACON_ATTESTER"] = phase0.DomainType{0x01, 0x00, 0x00, 0x00}
+	shufflingSpec, err := newShufflingSpec(chainSpec)
+	require.NoError(t, err)
+	require.Equal(t, uint64(90), shufflingSpec.shuffleRoundCount)
+
+	chainSpec["SHUFFLE_ROUND_COUNT"] = "90"
+	_, err = newShufflingSpec(chainSpec)
+	require.EqualError(t, err, "SHUFFLE_ROUND_COUNT of unexpected type")
+}
+
+func TestCheckComputedBeaconCommittees(t *testing.T) {
+	ctx := context.Background()
+
+	validators := make([]*phase0.Validator, 40)
+	for i := range validators {
+		validators[i] = &phase0.Validator{
+			ActivationEpoch: 0,
+			ExitEpoch:       farFutureEpoch,
+		}
+	}
+	// Validator 0 is not yet active, and validator 1 has exited.
+	validators[0].ActivationEpoch = 5
+	validators[1].ExitEpoch = 1
+	randaoMixes := make([][]byte, testShufflingSpec.epochsPerHistoricalVector)
+	for i := range randaoMixes {
+		randaoMixes[i] = []byte(fmt.Sprintf("%032d", i))
+	}
+	state := &spec.VersionedBeaconState{
+		Version: spec.DataVersionAltair,
+		Altair: &altair.BeaconState{
+			Validators:  validators,
+			RANDAOMixes: randaoMixes,
+		},
+	}
+
+	computed, err := testShufflingSpec.computeBeaconCommittees(1, validators, randaoMixes)
+	require.NoError(t, err)
+	// 38 active validators gives 2 committees per slot.
+	require.Len(t, computed, 4)
+	committees := make([]*api.BeaconCommittee, 0)
+	members := 0
+	for slot := range computed {
+		require.Len(t, computed[slot], 2)
+		for index, committee := range computed[slot] {
+			members += len(committee)
+			for _, validatorIndex := range committee {
+				require.True(t, validatorIndex > 1)
+			}
+			committees = append(committees, &api.BeaconCommittee{
+				Slot:       phase0.Slot(4 + slot),
+				Index:      phase0.CommitteeIndex(index),
+				Validators: committee,
+			})
+		}
+	}
+	require.Equal(t, 38, members)
+
+	s := &Service{
+		eth2Client:    &testClient{committees: committees, state: state},
+		chainTime:     &testChainTime{},
+		shufflingSpec: testShufflingSpec,
+	}
+	diverged, err := s.checkComputedBeaconCommittees(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 0, diverged)
+
+	// Swap two members between committees, and drop a committee.
+	tampered := make([]*api.BeaconCommittee, 0)
+	for _, committee := range committees[:len(committees)-1] {
+		validators := make([]phase0.ValidatorIndex, len(committee.Validators))
+		copy(validators, committee.Validators)
+		tampered = append(tampered, &api.BeaconCommittee{Slot: committee.Slot, Index: committee.Index, Validators: validators})
+	}
+	tampered[0].Validators[0], tampered[1].Validators[0] = tampered[1].Validators[0], tampered[0].Validators[0]
+	s.eth2Client = &testClient{committees: tampered, state: state}
+	diverged, err = s.checkComputedBeaconCommittees(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 3, diverged)
+
+	// A state that does not match the fork schedule is rejected.
+	s.eth2Client = &testClient{committees: committees, state: &spec.VersionedBeaconState{Version: spec.DataVersionPhase0}}
+	_, err = s.checkComputedBeaconCommittees(ctx, 1)
+	require.EqualError(t, err, "beacon state has version PHASE0 but fork schedule requires ALTAIR")
+}
@@ -0,0 +1,178 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/audit/standard"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+// basicClient is a client that provides no data.
+type basicClient struct{}
+
+func (c *basicClient) Name() string {
+	return "basic"
+}
+
+func (c *basicClient) Address() string {
+	return "basic"
+}
+
+// auditClient is a client that provides all of the functions required for auditing.
+type auditClient struct {
+	basicClient
+}
+
+func (c *auditClient) Finality(_ context.Context, _ string) (*api.Finality, error) {
+	return &api.Finality{}, nil
+}
+
+func (c *auditClient) SignedBeaconBlock(_ context.Context, _ string) (*spec.VersionedSignedBeaconBlock, error) {
+	return nil, nil
+}
+
+func (c *auditClient) BeaconCommittees(_ context.Context, _ string) ([]*api.BeaconCommittee, error) {
+	return nil, nil
+}
+
+func (c *auditClient) BeaconCommitteesAtEpoch(_ context.Context, _ string, _ phase0.Epoch) ([]*api.BeaconCommittee, error) {
+	return nil, nil
+}
+
+func (c *auditClient) Validators(_ context.Context, _ string, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	return nil, nil
+}
+
+func (c *auditClient) ValidatorsByPubKey(_ context.Context, _ string, _ []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	return nil, nil
+}
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	chainTime := mockchaintime.New()
+	eth2Client := &auditClient{}
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "ETH2ClientBasic",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(&basicClient{}),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: Ethereum 2 client does not provide finality information",
+		},
+		{
+			name: "ETH2ClientNoBeaconState",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithRecomputeCommittees(true),
+			},
+			err: "problem with parameters: Ethereum 2 client does not provide beacon state",
+		},
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "IntervalZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithInterval(0),
+			},
+			err: "problem with parameters: interval must be greater than 0",
+		},
+		{
+			name: "EpochsZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithEpochs(0),
+			},
+			err: "problem with parameters: epochs must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithInterval(time.Second),
+				standard.WithEpochs(2),
+				standard.WithValidators(10),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// shufflingSpec holds the spec values required to compute beacon committees.
+type shufflingSpec struct {
+	slotsPerEpoch             uint64
+	shuffleRoundCount         uint64
+	targetCommitteeSize       uint64
+	maxCommitteesPerSlot      uint64
+	epochsPerHistoricalVector uint64
+	minSeedLookahead          uint64
+	domainBeaconAttester      phase0.DomainType
+}
+
+// newShufflingSpec obtains the shuffling spec values from the chain spec.
+func newShufflingSpec(chainSpec map[string]interface{}) (*shufflingSpec, error) {
+	values := make(map[string]uint64)
+	for _, name := range []string{
+		"SLOTS_PER_EPOCH",
+		"SHUFFLE_ROUND_COUNT",
+		"TARGET_COMMITTEE_SIZE",
+		"MAX_COMMITTEES_PER_SLOT",
+		"EPOCHS_PER_HISTORICAL_VECTOR",
+		"MIN_SEED_LOOKAHEAD",
+	} {
+		tmp, exists := chainSpec[name]
+		if !exists {
+			return nil, errors.Errorf("%s not found in spec", name)
+		}
+		value, ok := tmp.(uint64)
+		if !ok {
+			return nil, errors.Errorf("%s of unexpected type", name)
+		}
+		values[name] = value
+	}
+	tmp, exists := chainSpec["DOMAIN_BEACON_ATTESTER"]
+	if !exists {
+		return nil, errors.New("DOMAIN_BEACON_ATTESTER not found in spec")
+	}
+	domainBeaconAttester, ok := tmp.(phase0.DomainType)
+	if !ok {
+		return nil, errors.New("DOMAIN_BEACON_ATTESTER of unexpected type")
+	}
+
+	return &shufflingSpec{
+		slotsPerEpoch:             values["SLOTS_PER_EPOCH"],
+		shuffleRoundCount:         values["SHUFFLE_ROUND_COUNT"],
+		targetCommitteeSize:       values["TARGET_COMMITTEE_SIZE"],
+		maxCommitteesPerSlot:      values["MAX_COMMITTEES_PER_SLOT"],
+		epochsPerHistoricalVector: values["EPOCHS_PER_HISTORICAL_VECTOR"],
+		minSeedLookahead:          values["MIN_SEED_LOOKAHEAD"],
+		domainBeaconAttester:      domainBeaconAttester,
+	}, nil
+}
+
+// stateShufflingData obtains the validators and RANDAO mixes from a beacon state.
+func stateShufflingData(state *spec.VersionedBeaconState) ([]*phase0.Validator, [][]byte, error) {
+	switch state.Version {
+	case spec.DataVersionPhase0:
+		if state.Phase0 == nil {
+			return nil, nil, errors.New("no phase0 state")
+		}
+		return state.Phase0.Validators, state.Phase0.RANDAOMixes, nil
+	case spec.DataVersionAltair:
+		if state.Altair == nil {
+			return nil, nil, errors.New("no altair state")
+		}
+		return state.Altair.Validators, state.Altair.RANDAOMixes, nil
+	case spec.DataVersionBellatrix:
+		if state.Bellatrix == nil {
+			return nil, nil, errors.New("no bellatrix state")
+		}
+		return state.Bellatrix.Validators, state.Bellatrix.RANDAOMixes, nil
+	default:
+		return nil, nil, errors.New("unhandled state version")
+	}
+}
+
+// computeBeaconCommittees computes the beacon committees for an epoch from a
+// beacon state in that epoch, as per the spec's get_beacon_committee().  The
+// result is indexed by slot offset within the epoch, then committee index.
+func (s *shufflingSpec) computeBeaconCommittees(epoch phase0.Epoch,
+	validators []*phase0.Validator,
+	randaoMixes [][]byte,
+) ([][][]phase0.ValidatorIndex, error) {
+	if uint64(len(randaoMixes)) != s.epochsPerHistoricalVector {
+		return nil, errors.New("unexpected number of RANDAO mixes")
+	}
+
+	activeIndices := make([]phase0.ValidatorIndex, 0, len(validators))
+	for i, validator := range validators {
+		if validator.ActivationEpoch <= epoch && epoch < validator.ExitEpoch {
+			activeIndices = append(activeIndices, phase0.ValidatorIndex(i))
+		}
+	}
+
+	seed := s.seed(epoch, randaoMixes)
+	shuffled := s.shuffle(activeIndices, seed)
+
+	committeesPerSlot := uint64(len(activeIndices)) / s.slotsPerEpoch / s.targetCommitteeSize
+	if committeesPerSlot > s.maxCommitteesPerSlot {
+		committeesPerSlot = s.maxCommitteesPerSlot
+	}
+	if committeesPerSlot == 0 {
+		committeesPerSlot = 1
+	}
+
+	count := committeesPerSlot * s.slotsPerEpoch
+	activeCount := uint64(len(shuffled))
+	committees := make([][][]phase0.ValidatorIndex, s.slotsPerEpoch)
+	for slot := uint64(0); slot < s.slotsPerEpoch; slot++ {
+		committees[slot] = make([][]phase0.ValidatorIndex, committeesPerSlot)
+		for index := uint64(0); index < committeesPerSlot; index++ {
+			i := slot*committeesPerSlot + index
+			start := activeCount * i / count
+			end := activeCount * (i + 1) / count
+			committees[slot][index] = shuffled[start:end]
+		}
+	}
+
+	return committees, nil
+}
+
+// seed returns the attester seed for an epoch, as per the spec's get_seed().
+func (s *shufflingSpec) seed(epoch phase0.Epoch, randaoMixes [][]byte) [32]byte {
+	mix := randaoMixes[(uint64(epoch)+s.epochsPerHistoricalVector-s.minSeedLookahead-1)%s.epochsPerHistoricalVector]
+	data := make([]byte, 44)
+	copy(data, s.domainBeaconAttester[:])
+	binary.LittleEndian.PutUint64(data[4:], uint64(epoch))
+	copy(data[12:], mix)
+
+	return sha256.Sum256(data)
+}
+
+// shuffle returns the indices in shuffled order, such that element i of the
+// result is the element at compute_shuffled_index(i) of the input.  This
+// applies the swap-or-not rounds to the whole list in reverse order, which
+// avoids hashing separately for each index.
+func (s *shufflingSpec) shuffle(indices []phase0.ValidatorIndex, seed [32]byte) []phase0.ValidatorIndex {
+	res := make([]phase0.ValidatorIndex, len(indices))
+	copy(res, indices)
+	n := uint64(len(res))
+	if n < 2 {
+		return res
+	}
+
+	buf := make([]byte, 37)
+	copy(buf, seed[:])
+	sources := make([][32]byte, (n+255)/256)
+	for round := int(s.shuffleRoundCount) - 1; round >= 0; round-- {
+		buf[32] = byte(round)
+		pivotHash := sha256.Sum256(buf[:33])
+		pivot := binary.LittleEndian.Uint64(pivotHash[:8]) % n
+		for i := range sources {
+			binary.LittleEndian.PutUint32(buf[33:], uint32(i))
+			sources[i] = sha256.Sum256(buf)
+		}
+		for i := uint64(0); i < n; i++ {
+			flip := (pivot + n - i) % n
+			if flip <= i {
+				// Each pair is considered once, from its lower index.
+				continue
+			}
+			// flip is the higher of the pair, so is the position.
+			source := sources[flip/256]
+			if (source[(flip%256)/8]>>(flip%8))&1 == 1 {
+				res[i], res[flip] = res[flip], res[i]
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,127 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"golang.org/x/sync/semaphore"
+)
+
+// recordingClient is a beacon committees provider that records the states requested.
+type recordingClient struct {
+	testClient
+	stateIDs []string
+}
+
+func (c *recordingClient) BeaconCommittees(ctx context.Context, stateID string) ([]*api.BeaconCommittee, error) {
+	c.stateIDs = append(c.stateIDs, stateID)
+	return c.testClient.BeaconCommittees(ctx, stateID)
+}
+
+func TestBackfillEpochs(t *testing.T) {
+	ctx := context.Background()
+
+	client := &recordingClient{}
+	chainTime := mockchaintime.New()
+	chainDB := mockchaindb.New()
+	s := &Service{
+		eth2Client:             client,
+		catchupClients:         []eth2client.Service{client},
+		chainDB:                chainDB,
+		beaconCommitteesSetter: chainDB.(chaindb.BeaconCommitteesSetter),
+		epochProvenanceSetter:  chainDB.(chaindb.EpochProvenanceSetter),
+		epochCompletionsSetter: chainDB.(chaindb.EpochCompletionsSetter),
+		chainTime:              chainTime,
+		activitySem:            semaphore.NewWeighted(1),
+		firstEpoch:             2,
+		followEpoch:            5,
+	}
+
+	s.backfillEpochs(ctx)
+
+	// Epochs before the follow epoch are fetched most recent first.
+	expected := make([]string, 0)
+	for epoch := phase0.Epoch(4); epoch >= 2; epoch-- {
+		expected = append(expected, fmt.Sprintf("%d", chainTime.FirstSlotOfEpoch(epoch)))
+	}
+	require.Equal(t, expected, client.stateIDs)
+}
+
+// epochChainTime is a chain time service with a settable current epoch.
+type epochChainTime struct {
+	chaintime.Service
+	currentEpoch phase0.Epoch
+}
+
+func (c *epochChainTime) CurrentEpoch() phase0.Epoch {
+	return c.currentEpoch
+}
+
+func TestFollowFromHead(t *testing.T) {
+	tests := []struct {
+		name              string
+		backfill          bool
+		headFirstDistance phase0.Epoch
+		latestEpoch       phase0.Epoch
+		expected          bool
+	}{
+		{
+			name:        "Disabled",
+			latestEpoch: 0,
+		},
+		{
+			name:     "Backfill",
+			backfill: true,
+			expected: true,
+		},
+		{
+			name:              "Near",
+			headFirstDistance: 100,
+			latestEpoch:       901,
+		},
+		{
+			name:              "Far",
+			headFirstDistance: 100,
+			latestEpoch:       900,
+			expected:          true,
+		},
+		{
+			name:              "Empty",
+			headFirstDistance: 100,
+			expected:          true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Service{
+				chainTime:         &epochChainTime{Service: mockchaintime.New(), currentEpoch: 1000},
+				backfill:          test.backfill,
+				headFirstDistance: test.headFirstDistance,
+			}
+			require.Equal(t, test.expected, s.followFromHead(&metadata{LatestEpoch: test.latestEpoch}))
+		})
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fetchedBeaconCommittees are the beacon committees fetched for an epoch.
+type fetchedBeaconCommittees struct {
+	epoch            phase0.Epoch
+	beaconCommittees []*api.BeaconCommittee
+	provenance       *chaindb.Provenance
+	err              error
+}
+
+// fetchBeaconCommitteesBatch fetches the beacon committees for consecutive
+// epochs from startEpoch, up to one epoch per catchup client and no further
+// than endEpoch.  Each epoch is fetched from a different catchup client in
+// parallel; if a catchup client fails the epoch is refetched from the main
+// client.  Results are returned in epoch order.
+func (s *Service) fetchBeaconCommitteesBatch(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) []*fetchedBeaconCommittees {
+	batchSize := len(s.catchupClients)
+	if uint64(endEpoch-startEpoch)+1 < uint64(batchSize) {
+		batchSize = int(endEpoch-startEpoch) + 1
+	}
+
+	batch := make([]*fetchedBeaconCommittees, batchSize)
+	var wg sync.WaitGroup
+	for i := 0; i < batchSize; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			epoch := startEpoch + phase0.Epoch(i)
+			beaconCommittees, provenance, err := s.fetchBeaconCommitteesWithFallback(ctx, s.catchupClients[i], epoch)
+			batch[i] = &fetchedBeaconCommittees{
+				epoch:            epoch,
+				beaconCommittees: beaconCommittees,
+				provenance:       provenance,
+				err:              err,
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return batch
+}
+
+// fetchBeaconCommitteesWithFallback fetches the beacon committees for an epoch
+// from the given client, retrying according to the retry policy.  If the client
+// fails the epoch is refetched from the main client.  The provenance returned
+// is that of the client that supplied the beacon committees.
+func (s *Service) fetchBeaconCommitteesWithFallback(ctx context.Context,
+	client eth2client.Service,
+	epoch phase0.Epoch,
+) (
+	[]*api.BeaconCommittee,
+	*chaindb.Provenance,
+	error,
+) {
+	var beaconCommittees []*api.BeaconCommittee
+	var provenance *chaindb.Provenance
+	err := s.retryPolicy.Do(ctx, func() error {
+		var err error
+		beaconCommittees, provenance, err = s.fetchBeaconCommittees(ctx, client, epoch)
+		if err != nil && client != s.eth2Client {
+			log.Debug().Uint64("epoch", uint64(epoch)).Str("address", client.Address()).Err(err).Msg("Failed to fetch beacon committees from catchup client; trying main client")
+			beaconCommittees, provenance, err = s.fetchBeaconCommittees(ctx, s.eth2Client, epoch)
+		}
+		return err
+	})
+
+	return beaconCommittees, provenance, err
+}
+
+// catchupGap catches up the epochs in a gap with multiple workers, each of which
+// fetches and stores a number of epochs at a time in their own transaction.
+// Epochs that cannot be fetched are left unprocessed, to be refetched later.
+func (s *Service) catchupGap(ctx context.Context, md *metadata, gap util.EpochRange) error {
+	err := util.ProcessGapWithWorkers(ctx, gap, s.catchupWorkers, s.catchupEpochsPerTx,
+		func(ctx context.Context, worker int, epochRange util.EpochRange) []*util.EpochResult {
+			client := s.catchupClients[worker%len(s.catchupClients)]
+			var results []*util.EpochResult
+			err := s.throttle.Wait(util.WithEpochHeadDistance(ctx, s.chainTime, epochRange.End))
+			if err == nil {
+				err = s.scheduler.Run(ctx, "catchup epochs", scheduler.PriorityCatchup, func(ctx context.Context) {
+					results = s.catchupEpochsWithClient(ctx, client, epochRange)
+				})
+			}
+			if err != nil {
+				results = make([]*util.EpochResult, 0)
+				for epoch := epochRange.Start; epoch <= epochRange.End; epoch++ {
+					results = append(results, &util.EpochResult{Epoch: epoch, FetchErr: err})
+				}
+			}
+			for _, result := range results {
+				if result.FetchErr != nil {
+					// Leave the epoch unprocessed and carry on, rather than stalling.
+					log.Warn().Uint64("epoch", uint64(result.Epoch)).Err(result.FetchErr).Msg("Failed to fetch beacon committees; will refetch later")
+					monitorEpochMissed()
+				}
+			}
+			return results
+		},
+		func(ctx context.Context, epochs ...phase0.Epoch) error {
+			return s.recordProcessed(ctx, md, epochs...)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to catch up beacon committees")
+	}
+
+	return nil
+}
+
+// catchupEpochsWithClient fetches the beacon committees for a range of epochs from the
+// given client and stores them in a single transaction.  Each epoch is stored as soon as
+// it has been fetched, so only a single epoch's committees are held in memory at a time.
+func (s *Service) catchupEpochsWithClient(ctx context.Context, client eth2client.Service, epochRange util.EpochRange) []*util.EpochResult {
+	ctx, span := tracer.Start(ctx, "catchupEpochsWithClient", trace.WithAttributes(
+		attribute.Int64("start_epoch", int64(epochRange.Start)),
+		attribute.Int64("end_epoch", int64(epochRange.End)),
+	))
+	defer span.End()
+
+	// Epochs that have been completed since the gap was obtained are not fetched again.
+	completed := make(map[phase0.Epoch]bool)
+	completedEpochs, err := s.epochCompletionsProvider.CompletedEpochs(ctx, metadataKey, epochRange.Start, epochRange.End)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain completed epochs; fetching all epochs")
+	}
+	for _, epoch := range completedEpochs {
+		completed[epoch] = true
+	}
+
+	var results []*util.EpochResult
+	ctx = util.WithEpochHeadDistance(ctx, s.chainTime, epochRange.End)
+	err = util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		// The transaction may be retried, in which case the results start afresh.
+		results = make([]*util.EpochResult, 0, int(epochRange.End-epochRange.Start)+1)
+		for epoch := epochRange.Start; epoch <= epochRange.End; epoch++ {
+			if completed[epoch] {
+				results = append(results, &util.EpochResult{Epoch: epoch})
+				continue
+			}
+			beaconCommittees, provenance, err := s.fetchBeaconCommitteesWithFallback(ctx, client, epoch)
+			if err != nil {
+				results = append(results, &util.EpochResult{Epoch: epoch, FetchErr: err})
+				continue
+			}
+			if err := s.storeBeaconCommittees(ctx, epoch, beaconCommittees, provenance); err != nil {
+				return err
+			}
+			results = append(results, &util.EpochResult{Epoch: epoch})
+		}
+		return nil
+	})
+	if err != nil {
+		// Nothing in the transaction was stored.
+		results = make([]*util.EpochResult, 0, int(epochRange.End-epochRange.Start)+1)
+		for epoch := epochRange.Start; epoch <= epochRange.End; epoch++ {
+			if completed[epoch] {
+				results = append(results, &util.EpochResult{Epoch: epoch})
+			} else {
+				results = append(results, &util.EpochResult{Epoch: epoch, StoreErr: err})
+			}
+		}
+	}
+
+	return results
+}
+
+// recordProcessed marks epochs as processed and stores the metadata in its own transaction.
+func (s *Service) recordProcessed(ctx context.Context, md *metadata, epochs ...phase0.Epoch) error {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		if err := s.setProcessed(ctx, md, epochs...); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+}
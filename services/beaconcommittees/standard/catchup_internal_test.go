@@ -0,0 +1,156 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	mockscheduler "github.com/wealdtech/chaind/services/scheduler/mock"
+	"github.com/wealdtech/chaind/util"
+)
+
+// testClient is a beacon committees provider that returns a single
+// committee with its index set to identify the client.
+type testClient struct {
+	index phase0.CommitteeIndex
+	fail  bool
+}
+
+func (c *testClient) Name() string {
+	return "test"
+}
+
+func (c *testClient) Address() string {
+	return "test"
+}
+
+func (c *testClient) BeaconCommittees(_ context.Context, _ string) ([]*api.BeaconCommittee, error) {
+	if c.fail {
+		return nil, errors.New("failed")
+	}
+	return []*api.BeaconCommittee{{Index: c.index}}, nil
+}
+
+func (c *testClient) BeaconCommitteesAtEpoch(ctx context.Context, stateID string, _ phase0.Epoch) ([]*api.BeaconCommittee, error) {
+	return c.BeaconCommittees(ctx, stateID)
+}
+
+func TestFetchBeaconCommitteesBatch(t *testing.T) {
+	ctx := context.Background()
+
+	mainClient := &testClient{index: 0}
+	catchupClient1 := &testClient{index: 1}
+	catchupClient2 := &testClient{index: 2}
+	s := &Service{
+		eth2Client:     mainClient,
+		catchupClients: []eth2client.Service{catchupClient1, catchupClient2},
+		chainTime:      mockchaintime.New(),
+	}
+
+	// Full batch.
+	batch := s.fetchBeaconCommitteesBatch(ctx, 10, 20)
+	require.Len(t, batch, 2)
+	for i, fetched := range batch {
+		require.NoError(t, fetched.err)
+		require.Equal(t, phase0.Epoch(10+i), fetched.epoch)
+		require.Equal(t, phase0.CommitteeIndex(i+1), fetched.beaconCommittees[0].Index)
+		require.Equal(t, &chaindb.Provenance{Address: "test"}, fetched.provenance)
+	}
+
+	// Batch limited by end epoch.
+	batch = s.fetchBeaconCommitteesBatch(ctx, 20, 20)
+	require.Len(t, batch, 1)
+	require.Equal(t, phase0.Epoch(20), batch[0].epoch)
+
+	// Failing catchup client falls back to the main client.
+	catchupClient2.fail = true
+	batch = s.fetchBeaconCommitteesBatch(ctx, 10, 20)
+	require.Len(t, batch, 2)
+	require.NoError(t, batch[1].err)
+	require.Equal(t, phase0.CommitteeIndex(0), batch[1].beaconCommittees[0].Index)
+
+	// Failing main client as well results in an error.
+	mainClient.fail = true
+	batch = s.fetchBeaconCommitteesBatch(ctx, 10, 20)
+	require.NoError(t, batch[0].err)
+	require.EqualError(t, batch[1].err, "failed to fetch beacon committees: failed")
+}
+
+// completionsDB is a chain database with completion markers for a set of epochs.
+type completionsDB struct {
+	chaindb.Service
+	completed []phase0.Epoch
+}
+
+func (c *completionsDB) CompletedEpochs(_ context.Context, _ string, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	res := make([]phase0.Epoch, 0)
+	for _, epoch := range c.completed {
+		if epoch >= startEpoch && epoch <= endEpoch {
+			res = append(res, epoch)
+		}
+	}
+	return res, nil
+}
+
+func TestCatchupGapWithWorkers(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := &completionsDB{Service: mockchaindb.New()}
+	client := &testClient{}
+	s := &Service{
+		eth2Client:               client,
+		catchupClients:           []eth2client.Service{client},
+		chainDB:                  chainDB,
+		beaconCommitteesSetter:   chainDB.Service.(chaindb.BeaconCommitteesSetter),
+		epochProvenanceSetter:    chainDB.Service.(chaindb.EpochProvenanceSetter),
+		epochCompletionsSetter:   chainDB.Service.(chaindb.EpochCompletionsSetter),
+		epochCompletionsProvider: chainDB,
+		chainTime:                mockchaintime.New(),
+		catchupWorkers:           4,
+		catchupEpochsPerTx:       1,
+		scheduler:                mockscheduler.New(),
+	}
+
+	md := &metadata{}
+	md.setProcessed(5)
+	require.NoError(t, s.catchupGap(ctx, md, util.EpochRange{Start: 10, End: 20}))
+	require.Equal(t, util.EpochRanges{{Start: 5, End: 5}, {Start: 10, End: 20}}, md.ProcessedEpochs)
+	require.Equal(t, phase0.Epoch(20), md.LatestEpoch)
+
+	// Epochs that cannot be fetched are left unprocessed.
+	client.fail = true
+	require.NoError(t, s.catchupGap(ctx, md, util.EpochRange{Start: 21, End: 30}))
+	require.Equal(t, util.EpochRanges{{Start: 5, End: 5}, {Start: 10, End: 20}}, md.ProcessedEpochs)
+
+	// Epochs that are already complete are not fetched.
+	chainDB.completed = []phase0.Epoch{21, 22}
+	require.NoError(t, s.catchupGap(ctx, md, util.EpochRange{Start: 21, End: 30}))
+	require.Equal(t, util.EpochRanges{{Start: 5, End: 5}, {Start: 10, End: 22}}, md.ProcessedEpochs)
+
+	// Multiple epochs per transaction, with a partial final transaction.
+	client.fail = false
+	s.catchupEpochsPerTx = 3
+	require.NoError(t, s.catchupGap(ctx, md, util.EpochRange{Start: 23, End: 30}))
+	require.Equal(t, util.EpochRanges{{Start: 5, End: 5}, {Start: 10, End: 30}}, md.ProcessedEpochs)
+	require.Equal(t, phase0.Epoch(30), md.LatestEpoch)
+}
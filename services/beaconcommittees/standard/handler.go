@@ -18,9 +18,11 @@ import (
 	"fmt"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // OnBeaconChainHeadUpdated receives beacon chain head updated notifications.
@@ -37,10 +39,10 @@ func (s *Service) OnBeaconChainHeadUpdated(
 		return
 	}
 
-	// Only allow 1 handler to be active.
+	// Only allow a limited number of handlers to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		log.Debug().Msg("Too many handlers running")
 		return
 	}
 
@@ -50,22 +52,130 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	md, err := s.getMetadata(ctx)
 	if err != nil {
 		s.activitySem.Release(1)
+		monitorError(err)
 		log.Error().Err(err).Msg("Failed to obtain metadata")
 		return
 	}
 
+	if !s.catchupMu.TryLock() {
+		// Another handler is catching up; update just this epoch rather than wait for it.
+		s.updateHeadEpoch(ctx, md, epoch)
+		s.activitySem.Release(1)
+		return
+	}
 	s.catchup(ctx, md)
+	s.catchupMu.Unlock()
 	s.activitySem.Release(1)
 }
 
+// onEventsResubscribed catches up with any epochs missed while the event stream was down.
+func (s *Service) onEventsResubscribed(ctx context.Context) {
+	s.OnBeaconChainHeadUpdated(ctx, s.chainTime.CurrentSlot(), phase0.Root{}, phase0.Root{}, true)
+}
+
+// OnChainReorg receives chain reorganization notifications.
+func (s *Service) OnChainReorg(
+	ctx context.Context,
+	slot phase0.Slot,
+	depth uint64,
+) {
+	// The reorg replaced the blocks after the common ancestor of the old and new heads,
+	// which can change the beacon committees for the epochs from that point onwards.
+	firstSlot := phase0.Slot(0)
+	if uint64(slot) > depth {
+		firstSlot = slot - phase0.Slot(depth) + 1
+	}
+	firstEpoch := s.chainTime.SlotToEpoch(firstSlot)
+	lastEpoch := s.chainTime.CurrentEpoch()
+	log := log.With().Uint64("first_epoch", uint64(firstEpoch)).Uint64("last_epoch", uint64(lastEpoch)).Logger()
+	log.Trace().Msg("Reorg handler called")
+
+	// Wait for an available handler, as the reorg must not be missed.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		log.Warn().Err(err).Msg("Failed to acquire semaphore")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		monitorError(err)
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	log.Debug().Msg("Refetching beacon committees following chain reorg")
+	for epoch := firstEpoch; epoch <= lastEpoch; epoch++ {
+		if !md.ProcessedEpochs.Contains(epoch) {
+			// Epochs that have not yet been processed will be fetched by catchup.
+			continue
+		}
+		s.updateHeadEpoch(ctx, md, epoch)
+	}
+}
+
+// updateHeadEpoch fetches and stores the beacon committees for a single epoch.
+func (s *Service) updateHeadEpoch(ctx context.Context, md *metadata, epoch phase0.Epoch) {
+	beaconCommittees, provenance, err := s.fetchBeaconCommitteesWithFallback(ctx, s.eth2Client, epoch)
+	if err != nil {
+		monitorError(err)
+		log.Warn().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to fetch beacon committees; will refetch later")
+		monitorEpochMissed()
+		return
+	}
+	if err := s.storeFetched(ctx, md, &fetchedBeaconCommittees{
+		epoch:            epoch,
+		beaconCommittees: beaconCommittees,
+		provenance:       provenance,
+	}); err != nil {
+		monitorError(err)
+		log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to store beacon committees")
+	}
+}
+
 func (s *Service) updateBeaconCommitteesForEpoch(ctx context.Context, epoch phase0.Epoch) error {
-	log.Trace().Uint64("epoch", uint64(epoch)).Msg("Updating beacon committees")
+	var beaconCommittees []*api.BeaconCommittee
+	var provenance *chaindb.Provenance
+	if err := s.retryPolicy.Do(ctx, func() error {
+		var err error
+		beaconCommittees, provenance, err = s.fetchBeaconCommittees(ctx, s.eth2Client, epoch)
+		return err
+	}); err != nil {
+		return err
+	}
 
-	beaconCommittees, err := s.eth2Client.(eth2client.BeaconCommitteesProvider).BeaconCommittees(ctx, fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch)))
+	return s.storeBeaconCommittees(ctx, epoch, beaconCommittees, provenance)
+}
+
+// fetchBeaconCommittees fetches the beacon committees for an epoch from the given client,
+// along with the provenance of the beacon committees.
+func (s *Service) fetchBeaconCommittees(ctx context.Context,
+	client eth2client.Service,
+	epoch phase0.Epoch,
+) (
+	[]*api.BeaconCommittee,
+	*chaindb.Provenance,
+	error,
+) {
+	log.Trace().Uint64("epoch", uint64(epoch)).Str("address", client.Address()).Msg("Fetching beacon committees")
+
+	beaconCommittees, err := client.(eth2client.BeaconCommitteesProvider).BeaconCommittees(ctx, fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch)))
 	if err != nil {
-		return errors.Wrap(err, "failed to fetch beacon committees")
+		return nil, nil, errors.Wrap(err, "failed to fetch beacon committees")
 	}
 
+	return beaconCommittees, util.Provenance(ctx, client), nil
+}
+
+// provenanceDataset is the dataset for which epoch provenance is recorded.
+const provenanceDataset = "beaconcommittees"
+
+// storeBeaconCommittees stores the beacon committees for an epoch, along with their provenance.
+func (s *Service) storeBeaconCommittees(ctx context.Context,
+	epoch phase0.Epoch,
+	beaconCommittees []*api.BeaconCommittee,
+	provenance *chaindb.Provenance,
+) error {
 	for _, beaconCommittee := range beaconCommittees {
 		dbBeaconCommittee := &chaindb.BeaconCommittee{
 			Slot:      beaconCommittee.Slot,
@@ -76,6 +186,11 @@ func (s *Service) updateBeaconCommitteesForEpoch(ctx context.Context, epoch phas
 			return errors.Wrap(err, "failed to set beacon committee")
 		}
 	}
+	if provenance != nil {
+		if err := s.epochProvenanceSetter.SetEpochProvenance(ctx, provenanceDataset, epoch, provenance); err != nil {
+			return errors.Wrap(err, "failed to set epoch provenance")
+		}
+	}
 	monitorEpochProcessed(epoch)
 
 	return nil
@@ -0,0 +1,81 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"golang.org/x/sync/semaphore"
+)
+
+func TestHeadUpdatedDuringCatchup(t *testing.T) {
+	ctx := context.Background()
+
+	client := &recordingClient{}
+	chainDB := mockchaindb.New()
+	s := &Service{
+		eth2Client:             client,
+		catchupClients:         []eth2client.Service{client},
+		chainDB:                chainDB,
+		beaconCommitteesSetter: chainDB.(chaindb.BeaconCommitteesSetter),
+		epochProvenanceSetter:  chainDB.(chaindb.EpochProvenanceSetter),
+		epochCompletionsSetter: chainDB.(chaindb.EpochCompletionsSetter),
+		chainTime:              mockchaintime.New(),
+		activitySem:            semaphore.NewWeighted(2),
+		concurrency:            2,
+	}
+
+	// Another handler is catching up, so only the head epoch is fetched.
+	s.catchupMu.Lock()
+	s.OnBeaconChainHeadUpdated(ctx, 0, phase0.Root{}, phase0.Root{}, true)
+	require.Len(t, client.stateIDs, 1)
+	s.catchupMu.Unlock()
+
+	// All handler slots in use, so nothing is fetched.
+	require.True(t, s.activitySem.TryAcquire(2))
+	s.OnBeaconChainHeadUpdated(ctx, 0, phase0.Root{}, phase0.Root{}, true)
+	require.Len(t, client.stateIDs, 1)
+}
+
+func TestChainReorgUnprocessedEpochs(t *testing.T) {
+	ctx := context.Background()
+
+	client := &recordingClient{}
+	chainDB := mockchaindb.New()
+	s := &Service{
+		eth2Client:             client,
+		catchupClients:         []eth2client.Service{client},
+		chainDB:                chainDB,
+		beaconCommitteesSetter: chainDB.(chaindb.BeaconCommitteesSetter),
+		epochProvenanceSetter:  chainDB.(chaindb.EpochProvenanceSetter),
+		epochCompletionsSetter: chainDB.(chaindb.EpochCompletionsSetter),
+		chainTime:              mockchaintime.New(),
+		activitySem:            semaphore.NewWeighted(1),
+		concurrency:            1,
+	}
+
+	// Epochs that have not been processed are left for catchup.
+	s.OnChainReorg(ctx, 10, 20)
+	require.Empty(t, client.stateIDs)
+
+	// Handler slot is released afterwards.
+	require.True(t, s.activitySem.TryAcquire(1))
+}
@@ -19,11 +19,18 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
 )
 
 // metadata stored about this service.
 type metadata struct {
-	LatestEpoch  phase0.Epoch   `json:"latest_epoch"`
+	// LatestEpoch is the highest epoch processed.
+	LatestEpoch phase0.Epoch `json:"latest_epoch"`
+	// ProcessedEpochs are the epochs that have been processed.
+	ProcessedEpochs util.EpochRanges `json:"processed_epochs,omitempty"`
+	// MissedEpochs are epochs that failed to process.  This is only present
+	// in metadata written by older versions of chaind, and is converted to
+	// processed epochs when read.
 	MissedEpochs []phase0.Epoch `json:"missed_epochs,omitempty"`
 }
 
@@ -43,9 +50,20 @@ func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	if err := json.Unmarshal(mdJSON, md); err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal metadata")
 	}
+	if md.ProcessedEpochs == nil {
+		// Metadata written by older versions of chaind.
+		md.ProcessedEpochs = util.ProcessedFromLatest(md.LatestEpoch, md.MissedEpochs)
+		md.MissedEpochs = nil
+	}
 	return md, nil
 }
 
+// setProcessed marks an epoch as processed.
+func (md *metadata) setProcessed(epoch phase0.Epoch) {
+	md.ProcessedEpochs.Add(epoch)
+	md.LatestEpoch, _ = md.ProcessedEpochs.Highest()
+}
+
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	mdJSON, err := json.Marshal(md)
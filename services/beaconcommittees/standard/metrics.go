@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/util"
 )
 
 var metricsNamespace = "chaind_beaconcommittees"
@@ -27,6 +28,8 @@ var metricsNamespace = "chaind_beaconcommittees"
 var highestEpoch phase0.Epoch
 var latestEpoch prometheus.Gauge
 var epochsProcessed prometheus.Gauge
+var epochsMissed prometheus.Counter
+var errorsTotal *prometheus.CounterVec
 
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
 	if latestEpoch != nil {
@@ -62,6 +65,27 @@ func registerPrometheusMetrics(ctx context.Context) error {
 		return errors.Wrap(err, "failed to register epochs_processed")
 	}
 
+	epochsMissed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "epochs_missed_total",
+		Help:      "Number of epochs for which beacon committees could not be fetched",
+	})
+	if err := prometheus.Register(epochsMissed); err != nil {
+		return errors.Wrap(err, "failed to register epochs_missed_total")
+	}
+
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "errors_total",
+		Help:      "Number of errors, by type",
+	}, []string{"type"})
+	if err := prometheus.Register(errorsTotal); err != nil {
+		return errors.Wrap(err, "failed to register errors_total")
+	}
+	for _, errorClass := range util.ErrorClasses {
+		errorsTotal.WithLabelValues(errorClass)
+	}
+
 	return nil
 }
 
@@ -74,3 +98,16 @@ func monitorEpochProcessed(epoch phase0.Epoch) {
 		}
 	}
 }
+
+func monitorEpochMissed() {
+	if epochsMissed != nil {
+		epochsMissed.Inc()
+	}
+}
+
+// monitorError counts an error by its type.
+func monitorError(err error) {
+	if errorsTotal != nil && err != nil {
+		errorsTotal.WithLabelValues(util.ClassifyError(err)).Inc()
+	}
+}
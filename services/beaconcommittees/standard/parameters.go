@@ -15,21 +15,37 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	monitor    metrics.Service
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
-	chainTime  chaintime.Service
-	startEpoch int64
+	logLevel           zerolog.Level
+	logLevelSampler    zerolog.Sampler
+	monitor            metrics.Service
+	eth2Client         eth2client.Service
+	catchupClients     []eth2client.Service
+	chainDB            chaindb.Service
+	chainTime          chaintime.Service
+	startEpoch         int64
+	passive            bool
+	backfill           bool
+	headFirstDistance  phase0.Epoch
+	throttle           *util.Throttle
+	retryPolicy        *util.RetryPolicy
+	catchupWorkers     int
+	catchupEpochsPerTx int
+	concurrency        int64
+	eventsStallTimeout time.Duration
+	scheduler          scheduler.Service
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +66,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -64,6 +88,14 @@ func WithETH2Client(eth2Client eth2client.Service) Parameter {
 	})
 }
 
+// WithCatchupClients sets the Ethereum 2 clients across which requests are
+// spread when catching up.  If not supplied the Ethereum 2 client is used.
+func WithCatchupClients(clients []eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.catchupClients = clients
+	})
+}
+
 // WithChainDB sets the chain database for this module.
 func WithChainDB(chainDB chaindb.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -85,11 +117,93 @@ func WithStartEpoch(startEpoch int64) Parameter {
 	})
 }
 
+// WithPassive sets the service to passive mode, in which it neither catches up with
+// nor follows the chain and only acts on explicit requests such as reindexing.
+func WithPassive(passive bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.passive = passive
+	})
+}
+
+// WithBackfill sets the service to follow the chain from the current epoch on start,
+// and to fill in earlier epochs in reverse order in the background.
+func WithBackfill(backfill bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backfill = backfill
+	})
+}
+
+// WithHeadFirstDistance sets the number of epochs the module can be behind the
+// chain on start before it follows the chain from the current epoch and backfills
+// earlier data in the background, as if backfill were enabled.  0 disables this.
+func WithHeadFirstDistance(distance phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headFirstDistance = distance
+	})
+}
+
+// WithThrottle sets the throttle that holds back catchup and backfill while
+// the database is under load.
+func WithThrottle(throttle *util.Throttle) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.throttle = throttle
+	})
+}
+
+// WithRetryPolicy sets the policy for retrying failed fetches from the beacon node.
+func WithRetryPolicy(policy *util.RetryPolicy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.retryPolicy = policy
+	})
+}
+
+// WithCatchupWorkers sets the number of epochs processed concurrently when catching up.
+func WithCatchupWorkers(workers int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.catchupWorkers = workers
+	})
+}
+
+// WithCatchupEpochsPerTx sets the number of epochs stored in each database transaction
+// when catching up.
+func WithCatchupEpochsPerTx(epochs int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.catchupEpochsPerTx = epochs
+	})
+}
+
+// WithConcurrency sets the number of handlers that can be active at the same time.
+// If more than one handler can be active, a handler that arrives while another is
+// catching up processes just its own epoch rather than waiting for the catchup.
+func WithConcurrency(concurrency int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.concurrency = concurrency
+	})
+}
+
+// WithEventsStallTimeout sets the time without events after which the event
+// stream is considered lost and is re-established.  0 disables the check.
+func WithEventsStallTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eventsStallTimeout = timeout
+	})
+}
+
+// WithScheduler sets the scheduler for jobs run by this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:   zerolog.GlobalLevel(),
-		startEpoch: -1,
+		logLevel:           zerolog.GlobalLevel(),
+		startEpoch:         -1,
+		catchupWorkers:     1,
+		catchupEpochsPerTx: 1,
+		concurrency:        1,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -109,12 +223,30 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		//nolint:stylecheck
 		return nil, errors.New("Ethereum 2 client does not provide events") // skipcq: SCC-ST1005
 	}
+	for _, client := range parameters.catchupClients {
+		if _, isProvider := client.(eth2client.BeaconCommitteesProvider); !isProvider {
+			return nil, errors.New("catchup Ethereum 2 client does not provide beacon committee information")
+		}
+	}
 	if parameters.chainDB == nil {
 		return nil, errors.New("no chain database specified")
 	}
 	if parameters.chainTime == nil {
 		return nil, errors.New("no chain time specified")
 	}
+	if parameters.catchupWorkers < 1 {
+		return nil, errors.New("catchup workers must be at least 1")
+	}
+	if parameters.catchupEpochsPerTx < 1 {
+		return nil, errors.New("catchup epochs per transaction must be at least 1")
+	}
+	if parameters.concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
+	}
+
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
 
 	return &parameters, nil
 }
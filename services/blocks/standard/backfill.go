@@ -0,0 +1,180 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// backfillRetryInterval is the time to wait before refetching a block that failed to backfill.
+var backfillRetryInterval = time.Minute
+
+// followFromHead returns true if the service should follow the chain from the
+// current slot and backfill earlier slots, rather than catch up in order.  This is
+// the case if backfill is enabled, if a backfill is in progress, or if the service
+// is at least the head-first distance behind the chain.
+func (s *Service) followFromHead(md *metadata) bool {
+	if s.backfill || md.Backfill != nil {
+		return true
+	}
+	if s.headFirstDistance == 0 {
+		return false
+	}
+	currentEpoch := s.chainTime.CurrentEpoch()
+	latestEpoch := s.chainTime.SlotToEpoch(md.LatestSlot)
+	if currentEpoch < latestEpoch+s.headFirstDistance {
+		return false
+	}
+	log.Info().Uint64("latest_epoch", uint64(latestEpoch)).Msg("Far behind the chain; following the chain and backfilling earlier slots")
+
+	return true
+}
+
+// startBackfill sets the slots before the current slot that have yet to be
+// processed to be backfilled, so that catchup starts from the current slot, and
+// starts backfilling in the background.
+// This requires the activity semaphore to be held.
+func (s *Service) startBackfill(ctx context.Context, md *metadata) error {
+	currentSlot := s.chainTime.CurrentSlot()
+	if md.Backfill == nil && currentSlot > md.LatestSlot {
+		md.Backfill = &slotRange{
+			Start: md.LatestSlot,
+			End:   currentSlot - 1,
+		}
+		md.LatestSlot = currentSlot - 1
+
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.setMetadata(ctx, md)
+		}); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+	}
+
+	if md.Backfill != nil {
+		log.Info().Uint64("start_slot", uint64(md.Backfill.Start)).Uint64("end_slot", uint64(md.Backfill.End)).Msg("Backfilling slots")
+		go s.backfillSlots(ctx)
+	}
+
+	return nil
+}
+
+// backfillSlots processes the slots to be backfilled, most recent first, so
+// that recent data is available as soon as possible.
+func (s *Service) backfillSlots(ctx context.Context) {
+	for {
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			monitorError(err)
+			log.Error().Err(err).Msg("Failed to obtain metadata for backfill")
+			return
+		}
+		if md.Backfill == nil {
+			log.Info().Msg("Backfill complete")
+			return
+		}
+		slot := md.Backfill.End
+		if err := s.throttle.Wait(ctx); err != nil {
+			return
+		}
+
+		// Fetching takes place outside of the activity semaphore, so that following
+		// the chain is held up only for as long as it takes to store the block.
+		signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+		if err == nil && signedBlock != nil {
+			err = s.checkBlockVersion(slot, signedBlock)
+		}
+		if err != nil {
+			monitorError(err)
+			log.Warn().Uint64("slot", uint64(slot)).Err(err).Msg("Failed to fetch block for backfill; will retry")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backfillRetryInterval):
+			}
+			continue
+		}
+
+		block, err := s.storeBackfillBlock(ctx, slot, signedBlock)
+		if err != nil {
+			monitorError(err)
+			log.Error().Uint64("slot", uint64(slot)).Err(err).Msg("Failed to store backfilled block")
+			return
+		}
+		monitorBlockProcessed(slot)
+
+		if block != nil {
+			for _, blockHandler := range s.blockHandlers {
+				blockHandler.OnBlockIndexed(ctx, block.Slot, block.Root)
+			}
+		}
+	}
+}
+
+// storeBackfillBlock stores a backfilled block, returning the block as stored in the database.
+// The block can be nil, in which case the slot is marked as backfilled without storing anything.
+func (s *Service) storeBackfillBlock(ctx context.Context,
+	slot phase0.Slot,
+	signedBlock *spec.VersionedSignedBeaconBlock,
+) (
+	*chaindb.Block,
+	error,
+) {
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return nil, errors.Wrap(err, "failed to acquire semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	var block *chaindb.Block
+	err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		// Metadata is obtained afresh, as it may have been updated while following the chain.
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain metadata")
+		}
+		if md.Backfill == nil || md.Backfill.End != slot {
+			return errors.New("backfill metadata changed unexpectedly")
+		}
+
+		block = nil
+		if signedBlock != nil {
+			block, err = s.onBlock(ctx, signedBlock)
+			if err != nil {
+				return errors.Wrap(err, "failed to update block")
+			}
+		}
+		if md.Backfill.End == md.Backfill.Start {
+			md.Backfill = nil
+		} else {
+			md.Backfill.End--
+		}
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
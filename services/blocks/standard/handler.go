@@ -18,16 +18,24 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/altair"
 	"github.com/attestantio/go-eth2-client/spec/bellatrix"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// farFutureEpoch is the exit epoch of a validator that has not exited.
+var farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
 // OnBeaconChainHeadUpdated receives beacon chain head updated notifications.
 func (s *Service) OnBeaconChainHeadUpdated(
 	ctx context.Context,
@@ -37,6 +45,11 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	// skipcq: RVV-A0005
 	epochTransition bool,
 ) {
+	// Note when the block was seen, even if it is fetched by another handler.
+	if blockRoot != (phase0.Root{}) {
+		s.recordSeen(blockRoot, time.Now())
+	}
+
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
@@ -46,6 +59,10 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	defer s.activitySem.Release(1)
 
 	log := log.With().Uint64("slot", uint64(slot)).Str("block_root", fmt.Sprintf("%#x", blockRoot)).Logger()
+
+	ctx, span := tracer.Start(ctx, "OnBeaconChainHeadUpdated", trace.WithAttributes(attribute.Int64("slot", int64(slot))))
+	defer span.End()
+
 	log.Trace().
 		Str("state_root", fmt.Sprintf("%#x", stateRoot)).
 		Bool("epoch_transition", epochTransition).
@@ -58,6 +75,7 @@ func (s *Service) OnBeaconChainHeadUpdated(
 
 	md, err := s.getMetadata(ctx)
 	if err != nil {
+		monitorError(err)
 		log.Error().Err(err).Msg("Failed to obtain metadata")
 		return
 	}
@@ -68,7 +86,69 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	monitorBlockProcessed(slot)
 }
 
-func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) error {
+// onEventsResubscribed catches up with any slots missed while the event stream was down.
+func (s *Service) onEventsResubscribed(ctx context.Context) {
+	s.OnBeaconChainHeadUpdated(ctx, s.chainTime.CurrentSlot(), phase0.Root{}, phase0.Root{}, true)
+}
+
+// OnChainReorg receives chain reorganization notifications.
+func (s *Service) OnChainReorg(
+	ctx context.Context,
+	slot phase0.Slot,
+	depth uint64,
+) {
+	// The reorg replaced the blocks after the common ancestor of the old and new heads.
+	firstSlot := phase0.Slot(0)
+	if uint64(slot) > depth {
+		firstSlot = slot - phase0.Slot(depth) + 1
+	}
+	log := log.With().Uint64("first_slot", uint64(firstSlot)).Uint64("last_slot", uint64(slot)).Logger()
+	log.Trace().Msg("Reorg handler called")
+
+	// Wait for any active handler, as the reorg must not be missed.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		log.Warn().Err(err).Msg("Failed to acquire semaphore")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		monitorError(err)
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+	if slot > md.LatestSlot {
+		// Slots that have not yet been processed will be fetched by catchup.
+		slot = md.LatestSlot
+	}
+
+	log.Debug().Msg("Refetching blocks following chain reorg")
+	for curSlot := firstSlot; curSlot <= slot; curSlot++ {
+		var block *chaindb.Block
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			var err error
+			block, err = s.refetchBlockForSlot(ctx, curSlot)
+			return err
+		}); err != nil {
+			monitorError(err)
+			log.Warn().Uint64("slot", uint64(curSlot)).Err(err).Msg("Failed to refetch block")
+			return
+		}
+		if block != nil {
+			for _, blockHandler := range s.blockHandlers {
+				blockHandler.OnBlockIndexed(ctx, block.Slot, block.Root)
+			}
+		}
+	}
+}
+
+// updateBlockForSlot updates the block for the given slot.
+// Returns the block if it was updated, or nil if there was no update.
+func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) (*chaindb.Block, error) {
+	ctx, span := tracer.Start(ctx, "updateBlockForSlot", trace.WithAttributes(attribute.Int64("slot", int64(slot))))
+	defer span.End()
+
 	log := log.With().Uint64("slot", uint64(slot)).Logger()
 
 	// Start off by seeing if we already have the block (unless we are re-fetching regardless).
@@ -76,46 +156,91 @@ func (s *Service) updateBlockForSlot(ctx context.Context, slot phase0.Slot) erro
 		blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
 		if err == nil && len(blocks) > 0 {
 			log.Debug().Msg("Already have this block; not re-fetching")
-			return nil
+			return nil, nil
 		}
 	}
 
+	return s.refetchBlockForSlot(ctx, slot)
+}
+
+// refetchBlockForSlot fetches and stores the block for the given slot,
+// regardless of if it is already present in the database.
+// Returns the block if it was updated, or nil if there was no update.
+func (s *Service) refetchBlockForSlot(ctx context.Context, slot phase0.Slot) (*chaindb.Block, error) {
+	log := log.With().Uint64("slot", uint64(slot)).Logger()
+
 	log.Trace().Msg("Updating block for slot")
 	signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain beacon block for slot")
+		return nil, errors.Wrap(err, "failed to obtain beacon block for slot")
 	}
 	if signedBlock == nil {
 		log.Debug().Msg("No beacon block obtained for slot")
-		return nil
+		return nil, nil
+	}
+	if err := s.checkBlockVersion(slot, signedBlock); err != nil {
+		return nil, err
+	}
+	return s.onBlock(ctx, signedBlock)
+}
+
+// checkBlockVersion confirms that the version of a block obtained from the
+// beacon node is that of the fork in effect at its slot according to the fork
+// schedule, rather than that of the fork the beacon node is currently on.
+func (s *Service) checkBlockVersion(slot phase0.Slot, signedBlock *spec.VersionedSignedBeaconBlock) error {
+	epoch := s.chainTime.SlotToEpoch(slot)
+	expected := s.chainTime.EpochDataVersion(epoch)
+	if expected > spec.DataVersionBellatrix {
+		return fmt.Errorf("fork in effect at epoch %d is not supported", epoch)
+	}
+	if signedBlock.Version != expected {
+		return fmt.Errorf("beacon block has version %s but fork schedule requires %s", signedBlock.Version, expected)
 	}
-	return s.OnBlock(ctx, signedBlock)
+	return nil
 }
 
 // OnBlock handles a block.
 // This requires the context to hold an active transaction.
 func (s *Service) OnBlock(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock) error {
+	_, err := s.onBlock(ctx, signedBlock)
+	return err
+}
+
+// onBlock handles a block, returning the block as stored in the database.
+func (s *Service) onBlock(ctx context.Context, signedBlock *spec.VersionedSignedBeaconBlock) (*chaindb.Block, error) {
+	if s.halt.Halted() {
+		return nil, util.ErrHalted
+	}
+
 	// Update the block in the database.
 	dbBlock, err := s.dbBlock(ctx, signedBlock)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain database block")
+		return nil, errors.Wrap(err, "failed to obtain database block")
 	}
+	dbBlock.Provenance = util.Provenance(ctx, s.eth2Client)
+	dbBlock.SeenDelay = s.seenDelay(dbBlock.Slot, dbBlock.Root)
 	if err := s.blocksSetter.SetBlock(ctx, dbBlock); err != nil {
-		return errors.Wrap(err, "failed to set block")
+		return nil, errors.Wrap(err, "failed to set block")
 	}
 	switch signedBlock.Version {
 	case spec.DataVersionPhase0:
-		return s.onBlockPhase0(ctx, signedBlock.Phase0, dbBlock)
+		err = s.onBlockPhase0(ctx, signedBlock.Phase0, dbBlock)
 	case spec.DataVersionAltair:
-		return s.onBlockAltair(ctx, signedBlock.Altair, dbBlock)
+		err = s.onBlockAltair(ctx, signedBlock.Altair, dbBlock)
 	case spec.DataVersionBellatrix:
-		return s.onBlockBellatrix(ctx, signedBlock.Bellatrix, dbBlock)
+		err = s.onBlockBellatrix(ctx, signedBlock.Bellatrix, dbBlock)
 	default:
-		return errors.New("unknown block version")
+		err = errors.New("unknown block version")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dbBlock, nil
 }
 
 func (s *Service) onBlockPhase0(ctx context.Context, signedBlock *phase0.SignedBeaconBlock, dbBlock *chaindb.Block) error {
+	slashings := s.newBlockSlashings(ctx, dbBlock, signedBlock.Message.Body.ProposerSlashings, signedBlock.Message.Body.AttesterSlashings)
 	if err := s.updateAttestationsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
@@ -125,12 +250,14 @@ func (s *Service) onBlockPhase0(ctx context.Context, signedBlock *phase0.SignedB
 	if err := s.updateProposerSlashingsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		slashings,
 		signedBlock.Message.Body.ProposerSlashings); err != nil {
 		return errors.Wrap(err, "failed to update proposer slashings")
 	}
 	if err := s.updateAttesterSlashingsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		slashings,
 		signedBlock.Message.Body.AttesterSlashings); err != nil {
 		return errors.Wrap(err, "failed to update attester slashings")
 	}
@@ -143,6 +270,7 @@ func (s *Service) onBlockPhase0(ctx context.Context, signedBlock *phase0.SignedB
 	if err := s.updateVoluntaryExitsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		signedBlock.Message.StateRoot,
 		signedBlock.Message.Body.VoluntaryExits); err != nil {
 		return errors.Wrap(err, "failed to update voluntary exits")
 	}
@@ -150,6 +278,7 @@ func (s *Service) onBlockPhase0(ctx context.Context, signedBlock *phase0.SignedB
 }
 
 func (s *Service) onBlockAltair(ctx context.Context, signedBlock *altair.SignedBeaconBlock, dbBlock *chaindb.Block) error {
+	slashings := s.newBlockSlashings(ctx, dbBlock, signedBlock.Message.Body.ProposerSlashings, signedBlock.Message.Body.AttesterSlashings)
 	if err := s.updateAttestationsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
@@ -159,12 +288,14 @@ func (s *Service) onBlockAltair(ctx context.Context, signedBlock *altair.SignedB
 	if err := s.updateProposerSlashingsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		slashings,
 		signedBlock.Message.Body.ProposerSlashings); err != nil {
 		return errors.Wrap(err, "failed to update proposer slashings")
 	}
 	if err := s.updateAttesterSlashingsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		slashings,
 		signedBlock.Message.Body.AttesterSlashings); err != nil {
 		return errors.Wrap(err, "failed to update attester slashings")
 	}
@@ -177,6 +308,7 @@ func (s *Service) onBlockAltair(ctx context.Context, signedBlock *altair.SignedB
 	if err := s.updateVoluntaryExitsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		signedBlock.Message.StateRoot,
 		signedBlock.Message.Body.VoluntaryExits); err != nil {
 		return errors.Wrap(err, "failed to update voluntary exits")
 	}
@@ -190,6 +322,7 @@ func (s *Service) onBlockAltair(ctx context.Context, signedBlock *altair.SignedB
 }
 
 func (s *Service) onBlockBellatrix(ctx context.Context, signedBlock *bellatrix.SignedBeaconBlock, dbBlock *chaindb.Block) error {
+	slashings := s.newBlockSlashings(ctx, dbBlock, signedBlock.Message.Body.ProposerSlashings, signedBlock.Message.Body.AttesterSlashings)
 	if err := s.updateAttestationsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
@@ -199,12 +332,14 @@ func (s *Service) onBlockBellatrix(ctx context.Context, signedBlock *bellatrix.S
 	if err := s.updateProposerSlashingsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		slashings,
 		signedBlock.Message.Body.ProposerSlashings); err != nil {
 		return errors.Wrap(err, "failed to update proposer slashings")
 	}
 	if err := s.updateAttesterSlashingsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		slashings,
 		signedBlock.Message.Body.AttesterSlashings); err != nil {
 		return errors.Wrap(err, "failed to update attester slashings")
 	}
@@ -217,6 +352,7 @@ func (s *Service) onBlockBellatrix(ctx context.Context, signedBlock *bellatrix.S
 	if err := s.updateVoluntaryExitsForBlock(ctx,
 		signedBlock.Message.Slot,
 		dbBlock.Root,
+		signedBlock.Message.StateRoot,
 		signedBlock.Message.Body.VoluntaryExits); err != nil {
 		return errors.Wrap(err, "failed to update voluntary exits")
 	}
@@ -234,15 +370,24 @@ func (s *Service) updateAttestationsForBlock(ctx context.Context,
 	blockRoot phase0.Root,
 	attestations []*phase0.Attestation,
 ) error {
+	if !s.attestations {
+		return nil
+	}
+
 	beaconCommittees := make(map[phase0.Slot]map[phase0.CommitteeIndex]*chaindb.BeaconCommittee)
+	dbAttestations := make([]*chaindb.Attestation, 0, len(attestations))
 	for i, attestation := range attestations {
 		dbAttestation, err := s.dbAttestation(ctx, slot, blockRoot, uint64(i), attestation, beaconCommittees)
 		if err != nil {
 			return errors.Wrap(err, "failed to obtain database attestation")
 		}
-		if err := s.attestationsSetter.SetAttestation(ctx, dbAttestation); err != nil {
-			return errors.Wrap(err, "failed to set attestation")
-		}
+		dbAttestations = append(dbAttestations, dbAttestation)
+	}
+	if len(dbAttestations) == 0 {
+		return nil
+	}
+	if err := s.attestationsSetter.SetAttestations(ctx, dbAttestations); err != nil {
+		return errors.Wrap(err, "failed to set attestations")
 	}
 	return nil
 }
@@ -250,13 +395,19 @@ func (s *Service) updateAttestationsForBlock(ctx context.Context,
 func (s *Service) updateProposerSlashingsForBlock(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
+	slashings *blockSlashings,
 	proposerSlashings []*phase0.ProposerSlashing,
 ) error {
+	if !s.proposerSlashings {
+		return nil
+	}
+
 	for i, proposerSlashing := range proposerSlashings {
 		dbProposerSlashing, err := s.dbProposerSlashing(ctx, slot, blockRoot, uint64(i), proposerSlashing)
 		if err != nil {
 			return errors.Wrap(err, "failed to obtain database proposer slashing")
 		}
+		slashings.proposerSlashing(i, dbProposerSlashing)
 		if err := s.proposerSlashingsSetter.SetProposerSlashing(ctx, dbProposerSlashing); err != nil {
 			return errors.Wrap(err, "failed to set proposer slashing")
 		}
@@ -267,13 +418,19 @@ func (s *Service) updateProposerSlashingsForBlock(ctx context.Context,
 func (s *Service) updateAttesterSlashingsForBlock(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
+	slashings *blockSlashings,
 	attesterSlashings []*phase0.AttesterSlashing,
 ) error {
+	if !s.attesterSlashings {
+		return nil
+	}
+
 	for i, attesterSlashing := range attesterSlashings {
 		dbAttesterSlashing, err := s.dbAttesterSlashing(ctx, slot, blockRoot, uint64(i), attesterSlashing)
 		if err != nil {
 			return errors.Wrap(err, "failed to obtain database attester slashing")
 		}
+		slashings.attesterSlashing(i, dbAttesterSlashing)
 		if err := s.attesterSlashingsSetter.SetAttesterSlashing(ctx, dbAttesterSlashing); err != nil {
 			return errors.Wrap(err, "failed to set attester slashing")
 		}
@@ -286,6 +443,10 @@ func (s *Service) updateDepositsForBlock(ctx context.Context,
 	blockRoot phase0.Root,
 	deposits []*phase0.Deposit,
 ) error {
+	if !s.deposits {
+		return nil
+	}
+
 	for i, deposit := range deposits {
 		dbDeposit, err := s.dbDeposit(ctx, slot, blockRoot, uint64(i), deposit)
 		if err != nil {
@@ -301,10 +462,19 @@ func (s *Service) updateDepositsForBlock(ctx context.Context,
 func (s *Service) updateVoluntaryExitsForBlock(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
+	stateRoot phase0.Root,
 	voluntaryExits []*phase0.SignedVoluntaryExit,
 ) error {
+	if !s.voluntaryExits {
+		return nil
+	}
+	if len(voluntaryExits) == 0 {
+		return nil
+	}
+
+	validators := s.exitingValidators(ctx, stateRoot, voluntaryExits)
 	for i, voluntaryExit := range voluntaryExits {
-		dbVoluntaryExit, err := s.dbVoluntaryExit(ctx, slot, blockRoot, uint64(i), voluntaryExit)
+		dbVoluntaryExit, err := s.dbVoluntaryExit(ctx, slot, blockRoot, uint64(i), voluntaryExit, validators[voluntaryExit.Message.ValidatorIndex])
 		if err != nil {
 			return errors.Wrap(err, "failed to obtain database voluntary exit")
 		}
@@ -315,11 +485,41 @@ func (s *Service) updateVoluntaryExitsForBlock(ctx context.Context,
 	return nil
 }
 
+// exitingValidators fetches the validators that exit in a block, as they are
+// in the state after the block.  The voluntary exits are still stored if the
+// state is not available, for example if the beacon node has pruned it, so
+// an empty map is returned on failure.
+func (s *Service) exitingValidators(ctx context.Context,
+	stateRoot phase0.Root,
+	voluntaryExits []*phase0.SignedVoluntaryExit,
+) map[phase0.ValidatorIndex]*apiv1.Validator {
+	validatorsProvider, isProvider := s.eth2Client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return map[phase0.ValidatorIndex]*apiv1.Validator{}
+	}
+
+	indices := make([]phase0.ValidatorIndex, len(voluntaryExits))
+	for i, voluntaryExit := range voluntaryExits {
+		indices[i] = voluntaryExit.Message.ValidatorIndex
+	}
+	validators, err := validatorsProvider.Validators(ctx, fmt.Sprintf("%#x", stateRoot), indices)
+	if err != nil {
+		log.Debug().Err(err).Str("state_root", fmt.Sprintf("%#x", stateRoot)).Msg("Failed to obtain exiting validators; not storing exit queue information")
+		return map[phase0.ValidatorIndex]*apiv1.Validator{}
+	}
+
+	return validators
+}
+
 func (s *Service) updateSyncAggregateForBlock(ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
 	syncAggregate *altair.SyncAggregate,
 ) error {
+	if !s.syncAggregates {
+		return nil
+	}
+
 	dbSyncAggregate, err := s.dbSyncAggregate(ctx, slot, blockRoot, syncAggregate)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain database sync aggregate")
@@ -512,6 +712,10 @@ func (s *Service) dbAttestation(
 				aggregationIndices = append(aggregationIndices, committee.Committee[i])
 			}
 		}
+	} else if s.halt.Inconsistent() {
+		log.Error().Int("committee_length", len(committee.Committee)).Uint64("aggregation_bits_length", attestation.AggregationBits.Len()).Msg("Attestation and committee size mismatch; halting")
+		monitorHalted()
+		return nil, util.ErrHalted
 	} else {
 		log.Warn().Int("committee_length", len(committee.Committee)).Uint64("aggregation_bits_length", attestation.AggregationBits.Len()).Msg("Attestation and committee size mismatch")
 	}
@@ -595,13 +799,14 @@ func (*Service) dbDeposit(
 	return dbDeposit, nil
 }
 
-func (*Service) dbVoluntaryExit(
+func (s *Service) dbVoluntaryExit(
 	// skipcq: RVV-B0012
 	ctx context.Context,
 	slot phase0.Slot,
 	blockRoot phase0.Root,
 	index uint64,
 	voluntaryExit *phase0.SignedVoluntaryExit,
+	validator *apiv1.Validator,
 ) (*chaindb.VoluntaryExit, error) {
 	dbVoluntaryExit := &chaindb.VoluntaryExit{
 		InclusionSlot:      slot,
@@ -611,6 +816,21 @@ func (*Service) dbVoluntaryExit(
 		Epoch:              voluntaryExit.Message.Epoch,
 	}
 
+	if validator != nil && validator.Validator != nil && validator.Validator.ExitEpoch != farFutureEpoch {
+		exitEpoch := validator.Validator.ExitEpoch
+		withdrawableEpoch := validator.Validator.WithdrawableEpoch
+		dbVoluntaryExit.ExitEpoch = &exitEpoch
+		dbVoluntaryExit.WithdrawableEpoch = &withdrawableEpoch
+
+		// The earliest exit epoch is that given by an empty exit queue;
+		// anything beyond that is time spent waiting in the queue.
+		earliestExitEpoch := s.chainTime.SlotToEpoch(slot) + 1 + s.maxSeedLookahead
+		if exitEpoch >= earliestExitEpoch {
+			exitQueueEpochs := uint64(exitEpoch - earliestExitEpoch)
+			dbVoluntaryExit.ExitQueueEpochs = &exitQueueEpochs
+		}
+	}
+
 	return dbVoluntaryExit, nil
 }
 
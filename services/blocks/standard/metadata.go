@@ -24,6 +24,14 @@ import (
 // metadata stored about this service.
 type metadata struct {
 	LatestSlot phase0.Slot `json:"latest_slot"`
+	// Backfill is the range of slots yet to be backfilled, if any.
+	Backfill *slotRange `json:"backfill,omitempty"`
+}
+
+// slotRange is an inclusive range of slots.
+type slotRange struct {
+	Start phase0.Slot `json:"start"`
+	End   phase0.Slot `json:"end"`
 }
 
 // metadataKey is the key for the metadata.
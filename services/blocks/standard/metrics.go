@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/util"
 )
 
 var metricsNamespace = "chaind_blocks"
@@ -27,6 +28,8 @@ var metricsNamespace = "chaind_blocks"
 var highestSlot phase0.Slot
 var latestBlock prometheus.Gauge
 var blocksProcessed prometheus.Gauge
+var errorsTotal *prometheus.CounterVec
+var halted prometheus.Gauge
 
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
 	if latestBlock != nil {
@@ -63,6 +66,27 @@ func registerPrometheusMetrics(ctx context.Context) error {
 		return errors.Wrap(err, "failed to register blocks_processed")
 	}
 
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "errors_total",
+		Help:      "Number of errors, by type",
+	}, []string{"type"})
+	if err := prometheus.Register(errorsTotal); err != nil {
+		return errors.Wrap(err, "failed to register errors_total")
+	}
+	for _, errorClass := range util.ErrorClasses {
+		errorsTotal.WithLabelValues(errorClass)
+	}
+
+	halted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "halted",
+		Help:      "1 if the service has halted due to an inconsistency in strict mode",
+	})
+	if err := prometheus.Register(halted); err != nil {
+		return errors.Wrap(err, "failed to register halted")
+	}
+
 	return nil
 }
 
@@ -84,3 +108,16 @@ func monitorBlockProcessed(slot phase0.Slot) {
 		}
 	}
 }
+
+// monitorError counts an error by its type.
+func monitorError(err error) {
+	if errorsTotal != nil && err != nil {
+		errorsTotal.WithLabelValues(util.ClassifyError(err)).Inc()
+	}
+}
+
+func monitorHalted() {
+	if halted != nil {
+		halted.Set(1)
+	}
+}
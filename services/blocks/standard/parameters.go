@@ -15,24 +15,44 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 type parameters struct {
-	logLevel    zerolog.Level
-	monitor     metrics.Service
-	eth2Client  eth2client.Service
-	chainDB     chaindb.Service
-	chainTime   chaintime.Service
-	startSlot   int64
-	refetch     bool
-	activitySem *semaphore.Weighted
+	logLevel           zerolog.Level
+	logLevelSampler    zerolog.Sampler
+	monitor            metrics.Service
+	eth2Client         eth2client.Service
+	chainDB            chaindb.Service
+	chainTime          chaintime.Service
+	startSlot          int64
+	passive            bool
+	backfill           bool
+	headFirstDistance  phase0.Epoch
+	throttle           *util.Throttle
+	refetch            bool
+	attestations       bool
+	proposerSlashings  bool
+	attesterSlashings  bool
+	deposits           bool
+	voluntaryExits     bool
+	syncAggregates     bool
+	activitySem        *semaphore.Weighted
+	blockHandlers      []handlers.BlockHandler
+	eventsStallTimeout time.Duration
+	scheduler          scheduler.Service
+	strict             bool
 }
 
 // Parameter is the interface for service parameters.
@@ -53,6 +73,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -88,6 +116,39 @@ func WithStartSlot(startSlot int64) Parameter {
 	})
 }
 
+// WithPassive sets the service to passive mode, in which it neither catches up with
+// nor follows the chain and only acts on explicit requests such as reindexing.
+func WithPassive(passive bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.passive = passive
+	})
+}
+
+// WithBackfill sets the service to follow the chain from the current slot on start,
+// and to fill in earlier slots in reverse order in the background.
+func WithBackfill(backfill bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backfill = backfill
+	})
+}
+
+// WithHeadFirstDistance sets the number of epochs the module can be behind the
+// chain on start before it follows the chain from the current epoch and backfills
+// earlier data in the background, as if backfill were enabled.  0 disables this.
+func WithHeadFirstDistance(distance phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headFirstDistance = distance
+	})
+}
+
+// WithThrottle sets the throttle that holds back catchup and backfill while
+// the database is under load.
+func WithThrottle(throttle *util.Throttle) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.throttle = throttle
+	})
+}
+
 // WithRefetch sets the refetch flag for this module.
 func WithRefetch(refetch bool) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -95,6 +156,48 @@ func WithRefetch(refetch bool) Parameter {
 	})
 }
 
+// WithAttestations sets whether the attestations contained in blocks are stored.
+func WithAttestations(attestations bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attestations = attestations
+	})
+}
+
+// WithProposerSlashings sets whether the proposer slashings contained in blocks are stored.
+func WithProposerSlashings(proposerSlashings bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.proposerSlashings = proposerSlashings
+	})
+}
+
+// WithAttesterSlashings sets whether the attester slashings contained in blocks are stored.
+func WithAttesterSlashings(attesterSlashings bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attesterSlashings = attesterSlashings
+	})
+}
+
+// WithDeposits sets whether the deposits contained in blocks are stored.
+func WithDeposits(deposits bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.deposits = deposits
+	})
+}
+
+// WithVoluntaryExits sets whether the voluntary exits contained in blocks are stored.
+func WithVoluntaryExits(voluntaryExits bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.voluntaryExits = voluntaryExits
+	})
+}
+
+// WithSyncAggregates sets whether the sync aggregates contained in blocks are stored.
+func WithSyncAggregates(syncAggregates bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.syncAggregates = syncAggregates
+	})
+}
+
 // WithActivitySem sets the activity semaphore for this module.
 func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -102,11 +205,47 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithBlockHandlers sets the block handlers for this module.
+func WithBlockHandlers(handlers []handlers.BlockHandler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.blockHandlers = handlers
+	})
+}
+
+// WithEventsStallTimeout sets the time without events after which the event
+// stream is considered lost and is re-established.  0 disables the check.
+func WithEventsStallTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eventsStallTimeout = timeout
+	})
+}
+
+// WithScheduler sets the scheduler for jobs run by this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithStrict sets whether an inconsistency in the data halts the service,
+// rather than being logged.
+func WithStrict(strict bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.strict = strict
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:  zerolog.GlobalLevel(),
-		startSlot: -1,
+		logLevel:          zerolog.GlobalLevel(),
+		startSlot:         -1,
+		attestations:      true,
+		proposerSlashings: true,
+		attesterSlashings: true,
+		deposits:          true,
+		voluntaryExits:    true,
+		syncAggregates:    true,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -127,5 +266,9 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		return nil, errors.New("no activity semaphore specified")
 	}
 
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+
 	return &parameters, nil
 }
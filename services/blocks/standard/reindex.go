@@ -0,0 +1,128 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// ReindexSlots deletes and re-fetches the blocks for the slots from start to end inclusive.
+// Each slot is deleted and re-fetched within a single transaction, so the database never
+// holds a partially reindexed slot.
+func (s *Service) ReindexSlots(ctx context.Context, start phase0.Slot, end phase0.Slot) error {
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	log.Info().Uint64("start_slot", uint64(start)).Uint64("end_slot", uint64(end)).Msg("Reindexing slots")
+	for slot := start; slot <= end; slot++ {
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.reindexSlot(ctx, slot)
+		}); err != nil {
+			return errors.Wrapf(err, "failed to reindex slot %d", slot)
+		}
+		monitorBlockProcessed(slot)
+	}
+
+	return nil
+}
+
+// reindexSlot deletes and re-fetches the block for a slot.
+// This requires the context to hold an active transaction.
+func (s *Service) reindexSlot(ctx context.Context, slot phase0.Slot) error {
+	// Canonical and correctness flags are set by the finalizer rather than obtained from
+	// the beacon node, so note them before deletion to restore them afterwards.
+	blocks, err := s.blocksProvider.BlocksBySlot(ctx, slot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain existing blocks")
+	}
+	canonicals := make(map[phase0.Root]*bool)
+	attestations := make(map[phase0.Root][]*chaindb.Attestation)
+	for _, block := range blocks {
+		canonicals[block.Root] = block.Canonical
+		attestations[block.Root], err = s.attestationsProvider.AttestationsInBlock(ctx, block.Root)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain existing attestations")
+		}
+	}
+
+	if err := s.rangeDeleter.DeleteBlocks(ctx, slot, slot); err != nil {
+		return errors.Wrap(err, "failed to delete blocks")
+	}
+
+	block, err := s.refetchBlockForSlot(ctx, slot)
+	if err != nil {
+		return errors.Wrap(err, "failed to update block")
+	}
+	if block == nil {
+		return nil
+	}
+
+	return s.restoreFinality(ctx, block, canonicals, attestations[block.Root])
+}
+
+// restoreFinality restores the finality flags of a re-fetched block and its attestations.
+func (s *Service) restoreFinality(ctx context.Context,
+	block *chaindb.Block,
+	canonicals map[phase0.Root]*bool,
+	previousAttestations []*chaindb.Attestation,
+) error {
+	canonical, exists := canonicals[block.Root]
+	if !exists {
+		// Block is new, so has no flags to restore.
+		return nil
+	}
+	if canonical != nil {
+		block.Canonical = canonical
+		if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
+			return errors.Wrap(err, "failed to restore block canonical status")
+		}
+	}
+
+	previous := make(map[uint64]*chaindb.Attestation, len(previousAttestations))
+	for _, attestation := range previousAttestations {
+		previous[attestation.InclusionIndex] = attestation
+	}
+	attestations, err := s.attestationsProvider.AttestationsInBlock(ctx, block.Root)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain attestations")
+	}
+	restored := make([]*chaindb.Attestation, 0, len(attestations))
+	for _, attestation := range attestations {
+		prior, exists := previous[attestation.InclusionIndex]
+		if !exists || prior.Canonical == nil {
+			continue
+		}
+		attestation.Canonical = prior.Canonical
+		attestation.SourceCorrect = prior.SourceCorrect
+		attestation.TargetCorrect = prior.TargetCorrect
+		attestation.HeadCorrect = prior.HeadCorrect
+		attestation.Duplicate = prior.Duplicate
+		attestation.Conflicting = prior.Conflicting
+		restored = append(restored, attestation)
+	}
+	if len(restored) > 0 {
+		if err := s.attestationsSetter.SetAttestations(ctx, restored); err != nil {
+			return errors.Wrap(err, "failed to restore attestation finality")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// seenTimesRetention is the time for which the time a block was first seen is
+// held for the block to be stored.
+const seenTimesRetention = 10 * time.Minute
+
+// recordSeen records the time at which a block was first seen.
+func (s *Service) recordSeen(root phase0.Root, seen time.Time) {
+	s.seenTimesMu.Lock()
+	defer s.seenTimesMu.Unlock()
+
+	if _, exists := s.seenTimes[root]; exists {
+		return
+	}
+	s.seenTimes[root] = seen
+
+	// Drop expired times, including those of blocks that were never stored,
+	// for example because they were already present in the database.
+	for root, seenTime := range s.seenTimes {
+		if seen.Sub(seenTime) > seenTimesRetention {
+			delete(s.seenTimes, root)
+		}
+	}
+}
+
+// seenDelay returns the time from the start of the slot to when the block
+// was first seen, or nil if the block has not been seen.  The time is retained
+// until it expires, so that it is still available if the transaction storing
+// the block is rolled back and retried.
+func (s *Service) seenDelay(slot phase0.Slot, root phase0.Root) *time.Duration {
+	s.seenTimesMu.Lock()
+	defer s.seenTimesMu.Unlock()
+
+	seen, exists := s.seenTimes[root]
+	if !exists {
+		return nil
+	}
+	delay := seen.Sub(s.chainTime.StartOfSlot(slot))
+
+	return &delay
+}
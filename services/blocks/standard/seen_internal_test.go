@@ -0,0 +1,97 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/testing/mock"
+)
+
+func newSeenTestService(t *testing.T, genesis time.Time) *Service {
+	t.Helper()
+
+	chainTime, err := standardchaintime.New(context.Background(),
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(genesis)),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 32, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider([]*phase0.Fork{{}})),
+	)
+	require.NoError(t, err)
+
+	return &Service{
+		chainTime: chainTime,
+		seenTimes: make(map[phase0.Root]time.Time),
+	}
+}
+
+func TestSeenDelay(t *testing.T) {
+	genesis := time.Unix(1600000000, 0)
+	s := newSeenTestService(t, genesis)
+
+	// Slot 10 starts 120s after genesis.
+	s.recordSeen(phase0.Root{0x01}, genesis.Add(121500*time.Millisecond))
+	delay := s.seenDelay(10, phase0.Root{0x01})
+	require.NotNil(t, delay)
+	require.Equal(t, 1500*time.Millisecond, *delay)
+
+	// The delay is still available if the block is stored again, for example
+	// following a rolled back transaction.
+	delay = s.seenDelay(10, phase0.Root{0x01})
+	require.NotNil(t, delay)
+	require.Equal(t, 1500*time.Millisecond, *delay)
+
+	// A later sighting of the same block does not change the delay.
+	s.recordSeen(phase0.Root{0x01}, genesis.Add(130*time.Second))
+	delay = s.seenDelay(10, phase0.Root{0x01})
+	require.NotNil(t, delay)
+	require.Equal(t, 1500*time.Millisecond, *delay)
+
+	// A block seen before the start of its slot has a negative delay.
+	s.recordSeen(phase0.Root{0x02}, genesis.Add(131*time.Second))
+	delay = s.seenDelay(11, phase0.Root{0x02})
+	require.NotNil(t, delay)
+	require.Equal(t, -time.Second, *delay)
+}
+
+func TestSeenDelayMissing(t *testing.T) {
+	s := newSeenTestService(t, time.Unix(1600000000, 0))
+
+	require.Nil(t, s.seenDelay(10, phase0.Root{0x01}))
+}
+
+func TestSeenDelayEviction(t *testing.T) {
+	genesis := time.Unix(1600000000, 0)
+	s := newSeenTestService(t, genesis)
+
+	seen := genesis.Add(time.Hour)
+	s.recordSeen(phase0.Root{0x01}, seen)
+	s.recordSeen(phase0.Root{0x02}, seen.Add(seenTimesRetention))
+	// Both are retained up to the retention period.
+	require.NotNil(t, s.seenDelay(300, phase0.Root{0x01}))
+	require.NotNil(t, s.seenDelay(350, phase0.Root{0x02}))
+
+	// Recording a block after the retention period evicts the earlier block only.
+	s.recordSeen(phase0.Root{0x03}, seen.Add(seenTimesRetention+time.Second))
+	require.Nil(t, s.seenDelay(300, phase0.Root{0x01}))
+	require.NotNil(t, s.seenDelay(350, phase0.Root{0x02}))
+	require.NotNil(t, s.seenDelay(351, phase0.Root{0x03}))
+	require.Len(t, s.seenTimes, 2)
+}
@@ -15,6 +15,8 @@ package standard
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	api "github.com/attestantio/go-eth2-client/api/v1"
@@ -22,34 +24,61 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
-	eth2Client               eth2client.Service
-	chainDB                  chaindb.Service
-	blocksSetter             chaindb.BlocksSetter
-	attestationsSetter       chaindb.AttestationsSetter
-	attesterSlashingsSetter  chaindb.AttesterSlashingsSetter
-	proposerSlashingsSetter  chaindb.ProposerSlashingsSetter
-	syncAggregateSetter      chaindb.SyncAggregateSetter
-	depositsSetter           chaindb.DepositsSetter
-	voluntaryExitsSetter     chaindb.VoluntaryExitsSetter
-	beaconCommitteesProvider chaindb.BeaconCommitteesProvider
-	syncCommitteesProvider   chaindb.SyncCommitteesProvider
-	chainTime                chaintime.Service
-	refetch                  bool
-	lastHandledBlockRoot     phase0.Root
-	activitySem              *semaphore.Weighted
-	syncCommittees           map[uint64]*chaindb.SyncCommittee
+	eth2Client                  eth2client.Service
+	chainDB                     chaindb.Service
+	blocksSetter                chaindb.BlocksSetter
+	attestationsSetter          chaindb.AttestationsSetter
+	attesterSlashingsSetter     chaindb.AttesterSlashingsSetter
+	proposerSlashingsSetter     chaindb.ProposerSlashingsSetter
+	syncAggregateSetter         chaindb.SyncAggregateSetter
+	depositsSetter              chaindb.DepositsSetter
+	voluntaryExitsSetter        chaindb.VoluntaryExitsSetter
+	beaconCommitteesProvider    chaindb.BeaconCommitteesProvider
+	syncCommitteesProvider      chaindb.SyncCommitteesProvider
+	blocksProvider              chaindb.BlocksProvider
+	attestationsProvider        chaindb.AttestationsProvider
+	rangeDeleter                chaindb.RangeDeleter
+	chainTime                   chaintime.Service
+	scheduler                   scheduler.Service
+	refetch                     bool
+	backfill                    bool
+	headFirstDistance           phase0.Epoch
+	throttle                    *util.Throttle
+	attestations                bool
+	proposerSlashings           bool
+	attesterSlashings           bool
+	deposits                    bool
+	voluntaryExits              bool
+	syncAggregates              bool
+	maxSeedLookahead            phase0.Epoch
+	whistleblowerRewardQuotient uint64
+	lastHandledBlockRoot        phase0.Root
+	activitySem                 *semaphore.Weighted
+	syncCommittees              map[uint64]*chaindb.SyncCommittee
+	blockHandlers               []handlers.BlockHandler
+	eventsStallTimeout          time.Duration
+	halt                        *util.Halt
+	seenTimes                   map[phase0.Root]time.Time
+	seenTimesMu                 sync.Mutex
 }
 
 // module-wide log.
 var log zerolog.Logger
 
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/blocks/standard")
+
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -59,6 +88,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "blocks").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -109,22 +141,75 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support sync committee providing")
 	}
 
+	blocksProvider, isBlocksProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isBlocksProvider {
+		return nil, errors.New("chain DB does not support block providing")
+	}
+
+	attestationsProvider, isAttestationsProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isAttestationsProvider {
+		return nil, errors.New("chain DB does not support attestation providing")
+	}
+
+	rangeDeleter, isRangeDeleter := parameters.chainDB.(chaindb.RangeDeleter)
+	if !isRangeDeleter {
+		return nil, errors.New("chain DB does not support range deletion")
+	}
+
+	// The seed lookahead is used to calculate the time an exit spent in the
+	// exit queue, and the whistleblower reward quotient to calculate the
+	// rewards for slashings; fall back to the mainnet values if they are
+	// unavailable.
+	maxSeedLookahead := phase0.Epoch(4)
+	whistleblowerRewardQuotient := uint64(512)
+	if specProvider, isProvider := parameters.eth2Client.(eth2client.SpecProvider); isProvider {
+		spec, err := specProvider.Spec(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain spec")
+		}
+		if tmp, exists := spec["MAX_SEED_LOOKAHEAD"].(uint64); exists {
+			maxSeedLookahead = phase0.Epoch(tmp)
+		}
+		if tmp, exists := spec["WHISTLEBLOWER_REWARD_QUOTIENT"].(uint64); exists && tmp > 0 {
+			whistleblowerRewardQuotient = tmp
+		}
+	}
+
 	s := &Service{
-		eth2Client:               parameters.eth2Client,
-		chainDB:                  parameters.chainDB,
-		blocksSetter:             blocksSetter,
-		attestationsSetter:       attestationsSetter,
-		attesterSlashingsSetter:  attesterSlashingsSetter,
-		proposerSlashingsSetter:  proposerSlashingsSetter,
-		syncAggregateSetter:      syncAggregateSetter,
-		depositsSetter:           depositsSetter,
-		voluntaryExitsSetter:     voluntaryExitsSetter,
-		beaconCommitteesProvider: beaconCommitteesProvider,
-		syncCommitteesProvider:   syncCommitteesProvider,
-		chainTime:                parameters.chainTime,
-		refetch:                  parameters.refetch,
-		activitySem:              parameters.activitySem,
-		syncCommittees:           make(map[uint64]*chaindb.SyncCommittee),
+		eth2Client:                  parameters.eth2Client,
+		chainDB:                     parameters.chainDB,
+		blocksSetter:                blocksSetter,
+		attestationsSetter:          attestationsSetter,
+		attesterSlashingsSetter:     attesterSlashingsSetter,
+		proposerSlashingsSetter:     proposerSlashingsSetter,
+		syncAggregateSetter:         syncAggregateSetter,
+		depositsSetter:              depositsSetter,
+		voluntaryExitsSetter:        voluntaryExitsSetter,
+		beaconCommitteesProvider:    beaconCommitteesProvider,
+		syncCommitteesProvider:      syncCommitteesProvider,
+		blocksProvider:              blocksProvider,
+		attestationsProvider:        attestationsProvider,
+		rangeDeleter:                rangeDeleter,
+		chainTime:                   parameters.chainTime,
+		scheduler:                   parameters.scheduler,
+		refetch:                     parameters.refetch,
+		backfill:                    parameters.backfill,
+		headFirstDistance:           parameters.headFirstDistance,
+		throttle:                    parameters.throttle,
+		attestations:                parameters.attestations,
+		proposerSlashings:           parameters.proposerSlashings,
+		attesterSlashings:           parameters.attesterSlashings,
+		deposits:                    parameters.deposits,
+		voluntaryExits:              parameters.voluntaryExits,
+		syncAggregates:              parameters.syncAggregates,
+		maxSeedLookahead:            maxSeedLookahead,
+		whistleblowerRewardQuotient: whistleblowerRewardQuotient,
+		activitySem:                 parameters.activitySem,
+		eventsStallTimeout:          parameters.eventsStallTimeout,
+		syncCommittees:              make(map[uint64]*chaindb.SyncCommittee),
+		blockHandlers:               parameters.blockHandlers,
+		halt:                        util.NewHalt(parameters.strict),
+		seenTimes:                   make(map[phase0.Root]time.Time),
 	}
 
 	// Note the current highest processed block for the monitor.
@@ -135,7 +220,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	monitorLatestBlock(md.LatestSlot)
 
 	// Update to current epoch before starting (in the background).
-	go s.updateAfterRestart(ctx, parameters.startSlot)
+	if !parameters.passive {
+		go s.updateAfterRestart(ctx, parameters.startSlot)
+	}
 
 	return s, nil
 }
@@ -163,20 +250,32 @@ func (s *Service) updateAfterRestart(ctx context.Context, startSlot int64) {
 		// We have a definite hit on this being the last processed slot; increment it to avoid duplication of work.
 		md.LatestSlot++
 	}
+	if s.followFromHead(md) {
+		// Follow the chain from the current slot, filling in earlier slots in the background.
+		if err := s.startBackfill(ctx, md); err != nil {
+			log.Fatal().Err(err).Msg("Failed to start backfill")
+		}
+	}
 
 	log.Info().Uint64("slot", uint64(md.LatestSlot)).Msg("Catching up from slot")
 	s.catchup(ctx, md)
 	log.Info().Msg("Caught up")
 
-	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
-		if event.Data == nil {
-			// Happens when the channel shuts down, nothing to worry about.
-			return
+	// Set up the handler for new chain head updates and reorgs.
+	if err := util.SubscribeEvents(ctx, log, s.eth2Client.(eth2client.EventsProvider), []string{"head", "chain_reorg"}, s.eventsStallTimeout, func(event *api.Event) {
+		switch eventData := event.Data.(type) {
+		case *api.HeadEvent:
+			s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
+		case *api.ChainReorgEvent:
+			log.Trace().Str("event", eventData.String()).Msg("Received reorg event")
+			if err := s.scheduler.Submit(ctx, "chain reorg", scheduler.PriorityHead, func(ctx context.Context) {
+				s.OnChainReorg(ctx, eventData.Slot, eventData.Depth)
+			}); err != nil {
+				monitorError(err)
+				log.Error().Err(err).Msg("Failed to schedule reorg handling")
+			}
 		}
-		eventData := event.Data.(*api.HeadEvent)
-		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
-	}); err != nil {
+	}, s.onEventsResubscribed); err != nil {
 		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 }
@@ -191,31 +290,35 @@ func (s *Service) catchup(ctx context.Context, md *metadata) {
 	for slot := firstSlot; slot <= s.chainTime.CurrentSlot(); slot++ {
 		log := log.With().Uint64("slot", uint64(slot)).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
-		ctx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
+		dbCtx := util.WithEpochHeadDistance(ctx, s.chainTime, s.chainTime.SlotToEpoch(slot))
+		if err := s.throttle.Wait(dbCtx); err != nil {
+			log.Debug().Err(err).Msg("Catchup interrupted while throttled")
 			return
 		}
-
-		if err := s.updateBlockForSlot(ctx, slot); err != nil {
+		var block *chaindb.Block
+		if err := util.RunTx(dbCtx, s.chainDB, func(ctx context.Context) error {
+			var err error
+			block, err = s.updateBlockForSlot(ctx, slot)
+			if err != nil {
+				return errors.Wrap(err, "failed to update block")
+			}
+			md.LatestSlot = slot
+			if err := s.setMetadata(ctx, md); err != nil {
+				return errors.Wrap(err, "failed to set metadata")
+			}
+			return nil
+		}); err != nil {
+			monitorError(err)
 			log.Warn().Err(err).Msg("Failed to update block")
-			cancel()
-			return
-		}
-
-		md.LatestSlot = slot
-		if err := s.setMetadata(ctx, md); err != nil {
-			log.Error().Err(err).Msg("Failed to set metadata")
-			cancel()
-			return
-		}
-
-		if err := s.chainDB.CommitTx(ctx); err != nil {
-			log.Error().Err(err).Msg("Failed to commit transaction")
-			cancel()
 			return
 		}
 		log.Trace().Msg("Updated block")
 		monitorBlockProcessed(slot)
+
+		if block != nil {
+			for _, blockHandler := range s.blockHandlers {
+				blockHandler.OnBlockIndexed(ctx, block.Slot, block.Root)
+			}
+		}
 	}
 }
@@ -0,0 +1,199 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// blockSlashings holds the economic results of the slashings in a block.
+type blockSlashings struct {
+	proposerIndex phase0.ValidatorIndex
+	// available is false if the states required to calculate the results
+	// could not be obtained.
+	available               bool
+	proposerSlashingRewards []phase0.Gwei
+	attesterSlashingIndices [][]phase0.ValidatorIndex
+	attesterSlashingRewards []phase0.Gwei
+}
+
+// newBlockSlashings calculates the validators slashed by the slashings in a
+// block, and the whistleblower rewards paid to the block's proposer.
+//
+// Validators are checked for slashability against the state of the parent
+// block, and rewards are calculated from their effective balance in the
+// state after the block.  Slashings are processed in the same order as the
+// spec, so a validator slashed by more than one slashing in the same block is
+// only counted against the first.
+func (s *Service) newBlockSlashings(ctx context.Context,
+	block *chaindb.Block,
+	proposerSlashings []*phase0.ProposerSlashing,
+	attesterSlashings []*phase0.AttesterSlashing,
+) *blockSlashings {
+	res := &blockSlashings{
+		proposerIndex: block.ProposerIndex,
+	}
+	if !s.proposerSlashings && !s.attesterSlashings {
+		return res
+	}
+	if len(proposerSlashings) == 0 && len(attesterSlashings) == 0 {
+		return res
+	}
+
+	attesterSlashingCandidates := make([][]phase0.ValidatorIndex, len(attesterSlashings))
+	candidates := make([]phase0.ValidatorIndex, 0)
+	for _, proposerSlashing := range proposerSlashings {
+		candidates = append(candidates, proposerSlashing.SignedHeader1.Message.ProposerIndex)
+	}
+	for i, attesterSlashing := range attesterSlashings {
+		attesterSlashingCandidates[i] = intersectIndices(attesterSlashing.Attestation1.AttestingIndices, attesterSlashing.Attestation2.AttestingIndices)
+		candidates = append(candidates, attesterSlashingCandidates[i]...)
+	}
+
+	preValidators, postValidators, err := s.slashingValidators(ctx, block, candidates)
+	if err != nil {
+		log.Debug().Err(err).Uint64("slot", uint64(block.Slot)).Msg("Failed to obtain slashed validators; not storing slashing rewards")
+		return res
+	}
+
+	epoch := s.chainTime.SlotToEpoch(block.Slot)
+	slashed := make(map[phase0.ValidatorIndex]bool)
+	res.proposerSlashingRewards = make([]phase0.Gwei, len(proposerSlashings))
+	for i, proposerSlashing := range proposerSlashings {
+		index := proposerSlashing.SignedHeader1.Message.ProposerIndex
+		postValidator, exists := postValidators[index]
+		if !exists || postValidator.Validator == nil {
+			log.Debug().Uint64("slot", uint64(block.Slot)).Uint64("validator_index", uint64(index)).Msg("Slashed validator not found; not storing slashing rewards")
+			return res
+		}
+		res.proposerSlashingRewards[i] = postValidator.Validator.EffectiveBalance / phase0.Gwei(s.whistleblowerRewardQuotient)
+		slashed[index] = true
+	}
+
+	res.attesterSlashingIndices = make([][]phase0.ValidatorIndex, len(attesterSlashings))
+	res.attesterSlashingRewards = make([]phase0.Gwei, len(attesterSlashings))
+	for i := range attesterSlashings {
+		res.attesterSlashingIndices[i] = make([]phase0.ValidatorIndex, 0)
+		for _, index := range attesterSlashingCandidates[i] {
+			preValidator, preExists := preValidators[index]
+			postValidator, postExists := postValidators[index]
+			if !preExists || !postExists || preValidator.Validator == nil || postValidator.Validator == nil {
+				log.Debug().Uint64("slot", uint64(block.Slot)).Uint64("validator_index", uint64(index)).Msg("Slashed validator not found; not storing slashing rewards")
+				return res
+			}
+			if slashed[index] || !slashable(preValidator.Validator, epoch) {
+				continue
+			}
+			res.attesterSlashingIndices[i] = append(res.attesterSlashingIndices[i], index)
+			res.attesterSlashingRewards[i] += postValidator.Validator.EffectiveBalance / phase0.Gwei(s.whistleblowerRewardQuotient)
+			slashed[index] = true
+		}
+	}
+	res.available = true
+
+	return res
+}
+
+// slashingValidators fetches the given validators from the states before and
+// after the block.
+func (s *Service) slashingValidators(ctx context.Context,
+	block *chaindb.Block,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	validatorsProvider, isProvider := s.eth2Client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, nil, errors.New("client does not provide validators")
+	}
+
+	parent, err := s.blocksProvider.BlockByRoot(ctx, block.ParentRoot)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to obtain parent block")
+	}
+	if parent == nil {
+		return nil, nil, errors.Errorf("parent block %#x not found", block.ParentRoot)
+	}
+
+	preValidators, err := validatorsProvider.Validators(ctx, fmt.Sprintf("%#x", parent.StateRoot), indices)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to obtain validators before block")
+	}
+	postValidators, err := validatorsProvider.Validators(ctx, fmt.Sprintf("%#x", block.StateRoot), indices)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to obtain validators after block")
+	}
+
+	return preValidators, postValidators, nil
+}
+
+// proposerSlashing adds the economic results to a proposer slashing.
+func (b *blockSlashings) proposerSlashing(index int, proposerSlashing *chaindb.ProposerSlashing) {
+	proposerIndex := b.proposerIndex
+	proposerSlashing.InclusionProposerIndex = &proposerIndex
+	if !b.available {
+		return
+	}
+	reward := b.proposerSlashingRewards[index]
+	proposerSlashing.WhistleblowerReward = &reward
+}
+
+// attesterSlashing adds the economic results to an attester slashing.
+func (b *blockSlashings) attesterSlashing(index int, attesterSlashing *chaindb.AttesterSlashing) {
+	proposerIndex := b.proposerIndex
+	attesterSlashing.InclusionProposerIndex = &proposerIndex
+	if !b.available {
+		return
+	}
+	attesterSlashing.SlashedIndices = b.attesterSlashingIndices[index]
+	reward := b.attesterSlashingRewards[index]
+	attesterSlashing.WhistleblowerReward = &reward
+}
+
+// slashable returns true if the validator can be slashed at the given epoch.
+func slashable(validator *phase0.Validator, epoch phase0.Epoch) bool {
+	return !validator.Slashed &&
+		validator.ActivationEpoch <= epoch &&
+		epoch < validator.WithdrawableEpoch
+}
+
+// intersectIndices returns the sorted indices present in both lists.
+func intersectIndices(indices1 []uint64, indices2 []uint64) []phase0.ValidatorIndex {
+	present := make(map[uint64]bool, len(indices1))
+	for _, index := range indices1 {
+		present[index] = true
+	}
+	res := make([]phase0.ValidatorIndex, 0)
+	for _, index := range indices2 {
+		if present[index] {
+			res = append(res, phase0.ValidatorIndex(index))
+			delete(present, index)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+
+	return res
+}
@@ -0,0 +1,38 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaindb
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// headDistance is a context tag for the number of epochs that data written is behind the head of the chain.
+type headDistance struct{}
+
+// WithHeadDistance returns a context recording that data written in transactions
+// begun with it is the given number of epochs behind the head of the chain.  A
+// database can use this to trade durability for speed when catching up, as data
+// far behind the head of the chain that is lost in a crash is simply fetched again.
+func WithHeadDistance(ctx context.Context, distance phase0.Epoch) context.Context {
+	return context.WithValue(ctx, &headDistance{}, distance)
+}
+
+// HeadDistance returns the number of epochs that data written is behind the head
+// of the chain, and true, if it has been recorded in the context.
+func HeadDistance(ctx context.Context) (phase0.Epoch, bool) {
+	distance, ok := ctx.Value(&headDistance{}).(phase0.Epoch)
+	return distance, ok
+}
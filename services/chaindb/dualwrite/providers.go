@@ -0,0 +1,469 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dualwrite
+
+import (
+	"context"
+	"time"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// AddressLabels fetches the labels for the given addresses.
+// Addresses without a label are not present in the result.
+func (s *Service) AddressLabels(ctx context.Context, addresses [][20]byte) (map[[20]byte]string, error) {
+	return s.primary.AddressLabels(ctx, addresses)
+}
+
+// UnlabeledAddresses fetches the fee recipient and withdrawal addresses that do not have a label.
+func (s *Service) UnlabeledAddresses(ctx context.Context) ([][20]byte, error) {
+	return s.primary.UnlabeledAddresses(ctx)
+}
+
+// ValidatorLabels fetches the labels for all labeled validators.
+func (s *Service) ValidatorLabels(ctx context.Context) (map[phase0.BLSPubKey]string, error) {
+	return s.primary.ValidatorLabels(ctx)
+}
+
+// AttestationsForBlock fetches all attestations made for the given block.
+func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
+	return s.primary.AttestationsForBlock(ctx, blockRoot)
+}
+
+// AttestationsInBlock fetches all attestations contained in the given block.
+func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root) ([]*chaindb.Attestation, error) {
+	return s.primary.AttestationsInBlock(ctx, blockRoot)
+}
+
+// Attestations provides attestations according to the filter.
+func (s *Service) Attestations(ctx context.Context, filter *chaindb.AttestationFilter) ([]*chaindb.Attestation, error) {
+	return s.primary.Attestations(ctx, filter)
+}
+
+// AttestationsForSlotRange fetches all attestations made for the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// attestations for slots 2 and 3.
+func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
+	return s.primary.AttestationsForSlotRange(ctx, startSlot, endSlot)
+}
+
+// AttestationsInSlotRange fetches all attestations made in the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// attestations in slots 2 and 3.
+func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Attestation, error) {
+	return s.primary.AttestationsInSlotRange(ctx, startSlot, endSlot)
+}
+
+// IndeterminateAttestationSlots fetches the slots in the given range with attestations that do not have a canonical status.
+func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	return s.primary.IndeterminateAttestationSlots(ctx, minSlot, maxSlot)
+}
+
+// AttesterSlashingsForSlotRange fetches all attester slashings made for the given slot range.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.AttesterSlashing, error) {
+	return s.primary.AttesterSlashingsForSlotRange(ctx, minSlot, maxSlot)
+}
+
+// AttesterSlashingsForValidator fetches all attester slashings made for the given validator.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.AttesterSlashing, error) {
+	return s.primary.AttesterSlashingsForValidator(ctx, index)
+}
+
+// BeaconCommitteeBySlotAndIndex fetches the beacon committee with the given slot and index.
+func (s *Service) BeaconCommitteeBySlotAndIndex(ctx context.Context, slot phase0.Slot, index phase0.CommitteeIndex) (*chaindb.BeaconCommittee, error) {
+	return s.primary.BeaconCommitteeBySlotAndIndex(ctx, slot, index)
+}
+
+// AttesterDuties fetches the attester duties at the given slot range for the given validator indices.
+func (s *Service) AttesterDuties(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot, validatorIndices []phase0.ValidatorIndex) ([]*chaindb.AttesterDuty, error) {
+	return s.primary.AttesterDuties(ctx, startSlot, endSlot, validatorIndices)
+}
+
+// BlocksBySlot fetches all blocks with the given slot.
+func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
+	return s.primary.BlocksBySlot(ctx, slot)
+}
+
+// BlocksForSlotRange fetches all blocks with the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// blocks duties for slots 2 and 3.
+func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Block, error) {
+	return s.primary.BlocksForSlotRange(ctx, startSlot, endSlot)
+}
+
+// BlockByRoot fetches the block with the given root.
+func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.Block, error) {
+	return s.primary.BlockByRoot(ctx, root)
+}
+
+// BlocksByParentRoot fetches the blocks with the given parent root.
+func (s *Service) BlocksByParentRoot(ctx context.Context, root phase0.Root) ([]*chaindb.Block, error) {
+	return s.primary.BlocksByParentRoot(ctx, root)
+}
+
+// Blocks provides blocks according to the filter.
+func (s *Service) Blocks(ctx context.Context, filter *chaindb.BlockFilter) ([]*chaindb.Block, error) {
+	return s.primary.Blocks(ctx, filter)
+}
+
+// EmptySlots fetches the slots in the given range without a block in the database.
+func (s *Service) EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	return s.primary.EmptySlots(ctx, minSlot, maxSlot)
+}
+
+// LatestBlocks fetches the blocks with the highest slot number in the database.
+func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
+	return s.primary.LatestBlocks(ctx)
+}
+
+// IndeterminateBlocks fetches the blocks in the given range that do not have a canonical status.
+func (s *Service) IndeterminateBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Root, error) {
+	return s.primary.IndeterminateBlocks(ctx, minSlot, maxSlot)
+}
+
+// CanonicalBlockPresenceForSlotRange returns a boolean for each slot in the range for the presence
+// of a canonical block.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// presence duties for slots 2 and 3.
+func (s *Service) CanonicalBlockPresenceForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]bool, error) {
+	return s.primary.CanonicalBlockPresenceForSlotRange(ctx, minSlot, maxSlot)
+}
+
+// LatestCanonicalBlock returns the slot of the latest canonical block known in the database.
+func (s *Service) LatestCanonicalBlock(ctx context.Context) (phase0.Slot, error) {
+	return s.primary.LatestCanonicalBlock(ctx)
+}
+
+// ChainSpec fetches all chain specification values.
+func (s *Service) ChainSpec(ctx context.Context) (map[string]interface{}, error) {
+	return s.primary.ChainSpec(ctx)
+}
+
+// ChainSpecValue fetches a chain specification value given its key.
+func (s *Service) ChainSpecValue(ctx context.Context, key string) (interface{}, error) {
+	return s.primary.ChainSpecValue(ctx, key)
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (s *Service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	return s.primary.ForkSchedule(ctx)
+}
+
+// Genesis fetches genesis values.
+func (s *Service) Genesis(ctx context.Context) (*api.Genesis, error) {
+	return s.primary.Genesis(ctx)
+}
+
+// ETH1DepositsByPublicKey fetches Ethereum 1 deposits for a given set of validator public keys.
+func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*chaindb.ETH1Deposit, error) {
+	return s.primary.ETH1DepositsByPublicKey(ctx, pubKeys)
+}
+
+// ETH1DepositsByIndexRange fetches Ethereum 1 deposits for the given range of deposit indices.
+func (s *Service) ETH1DepositsByIndexRange(ctx context.Context, startIndex uint64, endIndex uint64) ([]*chaindb.ETH1Deposit, error) {
+	return s.primary.ETH1DepositsByIndexRange(ctx, startIndex, endIndex)
+}
+
+// LatestETH1DepositInclusion fetches the inclusion of the Ethereum 1 deposit with the highest index
+// that has been included in the beacon chain, or nil if there is none.
+func (s *Service) LatestETH1DepositInclusion(ctx context.Context) (*chaindb.ETH1DepositInclusion, error) {
+	return s.primary.LatestETH1DepositInclusion(ctx)
+}
+
+// ETH1DepositInclusionsByIndexRange fetches the inclusions for the given range of deposit indices.
+func (s *Service) ETH1DepositInclusionsByIndexRange(ctx context.Context, startIndex uint64, endIndex uint64) ([]*chaindb.ETH1DepositInclusion, error) {
+	return s.primary.ETH1DepositInclusionsByIndexRange(ctx, startIndex, endIndex)
+}
+
+// ProposerDutiesForSlotRange fetches all proposer duties for the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// proposer duties for slots 2 and 3.
+func (s *Service) ProposerDutiesForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.ProposerDuty, error) {
+	return s.primary.ProposerDutiesForSlotRange(ctx, startSlot, endSlot)
+}
+
+// ProposerDutiesForValidator provides all proposer duties for the given validator index.
+func (s *Service) ProposerDutiesForValidator(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.ProposerDuty, error) {
+	return s.primary.ProposerDutiesForValidator(ctx, proposer)
+}
+
+// MissedSlotsForSlotRange fetches the missed slots in the given slot range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+// missed slots for slots 2 and 3.
+func (s *Service) MissedSlotsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.MissedSlot, error) {
+	return s.primary.MissedSlotsForSlotRange(ctx, startSlot, endSlot)
+}
+
+// MissedSlotsForProposer fetches the missed slots assigned to the given proposer.
+func (s *Service) MissedSlotsForProposer(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.MissedSlot, error) {
+	return s.primary.MissedSlotsForProposer(ctx, proposer)
+}
+
+// ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.ProposerSlashing, error) {
+	return s.primary.ProposerSlashingsForSlotRange(ctx, minSlot, maxSlot)
+}
+
+// ProposerSlashingsForValidator fetches all proposer slashings made for the given validator.
+// It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase0.ValidatorIndex) ([]*chaindb.ProposerSlashing, error) {
+	return s.primary.ProposerSlashingsForValidator(ctx, index)
+}
+
+// SyncAggregateForBlock provides the sync aggregate for the supplied block root.
+func (s *Service) SyncAggregateForBlock(ctx context.Context, blockRoot phase0.Root) (*chaindb.SyncAggregate, error) {
+	return s.primary.SyncAggregateForBlock(ctx, blockRoot)
+}
+
+// Validators fetches all validators.
+func (s *Service) Validators(ctx context.Context) ([]*chaindb.Validator, error) {
+	return s.primary.Validators(ctx)
+}
+
+// ValidatorsByPublicKey fetches all validators matching the given public keys.
+// This is a common starting point for external entities to query specific validators, as they should
+// always have the public key at a minimum, hence the return map keyed by public key.
+func (s *Service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
+	return s.primary.ValidatorsByPublicKey(ctx, pubKeys)
+}
+
+// ValidatorsByIndex fetches all validators matching the given indices.
+func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	return s.primary.ValidatorsByIndex(ctx, indices)
+}
+
+// ValidatorsWithFilter provides validators according to the filter.
+func (s *Service) ValidatorsWithFilter(ctx context.Context, filter *chaindb.ValidatorFilter) ([]*chaindb.Validator, error) {
+	return s.primary.ValidatorsWithFilter(ctx, filter)
+}
+
+// ValidatorBalancesByIndexAndEpoch fetches the validator balances for the given validators and epoch.
+func (s *Service) ValidatorBalancesByIndexAndEpoch(ctx context.Context, indices []phase0.ValidatorIndex, epoch phase0.Epoch) (map[phase0.ValidatorIndex]*chaindb.ValidatorBalance, error) {
+	return s.primary.ValidatorBalancesByIndexAndEpoch(ctx, indices, epoch)
+}
+
+// ValidatorBalancesByIndexAndEpochRange fetches the validator balances for the given validators and epoch range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// balances for epochs 2 and 3.
+func (s *Service) ValidatorBalancesByIndexAndEpochRange(ctx context.Context, indices []phase0.ValidatorIndex, startEpoch phase0.Epoch, endEpoch phase0.Epoch) (map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance, error) {
+	return s.primary.ValidatorBalancesByIndexAndEpochRange(ctx, indices, startEpoch, endEpoch)
+}
+
+// ValidatorBalancesByIndexAndEpochs fetches the validator balances for the given validators at the specified epochs.
+func (s *Service) ValidatorBalancesByIndexAndEpochs(ctx context.Context, indices []phase0.ValidatorIndex, epochs []phase0.Epoch) (map[phase0.ValidatorIndex][]*chaindb.ValidatorBalance, error) {
+	return s.primary.ValidatorBalancesByIndexAndEpochs(ctx, indices, epochs)
+}
+
+// AggregateValidatorBalancesByIndexAndEpoch fetches the aggregate validator balances for the given validators and epoch.
+func (s *Service) AggregateValidatorBalancesByIndexAndEpoch(ctx context.Context, indices []phase0.ValidatorIndex, epoch phase0.Epoch) (*chaindb.AggregateValidatorBalance, error) {
+	return s.primary.AggregateValidatorBalancesByIndexAndEpoch(ctx, indices, epoch)
+}
+
+// AggregateValidatorBalancesByIndexAndEpochRange fetches the aggregate validator balances for the given validators and
+// epoch range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// balances for epochs 2 and 3.
+func (s *Service) AggregateValidatorBalancesByIndexAndEpochRange(ctx context.Context, indices []phase0.ValidatorIndex, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.AggregateValidatorBalance, error) {
+	return s.primary.AggregateValidatorBalancesByIndexAndEpochRange(ctx, indices, startEpoch, endEpoch)
+}
+
+// AggregateValidatorBalancesByIndexAndEpochs fetches the validator balances for the given validators at the specified epochs.
+func (s *Service) AggregateValidatorBalancesByIndexAndEpochs(ctx context.Context, indices []phase0.ValidatorIndex, epochs []phase0.Epoch) ([]*chaindb.AggregateValidatorBalance, error) {
+	return s.primary.AggregateValidatorBalancesByIndexAndEpochs(ctx, indices, epochs)
+}
+
+// EpochParticipationForEpochRange fetches the participation for each epoch in the given range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// participation for epochs 2 and 3.
+func (s *Service) EpochParticipationForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.EpochParticipation, error) {
+	return s.primary.EpochParticipationForEpochRange(ctx, startEpoch, endEpoch)
+}
+
+// ValidatorAttestationCountsForRange fetches the attestation counts for each validator in the given index range
+// over the given epoch range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) ValidatorAttestationCountsForRange(ctx context.Context,
+	startIndex phase0.ValidatorIndex,
+	endIndex phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorAttestationCounts,
+	error,
+) {
+	return s.primary.ValidatorAttestationCountsForRange(ctx, startIndex, endIndex, startEpoch, endEpoch)
+}
+
+// ValidatorPerformanceForEpochRange fetches the performance of the given validators over the given epoch range,
+// combining their epoch summaries, balances and deposits.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) ValidatorPerformanceForEpochRange(ctx context.Context,
+	indices []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorPerformance,
+	error,
+) {
+	return s.primary.ValidatorPerformanceForEpochRange(ctx, indices, startEpoch, endEpoch)
+}
+
+// ActiveValidatorCount fetches the number of validators active at the given epoch.
+func (s *Service) ActiveValidatorCount(ctx context.Context, epoch phase0.Epoch) (uint64, error) {
+	return s.primary.ActiveValidatorCount(ctx, epoch)
+}
+
+// TotalValidatorBalancesForEpochRange fetches the total balances of all validators for each epoch in the given range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// balances for epochs 2 and 3.
+func (s *Service) TotalValidatorBalancesForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.AggregateValidatorBalance, error) {
+	return s.primary.TotalValidatorBalancesForEpochRange(ctx, startEpoch, endEpoch)
+}
+
+// DepositsByPublicKey fetches deposits for a given set of validator public keys.
+func (s *Service) DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
+	return s.primary.DepositsByPublicKey(ctx, pubKeys)
+}
+
+// DepositsForSlotRange fetches all deposits made in the given slot range.
+// It will return deposits from blocks that are canonical or undefined, but not from non-canonical blocks.
+func (s *Service) DepositsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Deposit, error) {
+	return s.primary.DepositsForSlotRange(ctx, minSlot, maxSlot)
+}
+
+// BlockSummaryForSlot obtains the summary of a block for a given slot.
+func (s *Service) BlockSummaryForSlot(ctx context.Context, slot phase0.Slot) (*chaindb.BlockSummary, error) {
+	return s.primary.BlockSummaryForSlot(ctx, slot)
+}
+
+// ValidatorSummaries provides summaries according to the filter.
+func (s *Service) ValidatorSummaries(ctx context.Context, filter *chaindb.ValidatorSummaryFilter) ([]*chaindb.ValidatorEpochSummary, error) {
+	return s.primary.ValidatorSummaries(ctx, filter)
+}
+
+// ValidatorSummariesForEpoch obtains all summaries for a given epoch.
+func (s *Service) ValidatorSummariesForEpoch(ctx context.Context, epoch phase0.Epoch) ([]*chaindb.ValidatorEpochSummary, error) {
+	return s.primary.ValidatorSummariesForEpoch(ctx, epoch)
+}
+
+// ValidatorSummaryForEpoch obtains the summary of a validator for a given epoch.
+func (s *Service) ValidatorSummaryForEpoch(ctx context.Context, index phase0.ValidatorIndex, epoch phase0.Epoch) (*chaindb.ValidatorEpochSummary, error) {
+	return s.primary.ValidatorSummaryForEpoch(ctx, index, epoch)
+}
+
+// SyncCommittee provides a sync committee for the given sync committee period.
+func (s *Service) SyncCommittee(ctx context.Context, period uint64) (*chaindb.SyncCommittee, error) {
+	return s.primary.SyncCommittee(ctx, period)
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (s *Service) GenesisTime(ctx context.Context) (time.Time, error) {
+	return s.primary.GenesisTime(ctx)
+}
+
+// Spec provides the spec information of the chain.
+func (s *Service) Spec(ctx context.Context) (map[string]interface{}, error) {
+	return s.primary.Spec(ctx)
+}
+
+// SlotsWithoutBeaconCommittees fetches the slots in the given range without beacon committees
+// in the database, ignoring slots before the first or after the last with beacon committees.
+func (s *Service) SlotsWithoutBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	return s.primary.SlotsWithoutBeaconCommittees(ctx, minSlot, maxSlot)
+}
+
+// SlotsWithoutProposerDuties fetches the slots in the given range without a proposer duty
+// in the database, ignoring slots before the first or after the last with a proposer duty.
+func (s *Service) SlotsWithoutProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	return s.primary.SlotsWithoutProposerDuties(ctx, minSlot, maxSlot)
+}
+
+// EpochsWithoutValidatorBalances fetches the epochs in the given range without validator balances
+// in the database, ignoring epochs before the first or after the last with validator balances.
+func (s *Service) EpochsWithoutValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	return s.primary.EpochsWithoutValidatorBalances(ctx, minEpoch, maxEpoch)
+}
+
+// CompletedEpochs fetches the epochs in the given range for which the named service has
+// recorded completion.
+func (s *Service) CompletedEpochs(ctx context.Context, service string, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	return s.primary.CompletedEpochs(ctx, service, startEpoch, endEpoch)
+}
+
+// EpochProvenance fetches the provenance of the named dataset for the given epoch,
+// or nil if it is not known.
+func (s *Service) EpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch) (*chaindb.Provenance, error) {
+	return s.primary.EpochProvenance(ctx, dataset, epoch)
+}
+
+// EpochChecksumDatasets provides the names of the datasets for which checksums can be computed.
+func (s *Service) EpochChecksumDatasets() []string {
+	return s.primary.EpochChecksumDatasets()
+}
+
+// ComputeEpochChecksum computes the checksum of the named dataset for the given epoch from
+// the indexed data.
+func (s *Service) ComputeEpochChecksum(ctx context.Context, dataset string, epoch phase0.Epoch) (*chaindb.EpochChecksum, error) {
+	return s.primary.ComputeEpochChecksum(ctx, dataset, epoch)
+}
+
+// EpochChecksums fetches the stored checksums of all datasets for the given epoch range.
+func (s *Service) EpochChecksums(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.EpochChecksum, error) {
+	return s.primary.EpochChecksums(ctx, startEpoch, endEpoch)
+}
+
+// TableStats fetches the statistics for each of the database's tables.
+func (s *Service) TableStats(ctx context.Context) ([]*chaindb.TableStats, error) {
+	return s.primary.TableStats(ctx)
+}
+
+// DatabaseLoad fetches the current load on the database.
+func (s *Service) DatabaseLoad(ctx context.Context) (*chaindb.DatabaseLoad, error) {
+	return s.primary.DatabaseLoad(ctx)
+}
+
+// StateRootBySlot fetches the state root for the given slot.
+func (s *Service) StateRootBySlot(ctx context.Context, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	return s.primary.StateRootBySlot(ctx, slot)
+}
+
+// LatestArchivedStateRoot fetches the latest state root at or before the
+// given slot for which an archive of the full state is available.
+func (s *Service) LatestArchivedStateRoot(ctx context.Context, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	return s.primary.LatestArchivedStateRoot(ctx, slot)
+}
+
+// LatestValidatorActivation fetches the validator activation with the highest
+// activation epoch, or nil if there is none.
+func (s *Service) LatestValidatorActivation(ctx context.Context) (*chaindb.ValidatorActivation, error) {
+	return s.primary.LatestValidatorActivation(ctx)
+}
+
+// ValidatorActivationLatencies fetches the given percentile of the latencies
+// of validators activated in the given epoch range.
+func (s *Service) ValidatorActivationLatencies(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	percentile float64,
+) (
+	*chaindb.ValidatorActivationLatencies,
+	error,
+) {
+	return s.primary.ValidatorActivationLatencies(ctx, startEpoch, endEpoch, percentile)
+}
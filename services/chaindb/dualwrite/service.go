@@ -0,0 +1,197 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dualwrite provides a chain database that writes to two backends,
+// allowing data to be migrated from one backend to another without downtime.
+package dualwrite
+
+import (
+	"bytes"
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// backend is the full set of functions a chain database must provide to be
+// used as either side of a dual-write service.
+type backend interface {
+	chaindb.Service
+	chaindb.AddressLabelsProvider
+	chaindb.AddressLabelsSetter
+	chaindb.AttestationsProvider
+	chaindb.AttestationsSetter
+	chaindb.AttesterSlashingsProvider
+	chaindb.AttesterSlashingsSetter
+	chaindb.BeaconCommitteesProvider
+	chaindb.BeaconCommitteesSetter
+	chaindb.BlocksProvider
+	chaindb.BlocksSetter
+	chaindb.ChainSpecProvider
+	chaindb.ChainSpecSetter
+	chaindb.ForkScheduleProvider
+	chaindb.ForkScheduleSetter
+	chaindb.GapsProvider
+	chaindb.GenesisProvider
+	chaindb.GenesisSetter
+	chaindb.ETH1DepositsProvider
+	chaindb.ETH1DepositsSetter
+	chaindb.ETH1DepositInclusionsProvider
+	chaindb.ETH1DepositInclusionsSetter
+	chaindb.ProposerDutiesProvider
+	chaindb.ProposerDutiesSetter
+	chaindb.MissedSlotsProvider
+	chaindb.MissedSlotsSetter
+	chaindb.ProposerSlashingsProvider
+	chaindb.ProposerSlashingsSetter
+	chaindb.EpochCompletionsProvider
+	chaindb.EpochCompletionsSetter
+	chaindb.EpochProvenanceProvider
+	chaindb.EpochProvenanceSetter
+	chaindb.ExecutionTransactionsSetter
+	chaindb.ExecutionBlockSummariesSetter
+	chaindb.RangeDeleter
+	chaindb.SyncAggregateProvider
+	chaindb.SyncAggregateSetter
+	chaindb.ValidatorsProvider
+	chaindb.AggregateValidatorBalancesProvider
+	chaindb.AggregatesProvider
+	chaindb.TableStatsProvider
+	chaindb.StateRootsProvider
+	chaindb.StateRootsSetter
+	chaindb.ValidatorActivationsProvider
+	chaindb.ValidatorActivationsSetter
+	chaindb.DatabaseLoadProvider
+	chaindb.EpochChecksumsProvider
+	chaindb.EpochChecksumsSetter
+	chaindb.ValidatorsSetter
+	chaindb.DepositsProvider
+	chaindb.DepositsSetter
+	chaindb.VoluntaryExitsSetter
+	chaindb.ValidatorEpochSummariesSetter
+	chaindb.ValidatorLabelsProvider
+	chaindb.ValidatorLabelsSetter
+	chaindb.ValidatorLabelEpochSummariesSetter
+	chaindb.BlockSummariesProvider
+	chaindb.ValidatorEpochSummariesProvider
+	chaindb.BlockSummariesSetter
+	chaindb.EpochSummariesSetter
+	chaindb.SyncCommitteesProvider
+	chaindb.SyncCommitteesSetter
+	chaindb.MaterializedViewsSetter
+	eth2client.GenesisTimeProvider
+	eth2client.SpecProvider
+}
+
+// Service is a chain database service that writes to two backends.
+// All reads are served by the primary backend.
+type Service struct {
+	primary   backend
+	secondary backend
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "chaindb").Str("impl", "dualwrite").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	primary, isBackend := parameters.primary.(backend)
+	if !isBackend {
+		return nil, errors.New("primary chain database does not provide all required functions")
+	}
+	secondary, isBackend := parameters.secondary.(backend)
+	if !isBackend {
+		return nil, errors.New("secondary chain database does not provide all required functions")
+	}
+
+	s := &Service{
+		primary:   primary,
+		secondary: secondary,
+	}
+
+	if err := s.checkSecondary(ctx); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// checkSecondary checks that the secondary backend holds the same data as the
+// primary.  Reads only go to the primary, so data that is not in the secondary
+// when dual-writing starts is never written to it; as such the secondary must
+// start as a copy of the primary.  The progress metadata of each service is
+// compared to confirm this.
+func (s *Service) checkSecondary(ctx context.Context) error {
+	checked := make(map[string]bool)
+	for _, source := range util.ProgressSources {
+		if checked[source.Key] {
+			continue
+		}
+		checked[source.Key] = true
+
+		primaryMD, err := s.primary.Metadata(ctx, source.Key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to obtain primary metadata for %s", source.Key)
+		}
+		secondaryMD, err := s.secondary.Metadata(ctx, source.Key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to obtain secondary metadata for %s", source.Key)
+		}
+		if !bytes.Equal(primaryMD, secondaryMD) {
+			return errors.Errorf("secondary chain database does not match primary for %s; it must start as a copy of the primary", source.Key)
+		}
+	}
+
+	return nil
+}
+
+// AwaitLeadership blocks until this instance is the leader of the primary backend.
+func (s *Service) AwaitLeadership(ctx context.Context) (<-chan struct{}, error) {
+	elector, isElector := s.primary.(chaindb.LeaderElector)
+	if !isElector {
+		return nil, errors.New("primary chain database does not support leader election")
+	}
+
+	return elector.AwaitLeadership(ctx)
+}
+
+// Close closes both backends, secondary first.
+func (s *Service) Close(ctx context.Context) error {
+	if closer, isCloser := s.secondary.(chaindb.Closer); isCloser {
+		if err := closer.Close(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to close secondary chain database cleanly")
+		}
+	}
+	if closer, isCloser := s.primary.(chaindb.Closer); isCloser {
+		if err := closer.Close(ctx); err != nil {
+			return errors.Wrap(err, "failed to close primary chain database")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dualwrite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// txKey is the context key for a test backend's transaction.
+type txKey struct {
+	name string
+}
+
+// testBackend is a backend that records the calls made to it.  Calls that are
+// not overridden panic, as the embedded backend is nil.
+type testBackend struct {
+	backend
+	name       string
+	events     *[]string
+	metadata   map[string][]byte
+	failWrite  bool
+	failCommit bool
+}
+
+func (b *testBackend) record(event string) {
+	*b.events = append(*b.events, fmt.Sprintf("%s %s", b.name, event))
+}
+
+func (b *testBackend) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	b.record("begin")
+	return context.WithValue(ctx, txKey{name: b.name}, true), func() { b.record("rollback") }, nil
+}
+
+func (b *testBackend) CommitTx(ctx context.Context) error {
+	if ctx.Value(txKey{name: b.name}) == nil {
+		return errors.New("commit outside of transaction")
+	}
+	if b.failCommit {
+		return errors.New("commit failed")
+	}
+	b.record("commit")
+	return nil
+}
+
+func (b *testBackend) SetMetadata(ctx context.Context, key string, _ []byte) error {
+	if ctx.Value(txKey{name: b.name}) == nil {
+		return errors.New("write outside of transaction")
+	}
+	if b.failWrite {
+		return errors.New("write failed")
+	}
+	b.record(fmt.Sprintf("set %s", key))
+	return nil
+}
+
+func (b *testBackend) SetAddressLabels(ctx context.Context, labels []*chaindb.AddressLabel) error {
+	if ctx.Value(txKey{name: b.name}) == nil {
+		return errors.New("write outside of transaction")
+	}
+	b.record(fmt.Sprintf("set %d address labels", len(labels)))
+	return nil
+}
+
+func (b *testBackend) SetValidatorLabels(ctx context.Context, labels []*chaindb.ValidatorLabel) error {
+	if ctx.Value(txKey{name: b.name}) == nil {
+		return errors.New("write outside of transaction")
+	}
+	b.record(fmt.Sprintf("set %d validator labels", len(labels)))
+	return nil
+}
+
+func (b *testBackend) Metadata(_ context.Context, key string) ([]byte, error) {
+	return b.metadata[key], nil
+}
+
+func newTestService(t *testing.T, primary *testBackend, secondary *testBackend) *Service {
+	t.Helper()
+	s, err := New(context.Background(),
+		WithLogLevel(zerolog.Disabled),
+		WithPrimary(primary),
+		WithSecondary(secondary),
+	)
+	require.NoError(t, err)
+	return s
+}
+
+func TestWriteOrder(t *testing.T) {
+	events := make([]string, 0)
+	primary := &testBackend{name: "primary", events: &events}
+	secondary := &testBackend{name: "secondary", events: &events}
+	s := newTestService(t, primary, secondary)
+
+	ctx, _, err := s.BeginTx(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, s.SetMetadata(ctx, "test", []byte("{}")))
+	require.NoError(t, s.CommitTx(ctx))
+
+	require.Equal(t, []string{
+		"secondary begin",
+		"primary begin",
+		"secondary set test",
+		"primary set test",
+		"secondary commit",
+		"primary commit",
+	}, events)
+}
+
+func TestSetLabels(t *testing.T) {
+	events := make([]string, 0)
+	primary := &testBackend{name: "primary", events: &events}
+	secondary := &testBackend{name: "secondary", events: &events}
+	s := newTestService(t, primary, secondary)
+
+	ctx, _, err := s.BeginTx(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, s.SetAddressLabels(ctx, []*chaindb.AddressLabel{{Label: "a"}, {Label: "b"}}))
+	require.NoError(t, s.SetValidatorLabels(ctx, []*chaindb.ValidatorLabel{{Label: "c"}}))
+	require.NoError(t, s.CommitTx(ctx))
+
+	// Both backends receive the labels.
+	require.Equal(t, []string{
+		"secondary begin",
+		"primary begin",
+		"secondary set 2 address labels",
+		"primary set 2 address labels",
+		"secondary set 1 validator labels",
+		"primary set 1 validator labels",
+		"secondary commit",
+		"primary commit",
+	}, events)
+}
+
+func TestSecondaryWriteFailure(t *testing.T) {
+	events := make([]string, 0)
+	primary := &testBackend{name: "primary", events: &events}
+	secondary := &testBackend{name: "secondary", events: &events, failWrite: true}
+	s := newTestService(t, primary, secondary)
+
+	ctx, cancel, err := s.BeginTx(context.Background())
+	require.NoError(t, err)
+	require.EqualError(t, s.SetMetadata(ctx, "test", []byte("{}")), "secondary: write failed")
+	cancel()
+
+	// The primary is not written, and both transactions are rolled back.
+	require.Equal(t, []string{
+		"secondary begin",
+		"primary begin",
+		"primary rollback",
+		"secondary rollback",
+	}, events)
+}
+
+func TestSecondaryCommitFailure(t *testing.T) {
+	events := make([]string, 0)
+	primary := &testBackend{name: "primary", events: &events}
+	secondary := &testBackend{name: "secondary", events: &events, failCommit: true}
+	s := newTestService(t, primary, secondary)
+
+	ctx, cancel, err := s.BeginTx(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, s.SetMetadata(ctx, "test", []byte("{}")))
+	require.EqualError(t, s.CommitTx(ctx), "failed to commit secondary transaction: commit failed")
+	cancel()
+
+	// The primary is not committed, so the work will be carried out again.
+	require.Equal(t, []string{
+		"secondary begin",
+		"primary begin",
+		"secondary set test",
+		"primary set test",
+		"primary rollback",
+		"secondary rollback",
+	}, events)
+}
+
+func TestWriteOutsideTransaction(t *testing.T) {
+	events := make([]string, 0)
+	s := newTestService(t, &testBackend{name: "primary", events: &events}, &testBackend{name: "secondary", events: &events})
+
+	require.ErrorIs(t, s.SetMetadata(context.Background(), "test", []byte("{}")), ErrNoTransaction)
+	require.Empty(t, events)
+}
+
+func TestNewSecondaryMismatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		primary   map[string][]byte
+		secondary map[string][]byte
+		err       string
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:      "Match",
+			primary:   map[string][]byte{"blocks.standard": []byte(`{"latest_slot":10}`)},
+			secondary: map[string][]byte{"blocks.standard": []byte(`{"latest_slot":10}`)},
+		},
+		{
+			name:    "SecondaryEmpty",
+			primary: map[string][]byte{"blocks.standard": []byte(`{"latest_slot":10}`)},
+			err:     "secondary chain database does not match primary for blocks.standard; it must start as a copy of the primary",
+		},
+		{
+			name:      "SecondaryBehind",
+			primary:   map[string][]byte{"blocks.standard": []byte(`{"latest_slot":10}`)},
+			secondary: map[string][]byte{"blocks.standard": []byte(`{"latest_slot":5}`)},
+			err:       "secondary chain database does not match primary for blocks.standard; it must start as a copy of the primary",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events := make([]string, 0)
+			_, err := New(context.Background(),
+				WithLogLevel(zerolog.Disabled),
+				WithPrimary(&testBackend{name: "primary", events: &events, metadata: test.primary}),
+				WithSecondary(&testBackend{name: "secondary", events: &events, metadata: test.secondary}),
+			)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
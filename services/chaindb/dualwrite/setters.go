@@ -0,0 +1,324 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dualwrite
+
+import (
+	"context"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetAddressLabels sets address labels.
+func (s *Service) SetAddressLabels(ctx context.Context, labels []*chaindb.AddressLabel) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetAddressLabels(ctx, labels)
+	})
+}
+
+// SetAttestation sets an attestation.
+func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetAttestation(ctx, attestation)
+	})
+}
+
+// SetAttestations sets multiple attestations.
+func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.Attestation) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetAttestations(ctx, attestations)
+	})
+}
+
+// SetAttesterSlashing sets an attester slashing.
+func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *chaindb.AttesterSlashing) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetAttesterSlashing(ctx, attesterSlashing)
+	})
+}
+
+// SetBeaconCommittee sets a beacon committee.
+func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetBeaconCommittee(ctx, beaconCommittee)
+	})
+}
+
+// SetBlock sets a block.
+func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetBlock(ctx, block)
+	})
+}
+
+// SetChainSpecValue sets the value of the provided key.
+func (s *Service) SetChainSpecValue(ctx context.Context, key string, value interface{}) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetChainSpecValue(ctx, key, value)
+	})
+}
+
+// SetForkSchedule sets the fork schedule.
+func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetForkSchedule(ctx, schedule)
+	})
+}
+
+// SetGenesis sets the genesis information.
+func (s *Service) SetGenesis(ctx context.Context, genesis *api.Genesis) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetGenesis(ctx, genesis)
+	})
+}
+
+// SetETH1Deposit sets an Ethereum 1 deposit.
+func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Deposit) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetETH1Deposit(ctx, deposit)
+	})
+}
+
+// SetETH1DepositInclusion sets the inclusion of an Ethereum 1 deposit.
+func (s *Service) SetETH1DepositInclusion(ctx context.Context, inclusion *chaindb.ETH1DepositInclusion) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetETH1DepositInclusion(ctx, inclusion)
+	})
+}
+
+// SetProposerDuty sets a proposer duty.
+func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.ProposerDuty) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetProposerDuty(ctx, proposerDuty)
+	})
+}
+
+// SetMissedSlot sets a missed slot.
+func (s *Service) SetMissedSlot(ctx context.Context, missedSlot *chaindb.MissedSlot) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetMissedSlot(ctx, missedSlot)
+	})
+}
+
+// DeleteMissedSlot removes the record of a missed slot.
+func (s *Service) DeleteMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.DeleteMissedSlot(ctx, slot)
+	})
+}
+
+// SetProposerSlashing sets an proposer slashing.
+func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *chaindb.ProposerSlashing) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetProposerSlashing(ctx, proposerSlashing)
+	})
+}
+
+// SetSyncAggregate sets the sync aggregate.
+func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.SyncAggregate) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetSyncAggregate(ctx, syncAggregate)
+	})
+}
+
+// SetValidator sets a validator.
+func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidator(ctx, validator)
+	})
+}
+
+// SetValidatorBalance sets a validator balance.
+func (s *Service) SetValidatorBalance(ctx context.Context, validatorBalance *chaindb.ValidatorBalance) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidatorBalance(ctx, validatorBalance)
+	})
+}
+
+// SetValidatorBalances sets multiple validator balances.
+func (s *Service) SetValidatorBalances(ctx context.Context, validatorBalances []*chaindb.ValidatorBalance) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidatorBalances(ctx, validatorBalances)
+	})
+}
+
+// SetDeposit sets a deposit.
+func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetDeposit(ctx, deposit)
+	})
+}
+
+// SetVoluntaryExit sets a voluntary exit.
+func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.VoluntaryExit) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetVoluntaryExit(ctx, voluntaryExit)
+	})
+}
+
+// SetValidatorEpochSummary sets a validator epoch summary.
+func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidatorEpochSummary(ctx, summary)
+	})
+}
+
+// SetValidatorEpochSummaries sets multiple validator epoch summaries.
+func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidatorEpochSummaries(ctx, summaries)
+	})
+}
+
+// SetValidatorLabels sets validator labels.
+func (s *Service) SetValidatorLabels(ctx context.Context, labels []*chaindb.ValidatorLabel) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidatorLabels(ctx, labels)
+	})
+}
+
+// SetValidatorLabelEpochSummaries sets multiple validator label epoch summaries.
+func (s *Service) SetValidatorLabelEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorLabelEpochSummary) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidatorLabelEpochSummaries(ctx, summaries)
+	})
+}
+
+// SetBlockSummary sets a block summary.
+func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSummary) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetBlockSummary(ctx, summary)
+	})
+}
+
+// SetEpochSummary sets an epoch summary.
+func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSummary) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetEpochSummary(ctx, summary)
+	})
+}
+
+// SetSyncCommittee sets a sync committee.
+func (s *Service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.SyncCommittee) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetSyncCommittee(ctx, syncCommittee)
+	})
+}
+
+// SetMaterializedView creates a materialized view if it does not already exist.
+func (s *Service) SetMaterializedView(ctx context.Context, name string, query string) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetMaterializedView(ctx, name, query)
+	})
+}
+
+// DropMaterializedView drops a materialized view if it exists.
+func (s *Service) DropMaterializedView(ctx context.Context, name string) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.DropMaterializedView(ctx, name)
+	})
+}
+
+// RefreshMaterializedView refreshes the contents of a materialized view.
+func (s *Service) RefreshMaterializedView(ctx context.Context, name string) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.RefreshMaterializedView(ctx, name)
+	})
+}
+
+// DeleteBlocks deletes the blocks in the given slot range, along with the data contained within them.
+func (s *Service) DeleteBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.DeleteBlocks(ctx, minSlot, maxSlot)
+	})
+}
+
+// DeleteBeaconCommittees deletes the beacon committees in the given slot range.
+func (s *Service) DeleteBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.DeleteBeaconCommittees(ctx, minSlot, maxSlot)
+	})
+}
+
+// DeleteProposerDuties deletes the proposer duties in the given slot range.
+func (s *Service) DeleteProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.DeleteProposerDuties(ctx, minSlot, maxSlot)
+	})
+}
+
+// DeleteValidatorBalances deletes the validator balances in the given epoch range.
+func (s *Service) DeleteValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.DeleteValidatorBalances(ctx, minEpoch, maxEpoch)
+	})
+}
+
+// SetEpochComplete records that the named service has completed processing of an epoch.
+func (s *Service) SetEpochComplete(ctx context.Context, service string, epoch phase0.Epoch) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetEpochComplete(ctx, service, epoch)
+	})
+}
+
+// SetEpochProvenance sets the provenance of the named dataset for the given epoch.
+func (s *Service) SetEpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch, provenance *chaindb.Provenance) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetEpochProvenance(ctx, dataset, epoch, provenance)
+	})
+}
+
+// SetEpochChecksums sets multiple epoch checksums.
+func (s *Service) SetEpochChecksums(ctx context.Context, checksums []*chaindb.EpochChecksum) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetEpochChecksums(ctx, checksums)
+	})
+}
+
+// SetExecutionTransactions sets the transactions of an execution block.
+func (s *Service) SetExecutionTransactions(ctx context.Context, transactions []*chaindb.ExecutionTransaction) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetExecutionTransactions(ctx, transactions)
+	})
+}
+
+// SetExecutionBlockSummary sets the summary of an execution block.
+func (s *Service) SetExecutionBlockSummary(ctx context.Context, summary *chaindb.ExecutionBlockSummary) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetExecutionBlockSummary(ctx, summary)
+	})
+}
+
+// SetStateRoots sets multiple state roots.
+func (s *Service) SetStateRoots(ctx context.Context, stateRoots []*chaindb.StateRoot) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetStateRoots(ctx, stateRoots)
+	})
+}
+
+// SetValidatorActivations sets multiple validator activations.
+func (s *Service) SetValidatorActivations(ctx context.Context, activations []*chaindb.ValidatorActivation) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetValidatorActivations(ctx, activations)
+	})
+}
+
+// DeleteEpochCompletions removes the completion markers for the named service from the
+// given epoch onwards.
+func (s *Service) DeleteEpochCompletions(ctx context.Context, service string, fromEpoch phase0.Epoch) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.DeleteEpochCompletions(ctx, service, fromEpoch)
+	})
+}
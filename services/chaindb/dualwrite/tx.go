@@ -0,0 +1,114 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dualwrite
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrNoTransaction is returned when an attempt to carry out a mutation to the database
+	// is not inside a transaction.
+	ErrNoTransaction = errors.New("no transaction for action")
+)
+
+// SecondaryCtx is a context tag for the secondary backend's transaction context.
+type SecondaryCtx struct{}
+
+// BeginTx begins a transaction on both backends.
+// The transactions can be rolled back by invoking the cancel function.
+func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	// The backends both store their transaction in the context, so each
+	// transaction is started from the parent context and the secondary's
+	// context is carried inside the primary's context.
+	secondaryCtx, secondaryCancel, err := s.secondary.BeginTx(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to begin secondary transaction")
+	}
+
+	primaryCtx, primaryCancel, err := s.primary.BeginTx(ctx)
+	if err != nil {
+		secondaryCancel()
+		return nil, nil, errors.Wrap(err, "failed to begin primary transaction")
+	}
+
+	ctx = context.WithValue(primaryCtx, &SecondaryCtx{}, secondaryCtx)
+
+	return ctx, func() {
+		primaryCancel()
+		secondaryCancel()
+	}, nil
+}
+
+// CommitTx commits a transaction on both backends.
+// The secondary is committed first, so that if either commit fails the primary
+// (and hence the metadata used to track progress) has not moved on, and the
+// work will be carried out again.
+func (s *Service) CommitTx(ctx context.Context) error {
+	secondaryCtx, err := s.secondaryCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if err := s.secondary.CommitTx(secondaryCtx); err != nil {
+		return errors.Wrap(err, "failed to commit secondary transaction")
+	}
+
+	if err := s.primary.CommitTx(ctx); err != nil {
+		log.Warn().Err(err).Msg("Primary commit failed after secondary commit succeeded; secondary will be updated on retry")
+		return errors.Wrap(err, "failed to commit primary transaction")
+	}
+
+	return nil
+}
+
+// SetMetadata sets a metadata key to a JSON value.
+func (s *Service) SetMetadata(ctx context.Context, key string, value []byte) error {
+	return s.write(ctx, func(ctx context.Context, b backend) error {
+		return b.SetMetadata(ctx, key, value)
+	})
+}
+
+// Metadata obtains the JSON value from a metadata key.
+func (s *Service) Metadata(ctx context.Context, key string) ([]byte, error) {
+	return s.primary.Metadata(ctx, key)
+}
+
+// secondaryCtx returns the context holding the secondary backend's transaction.
+func (*Service) secondaryCtx(ctx context.Context) (context.Context, error) {
+	if ctx == nil {
+		return nil, errors.New("no context")
+	}
+	secondaryCtx, ok := ctx.Value(&SecondaryCtx{}).(context.Context)
+	if !ok {
+		return nil, ErrNoTransaction
+	}
+	return secondaryCtx, nil
+}
+
+// write carries out a write against the secondary and then the primary backend.
+func (s *Service) write(ctx context.Context, fn func(context.Context, backend) error) error {
+	secondaryCtx, err := s.secondaryCtx(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(secondaryCtx, s.secondary); err != nil {
+		return errors.Wrap(err, "secondary")
+	}
+	if err := fn(ctx, s.primary); err != nil {
+		return errors.Wrap(err, "primary")
+	}
+	return nil
+}
@@ -50,3 +50,133 @@ type ValidatorSummaryFilter struct {
 	// If nil then no filter is applied
 	ValidatorIndices *[]phase0.ValidatorIndex
 }
+
+// BlockCursor is a position in the list of blocks, used to continue fetching
+// blocks from where a previous request finished.
+type BlockCursor struct {
+	Slot phase0.Slot
+	Root phase0.Root
+}
+
+// BlockFilter defines a filter for fetching blocks.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (slot, root) order.
+type BlockFilter struct {
+	// Limit is the maximum number of blocks to return.
+	// If 0 then there is no limit.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest slot from which to fetch blocks.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest slot from which to fetch blocks.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// Canonical is the canonical state of the blocks to fetch.
+	// If nil then no filter is applied.
+	Canonical *bool
+
+	// ProposerIndices is the list of proposers for which to fetch blocks.
+	// If nil then no filter is applied.
+	ProposerIndices *[]phase0.ValidatorIndex
+
+	// Cursor is the position from which to continue fetching blocks.  Blocks
+	// at the cursor are not returned.  To fetch the next page of results set
+	// this to the position of the last block returned for OrderEarliest, or
+	// the first block returned for OrderLatest.
+	// If nil then blocks are fetched from the start.
+	Cursor *BlockCursor
+}
+
+// AttestationCursor is a position in the list of attestations, used to continue
+// fetching attestations from where a previous request finished.
+type AttestationCursor struct {
+	InclusionSlot      phase0.Slot
+	InclusionBlockRoot phase0.Root
+	InclusionIndex     uint64
+}
+
+// AttestationFilter defines a filter for fetching attestations.
+// Filter elements are ANDed together.
+// Results are always returned in ascending (inclusion slot, inclusion block root, inclusion index) order.
+type AttestationFilter struct {
+	// Limit is the maximum number of attestations to return.
+	// If 0 then there is no limit.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the earliest results
+	// that match the filter are returned, or OrderLatest, in which case the
+	// latest results that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// From is the earliest inclusion slot from which to fetch attestations.
+	// If nil then there is no earliest slot.
+	From *phase0.Slot
+
+	// To is the latest inclusion slot from which to fetch attestations.
+	// If nil then there is no latest slot.
+	To *phase0.Slot
+
+	// Canonical is the canonical state of the attestations to fetch.
+	// If nil then no filter is applied.
+	Canonical *bool
+
+	// ValidatorIndices is the list of validators for which to fetch attestations;
+	// an attestation matches if any of the validators is in its aggregation indices.
+	// If nil then no filter is applied.
+	ValidatorIndices *[]phase0.ValidatorIndex
+
+	// Cursor is the position from which to continue fetching attestations.
+	// Attestations at the cursor are not returned.  To fetch the next page of
+	// results set this to the position of the last attestation returned for
+	// OrderEarliest, or the first attestation returned for OrderLatest.
+	// If nil then attestations are fetched from the start.
+	Cursor *AttestationCursor
+}
+
+// ValidatorFilter defines a filter for fetching validators.
+// Filter elements are ANDed together.
+// Results are always returned in ascending validator index order.
+type ValidatorFilter struct {
+	// Limit is the maximum number of validators to return.
+	// If 0 then there is no limit.
+	Limit uint32
+
+	// Order is either OrderEarliest, in which case the lowest indices
+	// that match the filter are returned, or OrderLatest, in which case the
+	// highest indices that match the filter are returned.
+	// The default is OrderEarliest.
+	Order Order
+
+	// ValidatorIndices is the list of validator indices to fetch.
+	// If nil then no filter is applied.
+	ValidatorIndices *[]phase0.ValidatorIndex
+
+	// PublicKeys is the list of validator public keys to fetch.
+	// If nil then no filter is applied.
+	PublicKeys *[]phase0.BLSPubKey
+
+	// ActiveAt is an epoch at which the validators to fetch are active.
+	// If nil then no filter is applied.
+	ActiveAt *phase0.Epoch
+
+	// Slashed is the slashed state of the validators to fetch.
+	// If nil then no filter is applied.
+	Slashed *bool
+
+	// Cursor is the index from which to continue fetching validators.  The
+	// validator at the cursor is not returned.  To fetch the next page of
+	// results set this to the index of the last validator returned for
+	// OrderEarliest, or the first validator returned for OrderLatest.
+	// If nil then validators are fetched from the start.
+	Cursor *phase0.ValidatorIndex
+}
@@ -39,6 +39,11 @@ func (s *service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 	return nil, nil
 }
 
+// Attestations provides attestations according to the filter.
+func (s *service) Attestations(ctx context.Context, filter *chaindb.AttestationFilter) ([]*chaindb.Attestation, error) {
+	return nil, nil
+}
+
 // AttestationsForSlotRange fetches all attestations made for the given slot range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // attestations for slots 2 and 3.
@@ -63,6 +68,11 @@ func (s *service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 	return nil
 }
 
+// SetAttestations sets multiple attestations.
+func (s *service) SetAttestations(ctx context.Context, attestations []*chaindb.Attestation) error {
+	return nil
+}
+
 // AttesterSlashingsForSlotRange fetches all attester slashings made for the given slot range.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
 func (s *service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.AttesterSlashing, error) {
@@ -117,6 +127,11 @@ func (s *service) BlocksByParentRoot(ctx context.Context, root phase0.Root) ([]*
 	return nil, nil
 }
 
+// Blocks provides blocks according to the filter.
+func (s *service) Blocks(ctx context.Context, filter *chaindb.BlockFilter) ([]*chaindb.Block, error) {
+	return nil, nil
+}
+
 // EmptySlots fetches the slots in the given range without a block in the database.
 func (s *service) EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
 	return nil, nil
@@ -298,6 +313,27 @@ func (s *service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
 	return nil
 }
 
+// ETH1DepositsByIndexRange fetches Ethereum 1 deposits for the given range of deposit indices.
+func (s *service) ETH1DepositsByIndexRange(ctx context.Context, startIndex uint64, endIndex uint64) ([]*chaindb.ETH1Deposit, error) {
+	return nil, nil
+}
+
+// LatestETH1DepositInclusion fetches the inclusion of the Ethereum 1 deposit with the highest index
+// that has been included in the beacon chain, or nil if there is none.
+func (s *service) LatestETH1DepositInclusion(ctx context.Context) (*chaindb.ETH1DepositInclusion, error) {
+	return nil, nil
+}
+
+// ETH1DepositInclusionsByIndexRange fetches the inclusions for the given range of deposit indices.
+func (s *service) ETH1DepositInclusionsByIndexRange(ctx context.Context, startIndex uint64, endIndex uint64) ([]*chaindb.ETH1DepositInclusion, error) {
+	return nil, nil
+}
+
+// SetETH1DepositInclusion sets the inclusion of an Ethereum 1 deposit.
+func (s *service) SetETH1DepositInclusion(ctx context.Context, inclusion *chaindb.ETH1DepositInclusion) error {
+	return nil
+}
+
 // ProposerDutiesForSlotRange fetches all proposer duties for the given slot range.
 // Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 // proposer duties for slots 2 and 3.
@@ -315,6 +351,26 @@ func (s *service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.Pro
 	return nil
 }
 
+// MissedSlotsForSlotRange fetches the missed slots in the given slot range.
+func (s *service) MissedSlotsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.MissedSlot, error) {
+	return nil, nil
+}
+
+// MissedSlotsForProposer fetches the missed slots assigned to the given proposer.
+func (s *service) MissedSlotsForProposer(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.MissedSlot, error) {
+	return nil, nil
+}
+
+// SetMissedSlot sets a missed slot.
+func (s *service) SetMissedSlot(ctx context.Context, missedSlot *chaindb.MissedSlot) error {
+	return nil
+}
+
+// DeleteMissedSlot removes the record of a missed slot.
+func (s *service) DeleteMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	return nil
+}
+
 // ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
 // It will return slashings from blocks that are canonical or undefined, but not from non-canonical blocks.
 func (s *service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.ProposerSlashing, error) {
@@ -359,6 +415,11 @@ func (s *service) ValidatorsByIndex(ctx context.Context, indices []phase0.Valida
 	return nil, nil
 }
 
+// ValidatorsWithFilter provides validators according to the filter.
+func (s *service) ValidatorsWithFilter(ctx context.Context, filter *chaindb.ValidatorFilter) ([]*chaindb.Validator, error) {
+	return nil, nil
+}
+
 // ValidatorBalancesByIndexAndEpoch fetches the validator balances for the given validators and epoch.
 func (s *service) ValidatorBalancesByIndexAndEpoch(
 	ctx context.Context,
@@ -438,6 +499,64 @@ func (s *service) AggregateValidatorBalancesByIndexAndEpochs(
 	return nil, nil
 }
 
+// EpochParticipationForEpochRange fetches the participation for each epoch in the given range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// participation for epochs 2 and 3.
+func (s *service) EpochParticipationForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.EpochParticipation, error) {
+	return nil, nil
+}
+
+// ValidatorAttestationCountsForRange fetches the attestation counts for each validator in the given index range
+// over the given epoch range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *service) ValidatorAttestationCountsForRange(ctx context.Context,
+	startIndex phase0.ValidatorIndex,
+	endIndex phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorAttestationCounts,
+	error,
+) {
+	return nil, nil
+}
+
+// ValidatorPerformanceForEpochRange fetches the performance of the given validators over the given epoch range,
+// combining their epoch summaries, balances and deposits.
+// Ranges are inclusive of start and exclusive of end.
+func (s *service) ValidatorPerformanceForEpochRange(ctx context.Context,
+	indices []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorPerformance,
+	error,
+) {
+	return nil, nil
+}
+
+// TableStats fetches the statistics for each of the database's tables.
+func (s *service) TableStats(ctx context.Context) ([]*chaindb.TableStats, error) {
+	return []*chaindb.TableStats{}, nil
+}
+
+// DatabaseLoad fetches the current load on the database.
+func (s *service) DatabaseLoad(ctx context.Context) (*chaindb.DatabaseLoad, error) {
+	return &chaindb.DatabaseLoad{}, nil
+}
+
+// ActiveValidatorCount fetches the number of validators active at the given epoch.
+func (s *service) ActiveValidatorCount(ctx context.Context, epoch phase0.Epoch) (uint64, error) {
+	return 0, nil
+}
+
+// TotalValidatorBalancesForEpochRange fetches the total balances of all validators for each epoch in the given range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// balances for epochs 2 and 3.
+func (s *service) TotalValidatorBalancesForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.AggregateValidatorBalance, error) {
+	return nil, nil
+}
+
 // SetValidator sets a validator.
 func (s *service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
 	return nil
@@ -524,6 +643,21 @@ func (s *service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.S
 	return nil
 }
 
+// SetMaterializedView creates a materialized view if it does not already exist.
+func (s *service) SetMaterializedView(ctx context.Context, name string, query string) error {
+	return nil
+}
+
+// DropMaterializedView drops a materialized view if it exists.
+func (s *service) DropMaterializedView(ctx context.Context, name string) error {
+	return nil
+}
+
+// RefreshMaterializedView refreshes the contents of a materialized view.
+func (s *service) RefreshMaterializedView(ctx context.Context, name string) error {
+	return nil
+}
+
 // BeginTx begins a transaction.
 func (s *service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
 	return nil, nil, nil
@@ -543,3 +677,175 @@ func (s *service) SetMetadata(ctx context.Context, key string, value []byte) err
 func (s *service) Metadata(ctx context.Context, key string) ([]byte, error) {
 	return nil, nil
 }
+
+// GenesisTime provides the genesis time of the chain.
+func (s *service) GenesisTime(ctx context.Context) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+// SlotsWithoutBeaconCommittees fetches the slots in the given range without beacon committees
+// in the database, ignoring slots before the first or after the last with beacon committees.
+func (s *service) SlotsWithoutBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	return nil, nil
+}
+
+// SlotsWithoutProposerDuties fetches the slots in the given range without a proposer duty
+// in the database, ignoring slots before the first or after the last with a proposer duty.
+func (s *service) SlotsWithoutProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	return nil, nil
+}
+
+// EpochsWithoutValidatorBalances fetches the epochs in the given range without validator balances
+// in the database, ignoring epochs before the first or after the last with validator balances.
+func (s *service) EpochsWithoutValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	return nil, nil
+}
+
+// DeleteBlocks deletes the blocks in the given slot range, along with the data contained within them.
+func (s *service) DeleteBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	return nil
+}
+
+// DeleteBeaconCommittees deletes the beacon committees in the given slot range.
+func (s *service) DeleteBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	return nil
+}
+
+// DeleteProposerDuties deletes the proposer duties in the given slot range.
+func (s *service) DeleteProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	return nil
+}
+
+// DeleteValidatorBalances deletes the validator balances in the given epoch range.
+func (s *service) DeleteValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) error {
+	return nil
+}
+
+// SetEpochComplete records that the named service has completed processing of an epoch.
+func (s *service) SetEpochComplete(ctx context.Context, service string, epoch phase0.Epoch) error {
+	return nil
+}
+
+// SetEpochProvenance sets the provenance of the named dataset for the given epoch.
+func (s *service) SetEpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch, provenance *chaindb.Provenance) error {
+	return nil
+}
+
+// SetEpochChecksums sets multiple epoch checksums.
+func (s *service) SetEpochChecksums(ctx context.Context, checksums []*chaindb.EpochChecksum) error {
+	return nil
+}
+
+// SetExecutionTransactions sets the transactions of an execution block.
+func (s *service) SetExecutionTransactions(ctx context.Context, transactions []*chaindb.ExecutionTransaction) error {
+	return nil
+}
+
+// SetExecutionBlockSummary sets the summary of an execution block.
+func (s *service) SetExecutionBlockSummary(ctx context.Context, summary *chaindb.ExecutionBlockSummary) error {
+	return nil
+}
+
+// SetStateRoots sets multiple state roots.
+func (s *service) SetStateRoots(ctx context.Context, stateRoots []*chaindb.StateRoot) error {
+	return nil
+}
+
+// StateRootBySlot fetches the state root for the given slot.
+func (s *service) StateRootBySlot(ctx context.Context, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	return nil, nil
+}
+
+// LatestArchivedStateRoot fetches the latest state root at or before the
+// given slot for which an archive of the full state is available.
+func (s *service) LatestArchivedStateRoot(ctx context.Context, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	return nil, nil
+}
+
+// SetValidatorActivations sets multiple validator activations.
+func (s *service) SetValidatorActivations(ctx context.Context, activations []*chaindb.ValidatorActivation) error {
+	return nil
+}
+
+// LatestValidatorActivation fetches the validator activation with the highest
+// activation epoch, or nil if there is none.
+func (s *service) LatestValidatorActivation(ctx context.Context) (*chaindb.ValidatorActivation, error) {
+	return nil, nil
+}
+
+// ValidatorActivationLatencies fetches the given percentile of the latencies
+// of validators activated in the given epoch range.
+func (s *service) ValidatorActivationLatencies(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	percentile float64,
+) (
+	*chaindb.ValidatorActivationLatencies,
+	error,
+) {
+	return &chaindb.ValidatorActivationLatencies{}, nil
+}
+
+// AddressLabels fetches the labels for the given addresses.
+func (s *service) AddressLabels(ctx context.Context, addresses [][20]byte) (map[[20]byte]string, error) {
+	return map[[20]byte]string{}, nil
+}
+
+// UnlabeledAddresses fetches the fee recipient and withdrawal addresses that do not have a label.
+func (s *service) UnlabeledAddresses(ctx context.Context) ([][20]byte, error) {
+	return [][20]byte{}, nil
+}
+
+// SetAddressLabels sets address labels.
+func (s *service) SetAddressLabels(ctx context.Context, labels []*chaindb.AddressLabel) error {
+	return nil
+}
+
+// ValidatorLabels fetches the labels for all labeled validators.
+func (s *service) ValidatorLabels(ctx context.Context) (map[phase0.BLSPubKey]string, error) {
+	return map[phase0.BLSPubKey]string{}, nil
+}
+
+// SetValidatorLabels sets validator labels.
+func (s *service) SetValidatorLabels(ctx context.Context, labels []*chaindb.ValidatorLabel) error {
+	return nil
+}
+
+// SetValidatorLabelEpochSummaries sets multiple validator label epoch summaries.
+func (s *service) SetValidatorLabelEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorLabelEpochSummary) error {
+	return nil
+}
+
+// EpochProvenance fetches the provenance of the named dataset for the given epoch,
+// or nil if it is not known.
+func (s *service) EpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch) (*chaindb.Provenance, error) {
+	return nil, nil
+}
+
+// EpochChecksumDatasets provides the names of the datasets for which checksums can be computed.
+func (s *service) EpochChecksumDatasets() []string {
+	return nil
+}
+
+// ComputeEpochChecksum computes the checksum of the named dataset for the given epoch from
+// the indexed data.
+func (s *service) ComputeEpochChecksum(ctx context.Context, dataset string, epoch phase0.Epoch) (*chaindb.EpochChecksum, error) {
+	return nil, nil
+}
+
+// EpochChecksums fetches the stored checksums of all datasets for the given epoch range.
+func (s *service) EpochChecksums(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.EpochChecksum, error) {
+	return nil, nil
+}
+
+// DeleteEpochCompletions removes the completion markers for the named service from the
+// given epoch onwards.
+func (s *service) DeleteEpochCompletions(ctx context.Context, service string, fromEpoch phase0.Epoch) error {
+	return nil
+}
+
+// CompletedEpochs fetches the epochs in the given range for which the named service has
+// recorded completion.
+func (s *service) CompletedEpochs(ctx context.Context, service string, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	return []phase0.Epoch{}, nil
+}
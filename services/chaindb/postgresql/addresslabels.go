@@ -0,0 +1,142 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetAddressLabels sets address labels.
+func (s *Service) SetAddressLabels(ctx context.Context, labels []*chaindb.AddressLabel) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, label := range labels {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_address_labels(f_address
+                            ,f_label
+                            ,f_source
+                            )
+VALUES($1,$2,$3)
+ON CONFLICT (f_address) DO
+UPDATE
+SET f_label = excluded.f_label
+   ,f_source = excluded.f_source
+`,
+			label.Address[:],
+			label.Label,
+			label.Source,
+		); err != nil {
+			return errors.Wrap(err, "failed to set address label")
+		}
+	}
+
+	return nil
+}
+
+// AddressLabels fetches the labels for the given addresses.
+// Addresses without a label are not present in the result.
+func (s *Service) AddressLabels(ctx context.Context, addresses [][20]byte) (map[[20]byte]string, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	addressBytes := make([][]byte, len(addresses))
+	for i := range addresses {
+		addressBytes[i] = addresses[i][:]
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_address
+      ,f_label
+FROM t_address_labels
+WHERE f_address = ANY($1)
+`,
+		addressBytes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make(map[[20]byte]string)
+	for rows.Next() {
+		var address []byte
+		var label string
+		if err := rows.Scan(&address, &label); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		var key [20]byte
+		copy(key[:], address)
+		labels[key] = label
+	}
+
+	return labels, nil
+}
+
+// UnlabeledAddresses fetches the fee recipient and withdrawal addresses that do not have a label.
+func (s *Service) UnlabeledAddresses(ctx context.Context) ([][20]byte, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	// Withdrawal addresses are the last 20 bytes of withdrawal credentials with the 0x01 prefix.
+	rows, err := tx.Query(ctx, `
+SELECT f_address
+FROM (
+  SELECT DISTINCT f_fee_recipient AS f_address
+  FROM t_block_execution_payloads
+  UNION
+  SELECT DISTINCT SUBSTRING(f_withdrawal_credentials FROM 13 FOR 20)
+  FROM t_validators
+  WHERE SUBSTRING(f_withdrawal_credentials FROM 1 FOR 1) = '\x01'::BYTEA
+) AS addresses
+WHERE f_address NOT IN (SELECT f_address FROM t_address_labels)
+ORDER BY f_address
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := make([][20]byte, 0)
+	for rows.Next() {
+		var address []byte
+		if err := rows.Scan(&address); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		var res [20]byte
+		copy(res[:], address)
+		addresses = append(addresses, res)
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,409 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// EpochParticipationForEpochRange fetches the participation for each epoch in the given range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// participation for epochs 2 and 3.
+func (s *Service) EpochParticipationForEpochRange(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.EpochParticipation,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_epoch
+            ,f_active_balance
+            ,f_attesting_balance
+            ,f_target_correct_balance
+            ,f_head_correct_balance
+            ,COALESCE(f_attesting_balance::FLOAT8 / NULLIF(f_active_balance, 0), 0)
+      FROM t_epoch_summaries
+      WHERE f_epoch >= $1
+        AND f_epoch < $2
+      ORDER BY f_epoch`,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	participations := make([]*chaindb.EpochParticipation, 0)
+	for rows.Next() {
+		participation := &chaindb.EpochParticipation{}
+		err := rows.Scan(
+			&participation.Epoch,
+			&participation.ActiveBalance,
+			&participation.AttestingBalance,
+			&participation.TargetCorrectBalance,
+			&participation.HeadCorrectBalance,
+			&participation.Rate,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		participations = append(participations, participation)
+	}
+
+	return participations, nil
+}
+
+// ValidatorAttestationCountsForRange fetches the attestation counts for each validator in the given index range
+// over the given epoch range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) ValidatorAttestationCountsForRange(ctx context.Context,
+	startIndex phase0.ValidatorIndex,
+	endIndex phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorAttestationCounts,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_validator_index
+            ,COUNT(*)
+            ,COUNT(*) FILTER (WHERE f_attestation_included)
+            ,COUNT(*) FILTER (WHERE f_attestation_target_correct)
+            ,COUNT(*) FILTER (WHERE f_attestation_head_correct)
+      FROM t_validator_epoch_summaries
+      WHERE f_validator_index >= $1
+        AND f_validator_index < $2
+        AND f_epoch >= $3
+        AND f_epoch < $4
+      GROUP BY f_validator_index
+      ORDER BY f_validator_index`,
+		startIndex,
+		endIndex,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make([]*chaindb.ValidatorAttestationCounts, 0)
+	for rows.Next() {
+		count := &chaindb.ValidatorAttestationCounts{}
+		err := rows.Scan(
+			&count.Index,
+			&count.Epochs,
+			&count.Included,
+			&count.TargetCorrect,
+			&count.HeadCorrect,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// ValidatorPerformanceForEpochRange fetches the performance of the given validators over the given epoch range,
+// combining their epoch summaries, balances and deposits.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) ValidatorPerformanceForEpochRange(ctx context.Context,
+	indices []phase0.ValidatorIndex,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.ValidatorPerformance,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	slotsPerEpoch, err := s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain SLOTS_PER_EPOCH")
+	}
+	slotsPerEpochVal, isUint := slotsPerEpoch.(uint64)
+	if !isUint {
+		return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
+	}
+
+	performances := make(map[phase0.ValidatorIndex]*chaindb.ValidatorPerformance, len(indices))
+	for _, index := range indices {
+		performances[index] = &chaindb.ValidatorPerformance{
+			Index: index,
+		}
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_validator_index
+            ,COUNT(*)
+            ,COUNT(*) FILTER (WHERE f_attestation_included)
+            ,COUNT(*) FILTER (WHERE f_attestation_target_correct)
+            ,COUNT(*) FILTER (WHERE f_attestation_head_correct)
+            ,COUNT(*) FILTER (WHERE f_attestation_source_timely)
+            ,COUNT(*) FILTER (WHERE f_attestation_target_timely)
+            ,COUNT(*) FILTER (WHERE f_attestation_head_timely)
+            ,COALESCE(AVG(f_attestation_inclusion_delay), 0)::FLOAT8
+            ,SUM(f_proposer_duties)
+            ,SUM(f_proposals_included)
+      FROM t_validator_epoch_summaries
+      WHERE f_validator_index = ANY($1)
+        AND f_epoch >= $2
+        AND f_epoch < $3
+      GROUP BY f_validator_index`,
+		indices,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var index phase0.ValidatorIndex
+		performance := &chaindb.ValidatorPerformance{}
+		err := rows.Scan(
+			&index,
+			&performance.Epochs,
+			&performance.AttestationsIncluded,
+			&performance.AttestationsTargetCorrect,
+			&performance.AttestationsHeadCorrect,
+			&performance.AttestationsSourceTimely,
+			&performance.AttestationsTargetTimely,
+			&performance.AttestationsHeadTimely,
+			&performance.InclusionDelay,
+			&performance.ProposerDuties,
+			&performance.ProposalsIncluded,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if _, exists := performances[index]; exists {
+			performance.Index = index
+			performances[index] = performance
+		}
+	}
+	rows.Close()
+
+	rows, err = tx.Query(ctx, `
+      SELECT f_validator_index
+            ,f_epoch
+            ,f_balance
+      FROM t_validator_balances
+      WHERE f_validator_index = ANY($1)
+        AND f_epoch IN ($2, $3)`,
+		indices,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var index phase0.ValidatorIndex
+		var epoch phase0.Epoch
+		var balance phase0.Gwei
+		if err := rows.Scan(&index, &epoch, &balance); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		performance, exists := performances[index]
+		if !exists {
+			continue
+		}
+		if epoch == startEpoch {
+			startBalance := balance
+			performance.StartBalance = &startBalance
+		}
+		if epoch == endEpoch {
+			endBalance := balance
+			performance.EndBalance = &endBalance
+		}
+	}
+	rows.Close()
+
+	minSlot := phase0.Slot(uint64(startEpoch) * slotsPerEpochVal)
+	maxSlot := phase0.Slot(uint64(endEpoch) * slotsPerEpochVal)
+	rows, err = tx.Query(ctx, `
+      SELECT t_validators.f_index
+            ,SUM(t_deposits.f_amount)
+      FROM t_deposits
+      JOIN t_validators ON t_validators.f_public_key = t_deposits.f_validator_pubkey
+      WHERE t_validators.f_index = ANY($1)
+        AND t_deposits.f_inclusion_slot >= $2
+        AND t_deposits.f_inclusion_slot < $3
+        AND t_deposits.f_inclusion_block_root IN (SELECT f_root FROM t_blocks WHERE f_slot >= $2 AND f_slot < $3 AND (f_canonical IS NULL OR f_canonical = true))
+      GROUP BY t_validators.f_index`,
+		indices,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var index phase0.ValidatorIndex
+		var deposits phase0.Gwei
+		if err := rows.Scan(&index, &deposits); err != nil {
+			rows.Close()
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if performance, exists := performances[index]; exists {
+			performance.Deposits = deposits
+		}
+	}
+	rows.Close()
+
+	res := make([]*chaindb.ValidatorPerformance, 0, len(performances))
+	for _, performance := range performances {
+		if performance.StartBalance != nil && performance.EndBalance != nil {
+			income := int64(*performance.EndBalance) - int64(*performance.StartBalance) - int64(performance.Deposits)
+			performance.Income = &income
+		}
+		res = append(res, performance)
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].Index < res[j].Index
+	})
+
+	return res, nil
+}
+
+// ActiveValidatorCount fetches the number of validators active at the given epoch.
+func (s *Service) ActiveValidatorCount(ctx context.Context, epoch phase0.Epoch) (uint64, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	count := uint64(0)
+	err = tx.QueryRow(ctx, `
+      SELECT COUNT(*)
+      FROM t_validators
+      WHERE f_activation_epoch <= $1
+        AND (f_exit_epoch IS NULL OR f_exit_epoch > $1)`,
+		epoch,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// TotalValidatorBalancesForEpochRange fetches the total balances of all validators for each epoch in the given range.
+// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+// balances for epochs 2 and 3.
+func (s *Service) TotalValidatorBalancesForEpochRange(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]*chaindb.AggregateValidatorBalance,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_epoch
+            ,SUM(f_balance)
+            ,SUM(f_effective_balance)
+      FROM t_validator_balances
+      WHERE f_epoch >= $1
+        AND f_epoch < $2
+      GROUP BY f_epoch
+      ORDER BY f_epoch`,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aggregateBalances := make([]*chaindb.AggregateValidatorBalance, 0)
+	for rows.Next() {
+		aggregateBalance := &chaindb.AggregateValidatorBalance{}
+		err := rows.Scan(
+			&aggregateBalance.Epoch,
+			&aggregateBalance.Balance,
+			&aggregateBalance.EffectiveBalance,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		aggregateBalances = append(aggregateBalances, aggregateBalance)
+	}
+
+	return aggregateBalances, nil
+}
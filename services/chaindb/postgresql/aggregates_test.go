@@ -0,0 +1,116 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestEpochParticipationForEpochRange(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetEpochSummary(ctx, &chaindb.EpochSummary{
+		Epoch:            1000000,
+		ActiveBalance:    1000,
+		AttestingBalance: 750,
+	}))
+	require.NoError(t, s.SetEpochSummary(ctx, &chaindb.EpochSummary{
+		Epoch: 1000001,
+	}))
+
+	participations, err := s.EpochParticipationForEpochRange(ctx, 1000000, 1000002)
+	require.NoError(t, err)
+	require.Len(t, participations, 2)
+	require.Equal(t, 0.75, participations[0].Rate)
+	// No active balance results in a rate of 0 rather than an error.
+	require.Equal(t, float64(0), participations[1].Rate)
+}
+
+func TestValidatorPerformanceForEpochRange(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	index := phase0.ValidatorIndex(10000000)
+	require.NoError(t, s.SetValidator(ctx, &chaindb.Validator{
+		Index: index,
+	}))
+	targetCorrect := true
+	inclusionDelay := 2
+	require.NoError(t, s.SetValidatorEpochSummaries(ctx, []*chaindb.ValidatorEpochSummary{
+		{
+			Index:                     index,
+			Epoch:                     1000000,
+			ProposerDuties:            1,
+			ProposalsIncluded:         1,
+			AttestationIncluded:       true,
+			AttestationTargetCorrect:  &targetCorrect,
+			AttestationInclusionDelay: &inclusionDelay,
+		},
+		{
+			Index: index,
+			Epoch: 1000001,
+		},
+	}))
+	require.NoError(t, s.SetValidatorBalances(ctx, []*chaindb.ValidatorBalance{
+		{
+			Index:   index,
+			Epoch:   1000000,
+			Balance: 32000000000,
+		},
+		{
+			Index:   index,
+			Epoch:   1000002,
+			Balance: 32000010000,
+		},
+	}))
+
+	performances, err := s.ValidatorPerformanceForEpochRange(ctx, []phase0.ValidatorIndex{index, index + 1}, 1000000, 1000002)
+	require.NoError(t, err)
+	require.Len(t, performances, 2)
+	require.Equal(t, 2, performances[0].Epochs)
+	require.Equal(t, 1, performances[0].AttestationsIncluded)
+	require.Equal(t, 1, performances[0].AttestationsTargetCorrect)
+	require.Equal(t, float64(2), performances[0].InclusionDelay)
+	require.Equal(t, 1, performances[0].ProposalsIncluded)
+	require.NotNil(t, performances[0].Income)
+	require.Equal(t, int64(10000), *performances[0].Income)
+	// Validator without data is returned empty.
+	require.Equal(t, 0, performances[1].Epochs)
+	require.Nil(t, performances[1].Income)
+}
@@ -14,8 +14,12 @@
 package postgresql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -24,33 +28,50 @@ import (
 
 // SetAttestation sets an attestation.
 func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	return s.SetAttestations(ctx, []*chaindb.Attestation{attestation})
+}
+
+// attestationColumns is the number of columns written for each attestation.
+const attestationColumns = 19
+
+// maxAttestationsPerStatement is the maximum number of attestations written in a
+// single statement, as limited by the number of parameters a statement can have.
+const maxAttestationsPerStatement = 65535 / attestationColumns
+
+// SetAttestations sets multiple attestations.
+// Attestations are written in a single statement, rather than a statement per attestation.
+func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.Attestation) error {
 	tx := s.tx(ctx)
 	if tx == nil {
 		return ErrNoTransaction
 	}
 
-	var canonical sql.NullBool
-	if attestation.Canonical != nil {
-		canonical.Valid = true
-		canonical.Bool = *attestation.Canonical
-	}
-	var targetCorrect sql.NullBool
-	if attestation.TargetCorrect != nil {
-		targetCorrect.Valid = true
-		targetCorrect.Bool = *attestation.TargetCorrect
-	}
-	var headCorrect sql.NullBool
-	if attestation.HeadCorrect != nil {
-		headCorrect.Valid = true
-		headCorrect.Bool = *attestation.HeadCorrect
-	}
-	_, err := tx.Exec(ctx, `
+	for start := 0; start < len(attestations); start += maxAttestationsPerStatement {
+		end := start + maxAttestationsPerStatement
+		if end > len(attestations) {
+			end = len(attestations)
+		}
+		batch := attestations[start:end]
+
+		values := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*attestationColumns)
+		for i, attestation := range batch {
+			placeholders := make([]string, attestationColumns)
+			for j := range placeholders {
+				placeholders[j] = fmt.Sprintf("$%d", i*attestationColumns+j+1)
+			}
+			values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+			args = append(args, s.attestationArgs(attestation)...)
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
       INSERT INTO t_attestations(f_inclusion_slot
                                 ,f_inclusion_block_root
                                 ,f_inclusion_index
                                 ,f_slot
                                 ,f_committee_index
                                 ,f_aggregation_bits
+                                ,f_aggregation_bits_compressed
                                 ,f_aggregation_indices
                                 ,f_beacon_block_root
                                 ,f_source_epoch
@@ -58,15 +79,19 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
                                 ,f_target_epoch
                                 ,f_target_root
                                 ,f_canonical
+                                ,f_source_correct
                                 ,f_target_correct
                                 ,f_head_correct
+                                ,f_duplicate
+                                ,f_conflicting
 						  )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
+      VALUES %s
       ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
       UPDATE
       SET f_slot = excluded.f_slot
          ,f_committee_index = excluded.f_committee_index
          ,f_aggregation_bits = excluded.f_aggregation_bits
+         ,f_aggregation_bits_compressed = excluded.f_aggregation_bits_compressed
          ,f_aggregation_indices = excluded.f_aggregation_indices
          ,f_beacon_block_root = excluded.f_beacon_block_root
          ,f_source_epoch = excluded.f_source_epoch
@@ -74,15 +99,64 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
          ,f_target_epoch = excluded.f_target_epoch
          ,f_target_root = excluded.f_target_root
          ,f_canonical = excluded.f_canonical
+         ,f_source_correct = excluded.f_source_correct
          ,f_target_correct = excluded.f_target_correct
          ,f_head_correct = excluded.f_head_correct
-	  `,
+         ,f_duplicate = excluded.f_duplicate
+         ,f_conflicting = excluded.f_conflicting
+	  `, strings.Join(values, ",")),
+			args...,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// attestationArgs returns the arguments for the columns written for an attestation.
+func (s *Service) attestationArgs(attestation *chaindb.Attestation) []interface{} {
+	aggregationBits, aggregationBitsCompressed := s.bitfieldArgs(attestation.AggregationBits)
+
+	var canonical sql.NullBool
+	if attestation.Canonical != nil {
+		canonical.Valid = true
+		canonical.Bool = *attestation.Canonical
+	}
+	var sourceCorrect sql.NullBool
+	if attestation.SourceCorrect != nil {
+		sourceCorrect.Valid = true
+		sourceCorrect.Bool = *attestation.SourceCorrect
+	}
+	var targetCorrect sql.NullBool
+	if attestation.TargetCorrect != nil {
+		targetCorrect.Valid = true
+		targetCorrect.Bool = *attestation.TargetCorrect
+	}
+	var headCorrect sql.NullBool
+	if attestation.HeadCorrect != nil {
+		headCorrect.Valid = true
+		headCorrect.Bool = *attestation.HeadCorrect
+	}
+	var duplicate sql.NullBool
+	if attestation.Duplicate != nil {
+		duplicate.Valid = true
+		duplicate.Bool = *attestation.Duplicate
+	}
+	var conflicting sql.NullBool
+	if attestation.Conflicting != nil {
+		conflicting.Valid = true
+		conflicting.Bool = *attestation.Conflicting
+	}
+
+	return []interface{}{
 		attestation.InclusionSlot,
 		attestation.InclusionBlockRoot[:],
 		attestation.InclusionIndex,
 		attestation.Slot,
 		attestation.CommitteeIndex,
-		attestation.AggregationBits,
+		aggregationBits,
+		aggregationBitsCompressed,
 		attestation.AggregationIndices,
 		attestation.BeaconBlockRoot[:],
 		attestation.SourceEpoch,
@@ -90,11 +164,12 @@ func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attes
 		attestation.TargetEpoch,
 		attestation.TargetRoot[:],
 		canonical,
+		sourceCorrect,
 		targetCorrect,
 		headCorrect,
-	)
-
-	return err
+		duplicate,
+		conflicting,
+	}
 }
 
 // AttestationsForBlock fetches all attestations made for the given block.
@@ -116,6 +191,7 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
+            ,f_aggregation_bits_compressed
             ,f_aggregation_indices
             ,f_beacon_block_root
             ,f_source_epoch
@@ -123,8 +199,11 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
             ,f_target_epoch
             ,f_target_root
             ,f_canonical
+            ,f_source_correct
             ,f_target_correct
             ,f_head_correct
+            ,f_duplicate
+            ,f_conflicting
       FROM t_attestations
       WHERE f_beacon_block_root = $1
       ORDER BY f_inclusion_slot
@@ -141,13 +220,17 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBitsCompressed []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
 		var targetRoot []byte
 		var canonical sql.NullBool
+		var sourceCorrect sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var duplicate sql.NullBool
+		var conflicting sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
@@ -155,6 +238,7 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
 			&attestation.AggregationBits,
+			&aggregationBitsCompressed,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -162,12 +246,19 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 			&attestation.TargetEpoch,
 			&targetRoot,
 			&canonical,
+			&sourceCorrect,
 			&targetCorrect,
 			&headCorrect,
+			&duplicate,
+			&conflicting,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		attestation.AggregationBits, err = expandedBitfield(attestation.AggregationBits, aggregationBitsCompressed)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to expand aggregation bits")
+		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
@@ -180,6 +271,10 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 			val := canonical.Bool
 			attestation.Canonical = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		if targetCorrect.Valid {
 			val := targetCorrect.Bool
 			attestation.TargetCorrect = &val
@@ -188,6 +283,14 @@ func (s *Service) AttestationsForBlock(ctx context.Context, blockRoot phase0.Roo
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if duplicate.Valid {
+			val := duplicate.Bool
+			attestation.Duplicate = &val
+		}
+		if conflicting.Valid {
+			val := conflicting.Bool
+			attestation.Conflicting = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -213,6 +316,7 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
+            ,f_aggregation_bits_compressed
             ,f_aggregation_indices
             ,f_beacon_block_root
             ,f_source_epoch
@@ -220,8 +324,11 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
             ,f_target_epoch
             ,f_target_root
             ,f_canonical
+            ,f_source_correct
             ,f_target_correct
             ,f_head_correct
+            ,f_duplicate
+            ,f_conflicting
       FROM t_attestations
       WHERE f_inclusion_block_root = $1
       ORDER BY f_inclusion_slot
@@ -238,13 +345,17 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBitsCompressed []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
 		var targetRoot []byte
 		var canonical sql.NullBool
+		var sourceCorrect sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var duplicate sql.NullBool
+		var conflicting sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
@@ -252,6 +363,7 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
 			&attestation.AggregationBits,
+			&aggregationBitsCompressed,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -259,12 +371,19 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 			&attestation.TargetEpoch,
 			&targetRoot,
 			&canonical,
+			&sourceCorrect,
 			&targetCorrect,
 			&headCorrect,
+			&duplicate,
+			&conflicting,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		attestation.AggregationBits, err = expandedBitfield(attestation.AggregationBits, aggregationBitsCompressed)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to expand aggregation bits")
+		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
@@ -277,6 +396,10 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 			val := canonical.Bool
 			attestation.Canonical = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		if targetCorrect.Valid {
 			val := targetCorrect.Bool
 			attestation.TargetCorrect = &val
@@ -285,6 +408,14 @@ func (s *Service) AttestationsInBlock(ctx context.Context, blockRoot phase0.Root
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if duplicate.Valid {
+			val := duplicate.Bool
+			attestation.Duplicate = &val
+		}
+		if conflicting.Valid {
+			val := conflicting.Bool
+			attestation.Conflicting = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -312,6 +443,7 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
+            ,f_aggregation_bits_compressed
             ,f_aggregation_indices
             ,f_beacon_block_root
             ,f_source_epoch
@@ -319,8 +451,11 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
             ,f_target_epoch
             ,f_target_root
             ,f_canonical
+            ,f_source_correct
             ,f_target_correct
             ,f_head_correct
+            ,f_duplicate
+            ,f_conflicting
       FROM t_attestations
       WHERE f_slot >= $1
         AND f_slot < $2
@@ -339,13 +474,17 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBitsCompressed []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
 		var targetRoot []byte
 		var canonical sql.NullBool
+		var sourceCorrect sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var duplicate sql.NullBool
+		var conflicting sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
@@ -353,6 +492,7 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
 			&attestation.AggregationBits,
+			&aggregationBitsCompressed,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -360,12 +500,19 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 			&attestation.TargetEpoch,
 			&targetRoot,
 			&canonical,
+			&sourceCorrect,
 			&targetCorrect,
 			&headCorrect,
+			&duplicate,
+			&conflicting,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		attestation.AggregationBits, err = expandedBitfield(attestation.AggregationBits, aggregationBitsCompressed)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to expand aggregation bits")
+		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
@@ -378,6 +525,10 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 			val := canonical.Bool
 			attestation.Canonical = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		if targetCorrect.Valid {
 			val := targetCorrect.Bool
 			attestation.TargetCorrect = &val
@@ -386,6 +537,14 @@ func (s *Service) AttestationsForSlotRange(ctx context.Context, startSlot phase0
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if duplicate.Valid {
+			val := duplicate.Bool
+			attestation.Duplicate = &val
+		}
+		if conflicting.Valid {
+			val := conflicting.Bool
+			attestation.Conflicting = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -413,6 +572,7 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
             ,f_slot
             ,f_committee_index
             ,f_aggregation_bits
+            ,f_aggregation_bits_compressed
             ,f_aggregation_indices
             ,f_beacon_block_root
             ,f_source_epoch
@@ -420,8 +580,11 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
             ,f_target_epoch
             ,f_target_root
             ,f_canonical
+            ,f_source_correct
             ,f_target_correct
             ,f_head_correct
+            ,f_duplicate
+            ,f_conflicting
       FROM t_attestations
       WHERE f_inclusion_slot >= $1
         AND f_inclusion_slot < $2
@@ -440,13 +603,17 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 	for rows.Next() {
 		attestation := &chaindb.Attestation{}
 		var inclusionBlockRoot []byte
+		var aggregationBitsCompressed []byte
 		var aggregationIndices []uint64
 		var beaconBlockRoot []byte
 		var sourceRoot []byte
 		var targetRoot []byte
 		var canonical sql.NullBool
+		var sourceCorrect sql.NullBool
 		var targetCorrect sql.NullBool
 		var headCorrect sql.NullBool
+		var duplicate sql.NullBool
+		var conflicting sql.NullBool
 		err := rows.Scan(
 			&attestation.InclusionSlot,
 			&inclusionBlockRoot,
@@ -454,6 +621,7 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 			&attestation.Slot,
 			&attestation.CommitteeIndex,
 			&attestation.AggregationBits,
+			&aggregationBitsCompressed,
 			&aggregationIndices,
 			&beaconBlockRoot,
 			&attestation.SourceEpoch,
@@ -461,12 +629,19 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 			&attestation.TargetEpoch,
 			&targetRoot,
 			&canonical,
+			&sourceCorrect,
 			&targetCorrect,
 			&headCorrect,
+			&duplicate,
+			&conflicting,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
+		attestation.AggregationBits, err = expandedBitfield(attestation.AggregationBits, aggregationBitsCompressed)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to expand aggregation bits")
+		}
 		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
 		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
 		for i := range aggregationIndices {
@@ -479,6 +654,10 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 			val := canonical.Bool
 			attestation.Canonical = &val
 		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
 		if targetCorrect.Valid {
 			val := targetCorrect.Bool
 			attestation.TargetCorrect = &val
@@ -487,6 +666,14 @@ func (s *Service) AttestationsInSlotRange(ctx context.Context, startSlot phase0.
 			val := headCorrect.Bool
 			attestation.HeadCorrect = &val
 		}
+		if duplicate.Valid {
+			val := duplicate.Bool
+			attestation.Duplicate = &val
+		}
+		if conflicting.Valid {
+			val := conflicting.Bool
+			attestation.Conflicting = &val
+		}
 		attestations = append(attestations, attestation)
 	}
 
@@ -533,3 +720,200 @@ func (s *Service) IndeterminateAttestationSlots(ctx context.Context, minSlot pha
 
 	return slots, nil
 }
+
+// Attestations provides attestations according to the filter.
+func (s *Service) Attestations(ctx context.Context, filter *chaindb.AttestationFilter) ([]*chaindb.Attestation, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_inclusion_slot
+      ,f_inclusion_block_root
+      ,f_inclusion_index
+      ,f_slot
+      ,f_committee_index
+      ,f_aggregation_bits
+      ,f_aggregation_bits_compressed
+      ,f_aggregation_indices
+      ,f_beacon_block_root
+      ,f_source_epoch
+      ,f_source_root
+      ,f_target_epoch
+      ,f_target_root
+      ,f_canonical
+      ,f_source_correct
+      ,f_target_correct
+      ,f_head_correct
+      ,f_duplicate
+      ,f_conflicting
+FROM t_attestations`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_inclusion_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_inclusion_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Canonical != nil {
+		queryVals = append(queryVals, *filter.Canonical)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_canonical = $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, *filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_aggregation_indices && $%d::BIGINT[]`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Cursor != nil {
+		comparison := ">"
+		if filter.Order == chaindb.OrderLatest {
+			comparison = "<"
+		}
+		queryVals = append(queryVals, filter.Cursor.InclusionSlot, filter.Cursor.InclusionBlockRoot[:], filter.Cursor.InclusionIndex)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) %s ($%d,$%d,$%d)`, wherestr, comparison, len(queryVals)-2, len(queryVals)-1, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot,f_inclusion_block_root,f_inclusion_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_inclusion_slot DESC,f_inclusion_block_root DESC,f_inclusion_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attestations := make([]*chaindb.Attestation, 0)
+	for rows.Next() {
+		attestation := &chaindb.Attestation{}
+		var inclusionBlockRoot []byte
+		var aggregationBitsCompressed []byte
+		var aggregationIndices []uint64
+		var beaconBlockRoot []byte
+		var sourceRoot []byte
+		var targetRoot []byte
+		var canonical sql.NullBool
+		var sourceCorrect sql.NullBool
+		var targetCorrect sql.NullBool
+		var headCorrect sql.NullBool
+		var duplicate sql.NullBool
+		var conflicting sql.NullBool
+		err := rows.Scan(
+			&attestation.InclusionSlot,
+			&inclusionBlockRoot,
+			&attestation.InclusionIndex,
+			&attestation.Slot,
+			&attestation.CommitteeIndex,
+			&attestation.AggregationBits,
+			&aggregationBitsCompressed,
+			&aggregationIndices,
+			&beaconBlockRoot,
+			&attestation.SourceEpoch,
+			&sourceRoot,
+			&attestation.TargetEpoch,
+			&targetRoot,
+			&canonical,
+			&sourceCorrect,
+			&targetCorrect,
+			&headCorrect,
+			&duplicate,
+			&conflicting,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		attestation.AggregationBits, err = expandedBitfield(attestation.AggregationBits, aggregationBitsCompressed)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to expand aggregation bits")
+		}
+		copy(attestation.InclusionBlockRoot[:], inclusionBlockRoot)
+		attestation.AggregationIndices = make([]phase0.ValidatorIndex, len(aggregationIndices))
+		for i := range aggregationIndices {
+			attestation.AggregationIndices[i] = phase0.ValidatorIndex(aggregationIndices[i])
+		}
+		copy(attestation.BeaconBlockRoot[:], beaconBlockRoot)
+		copy(attestation.SourceRoot[:], sourceRoot)
+		copy(attestation.TargetRoot[:], targetRoot)
+		if canonical.Valid {
+			val := canonical.Bool
+			attestation.Canonical = &val
+		}
+		if sourceCorrect.Valid {
+			val := sourceCorrect.Bool
+			attestation.SourceCorrect = &val
+		}
+		if targetCorrect.Valid {
+			val := targetCorrect.Bool
+			attestation.TargetCorrect = &val
+		}
+		if headCorrect.Valid {
+			val := headCorrect.Bool
+			attestation.HeadCorrect = &val
+		}
+		if duplicate.Valid {
+			val := duplicate.Bool
+			attestation.Duplicate = &val
+		}
+		if conflicting.Valid {
+			val := conflicting.Bool
+			attestation.Conflicting = &val
+		}
+		attestations = append(attestations, attestation)
+	}
+
+	// Always return order of inclusion slot, inclusion block root then inclusion index.
+	sort.Slice(attestations, func(i int, j int) bool {
+		if attestations[i].InclusionSlot != attestations[j].InclusionSlot {
+			return attestations[i].InclusionSlot < attestations[j].InclusionSlot
+		}
+		if order := bytes.Compare(attestations[i].InclusionBlockRoot[:], attestations[j].InclusionBlockRoot[:]); order != 0 {
+			return order < 0
+		}
+		return attestations[i].InclusionIndex < attestations[j].InclusionIndex
+	})
+	return attestations, nil
+}
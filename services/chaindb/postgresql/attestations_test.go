@@ -0,0 +1,86 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestSetAttestations(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	block := &chaindb.Block{
+		Slot: 10,
+		Root: phase0.Root{
+			0xa0, 0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xab, 0xac, 0xad, 0xae, 0xaf,
+			0xa0, 0xa1, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xab, 0xac, 0xad, 0xae, 0xaf,
+		},
+		Graffiti:      []byte{},
+		ETH1BlockHash: []byte{},
+	}
+	attestations := make([]*chaindb.Attestation, 0)
+	for i := uint64(0); i < 3; i++ {
+		attestations = append(attestations, &chaindb.Attestation{
+			InclusionSlot:      block.Slot,
+			InclusionBlockRoot: block.Root,
+			InclusionIndex:     i,
+			Slot:               block.Slot - 1,
+			CommitteeIndex:     phase0.CommitteeIndex(i),
+			AggregationBits:    []byte{0x03},
+			AggregationIndices: []phase0.ValidatorIndex{phase0.ValidatorIndex(i), phase0.ValidatorIndex(i + 10)},
+		})
+	}
+
+	// Attempt to set the attestations without a transaction; should fail.
+	require.Error(t, s.SetAttestations(ctx, attestations))
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	require.NoError(t, s.SetBlock(ctx, block))
+	require.NoError(t, s.SetAttestations(ctx, attestations))
+
+	res, err := s.AttestationsInBlock(ctx, block.Root)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+
+	// Setting the attestations again updates them.
+	canonical := true
+	for _, attestation := range attestations {
+		attestation.Canonical = &canonical
+	}
+	require.NoError(t, s.SetAttestations(ctx, attestations))
+	res, err = s.AttestationsInBlock(ctx, block.Root)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	for _, attestation := range res {
+		require.NotNil(t, attestation.Canonical)
+		require.True(t, *attestation.Canonical)
+		require.Equal(t, []phase0.ValidatorIndex{phase0.ValidatorIndex(attestation.InclusionIndex), phase0.ValidatorIndex(attestation.InclusionIndex + 10)}, attestation.AggregationIndices)
+	}
+}
@@ -15,6 +15,7 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -28,6 +29,17 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
 		return ErrNoTransaction
 	}
 
+	var inclusionProposerIndex sql.NullInt64
+	if attesterSlashing.InclusionProposerIndex != nil {
+		inclusionProposerIndex.Valid = true
+		inclusionProposerIndex.Int64 = int64(*attesterSlashing.InclusionProposerIndex)
+	}
+	var whistleblowerReward sql.NullInt64
+	if attesterSlashing.WhistleblowerReward != nil {
+		whistleblowerReward.Valid = true
+		whistleblowerReward.Int64 = int64(*attesterSlashing.WhistleblowerReward)
+	}
+
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_attester_slashings(f_inclusion_slot
                                       ,f_inclusion_block_root
@@ -50,8 +62,11 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
                                       ,f_attestation_2_target_epoch
                                       ,f_attestation_2_target_root
                                       ,f_attestation_2_signature
+                                      ,f_inclusion_proposer_index
+                                      ,f_slashed_indices
+                                      ,f_whistleblower_reward
       )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24)
       ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
       UPDATE
       SET f_attestation_1_indices = excluded.f_attestation_1_indices
@@ -72,6 +87,9 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
          ,f_attestation_2_target_epoch = excluded.f_attestation_2_target_epoch
          ,f_attestation_2_target_root = excluded.f_attestation_2_target_root
          ,f_attestation_2_signature = excluded.f_attestation_2_signature
+         ,f_inclusion_proposer_index = excluded.f_inclusion_proposer_index
+         ,f_slashed_indices = excluded.f_slashed_indices
+         ,f_whistleblower_reward = excluded.f_whistleblower_reward
       `,
 		attesterSlashing.InclusionSlot,
 		attesterSlashing.InclusionBlockRoot[:],
@@ -94,6 +112,9 @@ func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *cha
 		attesterSlashing.Attestation2TargetEpoch,
 		attesterSlashing.Attestation2TargetRoot[:],
 		attesterSlashing.Attestation2Signature[:],
+		inclusionProposerIndex,
+		attesterSlashing.SlashedIndices,
+		whistleblowerReward,
 	)
 
 	return err
@@ -134,6 +155,9 @@ func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot pha
             ,f_attestation_2_target_epoch
             ,f_attestation_2_target_root
             ,f_attestation_2_signature
+            ,f_inclusion_proposer_index
+            ,f_slashed_indices
+            ,f_whistleblower_reward
       FROM t_attester_slashings
       WHERE f_inclusion_slot >= $1
         AND f_inclusion_slot < $2
@@ -161,6 +185,9 @@ func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot pha
 	var attestation2SourceRoot []byte
 	var attestation2TargetRoot []byte
 	var attestation2Signature []byte
+	var inclusionProposerIndex sql.NullInt64
+	var slashedIndices []uint64
+	var whistleblowerReward sql.NullInt64
 	for rows.Next() {
 		attesterSlashing := &chaindb.AttesterSlashing{}
 		err := rows.Scan(
@@ -185,6 +212,9 @@ func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot pha
 			&attesterSlashing.Attestation2TargetEpoch,
 			&attestation2TargetRoot,
 			&attestation2Signature,
+			&inclusionProposerIndex,
+			&slashedIndices,
+			&whistleblowerReward,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -206,6 +236,20 @@ func (s *Service) AttesterSlashingsForSlotRange(ctx context.Context, minSlot pha
 		copy(attesterSlashing.Attestation2SourceRoot[:], attestation2SourceRoot)
 		copy(attesterSlashing.Attestation2TargetRoot[:], attestation2TargetRoot)
 		copy(attesterSlashing.Attestation2Signature[:], attestation2Signature)
+		if inclusionProposerIndex.Valid {
+			tmp := phase0.ValidatorIndex(inclusionProposerIndex.Int64)
+			attesterSlashing.InclusionProposerIndex = &tmp
+		}
+		if slashedIndices != nil {
+			attesterSlashing.SlashedIndices = make([]phase0.ValidatorIndex, len(slashedIndices))
+			for i := range slashedIndices {
+				attesterSlashing.SlashedIndices[i] = phase0.ValidatorIndex(slashedIndices[i])
+			}
+		}
+		if whistleblowerReward.Valid {
+			tmp := phase0.Gwei(whistleblowerReward.Int64)
+			attesterSlashing.WhistleblowerReward = &tmp
+		}
 		attesterSlashings = append(attesterSlashings, attesterSlashing)
 	}
 
@@ -247,6 +291,9 @@ func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase
             ,f_attestation_2_target_epoch
             ,f_attestation_2_target_root
             ,f_attestation_2_signature
+            ,f_inclusion_proposer_index
+            ,f_slashed_indices
+            ,f_whistleblower_reward
       FROM t_attester_slashings
       WHERE $1 = ANY(f_attestation_1_indices)
         AND $1 = ANY(f_attestation_2_indices)
@@ -272,6 +319,9 @@ func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase
 	var attestation2SourceRoot []byte
 	var attestation2TargetRoot []byte
 	var attestation2Signature []byte
+	var inclusionProposerIndex sql.NullInt64
+	var slashedIndices []uint64
+	var whistleblowerReward sql.NullInt64
 	for rows.Next() {
 		attesterSlashing := &chaindb.AttesterSlashing{}
 		err := rows.Scan(
@@ -296,6 +346,9 @@ func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase
 			&attesterSlashing.Attestation2TargetEpoch,
 			&attestation2TargetRoot,
 			&attestation2Signature,
+			&inclusionProposerIndex,
+			&slashedIndices,
+			&whistleblowerReward,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -317,6 +370,20 @@ func (s *Service) AttesterSlashingsForValidator(ctx context.Context, index phase
 		copy(attesterSlashing.Attestation2SourceRoot[:], attestation2SourceRoot)
 		copy(attesterSlashing.Attestation2TargetRoot[:], attestation2TargetRoot)
 		copy(attesterSlashing.Attestation2Signature[:], attestation2Signature)
+		if inclusionProposerIndex.Valid {
+			tmp := phase0.ValidatorIndex(inclusionProposerIndex.Int64)
+			attesterSlashing.InclusionProposerIndex = &tmp
+		}
+		if slashedIndices != nil {
+			attesterSlashing.SlashedIndices = make([]phase0.ValidatorIndex, len(slashedIndices))
+			for i := range slashedIndices {
+				attesterSlashing.SlashedIndices[i] = phase0.ValidatorIndex(slashedIndices[i])
+			}
+		}
+		if whistleblowerReward.Valid {
+			tmp := phase0.Gwei(whistleblowerReward.Int64)
+			attesterSlashing.WhistleblowerReward = &tmp
+		}
 		attesterSlashings = append(attesterSlashings, attesterSlashing)
 	}
 
@@ -0,0 +1,37 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"github.com/wealdtech/chaind/util"
+)
+
+// bitfieldArgs returns the arguments for the raw and compressed columns of a
+// bitfield, only one of which is set.
+func (s *Service) bitfieldArgs(bitfield []byte) ([]byte, []byte) {
+	if s.compressBitfields {
+		return nil, util.CompressBitfield(bitfield)
+	}
+
+	return bitfield, nil
+}
+
+// expandedBitfield returns the bitfield from its raw and compressed columns.
+func expandedBitfield(bitfield []byte, compressed []byte) ([]byte, error) {
+	if bitfield != nil || compressed == nil {
+		return bitfield, nil
+	}
+
+	return util.ExpandBitfield(compressed)
+}
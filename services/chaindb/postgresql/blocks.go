@@ -14,8 +14,13 @@
 package postgresql
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -34,6 +39,23 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
 		canonical.Valid = true
 		canonical.Bool = *block.Canonical
 	}
+	// Provenance is retained if not supplied, as blocks are also set when
+	// updating their canonical status.
+	var provenanceAddress sql.NullString
+	var provenanceVersion sql.NullString
+	if block.Provenance != nil {
+		provenanceAddress.Valid = true
+		provenanceAddress.String = block.Provenance.Address
+		provenanceVersion.Valid = true
+		provenanceVersion.String = block.Provenance.Version
+	}
+	// Seen delay is similarly retained, as it is only known for blocks
+	// obtained when they were first seen.
+	var seenDelay sql.NullInt64
+	if block.SeenDelay != nil {
+		seenDelay.Valid = true
+		seenDelay.Int64 = block.SeenDelay.Milliseconds()
+	}
 	if _, err := tx.Exec(ctx, `
       INSERT INTO t_blocks(f_slot
                           ,f_proposer_index
@@ -47,8 +69,11 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
                           ,f_eth1_block_hash
                           ,f_eth1_deposit_count
                           ,f_eth1_deposit_root
+                          ,f_provenance_address
+                          ,f_provenance_version
+                          ,f_seen_delay
 						  )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
       ON CONFLICT (f_root) DO
       UPDATE
       SET f_slot = excluded.f_slot
@@ -62,6 +87,9 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
          ,f_eth1_block_hash = excluded.f_eth1_block_hash
          ,f_eth1_deposit_count = excluded.f_eth1_deposit_count
          ,f_eth1_deposit_root = excluded.f_eth1_deposit_root
+         ,f_provenance_address = COALESCE(excluded.f_provenance_address,t_blocks.f_provenance_address)
+         ,f_provenance_version = COALESCE(excluded.f_provenance_version,t_blocks.f_provenance_version)
+         ,f_seen_delay = COALESCE(excluded.f_seen_delay,t_blocks.f_seen_delay)
 	  `,
 		block.Slot,
 		block.ProposerIndex,
@@ -75,6 +103,9 @@ func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
 		block.ETH1BlockHash,
 		block.ETH1DepositCount,
 		block.ETH1DepositRoot[:],
+		provenanceAddress,
+		provenanceVersion,
+		seenDelay,
 	); err != nil {
 		return err
 	}
@@ -110,6 +141,9 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_provenance_address
+            ,f_provenance_version
+            ,f_seen_delay
       FROM t_blocks
       WHERE f_slot = $1`,
 		slot,
@@ -130,6 +164,9 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var provenanceAddress sql.NullString
+		var provenanceVersion sql.NullString
+		var seenDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -143,6 +180,9 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&provenanceAddress,
+			&provenanceVersion,
+			&seenDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -157,6 +197,11 @@ func (s *Service) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaind
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		block.Provenance = dbProvenance(provenanceAddress, provenanceVersion)
+		if seenDelay.Valid {
+			val := time.Duration(seenDelay.Int64) * time.Millisecond
+			block.SeenDelay = &val
+		}
 		blocks = append(blocks, block)
 	}
 
@@ -200,6 +245,9 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_provenance_address
+            ,f_provenance_version
+            ,f_seen_delay
       FROM t_blocks
       WHERE f_slot >= $1
         AND f_slot < $2
@@ -222,6 +270,9 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var provenanceAddress sql.NullString
+		var provenanceVersion sql.NullString
+		var seenDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -235,6 +286,9 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&provenanceAddress,
+			&provenanceVersion,
+			&seenDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -249,6 +303,11 @@ func (s *Service) BlocksForSlotRange(ctx context.Context, startSlot phase0.Slot,
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		block.Provenance = dbProvenance(provenanceAddress, provenanceVersion)
+		if seenDelay.Valid {
+			val := time.Duration(seenDelay.Int64) * time.Millisecond
+			block.SeenDelay = &val
+		}
 		blocks = append(blocks, block)
 	}
 
@@ -285,6 +344,9 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 	var stateRoot []byte
 	var canonical sql.NullBool
 	var eth1DepositRoot []byte
+	var provenanceAddress sql.NullString
+	var provenanceVersion sql.NullString
+	var seenDelay sql.NullInt64
 
 	err = tx.QueryRow(ctx, `
       SELECT f_slot
@@ -299,6 +361,9 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_provenance_address
+            ,f_provenance_version
+            ,f_seen_delay
       FROM t_blocks
       WHERE f_root = $1`,
 		root[:],
@@ -315,6 +380,9 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 		&block.ETH1BlockHash,
 		&block.ETH1DepositCount,
 		&eth1DepositRoot,
+		&provenanceAddress,
+		&provenanceVersion,
+		&seenDelay,
 	)
 	if err != nil {
 		return nil, err
@@ -329,6 +397,11 @@ func (s *Service) BlockByRoot(ctx context.Context, root phase0.Root) (*chaindb.B
 		block.Canonical = &val
 	}
 	copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+	block.Provenance = dbProvenance(provenanceAddress, provenanceVersion)
+	if seenDelay.Valid {
+		val := time.Duration(seenDelay.Int64) * time.Millisecond
+		block.SeenDelay = &val
+	}
 
 	// Add execution payload to the block if available.
 	block.ExecutionPayload, err = s.executionPayload(ctx, tx, block.Root)
@@ -413,6 +486,9 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_provenance_address
+            ,f_provenance_version
+            ,f_seen_delay
       FROM t_blocks
       WHERE f_parent_root = $1`,
 		parentRoot[:],
@@ -432,6 +508,9 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var provenanceAddress sql.NullString
+		var provenanceVersion sql.NullString
+		var seenDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -445,6 +524,9 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&provenanceAddress,
+			&provenanceVersion,
+			&seenDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -459,6 +541,11 @@ func (s *Service) BlocksByParentRoot(ctx context.Context, parentRoot phase0.Root
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		block.Provenance = dbProvenance(provenanceAddress, provenanceVersion)
+		if seenDelay.Valid {
+			val := time.Duration(seenDelay.Int64) * time.Millisecond
+			block.SeenDelay = &val
+		}
 		blocks = append(blocks, block)
 	}
 
@@ -585,6 +672,9 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
             ,f_eth1_block_hash
             ,f_eth1_deposit_count
             ,f_eth1_deposit_root
+            ,f_provenance_address
+            ,f_provenance_version
+            ,f_seen_delay
       FROM t_blocks
       WHERE f_slot = (SELECT MAX(f_slot) FROM t_blocks)`)
 	if err != nil {
@@ -603,6 +693,9 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 		var stateRoot []byte
 		var canonical sql.NullBool
 		var eth1DepositRoot []byte
+		var provenanceAddress sql.NullString
+		var provenanceVersion sql.NullString
+		var seenDelay sql.NullInt64
 		err := rows.Scan(
 			&block.Slot,
 			&block.ProposerIndex,
@@ -616,6 +709,9 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 			&block.ETH1BlockHash,
 			&block.ETH1DepositCount,
 			&eth1DepositRoot,
+			&provenanceAddress,
+			&provenanceVersion,
+			&seenDelay,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -630,6 +726,11 @@ func (s *Service) LatestBlocks(ctx context.Context) ([]*chaindb.Block, error) {
 			block.Canonical = &val
 		}
 		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		block.Provenance = dbProvenance(provenanceAddress, provenanceVersion)
+		if seenDelay.Valid {
+			val := time.Duration(seenDelay.Int64) * time.Millisecond
+			block.SeenDelay = &val
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -709,3 +810,174 @@ func (s *Service) ProposalCount(ctx context.Context, validatorIndices []phase0.V
 
 	return proposals, nil
 }
+
+// Blocks provides blocks according to the filter.
+func (s *Service) Blocks(ctx context.Context, filter *chaindb.BlockFilter) ([]*chaindb.Block, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_slot
+      ,f_proposer_index
+      ,f_root
+      ,f_graffiti
+      ,f_randao_reveal
+      ,f_body_root
+      ,f_parent_root
+      ,f_state_root
+      ,f_canonical
+      ,f_eth1_block_hash
+      ,f_eth1_deposit_count
+      ,f_eth1_deposit_root
+      ,f_provenance_address
+      ,f_provenance_version
+      ,f_seen_delay
+FROM t_blocks`)
+
+	wherestr := "WHERE"
+
+	if filter.From != nil {
+		queryVals = append(queryVals, *filter.From)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot >= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.To != nil {
+		queryVals = append(queryVals, *filter.To)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slot <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Canonical != nil {
+		queryVals = append(queryVals, *filter.Canonical)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_canonical = $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.ProposerIndices != nil && len(*filter.ProposerIndices) > 0 {
+		queryVals = append(queryVals, *filter.ProposerIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_proposer_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Cursor != nil {
+		comparison := ">"
+		if filter.Order == chaindb.OrderLatest {
+			comparison = "<"
+		}
+		queryVals = append(queryVals, filter.Cursor.Slot, filter.Cursor.Root[:])
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s (f_slot,f_root) %s ($%d,$%d)`, wherestr, comparison, len(queryVals)-1, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot,f_root`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_slot DESC,f_root DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocks := make([]*chaindb.Block, 0)
+	for rows.Next() {
+		block := &chaindb.Block{}
+		var blockRoot []byte
+		var randaoReveal []byte
+		var bodyRoot []byte
+		var parentRoot []byte
+		var stateRoot []byte
+		var canonical sql.NullBool
+		var eth1DepositRoot []byte
+		var provenanceAddress sql.NullString
+		var provenanceVersion sql.NullString
+		var seenDelay sql.NullInt64
+		err := rows.Scan(
+			&block.Slot,
+			&block.ProposerIndex,
+			&blockRoot,
+			&block.Graffiti,
+			&randaoReveal,
+			&bodyRoot,
+			&parentRoot,
+			&stateRoot,
+			&canonical,
+			&block.ETH1BlockHash,
+			&block.ETH1DepositCount,
+			&eth1DepositRoot,
+			&provenanceAddress,
+			&provenanceVersion,
+			&seenDelay,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(block.Root[:], blockRoot)
+		copy(block.RANDAOReveal[:], randaoReveal)
+		copy(block.BodyRoot[:], bodyRoot)
+		copy(block.ParentRoot[:], parentRoot)
+		copy(block.StateRoot[:], stateRoot)
+		if canonical.Valid {
+			val := canonical.Bool
+			block.Canonical = &val
+		}
+		copy(block.ETH1DepositRoot[:], eth1DepositRoot)
+		block.Provenance = dbProvenance(provenanceAddress, provenanceVersion)
+		if seenDelay.Valid {
+			val := time.Duration(seenDelay.Int64) * time.Millisecond
+			block.SeenDelay = &val
+		}
+		blocks = append(blocks, block)
+	}
+
+	// Add execution payload to the blocks where available.
+	for _, block := range blocks {
+		block.ExecutionPayload, err = s.executionPayload(ctx, tx, block.Root)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Always return order of slot then root.
+	sort.Slice(blocks, func(i int, j int) bool {
+		if blocks[i].Slot != blocks[j].Slot {
+			return blocks[i].Slot < blocks[j].Slot
+		}
+		return bytes.Compare(blocks[i].Root[:], blocks[j].Root[:]) < 0
+	})
+	return blocks, nil
+}
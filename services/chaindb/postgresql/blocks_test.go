@@ -109,3 +109,70 @@ func TestIndeterminateBlocks(t *testing.T) {
 	require.NotNil(t, dbBlock.Canonical)
 	require.True(t, *dbBlock.Canonical)
 }
+
+func TestBlocksFilter(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	canonical := true
+	for i := 1; i <= 4; i++ {
+		block := &chaindb.Block{
+			Slot:          phase0.Slot(1000000 + i),
+			ProposerIndex: phase0.ValidatorIndex(i % 2),
+			Root:          phase0.Root{0xf0, byte(i)},
+			Graffiti:      []byte{},
+			ETH1BlockHash: []byte{},
+			Canonical:     &canonical,
+		}
+		require.NoError(t, s.SetBlock(ctx, block))
+	}
+
+	from := phase0.Slot(1000001)
+	to := phase0.Slot(1000004)
+
+	// Fetch the first page.
+	blocks, err := s.Blocks(ctx, &chaindb.BlockFilter{
+		Limit: 3,
+		From:  &from,
+		To:    &to,
+	})
+	require.NoError(t, err)
+	require.Len(t, blocks, 3)
+	require.Equal(t, phase0.Slot(1000001), blocks[0].Slot)
+	require.Equal(t, phase0.Slot(1000003), blocks[2].Slot)
+
+	// Fetch the next page using the cursor.
+	blocks, err = s.Blocks(ctx, &chaindb.BlockFilter{
+		Limit: 3,
+		From:  &from,
+		To:    &to,
+		Cursor: &chaindb.BlockCursor{
+			Slot: blocks[2].Slot,
+			Root: blocks[2].Root,
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+	require.Equal(t, phase0.Slot(1000004), blocks[0].Slot)
+
+	// Fetch the latest blocks for a proposer; results are still returned in ascending order.
+	proposers := []phase0.ValidatorIndex{1}
+	blocks, err = s.Blocks(ctx, &chaindb.BlockFilter{
+		Order:           chaindb.OrderLatest,
+		From:            &from,
+		To:              &to,
+		ProposerIndices: &proposers,
+	})
+	require.NoError(t, err)
+	require.Len(t, blocks, 2)
+	require.Equal(t, phase0.Slot(1000001), blocks[0].Slot)
+	require.Equal(t, phase0.Slot(1000003), blocks[1].Slot)
+}
@@ -0,0 +1,53 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// passwordFromFile returns a function that sets the password of a connection
+// from the contents of a file.  It is called for each new connection, so a
+// password that is rotated in the file is used for subsequent connections;
+// existing connections are unaffected.
+func passwordFromFile(path string) func(context.Context, *pgx.ConnConfig) error {
+	return func(_ context.Context, config *pgx.ConnConfig) error {
+		password, err := readPasswordFile(path)
+		if err != nil {
+			return err
+		}
+		config.Password = password
+
+		return nil
+	}
+}
+
+// readPasswordFile reads a password from a file, ignoring surrounding whitespace.
+func readPasswordFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read password file")
+	}
+	password := strings.TrimSpace(string(data))
+	if password == "" {
+		return "", errors.New("password file is empty")
+	}
+
+	return password, nil
+}
@@ -0,0 +1,44 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordFromFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "password")
+	config := &pgx.ConnConfig{}
+
+	require.EqualError(t, passwordFromFile(path)(ctx, config), "failed to read password file: open "+path+": no such file or directory")
+
+	require.NoError(t, os.WriteFile(path, []byte("\n"), 0o600))
+	require.EqualError(t, passwordFromFile(path)(ctx, config), "password file is empty")
+
+	require.NoError(t, os.WriteFile(path, []byte("secret\n"), 0o600))
+	require.NoError(t, passwordFromFile(path)(ctx, config))
+	require.Equal(t, "secret", config.Password)
+
+	// Rotated password is picked up by the next connection.
+	require.NoError(t, os.WriteFile(path, []byte("rotated"), 0o600))
+	require.NoError(t, passwordFromFile(path)(ctx, config))
+	require.Equal(t, "rotated", config.Password)
+}
@@ -0,0 +1,87 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const (
+	// commitLatencyWeight is the weight given to each new commit latency in the
+	// moving average.
+	commitLatencyWeight = 0.1
+	// commitLatencyHalfLife is the time over which the moving average of commit
+	// latencies halves when there are no commits, so that a period of high
+	// latency is forgotten if the transactions that would measure it are held
+	// back.
+	commitLatencyHalfLife = 30 * time.Second
+)
+
+// DatabaseLoad fetches the current load on the database.
+func (s *Service) DatabaseLoad(ctx context.Context) (*chaindb.DatabaseLoad, error) {
+	stat := s.pool.Stat()
+	load := &chaindb.DatabaseLoad{
+		CommitLatency:  s.averageCommitLatency(),
+		Connections:    int(stat.AcquiredConns()),
+		MaxConnections: int(stat.MaxConns()),
+	}
+
+	// Replication lag is only visible to users with the pg_monitor role;
+	// for others it is null and so treated as no lag.
+	var lag float64
+	if err := s.pool.QueryRow(ctx, `
+SELECT COALESCE(EXTRACT(EPOCH FROM MAX(replay_lag)),0)::FLOAT
+FROM pg_stat_replication`).Scan(&lag); err != nil {
+		return nil, err
+	}
+	load.ReplicationLag = time.Duration(lag * float64(time.Second))
+
+	return load, nil
+}
+
+// recordCommitLatency adds the time taken to commit a transaction to the
+// moving average.
+func (s *Service) recordCommitLatency(latency time.Duration) {
+	s.commitLatencyMu.Lock()
+	defer s.commitLatencyMu.Unlock()
+
+	average := s.decayedCommitLatency(time.Now())
+	s.commitLatency = time.Duration((1-commitLatencyWeight)*float64(average) + commitLatencyWeight*float64(latency))
+	s.commitLatencyUpdated = time.Now()
+}
+
+// averageCommitLatency returns the moving average of the time taken to commit
+// a transaction.
+func (s *Service) averageCommitLatency() time.Duration {
+	s.commitLatencyMu.Lock()
+	defer s.commitLatencyMu.Unlock()
+
+	return s.decayedCommitLatency(time.Now())
+}
+
+// decayedCommitLatency returns the moving average of commit latencies as of
+// the given time, decayed for the time since it was last updated.
+// This requires commitLatencyMu to be held.
+func (s *Service) decayedCommitLatency(now time.Time) time.Duration {
+	if s.commitLatencyUpdated.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(s.commitLatencyUpdated)
+
+	return time.Duration(float64(s.commitLatency) * math.Pow(0.5, elapsed.Seconds()/commitLatencyHalfLife.Seconds()))
+}
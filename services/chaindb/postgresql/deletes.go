@@ -0,0 +1,93 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// DeleteBlocks deletes the blocks in the given slot range, along with the data contained within them.
+func (s *Service) DeleteBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Data contained within blocks is removed by cascading deletes.
+	_, err := tx.Exec(ctx, `
+      DELETE FROM t_blocks
+      WHERE f_slot >= $1
+        AND f_slot <= $2`,
+		minSlot,
+		maxSlot,
+	)
+
+	return err
+}
+
+// DeleteBeaconCommittees deletes the beacon committees in the given slot range.
+func (s *Service) DeleteBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      DELETE FROM t_beacon_committees
+      WHERE f_slot >= $1
+        AND f_slot <= $2`,
+		minSlot,
+		maxSlot,
+	)
+
+	return err
+}
+
+// DeleteProposerDuties deletes the proposer duties in the given slot range.
+func (s *Service) DeleteProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      DELETE FROM t_proposer_duties
+      WHERE f_slot >= $1
+        AND f_slot <= $2`,
+		minSlot,
+		maxSlot,
+	)
+
+	return err
+}
+
+// DeleteValidatorBalances deletes the validator balances in the given epoch range.
+func (s *Service) DeleteValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      DELETE FROM t_validator_balances
+      WHERE f_epoch >= $1
+        AND f_epoch <= $2`,
+		minEpoch,
+		maxEpoch,
+	)
+
+	return err
+}
@@ -0,0 +1,345 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// checksumDataset defines how the rows of a dataset for an epoch are obtained for checksumming.
+type checksumDataset struct {
+	// query selects the rows of the dataset in a deterministic order.  Its
+	// parameters are the start (inclusive) and end (exclusive) of the range,
+	// and its first column is the text of the row.  If bitfield is set the
+	// second and third columns are the raw and compressed forms of a bitfield,
+	// which are expanded before being checksummed so that the checksum does not
+	// depend on how bitfields are stored.
+	query    string
+	bitfield bool
+	// bySlot is true if the range is of slots rather than epochs.
+	bySlot bool
+}
+
+// checksumDatasets are the datasets for which checksums can be computed.  Data
+// that depends on the instance rather than the chain, such as provenance and
+// aggregation indices derived from stored bitfields, is not included.
+var checksumDatasets = map[string]*checksumDataset{
+	"blocks": {
+		query: `
+      SELECT ROW(f_slot
+                ,f_proposer_index
+                ,f_root
+                ,f_graffiti
+                ,f_randao_reveal
+                ,f_body_root
+                ,f_parent_root
+                ,f_state_root
+                ,f_canonical
+                ,f_eth1_block_hash
+                ,f_eth1_deposit_count
+                ,f_eth1_deposit_root
+                )::TEXT
+      FROM t_blocks
+      WHERE f_slot >= $1
+        AND f_slot < $2
+      ORDER BY f_slot
+              ,f_root`,
+		bySlot: true,
+	},
+	"attestations": {
+		query: `
+      SELECT ROW(f_inclusion_slot
+                ,f_inclusion_block_root
+                ,f_inclusion_index
+                ,f_slot
+                ,f_committee_index
+                ,f_beacon_block_root
+                ,f_source_epoch
+                ,f_source_root
+                ,f_target_epoch
+                ,f_target_root
+                ,f_canonical
+                ,f_target_correct
+                ,f_head_correct
+                )::TEXT
+            ,f_aggregation_bits
+            ,f_aggregation_bits_compressed
+      FROM t_attestations
+      WHERE f_inclusion_slot >= $1
+        AND f_inclusion_slot < $2
+      ORDER BY f_inclusion_slot
+              ,f_inclusion_block_root
+              ,f_inclusion_index`,
+		bitfield: true,
+		bySlot:   true,
+	},
+	"syncaggregates": {
+		query: `
+      SELECT ROW(f_inclusion_slot
+                ,f_inclusion_block_root
+                )::TEXT
+            ,f_bits
+            ,f_bits_compressed
+      FROM t_sync_aggregates
+      WHERE f_inclusion_slot >= $1
+        AND f_inclusion_slot < $2
+      ORDER BY f_inclusion_slot
+              ,f_inclusion_block_root`,
+		bitfield: true,
+		bySlot:   true,
+	},
+	"beaconcommittees": {
+		query: `
+      SELECT ROW(f_slot
+                ,f_index
+                ,f_committee
+                )::TEXT
+      FROM t_beacon_committees
+      WHERE f_slot >= $1
+        AND f_slot < $2
+      ORDER BY f_slot
+              ,f_index`,
+		bySlot: true,
+	},
+	"proposerduties": {
+		query: `
+      SELECT ROW(f_slot
+                ,f_validator_index
+                )::TEXT
+      FROM t_proposer_duties
+      WHERE f_slot >= $1
+        AND f_slot < $2
+      ORDER BY f_slot`,
+		bySlot: true,
+	},
+	"validators.balances": {
+		query: `
+      SELECT ROW(f_validator_index
+                ,f_epoch
+                ,f_balance
+                ,f_effective_balance
+                )::TEXT
+      FROM t_validator_balances
+      WHERE f_epoch >= $1
+        AND f_epoch < $2
+      ORDER BY f_validator_index`,
+	},
+	"epochsummaries": {
+		// All columns of epoch summaries are derived from chain data.
+		query: `
+      SELECT ROW(t_epoch_summaries.*)::TEXT
+      FROM t_epoch_summaries
+      WHERE f_epoch >= $1
+        AND f_epoch < $2`,
+	},
+}
+
+// EpochChecksumDatasets provides the names of the datasets for which checksums can be computed.
+func (s *Service) EpochChecksumDatasets() []string {
+	datasets := make([]string, 0, len(checksumDatasets))
+	for dataset := range checksumDatasets {
+		datasets = append(datasets, dataset)
+	}
+	sort.Strings(datasets)
+
+	return datasets
+}
+
+// ComputeEpochChecksum computes the checksum of the named dataset for the given epoch from
+// the indexed data.
+func (s *Service) ComputeEpochChecksum(ctx context.Context, dataset string, epoch phase0.Epoch) (*chaindb.EpochChecksum, error) {
+	definition, exists := checksumDatasets[dataset]
+	if !exists {
+		return nil, fmt.Errorf("unknown dataset %q", dataset)
+	}
+
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// The text of binary values depends on the session, so is fixed here.
+	if _, err := tx.Exec(ctx, "SET LOCAL bytea_output = 'hex'"); err != nil {
+		return nil, errors.Wrap(err, "failed to set bytea output")
+	}
+
+	start := uint64(epoch)
+	end := uint64(epoch) + 1
+	if definition.bySlot {
+		slotsPerEpoch, err := s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain SLOTS_PER_EPOCH")
+		}
+		slotsPerEpochVal, isUint := slotsPerEpoch.(uint64)
+		if !isUint {
+			return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
+		}
+		start *= slotsPerEpochVal
+		end *= slotsPerEpochVal
+	}
+
+	rows, err := tx.Query(ctx, definition.query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checksum := &chaindb.EpochChecksum{
+		Dataset: dataset,
+		Epoch:   epoch,
+	}
+	h := sha256.New()
+	for rows.Next() {
+		var text string
+		var bitfield []byte
+		var compressed []byte
+		if definition.bitfield {
+			err = rows.Scan(&text, &bitfield, &compressed)
+		} else {
+			err = rows.Scan(&text)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		writeChecksumField(h, []byte(text))
+		if definition.bitfield {
+			bitfield, err = expandedBitfield(bitfield, compressed)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to expand bitfield")
+			}
+			writeChecksumField(h, bitfield)
+		}
+		checksum.Rows++
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read rows")
+	}
+	checksum.Checksum = h.Sum(nil)
+
+	return checksum, nil
+}
+
+// writeChecksumField writes a length-prefixed field to a checksum, so that
+// adjacent fields cannot be confused.
+func writeChecksumField(hash hash.Hash, data []byte) {
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(data)))
+	// Writes to a hash never fail.
+	_, _ = hash.Write(length)
+	_, _ = hash.Write(data)
+}
+
+// SetEpochChecksums sets multiple epoch checksums.
+func (s *Service) SetEpochChecksums(ctx context.Context, checksums []*chaindb.EpochChecksum) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if len(checksums) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(checksums))
+	args := make([]interface{}, 0, len(checksums)*4)
+	for i, checksum := range checksums {
+		values = append(values, fmt.Sprintf("($%d,$%d,$%d,$%d)", i*4+1, i*4+2, i*4+3, i*4+4))
+		args = append(args, checksum.Dataset, checksum.Epoch, checksum.Rows, checksum.Checksum)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`
+      INSERT INTO t_epoch_checksums(f_dataset
+                                   ,f_epoch
+                                   ,f_rows
+                                   ,f_checksum)
+      VALUES %s
+      ON CONFLICT (f_dataset,f_epoch) DO
+      UPDATE
+      SET f_rows = excluded.f_rows
+         ,f_checksum = excluded.f_checksum`,
+		strings.Join(values, ",")),
+		args...,
+	); err != nil {
+		return errors.Wrap(err, "failed to set epoch checksums")
+	}
+
+	return nil
+}
+
+// EpochChecksums fetches the stored checksums of all datasets for the given epoch range.
+// Ranges are inclusive of start and exclusive of end.
+func (s *Service) EpochChecksums(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*chaindb.EpochChecksum, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_dataset
+            ,f_epoch
+            ,f_rows
+            ,f_checksum
+      FROM t_epoch_checksums
+      WHERE f_epoch >= $1
+        AND f_epoch < $2
+      ORDER BY f_epoch
+              ,f_dataset`,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checksums := make([]*chaindb.EpochChecksum, 0)
+	for rows.Next() {
+		checksum := &chaindb.EpochChecksum{}
+		if err := rows.Scan(
+			&checksum.Dataset,
+			&checksum.Epoch,
+			&checksum.Rows,
+			&checksum.Checksum,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		checksums = append(checksums, checksum)
+	}
+
+	return checksums, nil
+}
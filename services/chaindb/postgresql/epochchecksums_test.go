@@ -0,0 +1,62 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestEpochChecksums(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	_, err = s.ComputeEpochChecksum(ctx, "unknown", 1000000)
+	require.EqualError(t, err, `unknown dataset "unknown"`)
+
+	// An epoch without data has the checksum of no rows.
+	empty := sha256.Sum256(nil)
+	checksum, err := s.ComputeEpochChecksum(ctx, "epochsummaries", 1000000)
+	require.NoError(t, err)
+	require.Equal(t, &chaindb.EpochChecksum{Dataset: "epochsummaries", Epoch: 1000000, Checksum: empty[:]}, checksum)
+
+	// Checksums change with the data.
+	require.NoError(t, s.SetEpochSummary(ctx, &chaindb.EpochSummary{Epoch: 1000000, ActiveValidators: 10}))
+	checksum, err = s.ComputeEpochChecksum(ctx, "epochsummaries", 1000000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), checksum.Rows)
+	require.NotEqual(t, empty[:], checksum.Checksum)
+
+	// Stored checksums are updated rather than duplicated.
+	require.NoError(t, s.SetEpochChecksums(ctx, []*chaindb.EpochChecksum{
+		{Dataset: "epochsummaries", Epoch: 1000000, Checksum: empty[:]},
+	}))
+	require.NoError(t, s.SetEpochChecksums(ctx, []*chaindb.EpochChecksum{checksum}))
+	checksums, err := s.EpochChecksums(ctx, 1000000, 1000001)
+	require.NoError(t, err)
+	require.Equal(t, []*chaindb.EpochChecksum{checksum}, checksums)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// SetEpochComplete records that the named service has completed processing of an epoch.
+func (s *Service) SetEpochComplete(ctx context.Context, service string, epoch phase0.Epoch) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_epoch_completions(f_service
+                                     ,f_epoch)
+      VALUES($1,$2)
+      ON CONFLICT (f_service,f_epoch) DO NOTHING
+		 `,
+		service,
+		epoch,
+	)
+
+	return err
+}
+
+// DeleteEpochCompletions removes the completion markers for the named service from the
+// given epoch onwards.
+func (s *Service) DeleteEpochCompletions(ctx context.Context, service string, fromEpoch phase0.Epoch) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      DELETE FROM t_epoch_completions
+      WHERE f_service = $1
+        AND f_epoch >= $2`,
+		service,
+		fromEpoch,
+	)
+
+	return err
+}
+
+// CompletedEpochs fetches the epochs in the given range for which the named service has
+// recorded completion.  Ranges are inclusive of start and end.
+func (s *Service) CompletedEpochs(ctx context.Context,
+	service string,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) (
+	[]phase0.Epoch,
+	error,
+) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_epoch
+      FROM t_epoch_completions
+      WHERE f_service = $1
+        AND f_epoch >= $2
+        AND f_epoch <= $3
+      ORDER BY f_epoch`,
+		service,
+		startEpoch,
+		endEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	epochs := make([]phase0.Epoch, 0)
+	for rows.Next() {
+		var epoch uint64
+		if err := rows.Scan(&epoch); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		epochs = append(epochs, phase0.Epoch(epoch))
+	}
+
+	return epochs, nil
+}
@@ -46,8 +46,12 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
                                    ,f_attester_slashings
                                    ,f_deposits
                                    ,f_exiting_validators
-                                   ,f_canonical_blocks)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20)
+                                   ,f_canonical_blocks
+                                   ,f_exit_queue_length
+                                   ,f_churn_limit
+                                   ,f_activation_queue_wait
+                                   ,f_exit_queue_wait)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24)
       ON CONFLICT (f_epoch) DO
       UPDATE
       SET f_activation_queue_length = excluded.f_activation_queue_length
@@ -69,6 +73,10 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
          ,f_deposits = excluded.f_deposits
          ,f_exiting_validators = excluded.f_exiting_validators
          ,f_canonical_blocks = excluded.f_canonical_blocks
+         ,f_exit_queue_length = excluded.f_exit_queue_length
+         ,f_churn_limit = excluded.f_churn_limit
+         ,f_activation_queue_wait = excluded.f_activation_queue_wait
+         ,f_exit_queue_wait = excluded.f_exit_queue_wait
 		 `,
 		summary.Epoch,
 		summary.ActivationQueueLength,
@@ -90,6 +98,10 @@ func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSum
 		summary.Deposits,
 		summary.ExitingValidators,
 		summary.CanonicalBlocks,
+		summary.ExitQueueLength,
+		summary.ChurnLimit,
+		summary.ActivationQueueWait,
+		summary.ExitQueueWait,
 	)
 
 	return err
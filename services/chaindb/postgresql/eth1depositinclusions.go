@@ -0,0 +1,185 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetETH1DepositInclusion sets the inclusion of an Ethereum 1 deposit.
+func (s *Service) SetETH1DepositInclusion(ctx context.Context, inclusion *chaindb.ETH1DepositInclusion) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var inclusionSlot sql.NullInt64
+	if inclusion.InclusionSlot != nil {
+		inclusionSlot.Valid = true
+		inclusionSlot.Int64 = int64(*inclusion.InclusionSlot)
+	}
+	var inclusionBlockRoot []byte
+	if inclusion.InclusionBlockRoot != nil {
+		inclusionBlockRoot = inclusion.InclusionBlockRoot[:]
+	}
+	var inclusionIndex sql.NullInt64
+	if inclusion.InclusionIndex != nil {
+		inclusionIndex.Valid = true
+		inclusionIndex.Int64 = int64(*inclusion.InclusionIndex)
+	}
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_eth1_deposit_inclusions(f_deposit_index
+                                           ,f_inclusion_slot
+                                           ,f_inclusion_block_root
+                                           ,f_inclusion_index
+                                           ,f_missing)
+      VALUES($1,$2,$3,$4,$5)
+      ON CONFLICT (f_deposit_index) DO
+      UPDATE
+      SET f_inclusion_slot = excluded.f_inclusion_slot
+         ,f_inclusion_block_root = excluded.f_inclusion_block_root
+         ,f_inclusion_index = excluded.f_inclusion_index
+         ,f_missing = excluded.f_missing
+      `,
+		inclusion.DepositIndex,
+		inclusionSlot,
+		inclusionBlockRoot,
+		inclusionIndex,
+		inclusion.Missing,
+	)
+
+	return err
+}
+
+// LatestETH1DepositInclusion fetches the inclusion of the Ethereum 1 deposit with the highest index
+// that has been included in the beacon chain, or nil if there is none.
+func (s *Service) LatestETH1DepositInclusion(ctx context.Context) (*chaindb.ETH1DepositInclusion, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_deposit_index
+            ,f_inclusion_slot
+            ,f_inclusion_block_root
+            ,f_inclusion_index
+            ,f_missing
+      FROM t_eth1_deposit_inclusions
+      WHERE f_inclusion_slot IS NOT NULL
+      ORDER BY f_deposit_index DESC
+      LIMIT 1`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inclusions, err := scanETH1DepositInclusions(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(inclusions) == 0 {
+		return nil, nil
+	}
+
+	return inclusions[0], nil
+}
+
+// ETH1DepositInclusionsByIndexRange fetches the inclusions for the given range of deposit indices.
+func (s *Service) ETH1DepositInclusionsByIndexRange(ctx context.Context,
+	startIndex uint64,
+	endIndex uint64,
+) (
+	[]*chaindb.ETH1DepositInclusion,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_deposit_index
+            ,f_inclusion_slot
+            ,f_inclusion_block_root
+            ,f_inclusion_index
+            ,f_missing
+      FROM t_eth1_deposit_inclusions
+      WHERE f_deposit_index >= $1
+        AND f_deposit_index < $2
+      ORDER BY f_deposit_index`,
+		startIndex,
+		endIndex,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanETH1DepositInclusions(rows)
+}
+
+// scanETH1DepositInclusions scans rows in to Ethereum 1 deposit inclusions.
+func scanETH1DepositInclusions(rows pgx.Rows) ([]*chaindb.ETH1DepositInclusion, error) {
+	inclusions := make([]*chaindb.ETH1DepositInclusion, 0)
+	for rows.Next() {
+		inclusion := &chaindb.ETH1DepositInclusion{}
+		var inclusionSlot sql.NullInt64
+		var inclusionBlockRoot []byte
+		var inclusionIndex sql.NullInt64
+		err := rows.Scan(
+			&inclusion.DepositIndex,
+			&inclusionSlot,
+			&inclusionBlockRoot,
+			&inclusionIndex,
+			&inclusion.Missing,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if inclusionSlot.Valid {
+			slot := phase0.Slot(inclusionSlot.Int64)
+			inclusion.InclusionSlot = &slot
+		}
+		if inclusionBlockRoot != nil {
+			root := phase0.Root{}
+			copy(root[:], inclusionBlockRoot)
+			inclusion.InclusionBlockRoot = &root
+		}
+		if inclusionIndex.Valid {
+			index := uint64(inclusionIndex.Int64)
+			inclusion.InclusionIndex = &index
+		}
+		inclusions = append(inclusions, inclusion)
+	}
+
+	return inclusions, nil
+}
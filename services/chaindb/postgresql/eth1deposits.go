@@ -15,6 +15,7 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -28,6 +29,11 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
 		return ErrNoTransaction
 	}
 
+	var valid sql.NullBool
+	if deposit.Valid != nil {
+		valid.Valid = true
+		valid.Bool = *deposit.Valid
+	}
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_eth1_deposits(f_eth1_block_number
                                  ,f_eth1_block_hash
@@ -42,8 +48,9 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
                                  ,f_validator_pubkey
                                  ,f_withdrawal_credentials
                                  ,f_signature
-                                 ,f_amount)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
+                                 ,f_amount
+                                 ,f_valid)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15)
       ON CONFLICT (f_deposit_index) DO
       UPDATE
       SET f_eth1_block_number = excluded.f_eth1_block_number
@@ -58,6 +65,7 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
          ,f_withdrawal_credentials = excluded.f_withdrawal_credentials
          ,f_signature = excluded.f_signature
          ,f_amount = excluded.f_amount
+         ,f_valid = excluded.f_valid
       `,
 		deposit.ETH1BlockNumber,
 		deposit.ETH1BlockHash,
@@ -73,6 +81,7 @@ func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Depos
 		deposit.WithdrawalCredentials,
 		deposit.Signature[:],
 		deposit.Amount,
+		valid,
 	)
 
 	return err
@@ -109,6 +118,7 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
             ,f_withdrawal_credentials
             ,f_signature
             ,f_amount
+            ,f_valid
       FROM t_eth1_deposits
       WHERE f_validator_pubkey = ANY($1)
       ORDER BY f_eth1_block_number
@@ -126,6 +136,86 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
 		deposit := &chaindb.ETH1Deposit{}
 		var validatorPubKey []byte
 		var signature []byte
+		var valid sql.NullBool
+		err := rows.Scan(
+			&deposit.ETH1BlockNumber,
+			&deposit.ETH1BlockHash,
+			&deposit.ETH1BlockTimestamp,
+			&deposit.ETH1TxHash,
+			&deposit.ETH1LogIndex,
+			&deposit.ETH1Sender,
+			&deposit.ETH1Recipient,
+			&deposit.ETH1GasUsed,
+			&deposit.ETH1GasPrice,
+			&deposit.DepositIndex,
+			&validatorPubKey,
+			&deposit.WithdrawalCredentials,
+			&signature,
+			&deposit.Amount,
+			&valid,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		copy(deposit.ValidatorPubKey[:], validatorPubKey)
+		copy(deposit.Signature[:], signature)
+		if valid.Valid {
+			val := valid.Bool
+			deposit.Valid = &val
+		}
+		deposits = append(deposits, deposit)
+	}
+
+	return deposits, nil
+}
+
+// ETH1DepositsByIndexRange fetches Ethereum 1 deposits for the given range of deposit indices.
+func (s *Service) ETH1DepositsByIndexRange(ctx context.Context, startIndex uint64, endIndex uint64) ([]*chaindb.ETH1Deposit, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_eth1_block_number
+            ,f_eth1_block_hash
+            ,f_eth1_block_timestamp
+            ,f_eth1_tx_hash
+            ,f_eth1_log_index
+            ,f_eth1_sender
+            ,f_eth1_recipient
+            ,f_eth1_gas_used
+            ,f_eth1_gas_price
+            ,f_deposit_index
+            ,f_validator_pubkey
+            ,f_withdrawal_credentials
+            ,f_signature
+            ,f_amount
+            ,f_valid
+      FROM t_eth1_deposits
+      WHERE f_deposit_index >= $1
+        AND f_deposit_index < $2
+      ORDER BY f_deposit_index
+	  `,
+		startIndex,
+		endIndex,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deposits := make([]*chaindb.ETH1Deposit, 0)
+	for rows.Next() {
+		deposit := &chaindb.ETH1Deposit{}
+		var validatorPubKey []byte
+		var signature []byte
+		var valid sql.NullBool
 		err := rows.Scan(
 			&deposit.ETH1BlockNumber,
 			&deposit.ETH1BlockHash,
@@ -141,12 +231,17 @@ func (s *Service) ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.
 			&deposit.WithdrawalCredentials,
 			&signature,
 			&deposit.Amount,
+			&valid,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
 		}
 		copy(deposit.ValidatorPubKey[:], validatorPubKey)
 		copy(deposit.Signature[:], signature)
+		if valid.Valid {
+			val := valid.Bool
+			deposit.Valid = &val
+		}
 		deposits = append(deposits, deposit)
 	}
 
@@ -0,0 +1,160 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetExecutionTransactions sets the transactions of an execution block.
+func (s *Service) SetExecutionTransactions(ctx context.Context, transactions []*chaindb.ExecutionTransaction) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, transaction := range transactions {
+		var to []byte
+		if transaction.To != nil {
+			to = transaction.To[:]
+		}
+		var contractAddress []byte
+		if transaction.ContractAddress != nil {
+			contractAddress = transaction.ContractAddress[:]
+		}
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_execution_transactions(f_block_hash
+                                    ,f_block_number
+                                    ,f_index
+                                    ,f_hash
+                                    ,f_type
+                                    ,f_from
+                                    ,f_to
+                                    ,f_nonce
+                                    ,f_value
+                                    ,f_gas_limit
+                                    ,f_gas_price
+                                    ,f_max_fee_per_gas
+                                    ,f_max_priority_fee_per_gas
+                                    ,f_gas_used
+                                    ,f_effective_gas_price
+                                    ,f_status
+                                    ,f_contract_address
+                                    ,f_logs
+                                    )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18)
+ON CONFLICT (f_block_hash,f_index) DO
+UPDATE
+SET f_block_number = excluded.f_block_number
+   ,f_hash = excluded.f_hash
+   ,f_type = excluded.f_type
+   ,f_from = excluded.f_from
+   ,f_to = excluded.f_to
+   ,f_nonce = excluded.f_nonce
+   ,f_value = excluded.f_value
+   ,f_gas_limit = excluded.f_gas_limit
+   ,f_gas_price = excluded.f_gas_price
+   ,f_max_fee_per_gas = excluded.f_max_fee_per_gas
+   ,f_max_priority_fee_per_gas = excluded.f_max_priority_fee_per_gas
+   ,f_gas_used = excluded.f_gas_used
+   ,f_effective_gas_price = excluded.f_effective_gas_price
+   ,f_status = excluded.f_status
+   ,f_contract_address = excluded.f_contract_address
+   ,f_logs = excluded.f_logs
+`,
+			transaction.BlockHash[:],
+			transaction.BlockNumber,
+			transaction.Index,
+			transaction.Hash[:],
+			transaction.Type,
+			transaction.From[:],
+			to,
+			transaction.Nonce,
+			nullDecimal(transaction.Value),
+			transaction.GasLimit,
+			nullDecimal(transaction.GasPrice),
+			nullDecimal(transaction.MaxFeePerGas),
+			nullDecimal(transaction.MaxPriorityFeePerGas),
+			transaction.GasUsed,
+			nullDecimal(transaction.EffectiveGasPrice),
+			transaction.Status,
+			contractAddress,
+			transaction.Logs,
+		); err != nil {
+			return errors.Wrapf(err, "failed to set transaction %d of block %#x", transaction.Index, transaction.BlockHash)
+		}
+	}
+
+	return nil
+}
+
+// SetExecutionBlockSummary sets the summary of an execution block.
+func (s *Service) SetExecutionBlockSummary(ctx context.Context, summary *chaindb.ExecutionBlockSummary) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+INSERT INTO t_execution_block_summaries(f_block_hash
+                                       ,f_block_number
+                                       ,f_transactions
+                                       ,f_failed_transactions
+                                       ,f_contract_creations
+                                       ,f_logs
+                                       ,f_gas_used
+                                       ,f_total_fees
+                                       ,f_burnt_fees
+                                       )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
+ON CONFLICT (f_block_hash) DO
+UPDATE
+SET f_block_number = excluded.f_block_number
+   ,f_transactions = excluded.f_transactions
+   ,f_failed_transactions = excluded.f_failed_transactions
+   ,f_contract_creations = excluded.f_contract_creations
+   ,f_logs = excluded.f_logs
+   ,f_gas_used = excluded.f_gas_used
+   ,f_total_fees = excluded.f_total_fees
+   ,f_burnt_fees = excluded.f_burnt_fees
+`,
+		summary.BlockHash[:],
+		summary.BlockNumber,
+		summary.Transactions,
+		summary.FailedTransactions,
+		summary.ContractCreations,
+		summary.Logs,
+		summary.GasUsed,
+		nullDecimal(summary.TotalFees),
+		nullDecimal(summary.BurntFees),
+	)
+
+	return err
+}
+
+// nullDecimal converts a big integer to a decimal, which is null if the integer is nil.
+func nullDecimal(val *big.Int) decimal.NullDecimal {
+	if val == nil {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NullDecimal{
+		Decimal: decimal.NewFromBigInt(val, 0),
+		Valid:   true,
+	}
+}
@@ -43,6 +43,9 @@ func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork)
                                  ,f_version
 						         )
       VALUES($1,$2)
+      ON CONFLICT (f_epoch) DO
+      UPDATE
+      SET f_version = excluded.f_version
 	  `,
 				0,
 				fork.PreviousVersion[:],
@@ -56,6 +59,9 @@ func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork)
                                  ,f_version
 						         )
       VALUES($1,$2)
+      ON CONFLICT (f_epoch) DO
+      UPDATE
+      SET f_version = excluded.f_version
 	  `,
 			fork.Epoch,
 			fork.CurrentVersion[:],
@@ -0,0 +1,159 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// SlotsWithoutBeaconCommittees fetches the slots in the given range without beacon committees
+// in the database, ignoring slots before the first or after the last with beacon committees.
+func (s *Service) SlotsWithoutBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      WITH bounds AS (
+        SELECT MIN(f_slot) AS f_min
+              ,MAX(f_slot) AS f_max
+        FROM t_beacon_committees
+        WHERE f_slot >= $1
+          AND f_slot <= $2
+      )
+      SELECT missing
+      FROM bounds, generate_series(bounds.f_min,bounds.f_max,1) missing
+      WHERE NOT EXISTS (SELECT 1 FROM t_beacon_committees WHERE f_slot = missing)
+      ORDER BY missing`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSlots(rows)
+}
+
+// SlotsWithoutProposerDuties fetches the slots in the given range without a proposer duty
+// in the database, ignoring slots before the first or after the last with a proposer duty.
+func (s *Service) SlotsWithoutProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      WITH bounds AS (
+        SELECT MIN(f_slot) AS f_min
+              ,MAX(f_slot) AS f_max
+        FROM t_proposer_duties
+        WHERE f_slot >= $1
+          AND f_slot <= $2
+      )
+      SELECT missing
+      FROM bounds, generate_series(bounds.f_min,bounds.f_max,1) missing
+      WHERE NOT EXISTS (SELECT 1 FROM t_proposer_duties WHERE f_slot = missing)
+      ORDER BY missing`,
+		minSlot,
+		maxSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanSlots(rows)
+}
+
+// EpochsWithoutValidatorBalances fetches the epochs in the given range without validator balances
+// in the database, ignoring epochs before the first or after the last with validator balances.
+func (s *Service) EpochsWithoutValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	rows, err := tx.Query(ctx, `
+      WITH bounds AS (
+        SELECT MIN(f_epoch) AS f_min
+              ,MAX(f_epoch) AS f_max
+        FROM t_validator_balances
+        WHERE f_epoch >= $1
+          AND f_epoch <= $2
+      )
+      SELECT missing
+      FROM bounds, generate_series(bounds.f_min,bounds.f_max,1) missing
+      WHERE NOT EXISTS (SELECT 1 FROM t_validator_balances WHERE f_epoch = missing)
+      ORDER BY missing`,
+		minEpoch,
+		maxEpoch,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	epochs := make([]phase0.Epoch, 0)
+	for rows.Next() {
+		epoch := phase0.Epoch(0)
+		if err := rows.Scan(&epoch); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		epochs = append(epochs, epoch)
+	}
+
+	return epochs, nil
+}
+
+// scanSlots scans rows containing a single slot.
+func scanSlots(rows pgx.Rows) ([]phase0.Slot, error) {
+	slots := make([]phase0.Slot, 0)
+	for rows.Next() {
+		slot := phase0.Slot(0)
+		if err := rows.Scan(&slot); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots, nil
+}
@@ -0,0 +1,106 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// leaderLockID is the key of the advisory lock held by the leader.
+const leaderLockID = int64(0x636861696e64) // "chaind"
+
+// leaderCheckInterval is the interval at which the leader lock is checked.
+var leaderCheckInterval = 5 * time.Second
+
+// AwaitLeadership blocks until this instance is the leader, or the context
+// is done.  The returned channel is closed if leadership is lost.  Leadership
+// is held until the database is closed.
+//
+// Leadership is held with a session-level advisory lock, so if the leader dies
+// its connection is closed by the database server and another instance can take
+// over.
+func (s *Service) AwaitLeadership(ctx context.Context) (<-chan struct{}, error) {
+	for {
+		conn, err := s.pool.Acquire(ctx)
+		if err == nil {
+			acquired := false
+			err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", leaderLockID).Scan(&acquired)
+			if err == nil && acquired {
+				s.leaderMu.Lock()
+				s.leaderConn = conn
+				s.leaderMu.Unlock()
+				lost := make(chan struct{})
+				go s.watchLeadership(conn, lost)
+				return lost, nil
+			}
+			conn.Release()
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to check leader lock; will retry")
+		} else {
+			log.Trace().Msg("Leader lock held by another instance")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(leaderCheckInterval):
+		}
+	}
+}
+
+// watchLeadership periodically checks the connection holding the leader lock,
+// closing the supplied channel if it fails.
+func (s *Service) watchLeadership(conn *pgxpool.Conn, lost chan struct{}) {
+	for {
+		time.Sleep(leaderCheckInterval)
+
+		s.leaderMu.Lock()
+		if s.leaderConn != conn {
+			// Leadership has been released.
+			s.leaderMu.Unlock()
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), leaderCheckInterval)
+		_, err := conn.Exec(ctx, "SELECT 1")
+		cancel()
+		if err != nil {
+			log.Error().Err(err).Msg("Connection holding leader lock failed")
+			s.leaderConn = nil
+			conn.Release()
+			s.leaderMu.Unlock()
+			close(lost)
+			return
+		}
+		s.leaderMu.Unlock()
+	}
+}
+
+// releaseLeadership releases the leader lock, if held.
+func (s *Service) releaseLeadership() {
+	s.leaderMu.Lock()
+	defer s.leaderMu.Unlock()
+
+	if s.leaderConn == nil {
+		return
+	}
+	if _, err := s.leaderConn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", leaderLockID); err != nil {
+		log.Warn().Err(err).Msg("Failed to release leader lock")
+	}
+	s.leaderConn.Release()
+	s.leaderConn = nil
+}
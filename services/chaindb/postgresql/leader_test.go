@@ -0,0 +1,58 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestAwaitLeadership(t *testing.T) {
+	ctx := context.Background()
+	leader, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+	standby, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, standby.Close(ctx))
+	}()
+
+	_, err = leader.AwaitLeadership(ctx)
+	require.NoError(t, err)
+
+	// Standby cannot become leader while the leader holds the lock.
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	_, err = standby.AwaitLeadership(waitCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Standby takes over once the leader has closed.
+	require.NoError(t, leader.Close(ctx))
+	waitCtx, cancel = context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	_, err = standby.AwaitLeadership(waitCtx)
+	require.NoError(t, err)
+}
@@ -0,0 +1,77 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// viewNameRegex is the format of valid materialized view names.
+var viewNameRegex = regexp.MustCompile("^[a-z_][a-z0-9_]*$")
+
+// SetMaterializedView creates a materialized view if it does not already exist.
+func (s *Service) SetMaterializedView(ctx context.Context, name string, query string) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+	if !viewNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid materialized view name %q", name)
+	}
+
+	// Names cannot be passed as parameters, hence the formatted statement.
+	if _, err := tx.Exec(ctx, fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS %s", name, query)); err != nil {
+		return errors.Wrap(err, "failed to create materialized view")
+	}
+
+	return nil
+}
+
+// DropMaterializedView drops a materialized view if it exists.
+func (s *Service) DropMaterializedView(ctx context.Context, name string) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+	if !viewNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid materialized view name %q", name)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", name)); err != nil {
+		return errors.Wrap(err, "failed to drop materialized view")
+	}
+
+	return nil
+}
+
+// RefreshMaterializedView refreshes the contents of a materialized view.
+func (s *Service) RefreshMaterializedView(ctx context.Context, name string) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+	if !viewNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid materialized view name %q", name)
+	}
+
+	if _, err := tx.Exec(ctx, fmt.Sprintf("REFRESH MATERIALIZED VIEW %s", name)); err != nil {
+		return errors.Wrap(err, "failed to refresh materialized view")
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetMissedSlot sets a missed slot.
+func (s *Service) SetMissedSlot(ctx context.Context, missedSlot *chaindb.MissedSlot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	var proposer sql.NullInt64
+	if missedSlot.Proposer != nil {
+		proposer.Valid = true
+		proposer.Int64 = int64(*missedSlot.Proposer)
+	}
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_missed_slots(f_slot
+                                ,f_epoch
+                                ,f_proposer
+                                ,f_confirmed_at)
+      VALUES($1,$2,$3,$4)
+      ON CONFLICT (f_slot) DO
+      UPDATE
+      SET f_epoch = excluded.f_epoch
+         ,f_proposer = excluded.f_proposer
+		 `,
+		missedSlot.Slot,
+		missedSlot.Epoch,
+		proposer,
+		missedSlot.ConfirmedAt,
+	)
+
+	return err
+}
+
+// DeleteMissedSlot removes the record of a missed slot.
+func (s *Service) DeleteMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      DELETE FROM t_missed_slots
+      WHERE f_slot = $1
+		 `,
+		slot,
+	)
+
+	return err
+}
+
+// MissedSlotsForSlotRange fetches the missed slots in the given slot range.
+func (s *Service) MissedSlotsForSlotRange(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	[]*chaindb.MissedSlot,
+	error,
+) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_epoch
+            ,f_proposer
+            ,f_confirmed_at
+      FROM t_missed_slots
+      WHERE f_slot >= $1
+        AND f_slot < $2
+      ORDER BY f_slot`,
+		startSlot,
+		endSlot,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMissedSlots(rows)
+}
+
+// MissedSlotsForProposer fetches the missed slots assigned to the given proposer.
+func (s *Service) MissedSlotsForProposer(ctx context.Context, proposer phase0.ValidatorIndex) ([]*chaindb.MissedSlot, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_slot
+            ,f_epoch
+            ,f_proposer
+            ,f_confirmed_at
+      FROM t_missed_slots
+      WHERE f_proposer = $1
+      ORDER BY f_slot`,
+		proposer,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanMissedSlots(rows)
+}
+
+// scanMissedSlots scans rows in to missed slots.
+func scanMissedSlots(rows pgx.Rows) ([]*chaindb.MissedSlot, error) {
+	missedSlots := make([]*chaindb.MissedSlot, 0)
+	for rows.Next() {
+		missedSlot := &chaindb.MissedSlot{}
+		var proposer sql.NullInt64
+		err := rows.Scan(
+			&missedSlot.Slot,
+			&missedSlot.Epoch,
+			&proposer,
+			&missedSlot.ConfirmedAt,
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		if proposer.Valid {
+			validatorIndex := phase0.ValidatorIndex(proposer.Int64)
+			missedSlot.Proposer = &validatorIndex
+		}
+		missedSlots = append(missedSlots, missedSlot)
+	}
+
+	return missedSlots, nil
+}
@@ -15,21 +15,29 @@ package postgresql
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
 )
 
 type parameters struct {
-	logLevel       zerolog.Level
-	connectionURL  string
-	server         string
-	port           int32
-	user           string
-	password       string
-	clientCert     []byte
-	clientKey      []byte
-	caCert         []byte
-	maxConnections uint
+	logLevel            zerolog.Level
+	logLevelSampler     zerolog.Sampler
+	connectionURL       string
+	server              string
+	port                int32
+	user                string
+	password            string
+	passwordFile        string
+	clientCert          []byte
+	clientKey           []byte
+	caCert              []byte
+	maxConnections      uint
+	isolationLevel      string
+	asyncCommitDistance phase0.Epoch
+	compressBitfields   bool
+	schema              string
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +58,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithConnectionURL sets the connection URL for this module.
 // Deprecated.  Use the individual Server/User/Port/... functions.
 func WithConnectionURL(connectionURL string) Parameter {
@@ -79,6 +95,17 @@ func WithPassword(password string) Parameter {
 	})
 }
 
+// WithPasswordFile sets a file containing the password for this module, for
+// example a Docker or Kubernetes secret.  The file is read each time a
+// connection is made, so a rotated password is used without a restart.  If
+// supplied it takes precedence over the password set by WithPassword() or in
+// the connection URL.
+func WithPasswordFile(passwordFile string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.passwordFile = passwordFile
+	})
+}
+
 // WithPort sets the port for this module.
 func WithPort(port int32) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -114,10 +141,47 @@ func WithMaxConnections(maxConnections uint) Parameter {
 	})
 }
 
+// WithIsolationLevel sets the isolation level of read-write transactions.
+// Valid levels are "read committed", "repeatable read" and "serializable".
+func WithIsolationLevel(level string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.isolationLevel = level
+	})
+}
+
+// WithAsyncCommitDistance sets the number of epochs behind the head of the chain
+// beyond which transactions are committed asynchronously, without waiting for
+// them to be durable.  A crash can lose the most recently committed of these
+// transactions, but the data is behind the head of the chain so is fetched
+// again when catching up.  0 commits all transactions synchronously.
+func WithAsyncCommitDistance(distance phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.asyncCommitDistance = distance
+	})
+}
+
+// WithCompressBitfields sets if the aggregation bits of attestations and the
+// bits of sync aggregates are stored compressed.  Bitfields already stored are
+// unchanged, and both forms are expanded when read.
+func WithCompressBitfields(compress bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.compressBitfields = compress
+	})
+}
+
+// WithSchema sets the schema in which the tables are held, creating it if it
+// does not exist.  If not set the server's default search path is used.
+func WithSchema(schema string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.schema = schema
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:       zerolog.GlobalLevel(),
+		isolationLevel: "read committed",
 	}
 	for _, p := range params {
 		if params != nil {
@@ -125,6 +189,12 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		}
 	}
 
+	switch parameters.isolationLevel {
+	case "read committed", "repeatable read", "serializable":
+	default:
+		return nil, fmt.Errorf("invalid isolation level %q", parameters.isolationLevel)
+	}
+
 	if parameters.connectionURL != "" {
 		// Allow deprecated connection URL.
 		return &parameters, nil
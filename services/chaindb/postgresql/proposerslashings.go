@@ -15,6 +15,7 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
@@ -28,6 +29,17 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
 		return ErrNoTransaction
 	}
 
+	var inclusionProposerIndex sql.NullInt64
+	if proposerSlashing.InclusionProposerIndex != nil {
+		inclusionProposerIndex.Valid = true
+		inclusionProposerIndex.Int64 = int64(*proposerSlashing.InclusionProposerIndex)
+	}
+	var whistleblowerReward sql.NullInt64
+	if proposerSlashing.WhistleblowerReward != nil {
+		whistleblowerReward.Valid = true
+		whistleblowerReward.Int64 = int64(*proposerSlashing.WhistleblowerReward)
+	}
+
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_proposer_slashings(f_inclusion_slot
                                       ,f_inclusion_block_root
@@ -46,8 +58,10 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
                                       ,f_header_2_state_root
                                       ,f_header_2_body_root
                                       ,f_header_2_signature
+                                      ,f_inclusion_proposer_index
+                                      ,f_whistleblower_reward
       )
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19)
       ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
       UPDATE
       SET f_block_1_root = excluded.f_block_1_root
@@ -64,6 +78,8 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
          ,f_header_2_state_root = excluded.f_header_2_state_root
          ,f_header_2_body_root = excluded.f_header_2_body_root
          ,f_header_2_signature = excluded.f_header_2_signature
+         ,f_inclusion_proposer_index = excluded.f_inclusion_proposer_index
+         ,f_whistleblower_reward = excluded.f_whistleblower_reward
       `,
 		proposerSlashing.InclusionSlot,
 		proposerSlashing.InclusionBlockRoot[:],
@@ -82,6 +98,8 @@ func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *cha
 		proposerSlashing.Header2StateRoot[:],
 		proposerSlashing.Header2BodyRoot[:],
 		proposerSlashing.Header2Signature[:],
+		inclusionProposerIndex,
+		whistleblowerReward,
 	)
 
 	return err
@@ -118,6 +136,8 @@ func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot pha
             ,f_header_2_state_root
             ,f_header_2_body_root
             ,f_header_2_signature
+            ,f_inclusion_proposer_index
+            ,f_whistleblower_reward
       FROM t_proposer_slashings
       WHERE f_inclusion_slot >= $1
         AND f_inclusion_slot < $2
@@ -145,6 +165,8 @@ func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot pha
 	var header2StateRoot []byte
 	var header2BodyRoot []byte
 	var header2Signature []byte
+	var inclusionProposerIndex sql.NullInt64
+	var whistleblowerReward sql.NullInt64
 	for rows.Next() {
 		proposerSlashing := &chaindb.ProposerSlashing{}
 		err := rows.Scan(
@@ -165,6 +187,8 @@ func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot pha
 			&header2StateRoot,
 			&header2BodyRoot,
 			&header2Signature,
+			&inclusionProposerIndex,
+			&whistleblowerReward,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -180,6 +204,14 @@ func (s *Service) ProposerSlashingsForSlotRange(ctx context.Context, minSlot pha
 		copy(proposerSlashing.Header2StateRoot[:], header2StateRoot)
 		copy(proposerSlashing.Header2BodyRoot[:], header2BodyRoot)
 		copy(proposerSlashing.Header2Signature[:], header2Signature)
+		if inclusionProposerIndex.Valid {
+			tmp := phase0.ValidatorIndex(inclusionProposerIndex.Int64)
+			proposerSlashing.InclusionProposerIndex = &tmp
+		}
+		if whistleblowerReward.Valid {
+			tmp := phase0.Gwei(whistleblowerReward.Int64)
+			proposerSlashing.WhistleblowerReward = &tmp
+		}
 		proposerSlashings = append(proposerSlashings, proposerSlashing)
 	}
 
@@ -217,6 +249,8 @@ func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase
             ,f_header_2_state_root
             ,f_header_2_body_root
             ,f_header_2_signature
+            ,f_inclusion_proposer_index
+            ,f_whistleblower_reward
       FROM t_proposer_slashings
       WHERE f_header_1_slot IN (SELECT f_slot FROM t_proposer_duties WHERE f_validator_index = $1)
       ORDER BY f_inclusion_slot
@@ -241,6 +275,8 @@ func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase
 	var header2StateRoot []byte
 	var header2BodyRoot []byte
 	var header2Signature []byte
+	var inclusionProposerIndex sql.NullInt64
+	var whistleblowerReward sql.NullInt64
 	for rows.Next() {
 		proposerSlashing := &chaindb.ProposerSlashing{}
 		err := rows.Scan(
@@ -261,6 +297,8 @@ func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase
 			&header2StateRoot,
 			&header2BodyRoot,
 			&header2Signature,
+			&inclusionProposerIndex,
+			&whistleblowerReward,
 		)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to scan row")
@@ -276,6 +314,14 @@ func (s *Service) ProposerSlashingsForValidator(ctx context.Context, index phase
 		copy(proposerSlashing.Header2StateRoot[:], header2StateRoot)
 		copy(proposerSlashing.Header2BodyRoot[:], header2BodyRoot)
 		copy(proposerSlashing.Header2Signature[:], header2Signature)
+		if inclusionProposerIndex.Valid {
+			tmp := phase0.ValidatorIndex(inclusionProposerIndex.Int64)
+			proposerSlashing.InclusionProposerIndex = &tmp
+		}
+		if whistleblowerReward.Valid {
+			tmp := phase0.Gwei(whistleblowerReward.Int64)
+			proposerSlashing.WhistleblowerReward = &tmp
+		}
 		proposerSlashings = append(proposerSlashings, proposerSlashing)
 	}
 
@@ -0,0 +1,100 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetEpochProvenance sets the provenance of the named dataset for the given epoch.
+func (s *Service) SetEpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch, provenance *chaindb.Provenance) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	_, err := tx.Exec(ctx, `
+      INSERT INTO t_epoch_provenance(f_dataset
+                                    ,f_epoch
+                                    ,f_address
+                                    ,f_version)
+      VALUES($1,$2,$3,$4)
+      ON CONFLICT (f_dataset,f_epoch) DO
+      UPDATE
+      SET f_address = excluded.f_address
+         ,f_version = excluded.f_version
+		 `,
+		dataset,
+		epoch,
+		provenance.Address,
+		provenance.Version,
+	)
+
+	return err
+}
+
+// EpochProvenance fetches the provenance of the named dataset for the given epoch,
+// or nil if it is not known.
+func (s *Service) EpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch) (*chaindb.Provenance, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	provenance := &chaindb.Provenance{}
+	err = tx.QueryRow(ctx, `
+      SELECT f_address
+            ,f_version
+      FROM t_epoch_provenance
+      WHERE f_dataset = $1
+        AND f_epoch = $2`,
+		dataset,
+		epoch,
+	).Scan(
+		&provenance.Address,
+		&provenance.Version,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to obtain epoch provenance")
+	}
+
+	return provenance, nil
+}
+
+// dbProvenance returns the provenance for the given database fields, or nil if not present.
+func dbProvenance(address sql.NullString, version sql.NullString) *chaindb.Provenance {
+	if !address.Valid {
+		return nil
+	}
+	return &chaindb.Provenance{
+		Address: address.String,
+		Version: version.String,
+	}
+}
@@ -19,7 +19,10 @@ import (
 	"crypto/x509"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/jackc/pgtype"
 	shopspring "github.com/jackc/pgtype/ext/shopspring-numeric"
 	"github.com/jackc/pgx/v4"
@@ -27,16 +30,43 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel"
 )
 
 // Service is a chain database service.
 type Service struct {
-	pool *pgxpool.Pool
+	pool           *pgxpool.Pool
+	isolationLevel pgx.TxIsoLevel
+	// asyncCommitDistance is the number of epochs behind the head of the chain
+	// beyond which transactions are committed asynchronously.
+	asyncCommitDistance phase0.Epoch
+	// compressBitfields is true if bitfields are stored compressed.
+	compressBitfields bool
+	// schema is the schema in which the tables are held, if not the default.
+	schema string
+
+	// txMu protects closing and the addition of transactions to txs.
+	txMu    sync.Mutex
+	closing bool
+	txs     sync.WaitGroup
+
+	// commitLatencyMu protects commitLatency and commitLatencyUpdated, the
+	// moving average of the time taken to commit transactions.
+	commitLatencyMu      sync.Mutex
+	commitLatency        time.Duration
+	commitLatencyUpdated time.Time
+
+	// leaderMu protects leaderConn, the connection holding the leader lock.
+	leaderMu   sync.Mutex
+	leaderConn *pgxpool.Conn
 }
 
 // module-wide log.
 var log zerolog.Logger
 
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/chaindb/postgresql")
+
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -46,6 +76,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "chaindb").Str("impl", "postgresql").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	var pool *pgxpool.Pool
 	if parameters.connectionURL != "" {
@@ -55,6 +88,12 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 			return nil, errors.Wrap(err, "invalid connection URL")
 		}
 		config.MaxConns = int32(parameters.maxConnections)
+		if parameters.passwordFile != "" {
+			config.BeforeConnect = passwordFromFile(parameters.passwordFile)
+		}
+		if parameters.schema != "" {
+			config.ConnConfig.RuntimeParams["search_path"] = parameters.schema
+		}
 		pool, err = pgxpool.ConnectConfig(context.Background(), config)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to connect to database")
@@ -103,6 +142,12 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		}
 		config.AfterConnect = registerCustomTypes
 		config.ConnConfig.TLSConfig = tlsConfig
+		if parameters.passwordFile != "" {
+			config.BeforeConnect = passwordFromFile(parameters.passwordFile)
+		}
+		if parameters.schema != "" {
+			config.ConnConfig.RuntimeParams["search_path"] = parameters.schema
+		}
 
 		pool, err = pgxpool.ConnectConfig(context.Background(), config)
 		if err != nil {
@@ -110,6 +155,13 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		}
 	}
 
+	if parameters.schema != "" {
+		if _, err := pool.Exec(ctx, fmt.Sprintf("CREATE SCHEMA IF NOT EXISTS %s", pgx.Identifier{parameters.schema}.Sanitize())); err != nil {
+			pool.Close()
+			return nil, errors.Wrap(err, "failed to create schema")
+		}
+	}
+
 	go func() {
 		<-ctx.Done()
 		log.Trace().Msg("Context done; closing pool")
@@ -117,12 +169,60 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	}()
 
 	s := &Service{
-		pool: pool,
+		pool:                pool,
+		isolationLevel:      pgx.TxIsoLevel(parameters.isolationLevel),
+		asyncCommitDistance: parameters.asyncCommitDistance,
+		compressBitfields:   parameters.compressBitfields,
+		schema:              parameters.schema,
 	}
 
 	return s, nil
 }
 
+// Close stops the service accepting new transactions, waits for those in
+// progress to commit or roll back, and then closes the database connections.
+// If the context is done before all transactions have finished the
+// connections are closed regardless, rolling back any remaining transactions.
+func (s *Service) Close(ctx context.Context) error {
+	s.txMu.Lock()
+	s.closing = true
+	s.txMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.txs.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		log.Trace().Msg("All transactions finished; closing pool")
+	case <-ctx.Done():
+		err = errors.New("timed out waiting for transactions to finish")
+	}
+	// Leadership is given up only once transactions have finished, so that a
+	// standby does not start while they are still being committed.
+	s.releaseLeadership()
+	s.pool.Close()
+
+	return err
+}
+
+// DropSchema drops the schema in which the tables are held, along with all
+// of its data.  It is an error to call this if no schema was supplied when
+// the service was created.
+func (s *Service) DropSchema(ctx context.Context) error {
+	if s.schema == "" {
+		return errors.New("no schema specified")
+	}
+	if _, err := s.pool.Exec(ctx, fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", pgx.Identifier{s.schema}.Sanitize())); err != nil {
+		return errors.Wrap(err, "failed to drop schema")
+	}
+
+	return nil
+}
+
 // skipcq: RVV-B0012
 func registerCustomTypes(ctx context.Context, conn *pgx.Conn) error {
 	conn.ConnInfo().RegisterDataType(pgtype.DataType{
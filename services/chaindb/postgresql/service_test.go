@@ -17,6 +17,7 @@ import (
 	"context"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -26,26 +27,42 @@ import (
 
 func TestService(t *testing.T) {
 	tests := []struct {
-		name          string
-		connectionURL string
-		err           string
+		name           string
+		connectionURL  string
+		isolationLevel string
+		err            string
 	}{
 		{
 			name: "ServerMissing",
 			err:  "problem with parameters: no server specified",
 		},
+		{
+			name:           "IsolationLevelInvalid",
+			connectionURL:  os.Getenv("CHAINDB_URL"),
+			isolationLevel: "read uncommitted",
+			err:            "problem with parameters: invalid isolation level \"read uncommitted\"",
+		},
 		{
 			name:          "Good",
 			connectionURL: os.Getenv("CHAINDB_URL"),
 		},
+		{
+			name:           "GoodSerializable",
+			connectionURL:  os.Getenv("CHAINDB_URL"),
+			isolationLevel: "serializable",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			ctx := context.Background()
-			_, err := postgresql.New(ctx,
+			params := []postgresql.Parameter{
 				postgresql.WithConnectionURL(test.connectionURL),
-			)
+			}
+			if test.isolationLevel != "" {
+				params = append(params, postgresql.WithIsolationLevel(test.isolationLevel))
+			}
+			_, err := postgresql.New(ctx, params...)
 			if test.err != "" {
 				assert.EqualError(t, err, test.err)
 			} else {
@@ -73,4 +90,32 @@ func TestInterfaces(t *testing.T) {
 	require.Implements(t, (*chaindb.ValidatorsProvider)(nil), s)
 	require.Implements(t, (*chaindb.ValidatorsSetter)(nil), s)
 	require.Implements(t, (*chaindb.VoluntaryExitsSetter)(nil), s)
+	require.Implements(t, (*chaindb.Closer)(nil), s)
+}
+
+func TestClose(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx, postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")))
+	require.NoError(t, err)
+
+	txCtx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	closed := make(chan error)
+	go func() {
+		closed <- s.Close(ctx)
+	}()
+
+	// Close should wait for the transaction in progress to commit.
+	select {
+	case <-closed:
+		require.Fail(t, "close returned with a transaction in progress")
+	case <-time.After(100 * time.Millisecond):
+	}
+	_, _, err = s.BeginTx(ctx)
+	require.ErrorIs(t, err, postgresql.ErrClosing)
+
+	require.NoError(t, s.CommitTx(txCtx))
+	require.NoError(t, <-closed)
 }
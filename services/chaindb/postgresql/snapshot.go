@@ -0,0 +1,495 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// snapshotManifest is the first entry in a snapshot, describing its contents.
+type snapshotManifest struct {
+	SchemaVersion uint64       `json:"schema_version"`
+	Epoch         phase0.Epoch `json:"epoch"`
+	Slot          phase0.Slot  `json:"slot"`
+	Tables        []string     `json:"tables"`
+}
+
+// snapshotTable is a table that is included in a snapshot.
+type snapshotTable struct {
+	name string
+	// filter is a SQL condition limiting the rows exported.  It is formatted with
+	// the last slot and epoch of the snapshot, in that order.
+	filter string
+}
+
+// snapshotTables are the tables included in a snapshot, in an order that
+// satisfies their foreign key references on import.
+var snapshotTables = []*snapshotTable{
+	{name: "t_chain_spec"},
+	{name: "t_genesis"},
+	{name: "t_fork_schedule"},
+	// Validators hold their current state rather than their state at the snapshot's
+	// epoch, so only those that were known by the epoch are included; their state
+	// is brought up to date by the validators module of the importing instance.
+	{name: "t_validators", filter: "f_activation_eligibility_epoch <= %[2]d"},
+	{name: "t_blocks", filter: "f_slot <= %[1]d"},
+	{name: "t_block_execution_payloads", filter: "f_block_root IN (SELECT f_root FROM t_blocks WHERE f_slot <= %[1]d)"},
+	{name: "t_execution_transactions", filter: "f_block_hash IN (SELECT f_block_hash FROM t_block_execution_payloads WHERE f_block_root IN (SELECT f_root FROM t_blocks WHERE f_slot <= %[1]d))"},
+	{name: "t_execution_block_summaries", filter: "f_block_hash IN (SELECT f_block_hash FROM t_block_execution_payloads WHERE f_block_root IN (SELECT f_root FROM t_blocks WHERE f_slot <= %[1]d))"},
+	{name: "t_beacon_committees", filter: "f_slot <= %[1]d"},
+	{name: "t_proposer_duties", filter: "f_slot <= %[1]d"},
+	{name: "t_missed_slots", filter: "f_slot <= %[1]d"},
+	{name: "t_attestations", filter: "f_inclusion_slot <= %[1]d"},
+	{name: "t_sync_aggregates", filter: "f_inclusion_slot <= %[1]d"},
+	{name: "t_attester_slashings", filter: "f_inclusion_slot <= %[1]d"},
+	{name: "t_proposer_slashings", filter: "f_inclusion_slot <= %[1]d"},
+	{name: "t_voluntary_exits", filter: "f_inclusion_slot <= %[1]d"},
+	{name: "t_deposits", filter: "f_inclusion_slot <= %[1]d"},
+	{name: "t_eth1_deposits"},
+	{name: "t_eth1_deposit_inclusions", filter: "f_inclusion_slot <= %[1]d"},
+	{name: "t_validator_balances", filter: "f_epoch <= %[2]d"},
+	{name: "t_validator_epoch_summaries", filter: "f_epoch <= %[2]d"},
+	{name: "t_block_summaries", filter: "f_slot <= %[1]d"},
+	{name: "t_epoch_summaries", filter: "f_epoch <= %[2]d"},
+	{name: "t_sync_committees"},
+	{name: "t_epoch_completions", filter: "f_epoch <= %[2]d"},
+	{name: "t_epoch_provenance", filter: "f_epoch <= %[2]d"},
+	{name: "t_address_labels"},
+	{name: "t_validator_labels"},
+	{name: "t_validator_label_epoch_summaries", filter: "f_epoch <= %[2]d"},
+	{name: "t_state_roots", filter: "f_slot <= %[1]d"},
+	{name: "t_validator_activations", filter: "f_activation_epoch <= %[2]d"},
+	{name: "t_epoch_checksums", filter: "f_epoch <= %[2]d"},
+}
+
+const (
+	snapshotManifestName = "manifest.json"
+	snapshotMetadataName = "metadata.json"
+)
+
+// snapshotFinalizerMetadata is the subset of the finalizer's metadata required to
+// confirm that a snapshot only contains finalized data.
+type snapshotFinalizerMetadata struct {
+	LatestEpoch phase0.Epoch `json:"latest_epoch"`
+}
+
+// ExportSnapshot writes a snapshot of the data up to and including the given epoch,
+// which must be finalized.
+// The snapshot is a gzipped tar archive containing a manifest, the module metadata
+// and a CSV file for each table.
+func (s *Service) ExportSnapshot(ctx context.Context, epoch phase0.Epoch, w io.Writer) error {
+	ctx, err := s.beginROTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	defer s.commitROTx(ctx)
+	tx := s.tx(ctx)
+
+	// Data beyond finality can change, so must not be included in a snapshot.
+	finalizerMDJSON, err := s.Metadata(ctx, "finalizer.standard")
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain finalizer metadata")
+	}
+	if finalizerMDJSON == nil {
+		return errors.New("no finalized data available to export")
+	}
+	finalizerMD := &snapshotFinalizerMetadata{}
+	if err := json.Unmarshal(finalizerMDJSON, finalizerMD); err != nil {
+		return errors.Wrap(err, "failed to parse finalizer metadata")
+	}
+	if epoch > finalizerMD.LatestEpoch {
+		return fmt.Errorf("epoch %d is not finalized; latest finalized epoch is %d", epoch, finalizerMD.LatestEpoch)
+	}
+
+	version, err := s.version(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain schema version")
+	}
+	slotsPerEpoch, err := s.ChainSpecValue(ctx, "SLOTS_PER_EPOCH")
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain SLOTS_PER_EPOCH")
+	}
+	slotsPerEpochVal, isUint := slotsPerEpoch.(uint64)
+	if !isUint {
+		return errors.New("SLOTS_PER_EPOCH of unexpected type")
+	}
+	slot := phase0.Slot(uint64(epoch+1)*slotsPerEpochVal - 1)
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	manifest := &snapshotManifest{
+		SchemaVersion: version,
+		Epoch:         epoch,
+		Slot:          slot,
+		Tables:        make([]string, 0, len(snapshotTables)),
+	}
+	for _, table := range snapshotTables {
+		manifest.Tables = append(manifest.Tables, table.name)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal manifest")
+	}
+	if err := writeSnapshotEntry(tw, snapshotManifestName, bytes.NewReader(data), int64(len(data))); err != nil {
+		return err
+	}
+
+	metadata, err := s.snapshotMetadata(ctx, epoch, slot)
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(metadata)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal metadata")
+	}
+	if err := writeSnapshotEntry(tw, snapshotMetadataName, bytes.NewReader(data), int64(len(data))); err != nil {
+		return err
+	}
+
+	for _, table := range snapshotTables {
+		log.Trace().Str("table", table.name).Msg("Exporting table")
+		query := fmt.Sprintf("SELECT * FROM %s", table.name)
+		if table.filter != "" {
+			query = fmt.Sprintf("%s WHERE %s", query, fmt.Sprintf(table.filter, slot, epoch))
+		}
+
+		// Table sizes are required up front by tar, so stage each table in a temporary file.
+		tmpFile, err := os.CreateTemp("", "chaind-snapshot-")
+		if err != nil {
+			return errors.Wrap(err, "failed to create temporary file")
+		}
+		if _, err := tx.Conn().PgConn().CopyTo(ctx, tmpFile, fmt.Sprintf("COPY (%s) TO STDOUT WITH (FORMAT csv, HEADER)", query)); err != nil {
+			closeAndRemove(tmpFile)
+			return errors.Wrap(err, fmt.Sprintf("failed to export %s", table.name))
+		}
+		size, err := tmpFile.Seek(0, io.SeekCurrent)
+		if err != nil {
+			closeAndRemove(tmpFile)
+			return errors.Wrap(err, "failed to obtain temporary file size")
+		}
+		if _, err := tmpFile.Seek(0, io.SeekStart); err != nil {
+			closeAndRemove(tmpFile)
+			return errors.Wrap(err, "failed to rewind temporary file")
+		}
+		err = writeSnapshotEntry(tw, fmt.Sprintf("%s.csv", table.name), tmpFile, size)
+		closeAndRemove(tmpFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close snapshot archive")
+	}
+	if err := gzw.Close(); err != nil {
+		return errors.Wrap(err, "failed to close snapshot compression")
+	}
+
+	return nil
+}
+
+// ImportSnapshot imports a snapshot created by ExportSnapshot.
+// The database must be at the same schema version as the snapshot, and must not
+// yet contain any blocks.
+func (s *Service) ImportSnapshot(ctx context.Context, r io.Reader) error {
+	ctx, cancel, err := s.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	tx := s.tx(ctx)
+
+	var hasBlocks bool
+	if err := tx.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM t_blocks)").Scan(&hasBlocks); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to check for existing blocks")
+	}
+	if hasBlocks {
+		cancel()
+		return errors.New("database already contains data; snapshots can only be imported to an empty database")
+	}
+
+	version, err := s.version(ctx)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to obtain schema version")
+	}
+
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to decompress snapshot")
+	}
+	tr := tar.NewReader(gzr)
+
+	var manifest *snapshotManifest
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			cancel()
+			return errors.Wrap(err, "failed to read snapshot entry")
+		}
+
+		switch {
+		case hdr.Name == snapshotManifestName:
+			manifest = &snapshotManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to decode manifest")
+			}
+			if manifest.SchemaVersion != version {
+				cancel()
+				return fmt.Errorf("snapshot schema version %d does not match database schema version %d", manifest.SchemaVersion, version)
+			}
+			log.Info().Uint64("epoch", uint64(manifest.Epoch)).Msg("Importing snapshot")
+		case manifest == nil:
+			cancel()
+			return errors.New("snapshot does not start with a manifest")
+		case hdr.Name == snapshotMetadataName:
+			metadata := make(map[string]json.RawMessage)
+			if err := json.NewDecoder(tr).Decode(&metadata); err != nil {
+				cancel()
+				return errors.Wrap(err, "failed to decode metadata")
+			}
+			for key, value := range metadata {
+				if err := s.SetMetadata(ctx, key, value); err != nil {
+					cancel()
+					return errors.Wrap(err, fmt.Sprintf("failed to set metadata %s", key))
+				}
+			}
+		default:
+			table := strings.TrimSuffix(hdr.Name, ".csv")
+			if !manifestContains(manifest, table) {
+				cancel()
+				return fmt.Errorf("snapshot contains unexpected entry %s", hdr.Name)
+			}
+			log.Trace().Str("table", table).Msg("Importing table")
+			if err := s.importSnapshotTable(ctx, table, tr); err != nil {
+				cancel()
+				return err
+			}
+		}
+	}
+	if manifest == nil {
+		cancel()
+		return errors.New("snapshot does not contain a manifest")
+	}
+
+	if err := s.CommitTx(ctx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
+
+// importSnapshotTable imports the CSV data for a single table.
+func (s *Service) importSnapshotTable(ctx context.Context, table string, r io.Reader) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// Remove any data that has been obtained prior to the import, for example
+	// the chain specification, as the snapshot is authoritative.
+	if _, err := tx.Exec(ctx, fmt.Sprintf("DELETE FROM %s", table)); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to clear %s", table))
+	}
+
+	// The header line provides the columns, as column order can differ between
+	// databases that have been upgraded and those that have been created afresh.
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil {
+		if errors.Is(err, io.EOF) && header == "" {
+			// Empty table.
+			return nil
+		}
+		return errors.Wrap(err, fmt.Sprintf("failed to read header for %s", table))
+	}
+	columns := strings.Split(strings.TrimSpace(header), ",")
+	for _, column := range columns {
+		if !strings.HasPrefix(column, "f_") || strings.ContainsAny(column, " \"';") {
+			return fmt.Errorf("invalid column %q for %s", column, table)
+		}
+	}
+
+	if _, err := tx.Conn().PgConn().CopyFrom(ctx, br, fmt.Sprintf("COPY %s(%s) FROM STDIN WITH (FORMAT csv)", table, strings.Join(columns, ","))); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to import %s", table))
+	}
+
+	return nil
+}
+
+// snapshotMetadata obtains the module metadata for a snapshot, with progress
+// limited to the snapshot's epoch and slot so that modules on the importing
+// instance carry on from the end of the snapshot.
+func (s *Service) snapshotMetadata(ctx context.Context, epoch phase0.Epoch, slot phase0.Slot) (map[string]json.RawMessage, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return nil, ErrNoTransaction
+	}
+
+	rows, err := tx.Query(ctx, `
+      SELECT f_key
+            ,f_value
+      FROM t_metadata
+      WHERE f_key <> 'schema'
+	  `)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain metadata")
+	}
+	defer rows.Close()
+
+	res := make(map[string]json.RawMessage)
+	for rows.Next() {
+		var key string
+		var value []byte
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		limited, err := limitMetadataProgress(value, epoch, slot)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("failed to limit metadata %s", key))
+		}
+		res[key] = limited
+	}
+
+	return res, nil
+}
+
+// limitMetadataProgress ensures that the epoch and slot progress markers in
+// module metadata do not go beyond the given values.
+func limitMetadataProgress(value []byte, epoch phase0.Epoch, slot phase0.Slot) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	for key, field := range fields {
+		switch {
+		case key == "missed_epochs":
+			missed, isArray := field.([]interface{})
+			if !isArray {
+				continue
+			}
+			kept := make([]interface{}, 0, len(missed))
+			for _, missedEpoch := range missed {
+				val, isNumber := missedEpoch.(json.Number)
+				if !isNumber {
+					continue
+				}
+				if current, err := val.Int64(); err == nil && current <= int64(epoch) {
+					kept = append(kept, missedEpoch)
+				}
+			}
+			fields[key] = kept
+		case strings.HasSuffix(key, "_epochs"):
+			// Processed epoch ranges.
+			ranges, isArray := field.([]interface{})
+			if !isArray {
+				continue
+			}
+			kept := make([]interface{}, 0, len(ranges))
+			for _, epochRange := range ranges {
+				bounds, isObject := epochRange.(map[string]interface{})
+				if !isObject {
+					continue
+				}
+				start, isNumber := bounds["start"].(json.Number)
+				if !isNumber {
+					continue
+				}
+				if current, err := start.Int64(); err != nil || current > int64(epoch) {
+					continue
+				}
+				if end, isNumber := bounds["end"].(json.Number); isNumber {
+					if current, err := end.Int64(); err == nil && current > int64(epoch) {
+						bounds["end"] = uint64(epoch)
+					}
+				}
+				kept = append(kept, bounds)
+			}
+			fields[key] = kept
+		case strings.HasSuffix(key, "_epoch"):
+			if val, isNumber := field.(json.Number); isNumber {
+				if current, err := val.Int64(); err == nil && current > int64(epoch) {
+					fields[key] = uint64(epoch)
+				}
+			}
+		case strings.HasSuffix(key, "_slot"):
+			if val, isNumber := field.(json.Number); isNumber {
+				if current, err := val.Int64(); err == nil && current > int64(slot) {
+					fields[key] = uint64(slot)
+				}
+			}
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// manifestContains returns true if the manifest contains the given table.
+func manifestContains(manifest *snapshotManifest, table string) bool {
+	for _, name := range manifest.Tables {
+		if name == table {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSnapshotEntry writes a single entry to the snapshot archive.
+func writeSnapshotEntry(tw *tar.Writer, name string, r io.Reader, size int64) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to write header for %s", name))
+	}
+	if _, err := io.Copy(tw, r); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("failed to write %s", name))
+	}
+	return nil
+}
+
+// closeAndRemove closes and removes a temporary file.
+func closeAndRemove(f *os.File) {
+	if err := f.Close(); err != nil {
+		log.Warn().Err(err).Str("file", f.Name()).Msg("Failed to close temporary file")
+	}
+	if err := os.Remove(f.Name()); err != nil {
+		log.Warn().Err(err).Str("file", f.Name()).Msg("Failed to remove temporary file")
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitMetadataProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		epoch    phase0.Epoch
+		slot     phase0.Slot
+		expected string
+		err      string
+	}{
+		{
+			name:  "Invalid",
+			value: `bad`,
+			err:   "invalid character 'b' looking for beginning of value",
+		},
+		{
+			name:     "Slot",
+			value:    `{"latest_slot":200,"other":5}`,
+			epoch:    1,
+			slot:     63,
+			expected: `{"latest_slot":63,"other":5}`,
+		},
+		{
+			name:     "Epoch",
+			value:    `{"latest_epoch":6,"latest_balances_epoch":1}`,
+			epoch:    2,
+			slot:     95,
+			expected: `{"latest_balances_epoch":1,"latest_epoch":2}`,
+		},
+		{
+			name:     "MissedEpochs",
+			value:    `{"missed_epochs":[1,3,5]}`,
+			epoch:    3,
+			slot:     127,
+			expected: `{"missed_epochs":[1,3]}`,
+		},
+		{
+			name:     "EpochRanges",
+			value:    `{"balances_epochs":[{"start":0,"end":2},{"start":4,"end":8},{"start":10,"end":12}]}`,
+			epoch:    5,
+			slot:     191,
+			expected: `{"balances_epochs":[{"end":2,"start":0},{"end":5,"start":4}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := limitMetadataProgress([]byte(test.value), test.epoch, test.slot)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, string(res))
+			}
+		})
+	}
+}
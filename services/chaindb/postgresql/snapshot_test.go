@@ -0,0 +1,111 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+// TestSnapshotRoundTrip exports a snapshot and imports it again.  Data is
+// committed to and removed from the database, so this requires a scratch
+// database supplied in CHAINDB_SNAPSHOT_URL.
+func TestSnapshotRoundTrip(t *testing.T) {
+	if os.Getenv("CHAINDB_SNAPSHOT_URL") == "" {
+		t.Skip("CHAINDB_SNAPSHOT_URL not set")
+	}
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithLogLevel(zerolog.Disabled),
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_SNAPSHOT_URL")),
+	)
+	require.NoError(t, err)
+
+	// Populate the database with finalized and unfinalized data.
+	txCtx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.SetChainSpecValue(txCtx, "SLOTS_PER_EPOCH", uint64(32)))
+	require.NoError(t, s.SetMetadata(txCtx, "finalizer.standard", []byte(`{"latest_epoch":2}`)))
+	require.NoError(t, s.SetMetadata(txCtx, "blocks.standard", []byte(`{"latest_slot":200}`)))
+	for _, slot := range []phase0.Slot{10, 40, 200} {
+		require.NoError(t, s.SetBlock(txCtx, &chaindb.Block{
+			Slot:       slot,
+			Root:       phase0.Root{byte(slot)},
+			ParentRoot: phase0.Root{byte(slot - 1)},
+			Canonical:  nil,
+		}))
+	}
+	for _, validator := range []*chaindb.Validator{
+		{Index: 0, PublicKey: phase0.BLSPubKey{0x01}, ActivationEligibilityEpoch: 0, ExitEpoch: 0xffffffffffffffff, WithdrawableEpoch: 0xffffffffffffffff},
+		{Index: 1, PublicKey: phase0.BLSPubKey{0x02}, ActivationEligibilityEpoch: 5, ExitEpoch: 0xffffffffffffffff, WithdrawableEpoch: 0xffffffffffffffff},
+	} {
+		require.NoError(t, s.SetValidator(txCtx, validator))
+	}
+	require.NoError(t, s.CommitTx(txCtx))
+	cancel()
+
+	// Epochs that are not finalized cannot be exported.
+	require.EqualError(t, s.ExportSnapshot(ctx, 3, &bytes.Buffer{}), "epoch 3 is not finalized; latest finalized epoch is 2")
+
+	snapshot := &bytes.Buffer{}
+	require.NoError(t, s.ExportSnapshot(ctx, 1, snapshot))
+
+	// Import requires an empty database.
+	require.EqualError(t, s.ImportSnapshot(ctx, bytes.NewReader(snapshot.Bytes())), "database already contains data; snapshots can only be imported to an empty database")
+	txCtx, cancel, err = s.BeginTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.DeleteBlocks(txCtx, 0, 1000))
+	require.NoError(t, s.CommitTx(txCtx))
+	cancel()
+
+	require.NoError(t, s.ImportSnapshot(ctx, bytes.NewReader(snapshot.Bytes())))
+
+	// Only data up to the end of the snapshot's epoch is present.
+	for slot, present := range map[phase0.Slot]bool{10: true, 40: true, 200: false} {
+		blocks, err := s.BlocksBySlot(ctx, slot)
+		require.NoError(t, err)
+		if present {
+			require.Len(t, blocks, 1)
+		} else {
+			require.Empty(t, blocks)
+		}
+	}
+	validators, err := s.Validators(ctx)
+	require.NoError(t, err)
+	require.Len(t, validators, 1)
+	require.Equal(t, phase0.ValidatorIndex(0), validators[0].Index)
+
+	// Progress is limited to the end of the snapshot's epoch.
+	mdJSON, err := s.Metadata(ctx, "blocks.standard")
+	require.NoError(t, err)
+	md := make(map[string]uint64)
+	require.NoError(t, json.Unmarshal(mdJSON, &md))
+	require.Equal(t, uint64(63), md["latest_slot"])
+
+	// Clean up.
+	txCtx, cancel, err = s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+	require.NoError(t, s.DeleteBlocks(txCtx, 0, 1000))
+	require.NoError(t, s.CommitTx(txCtx))
+}
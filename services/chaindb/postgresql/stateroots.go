@@ -0,0 +1,143 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// stateRootColumns is the number of columns written for each state root.
+const stateRootColumns = 3
+
+// maxStateRootsPerStatement is the maximum number of state roots written in a
+// single statement, as limited by the number of parameters a statement can have.
+const maxStateRootsPerStatement = 65535 / stateRootColumns
+
+// SetStateRoots sets multiple state roots.
+func (s *Service) SetStateRoots(ctx context.Context, stateRoots []*chaindb.StateRoot) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for start := 0; start < len(stateRoots); start += maxStateRootsPerStatement {
+		end := start + maxStateRootsPerStatement
+		if end > len(stateRoots) {
+			end = len(stateRoots)
+		}
+		batch := stateRoots[start:end]
+
+		values := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*stateRootColumns)
+		for i, stateRoot := range batch {
+			values = append(values, fmt.Sprintf("($%d,$%d,$%d)", i*stateRootColumns+1, i*stateRootColumns+2, i*stateRootColumns+3))
+			var archiveLocation sql.NullString
+			if stateRoot.ArchiveLocation != "" {
+				archiveLocation.Valid = true
+				archiveLocation.String = stateRoot.ArchiveLocation
+			}
+			args = append(args, stateRoot.Slot, stateRoot.Root[:], archiveLocation)
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+INSERT INTO t_state_roots(f_slot
+                         ,f_root
+                         ,f_archive_location
+                         )
+VALUES %s
+ON CONFLICT (f_slot) DO
+UPDATE
+SET f_root = excluded.f_root
+   ,f_archive_location = excluded.f_archive_location
+`, strings.Join(values, ",")),
+			args...,
+		); err != nil {
+			return errors.Wrap(err, "failed to set state roots")
+		}
+	}
+
+	return nil
+}
+
+// StateRootBySlot fetches the state root for the given slot.
+// It returns nil if the state root for the slot is not known.
+func (s *Service) StateRootBySlot(ctx context.Context, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	return s.stateRoot(ctx, `
+SELECT f_slot
+      ,f_root
+      ,f_archive_location
+FROM t_state_roots
+WHERE f_slot = $1
+`, slot)
+}
+
+// LatestArchivedStateRoot fetches the latest state root at or before the
+// given slot for which an archive of the full state is available.
+// It returns nil if there is no such state root.
+func (s *Service) LatestArchivedStateRoot(ctx context.Context, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	return s.stateRoot(ctx, `
+SELECT f_slot
+      ,f_root
+      ,f_archive_location
+FROM t_state_roots
+WHERE f_slot <= $1
+  AND f_archive_location IS NOT NULL
+ORDER BY f_slot DESC
+LIMIT 1
+`, slot)
+}
+
+// stateRoot fetches a single state root with the given query.
+func (s *Service) stateRoot(ctx context.Context, query string, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	stateRoot := &chaindb.StateRoot{}
+	var root []byte
+	var archiveLocation sql.NullString
+	err = tx.QueryRow(ctx, query, slot).Scan(
+		&stateRoot.Slot,
+		&root,
+		&archiveLocation,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to obtain state root")
+	}
+	copy(stateRoot.Root[:], root)
+	if archiveLocation.Valid {
+		stateRoot.ArchiveLocation = archiveLocation.String
+	}
+
+	return stateRoot, nil
+}
@@ -26,21 +26,25 @@ func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.S
 		return ErrNoTransaction
 	}
 
+	bits, bitsCompressed := s.bitfieldArgs(syncAggregate.Bits)
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_sync_aggregates(f_inclusion_slot
                                    ,f_inclusion_block_root
                                    ,f_bits
+                                   ,f_bits_compressed
                                    ,f_indices
                                   )
-      VALUES($1,$2,$3,$4)
+      VALUES($1,$2,$3,$4,$5)
       ON CONFLICT (f_inclusion_slot, f_inclusion_block_root) DO
       UPDATE
       SET f_bits = excluded.f_bits
+         ,f_bits_compressed = excluded.f_bits_compressed
          ,f_indices = excluded.f_indices
 	  `,
 		syncAggregate.InclusionSlot,
 		syncAggregate.InclusionBlockRoot[:],
-		syncAggregate.Bits,
+		bits,
+		bitsCompressed,
 		syncAggregate.Indices,
 	)
 
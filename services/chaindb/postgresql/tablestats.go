@@ -0,0 +1,75 @@
+// Copyright © 2020 - 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// TableStats fetches the statistics for each of the database's tables.
+func (s *Service) TableStats(ctx context.Context) ([]*chaindb.TableStats, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// Row counts are the planner's estimates, which are kept up to date by
+	// autovacuum, as counting the rows of the larger tables takes minutes.
+	rows, err := tx.Query(ctx, `
+SELECT c.relname
+      ,GREATEST(c.reltuples,0)::BIGINT
+      ,pg_table_size(c.oid)
+      ,pg_indexes_size(c.oid)
+FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'r'
+  AND n.nspname = current_schema()
+ORDER BY c.relname`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make([]*chaindb.TableStats, 0)
+	for rows.Next() {
+		stat := &chaindb.TableStats{}
+		var rowCount int64
+		var tableSize int64
+		var indexSize int64
+		if err := rows.Scan(
+			&stat.Name,
+			&rowCount,
+			&tableSize,
+			&indexSize,
+		); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		stat.Rows = uint64(rowCount)
+		stat.TableSize = uint64(tableSize)
+		stat.IndexSize = uint64(indexSize)
+		stats = append(stats, stat)
+	}
+
+	return stats, nil
+}
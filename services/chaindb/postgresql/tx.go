@@ -17,16 +17,25 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	// ErrNoTransaction is returned when an attempt to carry out a mutation to the database
 	// is not inside a transaction.
 	ErrNoTransaction = errors.New("no transaction for action")
+
+	// ErrClosing is returned when an attempt to begin a transaction is made
+	// after the service has started to close.
+	ErrClosing = errors.New("database is shutting down")
 )
 
 // Tx is a context tag for the database transaction.
@@ -35,6 +44,9 @@ type Tx struct{}
 // TxID is a context tag for the transaction TxID.
 type TxID struct{}
 
+// txDone is a context tag for the function that marks the transaction as finished.
+type txDone struct{}
+
 func init() {
 	// We seed math.rand here so that we can obtain different IDs for requests.
 	// This is purely used as a way to match request and response entries in logs, so there is no
@@ -42,23 +54,64 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-// BeginTx begins a transaction on the database.
+// BeginTx begins a transaction on the database, at the configured isolation level.
 // The transaction can be rolled back by invoking the cancel function.
+// Transactions at isolation levels above read committed can fail with serialization
+// failures, in which case they should be run again; util.RunTx handles this.
 func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
 	// #nosec G404
 	id := fmt.Sprintf("%02x", rand.Int31())
 	log := log.With().Str("id", id).Logger()
 
+	// Track the transaction so that Close() can wait for it to finish.
+	s.txMu.Lock()
+	if s.closing {
+		s.txMu.Unlock()
+		return nil, nil, ErrClosing
+	}
+	s.txs.Add(1)
+	s.txMu.Unlock()
+
+	// The span covers the lifetime of the transaction, so that time spent in
+	// the database can be separated from time spent elsewhere.
+	ctx, span := tracer.Start(ctx, "chaindb transaction", trace.WithAttributes(attribute.String("id", id)))
+	var once sync.Once
+	done := func(err error) {
+		once.Do(func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+			s.txs.Done()
+		})
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
-	tx, err := s.pool.Begin(ctx)
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: s.isolationLevel})
 	if err != nil {
 		log.Trace().Err(err).Str("trace", fmt.Sprintf("+%v", errors.Wrap(err, "stack"))).Msg("Failed to begin transaction")
 		cancel()
+		done(err)
 		return nil, nil, errors.Wrap(err, "failed to begin transaction")
 	}
 
+	if s.commitAsync(ctx) {
+		if _, err := tx.Exec(ctx, "SET LOCAL synchronous_commit TO OFF"); err != nil {
+			log.Trace().Err(err).Msg("Failed to set asynchronous commit")
+			if err := tx.Rollback(ctx); err != nil {
+				log.Debug().Err(err).Msg("Failed to rollback transaction")
+			}
+			cancel()
+			done(err)
+			return nil, nil, errors.Wrap(err, "failed to set asynchronous commit")
+		}
+		span.SetAttributes(attribute.Bool("async_commit", true))
+	}
+
 	ctx = context.WithValue(ctx, &Tx{}, tx)
 	ctx = context.WithValue(ctx, &TxID{}, id)
+	ctx = context.WithValue(ctx, &txDone{}, done)
 
 	log.Trace().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Transaction started")
 	return ctx, func() {
@@ -67,10 +120,23 @@ func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelF
 			log.Warn().Err(err).Msg("Failed to rollback transaction")
 		}
 		log.Debug().Str("trace", fmt.Sprintf("%+v", errors.New("stack"))).Msg("Rolled back transaction")
+		span.SetAttributes(attribute.Bool("rolled_back", true))
 		cancel()
+		done(nil)
 	}, nil
 }
 
+// commitAsync returns true if a transaction begun with the given context can be
+// committed asynchronously, as the data it writes is far enough behind the head
+// of the chain.
+func (s *Service) commitAsync(ctx context.Context) bool {
+	if s.asyncCommitDistance == 0 {
+		return false
+	}
+	distance, ok := chaindb.HeadDistance(ctx)
+	return ok && distance >= s.asyncCommitDistance
+}
+
 // beginROTx begins a read-only transaction on the database.
 // The transaction should be committed.
 func (s *Service) beginROTx(ctx context.Context) (context.Context, error) {
@@ -130,7 +196,17 @@ func (s *Service) CommitTx(ctx context.Context) error {
 		return errors.New("no transaction")
 	}
 
+	started := time.Now()
 	err := tx.Commit(ctx)
+	if err == nil {
+		s.recordCommitLatency(time.Since(started))
+	}
+
+	// The transaction is finished once the commit returns, whether or not it succeeded.
+	if done, ok := ctx.Value(&txDone{}).(func(error)); ok {
+		done(err)
+	}
+
 	if err != nil {
 		log.Debug().Err(err).Str("trace", fmt.Sprintf("%+v", errors.Wrap(err, "stack"))).Msg("Failed to commit")
 		return err
@@ -27,7 +27,7 @@ type schemaMetadata struct {
 	Version uint64 `json:"version"`
 }
 
-var currentVersion = uint64(8)
+var currentVersion = uint64(28)
 
 type upgrade struct {
 	requiresRefetch bool
@@ -89,11 +89,150 @@ var upgrades = map[uint64]*upgrade{
 			addTimestamp,
 		},
 	},
+	9: {
+		funcs: []func(context.Context, *Service) error{
+			addValidatorWithdrawalCredentials,
+		},
+	},
+	10: {
+		funcs: []func(context.Context, *Service) error{
+			createEpochCompletions,
+		},
+	},
+	11: {
+		funcs: []func(context.Context, *Service) error{
+			createMissedSlots,
+		},
+	},
+	12: {
+		funcs: []func(context.Context, *Service) error{
+			addAttestationsDuplicateFields,
+		},
+	},
+	13: {
+		funcs: []func(context.Context, *Service) error{
+			addETH1DepositsValid,
+		},
+	},
+	14: {
+		funcs: []func(context.Context, *Service) error{
+			createETH1DepositInclusions,
+		},
+	},
+	15: {
+		funcs: []func(context.Context, *Service) error{
+			addBlocksProvenance,
+			createEpochProvenance,
+		},
+	},
+	16: {
+		funcs: []func(context.Context, *Service) error{
+			createExecutionEnrichment,
+		},
+	},
+	17: {
+		funcs: []func(context.Context, *Service) error{
+			createAddressLabels,
+		},
+	},
+	18: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorLabels,
+		},
+	},
+	19: {
+		funcs: []func(context.Context, *Service) error{
+			addVoluntaryExitsQueue,
+		},
+	},
+	20: {
+		funcs: []func(context.Context, *Service) error{
+			addSlashingRewards,
+		},
+	},
+	21: {
+		funcs: []func(context.Context, *Service) error{
+			createStateRoots,
+		},
+	},
+	22: {
+		funcs: []func(context.Context, *Service) error{
+			addEpochSummaryQueues,
+		},
+	},
+	23: {
+		funcs: []func(context.Context, *Service) error{
+			createValidatorActivations,
+		},
+	},
+	24: {
+		funcs: []func(context.Context, *Service) error{
+			addCompressedBitfields,
+		},
+	},
+	25: {
+		funcs: []func(context.Context, *Service) error{
+			createEpochChecksums,
+		},
+	},
+	26: {
+		funcs: []func(context.Context, *Service) error{
+			addAttestationsSourceCorrect,
+		},
+	},
+	27: {
+		funcs: []func(context.Context, *Service) error{
+			addValidatorEpochSummariesInclusionSlot,
+		},
+	},
+	28: {
+		funcs: []func(context.Context, *Service) error{
+			addBlocksSeenDelay,
+		},
+	},
 }
 
+// upgradeLockID is the key of the advisory lock held while upgrading the schema.
+const upgradeLockID = int64(0x636861696e6475) // "chaindu"
+
 // Upgrade upgrades the database.
 // Returns true if the upgrade requires blocks to be refetched.
+//
+// The upgrade is carried out while holding an advisory lock, so that if multiple
+// instances of chaind start at the same time only one of them carries out the
+// upgrade and the others wait for it to finish.  A schema newer than that
+// supported by this release is refused.
 func (s *Service) Upgrade(ctx context.Context) (bool, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to acquire connection for upgrade lock")
+	}
+	defer conn.Release()
+
+	acquired := false
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", upgradeLockID).Scan(&acquired); err != nil {
+		return false, errors.Wrap(err, "failed to obtain upgrade lock")
+	}
+	if !acquired {
+		log.Info().Msg("Waiting for another instance to finish upgrading the database")
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", upgradeLockID); err != nil {
+			return false, errors.Wrap(err, "failed to obtain upgrade lock")
+		}
+	}
+	defer func() {
+		// The lock is released with the session if this fails, but the
+		// connection is returned to the pool so it is released explicitly.
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", upgradeLockID); err != nil {
+			log.Warn().Err(err).Msg("Failed to release upgrade lock")
+		}
+	}()
+
+	return s.upgrade(ctx)
+}
+
+// upgrade upgrades the database.
+// This requires the upgrade lock to be held.
+func (s *Service) upgrade(ctx context.Context) (bool, error) {
 	// See if we have anything at all.
 	tableExists, err := s.tableExists(ctx, "t_metadata")
 	if err != nil {
@@ -113,6 +252,9 @@ func (s *Service) Upgrade(ctx context.Context) (bool, error) {
 		// Nothing to do.
 		return false, nil
 	}
+	if version > currentVersion {
+		return false, fmt.Errorf("database schema version %d is newer than version %d supported by this release", version, currentVersion)
+	}
 
 	ctx, cancel, err := s.BeginTx(ctx)
 	if err != nil {
@@ -518,6 +660,25 @@ WHERE table_schema = (SELECT current_schema())
 	return found, nil
 }
 
+// SchemaVersions returns the version of the database schema, and the version
+// required by this release.  A database without tables has version 0.
+func (s *Service) SchemaVersions(ctx context.Context) (uint64, uint64, error) {
+	tableExists, err := s.tableExists(ctx, "t_metadata")
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to check presence of tables")
+	}
+	if !tableExists {
+		return 0, currentVersion, nil
+	}
+
+	version, err := s.version(ctx)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "failed to obtain version")
+	}
+
+	return version, currentVersion, nil
+}
+
 // version obtains the version of the schema.
 func (s *Service) version(ctx context.Context) (uint64, error) {
 	data, err := s.Metadata(ctx, "schema")
@@ -831,6 +992,7 @@ CREATE TABLE t_validators (
  ,f_exit_epoch                   BIGINT
  ,f_withdrawable_epoch           BIGINT
  ,f_effective_balance            BIGINT NOT NULL
+ ,f_withdrawal_credentials       BYTEA
 );
 CREATE UNIQUE INDEX i_validators_1 ON t_validators(f_index);
 CREATE UNIQUE INDEX i_validators_2 ON t_validators(f_public_key);
@@ -855,6 +1017,9 @@ CREATE TABLE t_blocks (
  ,f_eth1_block_hash    BYTEA NOT NULL
  ,f_eth1_deposit_count BIGINT NOT NULL
  ,f_eth1_deposit_root  BYTEA NOT NULL
+ ,f_provenance_address TEXT
+ ,f_provenance_version TEXT
+ ,f_seen_delay         BIGINT
 );
 CREATE UNIQUE INDEX i_blocks_1 ON t_blocks(f_slot,f_root);
 CREATE UNIQUE INDEX i_blocks_2 ON t_blocks(f_root);
@@ -902,7 +1067,7 @@ CREATE TABLE t_attestations (
  ,f_inclusion_index      BIGINT NOT NULL
  ,f_slot                 BIGINT NOT NULL
  ,f_committee_index      BIGINT NOT NULL
- ,f_aggregation_bits     BYTEA NOT NULL
+ ,f_aggregation_bits     BYTEA
  ,f_aggregation_indices  BIGINT[] -- REFERENCES t_validators(f_index)
  ,f_beacon_block_root    BYTEA NOT NULL -- we don't reference this because the block may not exist in the canonical chain
  ,f_source_epoch         BIGINT NOT NULL
@@ -912,6 +1077,10 @@ CREATE TABLE t_attestations (
  ,f_canonical            BOOL
  ,f_target_correct       BOOL
  ,f_head_correct         BOOL
+ ,f_duplicate            BOOL
+ ,f_conflicting          BOOL
+ ,f_aggregation_bits_compressed BYTEA
+ ,f_source_correct       BOOL
 );
 CREATE UNIQUE INDEX i_attestations_1 ON t_attestations(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 CREATE INDEX i_attestations_2 ON t_attestations(f_slot);
@@ -921,8 +1090,9 @@ CREATE INDEX i_attestations_3 ON t_attestations(f_beacon_block_root);
 CREATE TABLE t_sync_aggregates (
   f_inclusion_slot       BIGINT NOT NULL
  ,f_inclusion_block_root BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
- ,f_bits                 BYTEA NOT NULL
+ ,f_bits                 BYTEA
  ,f_indices              BIGINT[] -- REFERENCES t_validators(f_index)
+ ,f_bits_compressed      BYTEA
 );
 CREATE UNIQUE INDEX i_sync_aggregates_1 ON t_sync_aggregates(f_inclusion_slot, f_inclusion_block_root);
 
@@ -949,28 +1119,33 @@ CREATE TABLE t_attester_slashings (
  ,f_attestation_2_target_epoch      BIGINT NOT NULL
  ,f_attestation_2_target_root       BYTEA NOT NULL
  ,f_attestation_2_signature         BYTEA NOT NULL
+ ,f_inclusion_proposer_index        BIGINT
+ ,f_slashed_indices                 BIGINT[] -- REFERENCES t_validators(f_index)
+ ,f_whistleblower_reward            BIGINT
 );
 CREATE UNIQUE INDEX i_attester_slashings_1 ON t_attester_slashings(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 
 -- t_proposer_slashings contains all proposer slashings included in blocks.
 CREATE TABLE t_proposer_slashings (
-  f_inclusion_slot          BIGINT NOT NULL
- ,f_inclusion_block_root    BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
- ,f_inclusion_index         BIGINT NOT NULL
- ,f_block_1_root            BYTEA NOT NULL
- ,f_header_1_slot           BIGINT NOT NULL
- ,f_header_1_proposer_index BIGINT NOT NULL
- ,f_header_1_parent_root    BYTEA NOT NULL
- ,f_header_1_state_root     BYTEA NOT NULL
- ,f_header_1_body_root      BYTEA NOT NULL
- ,f_header_1_signature      BYTEA NOT NULL
- ,f_block_2_root            BYTEA NOT NULL
- ,f_header_2_slot           BIGINT NOT NULL
- ,f_header_2_proposer_index BIGINT NOT NULL
- ,f_header_2_parent_root    BYTEA NOT NULL
- ,f_header_2_state_root     BYTEA NOT NULL
- ,f_header_2_body_root      BYTEA NOT NULL
- ,f_header_2_signature      BYTEA NOT NULL
+  f_inclusion_slot           BIGINT NOT NULL
+ ,f_inclusion_block_root     BYTEA NOT NULL REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_inclusion_index          BIGINT NOT NULL
+ ,f_block_1_root             BYTEA NOT NULL
+ ,f_header_1_slot            BIGINT NOT NULL
+ ,f_header_1_proposer_index  BIGINT NOT NULL
+ ,f_header_1_parent_root     BYTEA NOT NULL
+ ,f_header_1_state_root      BYTEA NOT NULL
+ ,f_header_1_body_root       BYTEA NOT NULL
+ ,f_header_1_signature       BYTEA NOT NULL
+ ,f_block_2_root             BYTEA NOT NULL
+ ,f_header_2_slot            BIGINT NOT NULL
+ ,f_header_2_proposer_index  BIGINT NOT NULL
+ ,f_header_2_parent_root     BYTEA NOT NULL
+ ,f_header_2_state_root      BYTEA NOT NULL
+ ,f_header_2_body_root       BYTEA NOT NULL
+ ,f_header_2_signature       BYTEA NOT NULL
+ ,f_inclusion_proposer_index BIGINT
+ ,f_whistleblower_reward     BIGINT
 );
 CREATE UNIQUE INDEX i_proposer_slashings_1 ON t_proposer_slashings(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 
@@ -981,6 +1156,9 @@ CREATE TABLE t_voluntary_exits (
  ,f_inclusion_index      BIGINT NOT NULL
  ,f_validator_index      BIGINT NOT NULL
  ,f_epoch                BIGINT NOT NULL
+ ,f_exit_epoch           BIGINT
+ ,f_withdrawable_epoch   BIGINT
+ ,f_exit_queue_epochs    BIGINT
 );
 CREATE UNIQUE INDEX i_voluntary_exits_1 ON t_voluntary_exits(f_inclusion_slot,f_inclusion_block_root,f_inclusion_index);
 
@@ -1011,6 +1189,7 @@ CREATE TABLE t_eth1_deposits (
  ,f_withdrawal_credentials BYTEA NOT NULL
  ,f_signature              BYTEA NOT NULL
  ,f_amount                 BIGINT NOT NULL
+ ,f_valid                  BOOL
 );
 CREATE UNIQUE INDEX i_eth1_deposits_1 ON t_eth1_deposits(f_eth1_block_hash, f_eth1_tx_hash, f_eth1_log_index);
 CREATE INDEX i_eth1_deposits_2 ON t_eth1_deposits(f_validator_pubkey);
@@ -1018,6 +1197,16 @@ CREATE INDEX i_eth1_deposits_3 ON t_eth1_deposits(f_withdrawal_credentials);
 CREATE INDEX i_eth1_deposits_4 ON t_eth1_deposits(f_eth1_sender);
 CREATE INDEX i_eth1_deposits_5 ON t_eth1_deposits(f_eth1_recipient);
 
+-- t_eth1_deposit_inclusions links Ethereum 1 deposits to their inclusion in the beacon chain.
+CREATE TABLE t_eth1_deposit_inclusions (
+  f_deposit_index        BIGINT NOT NULL PRIMARY KEY
+ ,f_inclusion_slot       BIGINT
+ ,f_inclusion_block_root BYTEA REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_inclusion_index      BIGINT
+ ,f_missing              BOOL NOT NULL
+);
+CREATE INDEX i_eth1_deposit_inclusions_1 ON t_eth1_deposit_inclusions(f_inclusion_slot);
+
 -- t_validator_balances contains per-epoch balances.
 CREATE TABLE t_validator_balances (
   f_validator_index   BIGINT NOT NULL REFERENCES t_validators(f_index) ON DELETE CASCADE
@@ -1040,6 +1229,7 @@ CREATE TABLE t_validator_epoch_summaries (
  ,f_attestation_head_correct    BOOL
  ,f_attestation_head_timely     BOOL
  ,f_attestation_inclusion_delay INTEGER
+ ,f_attestation_inclusion_slot  BIGINT
 );
 CREATE UNIQUE INDEX IF NOT EXISTS i_validator_epoch_summaries_1 ON t_validator_epoch_summaries(f_validator_index, f_epoch);
 
@@ -1073,6 +1263,10 @@ CREATE TABLE t_epoch_summaries (
  ,f_deposits                         BIGINT NOT NULL
  ,f_exiting_validators               BIGINT NOT NULL
  ,f_canonical_blocks                 BIGINT NOT NULL
+ ,f_exit_queue_length                BIGINT
+ ,f_churn_limit                      BIGINT
+ ,f_activation_queue_wait            BIGINT
+ ,f_exit_queue_wait                  BIGINT
 );
 
 CREATE TABLE t_fork_schedule (
@@ -1085,6 +1279,134 @@ CREATE TABLE t_sync_committees (
  ,f_committee BIGINT[] NOT NULL -- REFERENCES t_validators(f_index)
 );
 CREATE UNIQUE INDEX IF NOT EXISTS i_sync_committees_1 ON t_sync_committees(f_period);
+
+-- t_epoch_completions contains markers for the epochs each service has completed.
+CREATE TABLE t_epoch_completions (
+  f_service TEXT NOT NULL
+ ,f_epoch   BIGINT NOT NULL
+ ,PRIMARY KEY (f_service, f_epoch)
+);
+
+-- t_missed_slots contains slots confirmed to have no canonical block.
+CREATE TABLE t_missed_slots (
+  f_slot         BIGINT NOT NULL PRIMARY KEY
+ ,f_epoch        BIGINT NOT NULL
+ ,f_proposer     BIGINT -- REFERENCES t_validators(f_index)
+ ,f_confirmed_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX i_missed_slots_1 ON t_missed_slots(f_proposer);
+
+-- t_epoch_provenance contains the beacon node that supplied each epoch of each dataset.
+CREATE TABLE t_epoch_provenance (
+  f_dataset TEXT NOT NULL
+ ,f_epoch   BIGINT NOT NULL
+ ,f_address TEXT NOT NULL
+ ,f_version TEXT NOT NULL
+ ,PRIMARY KEY (f_dataset, f_epoch)
+);
+
+-- t_execution_transactions contains the transactions of execution blocks, with
+-- the results from their receipts.
+CREATE TABLE t_execution_transactions (
+  f_block_hash               BYTEA NOT NULL
+ ,f_block_number             BIGINT NOT NULL
+ ,f_index                    BIGINT NOT NULL
+ ,f_hash                     BYTEA NOT NULL
+ ,f_type                     BIGINT NOT NULL
+ ,f_from                     BYTEA NOT NULL
+ ,f_to                       BYTEA
+ ,f_nonce                    BIGINT NOT NULL
+ ,f_value                    NUMERIC NOT NULL
+ ,f_gas_limit                BIGINT NOT NULL
+ ,f_gas_price                NUMERIC
+ ,f_max_fee_per_gas          NUMERIC
+ ,f_max_priority_fee_per_gas NUMERIC
+ ,f_gas_used                 BIGINT NOT NULL
+ ,f_effective_gas_price      NUMERIC
+ ,f_status                   BIGINT NOT NULL
+ ,f_contract_address         BYTEA
+ ,f_logs                     INTEGER NOT NULL
+ ,PRIMARY KEY (f_block_hash, f_index)
+);
+CREATE INDEX i_execution_transactions_1 ON t_execution_transactions(f_block_number);
+CREATE INDEX i_execution_transactions_2 ON t_execution_transactions(f_hash);
+CREATE INDEX i_execution_transactions_3 ON t_execution_transactions(f_from);
+CREATE INDEX i_execution_transactions_4 ON t_execution_transactions(f_to);
+
+-- t_execution_block_summaries contains summaries of the transactions in execution blocks.
+CREATE TABLE t_execution_block_summaries (
+  f_block_hash          BYTEA NOT NULL PRIMARY KEY
+ ,f_block_number        BIGINT NOT NULL
+ ,f_transactions        INTEGER NOT NULL
+ ,f_failed_transactions INTEGER NOT NULL
+ ,f_contract_creations  INTEGER NOT NULL
+ ,f_logs                INTEGER NOT NULL
+ ,f_gas_used            BIGINT NOT NULL
+ ,f_total_fees          NUMERIC NOT NULL
+ ,f_burnt_fees          NUMERIC NOT NULL
+);
+CREATE INDEX i_execution_block_summaries_1 ON t_execution_block_summaries(f_block_number);
+
+-- t_address_labels contains human-readable labels for execution addresses.
+CREATE TABLE t_address_labels (
+  f_address BYTEA NOT NULL PRIMARY KEY
+ ,f_label   TEXT NOT NULL
+ ,f_source  TEXT NOT NULL
+);
+
+-- t_validator_labels contains labels, such as node operators, for validators.
+CREATE TABLE t_validator_labels (
+  f_public_key BYTEA NOT NULL PRIMARY KEY
+ ,f_label      TEXT NOT NULL
+ ,f_source     TEXT NOT NULL
+);
+CREATE INDEX i_validator_labels_1 ON t_validator_labels(f_label);
+
+-- t_validator_label_epoch_summaries contains summary information about the validators with a label for an epoch.
+CREATE TABLE t_validator_label_epoch_summaries (
+  f_label                        TEXT NOT NULL
+ ,f_epoch                        BIGINT NOT NULL
+ ,f_validators                   INTEGER NOT NULL
+ ,f_proposer_duties              INTEGER NOT NULL
+ ,f_proposals_included           INTEGER NOT NULL
+ ,f_attestations_included        INTEGER NOT NULL
+ ,f_attestations_target_correct  INTEGER NOT NULL
+ ,f_attestations_head_correct    INTEGER NOT NULL
+ ,f_attestations_inclusion_delay BIGINT NOT NULL
+);
+CREATE UNIQUE INDEX i_validator_label_epoch_summaries_1 ON t_validator_label_epoch_summaries(f_label, f_epoch);
+CREATE INDEX i_validator_label_epoch_summaries_2 ON t_validator_label_epoch_summaries(f_epoch);
+
+-- t_state_roots contains the state roots of slots, and where their states are archived.
+CREATE TABLE t_state_roots (
+  f_slot             BIGINT NOT NULL PRIMARY KEY
+ ,f_root             BYTEA NOT NULL
+ ,f_archive_location TEXT
+);
+CREATE INDEX i_state_roots_1 ON t_state_roots(f_slot) WHERE f_archive_location IS NOT NULL;
+
+CREATE TABLE t_validator_activations (
+  f_validator_index              BIGINT NOT NULL PRIMARY KEY
+ ,f_deposit_index                BIGINT NOT NULL
+ ,f_eth1_block_timestamp         TIMESTAMPTZ NOT NULL
+ ,f_inclusion_slot               BIGINT NOT NULL
+ ,f_activation_eligibility_epoch BIGINT NOT NULL
+ ,f_activation_epoch             BIGINT NOT NULL
+ ,f_inclusion_latency            BIGINT NOT NULL
+ ,f_eligibility_latency          BIGINT NOT NULL
+ ,f_activation_latency           BIGINT NOT NULL
+);
+CREATE INDEX i_validator_activations_1 ON t_validator_activations(f_activation_epoch);
+
+-- t_epoch_checksums contains checksums over the data of each dataset for an epoch.
+CREATE TABLE t_epoch_checksums (
+  f_dataset  TEXT NOT NULL
+ ,f_epoch    BIGINT NOT NULL
+ ,f_rows     BIGINT NOT NULL
+ ,f_checksum BYTEA NOT NULL
+ ,PRIMARY KEY (f_dataset, f_epoch)
+);
+CREATE INDEX i_epoch_checksums_1 ON t_epoch_checksums(f_epoch);
 `); err != nil {
 		cancel()
 		return false, errors.Wrap(err, "failed to create initial tables")
@@ -1296,3 +1618,624 @@ ALTER COLUMN f_timestamp DROP DEFAULT
 
 	return nil
 }
+
+// addValidatorWithdrawalCredentials adds f_withdrawal_credentials to t_validators.
+func addValidatorWithdrawalCredentials(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	// This exists in the initial SQL, so don't attempt to add it if already present.
+	alreadyPresent, err := s.columnExists(ctx, "t_validators", "f_withdrawal_credentials")
+	if err != nil {
+		return errors.Wrap(err, "failed to check if f_withdrawal_credentials exists in t_validators")
+	}
+	if alreadyPresent {
+		// Nothing more to do.
+		return nil
+	}
+
+	// Add column.  This is populated the next time that validators are updated.
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validators
+ADD COLUMN f_withdrawal_credentials BYTEA
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_withdrawal_credentials to t_validators")
+	}
+
+	return nil
+}
+
+// createEpochCompletions creates the t_epoch_completions table.
+func createEpochCompletions(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_epoch_completions (
+  f_service TEXT NOT NULL
+ ,f_epoch   BIGINT NOT NULL
+ ,PRIMARY KEY (f_service, f_epoch)
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_epoch_completions")
+	}
+
+	return nil
+}
+
+// createMissedSlots creates the t_missed_slots table.
+func createMissedSlots(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_missed_slots (
+  f_slot         BIGINT NOT NULL PRIMARY KEY
+ ,f_epoch        BIGINT NOT NULL
+ ,f_proposer     BIGINT -- REFERENCES t_validators(f_index)
+ ,f_confirmed_at TIMESTAMPTZ NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_missed_slots")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_missed_slots_1 ON t_missed_slots(f_proposer)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_missed_slots_1")
+	}
+
+	return nil
+}
+
+// addAttestationsDuplicateFields adds duplicate and conflicting fields to the t_attestations table.
+func addAttestationsDuplicateFields(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+ADD COLUMN IF NOT EXISTS f_duplicate BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_duplicate to attestations table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+ADD COLUMN IF NOT EXISTS f_conflicting BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_conflicting to attestations table")
+	}
+
+	return nil
+}
+
+// addETH1DepositsValid adds the validity flag to the t_eth1_deposits table.
+func addETH1DepositsValid(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_eth1_deposits
+ADD COLUMN IF NOT EXISTS f_valid BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_valid to Ethereum 1 deposits table")
+	}
+
+	return nil
+}
+
+// createETH1DepositInclusions creates the t_eth1_deposit_inclusions table.
+func createETH1DepositInclusions(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_eth1_deposit_inclusions (
+  f_deposit_index        BIGINT NOT NULL PRIMARY KEY
+ ,f_inclusion_slot       BIGINT
+ ,f_inclusion_block_root BYTEA REFERENCES t_blocks(f_root) ON DELETE CASCADE
+ ,f_inclusion_index      BIGINT
+ ,f_missing              BOOL NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_eth1_deposit_inclusions")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_eth1_deposit_inclusions_1 ON t_eth1_deposit_inclusions(f_inclusion_slot)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_eth1_deposit_inclusions_1")
+	}
+
+	return nil
+}
+
+// addBlocksProvenance adds the provenance fields to the blocks table.
+func addBlocksProvenance(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_blocks
+ADD COLUMN IF NOT EXISTS f_provenance_address TEXT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_provenance_address to blocks table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_blocks
+ADD COLUMN IF NOT EXISTS f_provenance_version TEXT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_provenance_version to blocks table")
+	}
+
+	return nil
+}
+
+// createEpochProvenance creates the t_epoch_provenance table.
+func createEpochProvenance(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_epoch_provenance (
+  f_dataset TEXT NOT NULL
+ ,f_epoch   BIGINT NOT NULL
+ ,f_address TEXT NOT NULL
+ ,f_version TEXT NOT NULL
+ ,PRIMARY KEY (f_dataset, f_epoch)
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_epoch_provenance")
+	}
+
+	return nil
+}
+
+// createExecutionEnrichment creates the t_execution_transactions and
+// t_execution_block_summaries tables.
+func createExecutionEnrichment(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_execution_transactions (
+  f_block_hash               BYTEA NOT NULL
+ ,f_block_number             BIGINT NOT NULL
+ ,f_index                    BIGINT NOT NULL
+ ,f_hash                     BYTEA NOT NULL
+ ,f_type                     BIGINT NOT NULL
+ ,f_from                     BYTEA NOT NULL
+ ,f_to                       BYTEA
+ ,f_nonce                    BIGINT NOT NULL
+ ,f_value                    NUMERIC NOT NULL
+ ,f_gas_limit                BIGINT NOT NULL
+ ,f_gas_price                NUMERIC
+ ,f_max_fee_per_gas          NUMERIC
+ ,f_max_priority_fee_per_gas NUMERIC
+ ,f_gas_used                 BIGINT NOT NULL
+ ,f_effective_gas_price      NUMERIC
+ ,f_status                   BIGINT NOT NULL
+ ,f_contract_address         BYTEA
+ ,f_logs                     INTEGER NOT NULL
+ ,PRIMARY KEY (f_block_hash, f_index)
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_execution_transactions")
+	}
+
+	for i, column := range []string{"f_block_number", "f_hash", "f_from", "f_to"} {
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+CREATE INDEX IF NOT EXISTS i_execution_transactions_%d ON t_execution_transactions(%s)
+`, i+1, column)); err != nil {
+			return errors.Wrapf(err, "failed to create i_execution_transactions_%d", i+1)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_execution_block_summaries (
+  f_block_hash          BYTEA NOT NULL PRIMARY KEY
+ ,f_block_number        BIGINT NOT NULL
+ ,f_transactions        INTEGER NOT NULL
+ ,f_failed_transactions INTEGER NOT NULL
+ ,f_contract_creations  INTEGER NOT NULL
+ ,f_logs                INTEGER NOT NULL
+ ,f_gas_used            BIGINT NOT NULL
+ ,f_total_fees          NUMERIC NOT NULL
+ ,f_burnt_fees          NUMERIC NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_execution_block_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_execution_block_summaries_1 ON t_execution_block_summaries(f_block_number)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_execution_block_summaries_1")
+	}
+
+	return nil
+}
+
+// createAddressLabels creates the t_address_labels table.
+func createAddressLabels(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_address_labels (
+  f_address BYTEA NOT NULL PRIMARY KEY
+ ,f_label   TEXT NOT NULL
+ ,f_source  TEXT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_address_labels")
+	}
+
+	return nil
+}
+
+// createValidatorLabels creates the t_validator_labels and t_validator_label_epoch_summaries tables.
+func createValidatorLabels(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_labels (
+  f_public_key BYTEA NOT NULL PRIMARY KEY
+ ,f_label      TEXT NOT NULL
+ ,f_source     TEXT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_labels")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_labels_1 ON t_validator_labels(f_label)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_labels_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_label_epoch_summaries (
+  f_label                        TEXT NOT NULL
+ ,f_epoch                        BIGINT NOT NULL
+ ,f_validators                   INTEGER NOT NULL
+ ,f_proposer_duties              INTEGER NOT NULL
+ ,f_proposals_included           INTEGER NOT NULL
+ ,f_attestations_included        INTEGER NOT NULL
+ ,f_attestations_target_correct  INTEGER NOT NULL
+ ,f_attestations_head_correct    INTEGER NOT NULL
+ ,f_attestations_inclusion_delay BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_label_epoch_summaries")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE UNIQUE INDEX IF NOT EXISTS i_validator_label_epoch_summaries_1 ON t_validator_label_epoch_summaries(f_label, f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_label_epoch_summaries_1")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_label_epoch_summaries_2 ON t_validator_label_epoch_summaries(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_label_epoch_summaries_2")
+	}
+
+	return nil
+}
+
+// addVoluntaryExitsQueue adds the exit queue information to voluntary exits.
+func addVoluntaryExitsQueue(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_voluntary_exits
+ADD COLUMN IF NOT EXISTS f_exit_epoch BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_exit_epoch to voluntary exits table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_voluntary_exits
+ADD COLUMN IF NOT EXISTS f_withdrawable_epoch BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_withdrawable_epoch to voluntary exits table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_voluntary_exits
+ADD COLUMN IF NOT EXISTS f_exit_queue_epochs BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_exit_queue_epochs to voluntary exits table")
+	}
+
+	return nil
+}
+
+// addSlashingRewards adds the including proposer and whistleblower reward to slashings.
+func addSlashingRewards(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attester_slashings
+ADD COLUMN IF NOT EXISTS f_inclusion_proposer_index BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_inclusion_proposer_index to attester slashings table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attester_slashings
+ADD COLUMN IF NOT EXISTS f_slashed_indices BIGINT[]
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_slashed_indices to attester slashings table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attester_slashings
+ADD COLUMN IF NOT EXISTS f_whistleblower_reward BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_whistleblower_reward to attester slashings table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_proposer_slashings
+ADD COLUMN IF NOT EXISTS f_inclusion_proposer_index BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_inclusion_proposer_index to proposer slashings table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_proposer_slashings
+ADD COLUMN IF NOT EXISTS f_whistleblower_reward BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_whistleblower_reward to proposer slashings table")
+	}
+
+	return nil
+}
+
+// createStateRoots creates the t_state_roots table.
+func createStateRoots(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_state_roots (
+  f_slot             BIGINT NOT NULL PRIMARY KEY
+ ,f_root             BYTEA NOT NULL
+ ,f_archive_location TEXT
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_state_roots")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_state_roots_1 ON t_state_roots(f_slot) WHERE f_archive_location IS NOT NULL
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_state_roots_1")
+	}
+
+	return nil
+}
+
+// addEpochSummaryQueues adds the exit queue, churn limit and queue waits to epoch summaries.
+func addEpochSummaryQueues(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_exit_queue_length BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_exit_queue_length to epoch summaries table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_churn_limit BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_churn_limit to epoch summaries table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_activation_queue_wait BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_activation_queue_wait to epoch summaries table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_exit_queue_wait BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_exit_queue_wait to epoch summaries table")
+	}
+
+	return nil
+}
+
+// createValidatorActivations creates the t_validator_activations table.
+func createValidatorActivations(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_validator_activations (
+  f_validator_index              BIGINT NOT NULL PRIMARY KEY
+ ,f_deposit_index                BIGINT NOT NULL
+ ,f_eth1_block_timestamp         TIMESTAMPTZ NOT NULL
+ ,f_inclusion_slot               BIGINT NOT NULL
+ ,f_activation_eligibility_epoch BIGINT NOT NULL
+ ,f_activation_epoch             BIGINT NOT NULL
+ ,f_inclusion_latency            BIGINT NOT NULL
+ ,f_eligibility_latency          BIGINT NOT NULL
+ ,f_activation_latency           BIGINT NOT NULL
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_validator_activations")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_validator_activations_1 ON t_validator_activations(f_activation_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_validator_activations_1")
+	}
+
+	return nil
+}
+
+// addCompressedBitfields adds compressed forms of the aggregation bits of
+// attestations and the bits of sync aggregates.
+func addCompressedBitfields(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+ALTER COLUMN f_aggregation_bits DROP NOT NULL
+`); err != nil {
+		return errors.Wrap(err, "failed to drop not null from f_aggregation_bits")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+ADD COLUMN IF NOT EXISTS f_aggregation_bits_compressed BYTEA
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_aggregation_bits_compressed to attestations table")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_sync_aggregates
+ALTER COLUMN f_bits DROP NOT NULL
+`); err != nil {
+		return errors.Wrap(err, "failed to drop not null from f_bits")
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_sync_aggregates
+ADD COLUMN IF NOT EXISTS f_bits_compressed BYTEA
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_bits_compressed to sync aggregates table")
+	}
+
+	return nil
+}
+
+// createEpochChecksums creates the t_epoch_checksums table.
+func createEpochChecksums(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS t_epoch_checksums (
+  f_dataset  TEXT NOT NULL
+ ,f_epoch    BIGINT NOT NULL
+ ,f_rows     BIGINT NOT NULL
+ ,f_checksum BYTEA NOT NULL
+ ,PRIMARY KEY (f_dataset, f_epoch)
+)
+`); err != nil {
+		return errors.Wrap(err, "failed to create t_epoch_checksums")
+	}
+
+	if _, err := tx.Exec(ctx, `
+CREATE INDEX IF NOT EXISTS i_epoch_checksums_1 ON t_epoch_checksums(f_epoch)
+`); err != nil {
+		return errors.Wrap(err, "failed to create i_epoch_checksums_1")
+	}
+
+	return nil
+}
+
+// addAttestationsSourceCorrect adds the source vote correctness field to attestations.
+func addAttestationsSourceCorrect(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_attestations
+ADD COLUMN IF NOT EXISTS f_source_correct BOOL
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_source_correct to attestations table")
+	}
+
+	return nil
+}
+
+// addValidatorEpochSummariesInclusionSlot adds the attestation inclusion slot field to validator epoch summaries.
+func addValidatorEpochSummariesInclusionSlot(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_validator_epoch_summaries
+ADD COLUMN IF NOT EXISTS f_attestation_inclusion_slot BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_attestation_inclusion_slot to validator epoch summaries table")
+	}
+
+	return nil
+}
+
+// addBlocksSeenDelay adds the seen delay field to blocks.
+func addBlocksSeenDelay(ctx context.Context, s *Service) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	if _, err := tx.Exec(ctx, `
+ALTER TABLE t_blocks
+ADD COLUMN IF NOT EXISTS f_seen_delay BIGINT
+`); err != nil {
+		return errors.Wrap(err, "failed to add f_seen_delay to blocks table")
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestUpgradeConcurrent(t *testing.T) {
+	ctx := context.Background()
+	schema := fmt.Sprintf("chaind_test_%d", time.Now().UnixNano())
+
+	services := make([]*postgresql.Service, 3)
+	for i := range services {
+		s, err := postgresql.New(ctx,
+			postgresql.WithLogLevel(zerolog.Disabled),
+			postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+			postgresql.WithSchema(schema),
+		)
+		require.NoError(t, err)
+		services[i] = s
+	}
+	defer func() {
+		require.NoError(t, services[0].DropSchema(ctx))
+	}()
+
+	// All instances upgrade at once; only one of them creates the tables.
+	var wg sync.WaitGroup
+	errs := make([]error, len(services))
+	for i := range services {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = services[i].Upgrade(ctx)
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	version, required, err := services[0].SchemaVersions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, required, version)
+
+	// A schema newer than this release is refused.
+	dbCtx, cancel, err := services[0].BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+	require.NoError(t, services[0].SetMetadata(dbCtx, "schema", []byte(fmt.Sprintf(`{"version":%d}`, required+1))))
+	require.NoError(t, services[0].CommitTx(dbCtx))
+	_, err = services[1].Upgrade(ctx)
+	require.EqualError(t, err, fmt.Sprintf("database schema version %d is newer than version %d supported by this release", required+1, required))
+}
@@ -0,0 +1,228 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// validatorActivationColumns is the number of columns written for each validator activation.
+const validatorActivationColumns = 9
+
+// maxValidatorActivationsPerStatement is the maximum number of validator activations written in a
+// single statement, as limited by the number of parameters a statement can have.
+const maxValidatorActivationsPerStatement = 65535 / validatorActivationColumns
+
+// SetValidatorActivations sets multiple validator activations.
+func (s *Service) SetValidatorActivations(ctx context.Context, activations []*chaindb.ValidatorActivation) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for start := 0; start < len(activations); start += maxValidatorActivationsPerStatement {
+		end := start + maxValidatorActivationsPerStatement
+		if end > len(activations) {
+			end = len(activations)
+		}
+		batch := activations[start:end]
+
+		values := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*validatorActivationColumns)
+		for i, activation := range batch {
+			placeholders := make([]string, validatorActivationColumns)
+			for j := range placeholders {
+				placeholders[j] = fmt.Sprintf("$%d", i*validatorActivationColumns+j+1)
+			}
+			values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+			args = append(args,
+				activation.Index,
+				activation.DepositIndex,
+				activation.ETH1BlockTimestamp,
+				activation.InclusionSlot,
+				activation.ActivationEligibilityEpoch,
+				activation.ActivationEpoch,
+				int64(activation.InclusionLatency.Seconds()),
+				int64(activation.EligibilityLatency.Seconds()),
+				int64(activation.ActivationLatency.Seconds()),
+			)
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+INSERT INTO t_validator_activations(f_validator_index
+                                   ,f_deposit_index
+                                   ,f_eth1_block_timestamp
+                                   ,f_inclusion_slot
+                                   ,f_activation_eligibility_epoch
+                                   ,f_activation_epoch
+                                   ,f_inclusion_latency
+                                   ,f_eligibility_latency
+                                   ,f_activation_latency
+                                   )
+VALUES %s
+ON CONFLICT (f_validator_index) DO
+UPDATE
+SET f_deposit_index = excluded.f_deposit_index
+   ,f_eth1_block_timestamp = excluded.f_eth1_block_timestamp
+   ,f_inclusion_slot = excluded.f_inclusion_slot
+   ,f_activation_eligibility_epoch = excluded.f_activation_eligibility_epoch
+   ,f_activation_epoch = excluded.f_activation_epoch
+   ,f_inclusion_latency = excluded.f_inclusion_latency
+   ,f_eligibility_latency = excluded.f_eligibility_latency
+   ,f_activation_latency = excluded.f_activation_latency
+`, strings.Join(values, ",")),
+			args...,
+		); err != nil {
+			return errors.Wrap(err, "failed to set validator activations")
+		}
+	}
+
+	return nil
+}
+
+// LatestValidatorActivation fetches the validator activation with the highest
+// activation epoch, or nil if there is none.
+func (s *Service) LatestValidatorActivation(ctx context.Context) (*chaindb.ValidatorActivation, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	activation := &chaindb.ValidatorActivation{}
+	var inclusionLatency int64
+	var eligibilityLatency int64
+	var activationLatency int64
+	err = tx.QueryRow(ctx, `
+SELECT f_validator_index
+      ,f_deposit_index
+      ,f_eth1_block_timestamp
+      ,f_inclusion_slot
+      ,f_activation_eligibility_epoch
+      ,f_activation_epoch
+      ,f_inclusion_latency
+      ,f_eligibility_latency
+      ,f_activation_latency
+FROM t_validator_activations
+ORDER BY f_activation_epoch DESC
+        ,f_validator_index DESC
+LIMIT 1
+`).Scan(
+		&activation.Index,
+		&activation.DepositIndex,
+		&activation.ETH1BlockTimestamp,
+		&activation.InclusionSlot,
+		&activation.ActivationEligibilityEpoch,
+		&activation.ActivationEpoch,
+		&inclusionLatency,
+		&eligibilityLatency,
+		&activationLatency,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to obtain latest validator activation")
+	}
+	activation.InclusionLatency = time.Duration(inclusionLatency) * time.Second
+	activation.EligibilityLatency = time.Duration(eligibilityLatency) * time.Second
+	activation.ActivationLatency = time.Duration(activationLatency) * time.Second
+
+	return activation, nil
+}
+
+// ValidatorActivationLatencies fetches the given percentile, between 0 and 1, of the
+// latencies of validators activated in the given epoch range.
+func (s *Service) ValidatorActivationLatencies(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	percentile float64,
+) (
+	*chaindb.ValidatorActivationLatencies,
+	error,
+) {
+	if percentile < 0 || percentile > 1 {
+		return nil, errors.New("percentile must be between 0 and 1")
+	}
+
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	latencies := &chaindb.ValidatorActivationLatencies{}
+	var inclusion sql.NullFloat64
+	var eligibility sql.NullFloat64
+	var activation sql.NullFloat64
+	var total sql.NullFloat64
+	if err := tx.QueryRow(ctx, `
+SELECT COUNT(*)
+      ,PERCENTILE_CONT($3) WITHIN GROUP (ORDER BY f_inclusion_latency)
+      ,PERCENTILE_CONT($3) WITHIN GROUP (ORDER BY f_eligibility_latency)
+      ,PERCENTILE_CONT($3) WITHIN GROUP (ORDER BY f_activation_latency)
+      ,PERCENTILE_CONT($3) WITHIN GROUP (ORDER BY f_inclusion_latency + f_eligibility_latency + f_activation_latency)
+FROM t_validator_activations
+WHERE f_activation_epoch >= $1
+  AND f_activation_epoch < $2
+`,
+		startEpoch,
+		endEpoch,
+		percentile,
+	).Scan(
+		&latencies.Validators,
+		&inclusion,
+		&eligibility,
+		&activation,
+		&total,
+	); err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator activation latencies")
+	}
+	latencies.Inclusion = secondsDuration(inclusion)
+	latencies.Eligibility = secondsDuration(eligibility)
+	latencies.Activation = secondsDuration(activation)
+	latencies.Total = secondsDuration(total)
+
+	return latencies, nil
+}
+
+// secondsDuration converts a nullable number of seconds to a duration.
+func secondsDuration(seconds sql.NullFloat64) time.Duration {
+	if !seconds.Valid {
+		return 0
+	}
+
+	return time.Duration(seconds.Float64 * float64(time.Second))
+}
@@ -21,89 +21,90 @@ import (
 	"strings"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
-	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
 )
 
+// SetValidatorEpochSummary sets a validator epoch summary.
+func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	return s.SetValidatorEpochSummaries(ctx, []*chaindb.ValidatorEpochSummary{summary})
+}
+
+// validatorEpochSummaryColumns is the number of columns written for each validator epoch summary.
+const validatorEpochSummaryColumns = 12
+
+// maxValidatorEpochSummariesPerStatement is the maximum number of validator epoch summaries written in a
+// single statement, as limited by the number of parameters a statement can have.
+const maxValidatorEpochSummariesPerStatement = 65535 / validatorEpochSummaryColumns
+
 // SetValidatorEpochSummaries sets multiple validator epoch summaries.
+// Summaries are written in a single statement, rather than a statement per summary.
 func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
 	tx := s.tx(ctx)
 	if tx == nil {
 		return ErrNoTransaction
 	}
 
-	// Create a savepoint in case the copy fails.
-	nestedTx, err := tx.Begin(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to create nested transaction")
-	}
-
-	_, err = nestedTx.CopyFrom(ctx,
-		pgx.Identifier{"t_validator_epoch_summaries"},
-		[]string{
-			"f_validator_index",
-			"f_epoch",
-			"f_proposer_duties",
-			"f_proposals_included",
-			"f_attestation_included",
-			"f_attestation_target_correct",
-			"f_attestation_head_correct",
-			"f_attestation_inclusion_delay",
-			"f_attestation_source_timely",
-			"f_attestation_target_timely",
-			"f_attestation_head_timely",
-		},
-		pgx.CopyFromSlice(len(summaries), func(i int) ([]interface{}, error) {
-			return []interface{}{
-				summaries[i].Index,
-				summaries[i].Epoch,
-				summaries[i].ProposerDuties,
-				summaries[i].ProposalsIncluded,
-				summaries[i].AttestationIncluded,
-				summaries[i].AttestationTargetCorrect,
-				summaries[i].AttestationHeadCorrect,
-				summaries[i].AttestationInclusionDelay,
-				summaries[i].AttestationSourceTimely,
-				summaries[i].AttestationTargetTimely,
-				summaries[i].AttestationHeadTimely,
-			}, nil
-		}))
-
-	if err == nil {
-		if err := nestedTx.Commit(ctx); err != nil {
-			return errors.Wrap(err, "failed to commit nested transaction")
+	for start := 0; start < len(summaries); start += maxValidatorEpochSummariesPerStatement {
+		end := start + maxValidatorEpochSummariesPerStatement
+		if end > len(summaries) {
+			end = len(summaries)
 		}
-	} else {
-		if err := nestedTx.Rollback(ctx); err != nil {
-			return errors.Wrap(err, "failed to roll back nested transaction")
-		}
-
-		log.Debug().Err(err).Msg("Failed to copy insert blocks; applying one at a time")
-		for _, summary := range summaries {
-			if err := s.SetValidatorEpochSummary(ctx, summary); err != nil {
-				log.Info().Msg("Failure to insert individual summary")
-				return err
+		batch := summaries[start:end]
+
+		values := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*validatorEpochSummaryColumns)
+		for i, summary := range batch {
+			placeholders := make([]string, validatorEpochSummaryColumns)
+			for j := range placeholders {
+				placeholders[j] = fmt.Sprintf("$%d", i*validatorEpochSummaryColumns+j+1)
 			}
+			values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+			args = append(args, validatorEpochSummaryArgs(summary)...)
 		}
 
-		// Succeeded so clear the error.
-		err = nil
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+      INSERT INTO t_validator_epoch_summaries(f_validator_index
+                              ,f_epoch
+                              ,f_proposer_duties
+                              ,f_proposals_included
+                              ,f_attestation_included
+                              ,f_attestation_target_correct
+                              ,f_attestation_head_correct
+                              ,f_attestation_inclusion_delay
+                              ,f_attestation_inclusion_slot
+                              ,f_attestation_source_timely
+                              ,f_attestation_target_timely
+                              ,f_attestation_head_timely)
+      VALUES %s
+      ON CONFLICT (f_validator_index,f_epoch) DO
+      UPDATE
+      SET f_proposer_duties = excluded.f_proposer_duties
+         ,f_proposals_included = excluded.f_proposals_included
+         ,f_attestation_included = excluded.f_attestation_included
+         ,f_attestation_target_correct = excluded.f_attestation_target_correct
+         ,f_attestation_head_correct = excluded.f_attestation_head_correct
+         ,f_attestation_inclusion_delay = excluded.f_attestation_inclusion_delay
+         ,f_attestation_inclusion_slot = excluded.f_attestation_inclusion_slot
+         ,f_attestation_source_timely = excluded.f_attestation_source_timely
+         ,f_attestation_target_timely = excluded.f_attestation_target_timely
+         ,f_attestation_head_timely = excluded.f_attestation_head_timely
+		 `, strings.Join(values, ",")),
+			args...,
+		); err != nil {
+			return err
+		}
 	}
 
-	return err
+	return nil
 }
 
-// SetValidatorEpochSummary sets a validator epoch summary.
-func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
-	tx := s.tx(ctx)
-	if tx == nil {
-		return ErrNoTransaction
-	}
-
+// validatorEpochSummaryArgs returns the arguments for the columns written for a validator epoch summary.
+func validatorEpochSummaryArgs(summary *chaindb.ValidatorEpochSummary) []interface{} {
 	var attestationTargetCorrect sql.NullBool
 	var attestationHeadCorrect sql.NullBool
 	var attestationInclusionDelay sql.NullInt32
+	var attestationInclusionSlot sql.NullInt64
 	var attestationSourceTimely sql.NullBool
 	var attestationTargetTimely sql.NullBool
 	var attestationHeadTimely sql.NullBool
@@ -120,6 +121,10 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationInclusionDelay.Valid = true
 		attestationInclusionDelay.Int32 = int32(*summary.AttestationInclusionDelay)
 	}
+	if summary.AttestationInclusionSlot != nil {
+		attestationInclusionSlot.Valid = true
+		attestationInclusionSlot.Int64 = int64(*summary.AttestationInclusionSlot)
+	}
 	if summary.AttestationSourceTimely != nil {
 		attestationSourceTimely.Valid = true
 		attestationSourceTimely.Bool = *summary.AttestationSourceTimely
@@ -133,31 +138,7 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationHeadTimely.Bool = *summary.AttestationHeadTimely
 	}
 
-	_, err := tx.Exec(ctx, `
-      INSERT INTO t_validator_epoch_summaries(f_validator_index
-                              ,f_epoch
-                              ,f_proposer_duties
-                              ,f_proposals_included
-                              ,f_attestation_included
-                              ,f_attestation_target_correct
-                              ,f_attestation_head_correct
-                              ,f_attestation_inclusion_delay
-                              ,f_attestation_source_timely
-                              ,f_attestation_target_timely
-                              ,f_attestation_head_timely)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
-      ON CONFLICT (f_validator_index,f_epoch) DO
-      UPDATE
-      SET f_proposer_duties = excluded.f_proposer_duties
-         ,f_proposals_included = excluded.f_proposals_included
-         ,f_attestation_included = excluded.f_attestation_included
-         ,f_attestation_target_correct = excluded.f_attestation_target_correct
-         ,f_attestation_head_correct = excluded.f_attestation_head_correct
-         ,f_attestation_inclusion_delay = excluded.f_attestation_inclusion_delay
-         ,f_attestation_source_timely = excluded.f_attestation_source_timely
-         ,f_attestation_target_timely = excluded.f_attestation_target_timely
-         ,f_attestation_head_timely = excluded.f_attestation_head_timely
-		 `,
+	return []interface{}{
 		summary.Index,
 		summary.Epoch,
 		summary.ProposerDuties,
@@ -166,12 +147,11 @@ func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb
 		attestationTargetCorrect,
 		attestationHeadCorrect,
 		attestationInclusionDelay,
+		attestationInclusionSlot,
 		attestationSourceTimely,
 		attestationTargetTimely,
 		attestationHeadTimely,
-	)
-
-	return err
+	}
 }
 
 // ValidatorSummaries provides summaries according to the filter.
@@ -199,6 +179,7 @@ SELECT f_validator_index
       ,f_attestation_target_correct
       ,f_attestation_head_correct
       ,f_attestation_inclusion_delay
+      ,f_attestation_inclusion_slot
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
@@ -217,6 +198,7 @@ FROM t_validator_epoch_summaries`)
 		queryVals = append(queryVals, *filter.To)
 		queryBuilder.WriteString(fmt.Sprintf(`
 %s f_epoch <= $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
 	}
 
 	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
@@ -255,6 +237,7 @@ LIMIT $%d`, len(queryVals)))
 		var attestationTargetCorrect sql.NullBool
 		var attestationHeadCorrect sql.NullBool
 		var attestationInclusionDelay sql.NullInt32
+		var attestationInclusionSlot sql.NullInt64
 		var attestationSourceTimely sql.NullBool
 		var attestationTargetTimely sql.NullBool
 		var attestationHeadTimely sql.NullBool
@@ -267,6 +250,7 @@ LIMIT $%d`, len(queryVals)))
 			&attestationTargetCorrect,
 			&attestationHeadCorrect,
 			&attestationInclusionDelay,
+			&attestationInclusionSlot,
 			&attestationSourceTimely,
 			&attestationTargetTimely,
 			&attestationHeadTimely,
@@ -286,6 +270,10 @@ LIMIT $%d`, len(queryVals)))
 			val := int(attestationInclusionDelay.Int32)
 			summary.AttestationInclusionDelay = &val
 		}
+		if attestationInclusionSlot.Valid {
+			val := phase0.Slot(attestationInclusionSlot.Int64)
+			summary.AttestationInclusionSlot = &val
+		}
 		if attestationSourceTimely.Valid {
 			val := attestationSourceTimely.Bool
 			summary.AttestationSourceTimely = &val
@@ -333,6 +321,7 @@ SELECT f_validator_index
       ,f_attestation_target_correct
       ,f_attestation_head_correct
       ,f_attestation_inclusion_delay
+      ,f_attestation_inclusion_slot
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
@@ -354,6 +343,7 @@ ORDER BY f_validator_index
 		var attestationTargetCorrect sql.NullBool
 		var attestationHeadCorrect sql.NullBool
 		var attestationInclusionDelay sql.NullInt32
+		var attestationInclusionSlot sql.NullInt64
 		var attestationSourceTimely sql.NullBool
 		var attestationTargetTimely sql.NullBool
 		var attestationHeadTimely sql.NullBool
@@ -366,6 +356,7 @@ ORDER BY f_validator_index
 			&attestationTargetCorrect,
 			&attestationHeadCorrect,
 			&attestationInclusionDelay,
+			&attestationInclusionSlot,
 			&attestationSourceTimely,
 			&attestationTargetTimely,
 			&attestationHeadTimely,
@@ -385,6 +376,10 @@ ORDER BY f_validator_index
 			val := int(attestationInclusionDelay.Int32)
 			summary.AttestationInclusionDelay = &val
 		}
+		if attestationInclusionSlot.Valid {
+			val := phase0.Slot(attestationInclusionSlot.Int64)
+			summary.AttestationInclusionSlot = &val
+		}
 		if attestationSourceTimely.Valid {
 			val := attestationSourceTimely.Bool
 			summary.AttestationSourceTimely = &val
@@ -425,6 +420,7 @@ func (s *Service) ValidatorSummaryForEpoch(ctx context.Context,
 	var attestationTargetCorrect sql.NullBool
 	var attestationHeadCorrect sql.NullBool
 	var attestationInclusionDelay sql.NullInt32
+	var attestationInclusionSlot sql.NullInt64
 	var attestationSourceTimely sql.NullBool
 	var attestationTargetTimely sql.NullBool
 	var attestationHeadTimely sql.NullBool
@@ -438,6 +434,7 @@ SELECT f_validator_index
       ,f_attestation_target_correct
       ,f_attestation_head_correct
       ,f_attestation_inclusion_delay
+      ,f_attestation_inclusion_slot
       ,f_attestation_source_timely
       ,f_attestation_target_timely
       ,f_attestation_head_timely
@@ -456,6 +453,7 @@ WHERE f_validator_index = $1
 		&attestationTargetCorrect,
 		&attestationHeadCorrect,
 		&attestationInclusionDelay,
+		&attestationInclusionSlot,
 		&attestationSourceTimely,
 		&attestationTargetTimely,
 		&attestationHeadTimely,
@@ -476,6 +474,10 @@ WHERE f_validator_index = $1
 		val := int(attestationInclusionDelay.Int32)
 		summary.AttestationInclusionDelay = &val
 	}
+	if attestationInclusionSlot.Valid {
+		val := phase0.Slot(attestationInclusionSlot.Int64)
+		summary.AttestationInclusionSlot = &val
+	}
 	if attestationSourceTimely.Valid {
 		val := attestationSourceTimely.Bool
 		summary.AttestationSourceTimely = &val
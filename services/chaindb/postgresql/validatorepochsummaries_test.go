@@ -0,0 +1,55 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaindb/postgresql"
+)
+
+func TestSetValidatorEpochSummariesRepeated(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	delay := 1
+	inclusionSlot := phase0.Slot(32000001)
+	summaries := []*chaindb.ValidatorEpochSummary{
+		{Index: 1, Epoch: 1000000, AttestationIncluded: true, AttestationInclusionDelay: &delay, AttestationInclusionSlot: &inclusionSlot},
+		{Index: 2, Epoch: 1000000, AttestationIncluded: false},
+	}
+	require.NoError(t, s.SetValidatorEpochSummaries(ctx, summaries))
+
+	// Setting the summaries again, with one changed, should update rather than fail.
+	summaries[1].AttestationIncluded = true
+	summaries[1].AttestationInclusionDelay = &delay
+	summaries[1].AttestationInclusionSlot = &inclusionSlot
+	require.NoError(t, s.SetValidatorEpochSummaries(ctx, summaries))
+
+	res, err := s.ValidatorSummaryForEpoch(ctx, 2, 1000000)
+	require.NoError(t, err)
+	require.Equal(t, summaries[1], res)
+}
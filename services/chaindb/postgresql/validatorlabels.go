@@ -0,0 +1,156 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// validatorLabelColumns is the number of columns written for each validator label.
+const validatorLabelColumns = 3
+
+// maxValidatorLabelsPerStatement is the maximum number of validator labels written in a
+// single statement, as limited by the number of parameters a statement can have.
+const maxValidatorLabelsPerStatement = 65535 / validatorLabelColumns
+
+// SetValidatorLabels sets validator labels.
+func (s *Service) SetValidatorLabels(ctx context.Context, labels []*chaindb.ValidatorLabel) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for start := 0; start < len(labels); start += maxValidatorLabelsPerStatement {
+		end := start + maxValidatorLabelsPerStatement
+		if end > len(labels) {
+			end = len(labels)
+		}
+		batch := labels[start:end]
+
+		values := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*validatorLabelColumns)
+		for i, label := range batch {
+			values = append(values, fmt.Sprintf("($%d,$%d,$%d)", i*validatorLabelColumns+1, i*validatorLabelColumns+2, i*validatorLabelColumns+3))
+			args = append(args, label.PublicKey[:], label.Label, label.Source)
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
+INSERT INTO t_validator_labels(f_public_key
+                              ,f_label
+                              ,f_source
+                              )
+VALUES %s
+ON CONFLICT (f_public_key) DO
+UPDATE
+SET f_label = excluded.f_label
+   ,f_source = excluded.f_source
+`, strings.Join(values, ",")),
+			args...,
+		); err != nil {
+			return errors.Wrap(err, "failed to set validator labels")
+		}
+	}
+
+	return nil
+}
+
+// ValidatorLabels fetches the labels for all labeled validators.
+func (s *Service) ValidatorLabels(ctx context.Context) (map[phase0.BLSPubKey]string, error) {
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, cancel, err := s.BeginTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer cancel()
+	}
+
+	rows, err := tx.Query(ctx, `
+SELECT f_public_key
+      ,f_label
+FROM t_validator_labels
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make(map[phase0.BLSPubKey]string)
+	for rows.Next() {
+		var publicKey []byte
+		var label string
+		if err := rows.Scan(&publicKey, &label); err != nil {
+			return nil, errors.Wrap(err, "failed to scan row")
+		}
+		var key phase0.BLSPubKey
+		copy(key[:], publicKey)
+		labels[key] = label
+	}
+
+	return labels, nil
+}
+
+// SetValidatorLabelEpochSummaries sets multiple validator label epoch summaries.
+func (s *Service) SetValidatorLabelEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorLabelEpochSummary) error {
+	tx := s.tx(ctx)
+	if tx == nil {
+		return ErrNoTransaction
+	}
+
+	for _, summary := range summaries {
+		if _, err := tx.Exec(ctx, `
+INSERT INTO t_validator_label_epoch_summaries(f_label
+                                             ,f_epoch
+                                             ,f_validators
+                                             ,f_proposer_duties
+                                             ,f_proposals_included
+                                             ,f_attestations_included
+                                             ,f_attestations_target_correct
+                                             ,f_attestations_head_correct
+                                             ,f_attestations_inclusion_delay
+                                             )
+VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
+ON CONFLICT (f_label,f_epoch) DO
+UPDATE
+SET f_validators = excluded.f_validators
+   ,f_proposer_duties = excluded.f_proposer_duties
+   ,f_proposals_included = excluded.f_proposals_included
+   ,f_attestations_included = excluded.f_attestations_included
+   ,f_attestations_target_correct = excluded.f_attestations_target_correct
+   ,f_attestations_head_correct = excluded.f_attestations_head_correct
+   ,f_attestations_inclusion_delay = excluded.f_attestations_inclusion_delay
+`,
+			summary.Label,
+			summary.Epoch,
+			summary.Validators,
+			summary.ProposerDuties,
+			summary.ProposalsIncluded,
+			summary.AttestationsIncluded,
+			summary.AttestationsTargetCorrect,
+			summary.AttestationsHeadCorrect,
+			summary.AttestationsInclusionDelay,
+		); err != nil {
+			return errors.Wrap(err, "failed to set validator label epoch summary")
+		}
+	}
+
+	return nil
+}
@@ -65,8 +65,9 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
                               ,f_activation_epoch
                               ,f_exit_epoch
                               ,f_withdrawable_epoch
-                              ,f_effective_balance)
-      VALUES($1,$2,$3,$4,$5,$6,$7,$8)
+                              ,f_effective_balance
+                              ,f_withdrawal_credentials)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)
       ON CONFLICT (f_index) DO
       UPDATE
       SET f_public_key = excluded.f_public_key
@@ -76,6 +77,7 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
          ,f_exit_epoch = excluded.f_exit_epoch
          ,f_withdrawable_epoch = excluded.f_withdrawable_epoch
          ,f_effective_balance = excluded.f_effective_balance
+         ,f_withdrawal_credentials = excluded.f_withdrawal_credentials
 		 `,
 		validator.PublicKey[:],
 		validator.Index,
@@ -85,6 +87,7 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
 		exitEpoch,
 		withdrawableEpoch,
 		validator.EffectiveBalance,
+		validator.WithdrawalCredentials,
 	)
 
 	return err
@@ -92,55 +95,65 @@ func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator
 
 // SetValidatorBalance sets a validator's balance.
 func (s *Service) SetValidatorBalance(ctx context.Context, balance *chaindb.ValidatorBalance) error {
+	return s.SetValidatorBalances(ctx, []*chaindb.ValidatorBalance{balance})
+}
+
+// validatorBalanceColumns is the number of columns written for each validator balance.
+const validatorBalanceColumns = 4
+
+// maxValidatorBalancesPerStatement is the maximum number of validator balances written in a
+// single statement, as limited by the number of parameters a statement can have.
+const maxValidatorBalancesPerStatement = 65535 / validatorBalanceColumns
+
+// SetValidatorBalances sets multiple validator balances.
+// Balances are written in a single statement, rather than a statement per balance.
+func (s *Service) SetValidatorBalances(ctx context.Context, balances []*chaindb.ValidatorBalance) error {
 	tx := s.tx(ctx)
 	if tx == nil {
 		return ErrNoTransaction
 	}
 
-	_, err := tx.Exec(ctx, `
+	for start := 0; start < len(balances); start += maxValidatorBalancesPerStatement {
+		end := start + maxValidatorBalancesPerStatement
+		if end > len(balances) {
+			end = len(balances)
+		}
+		batch := balances[start:end]
+
+		values := make([]string, 0, len(batch))
+		args := make([]interface{}, 0, len(batch)*validatorBalanceColumns)
+		for i, balance := range batch {
+			placeholders := make([]string, validatorBalanceColumns)
+			for j := range placeholders {
+				placeholders[j] = fmt.Sprintf("$%d", i*validatorBalanceColumns+j+1)
+			}
+			values = append(values, fmt.Sprintf("(%s)", strings.Join(placeholders, ",")))
+			args = append(args,
+				balance.Index,
+				balance.Epoch,
+				balance.Balance,
+				balance.EffectiveBalance,
+			)
+		}
+
+		if _, err := tx.Exec(ctx, fmt.Sprintf(`
       INSERT INTO t_validator_balances(f_validator_index
                                       ,f_epoch
                                       ,f_balance
                                       ,f_effective_balance)
-      VALUES($1,$2,$3,$4)
+      VALUES %s
       ON CONFLICT (f_validator_index, f_epoch) DO
       UPDATE
       SET f_balance = excluded.f_balance
          ,f_effective_balance = excluded.f_effective_balance
-		 `,
-		balance.Index,
-		balance.Epoch,
-		balance.Balance,
-		balance.EffectiveBalance,
-	)
-
-	return err
-}
-
-// SetValidatorBalances sets multiple validator balances.
-func (s *Service) SetValidatorBalances(ctx context.Context, balances []*chaindb.ValidatorBalance) error {
-	tx := s.tx(ctx)
-	if tx == nil {
-		return ErrNoTransaction
+		 `, strings.Join(values, ",")),
+			args...,
+		); err != nil {
+			return err
+		}
 	}
 
-	_, err := tx.CopyFrom(ctx,
-		pgx.Identifier{"t_validator_balances"},
-		[]string{
-			"f_validator_index",
-			"f_epoch",
-			"f_balance",
-			"f_effective_balance",
-		},
-		pgx.CopyFromSlice(len(balances), func(i int) ([]interface{}, error) {
-			return []interface{}{
-				balances[i].Index,
-				balances[i].Epoch,
-				balances[i].Balance,
-				balances[i].EffectiveBalance,
-			}, nil
-		}))
-	return err
+	return nil
 }
 
 // Validators fetches all validators.
@@ -164,6 +177,7 @@ func (s *Service) Validators(ctx context.Context) ([]*chaindb.Validator, error)
             ,f_exit_epoch
             ,f_withdrawable_epoch
             ,f_effective_balance
+            ,f_withdrawal_credentials
       FROM t_validators
       ORDER BY f_index
 	  `)
@@ -212,6 +226,7 @@ func (s *Service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BL
             ,f_exit_epoch
             ,f_withdrawable_epoch
             ,f_effective_balance
+            ,f_withdrawal_credentials
       FROM t_validators
       WHERE f_public_key = ANY($1)
       ORDER BY f_index
@@ -256,6 +271,7 @@ func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.Valida
             ,f_exit_epoch
             ,f_withdrawable_epoch
             ,f_effective_balance
+            ,f_withdrawal_credentials
       FROM t_validators
       WHERE f_index = ANY($1)
       ORDER BY f_index
@@ -279,6 +295,123 @@ func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.Valida
 	return validators, nil
 }
 
+// ValidatorsWithFilter provides validators according to the filter.
+func (s *Service) ValidatorsWithFilter(ctx context.Context, filter *chaindb.ValidatorFilter) ([]*chaindb.Validator, error) {
+	var err error
+
+	tx := s.tx(ctx)
+	if tx == nil {
+		ctx, err = s.beginROTx(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to begin transaction")
+		}
+		tx = s.tx(ctx)
+		defer s.commitROTx(ctx)
+	}
+
+	// Build the query.
+	queryBuilder := strings.Builder{}
+	queryVals := make([]interface{}, 0)
+
+	queryBuilder.WriteString(`
+SELECT f_public_key
+      ,f_index
+      ,f_slashed
+      ,f_activation_eligibility_epoch
+      ,f_activation_epoch
+      ,f_exit_epoch
+      ,f_withdrawable_epoch
+      ,f_effective_balance
+      ,f_withdrawal_credentials
+FROM t_validators`)
+
+	wherestr := "WHERE"
+
+	if filter.ValidatorIndices != nil && len(*filter.ValidatorIndices) > 0 {
+		queryVals = append(queryVals, *filter.ValidatorIndices)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_index = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.PublicKeys != nil && len(*filter.PublicKeys) > 0 {
+		sqlPubKeys := make([][]byte, len(*filter.PublicKeys))
+		for i := range *filter.PublicKeys {
+			sqlPubKeys[i] = (*filter.PublicKeys)[i][:]
+		}
+		queryVals = append(queryVals, sqlPubKeys)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_public_key = ANY($%d)`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.ActiveAt != nil {
+		queryVals = append(queryVals, *filter.ActiveAt)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_activation_epoch <= $%d
+  AND (f_exit_epoch IS NULL OR f_exit_epoch > $%d)`, wherestr, len(queryVals), len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Slashed != nil {
+		queryVals = append(queryVals, *filter.Slashed)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_slashed = $%d`, wherestr, len(queryVals)))
+		wherestr = "  AND"
+	}
+
+	if filter.Cursor != nil {
+		comparison := ">"
+		if filter.Order == chaindb.OrderLatest {
+			comparison = "<"
+		}
+		queryVals = append(queryVals, *filter.Cursor)
+		queryBuilder.WriteString(fmt.Sprintf(`
+%s f_index %s $%d`, wherestr, comparison, len(queryVals)))
+	}
+
+	switch filter.Order {
+	case chaindb.OrderEarliest:
+		queryBuilder.WriteString(`
+ORDER BY f_index`)
+	case chaindb.OrderLatest:
+		queryBuilder.WriteString(`
+ORDER BY f_index DESC`)
+	default:
+		return nil, errors.New("no order specified")
+	}
+
+	if filter.Limit > 0 {
+		queryVals = append(queryVals, filter.Limit)
+		queryBuilder.WriteString(fmt.Sprintf(`
+LIMIT $%d`, len(queryVals)))
+	}
+
+	rows, err := tx.Query(ctx,
+		queryBuilder.String(),
+		queryVals...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	validators := make([]*chaindb.Validator, 0)
+	for rows.Next() {
+		validator, err := validatorFromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, validator)
+	}
+
+	// Always return order of validator index.
+	sort.Slice(validators, func(i int, j int) bool {
+		return validators[i].Index < validators[j].Index
+	})
+	return validators, nil
+}
+
 // ValidatorBalancesByIndexAndEpoch fetches the validator balances for the given validators and epoch.
 func (s *Service) ValidatorBalancesByIndexAndEpoch(
 	ctx context.Context,
@@ -515,6 +648,7 @@ func validatorFromRow(rows pgx.Rows) (*chaindb.Validator, error) {
 		&exitEpoch,
 		&withdrawableEpoch,
 		&validator.EffectiveBalance,
+		&validator.WithdrawalCredentials,
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to scan row")
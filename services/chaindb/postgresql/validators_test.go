@@ -97,3 +97,30 @@ func TestValidators(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, len(validators) > 0)
 }
+
+func TestSetValidatorBalancesRepeated(t *testing.T) {
+	ctx := context.Background()
+	s, err := postgresql.New(ctx,
+		postgresql.WithConnectionURL(os.Getenv("CHAINDB_URL")),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	defer cancel()
+
+	balances := []*chaindb.ValidatorBalance{
+		{Index: 1, Epoch: 1000000, Balance: 32000000000, EffectiveBalance: 32000000000},
+		{Index: 2, Epoch: 1000000, Balance: 31000000000, EffectiveBalance: 31000000000},
+	}
+	require.NoError(t, s.SetValidatorBalances(ctx, balances))
+
+	// Setting the balances again, with one changed, should update rather than fail.
+	balances[1].Balance = 31500000000
+	require.NoError(t, s.SetValidatorBalances(ctx, balances))
+
+	res, err := s.ValidatorBalancesByIndexAndEpoch(ctx, []phase0.ValidatorIndex{1, 2}, 1000000)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, balances[1], res[2])
+}
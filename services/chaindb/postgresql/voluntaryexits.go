@@ -15,6 +15,7 @@ package postgresql
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/wealdtech/chaind/services/chaindb"
 )
@@ -26,24 +27,49 @@ func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.V
 		return ErrNoTransaction
 	}
 
+	var exitEpoch sql.NullInt64
+	if voluntaryExit.ExitEpoch != nil {
+		exitEpoch.Valid = true
+		exitEpoch.Int64 = int64(*voluntaryExit.ExitEpoch)
+	}
+	var withdrawableEpoch sql.NullInt64
+	if voluntaryExit.WithdrawableEpoch != nil {
+		withdrawableEpoch.Valid = true
+		withdrawableEpoch.Int64 = int64(*voluntaryExit.WithdrawableEpoch)
+	}
+	var exitQueueEpochs sql.NullInt64
+	if voluntaryExit.ExitQueueEpochs != nil {
+		exitQueueEpochs.Valid = true
+		exitQueueEpochs.Int64 = int64(*voluntaryExit.ExitQueueEpochs)
+	}
+
 	_, err := tx.Exec(ctx, `
       INSERT INTO t_voluntary_exits(f_inclusion_slot
                                    ,f_inclusion_block_root
                                    ,f_inclusion_index
                                    ,f_validator_index
                                    ,f_epoch
+                                   ,f_exit_epoch
+                                   ,f_withdrawable_epoch
+                                   ,f_exit_queue_epochs
       )
-      VALUES($1,$2,$3,$4,$5)
+      VALUES($1,$2,$3,$4,$5,$6,$7,$8)
       ON CONFLICT (f_inclusion_slot,f_inclusion_block_root,f_inclusion_index) DO
       UPDATE
       SET f_validator_index = excluded.f_validator_index
          ,f_epoch = excluded.f_epoch
+         ,f_exit_epoch = excluded.f_exit_epoch
+         ,f_withdrawable_epoch = excluded.f_withdrawable_epoch
+         ,f_exit_queue_epochs = excluded.f_exit_queue_epochs
       `,
 		voluntaryExit.InclusionSlot,
 		voluntaryExit.InclusionBlockRoot[:],
 		voluntaryExit.InclusionIndex,
 		voluntaryExit.ValidatorIndex,
 		voluntaryExit.Epoch,
+		exitEpoch,
+		withdrawableEpoch,
+		exitQueueEpochs,
 	)
 
 	return err
@@ -28,6 +28,9 @@ type AttestationsProvider interface {
 	// AttestationsInBlock fetches all attestations contained in the given block.
 	AttestationsInBlock(ctx context.Context, blockRoot phase0.Root) ([]*Attestation, error)
 
+	// Attestations provides attestations according to the filter.
+	Attestations(ctx context.Context, filter *AttestationFilter) ([]*Attestation, error)
+
 	// AttestationsForSlotRange fetches all attestations made for the given slot range.
 	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
 	// attestations for slots 2 and 3.
@@ -46,6 +49,9 @@ type AttestationsProvider interface {
 type AttestationsSetter interface {
 	// SetAttestation sets an attestation.
 	SetAttestation(ctx context.Context, attestation *Attestation) error
+
+	// SetAttestations sets multiple attestations.
+	SetAttestations(ctx context.Context, attestations []*Attestation) error
 }
 
 // AttesterSlashingsProvider defines functions to obtain attester slashings.
@@ -96,6 +102,9 @@ type BlocksProvider interface {
 	// BlocksByParentRoot fetches the blocks with the given parent root.
 	BlocksByParentRoot(ctx context.Context, root phase0.Root) ([]*Block, error)
 
+	// Blocks provides blocks according to the filter.
+	Blocks(ctx context.Context, filter *BlockFilter) ([]*Block, error)
+
 	// EmptySlots fetches the slots in the given range without a block in the database.
 	EmptySlots(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
 
@@ -148,6 +157,21 @@ type ForkScheduleSetter interface {
 	SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error
 }
 
+// GapsProvider defines functions to find data missing from the database.
+type GapsProvider interface {
+	// SlotsWithoutBeaconCommittees fetches the slots in the given range without beacon committees
+	// in the database, ignoring slots before the first or after the last with beacon committees.
+	SlotsWithoutBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
+
+	// SlotsWithoutProposerDuties fetches the slots in the given range without a proposer duty
+	// in the database, ignoring slots before the first or after the last with a proposer duty.
+	SlotsWithoutProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]phase0.Slot, error)
+
+	// EpochsWithoutValidatorBalances fetches the epochs in the given range without validator balances
+	// in the database, ignoring epochs before the first or after the last with validator balances.
+	EpochsWithoutValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) ([]phase0.Epoch, error)
+}
+
 // GenesisProvider defines functions to access genesis information.
 type GenesisProvider interface {
 	// Genesis fetches genesis values.
@@ -164,6 +188,11 @@ type GenesisSetter interface {
 type ETH1DepositsProvider interface {
 	// ETH1DepositsByPublicKey fetches Ethereum 1 deposits for a given set of validator public keys.
 	ETH1DepositsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) ([]*ETH1Deposit, error)
+
+	// ETH1DepositsByIndexRange fetches Ethereum 1 deposits for the given range of deposit indices.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startIndex 2 and endIndex 4 will provide
+	// deposits with indices 2 and 3.
+	ETH1DepositsByIndexRange(ctx context.Context, startIndex uint64, endIndex uint64) ([]*ETH1Deposit, error)
 }
 
 // ETH1DepositsSetter defines functions to create and update Ethereum 1 deposits.
@@ -189,6 +218,128 @@ type ProposerDutiesSetter interface {
 	SetProposerDuty(ctx context.Context, proposerDuty *ProposerDuty) error
 }
 
+// MissedSlotsProvider defines functions to access missed slots.
+type MissedSlotsProvider interface {
+	// MissedSlotsForSlotRange fetches the missed slots in the given slot range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startSlot 2 and endSlot 4 will provide
+	// missed slots for slots 2 and 3.
+	MissedSlotsForSlotRange(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*MissedSlot, error)
+
+	// MissedSlotsForProposer fetches the missed slots assigned to the given proposer.
+	MissedSlotsForProposer(ctx context.Context, proposer phase0.ValidatorIndex) ([]*MissedSlot, error)
+}
+
+// MissedSlotsSetter defines functions to create and remove missed slots.
+type MissedSlotsSetter interface {
+	// SetMissedSlot sets a missed slot.  If the slot is already recorded as missed
+	// the time at which it was confirmed is unchanged.
+	SetMissedSlot(ctx context.Context, missedSlot *MissedSlot) error
+
+	// DeleteMissedSlot removes the record of a missed slot, for when a canonical
+	// block is found for the slot.
+	DeleteMissedSlot(ctx context.Context, slot phase0.Slot) error
+}
+
+// ETH1DepositInclusionsProvider defines functions to access the inclusion of
+// Ethereum 1 deposits in the beacon chain.
+type ETH1DepositInclusionsProvider interface {
+	// LatestETH1DepositInclusion fetches the inclusion of the Ethereum 1 deposit with the highest index
+	// that has been included in the beacon chain, or nil if there is none.
+	LatestETH1DepositInclusion(ctx context.Context) (*ETH1DepositInclusion, error)
+
+	// ETH1DepositInclusionsByIndexRange fetches the inclusions for the given range of deposit indices.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startIndex 2 and endIndex 4 will provide
+	// inclusions for deposits with indices 2 and 3.
+	ETH1DepositInclusionsByIndexRange(ctx context.Context, startIndex uint64, endIndex uint64) ([]*ETH1DepositInclusion, error)
+}
+
+// ETH1DepositInclusionsSetter defines functions to create and update the
+// inclusion of Ethereum 1 deposits in the beacon chain.
+type ETH1DepositInclusionsSetter interface {
+	// SetETH1DepositInclusion sets the inclusion of an Ethereum 1 deposit.
+	SetETH1DepositInclusion(ctx context.Context, inclusion *ETH1DepositInclusion) error
+}
+
+// EpochProvenanceProvider defines functions to access the provenance of epoch data.
+type EpochProvenanceProvider interface {
+	// EpochProvenance fetches the provenance of the named dataset for the given epoch,
+	// or nil if it is not known.
+	EpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch) (*Provenance, error)
+}
+
+// EpochProvenanceSetter defines functions to record the provenance of epoch data.
+type EpochProvenanceSetter interface {
+	// SetEpochProvenance sets the provenance of the named dataset for the given epoch.
+	SetEpochProvenance(ctx context.Context, dataset string, epoch phase0.Epoch, provenance *Provenance) error
+}
+
+// EpochChecksumsProvider defines functions to compute and access checksums of epoch data.
+type EpochChecksumsProvider interface {
+	// EpochChecksumDatasets provides the names of the datasets for which checksums can be computed.
+	EpochChecksumDatasets() []string
+
+	// ComputeEpochChecksum computes the checksum of the named dataset for the given epoch from
+	// the indexed data.
+	ComputeEpochChecksum(ctx context.Context, dataset string, epoch phase0.Epoch) (*EpochChecksum, error)
+
+	// EpochChecksums fetches the stored checksums of all datasets for the given epoch range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+	// checksums for epochs 2 and 3.
+	EpochChecksums(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*EpochChecksum, error)
+}
+
+// EpochChecksumsSetter defines functions to store checksums of epoch data.
+type EpochChecksumsSetter interface {
+	// SetEpochChecksums sets multiple epoch checksums.
+	SetEpochChecksums(ctx context.Context, checksums []*EpochChecksum) error
+}
+
+// ExecutionTransactionsSetter defines functions to create and update execution transactions.
+type ExecutionTransactionsSetter interface {
+	// SetExecutionTransactions sets the transactions of an execution block.
+	SetExecutionTransactions(ctx context.Context, transactions []*ExecutionTransaction) error
+}
+
+// ExecutionBlockSummariesSetter defines functions to create and update execution block summaries.
+type ExecutionBlockSummariesSetter interface {
+	// SetExecutionBlockSummary sets the summary of an execution block.
+	SetExecutionBlockSummary(ctx context.Context, summary *ExecutionBlockSummary) error
+}
+
+// AddressLabelsProvider defines functions to access address labels.
+type AddressLabelsProvider interface {
+	// AddressLabels fetches the labels for the given addresses.
+	// Addresses without a label are not present in the result.
+	AddressLabels(ctx context.Context, addresses [][20]byte) (map[[20]byte]string, error)
+
+	// UnlabeledAddresses fetches the fee recipient and withdrawal addresses that do not have a label.
+	UnlabeledAddresses(ctx context.Context) ([][20]byte, error)
+}
+
+// AddressLabelsSetter defines functions to create and update address labels.
+type AddressLabelsSetter interface {
+	// SetAddressLabels sets address labels.
+	SetAddressLabels(ctx context.Context, labels []*AddressLabel) error
+}
+
+// ValidatorLabelsProvider defines functions to access validator labels.
+type ValidatorLabelsProvider interface {
+	// ValidatorLabels fetches the labels for all labeled validators.
+	ValidatorLabels(ctx context.Context) (map[phase0.BLSPubKey]string, error)
+}
+
+// ValidatorLabelsSetter defines functions to create and update validator labels.
+type ValidatorLabelsSetter interface {
+	// SetValidatorLabels sets validator labels.
+	SetValidatorLabels(ctx context.Context, labels []*ValidatorLabel) error
+}
+
+// ValidatorLabelEpochSummariesSetter defines functions to create and update validator label epoch summaries.
+type ValidatorLabelEpochSummariesSetter interface {
+	// SetValidatorLabelEpochSummaries sets multiple validator label epoch summaries.
+	SetValidatorLabelEpochSummaries(ctx context.Context, summaries []*ValidatorLabelEpochSummary) error
+}
+
 // ProposerSlashingsProvider defines functions to access proposer slashings.
 type ProposerSlashingsProvider interface {
 	// ProposerSlashingsForSlotRange fetches all proposer slashings made for the given slot range.
@@ -231,6 +382,9 @@ type ValidatorsProvider interface {
 	// ValidatorsByIndex fetches all validators matching the given indices.
 	ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*Validator, error)
 
+	// ValidatorsWithFilter provides validators according to the filter.
+	ValidatorsWithFilter(ctx context.Context, filter *ValidatorFilter) ([]*Validator, error)
+
 	// ValidatorBalancesByIndexAndEpoch fetches the validator balances for the given validators and epoch.
 	ValidatorBalancesByIndexAndEpoch(
 		ctx context.Context,
@@ -302,6 +456,103 @@ type AggregateValidatorBalancesProvider interface {
 	)
 }
 
+// TableStatsProvider defines functions to access statistics about the database's tables.
+type TableStatsProvider interface {
+	// TableStats fetches the statistics for each of the database's tables.
+	TableStats(ctx context.Context) ([]*TableStats, error)
+}
+
+// StateRootsProvider defines functions to access state roots.
+type StateRootsProvider interface {
+	// StateRootBySlot fetches the state root for the given slot.
+	StateRootBySlot(ctx context.Context, slot phase0.Slot) (*StateRoot, error)
+
+	// LatestArchivedStateRoot fetches the latest state root at or before the
+	// given slot for which an archive of the full state is available.
+	LatestArchivedStateRoot(ctx context.Context, slot phase0.Slot) (*StateRoot, error)
+}
+
+// StateRootsSetter defines functions to create and update state roots.
+type StateRootsSetter interface {
+	// SetStateRoots sets multiple state roots.
+	SetStateRoots(ctx context.Context, stateRoots []*StateRoot) error
+}
+
+// ValidatorActivationsProvider defines functions to access validator activations.
+type ValidatorActivationsProvider interface {
+	// LatestValidatorActivation fetches the validator activation with the highest
+	// activation epoch, or nil if there is none.
+	LatestValidatorActivation(ctx context.Context) (*ValidatorActivation, error)
+
+	// ValidatorActivationLatencies fetches the given percentile, between 0 and 1, of the
+	// latencies of validators activated in the given epoch range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+	// latencies for validators activated in epochs 2 and 3.
+	ValidatorActivationLatencies(ctx context.Context,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+		percentile float64,
+	) (
+		*ValidatorActivationLatencies,
+		error,
+	)
+}
+
+// ValidatorActivationsSetter defines functions to create and update validator activations.
+type ValidatorActivationsSetter interface {
+	// SetValidatorActivations sets multiple validator activations.
+	SetValidatorActivations(ctx context.Context, activations []*ValidatorActivation) error
+}
+
+// DatabaseLoadProvider defines functions to access the load on the database.
+type DatabaseLoadProvider interface {
+	// DatabaseLoad fetches the current load on the database.
+	DatabaseLoad(ctx context.Context) (*DatabaseLoad, error)
+}
+
+// AggregatesProvider defines functions to access aggregate information calculated by the database.
+type AggregatesProvider interface {
+	// EpochParticipationForEpochRange fetches the participation for each epoch in the given range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+	// participation for epochs 2 and 3.
+	EpochParticipationForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*EpochParticipation, error)
+
+	// ValidatorAttestationCountsForRange fetches the attestation counts for each validator in the given index range
+	// over the given epoch range.
+	// Ranges are inclusive of start and exclusive of end.
+	ValidatorAttestationCountsForRange(
+		ctx context.Context,
+		startIndex phase0.ValidatorIndex,
+		endIndex phase0.ValidatorIndex,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		[]*ValidatorAttestationCounts,
+		error,
+	)
+
+	// ValidatorPerformanceForEpochRange fetches the performance of the given validators over the given epoch range,
+	// combining their epoch summaries, balances and deposits.
+	// Ranges are inclusive of start and exclusive of end.
+	ValidatorPerformanceForEpochRange(
+		ctx context.Context,
+		indices []phase0.ValidatorIndex,
+		startEpoch phase0.Epoch,
+		endEpoch phase0.Epoch,
+	) (
+		[]*ValidatorPerformance,
+		error,
+	)
+
+	// ActiveValidatorCount fetches the number of validators active at the given epoch.
+	ActiveValidatorCount(ctx context.Context, epoch phase0.Epoch) (uint64, error)
+
+	// TotalValidatorBalancesForEpochRange fetches the total balances of all validators for each epoch in the given range.
+	// Ranges are inclusive of start and exclusive of end i.e. a request with startEpoch 2 and endEpoch 4 will provide
+	// balances for epochs 2 and 3.
+	TotalValidatorBalancesForEpochRange(ctx context.Context, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]*AggregateValidatorBalance, error)
+}
+
 // ValidatorsSetter defines functions to create and update validator information.
 type ValidatorsSetter interface {
 	// SetValidator sets a validator.
@@ -387,6 +638,77 @@ type SyncCommitteesSetter interface {
 	SetSyncCommittee(ctx context.Context, syncCommittee *SyncCommittee) error
 }
 
+// MaterializedViewsSetter defines functions to manage materialized views.
+type MaterializedViewsSetter interface {
+	// SetMaterializedView creates a materialized view if it does not already exist.
+	SetMaterializedView(ctx context.Context, name string, query string) error
+
+	// DropMaterializedView drops a materialized view if it exists.
+	DropMaterializedView(ctx context.Context, name string) error
+
+	// RefreshMaterializedView refreshes the contents of a materialized view.
+	RefreshMaterializedView(ctx context.Context, name string) error
+}
+
+// EpochCompletionsProvider defines functions to access epoch completion markers.
+type EpochCompletionsProvider interface {
+	// CompletedEpochs fetches the epochs in the given range for which the named service has
+	// recorded completion.  Ranges are inclusive of start and end.
+	CompletedEpochs(ctx context.Context, service string, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]phase0.Epoch, error)
+}
+
+// EpochCompletionsSetter defines functions to create and remove epoch completion markers.
+// Markers should be set in the same transaction as the data for the epoch, so that the
+// presence of a marker always means that the data for the epoch is present.
+type EpochCompletionsSetter interface {
+	// SetEpochComplete records that the named service has completed processing of an epoch.
+	SetEpochComplete(ctx context.Context, service string, epoch phase0.Epoch) error
+
+	// DeleteEpochCompletions removes the completion markers for the named service from the
+	// given epoch onwards.
+	DeleteEpochCompletions(ctx context.Context, service string, fromEpoch phase0.Epoch) error
+}
+
+// RangeDeleter defines functions to delete data ahead of it being re-ingested.
+type RangeDeleter interface {
+	// DeleteBlocks deletes the blocks in the given slot range, along with the data contained within them.
+	DeleteBlocks(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error
+
+	// DeleteBeaconCommittees deletes the beacon committees in the given slot range.
+	DeleteBeaconCommittees(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error
+
+	// DeleteProposerDuties deletes the proposer duties in the given slot range.
+	DeleteProposerDuties(ctx context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) error
+
+	// DeleteValidatorBalances deletes the validator balances in the given epoch range.
+	DeleteValidatorBalances(ctx context.Context, minEpoch phase0.Epoch, maxEpoch phase0.Epoch) error
+}
+
+// Sink is a destination for data in addition to the chain database.
+// A sink receives data by implementing any of the setter interfaces above,
+// for example BlocksSetter, and is passed the data once the transaction in
+// which it was written has been committed.
+type Sink interface {
+	// Name returns the name of the sink.
+	Name() string
+}
+
+// LeaderElector defines functions to elect a leader between instances of chaind
+// that share a database.
+type LeaderElector interface {
+	// AwaitLeadership blocks until this instance is the leader, or the context
+	// is done.  The returned channel is closed if leadership is lost.  Leadership
+	// is held until the database is closed.
+	AwaitLeadership(ctx context.Context) (<-chan struct{}, error)
+}
+
+// Closer defines functions to shut down a chain database cleanly.
+type Closer interface {
+	// Close stops new transactions from starting, waits for those in progress
+	// to finish or for the context to be done, and then closes the database.
+	Close(ctx context.Context) error
+}
+
 // Service defines a minimal chain database service.
 type Service interface {
 	// BeginTx begins a transaction.
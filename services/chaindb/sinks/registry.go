@@ -0,0 +1,82 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Factory creates a sink.
+type Factory func(ctx context.Context) (chaindb.Sink, error)
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a sink available by name, so that it can be enabled with
+// configuration.  It is intended to be called from an init() function, and
+// panics if a sink of the same name has already been registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("sinks: nil factory for " + name)
+	}
+	if _, exists := registry[name]; exists {
+		panic("sinks: duplicate registration for " + name)
+	}
+	registry[name] = factory
+}
+
+// Registered returns the names of the registered sinks.
+func Registered() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Create creates the named sinks.
+func Create(ctx context.Context, names []string) ([]chaindb.Sink, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	sinks := make([]chaindb.Sink, 0, len(names))
+	for _, name := range names {
+		factory, exists := registry[name]
+		if !exists {
+			return nil, errors.Errorf("unknown sink %q", name)
+		}
+		sink, err := factory(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create sink %q", name)
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, nil
+}
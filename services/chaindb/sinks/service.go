@@ -0,0 +1,154 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sinks provides a chain database that passes data written to it on
+// to additional sinks, allowing indexed data to be sent to custom destinations.
+package sinks
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// backend is the full set of functions a chain database must provide to be
+// wrapped by the sinks service.
+type backend interface {
+	chaindb.Service
+	chaindb.AddressLabelsProvider
+	chaindb.AddressLabelsSetter
+	chaindb.AttestationsProvider
+	chaindb.AttestationsSetter
+	chaindb.AttesterSlashingsProvider
+	chaindb.AttesterSlashingsSetter
+	chaindb.BeaconCommitteesProvider
+	chaindb.BeaconCommitteesSetter
+	chaindb.BlocksProvider
+	chaindb.BlocksSetter
+	chaindb.ChainSpecProvider
+	chaindb.ChainSpecSetter
+	chaindb.ForkScheduleProvider
+	chaindb.ForkScheduleSetter
+	chaindb.GapsProvider
+	chaindb.GenesisProvider
+	chaindb.GenesisSetter
+	chaindb.ETH1DepositsProvider
+	chaindb.ETH1DepositsSetter
+	chaindb.ETH1DepositInclusionsProvider
+	chaindb.ETH1DepositInclusionsSetter
+	chaindb.ProposerDutiesProvider
+	chaindb.ProposerDutiesSetter
+	chaindb.MissedSlotsProvider
+	chaindb.MissedSlotsSetter
+	chaindb.ProposerSlashingsProvider
+	chaindb.ProposerSlashingsSetter
+	chaindb.SyncAggregateProvider
+	chaindb.SyncAggregateSetter
+	chaindb.ValidatorsProvider
+	chaindb.AggregateValidatorBalancesProvider
+	chaindb.AggregatesProvider
+	chaindb.TableStatsProvider
+	chaindb.StateRootsProvider
+	chaindb.StateRootsSetter
+	chaindb.ValidatorActivationsProvider
+	chaindb.ValidatorActivationsSetter
+	chaindb.DatabaseLoadProvider
+	chaindb.EpochChecksumsProvider
+	chaindb.EpochChecksumsSetter
+	chaindb.ValidatorsSetter
+	chaindb.DepositsProvider
+	chaindb.DepositsSetter
+	chaindb.VoluntaryExitsSetter
+	chaindb.ValidatorEpochSummariesSetter
+	chaindb.ValidatorLabelsProvider
+	chaindb.ValidatorLabelsSetter
+	chaindb.ValidatorLabelEpochSummariesSetter
+	chaindb.BlockSummariesProvider
+	chaindb.ValidatorEpochSummariesProvider
+	chaindb.BlockSummariesSetter
+	chaindb.EpochSummariesSetter
+	chaindb.SyncCommitteesProvider
+	chaindb.SyncCommitteesSetter
+	chaindb.MaterializedViewsSetter
+	chaindb.EpochCompletionsProvider
+	chaindb.EpochCompletionsSetter
+	chaindb.EpochProvenanceProvider
+	chaindb.EpochProvenanceSetter
+	chaindb.RangeDeleter
+	chaindb.ExecutionTransactionsSetter
+	chaindb.ExecutionBlockSummariesSetter
+	eth2client.GenesisTimeProvider
+	eth2client.SpecProvider
+}
+
+// Service is a chain database service that passes data written to it on to sinks.
+// All reads, and the setters that are not overridden here, are served by the
+// wrapped backend.
+type Service struct {
+	backend
+	sinks []chaindb.Sink
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "chaindb").Str("impl", "sinks").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	backend, isBackend := parameters.chainDB.(backend)
+	if !isBackend {
+		return nil, errors.New("chain database does not provide all required functions")
+	}
+
+	s := &Service{
+		backend: backend,
+		sinks:   parameters.sinks,
+	}
+
+	return s, nil
+}
+
+// AwaitLeadership blocks until this instance is the leader of the wrapped backend.
+func (s *Service) AwaitLeadership(ctx context.Context) (<-chan struct{}, error) {
+	elector, isElector := s.backend.(chaindb.LeaderElector)
+	if !isElector {
+		return nil, errors.New("chain database does not support leader election")
+	}
+
+	return elector.AwaitLeadership(ctx)
+}
+
+// Close closes the wrapped backend.  Writes to sinks are delivered as part of
+// committing a transaction, so are complete once the backend has closed.
+func (s *Service) Close(ctx context.Context) error {
+	closer, isCloser := s.backend.(chaindb.Closer)
+	if !isCloser {
+		return nil
+	}
+
+	return closer.Close(ctx)
+}
@@ -0,0 +1,102 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+type txCtx struct{}
+
+// testBackend is a mock backend that supports transactions.
+type testBackend struct {
+	backend
+}
+
+func (*testBackend) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return context.WithValue(ctx, &txCtx{}, true), func() {}, nil
+}
+
+// blocksSink is a sink that accepts blocks.
+type blocksSink struct {
+	blocks []*chaindb.Block
+	err    error
+}
+
+func (*blocksSink) Name() string {
+	return "blocks"
+}
+
+func (b *blocksSink) SetBlock(ctx context.Context, block *chaindb.Block) error {
+	if ctx.Value(&txCtx{}) != nil {
+		return errors.New("sink passed transaction context")
+	}
+	if b.err != nil {
+		return b.err
+	}
+	b.blocks = append(b.blocks, block)
+	return nil
+}
+
+// otherSink is a sink that does not accept any data.
+type otherSink struct{}
+
+func (*otherSink) Name() string {
+	return "other"
+}
+
+func TestWrites(t *testing.T) {
+	ctx := context.Background()
+
+	sink := &blocksSink{}
+	failingSink := &blocksSink{err: errors.New("failed")}
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(&testBackend{backend: mockchaindb.New().(backend)}),
+		WithSinks([]chaindb.Sink{failingSink, &otherSink{}, sink}),
+	)
+	require.NoError(t, err)
+
+	// Writes are not passed to sinks until committed.
+	txCtx, _, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.SetBlock(txCtx, &chaindb.Block{Slot: 1}))
+	require.NoError(t, s.SetBlock(txCtx, &chaindb.Block{Slot: 2}))
+	require.Len(t, sink.blocks, 0)
+	require.NoError(t, s.CommitTx(txCtx))
+	require.Len(t, sink.blocks, 2)
+	require.Equal(t, phase0.Slot(2), sink.blocks[1].Slot)
+
+	// Writes in a cancelled transaction are discarded.
+	txCtx, cancel, err := s.BeginTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.SetBlock(txCtx, &chaindb.Block{Slot: 3}))
+	cancel()
+	require.Len(t, sink.blocks, 2)
+
+	// Data not accepted by the sink is ignored.
+	txCtx, _, err = s.BeginTx(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.SetValidator(txCtx, &chaindb.Validator{Index: 1}))
+	require.NoError(t, s.CommitTx(txCtx))
+	require.Len(t, sink.blocks, 2)
+}
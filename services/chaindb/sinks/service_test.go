@@ -0,0 +1,165 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaindb/sinks"
+)
+
+type nullSink struct{}
+
+func (*nullSink) Name() string {
+	return "null"
+}
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []sinks.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []sinks.Parameter{
+				sinks.WithLogLevel(zerolog.Disabled),
+				sinks.WithSinks([]chaindb.Sink{&nullSink{}}),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "SinksMissing",
+			params: []sinks.Parameter{
+				sinks.WithLogLevel(zerolog.Disabled),
+				sinks.WithChainDB(mockchaindb.New()),
+			},
+			err: "problem with parameters: no sinks specified",
+		},
+		{
+			name: "SinkNil",
+			params: []sinks.Parameter{
+				sinks.WithLogLevel(zerolog.Disabled),
+				sinks.WithChainDB(mockchaindb.New()),
+				sinks.WithSinks([]chaindb.Sink{nil}),
+			},
+			err: "problem with parameters: nil sink specified",
+		},
+		{
+			name: "Good",
+			params: []sinks.Parameter{
+				sinks.WithLogLevel(zerolog.Disabled),
+				sinks.WithChainDB(mockchaindb.New()),
+				sinks.WithSinks([]chaindb.Sink{&nullSink{}}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := sinks.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+
+	sinks.Register("test", func(_ context.Context) (chaindb.Sink, error) {
+		return &nullSink{}, nil
+	})
+	require.Panics(t, func() {
+		sinks.Register("test", func(_ context.Context) (chaindb.Sink, error) {
+			return &nullSink{}, nil
+		})
+	})
+	require.Contains(t, sinks.Registered(), "test")
+
+	created, err := sinks.Create(ctx, []string{"test"})
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	require.Equal(t, "null", created[0].Name())
+
+	_, err = sinks.Create(ctx, []string{"unknown"})
+	require.EqualError(t, err, `unknown sink "unknown"`)
+}
+
+// TestInterfaces ensures that the wrapped chain database provides the functions
+// that the modules require of the chain database.
+func TestInterfaces(t *testing.T) {
+	ctx := context.Background()
+
+	s, err := sinks.New(ctx,
+		sinks.WithLogLevel(zerolog.Disabled),
+		sinks.WithChainDB(mockchaindb.New()),
+		sinks.WithSinks([]chaindb.Sink{&nullSink{}}),
+	)
+	require.NoError(t, err)
+
+	require.Implements(t, (*chaindb.AggregatesProvider)(nil), s)
+	require.Implements(t, (*chaindb.AttestationsProvider)(nil), s)
+	require.Implements(t, (*chaindb.AttestationsSetter)(nil), s)
+	require.Implements(t, (*chaindb.AttesterSlashingsProvider)(nil), s)
+	require.Implements(t, (*chaindb.AttesterSlashingsSetter)(nil), s)
+	require.Implements(t, (*chaindb.BeaconCommitteesProvider)(nil), s)
+	require.Implements(t, (*chaindb.BeaconCommitteesSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlockSummariesProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlockSummariesSetter)(nil), s)
+	require.Implements(t, (*chaindb.BlocksProvider)(nil), s)
+	require.Implements(t, (*chaindb.BlocksSetter)(nil), s)
+	require.Implements(t, (*chaindb.ChainSpecProvider)(nil), s)
+	require.Implements(t, (*chaindb.ChainSpecSetter)(nil), s)
+	require.Implements(t, (*chaindb.Closer)(nil), s)
+	require.Implements(t, (*chaindb.DepositsProvider)(nil), s)
+	require.Implements(t, (*chaindb.DepositsSetter)(nil), s)
+	require.Implements(t, (*chaindb.EpochCompletionsProvider)(nil), s)
+	require.Implements(t, (*chaindb.EpochCompletionsSetter)(nil), s)
+	require.Implements(t, (*chaindb.EpochProvenanceSetter)(nil), s)
+	require.Implements(t, (*chaindb.EpochSummariesSetter)(nil), s)
+	require.Implements(t, (*chaindb.ExecutionBlockSummariesSetter)(nil), s)
+	require.Implements(t, (*chaindb.ExecutionTransactionsSetter)(nil), s)
+	require.Implements(t, (*chaindb.ForkScheduleSetter)(nil), s)
+	require.Implements(t, (*chaindb.GapsProvider)(nil), s)
+	require.Implements(t, (*chaindb.GenesisProvider)(nil), s)
+	require.Implements(t, (*chaindb.GenesisSetter)(nil), s)
+	require.Implements(t, (*chaindb.LeaderElector)(nil), s)
+	require.Implements(t, (*chaindb.MaterializedViewsSetter)(nil), s)
+	require.Implements(t, (*chaindb.MissedSlotsProvider)(nil), s)
+	require.Implements(t, (*chaindb.MissedSlotsSetter)(nil), s)
+	require.Implements(t, (*chaindb.ProposerDutiesProvider)(nil), s)
+	require.Implements(t, (*chaindb.ProposerDutiesSetter)(nil), s)
+	require.Implements(t, (*chaindb.ProposerSlashingsProvider)(nil), s)
+	require.Implements(t, (*chaindb.ProposerSlashingsSetter)(nil), s)
+	require.Implements(t, (*chaindb.RangeDeleter)(nil), s)
+	require.Implements(t, (*chaindb.SyncAggregateSetter)(nil), s)
+	require.Implements(t, (*chaindb.SyncCommitteesProvider)(nil), s)
+	require.Implements(t, (*chaindb.SyncCommitteesSetter)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorEpochSummariesProvider)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorEpochSummariesSetter)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorsProvider)(nil), s)
+	require.Implements(t, (*chaindb.ValidatorsSetter)(nil), s)
+	require.Implements(t, (*chaindb.VoluntaryExitsSetter)(nil), s)
+}
@@ -0,0 +1,470 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"context"
+
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetAddressLabels sets address labels.
+func (s *Service) SetAddressLabels(ctx context.Context, labels []*chaindb.AddressLabel) error {
+	if err := s.backend.SetAddressLabels(ctx, labels); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetAddressLabels", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.AddressLabelsSetter); isSetter {
+			return setter.SetAddressLabels(ctx, labels)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetAttestation sets an attestation.
+func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	if err := s.backend.SetAttestation(ctx, attestation); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetAttestation", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.AttestationsSetter); isSetter {
+			return setter.SetAttestation(ctx, attestation)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetAttestations sets multiple attestations.
+func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.Attestation) error {
+	if err := s.backend.SetAttestations(ctx, attestations); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetAttestations", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.AttestationsSetter); isSetter {
+			return setter.SetAttestations(ctx, attestations)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetAttesterSlashing sets an attester slashing.
+func (s *Service) SetAttesterSlashing(ctx context.Context, attesterSlashing *chaindb.AttesterSlashing) error {
+	if err := s.backend.SetAttesterSlashing(ctx, attesterSlashing); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetAttesterSlashing", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.AttesterSlashingsSetter); isSetter {
+			return setter.SetAttesterSlashing(ctx, attesterSlashing)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetBeaconCommittee sets a beacon committee.
+func (s *Service) SetBeaconCommittee(ctx context.Context, beaconCommittee *chaindb.BeaconCommittee) error {
+	if err := s.backend.SetBeaconCommittee(ctx, beaconCommittee); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetBeaconCommittee", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.BeaconCommitteesSetter); isSetter {
+			return setter.SetBeaconCommittee(ctx, beaconCommittee)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetBlock sets a block.
+func (s *Service) SetBlock(ctx context.Context, block *chaindb.Block) error {
+	if err := s.backend.SetBlock(ctx, block); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetBlock", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.BlocksSetter); isSetter {
+			return setter.SetBlock(ctx, block)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetChainSpecValue sets the value of the provided key.
+func (s *Service) SetChainSpecValue(ctx context.Context, key string, value interface{}) error {
+	if err := s.backend.SetChainSpecValue(ctx, key, value); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetChainSpecValue", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ChainSpecSetter); isSetter {
+			return setter.SetChainSpecValue(ctx, key, value)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetForkSchedule sets the fork schedule.
+func (s *Service) SetForkSchedule(ctx context.Context, schedule []*phase0.Fork) error {
+	if err := s.backend.SetForkSchedule(ctx, schedule); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetForkSchedule", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ForkScheduleSetter); isSetter {
+			return setter.SetForkSchedule(ctx, schedule)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetGenesis sets the genesis information.
+func (s *Service) SetGenesis(ctx context.Context, genesis *api.Genesis) error {
+	if err := s.backend.SetGenesis(ctx, genesis); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetGenesis", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.GenesisSetter); isSetter {
+			return setter.SetGenesis(ctx, genesis)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetETH1Deposit sets an Ethereum 1 deposit.
+func (s *Service) SetETH1Deposit(ctx context.Context, deposit *chaindb.ETH1Deposit) error {
+	if err := s.backend.SetETH1Deposit(ctx, deposit); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetETH1Deposit", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ETH1DepositsSetter); isSetter {
+			return setter.SetETH1Deposit(ctx, deposit)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetETH1DepositInclusion sets the inclusion of an Ethereum 1 deposit.
+func (s *Service) SetETH1DepositInclusion(ctx context.Context, inclusion *chaindb.ETH1DepositInclusion) error {
+	if err := s.backend.SetETH1DepositInclusion(ctx, inclusion); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetETH1DepositInclusion", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ETH1DepositInclusionsSetter); isSetter {
+			return setter.SetETH1DepositInclusion(ctx, inclusion)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetExecutionTransactions sets the transactions of an execution block.
+func (s *Service) SetExecutionTransactions(ctx context.Context, transactions []*chaindb.ExecutionTransaction) error {
+	if err := s.backend.SetExecutionTransactions(ctx, transactions); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetExecutionTransactions", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ExecutionTransactionsSetter); isSetter {
+			return setter.SetExecutionTransactions(ctx, transactions)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetExecutionBlockSummary sets the summary of an execution block.
+func (s *Service) SetExecutionBlockSummary(ctx context.Context, summary *chaindb.ExecutionBlockSummary) error {
+	if err := s.backend.SetExecutionBlockSummary(ctx, summary); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetExecutionBlockSummary", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ExecutionBlockSummariesSetter); isSetter {
+			return setter.SetExecutionBlockSummary(ctx, summary)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetProposerDuty sets a proposer duty.
+func (s *Service) SetProposerDuty(ctx context.Context, proposerDuty *chaindb.ProposerDuty) error {
+	if err := s.backend.SetProposerDuty(ctx, proposerDuty); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetProposerDuty", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ProposerDutiesSetter); isSetter {
+			return setter.SetProposerDuty(ctx, proposerDuty)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetMissedSlot sets a missed slot.
+func (s *Service) SetMissedSlot(ctx context.Context, missedSlot *chaindb.MissedSlot) error {
+	if err := s.backend.SetMissedSlot(ctx, missedSlot); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetMissedSlot", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.MissedSlotsSetter); isSetter {
+			return setter.SetMissedSlot(ctx, missedSlot)
+		}
+		return nil
+	})
+	return nil
+}
+
+// DeleteMissedSlot removes the record of a missed slot.
+func (s *Service) DeleteMissedSlot(ctx context.Context, slot phase0.Slot) error {
+	if err := s.backend.DeleteMissedSlot(ctx, slot); err != nil {
+		return err
+	}
+	s.queue(ctx, "DeleteMissedSlot", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.MissedSlotsSetter); isSetter {
+			return setter.DeleteMissedSlot(ctx, slot)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetProposerSlashing sets an proposer slashing.
+func (s *Service) SetProposerSlashing(ctx context.Context, proposerSlashing *chaindb.ProposerSlashing) error {
+	if err := s.backend.SetProposerSlashing(ctx, proposerSlashing); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetProposerSlashing", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ProposerSlashingsSetter); isSetter {
+			return setter.SetProposerSlashing(ctx, proposerSlashing)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetSyncAggregate sets the sync aggregate.
+func (s *Service) SetSyncAggregate(ctx context.Context, syncAggregate *chaindb.SyncAggregate) error {
+	if err := s.backend.SetSyncAggregate(ctx, syncAggregate); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetSyncAggregate", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.SyncAggregateSetter); isSetter {
+			return setter.SetSyncAggregate(ctx, syncAggregate)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidator sets a validator.
+func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
+	if err := s.backend.SetValidator(ctx, validator); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidator", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorsSetter); isSetter {
+			return setter.SetValidator(ctx, validator)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidatorBalance sets a validator balance.
+func (s *Service) SetValidatorBalance(ctx context.Context, validatorBalance *chaindb.ValidatorBalance) error {
+	if err := s.backend.SetValidatorBalance(ctx, validatorBalance); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidatorBalance", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorsSetter); isSetter {
+			return setter.SetValidatorBalance(ctx, validatorBalance)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidatorBalances sets multiple validator balances.
+func (s *Service) SetValidatorBalances(ctx context.Context, validatorBalances []*chaindb.ValidatorBalance) error {
+	if err := s.backend.SetValidatorBalances(ctx, validatorBalances); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidatorBalances", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorsSetter); isSetter {
+			return setter.SetValidatorBalances(ctx, validatorBalances)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetDeposit sets a deposit.
+func (s *Service) SetDeposit(ctx context.Context, deposit *chaindb.Deposit) error {
+	if err := s.backend.SetDeposit(ctx, deposit); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetDeposit", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.DepositsSetter); isSetter {
+			return setter.SetDeposit(ctx, deposit)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetVoluntaryExit sets a voluntary exit.
+func (s *Service) SetVoluntaryExit(ctx context.Context, voluntaryExit *chaindb.VoluntaryExit) error {
+	if err := s.backend.SetVoluntaryExit(ctx, voluntaryExit); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetVoluntaryExit", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.VoluntaryExitsSetter); isSetter {
+			return setter.SetVoluntaryExit(ctx, voluntaryExit)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidatorEpochSummary sets a validator epoch summary.
+func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	if err := s.backend.SetValidatorEpochSummary(ctx, summary); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidatorEpochSummary", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorEpochSummariesSetter); isSetter {
+			return setter.SetValidatorEpochSummary(ctx, summary)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidatorEpochSummaries sets multiple validator epoch summaries.
+func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	if err := s.backend.SetValidatorEpochSummaries(ctx, summaries); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidatorEpochSummaries", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorEpochSummariesSetter); isSetter {
+			return setter.SetValidatorEpochSummaries(ctx, summaries)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidatorLabels sets validator labels.
+func (s *Service) SetValidatorLabels(ctx context.Context, labels []*chaindb.ValidatorLabel) error {
+	if err := s.backend.SetValidatorLabels(ctx, labels); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidatorLabels", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorLabelsSetter); isSetter {
+			return setter.SetValidatorLabels(ctx, labels)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidatorLabelEpochSummaries sets multiple validator label epoch summaries.
+func (s *Service) SetValidatorLabelEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorLabelEpochSummary) error {
+	if err := s.backend.SetValidatorLabelEpochSummaries(ctx, summaries); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidatorLabelEpochSummaries", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorLabelEpochSummariesSetter); isSetter {
+			return setter.SetValidatorLabelEpochSummaries(ctx, summaries)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetBlockSummary sets a block summary.
+func (s *Service) SetBlockSummary(ctx context.Context, summary *chaindb.BlockSummary) error {
+	if err := s.backend.SetBlockSummary(ctx, summary); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetBlockSummary", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.BlockSummariesSetter); isSetter {
+			return setter.SetBlockSummary(ctx, summary)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetEpochSummary sets an epoch summary.
+func (s *Service) SetEpochSummary(ctx context.Context, summary *chaindb.EpochSummary) error {
+	if err := s.backend.SetEpochSummary(ctx, summary); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetEpochSummary", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.EpochSummariesSetter); isSetter {
+			return setter.SetEpochSummary(ctx, summary)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetSyncCommittee sets a sync committee.
+func (s *Service) SetSyncCommittee(ctx context.Context, syncCommittee *chaindb.SyncCommittee) error {
+	if err := s.backend.SetSyncCommittee(ctx, syncCommittee); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetSyncCommittee", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.SyncCommitteesSetter); isSetter {
+			return setter.SetSyncCommittee(ctx, syncCommittee)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetStateRoots sets multiple state roots.
+func (s *Service) SetStateRoots(ctx context.Context, stateRoots []*chaindb.StateRoot) error {
+	if err := s.backend.SetStateRoots(ctx, stateRoots); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetStateRoots", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.StateRootsSetter); isSetter {
+			return setter.SetStateRoots(ctx, stateRoots)
+		}
+		return nil
+	})
+	return nil
+}
+
+// SetValidatorActivations sets multiple validator activations.
+func (s *Service) SetValidatorActivations(ctx context.Context, activations []*chaindb.ValidatorActivation) error {
+	if err := s.backend.SetValidatorActivations(ctx, activations); err != nil {
+		return err
+	}
+	s.queue(ctx, "SetValidatorActivations", func(ctx context.Context, sink chaindb.Sink) error {
+		if setter, isSetter := sink.(chaindb.ValidatorActivationsSetter); isSetter {
+			return setter.SetValidatorActivations(ctx, activations)
+		}
+		return nil
+	})
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sinks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// pendingCtx is a context tag for the writes pending in a transaction.
+type pendingCtx struct{}
+
+// pending holds the writes carried out in a transaction, to be passed to the
+// sinks once the transaction has been committed.
+type pending struct {
+	// ctx is the context prior to the transaction, used to write to the sinks.
+	ctx    context.Context
+	mu     sync.Mutex
+	writes []*write
+}
+
+// write is a write to be passed to the sinks.
+type write struct {
+	name string
+	// fn writes to a single sink, doing nothing if the sink does not accept the data.
+	fn func(context.Context, chaindb.Sink) error
+}
+
+// BeginTx begins a transaction.
+// The transaction can be rolled back by invoking the cancel function, in
+// which case the pending writes are discarded.
+func (s *Service) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	txCtx, cancel, err := s.backend.BeginTx(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return context.WithValue(txCtx, &pendingCtx{}, &pending{ctx: ctx}), cancel, nil
+}
+
+// CommitTx commits a transaction, and then passes its writes to the sinks.
+// Failures to write to a sink are logged, as the data has already been
+// committed to the chain database.
+func (s *Service) CommitTx(ctx context.Context) error {
+	if err := s.backend.CommitTx(ctx); err != nil {
+		return err
+	}
+
+	p, ok := ctx.Value(&pendingCtx{}).(*pending)
+	if !ok {
+		return nil
+	}
+	p.mu.Lock()
+	writes := p.writes
+	p.writes = nil
+	p.mu.Unlock()
+	for _, write := range writes {
+		s.deliver(p.ctx, write)
+	}
+
+	return nil
+}
+
+// queue queues a write for the sinks, to be carried out when the transaction
+// is committed.  If there is no transaction the write is carried out immediately.
+func (s *Service) queue(ctx context.Context, name string, fn func(context.Context, chaindb.Sink) error) {
+	write := &write{
+		name: name,
+		fn:   fn,
+	}
+	p, ok := ctx.Value(&pendingCtx{}).(*pending)
+	if !ok {
+		s.deliver(ctx, write)
+		return
+	}
+	p.mu.Lock()
+	p.writes = append(p.writes, write)
+	p.mu.Unlock()
+}
+
+// deliver passes a write to each of the sinks.
+func (s *Service) deliver(ctx context.Context, write *write) {
+	for _, sink := range s.sinks {
+		if err := write.fn(ctx, sink); err != nil {
+			log.Warn().Str("sink", sink.Name()).Str("write", write.name).Err(err).Msg("Failed to write to sink")
+		}
+	}
+}
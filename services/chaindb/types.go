@@ -36,6 +36,19 @@ type Block struct {
 	ETH1DepositRoot  phase0.Root
 	// Information only available from Bellatrix onwards.
 	ExecutionPayload *ExecutionPayload
+	// Provenance is the beacon node that supplied the block, if known.
+	Provenance *Provenance
+	// SeenDelay is the time from the start of the block's slot to when the
+	// block was first seen as the head of the chain, if known.
+	SeenDelay *time.Duration
+}
+
+// Provenance holds information about the beacon node that supplied data.
+type Provenance struct {
+	// Address is the address of the beacon node, without credentials.
+	Address string
+	// Version is the version advertised by the beacon node, or empty if not known.
+	Version string
 }
 
 // Validator holds information about a validator.
@@ -48,6 +61,8 @@ type Validator struct {
 	ActivationEpoch            phase0.Epoch
 	ExitEpoch                  phase0.Epoch
 	WithdrawableEpoch          phase0.Epoch
+	// WithdrawalCredentials is nil if the validator has not been updated since it was added to the schema.
+	WithdrawalCredentials []byte
 }
 
 // ValidatorBalance holds information about a validator's balance at a given epoch.
@@ -65,6 +80,51 @@ type AggregateValidatorBalance struct {
 	EffectiveBalance phase0.Gwei
 }
 
+// EpochParticipation holds participation information for an epoch.
+type EpochParticipation struct {
+	Epoch                phase0.Epoch
+	ActiveBalance        phase0.Gwei
+	AttestingBalance     phase0.Gwei
+	TargetCorrectBalance phase0.Gwei
+	HeadCorrectBalance   phase0.Gwei
+	// Rate is the attesting balance as a proportion of the active balance.
+	Rate float64
+}
+
+// ValidatorAttestationCounts holds counts of a validator's attestations over a range of epochs.
+type ValidatorAttestationCounts struct {
+	Index phase0.ValidatorIndex
+	// Epochs is the number of epochs for which the validator has a summary.
+	Epochs        int
+	Included      int
+	TargetCorrect int
+	HeadCorrect   int
+}
+
+// ValidatorPerformance holds a validator's performance over a range of epochs.
+type ValidatorPerformance struct {
+	Index phase0.ValidatorIndex
+	// Epochs is the number of epochs for which the validator has a summary.
+	Epochs                    int
+	AttestationsIncluded      int
+	AttestationsTargetCorrect int
+	AttestationsHeadCorrect   int
+	AttestationsSourceTimely  int
+	AttestationsTargetTimely  int
+	AttestationsHeadTimely    int
+	// InclusionDelay is the mean inclusion delay of included attestations.
+	InclusionDelay    float64
+	ProposerDuties    int
+	ProposalsIncluded int
+	// StartBalance and EndBalance are nil if the balance is not present in the database.
+	StartBalance *phase0.Gwei
+	EndBalance   *phase0.Gwei
+	// Deposits is the total of deposits for the validator included in the range.
+	Deposits phase0.Gwei
+	// Income is the change in balance less deposits, or nil if either balance is not present.
+	Income *int64
+}
+
 // BeaconCommittee holds information for beacon committees.
 type BeaconCommittee struct {
 	Slot      phase0.Slot
@@ -78,6 +138,32 @@ type ProposerDuty struct {
 	ValidatorIndex phase0.ValidatorIndex
 }
 
+// MissedSlot holds information about a slot confirmed to have no canonical block.
+type MissedSlot struct {
+	Slot  phase0.Slot
+	Epoch phase0.Epoch
+	// Proposer is the validator assigned to propose a block for the slot, or
+	// nil if the proposer duty is not known.
+	Proposer *phase0.ValidatorIndex
+	// ConfirmedAt is the time at which the slot was confirmed as missed.
+	ConfirmedAt time.Time
+}
+
+// ETH1DepositInclusion holds the result of reconciling an Ethereum 1 deposit
+// with the deposits included in beacon blocks.
+type ETH1DepositInclusion struct {
+	DepositIndex uint64
+	// InclusionSlot, InclusionBlockRoot and InclusionIndex identify the
+	// deposit in the beacon chain.  They are nil if the deposit has not been
+	// included.
+	InclusionSlot      *phase0.Slot
+	InclusionBlockRoot *phase0.Root
+	InclusionIndex     *uint64
+	// Missing is true if the deposit was not included in the beacon chain
+	// within the expected window.
+	Missing bool
+}
+
 // AttesterDuty holds information for attester duties.
 type AttesterDuty struct {
 	Slot           phase0.Slot
@@ -102,8 +188,15 @@ type Attestation struct {
 	TargetEpoch        phase0.Epoch
 	TargetRoot         phase0.Root
 	Canonical          *bool
+	SourceCorrect      *bool
 	TargetCorrect      *bool
 	HeadCorrect        *bool
+	// Duplicate is true if the votes of all validators in the attestation were
+	// already included on the canonical chain by an earlier attestation.
+	Duplicate *bool
+	// Conflicting is true if any validator in the attestation has a canonical
+	// attestation with different data for the same target epoch.
+	Conflicting *bool
 }
 
 // SyncAggregate holds information about a sync aggregate included in a block.
@@ -140,6 +233,10 @@ type ETH1Deposit struct {
 	WithdrawalCredentials []byte
 	Signature             phase0.BLSSignature
 	Amount                phase0.Gwei
+	// Valid is true if the deposit's Merkle proof is valid against the deposit
+	// contract root at the deposit's block, false if not, and nil if it could not
+	// be verified.
+	Valid *bool
 }
 
 // VoluntaryExit holds information about a voluntary exit included in a block.
@@ -149,6 +246,13 @@ type VoluntaryExit struct {
 	InclusionIndex     uint64
 	ValidatorIndex     phase0.ValidatorIndex
 	Epoch              phase0.Epoch
+	// ExitEpoch and WithdrawableEpoch are those of the validator in the state
+	// after the inclusion block.  They are nil if the state was not available.
+	ExitEpoch         *phase0.Epoch
+	WithdrawableEpoch *phase0.Epoch
+	// ExitQueueEpochs is the number of epochs that the exit waited in the
+	// exit queue, beyond the earliest epoch at which it could have exited.
+	ExitQueueEpochs *uint64
 }
 
 // AttesterSlashing holds information about an attester slashing included by a block.
@@ -174,6 +278,16 @@ type AttesterSlashing struct {
 	Attestation2TargetEpoch     phase0.Epoch
 	Attestation2TargetRoot      phase0.Root
 	Attestation2Signature       phase0.BLSSignature
+	// InclusionProposerIndex is the proposer of the block that included the
+	// slashing.  It is nil for slashings stored before it was recorded.
+	InclusionProposerIndex *phase0.ValidatorIndex
+	// SlashedIndices are the validators slashed by this slashing, which
+	// excludes validators in both attestations that were already slashed.
+	// It is nil if the state was not available.
+	SlashedIndices []phase0.ValidatorIndex
+	// WhistleblowerReward is the total whistleblower reward paid to the
+	// including proposer.  It is nil if the state was not available.
+	WhistleblowerReward *phase0.Gwei
 }
 
 // ProposerSlashing holds information about a proposer slashing included by a block.
@@ -195,6 +309,12 @@ type ProposerSlashing struct {
 	Header2StateRoot     phase0.Root
 	Header2BodyRoot      phase0.Root
 	Header2Signature     phase0.BLSSignature
+	// InclusionProposerIndex is the proposer of the block that included the
+	// slashing.  It is nil for slashings stored before it was recorded.
+	InclusionProposerIndex *phase0.ValidatorIndex
+	// WhistleblowerReward is the whistleblower reward paid to the including
+	// proposer.  It is nil if the state was not available.
+	WhistleblowerReward *phase0.Gwei
 }
 
 // ValidatorEpochSummary provides a summary of a validator's operations for an epoch.
@@ -207,6 +327,7 @@ type ValidatorEpochSummary struct {
 	AttestationTargetCorrect  *bool
 	AttestationHeadCorrect    *bool
 	AttestationInclusionDelay *int
+	AttestationInclusionSlot  *phase0.Slot
 	AttestationSourceTimely   *bool
 	AttestationTargetTimely   *bool
 	AttestationHeadTimely     *bool
@@ -243,6 +364,18 @@ type EpochSummary struct {
 	Deposits                      int
 	ExitingValidators             int
 	CanonicalBlocks               int
+	// ExitQueueLength is the number of active validators that have initiated
+	// an exit but not yet exited.
+	ExitQueueLength int
+	// ChurnLimit is the number of validators that can be activated, and the
+	// number that can exit, in the epoch.
+	ChurnLimit int
+	// ActivationQueueWait is the number of epochs to clear the activation
+	// queue at the churn limit.
+	ActivationQueueWait int
+	// ExitQueueWait is the number of epochs to clear the exit queue at the
+	// churn limit.
+	ExitQueueWait int
 }
 
 // SyncCommittee holds information for sync committees.
@@ -268,3 +401,154 @@ type ExecutionPayload struct {
 	BlockHash     [32]byte
 	// No transactions.
 }
+
+// ExecutionTransaction holds information about a transaction in an execution
+// block, combined with the results from its receipt.
+type ExecutionTransaction struct {
+	BlockHash   [32]byte
+	BlockNumber uint64
+	Index       uint64
+	Hash        [32]byte
+	Type        uint64
+	From        [20]byte
+	// To is nil for contract creation transactions.
+	To                   *[20]byte
+	Nonce                uint64
+	Value                *big.Int
+	GasLimit             uint64
+	GasPrice             *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	// Information from the receipt.
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	Status            uint64
+	ContractAddress   *[20]byte
+	Logs              int
+	// No input data.
+}
+
+// ExecutionBlockSummary holds summary information about the transactions in
+// an execution block.
+type ExecutionBlockSummary struct {
+	BlockHash          [32]byte
+	BlockNumber        uint64
+	Transactions       int
+	FailedTransactions int
+	ContractCreations  int
+	Logs               int
+	GasUsed            uint64
+	TotalFees          *big.Int
+	BurntFees          *big.Int
+}
+
+// AddressLabel holds a human-readable label for an execution address.
+type AddressLabel struct {
+	Address [20]byte
+	Label   string
+	// Source is the origin of the label, for example the name of the file
+	// from which it was imported or "ens".
+	Source string
+}
+
+// ValidatorLabel holds a label for a validator, such as the node operator or
+// staking pool that runs it.
+type ValidatorLabel struct {
+	PublicKey phase0.BLSPubKey
+	Label     string
+	// Source is the origin of the label, for example the name of the file
+	// from which it was imported.
+	Source string
+}
+
+// ValidatorLabelEpochSummary provides a summary of the validators with a
+// given label for an epoch.
+type ValidatorLabelEpochSummary struct {
+	Label                     string
+	Epoch                     phase0.Epoch
+	Validators                int
+	ProposerDuties            int
+	ProposalsIncluded         int
+	AttestationsIncluded      int
+	AttestationsTargetCorrect int
+	AttestationsHeadCorrect   int
+	// AttestationsInclusionDelay is the sum of the inclusion delays of the
+	// included attestations.
+	AttestationsInclusionDelay int
+}
+
+// TableStats provides statistics about a database table.
+type TableStats struct {
+	Name string
+	// Rows is the estimated number of rows in the table, as counting the
+	// rows of large tables exactly is too expensive to do periodically.
+	Rows uint64
+	// TableSize is the size of the table on disk in bytes, excluding indices.
+	TableSize uint64
+	// IndexSize is the size of the table's indices on disk in bytes.
+	IndexSize uint64
+}
+
+// DatabaseLoad provides information about the load on the database.
+type DatabaseLoad struct {
+	// CommitLatency is the recent average time taken to commit a transaction.
+	CommitLatency time.Duration
+	// Connections is the number of connections to the database in use.
+	Connections int
+	// MaxConnections is the maximum number of connections to the database.
+	MaxConnections int
+	// ReplicationLag is the largest time by which a replica of the database
+	// is behind in replaying changes, or 0 if there are no replicas.
+	ReplicationLag time.Duration
+}
+
+// ValidatorActivation holds the progress of a validator from its first
+// deposit on the Ethereum 1 chain to its activation.
+type ValidatorActivation struct {
+	Index                      phase0.ValidatorIndex
+	DepositIndex               uint64
+	ETH1BlockTimestamp         time.Time
+	InclusionSlot              phase0.Slot
+	ActivationEligibilityEpoch phase0.Epoch
+	ActivationEpoch            phase0.Epoch
+	// InclusionLatency is the time from the deposit's Ethereum 1 block to
+	// its inclusion in the beacon chain.
+	InclusionLatency time.Duration
+	// EligibilityLatency is the time from the deposit's inclusion in the
+	// beacon chain to the validator becoming eligible for activation.
+	EligibilityLatency time.Duration
+	// ActivationLatency is the time from the validator becoming eligible for
+	// activation to its activation.
+	ActivationLatency time.Duration
+}
+
+// ValidatorActivationLatencies holds a percentile of the latencies of
+// validator activations.
+type ValidatorActivationLatencies struct {
+	// Validators is the number of activations from which the latencies are
+	// calculated.
+	Validators  int
+	Inclusion   time.Duration
+	Eligibility time.Duration
+	Activation  time.Duration
+	Total       time.Duration
+}
+
+// StateRoot holds the state root of a slot.
+type StateRoot struct {
+	Slot phase0.Slot
+	Root phase0.Root
+	// ArchiveLocation is the file path or URL of an archive that holds the
+	// full state for the slot.  It is empty if the state is not archived.
+	ArchiveLocation string
+}
+
+// EpochChecksum holds a checksum over the indexed data of a dataset for an epoch.
+type EpochChecksum struct {
+	Dataset string
+	Epoch   phase0.Epoch
+	// Rows is the number of rows covered by the checksum.
+	Rows uint64
+	// Checksum is the SHA-256 hash of the rows, in a deterministic order.
+	Checksum []byte
+}
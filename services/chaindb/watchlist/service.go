@@ -0,0 +1,186 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watchlist provides a chain database that only stores per-validator
+// data for a watched set of validators, for users that are only interested in
+// their own validators and want a much smaller database.
+package watchlist
+
+import (
+	"context"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// backend is the full set of functions a chain database must provide to be
+// wrapped by the watchlist service.
+type backend interface {
+	chaindb.Service
+	chaindb.AddressLabelsProvider
+	chaindb.AddressLabelsSetter
+	chaindb.AttestationsProvider
+	chaindb.AttestationsSetter
+	chaindb.AttesterSlashingsProvider
+	chaindb.AttesterSlashingsSetter
+	chaindb.BeaconCommitteesProvider
+	chaindb.BeaconCommitteesSetter
+	chaindb.BlocksProvider
+	chaindb.BlocksSetter
+	chaindb.ChainSpecProvider
+	chaindb.ChainSpecSetter
+	chaindb.ForkScheduleProvider
+	chaindb.ForkScheduleSetter
+	chaindb.GapsProvider
+	chaindb.GenesisProvider
+	chaindb.GenesisSetter
+	chaindb.ETH1DepositsProvider
+	chaindb.ETH1DepositsSetter
+	chaindb.ETH1DepositInclusionsProvider
+	chaindb.ETH1DepositInclusionsSetter
+	chaindb.ProposerDutiesProvider
+	chaindb.ProposerDutiesSetter
+	chaindb.MissedSlotsProvider
+	chaindb.MissedSlotsSetter
+	chaindb.ProposerSlashingsProvider
+	chaindb.ProposerSlashingsSetter
+	chaindb.SyncAggregateProvider
+	chaindb.SyncAggregateSetter
+	chaindb.ValidatorsProvider
+	chaindb.AggregateValidatorBalancesProvider
+	chaindb.AggregatesProvider
+	chaindb.TableStatsProvider
+	chaindb.StateRootsProvider
+	chaindb.StateRootsSetter
+	chaindb.ValidatorActivationsProvider
+	chaindb.ValidatorActivationsSetter
+	chaindb.DatabaseLoadProvider
+	chaindb.EpochChecksumsProvider
+	chaindb.EpochChecksumsSetter
+	chaindb.ValidatorsSetter
+	chaindb.DepositsProvider
+	chaindb.DepositsSetter
+	chaindb.VoluntaryExitsSetter
+	chaindb.ValidatorEpochSummariesSetter
+	chaindb.ValidatorLabelsProvider
+	chaindb.ValidatorLabelsSetter
+	chaindb.ValidatorLabelEpochSummariesSetter
+	chaindb.BlockSummariesProvider
+	chaindb.ValidatorEpochSummariesProvider
+	chaindb.BlockSummariesSetter
+	chaindb.EpochSummariesSetter
+	chaindb.SyncCommitteesProvider
+	chaindb.SyncCommitteesSetter
+	chaindb.MaterializedViewsSetter
+	chaindb.EpochCompletionsProvider
+	chaindb.EpochCompletionsSetter
+	chaindb.EpochProvenanceProvider
+	chaindb.EpochProvenanceSetter
+	chaindb.RangeDeleter
+	chaindb.ExecutionTransactionsSetter
+	chaindb.ExecutionBlockSummariesSetter
+	eth2client.GenesisTimeProvider
+	eth2client.SpecProvider
+}
+
+// Service is a chain database service that discards per-validator data for
+// validators that are not watched.  All reads, and the setters that are not
+// overridden here, are served by the wrapped backend.
+type Service struct {
+	backend
+	mu         sync.RWMutex
+	indices    map[phase0.ValidatorIndex]bool
+	publicKeys map[phase0.BLSPubKey]bool
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "chaindb").Str("impl", "watchlist").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	backend, isBackend := parameters.chainDB.(backend)
+	if !isBackend {
+		return nil, errors.New("chain database does not provide all required functions")
+	}
+
+	s := &Service{
+		backend:    backend,
+		indices:    make(map[phase0.ValidatorIndex]bool),
+		publicKeys: make(map[phase0.BLSPubKey]bool),
+	}
+	for _, index := range parameters.indices {
+		s.indices[index] = true
+	}
+	for _, publicKey := range parameters.publicKeys {
+		s.publicKeys[publicKey] = true
+	}
+
+	// Public keys of validators that are already known can be resolved to
+	// indices now; others are resolved as the validators are written.
+	if len(parameters.publicKeys) > 0 {
+		validators, err := backend.ValidatorsByPublicKey(ctx, parameters.publicKeys)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain watched validators")
+		}
+		for _, validator := range validators {
+			s.indices[validator.Index] = true
+		}
+	}
+	log.Trace().Int("indices", len(s.indices)).Int("public_keys", len(s.publicKeys)).Msg("Watching validators")
+
+	return s, nil
+}
+
+// watched returns true if the validator with the given index is watched.
+func (s *Service) watched(index phase0.ValidatorIndex) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.indices[index]
+}
+
+// AwaitLeadership blocks until this instance is the leader of the wrapped backend.
+func (s *Service) AwaitLeadership(ctx context.Context) (<-chan struct{}, error) {
+	elector, isElector := s.backend.(chaindb.LeaderElector)
+	if !isElector {
+		return nil, errors.New("chain database does not support leader election")
+	}
+
+	return elector.AwaitLeadership(ctx)
+}
+
+// Close closes the wrapped backend.
+func (s *Service) Close(ctx context.Context) error {
+	closer, isCloser := s.backend.(chaindb.Closer)
+	if !isCloser {
+		return nil
+	}
+
+	return closer.Close(ctx)
+}
@@ -0,0 +1,187 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchlist
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+// recordingBackend is a mock backend that records the per-validator data written to it.
+type recordingBackend struct {
+	backend
+	balances     []*chaindb.ValidatorBalance
+	summaries    []*chaindb.ValidatorEpochSummary
+	attestations []*chaindb.Attestation
+}
+
+func (b *recordingBackend) SetValidatorBalance(_ context.Context, validatorBalance *chaindb.ValidatorBalance) error {
+	b.balances = append(b.balances, validatorBalance)
+	return nil
+}
+
+func (b *recordingBackend) SetValidatorBalances(_ context.Context, validatorBalances []*chaindb.ValidatorBalance) error {
+	b.balances = append(b.balances, validatorBalances...)
+	return nil
+}
+
+func (b *recordingBackend) SetValidatorEpochSummary(_ context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	b.summaries = append(b.summaries, summary)
+	return nil
+}
+
+func (b *recordingBackend) SetValidatorEpochSummaries(_ context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	b.summaries = append(b.summaries, summaries...)
+	return nil
+}
+
+func (b *recordingBackend) SetAttestation(_ context.Context, attestation *chaindb.Attestation) error {
+	b.attestations = append(b.attestations, attestation)
+	return nil
+}
+
+func (b *recordingBackend) SetAttestations(_ context.Context, attestations []*chaindb.Attestation) error {
+	b.attestations = append(b.attestations, attestations...)
+	return nil
+}
+
+func TestWrites(t *testing.T) {
+	ctx := context.Background()
+
+	b := &recordingBackend{backend: mockchaindb.New().(backend)}
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(b),
+		WithIndices([]phase0.ValidatorIndex{1}),
+		WithPublicKeys([]phase0.BLSPubKey{{0x03}}),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetValidatorBalances(ctx, []*chaindb.ValidatorBalance{{Index: 1}, {Index: 2}, {Index: 3}}))
+	require.Len(t, b.balances, 1)
+	require.Equal(t, phase0.ValidatorIndex(1), b.balances[0].Index)
+
+	// Watched public keys are resolved once their validator is written.
+	require.NoError(t, s.SetValidator(ctx, &chaindb.Validator{Index: 3, PublicKey: phase0.BLSPubKey{0x03}}))
+	require.NoError(t, s.SetValidatorEpochSummaries(ctx, []*chaindb.ValidatorEpochSummary{{Index: 1}, {Index: 2}, {Index: 3}}))
+	require.Len(t, b.summaries, 2)
+	require.Equal(t, phase0.ValidatorIndex(3), b.summaries[1].Index)
+
+	// Attestations are kept if any of their attesters is watched.
+	require.NoError(t, s.SetAttestations(ctx, []*chaindb.Attestation{
+		{Slot: 1, AggregationIndices: []phase0.ValidatorIndex{2, 3}},
+		{Slot: 2, AggregationIndices: []phase0.ValidatorIndex{4, 5}},
+	}))
+	require.Len(t, b.attestations, 1)
+	require.Equal(t, phase0.Slot(1), b.attestations[0].Slot)
+
+	// Nothing is written if nothing is watched.
+	require.NoError(t, s.SetValidatorBalances(ctx, []*chaindb.ValidatorBalance{{Index: 2}}))
+	require.Len(t, b.balances, 1)
+}
+
+// newTestService creates a watchlist service watching validator 1 by index and
+// validator 3 by public key, and the backend to which it writes.
+func newTestService(t *testing.T) (*Service, *recordingBackend) {
+	t.Helper()
+
+	b := &recordingBackend{backend: mockchaindb.New().(backend)}
+	s, err := New(context.Background(),
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(b),
+		WithIndices([]phase0.ValidatorIndex{1}),
+		WithPublicKeys([]phase0.BLSPubKey{{0x03}}),
+	)
+	require.NoError(t, err)
+	require.NoError(t, s.SetValidator(context.Background(), &chaindb.Validator{Index: 3, PublicKey: phase0.BLSPubKey{0x03}}))
+
+	return s, b
+}
+
+func TestSetValidatorBalance(t *testing.T) {
+	ctx := context.Background()
+	s, b := newTestService(t)
+
+	for _, index := range []phase0.ValidatorIndex{1, 2, 3} {
+		require.NoError(t, s.SetValidatorBalance(ctx, &chaindb.ValidatorBalance{Index: index}))
+	}
+	require.Len(t, b.balances, 2)
+	require.Equal(t, phase0.ValidatorIndex(1), b.balances[0].Index)
+	require.Equal(t, phase0.ValidatorIndex(3), b.balances[1].Index)
+}
+
+func TestSetValidatorBalances(t *testing.T) {
+	ctx := context.Background()
+	s, b := newTestService(t)
+
+	require.NoError(t, s.SetValidatorBalances(ctx, []*chaindb.ValidatorBalance{{Index: 1}, {Index: 2}, {Index: 3}}))
+	require.Len(t, b.balances, 2)
+	require.Equal(t, phase0.ValidatorIndex(1), b.balances[0].Index)
+	require.Equal(t, phase0.ValidatorIndex(3), b.balances[1].Index)
+}
+
+func TestSetValidatorEpochSummary(t *testing.T) {
+	ctx := context.Background()
+	s, b := newTestService(t)
+
+	for _, index := range []phase0.ValidatorIndex{1, 2, 3} {
+		require.NoError(t, s.SetValidatorEpochSummary(ctx, &chaindb.ValidatorEpochSummary{Index: index}))
+	}
+	require.Len(t, b.summaries, 2)
+	require.Equal(t, phase0.ValidatorIndex(1), b.summaries[0].Index)
+	require.Equal(t, phase0.ValidatorIndex(3), b.summaries[1].Index)
+}
+
+func TestSetValidatorEpochSummaries(t *testing.T) {
+	ctx := context.Background()
+	s, b := newTestService(t)
+
+	require.NoError(t, s.SetValidatorEpochSummaries(ctx, []*chaindb.ValidatorEpochSummary{{Index: 2}, {Index: 4}}))
+	require.Len(t, b.summaries, 0)
+	require.NoError(t, s.SetValidatorEpochSummaries(ctx, []*chaindb.ValidatorEpochSummary{{Index: 1}, {Index: 2}, {Index: 3}}))
+	require.Len(t, b.summaries, 2)
+	require.Equal(t, phase0.ValidatorIndex(1), b.summaries[0].Index)
+	require.Equal(t, phase0.ValidatorIndex(3), b.summaries[1].Index)
+}
+
+func TestSetAttestation(t *testing.T) {
+	ctx := context.Background()
+	s, b := newTestService(t)
+
+	require.NoError(t, s.SetAttestation(ctx, &chaindb.Attestation{Slot: 1, AggregationIndices: []phase0.ValidatorIndex{2, 3}}))
+	require.NoError(t, s.SetAttestation(ctx, &chaindb.Attestation{Slot: 2, AggregationIndices: []phase0.ValidatorIndex{4, 5}}))
+	require.NoError(t, s.SetAttestation(ctx, &chaindb.Attestation{Slot: 3, AggregationIndices: []phase0.ValidatorIndex{1}}))
+	require.Len(t, b.attestations, 2)
+	require.Equal(t, phase0.Slot(1), b.attestations[0].Slot)
+	require.Equal(t, phase0.Slot(3), b.attestations[1].Slot)
+}
+
+func TestSetAttestations(t *testing.T) {
+	ctx := context.Background()
+	s, b := newTestService(t)
+
+	require.NoError(t, s.SetAttestations(ctx, []*chaindb.Attestation{
+		{Slot: 1, AggregationIndices: []phase0.ValidatorIndex{2, 3}},
+		{Slot: 2, AggregationIndices: []phase0.ValidatorIndex{4, 5}},
+		{Slot: 3, AggregationIndices: []phase0.ValidatorIndex{}},
+	}))
+	require.Len(t, b.attestations, 1)
+	require.Equal(t, phase0.Slot(1), b.attestations[0].Slot)
+}
@@ -0,0 +1,79 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchlist_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chaindb/watchlist"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []watchlist.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []watchlist.Parameter{
+				watchlist.WithLogLevel(zerolog.Disabled),
+				watchlist.WithIndices([]phase0.ValidatorIndex{1}),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ValidatorsMissing",
+			params: []watchlist.Parameter{
+				watchlist.WithLogLevel(zerolog.Disabled),
+				watchlist.WithChainDB(mockchaindb.New()),
+			},
+			err: "problem with parameters: no validators specified",
+		},
+		{
+			name: "GoodIndices",
+			params: []watchlist.Parameter{
+				watchlist.WithLogLevel(zerolog.Disabled),
+				watchlist.WithChainDB(mockchaindb.New()),
+				watchlist.WithIndices([]phase0.ValidatorIndex{1}),
+			},
+		},
+		{
+			name: "GoodPublicKeys",
+			params: []watchlist.Parameter{
+				watchlist.WithLogLevel(zerolog.Disabled),
+				watchlist.WithChainDB(mockchaindb.New()),
+				watchlist.WithPublicKeys([]phase0.BLSPubKey{{0x01}}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := watchlist.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchlist
+
+import (
+	"context"
+
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// SetValidator sets a validator.
+// All validators are stored, as they are needed to follow the chain; watched
+// public keys are resolved to indices as their validators are seen.
+func (s *Service) SetValidator(ctx context.Context, validator *chaindb.Validator) error {
+	if err := s.backend.SetValidator(ctx, validator); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.publicKeys[validator.PublicKey] && !s.indices[validator.Index] {
+		log.Trace().Uint64("index", uint64(validator.Index)).Msg("Watched validator seen")
+		s.indices[validator.Index] = true
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// SetValidatorBalance sets a validator balance, if the validator is watched.
+func (s *Service) SetValidatorBalance(ctx context.Context, validatorBalance *chaindb.ValidatorBalance) error {
+	if !s.watched(validatorBalance.Index) {
+		return nil
+	}
+
+	return s.backend.SetValidatorBalance(ctx, validatorBalance)
+}
+
+// SetValidatorBalances sets the balances of the watched validators.
+func (s *Service) SetValidatorBalances(ctx context.Context, validatorBalances []*chaindb.ValidatorBalance) error {
+	filtered := make([]*chaindb.ValidatorBalance, 0)
+	for _, validatorBalance := range validatorBalances {
+		if s.watched(validatorBalance.Index) {
+			filtered = append(filtered, validatorBalance)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	return s.backend.SetValidatorBalances(ctx, filtered)
+}
+
+// SetValidatorEpochSummary sets a validator epoch summary, if the validator is watched.
+func (s *Service) SetValidatorEpochSummary(ctx context.Context, summary *chaindb.ValidatorEpochSummary) error {
+	if !s.watched(summary.Index) {
+		return nil
+	}
+
+	return s.backend.SetValidatorEpochSummary(ctx, summary)
+}
+
+// SetValidatorEpochSummaries sets the epoch summaries of the watched validators.
+func (s *Service) SetValidatorEpochSummaries(ctx context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	filtered := make([]*chaindb.ValidatorEpochSummary, 0)
+	for _, summary := range summaries {
+		if s.watched(summary.Index) {
+			filtered = append(filtered, summary)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	return s.backend.SetValidatorEpochSummaries(ctx, filtered)
+}
+
+// SetAttestation sets an attestation, if it contains the vote of a watched validator.
+func (s *Service) SetAttestation(ctx context.Context, attestation *chaindb.Attestation) error {
+	if !s.watchedAttestation(attestation) {
+		return nil
+	}
+
+	return s.backend.SetAttestation(ctx, attestation)
+}
+
+// SetAttestations sets the attestations that contain the vote of a watched validator.
+func (s *Service) SetAttestations(ctx context.Context, attestations []*chaindb.Attestation) error {
+	filtered := make([]*chaindb.Attestation, 0)
+	for _, attestation := range attestations {
+		if s.watchedAttestation(attestation) {
+			filtered = append(filtered, attestation)
+		}
+	}
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	return s.backend.SetAttestations(ctx, filtered)
+}
+
+// watchedAttestation returns true if the attestation contains the vote of a watched validator.
+func (s *Service) watchedAttestation(attestation *chaindb.Attestation) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, index := range attestation.AggregationIndices {
+		if s.indices[index] {
+			return true
+		}
+	}
+
+	return false
+}
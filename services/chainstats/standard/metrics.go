@@ -0,0 +1,108 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_chainstats"
+
+var participationRate prometheus.Gauge
+var activeValidators prometheus.Gauge
+var missedBlocks prometheus.Gauge
+var finalityDistance prometheus.Gauge
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if participationRate != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	participationRate = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "participation_rate",
+		Help:      "Proportion of active balance that attested in the latest summarized epoch",
+	})
+	if err := prometheus.Register(participationRate); err != nil {
+		return errors.Wrap(err, "failed to register participation_rate")
+	}
+
+	activeValidators = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "active_validators",
+		Help:      "Number of validators active in the current epoch",
+	})
+	if err := prometheus.Register(activeValidators); err != nil {
+		return errors.Wrap(err, "failed to register active_validators")
+	}
+
+	missedBlocks = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "missed_blocks",
+		Help:      "Number of slots without a canonical block in recent finalized epochs",
+	})
+	if err := prometheus.Register(missedBlocks); err != nil {
+		return errors.Wrap(err, "failed to register missed_blocks")
+	}
+
+	finalityDistance = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "finality_distance",
+		Help:      "Number of epochs between the current epoch and the epoch of the latest canonical block",
+	})
+	if err := prometheus.Register(finalityDistance); err != nil {
+		return errors.Wrap(err, "failed to register finality_distance")
+	}
+
+	return nil
+}
+
+func monitorParticipationRate(rate float64) {
+	if participationRate != nil {
+		participationRate.Set(rate)
+	}
+}
+
+func monitorActiveValidators(count uint64) {
+	if activeValidators != nil {
+		activeValidators.Set(float64(count))
+	}
+}
+
+func monitorMissedBlocks(count uint64) {
+	if missedBlocks != nil {
+		missedBlocks.Set(float64(count))
+	}
+}
+
+func monitorFinalityDistance(distance uint64) {
+	if finalityDistance != nil {
+		finalityDistance.Set(float64(distance))
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+// Service is a chain statistics service, periodically calculating statistics
+// about the chain from the chain database and exporting them as metrics.
+type Service struct {
+	chainTime          chaintime.Service
+	blocksProvider     chaindb.BlocksProvider
+	aggregatesProvider chaindb.AggregatesProvider
+	interval           time.Duration
+	epochs             uint64
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "chainstats").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	aggregatesProvider, isProvider := parameters.chainDB.(chaindb.AggregatesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide aggregates")
+	}
+
+	s := &Service{
+		chainTime:          parameters.chainTime,
+		blocksProvider:     blocksProvider,
+		aggregatesProvider: aggregatesProvider,
+		interval:           parameters.interval,
+		epochs:             parameters.epochs,
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically updates the chain statistics.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.update(ctx)
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping chain statistics")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update calculates the chain statistics.  Failure to calculate one statistic
+// does not stop the others from being updated.
+func (s *Service) update(ctx context.Context) {
+	currentEpoch := s.chainTime.CurrentEpoch()
+
+	if err := s.updateParticipation(ctx, currentEpoch); err != nil {
+		log.Warn().Err(err).Msg("Failed to update participation rate")
+	}
+
+	count, err := s.aggregatesProvider.ActiveValidatorCount(ctx, currentEpoch)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain active validator count")
+	} else {
+		monitorActiveValidators(count)
+	}
+
+	if err := s.updateFinality(ctx, currentEpoch); err != nil {
+		log.Warn().Err(err).Msg("Failed to update finality statistics")
+	}
+}
+
+// updateParticipation updates the participation rate with that of the latest summarized epoch.
+func (s *Service) updateParticipation(ctx context.Context, currentEpoch phase0.Epoch) error {
+	startEpoch := phase0.Epoch(0)
+	if uint64(currentEpoch) > s.epochs {
+		startEpoch = currentEpoch - phase0.Epoch(s.epochs)
+	}
+	participations, err := s.aggregatesProvider.EpochParticipationForEpochRange(ctx, startEpoch, currentEpoch+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain participation")
+	}
+	if len(participations) == 0 {
+		log.Trace().Msg("No recent epoch summaries; not updating participation rate")
+		return nil
+	}
+	monitorParticipationRate(participations[len(participations)-1].Rate)
+
+	return nil
+}
+
+// updateFinality updates the finality distance, and the number of missed blocks in
+// the finalized epochs leading up to the latest canonical block.
+func (s *Service) updateFinality(ctx context.Context, currentEpoch phase0.Epoch) error {
+	latestCanonicalSlot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest canonical block")
+	}
+
+	finalizedEpoch := s.chainTime.SlotToEpoch(latestCanonicalSlot)
+	if currentEpoch > finalizedEpoch {
+		monitorFinalityDistance(uint64(currentEpoch - finalizedEpoch))
+	} else {
+		monitorFinalityDistance(0)
+	}
+
+	endSlot := latestCanonicalSlot + 1
+	slots := phase0.Slot(s.epochs) * s.chainTime.FirstSlotOfEpoch(1)
+	startSlot := phase0.Slot(0)
+	if endSlot > slots {
+		startSlot = endSlot - slots
+	}
+	presence, err := s.blocksProvider.CanonicalBlockPresenceForSlotRange(ctx, startSlot, endSlot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain canonical block presence")
+	}
+	missed := uint64(0)
+	for _, present := range presence {
+		if !present {
+			missed++
+		}
+	}
+	monitorMissedBlocks(missed)
+
+	return nil
+}
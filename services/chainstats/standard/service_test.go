@@ -0,0 +1,97 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/chainstats/standard"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	chainTime := mockchaintime.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "IntervalZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithInterval(0),
+			},
+			err: "problem with parameters: interval must be greater than 0",
+		},
+		{
+			name: "EpochsZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithEpochs(0),
+			},
+			err: "problem with parameters: epochs must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithInterval(time.Second),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package chaintime
 import (
 	"time"
 
+	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/wealdtech/chaind/services/chaintime"
 )
@@ -95,3 +96,8 @@ func (s *service) AltairInitialEpoch() phase0.Epoch {
 func (s *service) AltairInitialSyncCommitteePeriod() uint64 {
 	return 0
 }
+
+// EpochDataVersion provides the data version of the fork in effect at the given epoch.
+func (s *service) EpochDataVersion(epoch phase0.Epoch) spec.DataVersion {
+	return spec.DataVersionPhase0
+}
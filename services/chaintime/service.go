@@ -16,6 +16,7 @@ package chaintime
 import (
 	"time"
 
+	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 )
 
@@ -51,4 +52,6 @@ type Service interface {
 	AltairInitialEpoch() phase0.Epoch
 	// AltairInitialSyncCommitteePeriod provides the sync committee period in which the Altair hard fork takes place.
 	AltairInitialSyncCommitteePeriod() uint64
+	// EpochDataVersion provides the data version of the fork in effect at the given epoch.
+	EpochDataVersion(epoch phase0.Epoch) spec.DataVersion
 }
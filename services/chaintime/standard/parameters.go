@@ -14,16 +14,22 @@
 package standard
 
 import (
+	"time"
+
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
 type parameters struct {
-	logLevel             zerolog.Level
-	genesisTimeProvider  eth2client.GenesisTimeProvider
-	specProvider         eth2client.SpecProvider
-	forkScheduleProvider eth2client.ForkScheduleProvider
+	logLevel                     zerolog.Level
+	logLevelSampler              zerolog.Sampler
+	genesisTimeProvider          eth2client.GenesisTimeProvider
+	specProvider                 eth2client.SpecProvider
+	forkScheduleProvider         eth2client.ForkScheduleProvider
+	slotDuration                 time.Duration
+	slotsPerEpoch                uint64
+	epochsPerSyncCommitteePeriod uint64
 }
 
 // Parameter is the interface for service parameters.
@@ -44,6 +50,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithGenesisTimeProvider sets the genesis time provider.
 func WithGenesisTimeProvider(provider eth2client.GenesisTimeProvider) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -65,6 +79,28 @@ func WithForkScheduleProvider(provider eth2client.ForkScheduleProvider) Paramete
 	})
 }
 
+// WithSlotDuration sets the duration of a slot, overriding SECONDS_PER_SLOT in the spec.
+func WithSlotDuration(duration time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotDuration = duration
+	})
+}
+
+// WithSlotsPerEpoch sets the number of slots in an epoch, overriding SLOTS_PER_EPOCH in the spec.
+func WithSlotsPerEpoch(slotsPerEpoch uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotsPerEpoch = slotsPerEpoch
+	})
+}
+
+// WithEpochsPerSyncCommitteePeriod sets the number of epochs in a sync committee period,
+// overriding EPOCHS_PER_SYNC_COMMITTEE_PERIOD in the spec.
+func WithEpochsPerSyncCommitteePeriod(epochs uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.epochsPerSyncCommitteePeriod = epochs
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
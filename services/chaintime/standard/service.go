@@ -16,9 +16,11 @@ package standard
 import (
 	"bytes"
 	"context"
+	"sort"
 	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -33,6 +35,7 @@ type Service struct {
 	epochsPerSyncCommitteePeriod uint64
 	altairForkEpoch              phase0.Epoch
 	bellatrixForkEpoch           phase0.Epoch
+	forkEpochs                   []phase0.Epoch
 }
 
 // module-wide log.
@@ -47,6 +50,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "chaintime").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	genesisTime, err := parameters.genesisTimeProvider.GenesisTime(ctx)
 	if err != nil {
@@ -59,32 +65,48 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.Wrap(err, "failed to obtain spec")
 	}
 
-	tmp, exists := spec["SECONDS_PER_SLOT"]
-	if !exists {
-		return nil, errors.New("SECONDS_PER_SLOT not found in spec")
-	}
-	slotDuration, ok := tmp.(time.Duration)
-	if !ok {
-		return nil, errors.New("SECONDS_PER_SLOT of unexpected type")
+	// Explicitly configured values take precedence over those in the spec.
+	slotDuration := parameters.slotDuration
+	if slotDuration == 0 {
+		tmp, exists := spec["SECONDS_PER_SLOT"]
+		if !exists {
+			return nil, errors.New("SECONDS_PER_SLOT not found in spec")
+		}
+		var ok bool
+		slotDuration, ok = tmp.(time.Duration)
+		if !ok {
+			return nil, errors.New("SECONDS_PER_SLOT of unexpected type")
+		}
 	}
 
-	tmp, exists = spec["SLOTS_PER_EPOCH"]
-	if !exists {
-		return nil, errors.New("SLOTS_PER_EPOCH not found in spec")
-	}
-	slotsPerEpoch, ok := tmp.(uint64)
-	if !ok {
-		return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
+	slotsPerEpoch := parameters.slotsPerEpoch
+	if slotsPerEpoch == 0 {
+		tmp, exists := spec["SLOTS_PER_EPOCH"]
+		if !exists {
+			return nil, errors.New("SLOTS_PER_EPOCH not found in spec")
+		}
+		var ok bool
+		slotsPerEpoch, ok = tmp.(uint64)
+		if !ok {
+			return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
+		}
 	}
 
-	var epochsPerSyncCommitteePeriod uint64
-	if tmp, exists := spec["EPOCHS_PER_SYNC_COMMITTEE_PERIOD"]; exists {
-		tmp2, ok := tmp.(uint64)
-		if !ok {
-			return nil, errors.New("EPOCHS_PER_SYNC_COMMITTEE_PERIOD of unexpected type")
+	epochsPerSyncCommitteePeriod := parameters.epochsPerSyncCommitteePeriod
+	if epochsPerSyncCommitteePeriod == 0 {
+		if tmp, exists := spec["EPOCHS_PER_SYNC_COMMITTEE_PERIOD"]; exists {
+			tmp2, ok := tmp.(uint64)
+			if !ok {
+				return nil, errors.New("EPOCHS_PER_SYNC_COMMITTEE_PERIOD of unexpected type")
+			}
+			epochsPerSyncCommitteePeriod = tmp2
 		}
-		epochsPerSyncCommitteePeriod = tmp2
 	}
+	log.Trace().
+		Dur("slot_duration", slotDuration).
+		Uint64("slots_per_epoch", slotsPerEpoch).
+		Uint64("epochs_per_sync_committee_period", epochsPerSyncCommitteePeriod).
+		Msg("Obtained chain parameters")
 
 	altairForkEpoch, err := fetchAltairForkEpoch(ctx, parameters.forkScheduleProvider)
 	if err != nil {
@@ -98,6 +120,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		bellatrixForkEpoch = 0xffffffffffffffff
 	}
 	log.Trace().Uint64("epoch", uint64(bellatrixForkEpoch)).Msg("Obtained Bellatrix fork epoch")
+	forkEpochs, err := fetchForkEpochs(ctx, parameters.forkScheduleProvider)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain fork epochs")
+	}
 
 	s := &Service{
 		genesisTime:                  genesisTime,
@@ -106,6 +132,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		epochsPerSyncCommitteePeriod: epochsPerSyncCommitteePeriod,
 		altairForkEpoch:              altairForkEpoch,
 		bellatrixForkEpoch:           bellatrixForkEpoch,
+		forkEpochs:                   forkEpochs,
 	}
 
 	return s, nil
@@ -202,6 +229,19 @@ func (s *Service) AltairInitialSyncCommitteePeriod() uint64 {
 	return uint64(s.altairForkEpoch) / s.epochsPerSyncCommitteePeriod
 }
 
+// EpochDataVersion provides the data version of the fork in effect at the given epoch,
+// according to the fork schedule.
+func (s *Service) EpochDataVersion(epoch phase0.Epoch) spec.DataVersion {
+	version := spec.DataVersionPhase0
+	for _, forkEpoch := range s.forkEpochs {
+		if epoch < forkEpoch {
+			break
+		}
+		version++
+	}
+	return version
+}
+
 func fetchAltairForkEpoch(ctx context.Context, provider eth2client.ForkScheduleProvider) (phase0.Epoch, error) {
 	forkSchedule, err := provider.ForkSchedule(ctx)
 	if err != nil {
@@ -239,3 +279,23 @@ func fetchBellatrixForkEpoch(ctx context.Context, provider eth2client.ForkSchedu
 	}
 	return 0, errors.New("no bellatrix fork obtained")
 }
+
+// fetchForkEpochs obtains the epochs of the forks after genesis, in order.
+func fetchForkEpochs(ctx context.Context, provider eth2client.ForkScheduleProvider) ([]phase0.Epoch, error) {
+	forkSchedule, err := provider.ForkSchedule(ctx)
+	if err != nil {
+		return nil, err
+	}
+	forkEpochs := make([]phase0.Epoch, 0, len(forkSchedule))
+	for i := range forkSchedule {
+		if bytes.Equal(forkSchedule[i].CurrentVersion[:], forkSchedule[i].PreviousVersion[:]) {
+			// This is the genesis fork; ignore it.
+			continue
+		}
+		forkEpochs = append(forkEpochs, forkSchedule[i].Epoch)
+	}
+	sort.Slice(forkEpochs, func(i int, j int) bool {
+		return forkEpochs[i] < forkEpochs[j]
+	})
+	return forkEpochs, nil
+}
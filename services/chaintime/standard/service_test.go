@@ -15,9 +15,11 @@ package standard_test
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -256,3 +258,73 @@ func TestTimestampToEpoch(t *testing.T) {
 		})
 	}
 }
+
+func TestEpochDataVersion(t *testing.T) {
+	// Forks at non-mainnet epochs, listed out of order.
+	forkSchedule := []*phase0.Fork{
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x01},
+			CurrentVersion:  phase0.Version{0x00, 0x00, 0x00, 0x01},
+			Epoch:           0,
+		},
+		{
+			PreviousVersion: phase0.Version{0x01, 0x00, 0x00, 0x01},
+			CurrentVersion:  phase0.Version{0x02, 0x00, 0x00, 0x01},
+			Epoch:           25,
+		},
+		{
+			PreviousVersion: phase0.Version{0x00, 0x00, 0x00, 0x01},
+			CurrentVersion:  phase0.Version{0x01, 0x00, 0x00, 0x01},
+			Epoch:           5,
+		},
+	}
+	s, err := standard.New(context.Background(),
+		standard.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standard.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 32, 256)),
+		standard.WithForkScheduleProvider(mock.NewForkScheduleProvider(forkSchedule)),
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		epoch   phase0.Epoch
+		version spec.DataVersion
+	}{
+		{epoch: 0, version: spec.DataVersionPhase0},
+		{epoch: 4, version: spec.DataVersionPhase0},
+		{epoch: 5, version: spec.DataVersionAltair},
+		{epoch: 24, version: spec.DataVersionAltair},
+		{epoch: 25, version: spec.DataVersionBellatrix},
+		{epoch: 1000000, version: spec.DataVersionBellatrix},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.version, s.EpochDataVersion(test.epoch), fmt.Sprintf("epoch %d", test.epoch))
+	}
+}
+
+func TestChainParameterOverrides(t *testing.T) {
+	genesisTime := time.Now()
+	forkSchedule := []*phase0.Fork{
+		{
+			PreviousVersion: phase0.Version{0x01, 0x02, 0x03, 0x04},
+			CurrentVersion:  phase0.Version{0x01, 0x02, 0x03, 0x04},
+			Epoch:           0,
+		},
+	}
+
+	// Gnosis Chain parameters override the mainnet values in the spec.
+	s, err := standard.New(context.Background(),
+		standard.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(genesisTime)),
+		standard.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 32, 256)),
+		standard.WithForkScheduleProvider(mock.NewForkScheduleProvider(forkSchedule)),
+		standard.WithSlotDuration(5*time.Second),
+		standard.WithSlotsPerEpoch(16),
+		standard.WithEpochsPerSyncCommitteePeriod(512),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, genesisTime.Add(5*time.Second), s.StartOfSlot(1))
+	require.Equal(t, phase0.Slot(16), s.FirstSlotOfEpoch(1))
+	require.Equal(t, phase0.Epoch(2), s.SlotToEpoch(32))
+	require.Equal(t, uint64(1), s.EpochToSyncCommitteePeriod(512))
+	require.Equal(t, phase0.Epoch(1), s.TimestampToEpoch(genesisTime.Add(80*time.Second)))
+}
@@ -0,0 +1,99 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// OnEpochIndexed is called when the summary for an epoch has been committed to the database,
+// at which point all information for the epoch is present.
+func (s *Service) OnEpochIndexed(ctx context.Context, epoch phase0.Epoch) {
+	// Checksums are computed in the background so as not to hold up the caller.
+	go s.onEpochIndexed(ctx, epoch)
+}
+
+func (s *Service) onEpochIndexed(ctx context.Context, epoch phase0.Epoch) {
+	log := log.With().Uint64("epoch", uint64(epoch)).Logger()
+	log.Trace().Msg("Handler called")
+
+	// Handlers run one at a time, so that each epoch indexed is checksummed.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		log.Debug().Err(err).Msg("Failed to acquire semaphore")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.catchup(ctx, epoch); err != nil {
+		monitorFailure()
+		log.Warn().Err(err).Msg("Failed to compute checksums; will retry when the next epoch is indexed")
+		return
+	}
+
+	log.Trace().Msg("Finished handling indexed epoch")
+}
+
+// catchup computes checksums for the epochs up to and including the given
+// epoch.  If the epoch has already been checksummed it has been indexed again,
+// for example following a reorg, so only its checksums are recomputed.
+func (s *Service) catchup(ctx context.Context, epoch phase0.Epoch) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	if epoch < md.NextEpoch {
+		return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.checksumEpoch(ctx, epoch)
+		})
+	}
+
+	for md.NextEpoch <= epoch {
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			if err := s.checksumEpoch(ctx, md.NextEpoch); err != nil {
+				return err
+			}
+			md.NextEpoch++
+			return s.setMetadata(ctx, md)
+		}); err != nil {
+			return err
+		}
+		monitorLatestEpoch(md.NextEpoch - 1)
+	}
+
+	return nil
+}
+
+// checksumEpoch computes and stores the checksums of each dataset for an epoch.
+func (s *Service) checksumEpoch(ctx context.Context, epoch phase0.Epoch) error {
+	checksums := make([]*chaindb.EpochChecksum, 0, len(s.datasets))
+	for _, dataset := range s.datasets {
+		checksum, err := s.epochChecksumsProvider.ComputeEpochChecksum(ctx, dataset, epoch)
+		if err != nil {
+			return errors.Wrapf(err, "failed to compute checksum of %s for epoch %d", dataset, epoch)
+		}
+		checksums = append(checksums, checksum)
+	}
+	if err := s.epochChecksumsSetter.SetEpochChecksums(ctx, checksums); err != nil {
+		return errors.Wrap(err, "failed to set checksums")
+	}
+	log.Trace().Uint64("epoch", uint64(epoch)).Msg("Computed checksums")
+
+	return nil
+}
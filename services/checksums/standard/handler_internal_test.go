@@ -0,0 +1,94 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+)
+
+// checksumsDB stores metadata and records the checksums written to it.
+type checksumsDB struct {
+	chaindb.Service
+	metadata  map[string][]byte
+	checksums map[phase0.Epoch][]*chaindb.EpochChecksum
+	computed  int
+}
+
+func (d *checksumsDB) Metadata(_ context.Context, key string) ([]byte, error) {
+	return d.metadata[key], nil
+}
+
+func (d *checksumsDB) SetMetadata(_ context.Context, key string, value []byte) error {
+	d.metadata[key] = value
+	return nil
+}
+
+func (*checksumsDB) EpochChecksumDatasets() []string {
+	return []string{"blocks", "proposerduties"}
+}
+
+func (d *checksumsDB) ComputeEpochChecksum(_ context.Context, dataset string, epoch phase0.Epoch) (*chaindb.EpochChecksum, error) {
+	d.computed++
+	return &chaindb.EpochChecksum{Dataset: dataset, Epoch: epoch}, nil
+}
+
+func (*checksumsDB) EpochChecksums(_ context.Context, _ phase0.Epoch, _ phase0.Epoch) ([]*chaindb.EpochChecksum, error) {
+	return nil, nil
+}
+
+func (d *checksumsDB) SetEpochChecksums(_ context.Context, checksums []*chaindb.EpochChecksum) error {
+	d.checksums[checksums[0].Epoch] = checksums
+	return nil
+}
+
+func TestCatchup(t *testing.T) {
+	ctx := context.Background()
+	chainDB := &checksumsDB{
+		Service:   mockchaindb.New(),
+		metadata:  make(map[string][]byte),
+		checksums: make(map[phase0.Epoch][]*chaindb.EpochChecksum),
+	}
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(chainDB),
+		WithStartEpoch(10),
+	)
+	require.NoError(t, err)
+
+	// Catchup computes checksums of all datasets for each epoch from the start epoch.
+	require.NoError(t, s.catchup(ctx, 12))
+	require.Len(t, chainDB.checksums, 3)
+	require.Len(t, chainDB.checksums[10], 2)
+	require.Equal(t, "blocks", chainDB.checksums[12][0].Dataset)
+	require.Equal(t, "proposerduties", chainDB.checksums[12][1].Dataset)
+	require.Equal(t, 6, chainDB.computed)
+	md, err := s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(13), md.NextEpoch)
+
+	// An epoch indexed again is recomputed without moving on.
+	require.NoError(t, s.catchup(ctx, 11))
+	require.Equal(t, 8, chainDB.computed)
+	md, err = s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(13), md.NextEpoch)
+}
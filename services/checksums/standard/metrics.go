@@ -0,0 +1,77 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_checksums"
+
+var latestEpoch prometheus.Gauge
+var failures prometheus.Counter
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if latestEpoch != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_epoch",
+		Help:      "Latest epoch for which checksums were computed",
+	})
+	if err := prometheus.Register(latestEpoch); err != nil {
+		return errors.Wrap(err, "failed to register latest_epoch")
+	}
+
+	failures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "failures_total",
+		Help:      "Number of failures to compute checksums",
+	})
+	if err := prometheus.Register(failures); err != nil {
+		return errors.Wrap(err, "failed to register failures_total")
+	}
+
+	return nil
+}
+
+func monitorLatestEpoch(epoch phase0.Epoch) {
+	if latestEpoch != nil {
+		latestEpoch.Set(float64(epoch))
+	}
+}
+
+func monitorFailure() {
+	if failures != nil {
+		failures.Inc()
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a checksums service, computing a checksum over the data of each
+// dataset for each epoch once the epoch has been indexed.
+type Service struct {
+	chainDB                chaindb.Service
+	epochChecksumsProvider chaindb.EpochChecksumsProvider
+	epochChecksumsSetter   chaindb.EpochChecksumsSetter
+	datasets               []string
+	activitySem            *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "checksums").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	epochChecksumsProvider, isProvider := parameters.chainDB.(chaindb.EpochChecksumsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide epoch checksums")
+	}
+	epochChecksumsSetter, isSetter := parameters.chainDB.(chaindb.EpochChecksumsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support epoch checksum setting")
+	}
+
+	available := make(map[string]bool)
+	for _, dataset := range epochChecksumsProvider.EpochChecksumDatasets() {
+		available[dataset] = true
+	}
+	datasets := parameters.datasets
+	if len(datasets) == 0 {
+		datasets = epochChecksumsProvider.EpochChecksumDatasets()
+	}
+	for _, dataset := range datasets {
+		if !available[dataset] {
+			return nil, fmt.Errorf("unknown dataset %q", dataset)
+		}
+	}
+
+	s := &Service{
+		chainDB:                parameters.chainDB,
+		epochChecksumsProvider: epochChecksumsProvider,
+		epochChecksumsSetter:   epochChecksumsSetter,
+		datasets:               datasets,
+		activitySem:            semaphore.NewWeighted(1),
+	}
+
+	if parameters.startEpoch >= 0 {
+		// Explicit requirement to start at a given epoch.
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			md, err := s.getMetadata(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to obtain metadata")
+			}
+			md.NextEpoch = phase0.Epoch(parameters.startEpoch)
+			return s.setMetadata(ctx, md)
+		}); err != nil {
+			return nil, errors.Wrap(err, "failed to set metadata with start epoch")
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,98 @@
+// Copyright © 2020 - 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_dbstats"
+
+var tableRows *prometheus.GaugeVec
+var tableBytes *prometheus.GaugeVec
+var indexBytes *prometheus.GaugeVec
+var growthRate *prometheus.GaugeVec
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if tableRows != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	tableRows = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "table_rows",
+		Help:      "Estimated number of rows in the table",
+	}, []string{"table"})
+	if err := prometheus.Register(tableRows); err != nil {
+		return errors.Wrap(err, "failed to register table_rows")
+	}
+
+	tableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "table_bytes",
+		Help:      "Size of the table on disk, excluding indices",
+	}, []string{"table"})
+	if err := prometheus.Register(tableBytes); err != nil {
+		return errors.Wrap(err, "failed to register table_bytes")
+	}
+
+	indexBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "index_bytes",
+		Help:      "Size of the table's indices on disk",
+	}, []string{"table"})
+	if err := prometheus.Register(indexBytes); err != nil {
+		return errors.Wrap(err, "failed to register index_bytes")
+	}
+
+	growthRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "growth_bytes_per_second",
+		Help:      "Rate of growth of the table and its indices on disk since the previous collection",
+	}, []string{"table"})
+	if err := prometheus.Register(growthRate); err != nil {
+		return errors.Wrap(err, "failed to register growth_bytes_per_second")
+	}
+
+	return nil
+}
+
+func monitorTable(table string, rows uint64, tableSize uint64, indexSize uint64) {
+	if tableRows != nil {
+		tableRows.WithLabelValues(table).Set(float64(rows))
+		tableBytes.WithLabelValues(table).Set(float64(tableSize))
+		indexBytes.WithLabelValues(table).Set(float64(indexSize))
+	}
+}
+
+func monitorGrowthRate(table string, rate float64) {
+	if growthRate != nil {
+		growthRate.WithLabelValues(table).Set(rate)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright © 2020 - 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Service is a database statistics service, periodically collecting the
+// sizes of the database's tables and exporting them as metrics.
+type Service struct {
+	tableStatsProvider chaindb.TableStatsProvider
+	interval           time.Duration
+
+	// previous holds the total size of each table at the previous collection,
+	// from which growth rates are calculated.
+	previous     map[string]uint64
+	previousTime time.Time
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "dbstats").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	tableStatsProvider, isProvider := parameters.chainDB.(chaindb.TableStatsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide table statistics")
+	}
+
+	s := &Service{
+		tableStatsProvider: tableStatsProvider,
+		interval:           parameters.interval,
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically updates the database statistics.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.update(ctx, time.Now()); err != nil {
+			log.Warn().Err(err).Msg("Failed to update database statistics")
+		}
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping database statistics")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update collects the database statistics.
+func (s *Service) update(ctx context.Context, now time.Time) error {
+	stats, err := s.tableStatsProvider.TableStats(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain table statistics")
+	}
+
+	for table, rate := range s.growthRates(stats, now) {
+		monitorGrowthRate(table, rate)
+	}
+	for _, stat := range stats {
+		monitorTable(stat.Name, stat.Rows, stat.TableSize, stat.IndexSize)
+	}
+	log.Trace().Int("tables", len(stats)).Msg("Updated database statistics")
+
+	return nil
+}
+
+// growthRates calculates the growth rate in bytes per second of each table
+// since the previous collection, and records the sizes for the next.  There
+// are no rates for the first collection, or for tables that are new since the
+// previous one.
+func (s *Service) growthRates(stats []*chaindb.TableStats, now time.Time) map[string]float64 {
+	rates := make(map[string]float64)
+	elapsed := now.Sub(s.previousTime).Seconds()
+	current := make(map[string]uint64, len(stats))
+	for _, stat := range stats {
+		size := stat.TableSize + stat.IndexSize
+		current[stat.Name] = size
+		if previous, exists := s.previous[stat.Name]; exists && elapsed > 0 {
+			rates[stat.Name] = (float64(size) - float64(previous)) / elapsed
+		}
+	}
+	s.previous = current
+	s.previousTime = now
+
+	return rates
+}
@@ -0,0 +1,46 @@
+// Copyright © 2020 - 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestGrowthRates(t *testing.T) {
+	s := &Service{}
+	start := time.Unix(1600000000, 0)
+
+	// No rates from the first collection.
+	rates := s.growthRates([]*chaindb.TableStats{
+		{Name: "t_blocks", TableSize: 1000, IndexSize: 200},
+	}, start)
+	require.Empty(t, rates)
+
+	rates = s.growthRates([]*chaindb.TableStats{
+		{Name: "t_blocks", TableSize: 1500, IndexSize: 300},
+		{Name: "t_new", TableSize: 100},
+	}, start.Add(10*time.Second))
+	require.Equal(t, map[string]float64{"t_blocks": 60}, rates)
+
+	// Tables can shrink, for example after pruning.
+	rates = s.growthRates([]*chaindb.TableStats{
+		{Name: "t_blocks", TableSize: 1300, IndexSize: 300},
+		{Name: "t_new", TableSize: 100},
+	}, start.Add(20*time.Second))
+	require.Equal(t, map[string]float64{"t_blocks": -20, "t_new": 0}, rates)
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// activationsPerBatch is the number of validator activations recorded in a single transaction.
+const activationsPerBatch = 1024
+
+// recordActivations records the latencies from deposit to activation of the
+// validators activated since the latest recorded activation.  Activation
+// epochs are set a number of epochs ahead of activation, so all validators
+// activated up to the current epoch are known.
+func (s *Service) recordActivations(ctx context.Context) error {
+	startEpoch := phase0.Epoch(0)
+	latest, err := s.validatorActivationsProvider.LatestValidatorActivation(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest validator activation")
+	}
+	if latest != nil {
+		startEpoch = latest.ActivationEpoch + 1
+	}
+	currentEpoch := s.chainTime.CurrentEpoch()
+	if startEpoch > currentEpoch {
+		return nil
+	}
+
+	validators, err := s.validatorsProvider.Validators(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validators")
+	}
+	activated := make([]*chaindb.Validator, 0)
+	for _, validator := range validators {
+		if validator.ActivationEpoch >= startEpoch && validator.ActivationEpoch <= currentEpoch {
+			activated = append(activated, validator)
+		}
+	}
+	log.Trace().Uint64("start_epoch", uint64(startEpoch)).Int("validators", len(activated)).Msg("Recording validator activations")
+
+	recorded := 0
+	for start := 0; start < len(activated); start += activationsPerBatch {
+		end := start + activationsPerBatch
+		if end > len(activated) {
+			end = len(activated)
+		}
+		activations, err := s.validatorActivations(ctx, activated[start:end])
+		if err != nil {
+			return err
+		}
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.validatorActivationsSetter.SetValidatorActivations(ctx, activations)
+		}); err != nil {
+			return errors.Wrap(err, "failed to set validator activations")
+		}
+		for _, activation := range activations {
+			monitorActivation(activation)
+		}
+		recorded += len(activations)
+	}
+	if skipped := len(activated) - recorded; skipped > 0 {
+		// This is expected for validators present at genesis, and for those whose
+		// deposits were made before the first block in the database.
+		log.Debug().Int("skipped", skipped).Msg("Validators activated without known deposits; not recorded")
+	}
+
+	return nil
+}
+
+// validatorActivations creates the activations for the given validators from
+// their first deposits.  Validators without known deposits are omitted.
+func (s *Service) validatorActivations(ctx context.Context, validators []*chaindb.Validator) ([]*chaindb.ValidatorActivation, error) {
+	pubKeys := make([]phase0.BLSPubKey, len(validators))
+	for i := range validators {
+		pubKeys[i] = validators[i].PublicKey
+	}
+
+	deposits, err := s.depositsProvider.DepositsByPublicKey(ctx, pubKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain deposits")
+	}
+	eth1Deposits, err := s.eth1DepositsProvider.ETH1DepositsByPublicKey(ctx, pubKeys)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain Ethereum 1 deposits")
+	}
+	firstETH1Deposits := make(map[phase0.BLSPubKey]*chaindb.ETH1Deposit, len(eth1Deposits))
+	for _, eth1Deposit := range eth1Deposits {
+		first, exists := firstETH1Deposits[eth1Deposit.ValidatorPubKey]
+		if !exists || eth1Deposit.DepositIndex < first.DepositIndex {
+			firstETH1Deposits[eth1Deposit.ValidatorPubKey] = eth1Deposit
+		}
+	}
+
+	activations := make([]*chaindb.ValidatorActivation, 0, len(validators))
+	for _, validator := range validators {
+		eth1Deposit, exists := firstETH1Deposits[validator.PublicKey]
+		if !exists {
+			continue
+		}
+		deposit := firstDeposit(deposits[validator.PublicKey])
+		if deposit == nil {
+			continue
+		}
+
+		inclusionTime := s.chainTime.StartOfSlot(deposit.InclusionSlot)
+		eligibilityTime := s.chainTime.StartOfEpoch(validator.ActivationEligibilityEpoch)
+		activationTime := s.chainTime.StartOfEpoch(validator.ActivationEpoch)
+		activations = append(activations, &chaindb.ValidatorActivation{
+			Index:                      validator.Index,
+			DepositIndex:               eth1Deposit.DepositIndex,
+			ETH1BlockTimestamp:         eth1Deposit.ETH1BlockTimestamp,
+			InclusionSlot:              deposit.InclusionSlot,
+			ActivationEligibilityEpoch: validator.ActivationEligibilityEpoch,
+			ActivationEpoch:            validator.ActivationEpoch,
+			InclusionLatency:           inclusionTime.Sub(eth1Deposit.ETH1BlockTimestamp),
+			EligibilityLatency:         eligibilityTime.Sub(inclusionTime),
+			ActivationLatency:          activationTime.Sub(eligibilityTime),
+		})
+	}
+
+	return activations, nil
+}
+
+// firstDeposit returns the earliest of the given beacon deposits, or nil if there are none.
+func firstDeposit(deposits []*chaindb.Deposit) *chaindb.Deposit {
+	var first *chaindb.Deposit
+	for _, deposit := range deposits {
+		if first == nil ||
+			deposit.InclusionSlot < first.InclusionSlot ||
+			(deposit.InclusionSlot == first.InclusionSlot && deposit.InclusionIndex < first.InclusionIndex) {
+			first = deposit
+		}
+	}
+
+	return first
+}
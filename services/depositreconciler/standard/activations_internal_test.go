@@ -0,0 +1,148 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// testEpochChainTime is a chain time service with 12 second slots and 32 slot epochs.
+type testEpochChainTime struct {
+	testChainTime
+	currentEpoch phase0.Epoch
+}
+
+func (c *testEpochChainTime) StartOfEpoch(epoch phase0.Epoch) time.Time {
+	return c.StartOfSlot(phase0.Slot(epoch) * 32)
+}
+
+func (c *testEpochChainTime) CurrentEpoch() phase0.Epoch {
+	return c.currentEpoch
+}
+
+// testActivationsChainDB holds validators and activations in memory.
+type testActivationsChainDB struct {
+	testChainDB
+	chaindb.ValidatorsProvider
+	validators  []*chaindb.Validator
+	activations map[phase0.ValidatorIndex]*chaindb.ValidatorActivation
+}
+
+func (d *testActivationsChainDB) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	return d.validators, nil
+}
+
+func (d *testActivationsChainDB) DepositsByPublicKey(_ context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
+	deposits := make(map[phase0.BLSPubKey][]*chaindb.Deposit)
+	for _, deposit := range d.deposits {
+		for _, pubKey := range pubKeys {
+			if deposit.ValidatorPubKey == pubKey {
+				deposits[pubKey] = append(deposits[pubKey], deposit)
+			}
+		}
+	}
+	return deposits, nil
+}
+
+func (d *testActivationsChainDB) LatestValidatorActivation(_ context.Context) (*chaindb.ValidatorActivation, error) {
+	var latest *chaindb.ValidatorActivation
+	for _, activation := range d.activations {
+		if latest == nil || activation.ActivationEpoch > latest.ActivationEpoch {
+			latest = activation
+		}
+	}
+	return latest, nil
+}
+
+func (d *testActivationsChainDB) ValidatorActivationLatencies(_ context.Context, _ phase0.Epoch, _ phase0.Epoch, _ float64) (*chaindb.ValidatorActivationLatencies, error) {
+	return nil, nil
+}
+
+func (d *testActivationsChainDB) SetValidatorActivations(_ context.Context, activations []*chaindb.ValidatorActivation) error {
+	for _, activation := range activations {
+		d.activations[activation.Index] = activation
+	}
+	return nil
+}
+
+func TestRecordActivations(t *testing.T) {
+	ctx := context.Background()
+
+	genesis := time.Unix(1600000000, 0)
+	chainTime := &testEpochChainTime{testChainTime: testChainTime{genesis: genesis}, currentEpoch: 10}
+
+	chainDB := &testActivationsChainDB{
+		activations: make(map[phase0.ValidatorIndex]*chaindb.ValidatorActivation),
+	}
+	// Ethereum 1 deposits are made 8 hours before genesis.
+	for i := uint64(0); i < 4; i++ {
+		chainDB.eth1Deposits = append(chainDB.eth1Deposits, &chaindb.ETH1Deposit{
+			ETH1BlockTimestamp: genesis.Add(-8 * time.Hour),
+			DepositIndex:       i,
+			ValidatorPubKey:    phase0.BLSPubKey{byte(i)},
+		})
+	}
+	// A top-up for validator 1 is not its first deposit.
+	chainDB.eth1Deposits = append(chainDB.eth1Deposits, &chaindb.ETH1Deposit{
+		ETH1BlockTimestamp: genesis,
+		DepositIndex:       4,
+		ValidatorPubKey:    phase0.BLSPubKey{1},
+	})
+	// Validator 3 has no deposit in the beacon chain.
+	chainDB.addDeposit(32, 0, 0)
+	chainDB.addDeposit(32, 1, 1)
+	chainDB.addDeposit(64, 0, 4)
+	chainDB.addDeposit(64, 1, 2)
+	for i := 0; i < 4; i++ {
+		chainDB.validators = append(chainDB.validators, &chaindb.Validator{
+			Index:                      phase0.ValidatorIndex(i),
+			PublicKey:                  phase0.BLSPubKey{byte(i)},
+			ActivationEligibilityEpoch: 3,
+			ActivationEpoch:            phase0.Epoch(8 + i),
+			ExitEpoch:                  0xffffffffffffffff,
+		})
+	}
+
+	s := &Service{
+		chainDB:                      chainDB,
+		chainTime:                    chainTime,
+		depositsProvider:             chainDB,
+		eth1DepositsProvider:         chainDB,
+		validatorsProvider:           chainDB,
+		validatorActivationsProvider: chainDB,
+		validatorActivationsSetter:   chainDB,
+	}
+
+	// Validator 3 activates after the current epoch.
+	require.NoError(t, s.recordActivations(ctx))
+	require.Len(t, chainDB.activations, 3)
+	activation := chainDB.activations[1]
+	require.Equal(t, uint64(1), activation.DepositIndex)
+	require.Equal(t, phase0.Slot(32), activation.InclusionSlot)
+	require.Equal(t, 8*time.Hour+32*12*time.Second, activation.InclusionLatency)
+	require.Equal(t, 64*12*time.Second, activation.EligibilityLatency)
+	require.Equal(t, 6*32*12*time.Second, activation.ActivationLatency)
+
+	// Validator 3 is not recorded without a beacon deposit.
+	chainTime.currentEpoch = 20
+	require.NoError(t, s.recordActivations(ctx))
+	require.Len(t, chainDB.activations, 3)
+	require.NotContains(t, chainDB.activations, phase0.ValidatorIndex(3))
+}
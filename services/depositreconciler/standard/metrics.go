@@ -0,0 +1,130 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_depositreconciler"
+
+var linkedDeposits prometheus.Counter
+var missingDeposits prometheus.Gauge
+var mismatches prometheus.Counter
+var halted prometheus.Gauge
+var activationLatencies *prometheus.HistogramVec
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if linkedDeposits != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	linkedDeposits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "linked_deposits_total",
+		Help:      "Number of Ethereum 1 deposits linked to deposits in beacon blocks",
+	})
+	if err := prometheus.Register(linkedDeposits); err != nil {
+		return errors.Wrap(err, "failed to register linked_deposits_total")
+	}
+
+	missingDeposits = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "missing_deposits",
+		Help:      "Number of Ethereum 1 deposits not included in beacon blocks within the expected window at the latest reconciliation",
+	})
+	if err := prometheus.Register(missingDeposits); err != nil {
+		return errors.Wrap(err, "failed to register missing_deposits")
+	}
+
+	mismatches = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "mismatches_total",
+		Help:      "Number of times an Ethereum 1 deposit did not match the deposit in the beacon chain at the same position",
+	})
+	if err := prometheus.Register(mismatches); err != nil {
+		return errors.Wrap(err, "failed to register mismatches_total")
+	}
+
+	halted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "halted",
+		Help:      "1 if the service has halted due to an inconsistency in strict mode",
+	})
+	if err := prometheus.Register(halted); err != nil {
+		return errors.Wrap(err, "failed to register halted")
+	}
+
+	activationLatencies = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "activation_latency_seconds",
+		Help:      "Time taken for each stage from deposit to activation of validators",
+		// 1 hour to 1024 hours.
+		Buckets: prometheus.ExponentialBuckets(3600, 2, 11),
+	}, []string{"stage"})
+	if err := prometheus.Register(activationLatencies); err != nil {
+		return errors.Wrap(err, "failed to register activation_latency_seconds")
+	}
+
+	return nil
+}
+
+func monitorLinkedDeposits(count int) {
+	if linkedDeposits != nil {
+		linkedDeposits.Add(float64(count))
+	}
+}
+
+func monitorMissingDeposits(count int) {
+	if missingDeposits != nil {
+		missingDeposits.Set(float64(count))
+	}
+}
+
+func monitorMismatch() {
+	if mismatches != nil {
+		mismatches.Inc()
+	}
+}
+
+func monitorHalted() {
+	if halted != nil {
+		halted.Set(1)
+	}
+}
+
+func monitorActivation(activation *chaindb.ValidatorActivation) {
+	if activationLatencies != nil {
+		activationLatencies.WithLabelValues("inclusion").Observe(activation.InclusionLatency.Seconds())
+		activationLatencies.WithLabelValues("eligibility").Observe(activation.EligibilityLatency.Seconds())
+		activationLatencies.WithLabelValues("activation").Observe(activation.ActivationLatency.Seconds())
+		activationLatencies.WithLabelValues("total").Observe((activation.InclusionLatency + activation.EligibilityLatency + activation.ActivationLatency).Seconds())
+	}
+}
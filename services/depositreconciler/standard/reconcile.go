@@ -0,0 +1,307 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// slotsPerBatch is the number of slots of beacon deposits linked in a single transaction.
+const slotsPerBatch = 1024
+
+// depositsPerBatch is the number of Ethereum 1 deposits checked for absence in a single transaction.
+const depositsPerBatch = 1024
+
+// anchorDeposits is the number of consecutive deposits that must match for
+// the first beacon deposit in the database to be anchored to an Ethereum 1
+// deposit.
+const anchorDeposits = 8
+
+// position is the position of a deposit in both chains.
+type position struct {
+	depositIndex   uint64
+	inclusionSlot  phase0.Slot
+	inclusionIndex uint64
+}
+
+// reconcile links Ethereum 1 deposits to the beacon deposits that included
+// them, and flags those that have not been included within the window.
+func (s *Service) reconcile(ctx context.Context) error {
+	latestSlot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest canonical block")
+	}
+
+	next, err := s.nextPosition(ctx, latestSlot)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		log.Debug().Msg("No starting position for reconciliation; not reconciling")
+		return nil
+	}
+	log.Trace().Uint64("deposit_index", next.depositIndex).Uint64("slot", uint64(next.inclusionSlot)).Msg("Reconciling deposits")
+
+	complete := true
+	for slot := next.inclusionSlot; slot <= latestSlot; slot += slotsPerBatch {
+		end := slot + slotsPerBatch
+		if end > latestSlot+1 {
+			end = latestSlot + 1
+		}
+		deposits, err := s.depositsProvider.DepositsForSlotRange(ctx, slot, end)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain beacon deposits")
+		}
+		// Skip deposits in the starting slot that have already been linked.
+		for len(deposits) > 0 && deposits[0].InclusionSlot == next.inclusionSlot && deposits[0].InclusionIndex < next.inclusionIndex {
+			deposits = deposits[1:]
+		}
+		if len(deposits) == 0 {
+			continue
+		}
+
+		linked, err := s.linkDeposits(ctx, next.depositIndex, deposits)
+		if err != nil {
+			return err
+		}
+		next.depositIndex += uint64(linked)
+		if linked < len(deposits) {
+			complete = false
+			break
+		}
+	}
+
+	if !complete {
+		// Unlinked beacon deposits remain, so cannot tell which Ethereum 1
+		// deposits are missing.
+		return nil
+	}
+
+	return s.flagMissing(ctx, next.depositIndex, latestSlot)
+}
+
+// nextPosition returns the position from which to continue reconciliation,
+// or nil if there is no position yet.
+func (s *Service) nextPosition(ctx context.Context, latestSlot phase0.Slot) (*position, error) {
+	latest, err := s.eth1DepositInclusionsProvider.LatestETH1DepositInclusion(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain latest deposit inclusion")
+	}
+	if latest != nil && latest.InclusionSlot != nil && latest.InclusionIndex != nil {
+		return &position{
+			depositIndex:   latest.DepositIndex + 1,
+			inclusionSlot:  *latest.InclusionSlot,
+			inclusionIndex: *latest.InclusionIndex + 1,
+		}, nil
+	}
+
+	return s.anchor(ctx, latestSlot)
+}
+
+// anchor finds the Ethereum 1 deposit that corresponds to the earliest beacon
+// deposit in the database.  Beacon deposits are ordered by deposit index, so
+// everything from here on can be linked sequentially; Ethereum 1 deposits
+// before the anchor are not reconciled.
+func (s *Service) anchor(ctx context.Context, latestSlot phase0.Slot) (*position, error) {
+	blocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+		Limit: 1,
+		Order: chaindb.OrderEarliest,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain earliest block")
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	var deposits []*chaindb.Deposit
+	for slot := blocks[0].Slot; slot <= latestSlot && len(deposits) == 0; slot += slotsPerBatch {
+		end := slot + slotsPerBatch
+		if end > latestSlot+1 {
+			end = latestSlot + 1
+		}
+		deposits, err = s.depositsProvider.DepositsForSlotRange(ctx, slot, end)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain beacon deposits")
+		}
+	}
+	if len(deposits) == 0 {
+		return nil, nil
+	}
+	if len(deposits) > anchorDeposits {
+		deposits = deposits[:anchorDeposits]
+	}
+
+	candidates, err := s.eth1DepositsProvider.ETH1DepositsByPublicKey(ctx, []phase0.BLSPubKey{deposits[0].ValidatorPubKey})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain candidate Ethereum 1 deposits")
+	}
+	for _, candidate := range candidates {
+		if !depositsMatch(candidate, deposits[0]) {
+			continue
+		}
+		eth1Deposits, err := s.eth1DepositsProvider.ETH1DepositsByIndexRange(ctx, candidate.DepositIndex, candidate.DepositIndex+uint64(len(deposits)))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain Ethereum 1 deposits")
+		}
+		if len(eth1Deposits) != len(deposits) {
+			continue
+		}
+		matched := true
+		for i := range deposits {
+			if !depositsMatch(eth1Deposits[i], deposits[i]) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			log.Info().Uint64("deposit_index", candidate.DepositIndex).Uint64("slot", uint64(deposits[0].InclusionSlot)).Msg("Anchored deposit reconciliation")
+			return &position{
+				depositIndex:   candidate.DepositIndex,
+				inclusionSlot:  deposits[0].InclusionSlot,
+				inclusionIndex: deposits[0].InclusionIndex,
+			}, nil
+		}
+	}
+
+	log.Debug().Msg("No Ethereum 1 deposits match the earliest beacon deposits")
+	return nil, nil
+}
+
+// linkDeposits links the beacon deposits to sequential Ethereum 1 deposits
+// starting at the given index, returning the number linked.  Linking stops
+// at the first Ethereum 1 deposit that is not yet known or does not match; in
+// strict mode a mismatch instead halts the service, linking nothing.
+func (s *Service) linkDeposits(ctx context.Context, startIndex uint64, deposits []*chaindb.Deposit) (int, error) {
+	eth1Deposits, err := s.eth1DepositsProvider.ETH1DepositsByIndexRange(ctx, startIndex, startIndex+uint64(len(deposits)))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain Ethereum 1 deposits")
+	}
+
+	linked := 0
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		linked = 0
+		for i, deposit := range deposits {
+			depositIndex := startIndex + uint64(i)
+			if i >= len(eth1Deposits) || eth1Deposits[i].DepositIndex != depositIndex {
+				log.Debug().Uint64("deposit_index", depositIndex).Msg("Ethereum 1 deposit not yet known; stopping")
+				break
+			}
+			if !depositsMatch(eth1Deposits[i], deposit) && s.halt.Inconsistent() {
+				log.Error().
+					Uint64("deposit_index", depositIndex).
+					Uint64("slot", uint64(deposit.InclusionSlot)).
+					Uint64("inclusion_index", deposit.InclusionIndex).
+					Msg("Ethereum 1 deposit does not match beacon deposit; halting")
+				monitorMismatch()
+				monitorHalted()
+				return util.ErrHalted
+			}
+			if !depositsMatch(eth1Deposits[i], deposit) {
+				log.Warn().
+					Uint64("deposit_index", depositIndex).
+					Uint64("slot", uint64(deposit.InclusionSlot)).
+					Uint64("inclusion_index", deposit.InclusionIndex).
+					Msg("Ethereum 1 deposit does not match beacon deposit; stopping")
+				monitorMismatch()
+				break
+			}
+			inclusionSlot := deposit.InclusionSlot
+			inclusionBlockRoot := deposit.InclusionBlockRoot
+			inclusionIndex := deposit.InclusionIndex
+			if err := s.eth1DepositInclusionsSetter.SetETH1DepositInclusion(ctx, &chaindb.ETH1DepositInclusion{
+				DepositIndex:       depositIndex,
+				InclusionSlot:      &inclusionSlot,
+				InclusionBlockRoot: &inclusionBlockRoot,
+				InclusionIndex:     &inclusionIndex,
+			}); err != nil {
+				return errors.Wrap(err, "failed to set deposit inclusion")
+			}
+			linked++
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	monitorLinkedDeposits(linked)
+
+	return linked, nil
+}
+
+// flagMissing flags the Ethereum 1 deposits from the given index that were
+// made more than the window before the latest slot as missing from the
+// beacon chain.
+func (s *Service) flagMissing(ctx context.Context, startIndex uint64, latestSlot phase0.Slot) error {
+	cutoff := s.chainTime.StartOfSlot(latestSlot).Add(-s.window)
+
+	missing := 0
+	for index := startIndex; ; index += depositsPerBatch {
+		eth1Deposits, err := s.eth1DepositsProvider.ETH1DepositsByIndexRange(ctx, index, index+depositsPerBatch)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain Ethereum 1 deposits")
+		}
+		if len(eth1Deposits) == 0 {
+			break
+		}
+
+		done := false
+		batchMissing := 0
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			done = false
+			batchMissing = 0
+			for _, eth1Deposit := range eth1Deposits {
+				if !eth1Deposit.ETH1BlockTimestamp.Before(cutoff) {
+					// Deposits are in time order, so all later deposits are within the window.
+					done = true
+					break
+				}
+				if err := s.eth1DepositInclusionsSetter.SetETH1DepositInclusion(ctx, &chaindb.ETH1DepositInclusion{
+					DepositIndex: eth1Deposit.DepositIndex,
+					Missing:      true,
+				}); err != nil {
+					return errors.Wrap(err, "failed to set deposit inclusion")
+				}
+				batchMissing++
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		missing += batchMissing
+		if done || len(eth1Deposits) < depositsPerBatch {
+			break
+		}
+	}
+
+	if missing > 0 {
+		log.Warn().Uint64("deposit_index", startIndex).Int("missing", missing).Msg("Ethereum 1 deposits not included in the beacon chain")
+	}
+	monitorMissingDeposits(missing)
+
+	return nil
+}
+
+// depositsMatch returns true if the Ethereum 1 deposit has the same content
+// as the beacon deposit.
+func depositsMatch(eth1Deposit *chaindb.ETH1Deposit, deposit *chaindb.Deposit) bool {
+	return eth1Deposit.ValidatorPubKey == deposit.ValidatorPubKey &&
+		bytes.Equal(eth1Deposit.WithdrawalCredentials, deposit.WithdrawalCredentials) &&
+		eth1Deposit.Amount == deposit.Amount
+}
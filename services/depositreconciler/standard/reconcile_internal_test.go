@@ -0,0 +1,263 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// testChainTime is a chain time service with 12 second slots.
+type testChainTime struct {
+	chaintime.Service
+	genesis time.Time
+}
+
+func (c *testChainTime) StartOfSlot(slot phase0.Slot) time.Time {
+	return c.genesis.Add(time.Duration(slot) * 12 * time.Second)
+}
+
+// testChainDB holds blocks, deposits and inclusions in memory.
+type testChainDB struct {
+	chaindb.BlocksProvider
+	latestSlot   phase0.Slot
+	earliestSlot phase0.Slot
+	deposits     []*chaindb.Deposit
+	eth1Deposits []*chaindb.ETH1Deposit
+	inclusions   map[uint64]*chaindb.ETH1DepositInclusion
+}
+
+func (d *testChainDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (d *testChainDB) CommitTx(_ context.Context) error {
+	return nil
+}
+
+func (d *testChainDB) SetMetadata(_ context.Context, _ string, _ []byte) error {
+	return nil
+}
+
+func (d *testChainDB) Metadata(_ context.Context, _ string) ([]byte, error) {
+	return nil, nil
+}
+
+func (d *testChainDB) LatestCanonicalBlock(_ context.Context) (phase0.Slot, error) {
+	return d.latestSlot, nil
+}
+
+func (d *testChainDB) Blocks(_ context.Context, _ *chaindb.BlockFilter) ([]*chaindb.Block, error) {
+	return []*chaindb.Block{{Slot: d.earliestSlot}}, nil
+}
+
+func (d *testChainDB) DepositsByPublicKey(_ context.Context, _ []phase0.BLSPubKey) (map[phase0.BLSPubKey][]*chaindb.Deposit, error) {
+	return nil, nil
+}
+
+func (d *testChainDB) DepositsForSlotRange(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Deposit, error) {
+	deposits := make([]*chaindb.Deposit, 0)
+	for _, deposit := range d.deposits {
+		if deposit.InclusionSlot >= minSlot && deposit.InclusionSlot < maxSlot {
+			deposits = append(deposits, deposit)
+		}
+	}
+	return deposits, nil
+}
+
+func (d *testChainDB) ETH1DepositsByPublicKey(_ context.Context, pubKeys []phase0.BLSPubKey) ([]*chaindb.ETH1Deposit, error) {
+	deposits := make([]*chaindb.ETH1Deposit, 0)
+	for _, deposit := range d.eth1Deposits {
+		for _, pubKey := range pubKeys {
+			if deposit.ValidatorPubKey == pubKey {
+				deposits = append(deposits, deposit)
+			}
+		}
+	}
+	return deposits, nil
+}
+
+func (d *testChainDB) ETH1DepositsByIndexRange(_ context.Context, startIndex uint64, endIndex uint64) ([]*chaindb.ETH1Deposit, error) {
+	deposits := make([]*chaindb.ETH1Deposit, 0)
+	for _, deposit := range d.eth1Deposits {
+		if deposit.DepositIndex >= startIndex && deposit.DepositIndex < endIndex {
+			deposits = append(deposits, deposit)
+		}
+	}
+	return deposits, nil
+}
+
+func (d *testChainDB) LatestETH1DepositInclusion(_ context.Context) (*chaindb.ETH1DepositInclusion, error) {
+	var latest *chaindb.ETH1DepositInclusion
+	for _, inclusion := range d.inclusions {
+		if inclusion.InclusionSlot != nil && (latest == nil || inclusion.DepositIndex > latest.DepositIndex) {
+			latest = inclusion
+		}
+	}
+	return latest, nil
+}
+
+func (d *testChainDB) ETH1DepositInclusionsByIndexRange(_ context.Context, startIndex uint64, endIndex uint64) ([]*chaindb.ETH1DepositInclusion, error) {
+	inclusions := make([]*chaindb.ETH1DepositInclusion, 0)
+	for _, inclusion := range d.inclusions {
+		if inclusion.DepositIndex >= startIndex && inclusion.DepositIndex < endIndex {
+			inclusions = append(inclusions, inclusion)
+		}
+	}
+	sort.Slice(inclusions, func(i int, j int) bool {
+		return inclusions[i].DepositIndex < inclusions[j].DepositIndex
+	})
+	return inclusions, nil
+}
+
+func (d *testChainDB) SetETH1DepositInclusion(_ context.Context, inclusion *chaindb.ETH1DepositInclusion) error {
+	d.inclusions[inclusion.DepositIndex] = inclusion
+	return nil
+}
+
+func (d *testChainDB) addDeposit(slot phase0.Slot, inclusionIndex uint64, depositIndex uint64) {
+	eth1Deposit := d.eth1Deposits[depositIndex]
+	d.deposits = append(d.deposits, &chaindb.Deposit{
+		InclusionSlot:         slot,
+		InclusionIndex:        inclusionIndex,
+		ValidatorPubKey:       eth1Deposit.ValidatorPubKey,
+		WithdrawalCredentials: eth1Deposit.WithdrawalCredentials,
+		Amount:                eth1Deposit.Amount,
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	ctx := context.Background()
+
+	genesis := time.Unix(1600000000, 0)
+	chainTime := &testChainTime{genesis: genesis}
+
+	chainDB := &testChainDB{
+		earliestSlot: 100,
+		latestSlot:   400,
+		inclusions:   make(map[uint64]*chaindb.ETH1DepositInclusion),
+	}
+	// Ethereum 1 deposits are made every 10 minutes, starting 1 hour before genesis.
+	for i := uint64(0); i < 10; i++ {
+		chainDB.eth1Deposits = append(chainDB.eth1Deposits, &chaindb.ETH1Deposit{
+			ETH1BlockTimestamp:    genesis.Add(-time.Hour).Add(time.Duration(i) * 10 * time.Minute),
+			DepositIndex:          i,
+			ValidatorPubKey:       phase0.BLSPubKey{byte(i)},
+			WithdrawalCredentials: []byte{0x00, byte(i)},
+			Amount:                32000000000,
+		})
+	}
+	// Deposits 0 and 1 precede the earliest block in the database.
+	chainDB.addDeposit(100, 0, 2)
+	chainDB.addDeposit(100, 1, 3)
+	chainDB.addDeposit(200, 0, 4)
+	chainDB.addDeposit(200, 1, 5)
+
+	s := &Service{
+		chainDB:                       chainDB,
+		chainTime:                     chainTime,
+		blocksProvider:                chainDB,
+		depositsProvider:              chainDB,
+		eth1DepositsProvider:          chainDB,
+		eth1DepositInclusionsProvider: chainDB,
+		eth1DepositInclusionsSetter:   chainDB,
+		window:                        time.Hour,
+	}
+
+	// Slot 400 starts at genesis+80m, so deposits 6 and 7 are outside the window.
+	require.NoError(t, s.reconcile(ctx))
+	inclusions, err := chainDB.ETH1DepositInclusionsByIndexRange(ctx, 0, 10)
+	require.NoError(t, err)
+	require.Len(t, inclusions, 6)
+	for i, inclusion := range inclusions[:4] {
+		require.Equal(t, uint64(i+2), inclusion.DepositIndex)
+		require.False(t, inclusion.Missing)
+		require.NotNil(t, inclusion.InclusionSlot)
+	}
+	require.Equal(t, phase0.Slot(200), *inclusions[3].InclusionSlot)
+	require.Equal(t, uint64(1), *inclusions[3].InclusionIndex)
+	for i, inclusion := range inclusions[4:] {
+		require.Equal(t, uint64(i+6), inclusion.DepositIndex)
+		require.True(t, inclusion.Missing)
+		require.Nil(t, inclusion.InclusionSlot)
+	}
+
+	// Deposit 6 turns up late.
+	chainDB.addDeposit(450, 0, 6)
+	chainDB.latestSlot = 450
+	require.NoError(t, s.reconcile(ctx))
+	require.False(t, chainDB.inclusions[6].Missing)
+	require.Equal(t, phase0.Slot(450), *chainDB.inclusions[6].InclusionSlot)
+	require.True(t, chainDB.inclusions[7].Missing)
+	require.True(t, chainDB.inclusions[8].Missing)
+	require.Nil(t, chainDB.inclusions[9])
+
+	// A beacon deposit that does not match the next Ethereum 1 deposit stops reconciliation.
+	chainDB.deposits = append(chainDB.deposits, &chaindb.Deposit{
+		InclusionSlot:         500,
+		ValidatorPubKey:       chainDB.eth1Deposits[7].ValidatorPubKey,
+		WithdrawalCredentials: chainDB.eth1Deposits[7].WithdrawalCredentials,
+		Amount:                1000000000,
+	})
+	chainDB.latestSlot = 1000
+	require.NoError(t, s.reconcile(ctx))
+	require.True(t, chainDB.inclusions[7].Missing)
+	require.Nil(t, chainDB.inclusions[7].InclusionSlot)
+	require.Nil(t, chainDB.inclusions[9])
+
+	// In strict mode the mismatch halts reconciliation.
+	s.halt = util.NewHalt(true)
+	require.ErrorIs(t, s.reconcile(ctx), util.ErrHalted)
+	require.True(t, s.halt.Halted())
+	require.Nil(t, chainDB.inclusions[7].InclusionSlot)
+}
+
+func TestReconcileNoAnchor(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := &testChainDB{
+		earliestSlot: 100,
+		latestSlot:   300,
+		inclusions:   make(map[uint64]*chaindb.ETH1DepositInclusion),
+	}
+	// A beacon deposit with no corresponding Ethereum 1 deposit.
+	chainDB.deposits = append(chainDB.deposits, &chaindb.Deposit{
+		InclusionSlot:         150,
+		ValidatorPubKey:       phase0.BLSPubKey{0x01},
+		WithdrawalCredentials: []byte{0x00, 0x01},
+		Amount:                32000000000,
+	})
+
+	s := &Service{
+		chainDB:                       chainDB,
+		chainTime:                     &testChainTime{genesis: time.Unix(1600000000, 0)},
+		blocksProvider:                chainDB,
+		depositsProvider:              chainDB,
+		eth1DepositsProvider:          chainDB,
+		eth1DepositInclusionsProvider: chainDB,
+		eth1DepositInclusionsSetter:   chainDB,
+		window:                        time.Hour,
+	}
+
+	require.NoError(t, s.reconcile(ctx))
+	require.Empty(t, chainDB.inclusions)
+}
@@ -0,0 +1,151 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a deposit reconciliation service, periodically matching the
+// deposits made on the Ethereum 1 chain with the deposits included in beacon
+// blocks, and recording the time taken from deposit to activation of validators.
+type Service struct {
+	chainDB                       chaindb.Service
+	chainTime                     chaintime.Service
+	blocksProvider                chaindb.BlocksProvider
+	depositsProvider              chaindb.DepositsProvider
+	eth1DepositsProvider          chaindb.ETH1DepositsProvider
+	eth1DepositInclusionsProvider chaindb.ETH1DepositInclusionsProvider
+	eth1DepositInclusionsSetter   chaindb.ETH1DepositInclusionsSetter
+	validatorsProvider            chaindb.ValidatorsProvider
+	validatorActivationsProvider  chaindb.ValidatorActivationsProvider
+	validatorActivationsSetter    chaindb.ValidatorActivationsSetter
+	interval                      time.Duration
+	window                        time.Duration
+	halt                          *util.Halt
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "depositreconciler").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	depositsProvider, isProvider := parameters.chainDB.(chaindb.DepositsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide deposits")
+	}
+
+	eth1DepositsProvider, isProvider := parameters.chainDB.(chaindb.ETH1DepositsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide Ethereum 1 deposits")
+	}
+
+	eth1DepositInclusionsProvider, isProvider := parameters.chainDB.(chaindb.ETH1DepositInclusionsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide Ethereum 1 deposit inclusions")
+	}
+
+	eth1DepositInclusionsSetter, isSetter := parameters.chainDB.(chaindb.ETH1DepositInclusionsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support Ethereum 1 deposit inclusion setting")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+
+	validatorActivationsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorActivationsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validator activations")
+	}
+
+	validatorActivationsSetter, isSetter := parameters.chainDB.(chaindb.ValidatorActivationsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support validator activation setting")
+	}
+
+	s := &Service{
+		chainDB:                       parameters.chainDB,
+		chainTime:                     parameters.chainTime,
+		blocksProvider:                blocksProvider,
+		depositsProvider:              depositsProvider,
+		eth1DepositsProvider:          eth1DepositsProvider,
+		eth1DepositInclusionsProvider: eth1DepositInclusionsProvider,
+		eth1DepositInclusionsSetter:   eth1DepositInclusionsSetter,
+		validatorsProvider:            validatorsProvider,
+		validatorActivationsProvider:  validatorActivationsProvider,
+		validatorActivationsSetter:    validatorActivationsSetter,
+		interval:                      parameters.interval,
+		window:                        parameters.window,
+		halt:                          util.NewHalt(parameters.strict),
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically reconciles deposits and records validator activations.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		err := s.reconcile(ctx)
+		if errors.Is(err, util.ErrHalted) {
+			log.Trace().Msg("Halted; stopping deposit reconciliation")
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to reconcile deposits")
+		}
+		if err := s.recordActivations(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to record validator activations")
+		}
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping deposit reconciliation")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type depositRootResponse struct {
+	Result string `json:"result"`
+}
+
+// depositRoot fetches the root of the deposit contract's deposit tree at the
+// given block.
+func (s *Service) depositRoot(ctx context.Context, block uint64) ([32]byte, error) {
+	reference, err := url.Parse("")
+	if err != nil {
+		return [32]byte{}, errors.Wrap(err, "invalid endpoint")
+	}
+	url := s.base.ResolveReference(reference).String()
+
+	// 0xc5f2892f is the selector for get_deposit_root().
+	reqBody := bytes.NewBuffer([]byte(fmt.Sprintf(`{"jsonrpc":"2.0","method":"eth_call","params":[{"to":"%#x","data":"0xc5f2892f"},"%#x"],"id":1901}`, s.depositContractAddress, block)))
+	respBodyReader, err := s.post(ctx, url, reqBody)
+	if err != nil {
+		log.Trace().Str("url", url).Err(err).Msg("Request failed")
+		return [32]byte{}, errors.Wrap(err, "request failed")
+	}
+	if respBodyReader == nil {
+		return [32]byte{}, errors.New("empty response")
+	}
+
+	var response depositRootResponse
+	if err := json.NewDecoder(respBodyReader).Decode(&response); err != nil {
+		return [32]byte{}, errors.Wrap(err, "invalid response")
+	}
+
+	data, err := hex.DecodeString(strings.TrimPrefix(response.Result, "0x"))
+	if err != nil {
+		return [32]byte{}, errors.Wrap(err, "invalid deposit root")
+	}
+	if len(data) != 32 {
+		return [32]byte{}, fmt.Errorf("incorrect length %d for deposit root", len(data))
+	}
+	var root [32]byte
+	copy(root[:], data)
+
+	return root, nil
+}
@@ -0,0 +1,134 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// depositContractTreeDepth is the depth of the deposit contract's Merkle tree.
+const depositContractTreeDepth = 32
+
+// zeroHashes are the roots of empty subtrees at each height of the tree.
+var zeroHashes [depositContractTreeDepth][32]byte
+
+func init() {
+	for i := 1; i < depositContractTreeDepth; i++ {
+		zeroHashes[i] = hashPair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+// depositTree is an incremental Merkle tree of deposits, built in the same way
+// as the tree in the deposit contract.  It holds only the branch required to
+// add deposits and calculate the root, so is small enough to store in metadata.
+type depositTree struct {
+	Count  uint64   `json:"count"`
+	Branch [][]byte `json:"branch"`
+}
+
+// newDepositTree creates an empty deposit tree.
+func newDepositTree() *depositTree {
+	branch := make([][]byte, depositContractTreeDepth)
+	for i := range branch {
+		branch[i] = make([]byte, 32)
+	}
+	return &depositTree{
+		Branch: branch,
+	}
+}
+
+// copy returns a copy of the deposit tree.
+func (t *depositTree) copy() *depositTree {
+	if t == nil {
+		return nil
+	}
+	branch := make([][]byte, len(t.Branch))
+	for i := range t.Branch {
+		branch[i] = make([]byte, len(t.Branch[i]))
+		copy(branch[i], t.Branch[i])
+	}
+	return &depositTree{
+		Count:  t.Count,
+		Branch: branch,
+	}
+}
+
+// insert adds the deposit with the given data root to the tree.
+func (t *depositTree) insert(leaf [32]byte) error {
+	if len(t.Branch) != depositContractTreeDepth {
+		return errors.New("invalid deposit tree branch")
+	}
+	t.Count++
+	size := t.Count
+	node := leaf
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if size&1 == 1 {
+			t.Branch[height] = node[:]
+			return nil
+		}
+		var sibling [32]byte
+		copy(sibling[:], t.Branch[height])
+		node = hashPair(sibling, node)
+		size /= 2
+	}
+
+	return errors.New("deposit tree full")
+}
+
+// root calculates the root of the tree, as provided by the deposit contract.
+func (t *depositTree) root() ([32]byte, error) {
+	if len(t.Branch) != depositContractTreeDepth {
+		return [32]byte{}, errors.New("invalid deposit tree branch")
+	}
+	var node [32]byte
+	size := t.Count
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if size&1 == 1 {
+			var sibling [32]byte
+			copy(sibling[:], t.Branch[height])
+			node = hashPair(sibling, node)
+		} else {
+			node = hashPair(node, zeroHashes[height])
+		}
+		size /= 2
+	}
+	// Mix in the number of deposits.
+	var count [32]byte
+	binary.LittleEndian.PutUint64(count[:8], t.Count)
+
+	return hashPair(node, count), nil
+}
+
+// depositDataRoot calculates the root of the deposit data for a deposit, which
+// is the leaf for the deposit in the deposit tree.
+func depositDataRoot(deposit *chaindb.ETH1Deposit) ([32]byte, error) {
+	depositData := &phase0.DepositData{
+		PublicKey:             deposit.ValidatorPubKey,
+		WithdrawalCredentials: deposit.WithdrawalCredentials,
+		Amount:                deposit.Amount,
+		Signature:             deposit.Signature,
+	}
+
+	return depositData.HashTreeRoot()
+}
+
+// hashPair hashes two nodes of the tree.
+func hashPair(left [32]byte, right [32]byte) [32]byte {
+	return sha256.Sum256(append(left[:], right[:]...))
+}
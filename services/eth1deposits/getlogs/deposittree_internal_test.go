@@ -0,0 +1,92 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package getlogs
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// naiveDepositTreeRoot calculates the root of a deposit tree from all of its leaves.
+func naiveDepositTreeRoot(leaves [][32]byte) [32]byte {
+	layer := leaves
+	for height := 0; height < depositContractTreeDepth; height++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[height])
+		}
+		next := make([][32]byte, 0, len(layer)/2)
+		for i := 0; i < len(layer); i += 2 {
+			next = append(next, hashPair(layer[i], layer[i+1]))
+		}
+		if len(next) == 0 {
+			next = append(next, hashPair(zeroHashes[height], zeroHashes[height]))
+		}
+		layer = next
+	}
+	var count [32]byte
+	binary.LittleEndian.PutUint64(count[:8], uint64(len(leaves)))
+
+	return hashPair(layer[0], count)
+}
+
+func TestDepositTreeEmpty(t *testing.T) {
+	root, err := newDepositTree().root()
+	require.NoError(t, err)
+	// Root of the deposit contract before any deposits.
+	require.Equal(t, "0xd70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e", fmt.Sprintf("%#x", root))
+}
+
+func TestDepositTree(t *testing.T) {
+	tree := newDepositTree()
+	leaves := make([][32]byte, 0)
+	for i := 0; i < 37; i++ {
+		leaf := [32]byte{byte(i + 1)}
+		require.NoError(t, tree.insert(leaf))
+		leaves = append(leaves, leaf)
+
+		root, err := tree.root()
+		require.NoError(t, err)
+		require.Equal(t, naiveDepositTreeRoot(leaves), root, "deposit %d", i)
+	}
+	require.Equal(t, uint64(37), tree.Count)
+
+	// Ensure the tree survives a round trip through metadata.
+	data, err := json.Marshal(tree)
+	require.NoError(t, err)
+	tree2 := &depositTree{}
+	require.NoError(t, json.Unmarshal(data, tree2))
+	root, err := tree.root()
+	require.NoError(t, err)
+	root2, err := tree2.root()
+	require.NoError(t, err)
+	require.Equal(t, root, root2)
+
+	// Ensure copies are independent.
+	tree3 := tree.copy()
+	require.NoError(t, tree3.insert([32]byte{0xff}))
+	root3, err := tree.root()
+	require.NoError(t, err)
+	require.Equal(t, root, root3)
+}
+
+func TestDepositTreeInvalid(t *testing.T) {
+	tree := &depositTree{}
+	require.EqualError(t, tree.insert([32]byte{}), "invalid deposit tree branch")
+	_, err := tree.root()
+	require.EqualError(t, err, "invalid deposit tree branch")
+}
@@ -16,62 +16,148 @@ package getlogs
 import (
 	"context"
 	"encoding/binary"
+	"fmt"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // handleBlocks handles a range of blocks.
-func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock uint64) error {
-	logs, err := s.getLogs(ctx, startBlock, endBlock)
+// If a deposit tree is supplied the deposits are verified against it, and the
+// updated tree is returned.
+func (s *Service) handleBlocks(ctx context.Context, startBlock uint64, endBlock uint64, tree *depositTree) (*depositTree, error) {
+	allLogs, err := s.getLogs(ctx, startBlock, endBlock)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain logs")
+		return nil, errors.Wrap(err, "failed to obtain logs")
 	}
-
-	ctx, cancel, err := s.eth1DepositsSetter.(chaindb.Service).BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction")
+	logs := make([]*logResponse, 0, len(allLogs))
+	for _, logEntry := range allLogs {
+		if len(logEntry.Data) > 0 {
+			logs = append(logs, logEntry)
+		}
 	}
 
-	for _, logEntry := range logs {
-		if len(logEntry.Data) == 0 {
-			continue
+	var updatedTree *depositTree
+	if err := util.RunTx(ctx, s.eth1DepositsSetter.(chaindb.Service), func(ctx context.Context) error {
+		// The tree is updated as deposits are added, so each attempt starts from a copy.
+		attemptTree := tree.copy()
+
+		// Deposits are verified once all deposits in their block are known, so
+		// they are held until the end of the block.
+		blockDeposits := make([]*chaindb.ETH1Deposit, 0)
+		for i, logEntry := range logs {
+			tx, err := s.transactionByHash(ctx, logEntry.TransactionHash)
+			if err != nil {
+				return errors.Wrap(err, "failed to obtain transaction from transaction hash")
+			}
+			receipt, err := s.transactionReceiptByHash(ctx, logEntry.TransactionHash)
+			if err != nil {
+				return errors.Wrap(err, "failed to obtain transaction receipt from transaction hash")
+			}
+
+			deposit, err := s.depositFromLogEntry(ctx, logEntry, tx, receipt)
+			if err != nil {
+				return errors.Wrap(err, "failed to obtain ETH1 deposit from log entry")
+			}
+
+			attemptTree, err = s.addToDepositTree(attemptTree, deposit)
+			if err != nil {
+				return errors.Wrap(err, "failed to add deposit to deposit tree")
+			}
+			blockDeposits = append(blockDeposits, deposit)
+
+			if i < len(logs)-1 && logs[i+1].BlockNumber == logEntry.BlockNumber {
+				// More deposits to come in this block.
+				continue
+			}
+
+			if attemptTree != nil {
+				if err := s.verifyDeposits(ctx, attemptTree, logEntry.BlockNumber, blockDeposits); err != nil {
+					return errors.Wrap(err, "failed to verify deposits")
+				}
+			}
+			for _, blockDeposit := range blockDeposits {
+				if err := s.eth1DepositsSetter.SetETH1Deposit(ctx, blockDeposit); err != nil {
+					return errors.Wrap(err, "failed to set ETH1 deposit")
+				}
+				log.Trace().Uint64("deposit_index", blockDeposit.DepositIndex).Msg("Processed deposit")
+			}
+			blockDeposits = make([]*chaindb.ETH1Deposit, 0)
 		}
 
-		tx, err := s.transactionByHash(ctx, logEntry.TransactionHash)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to obtain transaction from transaction hash")
-		}
-		receipt, err := s.transactionReceiptByHash(ctx, logEntry.TransactionHash)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to obtain transaction receipt from transaction hash")
-		}
+		updatedTree = attemptTree
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	for block := startBlock; block < endBlock; block++ {
+		monitorBlockProcessed(block)
+	}
 
-		deposit, err := s.depositFromLogEntry(ctx, logEntry, tx, receipt)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to obtain ETH1 deposit from log entry")
-		}
+	return updatedTree, nil
+}
 
-		if err := s.eth1DepositsSetter.SetETH1Deposit(ctx, deposit); err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to set ETH1 deposit")
-		}
-		log.Trace().Uint64("deposit_index", deposit.DepositIndex).Msg("Processed deposit")
+// addToDepositTree adds a deposit to the deposit tree.
+// If the deposit does not follow on from the deposits already in the tree then
+// the tree can no longer be used to verify deposits, and nil is returned.
+func (s *Service) addToDepositTree(tree *depositTree, deposit *chaindb.ETH1Deposit) (*depositTree, error) {
+	if tree == nil {
+		return nil, nil
 	}
-
-	if err := s.eth1DepositsSetter.(chaindb.Service).CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to commit transaction")
+	if deposit.DepositIndex != tree.Count {
+		log.Warn().Uint64("deposit_index", deposit.DepositIndex).Uint64("expected_deposit_index", tree.Count).Msg("Deposit out of sequence; deposits will not be verified")
+		return nil, nil
+	}
+	leaf, err := depositDataRoot(deposit)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate deposit data root")
+	}
+	if err := tree.insert(leaf); err != nil {
+		return nil, err
 	}
 
-	for block := startBlock; block < endBlock; block++ {
-		monitorBlockProcessed(block)
+	return tree, nil
+}
+
+// verifyDeposits verifies the deposits for a block, by checking that the root
+// of the deposit tree including the block's deposits matches the deposit
+// contract's root at the block.  This is equivalent to verifying the Merkle
+// proof of each deposit against the contract's root.
+// In strict mode a mismatch halts the service rather than marking the
+// deposits as invalid.
+func (s *Service) verifyDeposits(ctx context.Context, tree *depositTree, block uint64, deposits []*chaindb.ETH1Deposit) error {
+	root, err := tree.root()
+	if err != nil {
+		return errors.Wrap(err, "failed to calculate deposit tree root")
+	}
+	contractRoot, err := s.depositRoot(ctx, block)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain deposit contract root")
+	}
+	valid := root == contractRoot
+	if !valid && s.halt.Inconsistent() {
+		log.Error().
+			Uint64("block", block).
+			Str("root", fmt.Sprintf("%#x", root)).
+			Str("contract_root", fmt.Sprintf("%#x", contractRoot)).
+			Msg("Deposit tree root does not match deposit contract root; halting")
+		monitorHalted()
+		return util.ErrHalted
 	}
+	if !valid {
+		log.Warn().
+			Uint64("block", block).
+			Str("root", fmt.Sprintf("%#x", root)).
+			Str("contract_root", fmt.Sprintf("%#x", contractRoot)).
+			Msg("Deposit tree root does not match deposit contract root; deposits are invalid")
+	}
+	for _, deposit := range deposits {
+		deposit.Valid = &valid
+	}
+	monitorDepositsVerified(len(deposits), valid)
 
 	return nil
 }
@@ -80,37 +166,25 @@ func (s *Service) handleMissed(ctx context.Context, md *metadata) {
 	failed := 0
 	for i := 0; i < len(md.MissedBlocks); i++ {
 		log := log.With().Uint64("block", md.MissedBlocks[i]).Logger()
-		// Each update goes in to its own transaction, to make the data available sooner.
-		ctx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
-			return
-		}
-
-		if err := s.handleBlocks(ctx, md.MissedBlocks[i], md.MissedBlocks[i]); err != nil {
+		// Missed blocks are out of sequence with the deposit tree, so their
+		// deposits are not verified.
+		if _, err := s.handleBlocks(ctx, md.MissedBlocks[i], md.MissedBlocks[i], nil); err != nil {
 			log.Warn().Err(err).Msg("Failed to update block")
 			failed++
-			cancel()
 			continue
-		} else {
-			log.Trace().Msg("Updated block")
-			// Remove this from the list of missed blocks.
-			missedBlocks := make([]uint64, len(md.MissedBlocks)-1)
-			copy(missedBlocks[:failed], md.MissedBlocks[:failed])
-			copy(missedBlocks[failed:], md.MissedBlocks[i+1:])
-			md.MissedBlocks = missedBlocks
-			i--
 		}
-
-		if err := s.setMetadata(ctx, md); err != nil {
+		log.Trace().Msg("Updated block")
+		// Remove this from the list of missed blocks.
+		missedBlocks := make([]uint64, len(md.MissedBlocks)-1)
+		copy(missedBlocks[:failed], md.MissedBlocks[:failed])
+		copy(missedBlocks[failed:], md.MissedBlocks[i+1:])
+		md.MissedBlocks = missedBlocks
+		i--
+
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.setMetadata(ctx, md)
+		}); err != nil {
 			log.Error().Err(err).Msg("Failed to set metadata")
-			cancel()
-			return
-		}
-
-		if err := s.chainDB.CommitTx(ctx); err != nil {
-			log.Error().Err(err).Msg("Failed to commit transaction")
-			cancel()
 			return
 		}
 	}
@@ -24,6 +24,9 @@ import (
 type metadata struct {
 	LatestBlock  uint64   `json:"latest_block"`
 	MissedBlocks []uint64 `json:"missed_blocks,omitempty"`
+	// DepositTree is the deposit tree up to the latest block, used to verify
+	// deposits.  It is nil if deposits cannot be verified.
+	DepositTree *depositTree `json:"deposit_tree,omitempty"`
 }
 
 // metadataKey is the key for the metadata.
@@ -26,6 +26,8 @@ var metricsNamespace = "chaind_eth1deposits"
 var highestBlock uint64
 var latestBlock prometheus.Gauge
 var blocksProcessed prometheus.Gauge
+var depositsVerified *prometheus.CounterVec
+var halted prometheus.Gauge
 
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
 	if latestBlock != nil {
@@ -61,6 +63,24 @@ func registerPrometheusMetrics(ctx context.Context) error {
 		return errors.Wrap(err, "failed to register blocks_processed")
 	}
 
+	depositsVerified = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "deposits_verified_total",
+		Help:      "Number of deposits verified against the deposit contract root",
+	}, []string{"result"})
+	if err := prometheus.Register(depositsVerified); err != nil {
+		return errors.Wrap(err, "failed to register deposits_verified_total")
+	}
+
+	halted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "halted",
+		Help:      "1 if the service has halted due to an inconsistency in strict mode",
+	})
+	if err := prometheus.Register(halted); err != nil {
+		return errors.Wrap(err, "failed to register halted")
+	}
+
 	return nil
 }
 
@@ -73,3 +93,19 @@ func monitorBlockProcessed(block uint64) {
 		}
 	}
 }
+
+func monitorDepositsVerified(deposits int, valid bool) {
+	if depositsVerified != nil {
+		if valid {
+			depositsVerified.WithLabelValues("valid").Add(float64(deposits))
+		} else {
+			depositsVerified.WithLabelValues("invalid").Add(float64(deposits))
+		}
+	}
+}
+
+func monitorHalted() {
+	if halted != nil {
+		halted.Set(1)
+	}
+}
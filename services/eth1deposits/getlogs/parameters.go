@@ -24,12 +24,14 @@ import (
 
 type parameters struct {
 	logLevel           zerolog.Level
+	logLevelSampler    zerolog.Sampler
 	monitor            metrics.Service
 	connectionURL      string
 	chainDB            chaindb.Service
 	eth1DepositsSetter chaindb.ETH1DepositsSetter
 	eth1Confirmations  uint64
 	startBlock         string
+	strict             bool
 }
 
 // Parameter is the interface for service parameters.
@@ -50,6 +52,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -92,6 +102,14 @@ func WithStartBlock(block string) Parameter {
 	})
 }
 
+// WithStrict sets whether an inconsistency in the data halts the service,
+// rather than being logged.
+func WithStrict(strict bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.strict = strict
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
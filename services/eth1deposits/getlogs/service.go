@@ -27,6 +27,7 @@ import (
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -45,6 +46,7 @@ type Service struct {
 	blocksPerRequest       uint64
 	depositContractAddress []byte
 	activitySem            *semaphore.Weighted
+	halt                   *util.Halt
 }
 
 // New creates a new Ethereum 1 deposit service.
@@ -59,6 +61,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if parameters.logLevel != log.GetLevel() {
 		log = log.Level(parameters.logLevel)
 	}
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -108,6 +113,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		blocksPerRequest:       64,
 		depositContractAddress: depositContractAddress,
 		activitySem:            semaphore.NewWeighted(1),
+		halt:                   util.NewHalt(parameters.strict),
 	}
 
 	chainID, err := s.chainID(ctx)
@@ -149,6 +155,17 @@ func (s *Service) updateAfterRestart(ctx context.Context, startBlock int64) {
 		} else {
 			md.LatestBlock = 0
 		}
+		// Rebuild the deposit tree from the start block.  If the start block is
+		// after the first deposit the tree will not match and verification
+		// will stop.
+		md.DepositTree = newDepositTree()
+	}
+	if md.LatestBlock == 0 && md.DepositTree == nil {
+		// Starting from scratch.
+		md.DepositTree = newDepositTree()
+	}
+	if md.DepositTree == nil {
+		log.Warn().Msg("No deposit tree; deposits will not be verified")
 	}
 	log.Info().Uint64("block", md.LatestBlock).Msg("Last processed block")
 
@@ -196,6 +213,11 @@ func (s *Service) checkLatestBlock(ctx context.Context) {
 }
 
 func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
+	if s.halt.Halted() {
+		log.Debug().Msg("Service halted; not fetching blocks")
+		return
+	}
+
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
@@ -225,29 +247,24 @@ func (s *Service) parseNewBlocks(ctx context.Context, md *metadata) {
 
 		log := log.With().Uint64("start_block", startBlock).Uint64("end_block", endBlock).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
-		ctx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
+		tree, err := s.handleBlocks(ctx, startBlock, endBlock, md.DepositTree.copy())
+		if errors.Is(err, util.ErrHalted) {
 			return
 		}
-
-		if err := s.handleBlocks(ctx, startBlock, endBlock); err != nil {
+		if err != nil {
 			log.Warn().Err(err).Msg("Failed to update ETH1 deposits")
 			for missedBlock := block; missedBlock <= endBlock; missedBlock++ {
 				md.MissedBlocks = append(md.MissedBlocks, missedBlock)
 			}
+		} else {
+			md.DepositTree = tree
 		}
 
 		md.LatestBlock = endBlock
-		if err := s.setMetadata(ctx, md); err != nil {
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.setMetadata(ctx, md)
+		}); err != nil {
 			log.Error().Err(err).Msg("Failed to set metadata")
-			cancel()
-			return
-		}
-
-		if err := s.chainDB.CommitTx(ctx); err != nil {
-			log.Error().Err(err).Msg("Failed to commit transaction")
-			cancel()
 			return
 		}
 	}
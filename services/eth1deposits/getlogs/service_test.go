@@ -25,6 +25,10 @@ import (
 )
 
 func TestService(t *testing.T) {
+	if os.Getenv("CHAINDB_URL") == "" || os.Getenv("EXECCLIENT_URL") == "" {
+		t.Skip("CHAINDB_URL and EXECCLIENT_URL required")
+	}
+
 	ctx := context.Background()
 
 	chainDB, err := postgresqlchaindb.New(ctx,
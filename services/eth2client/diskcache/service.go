@@ -0,0 +1,203 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskcache
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service is an Ethereum 2 client that caches responses from a beacon node on
+// local disk, so that they do not need to be fetched again if they are
+// requested again, for example after a restart or a reindex.  Only responses
+// for finalized epochs are cached, as they cannot change.
+type Service struct {
+	client        eth2client.Service
+	dir           string
+	slotsPerEpoch uint64
+
+	// finalizedMu protects finalized information.
+	finalizedMu      sync.Mutex
+	finalizedEpoch   phase0.Epoch
+	finalizedChecked time.Time
+}
+
+// finalityCheckInterval is the minimum interval between fetches of finality
+// from the beacon node.
+const finalityCheckInterval = time.Minute
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new caching client.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "eth2client").Str("impl", "diskcache").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := os.MkdirAll(parameters.dir, 0o700); err != nil {
+		return nil, errors.Wrap(err, "failed to create cache directory")
+	}
+
+	slotsPerEpoch, err := parameters.client.(eth2client.SlotsPerEpochProvider).SlotsPerEpoch(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain slots per epoch")
+	}
+
+	s := &Service{
+		client:        parameters.client,
+		dir:           parameters.dir,
+		slotsPerEpoch: slotsPerEpoch,
+	}
+
+	return s, nil
+}
+
+// Name returns the name of the client implementation.
+func (s *Service) Name() string {
+	return s.client.Name()
+}
+
+// Address returns the address of the beacon node.
+func (s *Service) Address() string {
+	return s.client.Address()
+}
+
+// isFinalized returns true if the given epoch is known to be finalized.
+func (s *Service) isFinalized(ctx context.Context, epoch phase0.Epoch) bool {
+	s.finalizedMu.Lock()
+	defer s.finalizedMu.Unlock()
+
+	if epoch <= s.finalizedEpoch && !s.finalizedChecked.IsZero() {
+		return true
+	}
+	if time.Since(s.finalizedChecked) < finalityCheckInterval {
+		return false
+	}
+
+	finality, err := s.client.(eth2client.FinalityProvider).Finality(ctx, "head")
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain finality; not caching response")
+		return false
+	}
+	if finality == nil || finality.Finalized == nil {
+		log.Debug().Msg("No finality returned; not caching response")
+		return false
+	}
+	s.finalizedEpoch = finality.Finalized.Epoch
+	s.finalizedChecked = time.Now()
+
+	return epoch <= s.finalizedEpoch
+}
+
+// path returns the path of the cache file for the given request type and key.
+func (s *Service) path(requestType string, key uint64) string {
+	return filepath.Join(s.dir, requestType, fmt.Sprintf("%d.json.gz", key))
+}
+
+// load loads a cached response in to res, returning true if it was found.
+func (s *Service) load(requestType string, key uint64, res interface{}) bool {
+	path := s.path(requestType, key)
+	f, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn().Str("path", path).Err(err).Msg("Failed to open cached response")
+		}
+		return false
+	}
+	defer f.Close()
+
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		log.Warn().Str("path", path).Err(err).Msg("Failed to read cached response; removing")
+		s.remove(path)
+		return false
+	}
+	if err := json.NewDecoder(reader).Decode(res); err != nil {
+		log.Warn().Str("path", path).Err(err).Msg("Failed to decode cached response; removing")
+		s.remove(path)
+		return false
+	}
+	log.Trace().Str("type", requestType).Uint64("key", key).Msg("Loaded cached response")
+
+	return true
+}
+
+// store stores a response.  Failure to store a response is logged but is not
+// an error, as the response can always be fetched again.
+func (s *Service) store(requestType string, key uint64, res interface{}) {
+	if err := s.write(s.path(requestType, key), res); err != nil {
+		log.Warn().Str("type", requestType).Uint64("key", key).Err(err).Msg("Failed to cache response")
+		return
+	}
+	log.Trace().Str("type", requestType).Uint64("key", key).Msg("Cached response")
+}
+
+// write writes a response to the given path.  The response is written to a
+// temporary file that is renamed once complete, so that a crash cannot leave a
+// partial response in the cache.
+func (s *Service) write(path string, res interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "failed to create directory")
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary file")
+	}
+	defer s.remove(f.Name())
+
+	writer := gzip.NewWriter(f)
+	if err := json.NewEncoder(writer).Encode(res); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to encode response")
+	}
+	if err := writer.Close(); err != nil {
+		f.Close()
+		return errors.Wrap(err, "failed to compress response")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close temporary file")
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Wrap(err, "failed to rename temporary file")
+	}
+
+	return nil
+}
+
+// remove removes a file, ignoring it if it does not exist.
+func (s *Service) remove(path string) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		log.Warn().Str("path", path).Err(err).Msg("Failed to remove file")
+	}
+}
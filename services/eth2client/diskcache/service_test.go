@@ -0,0 +1,203 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diskcache_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/diskcache"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []diskcache.Parameter
+		err    string
+	}{
+		{
+			name: "ClientMissing",
+			params: []diskcache.Parameter{
+				diskcache.WithLogLevel(zerolog.Disabled),
+				diskcache.WithDir(t.TempDir()),
+			},
+			err: "problem with parameters: no client specified",
+		},
+		{
+			name: "DirMissing",
+			params: []diskcache.Parameter{
+				diskcache.WithLogLevel(zerolog.Disabled),
+				diskcache.WithClient(client),
+			},
+			err: "problem with parameters: no directory specified",
+		},
+		{
+			name: "Good",
+			params: []diskcache.Parameter{
+				diskcache.WithLogLevel(zerolog.Disabled),
+				diskcache.WithClient(client),
+				diskcache.WithDir(t.TempDir()),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := diskcache.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// countingClient counts the requests made to it that can be cached.
+type countingClient struct {
+	*mock.Service
+	requests int
+}
+
+func (c *countingClient) BeaconCommittees(_ context.Context, _ string) ([]*apiv1.BeaconCommittee, error) {
+	c.requests++
+	return []*apiv1.BeaconCommittee{
+		{Slot: 64, Index: 0, Validators: []phase0.ValidatorIndex{1, 2}},
+	}, nil
+}
+
+func (c *countingClient) ProposerDuties(_ context.Context, epoch phase0.Epoch, _ []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	c.requests++
+	return []*apiv1.ProposerDuty{
+		{Slot: phase0.Slot(epoch) * 32, ValidatorIndex: 1},
+	}, nil
+}
+
+func (c *countingClient) Validators(_ context.Context, _ string, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	c.requests++
+	return map[phase0.ValidatorIndex]*apiv1.Validator{
+		1: {
+			Index:   1,
+			Balance: 32000000000,
+			Status:  apiv1.ValidatorStateActiveOngoing,
+			Validator: &phase0.Validator{
+				EffectiveBalance:           32000000000,
+				WithdrawalCredentials:      make([]byte, 32),
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  0xffffffffffffffff,
+				WithdrawableEpoch:          0xffffffffffffffff,
+			},
+		},
+	}, nil
+}
+
+func TestCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClient, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+	client := &countingClient{Service: mockClient}
+	dir := t.TempDir()
+
+	s, err := diskcache.New(ctx,
+		diskcache.WithLogLevel(zerolog.Disabled),
+		diskcache.WithClient(client),
+		diskcache.WithDir(dir),
+	)
+	require.NoError(t, err)
+
+	// Mock finalized epoch is 6, so slot 64 (epoch 2) is cached.
+	committees, err := s.BeaconCommittees(ctx, "64")
+	require.NoError(t, err)
+	require.Len(t, committees, 1)
+	require.Equal(t, 1, client.requests)
+	committees, err = s.BeaconCommittees(ctx, "64")
+	require.NoError(t, err)
+	require.Equal(t, []phase0.ValidatorIndex{1, 2}, committees[0].Validators)
+	require.Equal(t, 1, client.requests)
+
+	duties, err := s.ProposerDuties(ctx, 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, client.requests)
+	duties2, err := s.ProposerDuties(ctx, 2, nil)
+	require.NoError(t, err)
+	require.Equal(t, duties, duties2)
+	require.Equal(t, 2, client.requests)
+
+	validators, err := s.Validators(ctx, "64", nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, client.requests)
+	validators2, err := s.Validators(ctx, "64", nil)
+	require.NoError(t, err)
+	require.Equal(t, validators, validators2)
+	require.Equal(t, 3, client.requests)
+
+	// Unfinalized epochs, named states and filtered requests are not cached.
+	_, err = s.BeaconCommittees(ctx, "320")
+	require.NoError(t, err)
+	_, err = s.BeaconCommittees(ctx, "320")
+	require.NoError(t, err)
+	require.Equal(t, 5, client.requests)
+	_, err = s.Validators(ctx, "head", nil)
+	require.NoError(t, err)
+	_, err = s.Validators(ctx, "64", []phase0.ValidatorIndex{1})
+	require.NoError(t, err)
+	require.Equal(t, 7, client.requests)
+
+	// Corrupt cache entries are fetched again.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "validators", "64.json.gz"), []byte("bad"), 0o600))
+	_, err = s.Validators(ctx, "64", nil)
+	require.NoError(t, err)
+	require.Equal(t, 8, client.requests)
+	_, err = s.Validators(ctx, "64", nil)
+	require.NoError(t, err)
+	require.Equal(t, 8, client.requests)
+}
+
+func TestBeaconStateRoot(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	s, err := diskcache.New(ctx,
+		diskcache.WithLogLevel(zerolog.Disabled),
+		diskcache.WithClient(client),
+		diskcache.WithDir(t.TempDir()),
+	)
+	require.NoError(t, err)
+
+	// Ensure that the service can supply state roots.
+	require.Implements(t, (*eth2client.BeaconStateRootProvider)(nil), s)
+	root, err := s.BeaconStateRoot(ctx, "64")
+	require.NoError(t, err)
+	require.Equal(t, &phase0.Root{}, root)
+}
@@ -0,0 +1,52 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failover
+
+import (
+	"context"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/pkg/errors"
+)
+
+// Events feeds requested events with the given topics to the supplied handler.
+// Events are subscribed to on all beacon nodes, but only those from the active
+// beacon node are passed to the handler, so that events continue to arrive
+// after a failover.
+func (s *Service) Events(ctx context.Context, topics []string, handler eth2client.EventHandlerFunc) error {
+	subscribed := 0
+	for i, client := range s.clients {
+		provider, isProvider := client.(eth2client.EventsProvider)
+		if !isProvider {
+			log.Debug().Str("address", s.addresses[i]).Msg("Client is not an EventsProvider")
+			continue
+		}
+		index := i
+		if err := provider.Events(ctx, topics, func(event *apiv1.Event) {
+			if s.activeIndex() == index {
+				handler(event)
+			}
+		}); err != nil {
+			log.Warn().Str("address", s.addresses[i]).Err(err).Msg("Failed to subscribe to events")
+			continue
+		}
+		subscribed++
+	}
+	if subscribed == 0 {
+		return errors.New("failed to subscribe to events on any beacon node")
+	}
+
+	return nil
+}
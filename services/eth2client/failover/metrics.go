@@ -0,0 +1,79 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failover
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_eth2client"
+
+var activeNode *prometheus.GaugeVec
+var failovers prometheus.Counter
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if activeNode != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(_ context.Context) error {
+	activeNode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "active_node",
+		Help:      "1 for the beacon node currently in use, otherwise 0",
+	}, []string{"address"})
+	if err := prometheus.Register(activeNode); err != nil {
+		return errors.Wrap(err, "failed to register active_node")
+	}
+
+	failovers = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "failovers_total",
+		Help:      "Number of times the beacon node in use has changed",
+	})
+	if err := prometheus.Register(failovers); err != nil {
+		return errors.Wrap(err, "failed to register failovers_total")
+	}
+
+	return nil
+}
+
+func monitorActiveNode(addresses []string, active string, changed bool) {
+	if activeNode != nil {
+		for _, address := range addresses {
+			if address == active {
+				activeNode.WithLabelValues(address).Set(1)
+			} else {
+				activeNode.WithLabelValues(address).Set(0)
+			}
+		}
+	}
+	if failovers != nil && changed {
+		failovers.Inc()
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failover
+
+import (
+	"errors"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	monitor         metrics.Service
+	clients         []eth2client.Service
+	checkInterval   time.Duration
+	maxSyncDistance phase0.Slot
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithClients sets the clients for the beacon nodes, in order of preference.
+func WithClients(clients []eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.clients = clients
+	})
+}
+
+// WithCheckInterval sets the interval at which the health of the beacon nodes is checked.
+func WithCheckInterval(checkInterval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.checkInterval = checkInterval
+	})
+}
+
+// WithMaxSyncDistance sets the maximum number of slots a beacon node can be
+// behind the chain, or the most up-to-date beacon node, and be considered healthy.
+func WithMaxSyncDistance(maxSyncDistance phase0.Slot) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxSyncDistance = maxSyncDistance
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:        zerolog.GlobalLevel(),
+		checkInterval:   30 * time.Second,
+		maxSyncDistance: 8,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if len(parameters.clients) == 0 {
+		return nil, errors.New("no clients specified")
+	}
+	if parameters.checkInterval == 0 {
+		return nil, errors.New("check interval must be greater than 0")
+	}
+
+	return &parameters, nil
+}
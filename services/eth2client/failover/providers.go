@@ -0,0 +1,280 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failover
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// BeaconCommittees fetches the chain's beacon committees given a state.
+func (s *Service) BeaconCommittees(ctx context.Context, stateID string) ([]*apiv1.BeaconCommittee, error) {
+	var res []*apiv1.BeaconCommittee
+	err := s.call(ctx, "beacon committees", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.BeaconCommitteesProvider)
+		if !isProvider {
+			return errors.New("client is not a BeaconCommitteesProvider")
+		}
+		var err error
+		res, err = provider.BeaconCommittees(ctx, stateID)
+		return err
+	})
+	return res, err
+}
+
+// BeaconCommitteesAtEpoch fetches the chain's beacon committees given a state at the given epoch.
+func (s *Service) BeaconCommitteesAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) ([]*apiv1.BeaconCommittee, error) {
+	var res []*apiv1.BeaconCommittee
+	err := s.call(ctx, "beacon committees at epoch", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.BeaconCommitteesProvider)
+		if !isProvider {
+			return errors.New("client is not a BeaconCommitteesProvider")
+		}
+		var err error
+		res, err = provider.BeaconCommitteesAtEpoch(ctx, stateID, epoch)
+		return err
+	})
+	return res, err
+}
+
+// BeaconState fetches a beacon state given a state ID.
+func (s *Service) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	var res *spec.VersionedBeaconState
+	err := s.call(ctx, "beacon state", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.BeaconStateProvider)
+		if !isProvider {
+			return errors.New("client is not a BeaconStateProvider")
+		}
+		var err error
+		res, err = provider.BeaconState(ctx, stateID)
+		return err
+	})
+	return res, err
+}
+
+// BeaconStateRoot fetches a beacon state root given a state ID.
+func (s *Service) BeaconStateRoot(ctx context.Context, stateID string) (*phase0.Root, error) {
+	var res *phase0.Root
+	err := s.call(ctx, "beacon state root", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.BeaconStateRootProvider)
+		if !isProvider {
+			return errors.New("client is not a BeaconStateRootProvider")
+		}
+		var err error
+		res, err = provider.BeaconStateRoot(ctx, stateID)
+		return err
+	})
+	return res, err
+}
+
+// Finality provides the finality given a state ID.
+func (s *Service) Finality(ctx context.Context, stateID string) (*apiv1.Finality, error) {
+	var res *apiv1.Finality
+	err := s.call(ctx, "finality", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.FinalityProvider)
+		if !isProvider {
+			return errors.New("client is not a FinalityProvider")
+		}
+		var err error
+		res, err = provider.Finality(ctx, stateID)
+		return err
+	})
+	return res, err
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (s *Service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	var res []*phase0.Fork
+	err := s.call(ctx, "fork schedule", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.ForkScheduleProvider)
+		if !isProvider {
+			return errors.New("client is not a ForkScheduleProvider")
+		}
+		var err error
+		res, err = provider.ForkSchedule(ctx)
+		return err
+	})
+	return res, err
+}
+
+// Genesis provides the genesis information of the chain.
+func (s *Service) Genesis(ctx context.Context) (*apiv1.Genesis, error) {
+	var res *apiv1.Genesis
+	err := s.call(ctx, "genesis", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.GenesisProvider)
+		if !isProvider {
+			return errors.New("client is not a GenesisProvider")
+		}
+		var err error
+		res, err = provider.Genesis(ctx)
+		return err
+	})
+	return res, err
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (s *Service) GenesisTime(ctx context.Context) (time.Time, error) {
+	var res time.Time
+	err := s.call(ctx, "genesis time", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.GenesisTimeProvider)
+		if !isProvider {
+			return errors.New("client is not a GenesisTimeProvider")
+		}
+		var err error
+		res, err = provider.GenesisTime(ctx)
+		return err
+	})
+	return res, err
+}
+
+// NodeSyncing provides the state of the active beacon node's synchronization with the chain.
+func (s *Service) NodeSyncing(ctx context.Context) (*apiv1.SyncState, error) {
+	var res *apiv1.SyncState
+	err := s.call(ctx, "node syncing", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.NodeSyncingProvider)
+		if !isProvider {
+			return errors.New("client is not a NodeSyncingProvider")
+		}
+		var err error
+		res, err = provider.NodeSyncing(ctx)
+		return err
+	})
+	return res, err
+}
+
+// NodeVersion returns a free-text string with the node version.
+func (s *Service) NodeVersion(ctx context.Context) (string, error) {
+	var res string
+	err := s.call(ctx, "node version", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.NodeVersionProvider)
+		if !isProvider {
+			return errors.New("client is not a NodeVersionProvider")
+		}
+		var err error
+		res, err = provider.NodeVersion(ctx)
+		return err
+	})
+	return res, err
+}
+
+// ProposerDuties obtains proposer duties for the given epoch.
+func (s *Service) ProposerDuties(ctx context.Context, epoch phase0.Epoch, validatorIndices []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	var res []*apiv1.ProposerDuty
+	err := s.call(ctx, "proposer duties", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.ProposerDutiesProvider)
+		if !isProvider {
+			return errors.New("client is not a ProposerDutiesProvider")
+		}
+		var err error
+		res, err = provider.ProposerDuties(ctx, epoch, validatorIndices)
+		return err
+	})
+	return res, err
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (s *Service) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	var res *spec.VersionedSignedBeaconBlock
+	err := s.call(ctx, "signed beacon block", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.SignedBeaconBlockProvider)
+		if !isProvider {
+			return errors.New("client is not a SignedBeaconBlockProvider")
+		}
+		var err error
+		res, err = provider.SignedBeaconBlock(ctx, blockID)
+		return err
+	})
+	return res, err
+}
+
+// Spec provides the spec information of the chain.
+func (s *Service) Spec(ctx context.Context) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	err := s.call(ctx, "spec", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.SpecProvider)
+		if !isProvider {
+			return errors.New("client is not a SpecProvider")
+		}
+		var err error
+		res, err = provider.Spec(ctx)
+		return err
+	})
+	return res, err
+}
+
+// SyncCommittee fetches the sync committee for the given state.
+func (s *Service) SyncCommittee(ctx context.Context, stateID string) (*apiv1.SyncCommittee, error) {
+	var res *apiv1.SyncCommittee
+	err := s.call(ctx, "sync committee", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.SyncCommitteesProvider)
+		if !isProvider {
+			return errors.New("client is not a SyncCommitteesProvider")
+		}
+		var err error
+		res, err = provider.SyncCommittee(ctx, stateID)
+		return err
+	})
+	return res, err
+}
+
+// SyncCommitteeAtEpoch fetches the sync committee for the given epoch at the given state.
+func (s *Service) SyncCommitteeAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) (*apiv1.SyncCommittee, error) {
+	var res *apiv1.SyncCommittee
+	err := s.call(ctx, "sync committee at epoch", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.SyncCommitteesProvider)
+		if !isProvider {
+			return errors.New("client is not a SyncCommitteesProvider")
+		}
+		var err error
+		res, err = provider.SyncCommitteeAtEpoch(ctx, stateID, epoch)
+		return err
+	})
+	return res, err
+}
+
+// Validators provides the validators, with their balance and status, for a given state.
+func (s *Service) Validators(ctx context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	var res map[phase0.ValidatorIndex]*apiv1.Validator
+	err := s.call(ctx, "validators", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.ValidatorsProvider)
+		if !isProvider {
+			return errors.New("client is not a ValidatorsProvider")
+		}
+		var err error
+		res, err = provider.Validators(ctx, stateID, validatorIndices)
+		return err
+	})
+	return res, err
+}
+
+// ValidatorsByPubKey provides the validators, with their balance and status, for a given state.
+func (s *Service) ValidatorsByPubKey(ctx context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	var res map[phase0.ValidatorIndex]*apiv1.Validator
+	err := s.call(ctx, "validators by public key", func(client eth2client.Service) error {
+		provider, isProvider := client.(eth2client.ValidatorsProvider)
+		if !isProvider {
+			return errors.New("client is not a ValidatorsProvider")
+		}
+		var err error
+		res, err = provider.ValidatorsByPubKey(ctx, stateID, validatorPubKeys)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,206 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failover
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service is an Ethereum 2 client that uses a number of beacon nodes in
+// order of preference.  Requests go to the active beacon node; if a request
+// fails it is retried against the other beacon nodes in turn.  The health of
+// the beacon nodes is checked periodically, and the most preferred healthy
+// beacon node becomes the active beacon node.
+type Service struct {
+	clients         []eth2client.Service
+	addresses       []string
+	checkInterval   time.Duration
+	maxSyncDistance phase0.Slot
+
+	activeMu sync.RWMutex
+	active   int
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new failover client.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "eth2client").Str("impl", "failover").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	addresses := make([]string, len(parameters.clients))
+	for i, client := range parameters.clients {
+		addresses[i] = client.Address()
+	}
+
+	s := &Service{
+		clients:         parameters.clients,
+		addresses:       addresses,
+		checkInterval:   parameters.checkInterval,
+		maxSyncDistance: parameters.maxSyncDistance,
+	}
+
+	s.checkClients(ctx)
+	go s.monitor(ctx)
+
+	return s, nil
+}
+
+// Name returns the name of the client implementation.
+func (s *Service) Name() string {
+	return "failover"
+}
+
+// Address returns the address of the active beacon node.
+func (s *Service) Address() string {
+	return s.activeClient().Address()
+}
+
+// monitor periodically checks the health of the beacon nodes until the context is done.
+func (s *Service) monitor(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkClients(ctx)
+		}
+	}
+}
+
+// checkClients obtains the sync state of each beacon node and makes the most
+// preferred healthy beacon node active.  If no beacon node is healthy then the
+// active beacon node is left unchanged.
+func (s *Service) checkClients(ctx context.Context) {
+	syncStates := make([]*apiv1.SyncState, len(s.clients))
+	var wg sync.WaitGroup
+	for i := range s.clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			provider, isProvider := s.clients[i].(eth2client.NodeSyncingProvider)
+			if !isProvider {
+				return
+			}
+			syncState, err := provider.NodeSyncing(ctx)
+			if err != nil {
+				log.Debug().Str("address", s.addresses[i]).Err(err).Msg("Failed to obtain sync state")
+				return
+			}
+			syncStates[i] = syncState
+		}(i)
+	}
+	wg.Wait()
+
+	bestHead := phase0.Slot(0)
+	for _, syncState := range syncStates {
+		if syncState != nil && syncState.HeadSlot > bestHead {
+			bestHead = syncState.HeadSlot
+		}
+	}
+
+	for i, syncState := range syncStates {
+		if s.healthy(syncState, bestHead) {
+			s.setActive(i)
+			return
+		}
+	}
+	log.Warn().Msg("No healthy beacon nodes; keeping active beacon node")
+}
+
+// healthy returns true if the sync state shows a beacon node that is close
+// enough to both the head of the chain and to the best-synced beacon node.
+func (s *Service) healthy(syncState *apiv1.SyncState, bestHead phase0.Slot) bool {
+	if syncState == nil {
+		return false
+	}
+	if syncState.SyncDistance > s.maxSyncDistance {
+		return false
+	}
+	return syncState.HeadSlot+s.maxSyncDistance >= bestHead
+}
+
+// activeIndex returns the index of the active beacon node.
+func (s *Service) activeIndex() int {
+	s.activeMu.RLock()
+	defer s.activeMu.RUnlock()
+	return s.active
+}
+
+// activeClient returns the active beacon node.
+func (s *Service) activeClient() eth2client.Service {
+	return s.clients[s.activeIndex()]
+}
+
+// setActive sets the active beacon node.
+func (s *Service) setActive(index int) {
+	s.activeMu.Lock()
+	changed := s.active != index
+	s.active = index
+	s.activeMu.Unlock()
+
+	if changed {
+		log.Info().Str("address", s.addresses[index]).Msg("Changed active beacon node")
+	}
+	monitorActiveNode(s.addresses, s.addresses[index], changed)
+}
+
+// call calls the supplied function against the active beacon node.  If the
+// call fails it is tried against each of the other beacon nodes in turn, and
+// the first beacon node to succeed becomes the active beacon node.
+func (s *Service) call(ctx context.Context, operation string, fn func(client eth2client.Service) error) error {
+	active := s.activeIndex()
+	var err error
+	for i := 0; i < len(s.clients); i++ {
+		index := (active + i) % len(s.clients)
+		err = fn(s.clients[index])
+		if err == nil {
+			if index != active {
+				s.setActive(index)
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			// No point trying other beacon nodes.
+			return err
+		}
+		log.Debug().Str("address", s.addresses[index]).Str("operation", operation).Err(err).Msg("Request failed")
+	}
+
+	return err
+}
@@ -0,0 +1,159 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failover
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// testClient is a beacon node whose state can be altered by tests.
+type testClient struct {
+	address   string
+	syncState *apiv1.SyncState
+	fail      bool
+	events    eth2client.EventHandlerFunc
+}
+
+func (c *testClient) Name() string {
+	return "test"
+}
+
+func (c *testClient) Address() string {
+	return c.address
+}
+
+func (c *testClient) NodeSyncing(_ context.Context) (*apiv1.SyncState, error) {
+	if c.fail {
+		return nil, errors.New("failed")
+	}
+	return c.syncState, nil
+}
+
+func (c *testClient) Events(_ context.Context, _ []string, handler eth2client.EventHandlerFunc) error {
+	c.events = handler
+	return nil
+}
+
+func newTestClient(address string, headSlot phase0.Slot) *testClient {
+	return &testClient{
+		address: address,
+		syncState: &apiv1.SyncState{
+			HeadSlot: headSlot,
+		},
+	}
+}
+
+func TestCheckClients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primary := newTestClient("primary", 1000)
+	secondary := newTestClient("secondary", 1000)
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithClients([]eth2client.Service{primary, secondary}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "primary", s.Address())
+
+	// Primary fails.
+	primary.fail = true
+	s.checkClients(ctx)
+	require.Equal(t, "secondary", s.Address())
+
+	// Primary recovers.
+	primary.fail = false
+	s.checkClients(ctx)
+	require.Equal(t, "primary", s.Address())
+
+	// Primary syncing.
+	primary.syncState = &apiv1.SyncState{HeadSlot: 1000, SyncDistance: 100, IsSyncing: true}
+	s.checkClients(ctx)
+	require.Equal(t, "secondary", s.Address())
+
+	// Primary stale compared to secondary.
+	primary.syncState = &apiv1.SyncState{HeadSlot: 1000}
+	secondary.syncState = &apiv1.SyncState{HeadSlot: 1100}
+	s.checkClients(ctx)
+	require.Equal(t, "secondary", s.Address())
+
+	// Primary catches up.
+	primary.syncState = &apiv1.SyncState{HeadSlot: 1100}
+	s.checkClients(ctx)
+	require.Equal(t, "primary", s.Address())
+
+	// No healthy nodes; active node unchanged.
+	primary.fail = true
+	secondary.fail = true
+	s.checkClients(ctx)
+	require.Equal(t, "primary", s.Address())
+}
+
+func TestCallFailover(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primary := newTestClient("primary", 1000)
+	secondary := newTestClient("secondary", 1000)
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithClients([]eth2client.Service{primary, secondary}),
+	)
+	require.NoError(t, err)
+
+	primary.fail = true
+	syncState, err := s.NodeSyncing(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, syncState)
+	require.Equal(t, "secondary", s.Address())
+
+	secondary.fail = true
+	_, err = s.NodeSyncing(ctx)
+	require.EqualError(t, err, "failed")
+}
+
+func TestEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primary := newTestClient("primary", 1000)
+	secondary := newTestClient("secondary", 1000)
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithClients([]eth2client.Service{primary, secondary}),
+	)
+	require.NoError(t, err)
+
+	received := make([]string, 0)
+	require.NoError(t, s.Events(ctx, []string{"head"}, func(event *apiv1.Event) {
+		received = append(received, event.Topic)
+	}))
+
+	primary.events(&apiv1.Event{Topic: "primary"})
+	secondary.events(&apiv1.Event{Topic: "secondary"})
+	require.Equal(t, []string{"primary"}, received)
+
+	s.setActive(1)
+	primary.events(&apiv1.Event{Topic: "primary"})
+	secondary.events(&apiv1.Event{Topic: "secondary"})
+	require.Equal(t, []string{"primary", "secondary"}, received)
+}
@@ -0,0 +1,116 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package failover_test
+
+import (
+	"context"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/failover"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client1, err := mock.New(ctx, mock.WithName("client1"))
+	require.NoError(t, err)
+	client2, err := mock.New(ctx, mock.WithName("client2"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []failover.Parameter
+		err    string
+	}{
+		{
+			name: "ClientsMissing",
+			params: []failover.Parameter{
+				failover.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no clients specified",
+		},
+		{
+			name: "CheckIntervalZero",
+			params: []failover.Parameter{
+				failover.WithLogLevel(zerolog.Disabled),
+				failover.WithClients([]eth2client.Service{client1, client2}),
+				failover.WithCheckInterval(0),
+			},
+			err: "problem with parameters: check interval must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []failover.Parameter{
+				failover.WithLogLevel(zerolog.Disabled),
+				failover.WithClients([]eth2client.Service{client1, client2}),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, err := failover.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, "failover", s.Name())
+				require.Equal(t, "client1", s.Address())
+			}
+		})
+	}
+}
+
+func TestProviders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	s, err := failover.New(ctx,
+		failover.WithLogLevel(zerolog.Disabled),
+		failover.WithClients([]eth2client.Service{client}),
+	)
+	require.NoError(t, err)
+
+	// Ensure that the service can stand in for a beacon node.
+	require.Implements(t, (*eth2client.GenesisTimeProvider)(nil), s)
+	require.Implements(t, (*eth2client.SpecProvider)(nil), s)
+	require.Implements(t, (*eth2client.ForkScheduleProvider)(nil), s)
+	require.Implements(t, (*eth2client.NodeSyncingProvider)(nil), s)
+	require.Implements(t, (*eth2client.EventsProvider)(nil), s)
+	require.Implements(t, (*eth2client.SignedBeaconBlockProvider)(nil), s)
+	require.Implements(t, (*eth2client.BeaconCommitteesProvider)(nil), s)
+	require.Implements(t, (*eth2client.ProposerDutiesProvider)(nil), s)
+	require.Implements(t, (*eth2client.SyncCommitteesProvider)(nil), s)
+	require.Implements(t, (*eth2client.ValidatorsProvider)(nil), s)
+	require.Implements(t, (*eth2client.FinalityProvider)(nil), s)
+	require.Implements(t, (*eth2client.GenesisProvider)(nil), s)
+	require.Implements(t, (*eth2client.BeaconStateRootProvider)(nil), s)
+
+	syncState, err := s.NodeSyncing(ctx)
+	require.NoError(t, err)
+	require.Equal(t, client.HeadSlot, syncState.HeadSlot)
+
+	root, err := s.BeaconStateRoot(ctx, "64")
+	require.NoError(t, err)
+	require.Equal(t, &phase0.Root{}, root)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"errors"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	client          eth2client.Service
+	network         string
+	configFile      string
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithClient sets the client for the beacon node.
+func WithClient(client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.client = client
+	})
+}
+
+// WithNetwork sets the name of the preset network whose configuration
+// overrides that of the beacon node.
+func WithNetwork(network string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.network = network
+	})
+}
+
+// WithConfigFile sets the path to a config.yaml file whose configuration
+// overrides that of the beacon node and of any preset network.
+func WithConfigFile(configFile string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.configFile = configFile
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.client == nil {
+		return nil, errors.New("no client specified")
+	}
+	if _, isProvider := parameters.client.(eth2client.SpecProvider); !isProvider {
+		return nil, errors.New("client is not a SpecProvider")
+	}
+	if _, isProvider := parameters.client.(eth2client.ForkScheduleProvider); !isProvider {
+		return nil, errors.New("client is not a ForkScheduleProvider")
+	}
+	if parameters.network == "" && parameters.configFile == "" {
+		return nil, errors.New("no network or config file specified")
+	}
+	if parameters.network != "" {
+		if _, exists := presets[parameters.network]; !exists {
+			return nil, errors.New("unknown network")
+		}
+	}
+
+	return &parameters, nil
+}
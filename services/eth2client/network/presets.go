@@ -0,0 +1,116 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import "sort"
+
+// presets are the configurations of well-known networks, as they would be
+// found in the network's config.yaml file.
+var presets = map[string]map[string]string{
+	"mainnet": {
+		"CONFIG_NAME":                      "mainnet",
+		"PRESET_BASE":                      "mainnet",
+		"SECONDS_PER_SLOT":                 "12",
+		"SLOTS_PER_EPOCH":                  "32",
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": "256",
+		"MIN_GENESIS_TIME":                 "1606824000",
+		"GENESIS_DELAY":                    "604800",
+		"GENESIS_FORK_VERSION":             "0x00000000",
+		"ALTAIR_FORK_VERSION":              "0x01000000",
+		"ALTAIR_FORK_EPOCH":                "74240",
+		"BELLATRIX_FORK_VERSION":           "0x02000000",
+		"BELLATRIX_FORK_EPOCH":             "144896",
+		"CAPELLA_FORK_VERSION":             "0x03000000",
+		"CAPELLA_FORK_EPOCH":               "194048",
+		"DENEB_FORK_VERSION":               "0x04000000",
+		"DENEB_FORK_EPOCH":                 "269568",
+		"DEPOSIT_CHAIN_ID":                 "1",
+		"DEPOSIT_NETWORK_ID":               "1",
+		"DEPOSIT_CONTRACT_ADDRESS":         "0x00000000219ab540356cBB839Cbe05303d7705Fa",
+	},
+	"sepolia": {
+		"CONFIG_NAME":                      "sepolia",
+		"PRESET_BASE":                      "mainnet",
+		"SECONDS_PER_SLOT":                 "12",
+		"SLOTS_PER_EPOCH":                  "32",
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": "256",
+		"MIN_GENESIS_TIME":                 "1655647200",
+		"GENESIS_DELAY":                    "86400",
+		"GENESIS_FORK_VERSION":             "0x90000069",
+		"ALTAIR_FORK_VERSION":              "0x90000070",
+		"ALTAIR_FORK_EPOCH":                "50",
+		"BELLATRIX_FORK_VERSION":           "0x90000071",
+		"BELLATRIX_FORK_EPOCH":             "100",
+		"CAPELLA_FORK_VERSION":             "0x90000072",
+		"CAPELLA_FORK_EPOCH":               "56832",
+		"DENEB_FORK_VERSION":               "0x90000073",
+		"DENEB_FORK_EPOCH":                 "132608",
+		"DEPOSIT_CHAIN_ID":                 "11155111",
+		"DEPOSIT_NETWORK_ID":               "11155111",
+		"DEPOSIT_CONTRACT_ADDRESS":         "0x7f02C3E3c98b133055B8B348B2Ac625669Ed295D",
+	},
+	"holesky": {
+		"CONFIG_NAME":                      "holesky",
+		"PRESET_BASE":                      "mainnet",
+		"SECONDS_PER_SLOT":                 "12",
+		"SLOTS_PER_EPOCH":                  "32",
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": "256",
+		"MIN_GENESIS_TIME":                 "1695902100",
+		"GENESIS_DELAY":                    "300",
+		"GENESIS_FORK_VERSION":             "0x01017000",
+		"ALTAIR_FORK_VERSION":              "0x02017000",
+		"ALTAIR_FORK_EPOCH":                "0",
+		"BELLATRIX_FORK_VERSION":           "0x03017000",
+		"BELLATRIX_FORK_EPOCH":             "0",
+		"CAPELLA_FORK_VERSION":             "0x04017000",
+		"CAPELLA_FORK_EPOCH":               "256",
+		"DENEB_FORK_VERSION":               "0x05017000",
+		"DENEB_FORK_EPOCH":                 "29696",
+		"DEPOSIT_CHAIN_ID":                 "17000",
+		"DEPOSIT_NETWORK_ID":               "17000",
+		"DEPOSIT_CONTRACT_ADDRESS":         "0x4242424242424242424242424242424242424242",
+	},
+	"gnosis": {
+		"CONFIG_NAME":                      "gnosis",
+		"PRESET_BASE":                      "gnosis",
+		"SECONDS_PER_SLOT":                 "5",
+		"SLOTS_PER_EPOCH":                  "16",
+		"EPOCHS_PER_SYNC_COMMITTEE_PERIOD": "512",
+		"MIN_GENESIS_TIME":                 "1638968400",
+		"GENESIS_DELAY":                    "6000",
+		"GENESIS_FORK_VERSION":             "0x00000064",
+		"ALTAIR_FORK_VERSION":              "0x01000064",
+		"ALTAIR_FORK_EPOCH":                "512",
+		"BELLATRIX_FORK_VERSION":           "0x02000064",
+		"BELLATRIX_FORK_EPOCH":             "385536",
+		"CAPELLA_FORK_VERSION":             "0x03000064",
+		"CAPELLA_FORK_EPOCH":               "648704",
+		"DENEB_FORK_VERSION":               "0x04000064",
+		"DENEB_FORK_EPOCH":                 "889856",
+		"DEPOSIT_CHAIN_ID":                 "100",
+		"DEPOSIT_NETWORK_ID":               "100",
+		"DEPOSIT_CONTRACT_ADDRESS":         "0x0B98057eA310F4d31F2a452B414647007d1645d9",
+	},
+}
+
+// Networks returns the names of the preset networks.
+func Networks() []string {
+	networks := make([]string, 0, len(presets))
+	for network := range presets {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+
+	return networks
+}
@@ -0,0 +1,287 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// Service is an Ethereum 2 client that overrides the chain configuration
+// provided by a beacon node with that of a preset network or a custom
+// config.yaml file, for beacon nodes that do not report it correctly.
+type Service struct {
+	client        eth2client.Service
+	spec          map[string]interface{}
+	forkSchedule  []*phase0.Fork
+	slotsPerEpoch uint64
+}
+
+// farFutureEpoch is the epoch used by configurations for forks that are not
+// yet scheduled.
+const farFutureEpoch = phase0.Epoch(0xffffffffffffffff)
+
+// forks are the names of the forks after genesis, in the order in which they
+// occur.
+var forks = []string{"ALTAIR", "BELLATRIX", "CAPELLA", "DENEB", "ELECTRA"}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new network configuration client.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "eth2client").Str("impl", "network").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	config := make(map[string]string)
+	for k, v := range presets[parameters.network] {
+		config[k] = v
+	}
+	if parameters.configFile != "" {
+		fileConfig, err := readConfigFile(parameters.configFile)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileConfig {
+			config[k] = v
+		}
+	}
+	overrides, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeSpec, err := parameters.client.(eth2client.SpecProvider).Spec(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain spec from beacon node")
+	}
+	spec, err := mergeSpec(nodeSpec, overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	slotsPerEpoch, isUint := spec["SLOTS_PER_EPOCH"].(uint64)
+	if !isUint {
+		return nil, errors.New("SLOTS_PER_EPOCH not found in spec")
+	}
+
+	forkSchedule, err := forkScheduleFromSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if forkSchedule == nil {
+		// No forks in the configuration, so use those of the beacon node.
+		forkSchedule, err = parameters.client.(eth2client.ForkScheduleProvider).ForkSchedule(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain fork schedule from beacon node")
+		}
+	}
+
+	log.Trace().Str("network", parameters.network).Str("config_file", parameters.configFile).Int("overrides", len(overrides)).Msg("Overriding chain configuration")
+
+	s := &Service{
+		client:        parameters.client,
+		spec:          spec,
+		forkSchedule:  forkSchedule,
+		slotsPerEpoch: slotsPerEpoch,
+	}
+
+	return s, nil
+}
+
+// Name returns the name of the client implementation.
+func (s *Service) Name() string {
+	return s.client.Name()
+}
+
+// Address returns the address of the beacon node.
+func (s *Service) Address() string {
+	return s.client.Address()
+}
+
+// Spec provides the spec information of the chain.
+func (s *Service) Spec(_ context.Context) (map[string]interface{}, error) {
+	return s.spec, nil
+}
+
+// SlotsPerEpoch provides the slots per epoch of the chain.
+func (s *Service) SlotsPerEpoch(_ context.Context) (uint64, error) {
+	return s.slotsPerEpoch, nil
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (s *Service) ForkSchedule(_ context.Context) ([]*phase0.Fork, error) {
+	return s.forkSchedule, nil
+}
+
+// readConfigFile reads the scalar values from a config.yaml file.  Values
+// are kept as they are written in the file, as YAML would otherwise parse
+// hex values such as fork versions as integers.
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read config file")
+	}
+
+	nodes := make(map[string]yaml.Node)
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, errors.Wrap(err, "failed to parse config file")
+	}
+
+	config := make(map[string]string, len(nodes))
+	for k, node := range nodes {
+		if node.Kind != yaml.ScalarNode {
+			// Only scalar values are part of the spec.
+			continue
+		}
+		config[k] = node.Value
+	}
+
+	return config, nil
+}
+
+// parseConfig parses configuration values in to the types returned by the
+// beacon node's spec.
+func parseConfig(config map[string]string) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(config))
+	for k, v := range config {
+		val, err := parseValue(k, v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for %s", k)
+		}
+		res[k] = val
+	}
+
+	return res, nil
+}
+
+// parseValue parses a single configuration value, following the rules used
+// by the client when parsing the spec from the beacon node.
+func parseValue(key string, value string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(key, "DOMAIN_"):
+		bytes, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid domain type")
+		}
+		var domainType phase0.DomainType
+		copy(domainType[:], bytes)
+		return domainType, nil
+	case strings.HasSuffix(key, "_FORK_VERSION"):
+		bytes, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid fork version")
+		}
+		var version phase0.Version
+		copy(version[:], bytes)
+		return version, nil
+	case strings.HasPrefix(value, "0x"):
+		bytes, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid hex value")
+		}
+		return bytes, nil
+	}
+
+	intVal, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		// Not a number, so a string.
+		return value, nil
+	}
+	switch {
+	case strings.HasSuffix(key, "_TIME") && intVal != 0:
+		return time.Unix(int64(intVal), 0), nil
+	case (strings.HasPrefix(key, "SECONDS_PER_") || key == "GENESIS_DELAY") && intVal != 0:
+		return time.Duration(intVal) * time.Second, nil
+	default:
+		return intVal, nil
+	}
+}
+
+// mergeSpec merges configuration overrides in to the spec from the beacon
+// node.  The beacon node must be on the same chain as the configuration.
+func mergeSpec(nodeSpec map[string]interface{}, overrides map[string]interface{}) (map[string]interface{}, error) {
+	if nodeChainID, exists := nodeSpec["DEPOSIT_CHAIN_ID"]; exists {
+		if chainID, exists := overrides["DEPOSIT_CHAIN_ID"]; exists && chainID != nodeChainID {
+			return nil, errors.Errorf("beacon node is on chain %v but configuration is for chain %v", nodeChainID, chainID)
+		}
+	}
+
+	spec := make(map[string]interface{}, len(nodeSpec)+len(overrides))
+	for k, v := range nodeSpec {
+		spec[k] = v
+	}
+	for k, v := range overrides {
+		spec[k] = v
+	}
+
+	return spec, nil
+}
+
+// forkScheduleFromSpec creates the fork schedule from the fork versions and
+// epochs in the spec.  It returns nil if the spec does not contain a genesis
+// fork version.
+func forkScheduleFromSpec(spec map[string]interface{}) ([]*phase0.Fork, error) {
+	genesisForkVersion, exists := spec["GENESIS_FORK_VERSION"].(phase0.Version)
+	if !exists {
+		return nil, nil
+	}
+
+	forkSchedule := []*phase0.Fork{
+		{
+			PreviousVersion: genesisForkVersion,
+			CurrentVersion:  genesisForkVersion,
+			Epoch:           0,
+		},
+	}
+	for _, fork := range forks {
+		version, exists := spec[fork+"_FORK_VERSION"].(phase0.Version)
+		if !exists {
+			continue
+		}
+		epoch, exists := spec[fork+"_FORK_EPOCH"].(uint64)
+		if !exists {
+			return nil, errors.Errorf("no epoch for fork %s", strings.ToLower(fork))
+		}
+		if phase0.Epoch(epoch) == farFutureEpoch {
+			continue
+		}
+		forkSchedule = append(forkSchedule, &phase0.Fork{
+			PreviousVersion: forkSchedule[len(forkSchedule)-1].CurrentVersion,
+			CurrentVersion:  version,
+			Epoch:           phase0.Epoch(epoch),
+		})
+	}
+
+	return forkSchedule, nil
+}
@@ -0,0 +1,212 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/network"
+)
+
+// specClient is a client that returns a given spec.
+type specClient struct {
+	*mock.Service
+	spec map[string]interface{}
+}
+
+func (c *specClient) Spec(_ context.Context) (map[string]interface{}, error) {
+	return c.spec, nil
+}
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	missingConfigFile := filepath.Join(t.TempDir(), "config.yaml")
+
+	mainnetClient := &specClient{
+		Service: client,
+		spec: map[string]interface{}{
+			"DEPOSIT_CHAIN_ID": uint64(1),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		params []network.Parameter
+		err    string
+	}{
+		{
+			name: "ClientMissing",
+			params: []network.Parameter{
+				network.WithLogLevel(zerolog.Disabled),
+				network.WithNetwork("holesky"),
+			},
+			err: "problem with parameters: no client specified",
+		},
+		{
+			name: "NetworkMissing",
+			params: []network.Parameter{
+				network.WithLogLevel(zerolog.Disabled),
+				network.WithClient(client),
+			},
+			err: "problem with parameters: no network or config file specified",
+		},
+		{
+			name: "NetworkUnknown",
+			params: []network.Parameter{
+				network.WithLogLevel(zerolog.Disabled),
+				network.WithClient(client),
+				network.WithNetwork("unknown"),
+			},
+			err: "problem with parameters: unknown network",
+		},
+		{
+			name: "ConfigFileMissing",
+			params: []network.Parameter{
+				network.WithLogLevel(zerolog.Disabled),
+				network.WithClient(client),
+				network.WithConfigFile(missingConfigFile),
+			},
+			err: "failed to read config file: open " + missingConfigFile + ": no such file or directory",
+		},
+		{
+			name: "ChainMismatch",
+			params: []network.Parameter{
+				network.WithLogLevel(zerolog.Disabled),
+				network.WithClient(mainnetClient),
+				network.WithNetwork("sepolia"),
+			},
+			err: "beacon node is on chain 1 but configuration is for chain 11155111",
+		},
+		{
+			name: "Good",
+			params: []network.Parameter{
+				network.WithLogLevel(zerolog.Disabled),
+				network.WithClient(mainnetClient),
+				network.WithNetwork("mainnet"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := network.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPreset(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	s, err := network.New(ctx,
+		network.WithLogLevel(zerolog.Disabled),
+		network.WithClient(client),
+		network.WithNetwork("gnosis"),
+	)
+	require.NoError(t, err)
+
+	slotsPerEpoch, err := s.SlotsPerEpoch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(16), slotsPerEpoch)
+
+	spec, err := s.Spec(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, spec["SECONDS_PER_SLOT"])
+	require.Equal(t, time.Unix(1638968400, 0), spec["MIN_GENESIS_TIME"])
+	require.Equal(t, uint64(100), spec["DEPOSIT_CHAIN_ID"])
+	require.Equal(t, phase0.Version{0x00, 0x00, 0x00, 0x64}, spec["GENESIS_FORK_VERSION"])
+
+	forkSchedule, err := s.ForkSchedule(ctx)
+	require.NoError(t, err)
+	require.Len(t, forkSchedule, 5)
+	require.Equal(t, phase0.Version{0x03, 0x00, 0x00, 0x64}, forkSchedule[4].PreviousVersion)
+	require.Equal(t, phase0.Version{0x04, 0x00, 0x00, 0x64}, forkSchedule[4].CurrentVersion)
+	require.Equal(t, phase0.Epoch(889856), forkSchedule[4].Epoch)
+
+	require.Contains(t, network.Networks(), "holesky")
+}
+
+func TestConfigFile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+# Devnet configuration.
+CONFIG_NAME: 'devnet'
+PRESET_BASE: 'minimal'
+SECONDS_PER_SLOT: 6
+SLOTS_PER_EPOCH: 8
+GENESIS_FORK_VERSION: 0x10000038
+ALTAIR_FORK_VERSION: 0x20000038
+ALTAIR_FORK_EPOCH: 0
+BELLATRIX_FORK_VERSION: 0x30000038
+BELLATRIX_FORK_EPOCH: 10
+CAPELLA_FORK_VERSION: 0x40000038
+CAPELLA_FORK_EPOCH: 18446744073709551615
+DEPOSIT_CONTRACT_ADDRESS: 0x4242424242424242424242424242424242424242
+BLOB_SCHEDULE:
+  - EPOCH: 0
+`), 0o600))
+
+	s, err := network.New(ctx,
+		network.WithLogLevel(zerolog.Disabled),
+		network.WithClient(client),
+		network.WithConfigFile(configFile),
+	)
+	require.NoError(t, err)
+
+	slotsPerEpoch, err := s.SlotsPerEpoch(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(8), slotsPerEpoch)
+
+	spec, err := s.Spec(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "devnet", spec["CONFIG_NAME"])
+	require.Equal(t, 6*time.Second, spec["SECONDS_PER_SLOT"])
+	require.Len(t, spec["DEPOSIT_CONTRACT_ADDRESS"], 20)
+	require.NotContains(t, spec, "BLOB_SCHEDULE")
+
+	// Capella is not scheduled so is not in the fork schedule.
+	forkSchedule, err := s.ForkSchedule(ctx)
+	require.NoError(t, err)
+	require.Len(t, forkSchedule, 3)
+	require.Equal(t, phase0.Version{0x10, 0x00, 0x00, 0x38}, forkSchedule[0].CurrentVersion)
+	require.Equal(t, phase0.Version{0x30, 0x00, 0x00, 0x38}, forkSchedule[2].CurrentVersion)
+	require.Equal(t, phase0.Epoch(10), forkSchedule[2].Epoch)
+}
@@ -0,0 +1,111 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	address         string
+	headers         map[string]string
+	bearerToken     string
+	username        string
+	password        string
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithAddress sets the address of the beacon node.  This can be a host and
+// port, an HTTP or HTTPS URL optionally containing credentials for basic
+// authentication, or the path to a unix socket in the form unix:///path.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithHeaders sets additional headers sent with each request.
+func WithHeaders(headers map[string]string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headers = headers
+	})
+}
+
+// WithBearerToken sets a token, for example a JWT, sent as a bearer token
+// with each request.
+func WithBearerToken(token string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bearerToken = token
+	})
+}
+
+// WithBasicAuth sets the username and password used for basic
+// authentication.  If supplied they take precedence over any credentials in
+// the address.
+func WithBasicAuth(username string, password string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.username = username
+		p.password = password
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.address == "" {
+		return nil, errors.New("no address specified")
+	}
+	if parameters.bearerToken != "" && parameters.username != "" {
+		return nil, errors.New("cannot use both bearer token and basic authentication")
+	}
+
+	return &parameters, nil
+}
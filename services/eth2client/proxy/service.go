@@ -0,0 +1,165 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service is a local proxy to a beacon node.  It allows connections to beacon
+// nodes that require authentication or are only reachable over a unix socket,
+// which the Ethereum 2 client does not support itself.  The client connects to
+// the proxy, which adds authentication to each request and forwards it to the
+// beacon node.
+type Service struct {
+	address       string
+	listenAddress string
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new proxy, which runs until the context is done.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "eth2client").Str("impl", "proxy").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	target, transport, err := parseAddress(parameters.address)
+	if err != nil {
+		return nil, err
+	}
+	username := parameters.username
+	password := parameters.password
+	if username == "" && target.User != nil {
+		username = target.User.Username()
+		password, _ = target.User.Password()
+	}
+	// Credentials are not shown in the address of the beacon node.
+	address := parameters.address
+	if target.User != nil {
+		address = target.Redacted()
+		target.User = nil
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	// Flush immediately, so that event streams are not held up.
+	proxy.FlushInterval = -1
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Host = target.Host
+		for name, value := range parameters.headers {
+			req.Header.Set(name, value)
+		}
+		if parameters.bearerToken != "" {
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", parameters.bearerToken))
+		}
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		log.Debug().Str("address", address).Str("path", req.URL.Path).Err(err).Msg("Request to beacon node failed")
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to listen")
+	}
+	server := &http.Server{
+		Handler:           proxy,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Str("address", address).Err(err).Msg("Proxy failed")
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			log.Debug().Err(err).Msg("Failed to close proxy")
+		}
+	}()
+
+	log.Trace().Str("address", address).Str("listen_address", listener.Addr().String()).Msg("Started proxy")
+
+	return &Service{
+		address:       address,
+		listenAddress: listener.Addr().String(),
+	}, nil
+}
+
+// Address returns the address of the beacon node, with any credentials removed.
+func (s *Service) Address() string {
+	return s.address
+}
+
+// ListenAddress returns the local address on which the proxy listens.
+func (s *Service) ListenAddress() string {
+	return s.listenAddress
+}
+
+// parseAddress parses the address of a beacon node, returning the URL to
+// which requests are sent and the transport with which to send them.
+func parseAddress(address string) (*url.URL, *http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if strings.HasPrefix(address, "unix://") {
+		path := strings.TrimPrefix(address, "unix://")
+		if path == "" {
+			return nil, nil, errors.New("no path in unix socket address")
+		}
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", path)
+		}
+		// The host is required by HTTP, but is not used to connect.
+		return &url.URL{Scheme: "http", Host: "localhost"}, transport, nil
+	}
+
+	if !strings.HasPrefix(address, "http") {
+		address = fmt.Sprintf("http://%s", address)
+	}
+	target, err := url.Parse(address)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "invalid address")
+	}
+	if target.Host == "" {
+		return nil, nil, errors.New("no host in address")
+	}
+
+	return target, transport, nil
+}
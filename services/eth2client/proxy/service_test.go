@@ -0,0 +1,157 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package proxy_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/proxy"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		params []proxy.Parameter
+		err    string
+	}{
+		{
+			name: "AddressMissing",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no address specified",
+		},
+		{
+			name: "AuthDuplicate",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("localhost:5051"),
+				proxy.WithBearerToken("token"),
+				proxy.WithBasicAuth("user", "secret"),
+			},
+			err: "problem with parameters: cannot use both bearer token and basic authentication",
+		},
+		{
+			name: "UnixPathMissing",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("unix://"),
+			},
+			err: "no path in unix socket address",
+		},
+		{
+			name: "Good",
+			params: []proxy.Parameter{
+				proxy.WithLogLevel(zerolog.Disabled),
+				proxy.WithAddress("localhost:5051"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := proxy.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// echoHandler returns the authorization and API key headers of the request.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "%s %s|%s", r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("X-Api-Key"))
+})
+
+func get(t *testing.T, s *proxy.Service, path string) string {
+	t.Helper()
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", s.ListenAddress(), path))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestHeaders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(echoHandler)
+	defer server.Close()
+
+	s, err := proxy.New(ctx,
+		proxy.WithLogLevel(zerolog.Disabled),
+		proxy.WithAddress(server.URL),
+		proxy.WithBearerToken("token"),
+		proxy.WithHeaders(map[string]string{"X-Api-Key": "key"}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, server.URL, s.Address())
+	require.Equal(t, "/eth/v1/node/version Bearer token|key", get(t, s, "/eth/v1/node/version"))
+}
+
+func TestBasicAuthFromAddress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(echoHandler)
+	defer server.Close()
+
+	address := strings.Replace(server.URL, "http://", "http://user:secret@", 1)
+	s, err := proxy.New(ctx,
+		proxy.WithLogLevel(zerolog.Disabled),
+		proxy.WithAddress(address),
+	)
+	require.NoError(t, err)
+	require.Equal(t, strings.Replace(server.URL, "http://", "http://user:xxxxx@", 1), s.Address())
+	require.Equal(t, "/eth/v1/node/version Basic dXNlcjpzZWNyZXQ=|", get(t, s, "/eth/v1/node/version"))
+}
+
+func TestUnixSocket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), "beacon.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	server := httptest.NewUnstartedServer(echoHandler)
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	s, err := proxy.New(ctx,
+		proxy.WithLogLevel(zerolog.Disabled),
+		proxy.WithAddress(fmt.Sprintf("unix://%s", path)),
+	)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("unix://%s", path), s.Address())
+	require.Equal(t, "/eth/v1/node/version |", get(t, s, "/eth/v1/node/version"))
+}
@@ -0,0 +1,133 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimited
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/util"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	client          eth2client.Service
+	address         string
+	rateLimiter     *util.RateLimiter
+	timeout         time.Duration
+	timeouts        map[string]time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithClient sets the client for the beacon node.
+func WithClient(client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.client = client
+	})
+}
+
+// WithAddress sets the address reported for the beacon node, if it differs
+// from the address of the client, for example when the client connects to the
+// beacon node through a proxy.
+func WithAddress(address string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.address = address
+	})
+}
+
+// WithRateLimiter sets the rate limiter for requests.  The same rate limiter
+// can be shared between clients to limit their combined rate of requests.
+func WithRateLimiter(rateLimiter *util.RateLimiter) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.rateLimiter = rateLimiter
+	})
+}
+
+// WithTimeout sets the timeout for requests that do not have their own timeout
+// set with WithTimeouts.  0 leaves requests without a timeout.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// WithTimeouts sets timeouts for types of request, keyed by request type, for
+// example "validators".  Request types that are not present use the timeout
+// set by WithTimeout.
+func WithTimeouts(timeouts map[string]time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeouts = timeouts
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.client == nil {
+		return nil, errors.New("no client specified")
+	}
+	if parameters.rateLimiter == nil {
+		return nil, errors.New("no rate limiter specified")
+	}
+	if parameters.timeout < 0 {
+		return nil, errors.New("timeout cannot be negative")
+	}
+	for requestType, timeout := range parameters.timeouts {
+		if _, exists := requestTypes[requestType]; !exists {
+			return nil, fmt.Errorf("unknown request type %q", requestType)
+		}
+		if timeout < 0 {
+			return nil, fmt.Errorf("timeout for %s cannot be negative", requestType)
+		}
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,286 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimited
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// BeaconCommittees fetches the chain's beacon committees given a state.
+func (s *Service) BeaconCommittees(ctx context.Context, stateID string) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconCommitteesProvider")
+	}
+	ctx, done, err := s.begin(ctx, "beacon committees")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.BeaconCommittees(ctx, stateID)
+	return res, done(err)
+}
+
+// BeaconCommitteesAtEpoch fetches the chain's beacon committees given a state at the given epoch.
+func (s *Service) BeaconCommitteesAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconCommitteesProvider")
+	}
+	ctx, done, err := s.begin(ctx, "beacon committees at epoch")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.BeaconCommitteesAtEpoch(ctx, stateID, epoch)
+	return res, done(err)
+}
+
+// BeaconState fetches a beacon state given a state ID.
+func (s *Service) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	provider, isProvider := s.client.(eth2client.BeaconStateProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconStateProvider")
+	}
+	ctx, done, err := s.begin(ctx, "beacon state")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.BeaconState(ctx, stateID)
+	return res, done(err)
+}
+
+// BeaconStateRoot fetches a beacon state root given a state ID.
+func (s *Service) BeaconStateRoot(ctx context.Context, stateID string) (*phase0.Root, error) {
+	provider, isProvider := s.client.(eth2client.BeaconStateRootProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconStateRootProvider")
+	}
+	ctx, done, err := s.begin(ctx, "beacon state root")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.BeaconStateRoot(ctx, stateID)
+	return res, done(err)
+}
+
+// Events feeds requested events with the given topics to the supplied handler.
+func (s *Service) Events(ctx context.Context, topics []string, handler eth2client.EventHandlerFunc) error {
+	provider, isProvider := s.client.(eth2client.EventsProvider)
+	if !isProvider {
+		return errors.New("client is not an EventsProvider")
+	}
+	return provider.Events(ctx, topics, handler)
+}
+
+// Finality provides the finality given a state ID.
+func (s *Service) Finality(ctx context.Context, stateID string) (*apiv1.Finality, error) {
+	provider, isProvider := s.client.(eth2client.FinalityProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a FinalityProvider")
+	}
+	ctx, done, err := s.begin(ctx, "finality")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.Finality(ctx, stateID)
+	return res, done(err)
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (s *Service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	provider, isProvider := s.client.(eth2client.ForkScheduleProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ForkScheduleProvider")
+	}
+	ctx, done, err := s.begin(ctx, "fork schedule")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.ForkSchedule(ctx)
+	return res, done(err)
+}
+
+// Genesis provides the genesis information of the chain.
+func (s *Service) Genesis(ctx context.Context) (*apiv1.Genesis, error) {
+	provider, isProvider := s.client.(eth2client.GenesisProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a GenesisProvider")
+	}
+	ctx, done, err := s.begin(ctx, "genesis")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.Genesis(ctx)
+	return res, done(err)
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (s *Service) GenesisTime(ctx context.Context) (time.Time, error) {
+	provider, isProvider := s.client.(eth2client.GenesisTimeProvider)
+	if !isProvider {
+		return time.Time{}, errors.New("client is not a GenesisTimeProvider")
+	}
+	ctx, done, err := s.begin(ctx, "genesis time")
+	if err != nil {
+		return time.Time{}, err
+	}
+	res, err := provider.GenesisTime(ctx)
+	return res, done(err)
+}
+
+// NodeSyncing provides the state of the active beacon node's synchronization with the chain.
+func (s *Service) NodeSyncing(ctx context.Context) (*apiv1.SyncState, error) {
+	provider, isProvider := s.client.(eth2client.NodeSyncingProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a NodeSyncingProvider")
+	}
+	ctx, done, err := s.begin(ctx, "node syncing")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.NodeSyncing(ctx)
+	return res, done(err)
+}
+
+// NodeVersion returns a free-text string with the node version.
+func (s *Service) NodeVersion(ctx context.Context) (string, error) {
+	provider, isProvider := s.client.(eth2client.NodeVersionProvider)
+	if !isProvider {
+		return "", errors.New("client is not a NodeVersionProvider")
+	}
+	ctx, done, err := s.begin(ctx, "node version")
+	if err != nil {
+		return "", err
+	}
+	res, err := provider.NodeVersion(ctx)
+	return res, done(err)
+}
+
+// ProposerDuties obtains proposer duties for the given epoch.
+func (s *Service) ProposerDuties(ctx context.Context, epoch phase0.Epoch, validatorIndices []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	provider, isProvider := s.client.(eth2client.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ProposerDutiesProvider")
+	}
+	ctx, done, err := s.begin(ctx, "proposer duties")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.ProposerDuties(ctx, epoch, validatorIndices)
+	return res, done(err)
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (s *Service) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	provider, isProvider := s.client.(eth2client.SignedBeaconBlockProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SignedBeaconBlockProvider")
+	}
+	ctx, done, err := s.begin(ctx, "signed beacon block")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.SignedBeaconBlock(ctx, blockID)
+	return res, done(err)
+}
+
+// SlotsPerEpoch provides the slots per epoch of the chain.
+func (s *Service) SlotsPerEpoch(ctx context.Context) (uint64, error) {
+	provider, isProvider := s.client.(eth2client.SlotsPerEpochProvider)
+	if !isProvider {
+		return 0, errors.New("client is not a SlotsPerEpochProvider")
+	}
+	ctx, done, err := s.begin(ctx, "slots per epoch")
+	if err != nil {
+		return 0, err
+	}
+	res, err := provider.SlotsPerEpoch(ctx)
+	return res, done(err)
+}
+
+// Spec provides the spec information of the chain.
+func (s *Service) Spec(ctx context.Context) (map[string]interface{}, error) {
+	provider, isProvider := s.client.(eth2client.SpecProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SpecProvider")
+	}
+	ctx, done, err := s.begin(ctx, "spec")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.Spec(ctx)
+	return res, done(err)
+}
+
+// SyncCommittee fetches the sync committee for the given state.
+func (s *Service) SyncCommittee(ctx context.Context, stateID string) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SyncCommitteesProvider")
+	}
+	ctx, done, err := s.begin(ctx, "sync committee")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.SyncCommittee(ctx, stateID)
+	return res, done(err)
+}
+
+// SyncCommitteeAtEpoch fetches the sync committee for the given epoch at the given state.
+func (s *Service) SyncCommitteeAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SyncCommitteesProvider")
+	}
+	ctx, done, err := s.begin(ctx, "sync committee at epoch")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.SyncCommitteeAtEpoch(ctx, stateID, epoch)
+	return res, done(err)
+}
+
+// Validators provides the validators, with their balance and status, for a given state.
+func (s *Service) Validators(ctx context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ValidatorsProvider")
+	}
+	ctx, done, err := s.begin(ctx, "validators")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.Validators(ctx, stateID, validatorIndices)
+	return res, done(err)
+}
+
+// ValidatorsByPubKey provides the validators, with their balance and status, for a given state.
+func (s *Service) ValidatorsByPubKey(ctx context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ValidatorsProvider")
+	}
+	ctx, done, err := s.begin(ctx, "validators by public key")
+	if err != nil {
+		return nil, err
+	}
+	res, err := provider.ValidatorsByPubKey(ctx, stateID, validatorPubKeys)
+	return res, done(err)
+}
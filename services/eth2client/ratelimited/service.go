@@ -0,0 +1,155 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimited
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Service is an Ethereum 2 client that limits the rate of requests made to a
+// beacon node, and the time that each request can take.  Event subscriptions
+// are long-lived, so are not limited.
+type Service struct {
+	client      eth2client.Service
+	address     string
+	rateLimiter *util.RateLimiter
+	timeout     time.Duration
+	timeouts    map[string]time.Duration
+}
+
+// requestTypes are the types of request for which timeouts can be set, keyed
+// by the operations that are of that type.
+var requestTypes = map[string][]string{
+	"blocks":            {"signed beacon block"},
+	"beacon-committees": {"beacon committees", "beacon committees at epoch"},
+	"beacon-states":     {"beacon state"},
+	"proposer-duties":   {"proposer duties"},
+	"sync-committees":   {"sync committee", "sync committee at epoch"},
+	"validators":        {"validators", "validators by public key"},
+}
+
+// RequestTypes returns the types of request for which timeouts can be set.
+func RequestTypes() []string {
+	res := make([]string, 0, len(requestTypes))
+	for requestType := range requestTypes {
+		res = append(res, requestType)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/eth2client/ratelimited")
+
+// New creates a new rate-limited client.
+func New(_ context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "eth2client").Str("impl", "ratelimited").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	// Index the timeouts by operation.
+	timeouts := make(map[string]time.Duration)
+	for requestType, timeout := range parameters.timeouts {
+		for _, operation := range requestTypes[requestType] {
+			timeouts[operation] = timeout
+		}
+	}
+
+	address := parameters.address
+	if address == "" {
+		address = parameters.client.Address()
+	}
+
+	s := &Service{
+		client:      parameters.client,
+		address:     address,
+		rateLimiter: parameters.rateLimiter,
+		timeout:     parameters.timeout,
+		timeouts:    timeouts,
+	}
+
+	return s, nil
+}
+
+// Name returns the name of the client implementation.
+func (s *Service) Name() string {
+	return s.client.Name()
+}
+
+// Address returns the address of the beacon node.
+func (s *Service) Address() string {
+	return s.address
+}
+
+// begin waits until the rate limiter allows a request to be made, and starts a
+// span for the request.  The returned context has the deadline for the request.
+// The returned function ends the span, recording the error of the request if
+// there is one, and returns the error.
+func (s *Service) begin(ctx context.Context, operation string) (context.Context, func(error) error, error) {
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("eth2client %s", operation),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("address", s.address)),
+	)
+	end := func(err error) error {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		return err
+	}
+
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		log.Trace().Str("address", s.address).Str("operation", operation).Err(err).Msg("Context done while waiting to make request")
+		return nil, nil, end(errors.Wrap(err, "failed waiting for rate limiter"))
+	}
+
+	// The timeout starts once the request is allowed, so that time waiting for
+	// the rate limiter does not count against it.
+	timeout, exists := s.timeouts[operation]
+	if !exists {
+		timeout = s.timeout
+	}
+	if timeout <= 0 {
+		return ctx, end, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func(err error) error {
+		cancel()
+		return end(err)
+	}, nil
+}
@@ -0,0 +1,159 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimited_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/ratelimited"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []ratelimited.Parameter
+		err    string
+	}{
+		{
+			name: "ClientMissing",
+			params: []ratelimited.Parameter{
+				ratelimited.WithLogLevel(zerolog.Disabled),
+				ratelimited.WithRateLimiter(util.NewRateLimiter(1, 1)),
+			},
+			err: "problem with parameters: no client specified",
+		},
+		{
+			name: "RateLimiterMissing",
+			params: []ratelimited.Parameter{
+				ratelimited.WithLogLevel(zerolog.Disabled),
+				ratelimited.WithClient(client),
+			},
+			err: "problem with parameters: no rate limiter specified",
+		},
+		{
+			name: "TimeoutNegative",
+			params: []ratelimited.Parameter{
+				ratelimited.WithLogLevel(zerolog.Disabled),
+				ratelimited.WithClient(client),
+				ratelimited.WithRateLimiter(util.NewRateLimiter(1, 1)),
+				ratelimited.WithTimeout(-1),
+			},
+			err: "problem with parameters: timeout cannot be negative",
+		},
+		{
+			name: "TimeoutsUnknown",
+			params: []ratelimited.Parameter{
+				ratelimited.WithLogLevel(zerolog.Disabled),
+				ratelimited.WithClient(client),
+				ratelimited.WithRateLimiter(util.NewRateLimiter(1, 1)),
+				ratelimited.WithTimeouts(map[string]time.Duration{"unknown": time.Second}),
+			},
+			err: `problem with parameters: unknown request type "unknown"`,
+		},
+		{
+			name: "Good",
+			params: []ratelimited.Parameter{
+				ratelimited.WithLogLevel(zerolog.Disabled),
+				ratelimited.WithClient(client),
+				ratelimited.WithRateLimiter(util.NewRateLimiter(1, 1)),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ratelimited.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	s, err := ratelimited.New(ctx,
+		ratelimited.WithLogLevel(zerolog.Disabled),
+		ratelimited.WithClient(client),
+		ratelimited.WithRateLimiter(util.NewRateLimiter(0.1, 1)),
+	)
+	require.NoError(t, err)
+	require.Equal(t, client.Name(), s.Name())
+
+	_, err = s.Genesis(ctx)
+	require.NoError(t, err)
+
+	// The second request has to wait for the rate limiter.
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	_, err = s.Genesis(shortCtx)
+	require.EqualError(t, err, "failed waiting for rate limiter: context deadline exceeded")
+}
+
+// slowClient is a client whose validators requests do not return until their
+// context is done.
+type slowClient struct{}
+
+func (*slowClient) Name() string    { return "slow" }
+func (*slowClient) Address() string { return "slow" }
+
+func (*slowClient) Validators(ctx context.Context, _ string, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (*slowClient) ValidatorsByPubKey(ctx context.Context, _ string, _ []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTimeouts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := ratelimited.New(ctx,
+		ratelimited.WithLogLevel(zerolog.Disabled),
+		ratelimited.WithClient(&slowClient{}),
+		ratelimited.WithRateLimiter(util.NewRateLimiter(0, 1)),
+		ratelimited.WithTimeout(time.Hour),
+		ratelimited.WithTimeouts(map[string]time.Duration{"validators": 10 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	started := time.Now()
+	_, err = s.Validators(ctx, "head", nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(started), time.Minute)
+}
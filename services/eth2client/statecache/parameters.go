@@ -0,0 +1,107 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statecache
+
+import (
+	"errors"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	client          eth2client.Service
+	size            int
+	prefetch        bool
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithClient sets the client for the beacon node.
+func WithClient(client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.client = client
+	})
+}
+
+// WithSize sets the number of responses held in the cache.
+func WithSize(size int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.size = size
+	})
+}
+
+// WithPrefetch sets whether a request for beacon committees, proposer duties or
+// validators at the start of an epoch also fetches the others for the epoch.
+func WithPrefetch(prefetch bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.prefetch = prefetch
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		size:     1,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.client == nil {
+		return nil, errors.New("no client specified")
+	}
+	if parameters.size < 1 {
+		return nil, errors.New("size must be at least 1")
+	}
+	if parameters.prefetch {
+		if parameters.size < prefetchedTypes {
+			return nil, errors.New("size must be at least 3 to prefetch")
+		}
+		if _, isProvider := parameters.client.(eth2client.SlotsPerEpochProvider); !isProvider {
+			return nil, errors.New("client is not a SlotsPerEpochProvider")
+		}
+	}
+
+	return &parameters, nil
+}
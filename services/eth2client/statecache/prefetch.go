@@ -0,0 +1,170 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statecache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// Request types held in the cache.
+const (
+	beaconCommitteesType = "beacon committees"
+	proposerDutiesType   = "proposer duties"
+	validatorsType       = "validators"
+)
+
+// prefetchedTypes is the number of request types fetched for an epoch when prefetching.
+const prefetchedTypes = 3
+
+// maxPrefetchedEpochs is the number of recently prefetched epochs remembered,
+// so that an epoch is not prefetched again once its entries have been evicted.
+const maxPrefetchedEpochs = 64
+
+// epochStart returns the epoch of the state with the given ID if it is the
+// first slot of an epoch.
+func (s *Service) epochStart(stateID string) (phase0.Epoch, bool) {
+	if !s.prefetch {
+		return 0, false
+	}
+	slot, err := strconv.ParseUint(stateID, 10, 64)
+	if err != nil || slot%s.slotsPerEpoch != 0 {
+		return 0, false
+	}
+
+	return phase0.Epoch(slot / s.slotsPerEpoch), true
+}
+
+// prefetchEpoch fetches the beacon committees, proposer duties and validators
+// for the start of an epoch other than the request type that triggered it.
+// They are independent reads, so are fetched concurrently with each other and
+// with the triggering request, and held for the modules that request them.
+func (s *Service) prefetchEpoch(epoch phase0.Epoch, requestType string) {
+	if !s.prefetch || !s.markPrefetched(epoch) {
+		return
+	}
+
+	stateID := fmt.Sprintf("%d", uint64(epoch)*s.slotsPerEpoch)
+	log.Trace().Uint64("epoch", uint64(epoch)).Str("type", requestType).Msg("Prefetching epoch")
+	if requestType != beaconCommitteesType {
+		if provider, isProvider := s.client.(eth2client.BeaconCommitteesProvider); isProvider {
+			go s.logPrefetch(epoch, beaconCommitteesType, func() error {
+				_, err := s.fetchBeaconCommittees(s.ctx, provider, stateID)
+				return err
+			})
+		}
+	}
+	if requestType != proposerDutiesType {
+		if provider, isProvider := s.client.(eth2client.ProposerDutiesProvider); isProvider {
+			go s.logPrefetch(epoch, proposerDutiesType, func() error {
+				_, err := s.fetchProposerDuties(s.ctx, provider, epoch)
+				return err
+			})
+		}
+	}
+	if requestType != validatorsType {
+		if provider, isProvider := s.client.(eth2client.ValidatorsProvider); isProvider {
+			go s.logPrefetch(epoch, validatorsType, func() error {
+				_, err := s.fetchValidators(s.ctx, provider, stateID)
+				return err
+			})
+		}
+	}
+}
+
+// logPrefetch runs a prefetch, logging if it fails.  A failed prefetch is not
+// cached, so the module that requests the data fetches it again.
+func (*Service) logPrefetch(epoch phase0.Epoch, requestType string, prefetch func() error) {
+	if err := prefetch(); err != nil {
+		log.Debug().Uint64("epoch", uint64(epoch)).Str("type", requestType).Err(err).Msg("Failed to prefetch")
+	}
+}
+
+// markPrefetched marks an epoch as prefetched, returning false if it already was.
+func (s *Service) markPrefetched(epoch phase0.Epoch) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, prefetched := range s.prefetched {
+		if prefetched == epoch {
+			return false
+		}
+	}
+	s.prefetched = append(s.prefetched, epoch)
+	if len(s.prefetched) > maxPrefetchedEpochs {
+		s.prefetched = s.prefetched[1:]
+	}
+
+	return true
+}
+
+// fetchBeaconCommittees fetches the beacon committees for a state through the cache.
+func (s *Service) fetchBeaconCommittees(ctx context.Context,
+	provider eth2client.BeaconCommitteesProvider,
+	stateID string,
+) (
+	[]*apiv1.BeaconCommittee,
+	error,
+) {
+	res, err := s.fetch(ctx, beaconCommitteesType, stateID, func(ctx context.Context) (interface{}, error) {
+		return provider.BeaconCommittees(ctx, stateID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]*apiv1.BeaconCommittee), nil
+}
+
+// fetchProposerDuties fetches the proposer duties for an epoch through the cache.
+func (s *Service) fetchProposerDuties(ctx context.Context,
+	provider eth2client.ProposerDutiesProvider,
+	epoch phase0.Epoch,
+) (
+	[]*apiv1.ProposerDuty,
+	error,
+) {
+	// Proposer duties are keyed by epoch rather than state, so cannot clash with other state IDs.
+	res, err := s.fetch(ctx, proposerDutiesType, fmt.Sprintf("%d", epoch), func(ctx context.Context) (interface{}, error) {
+		return provider.ProposerDuties(ctx, epoch, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.([]*apiv1.ProposerDuty), nil
+}
+
+// fetchValidators fetches the full validator set for a state through the cache.
+func (s *Service) fetchValidators(ctx context.Context,
+	provider eth2client.ValidatorsProvider,
+	stateID string,
+) (
+	map[phase0.ValidatorIndex]*apiv1.Validator,
+	error,
+) {
+	res, err := s.fetch(ctx, validatorsType, stateID, func(ctx context.Context) (interface{}, error) {
+		return provider.Validators(ctx, stateID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.(map[phase0.ValidatorIndex]*apiv1.Validator), nil
+}
@@ -0,0 +1,227 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statecache
+
+import (
+	"context"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// BeaconCommittees fetches the chain's beacon committees given a state.
+func (s *Service) BeaconCommittees(ctx context.Context, stateID string) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconCommitteesProvider")
+	}
+	if epoch, isEpochStart := s.epochStart(stateID); isEpochStart {
+		s.prefetchEpoch(epoch, beaconCommitteesType)
+	}
+
+	return s.fetchBeaconCommittees(ctx, provider, stateID)
+}
+
+// BeaconCommitteesAtEpoch fetches the chain's beacon committees given a state at the given epoch.
+func (s *Service) BeaconCommitteesAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) ([]*apiv1.BeaconCommittee, error) {
+	provider, isProvider := s.client.(eth2client.BeaconCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconCommitteesProvider")
+	}
+	return provider.BeaconCommitteesAtEpoch(ctx, stateID, epoch)
+}
+
+// BeaconState fetches a beacon state given a state ID.
+// Beacon states are too large to hold in memory, so are always fetched.
+func (s *Service) BeaconState(ctx context.Context, stateID string) (*spec.VersionedBeaconState, error) {
+	provider, isProvider := s.client.(eth2client.BeaconStateProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconStateProvider")
+	}
+	return provider.BeaconState(ctx, stateID)
+}
+
+// BeaconStateRoot fetches a beacon state root given a state ID.
+func (s *Service) BeaconStateRoot(ctx context.Context, stateID string) (*phase0.Root, error) {
+	provider, isProvider := s.client.(eth2client.BeaconStateRootProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a BeaconStateRootProvider")
+	}
+	return provider.BeaconStateRoot(ctx, stateID)
+}
+
+// Events feeds requested events with the given topics to the supplied handler.
+func (s *Service) Events(ctx context.Context, topics []string, handler eth2client.EventHandlerFunc) error {
+	provider, isProvider := s.client.(eth2client.EventsProvider)
+	if !isProvider {
+		return errors.New("client is not an EventsProvider")
+	}
+	return provider.Events(ctx, topics, handler)
+}
+
+// Finality provides the finality given a state ID.
+func (s *Service) Finality(ctx context.Context, stateID string) (*apiv1.Finality, error) {
+	provider, isProvider := s.client.(eth2client.FinalityProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a FinalityProvider")
+	}
+	return provider.Finality(ctx, stateID)
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (s *Service) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	provider, isProvider := s.client.(eth2client.ForkScheduleProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ForkScheduleProvider")
+	}
+	return provider.ForkSchedule(ctx)
+}
+
+// Genesis provides the genesis information of the chain.
+func (s *Service) Genesis(ctx context.Context) (*apiv1.Genesis, error) {
+	provider, isProvider := s.client.(eth2client.GenesisProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a GenesisProvider")
+	}
+	return provider.Genesis(ctx)
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (s *Service) GenesisTime(ctx context.Context) (time.Time, error) {
+	provider, isProvider := s.client.(eth2client.GenesisTimeProvider)
+	if !isProvider {
+		return time.Time{}, errors.New("client is not a GenesisTimeProvider")
+	}
+	return provider.GenesisTime(ctx)
+}
+
+// NodeSyncing provides the state of the active beacon node's synchronization with the chain.
+func (s *Service) NodeSyncing(ctx context.Context) (*apiv1.SyncState, error) {
+	provider, isProvider := s.client.(eth2client.NodeSyncingProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a NodeSyncingProvider")
+	}
+	return provider.NodeSyncing(ctx)
+}
+
+// NodeVersion returns a free-text string with the node version.
+func (s *Service) NodeVersion(ctx context.Context) (string, error) {
+	provider, isProvider := s.client.(eth2client.NodeVersionProvider)
+	if !isProvider {
+		return "", errors.New("client is not a NodeVersionProvider")
+	}
+	return provider.NodeVersion(ctx)
+}
+
+// ProposerDuties obtains proposer duties for the given epoch.
+func (s *Service) ProposerDuties(ctx context.Context, epoch phase0.Epoch, validatorIndices []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	provider, isProvider := s.client.(eth2client.ProposerDutiesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ProposerDutiesProvider")
+	}
+	if len(validatorIndices) > 0 {
+		return provider.ProposerDuties(ctx, epoch, validatorIndices)
+	}
+	s.prefetchEpoch(epoch, proposerDutiesType)
+
+	return s.fetchProposerDuties(ctx, provider, epoch)
+}
+
+// SignedBeaconBlock fetches a signed beacon block given a block ID.
+func (s *Service) SignedBeaconBlock(ctx context.Context, blockID string) (*spec.VersionedSignedBeaconBlock, error) {
+	provider, isProvider := s.client.(eth2client.SignedBeaconBlockProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SignedBeaconBlockProvider")
+	}
+	return provider.SignedBeaconBlock(ctx, blockID)
+}
+
+// SlotsPerEpoch provides the slots per epoch of the chain.
+func (s *Service) SlotsPerEpoch(ctx context.Context) (uint64, error) {
+	provider, isProvider := s.client.(eth2client.SlotsPerEpochProvider)
+	if !isProvider {
+		return 0, errors.New("client is not a SlotsPerEpochProvider")
+	}
+	return provider.SlotsPerEpoch(ctx)
+}
+
+// Spec provides the spec information of the chain.
+func (s *Service) Spec(ctx context.Context) (map[string]interface{}, error) {
+	provider, isProvider := s.client.(eth2client.SpecProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SpecProvider")
+	}
+	return provider.Spec(ctx)
+}
+
+// SyncCommittee fetches the sync committee for the given state.
+func (s *Service) SyncCommittee(ctx context.Context, stateID string) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SyncCommitteesProvider")
+	}
+	return provider.SyncCommittee(ctx, stateID)
+}
+
+// SyncCommitteeAtEpoch fetches the sync committee for the given epoch at the given state.
+func (s *Service) SyncCommitteeAtEpoch(ctx context.Context, stateID string, epoch phase0.Epoch) (*apiv1.SyncCommittee, error) {
+	provider, isProvider := s.client.(eth2client.SyncCommitteesProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a SyncCommitteesProvider")
+	}
+	return provider.SyncCommitteeAtEpoch(ctx, stateID, epoch)
+}
+
+// Validators provides the validators, with their balance and status, for a given state.
+// Requests for a subset of validators are served from the full validator set if it
+// is already held, but do not cause the full validator set to be fetched.
+func (s *Service) Validators(ctx context.Context, stateID string, validatorIndices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ValidatorsProvider")
+	}
+	if len(validatorIndices) > 0 {
+		cached, exists := s.cached(validatorsType, stateID)
+		if !exists {
+			return provider.Validators(ctx, stateID, validatorIndices)
+		}
+		validators := cached.(map[phase0.ValidatorIndex]*apiv1.Validator)
+		res := make(map[phase0.ValidatorIndex]*apiv1.Validator, len(validatorIndices))
+		for _, index := range validatorIndices {
+			if validator, exists := validators[index]; exists {
+				res[index] = validator
+			}
+		}
+		return res, nil
+	}
+
+	if epoch, isEpochStart := s.epochStart(stateID); isEpochStart {
+		s.prefetchEpoch(epoch, validatorsType)
+	}
+
+	return s.fetchValidators(ctx, provider, stateID)
+}
+
+// ValidatorsByPubKey provides the validators, with their balance and status, for a given state.
+func (s *Service) ValidatorsByPubKey(ctx context.Context, stateID string, validatorPubKeys []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	provider, isProvider := s.client.(eth2client.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("client is not a ValidatorsProvider")
+	}
+	return provider.ValidatorsByPubKey(ctx, stateID, validatorPubKeys)
+}
@@ -0,0 +1,236 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statecache
+
+import (
+	"context"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+)
+
+// Service is an Ethereum 2 client that holds responses derived from beacon
+// states in memory, keyed by the ID of the state, so that modules requesting
+// the same data for the same state share a single request to the beacon node.
+// Concurrent requests for the same data wait for the first request rather than
+// making their own.  Keying by the state ID that modules already supply avoids
+// an additional request to the beacon node to resolve it, at the cost that a
+// response for a slot replaced by a chain reorganisation can be returned until
+// it is evicted, so the cache should be kept small.
+type Service struct {
+	// ctx is the context for prefetches, which outlive the request that started them.
+	ctx           context.Context
+	client        eth2client.Service
+	size          int
+	prefetch      bool
+	slotsPerEpoch uint64
+
+	// mu protects the entries and their order.
+	mu      sync.Mutex
+	entries map[cacheKey]*entry
+	// order is the order in which entries were last used, least recent first.
+	order []cacheKey
+	// prefetched are the epochs most recently prefetched, least recent first.
+	prefetched []phase0.Epoch
+}
+
+// cacheKey is the key for a cached response.
+type cacheKey struct {
+	requestType string
+	stateID     string
+}
+
+// entry is a cached response, or one that is being fetched.
+type entry struct {
+	// done is closed once the response has been fetched.
+	done chan struct{}
+	res  interface{}
+	err  error
+}
+
+// namedStates are state IDs that refer to a moving state, so cannot be cached.
+var namedStates = map[string]bool{
+	"head":      true,
+	"finalized": true,
+	"justified": true,
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new caching client.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "eth2client").Str("impl", "statecache").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	s := &Service{
+		ctx:      ctx,
+		client:   parameters.client,
+		size:     parameters.size,
+		prefetch: parameters.prefetch,
+		entries:  make(map[cacheKey]*entry),
+		order:    make([]cacheKey, 0, parameters.size),
+	}
+
+	if s.prefetch {
+		s.slotsPerEpoch, err = parameters.client.(eth2client.SlotsPerEpochProvider).SlotsPerEpoch(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain slots per epoch")
+		}
+	}
+
+	return s, nil
+}
+
+// Name returns the name of the client implementation.
+func (s *Service) Name() string {
+	return s.client.Name()
+}
+
+// Address returns the address of the beacon node.
+func (s *Service) Address() string {
+	return s.client.Address()
+}
+
+// cacheable returns true if responses for the state with the given ID can be cached.
+func cacheable(stateID string) bool {
+	return !namedStates[stateID]
+}
+
+// fetch returns the response of the given type for the given state, calling
+// fetcher to obtain it if it is not already cached.
+func (s *Service) fetch(ctx context.Context,
+	requestType string,
+	stateID string,
+	fetcher func(ctx context.Context) (interface{}, error),
+) (
+	interface{},
+	error,
+) {
+	if !cacheable(stateID) {
+		return fetcher(ctx)
+	}
+	key := cacheKey{requestType: requestType, stateID: stateID}
+
+	s.mu.Lock()
+	e, exists := s.entries[key]
+	if exists {
+		s.touch(key)
+		s.mu.Unlock()
+		select {
+		case <-e.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if e.err != nil {
+			// The request being waited on failed; make this request separately,
+			// as its failure could have been specific to its context.
+			return fetcher(ctx)
+		}
+		log.Trace().Str("type", requestType).Str("state_id", stateID).Msg("Obtained cached response")
+		return e.res, nil
+	}
+	e = &entry{
+		done: make(chan struct{}),
+	}
+	s.entries[key] = e
+	s.order = append(s.order, key)
+	s.evict()
+	s.mu.Unlock()
+
+	e.res, e.err = fetcher(ctx)
+	close(e.done)
+	if e.err != nil {
+		s.mu.Lock()
+		if s.entries[key] == e {
+			s.remove(key)
+		}
+		s.mu.Unlock()
+	}
+
+	return e.res, e.err
+}
+
+// cached returns the response of the given type for the given state if it has
+// already been fetched, without fetching it.
+func (s *Service) cached(requestType string, stateID string) (interface{}, bool) {
+	if !cacheable(stateID) {
+		return nil, false
+	}
+	key := cacheKey{requestType: requestType, stateID: stateID}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, exists := s.entries[key]
+	if !exists {
+		return nil, false
+	}
+	select {
+	case <-e.done:
+	default:
+		return nil, false
+	}
+	if e.err != nil {
+		return nil, false
+	}
+	s.touch(key)
+
+	return e.res, true
+}
+
+// touch marks an entry as the most recently used.
+// This assumes the lock is held.
+func (s *Service) touch(key cacheKey) {
+	for i := range s.order {
+		if s.order[i] == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	s.order = append(s.order, key)
+}
+
+// remove removes an entry.
+// This assumes the lock is held.
+func (s *Service) remove(key cacheKey) {
+	delete(s.entries, key)
+	for i := range s.order {
+		if s.order[i] == key {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// evict removes the least recently used entries until the cache is within its size.
+// Requests waiting on an evicted entry still receive its response.
+// This assumes the lock is held.
+func (s *Service) evict() {
+	for len(s.order) > s.size {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+}
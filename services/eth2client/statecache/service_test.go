@@ -0,0 +1,243 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statecache_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/eth2client/statecache"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []statecache.Parameter
+		err    string
+	}{
+		{
+			name: "ClientMissing",
+			params: []statecache.Parameter{
+				statecache.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no client specified",
+		},
+		{
+			name: "SizeZero",
+			params: []statecache.Parameter{
+				statecache.WithLogLevel(zerolog.Disabled),
+				statecache.WithClient(client),
+				statecache.WithSize(0),
+			},
+			err: "problem with parameters: size must be at least 1",
+		},
+		{
+			name: "Good",
+			params: []statecache.Parameter{
+				statecache.WithLogLevel(zerolog.Disabled),
+				statecache.WithClient(client),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := statecache.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+// countingClient counts the requests made to it.
+type countingClient struct {
+	*mock.Service
+	requests int32
+}
+
+func (c *countingClient) BeaconStateRoot(_ context.Context, _ string) (*phase0.Root, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return &phase0.Root{}, nil
+}
+
+func (c *countingClient) BeaconCommittees(_ context.Context, _ string) ([]*apiv1.BeaconCommittee, error) {
+	atomic.AddInt32(&c.requests, 1)
+	// Slow enough for concurrent requests to overlap.
+	time.Sleep(10 * time.Millisecond)
+	return []*apiv1.BeaconCommittee{
+		{Slot: 64, Index: 0, Validators: []phase0.ValidatorIndex{1, 2}},
+	}, nil
+}
+
+func (c *countingClient) ProposerDuties(_ context.Context, epoch phase0.Epoch, _ []phase0.ValidatorIndex) ([]*apiv1.ProposerDuty, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return []*apiv1.ProposerDuty{
+		{Slot: phase0.Slot(uint64(epoch) * 32), ValidatorIndex: 1},
+	}, nil
+}
+
+func (c *countingClient) Validators(_ context.Context, _ string, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*apiv1.Validator, error) {
+	atomic.AddInt32(&c.requests, 1)
+	return map[phase0.ValidatorIndex]*apiv1.Validator{
+		1: {Index: 1, Balance: 32000000000},
+		2: {Index: 2, Balance: 31000000000},
+	}, nil
+}
+
+func TestCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClient, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+	client := &countingClient{Service: mockClient}
+
+	s, err := statecache.New(ctx,
+		statecache.WithLogLevel(zerolog.Disabled),
+		statecache.WithClient(client),
+		statecache.WithSize(2),
+	)
+	require.NoError(t, err)
+
+	// Concurrent requests for the same state share a single request.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			committees, err := s.BeaconCommittees(ctx, "64")
+			require.NoError(t, err)
+			require.Len(t, committees, 1)
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), atomic.LoadInt32(&client.requests))
+
+	// A subset of validators is not fetched in full.
+	_, err = s.Validators(ctx, "64", []phase0.ValidatorIndex{1})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&client.requests))
+
+	// Once the full set is held, a subset is served from it.
+	_, err = s.Validators(ctx, "64", nil)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&client.requests))
+	validators, err := s.Validators(ctx, "64", []phase0.ValidatorIndex{2, 3})
+	require.NoError(t, err)
+	require.Len(t, validators, 1)
+	require.Equal(t, phase0.Gwei(31000000000), validators[2].Balance)
+	require.Equal(t, int32(3), atomic.LoadInt32(&client.requests))
+
+	// Named states are not cached.
+	_, err = s.Validators(ctx, "head", nil)
+	require.NoError(t, err)
+	require.Equal(t, int32(4), atomic.LoadInt32(&client.requests))
+
+	// The least recently used entry is evicted.
+	_, err = s.BeaconCommittees(ctx, "96")
+	require.NoError(t, err)
+	require.Equal(t, int32(5), atomic.LoadInt32(&client.requests))
+	_, err = s.BeaconCommittees(ctx, "64")
+	require.NoError(t, err)
+	require.Equal(t, int32(6), atomic.LoadInt32(&client.requests))
+	_, err = s.BeaconCommittees(ctx, "96")
+	require.NoError(t, err)
+	require.Equal(t, int32(6), atomic.LoadInt32(&client.requests))
+}
+
+func TestPrefetch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockClient, err := mock.New(ctx, mock.WithName("client"))
+	require.NoError(t, err)
+	client := &countingClient{Service: mockClient}
+
+	_, err = statecache.New(ctx,
+		statecache.WithLogLevel(zerolog.Disabled),
+		statecache.WithClient(client),
+		statecache.WithSize(2),
+		statecache.WithPrefetch(true),
+	)
+	require.EqualError(t, err, "problem with parameters: size must be at least 3 to prefetch")
+
+	s, err := statecache.New(ctx,
+		statecache.WithLogLevel(zerolog.Disabled),
+		statecache.WithClient(client),
+		statecache.WithSize(3),
+		statecache.WithPrefetch(true),
+	)
+	require.NoError(t, err)
+
+	// A request for the validators at the start of an epoch fetches the
+	// beacon committees and proposer duties for the epoch concurrently.
+	_, err = s.Validators(ctx, "64", nil)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&client.requests) == 3
+	}, time.Second, time.Millisecond)
+
+	// The prefetched data is served from the cache, waiting for it if it is still being fetched.
+	committees, err := s.BeaconCommittees(ctx, "64")
+	require.NoError(t, err)
+	require.Len(t, committees, 1)
+	duties, err := s.ProposerDuties(ctx, 2, nil)
+	require.NoError(t, err)
+	require.Len(t, duties, 1)
+	require.Equal(t, phase0.Slot(64), duties[0].Slot)
+	require.Equal(t, int32(3), atomic.LoadInt32(&client.requests))
+
+	// A request for a state that is not the start of an epoch does not prefetch.
+	_, err = s.BeaconCommittees(ctx, "65")
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(4), atomic.LoadInt32(&client.requests))
+}
+
+// bareClient is a client that provides nothing.
+type bareClient struct{}
+
+func (bareClient) Name() string    { return "bare" }
+func (bareClient) Address() string { return "bare" }
+
+func TestNotProvider(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := statecache.New(ctx,
+		statecache.WithLogLevel(zerolog.Disabled),
+		statecache.WithClient(bareClient{}),
+	)
+	require.NoError(t, err)
+
+	_, err = s.BeaconStateRoot(ctx, "64")
+	require.EqualError(t, err, "client is not a BeaconStateRootProvider")
+}
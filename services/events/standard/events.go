@@ -0,0 +1,88 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import "encoding/json"
+
+const (
+	// TopicBlock is the topic for blocks that have been indexed.
+	TopicBlock = "block"
+	// TopicEpoch is the topic for epochs that have been indexed.
+	TopicEpoch = "epoch"
+	// TopicFinality is the topic for finality updates.
+	TopicFinality = "finality"
+	// TopicProgress is the topic for per-service indexing progress.
+	TopicProgress = "progress"
+)
+
+// topics are the topics to which clients can subscribe.
+var topics = map[string]bool{
+	TopicBlock:    true,
+	TopicEpoch:    true,
+	TopicFinality: true,
+	TopicProgress: true,
+}
+
+// event is the message sent to subscribers.
+type event struct {
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+type blockEvent struct {
+	Slot string `json:"slot"`
+	Root string `json:"root"`
+}
+
+type epochEvent struct {
+	Epoch string `json:"epoch"`
+}
+
+// publish sends an event to all subscribers of its topic.
+func (s *Service) publish(topic string, data interface{}) {
+	msg, err := newEvent(topic, data)
+	if err != nil {
+		log.Error().Str("topic", topic).Err(err).Msg("Failed to create event")
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for sub := range s.subscribers {
+		if !sub.topics[topic] {
+			continue
+		}
+		select {
+		case sub.events <- msg:
+		default:
+			// Subscriber is not keeping up; drop it rather than block indexing.
+			log.Debug().Str("remote", sub.remote).Msg("Subscriber buffer full; disconnecting")
+			s.removeSubscriber(sub)
+			monitorSubscriberDropped()
+		}
+	}
+	monitorEventPublished(topic)
+}
+
+// newEvent creates an event, marshalling its data.
+func newEvent(topic string, data interface{}) (*event, error) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &event{
+		Topic: topic,
+		Data:  dataJSON,
+	}, nil
+}
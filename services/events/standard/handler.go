@@ -0,0 +1,43 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// OnBlockIndexed is called when a block has been committed to the database.
+func (s *Service) OnBlockIndexed(_ context.Context, slot phase0.Slot, root phase0.Root) {
+	s.publish(TopicBlock, &blockEvent{
+		Slot: fmt.Sprintf("%d", slot),
+		Root: fmt.Sprintf("%#x", root),
+	})
+}
+
+// OnEpochIndexed is called when all information for an epoch has been committed to the database.
+func (s *Service) OnEpochIndexed(_ context.Context, epoch phase0.Epoch) {
+	s.publish(TopicEpoch, &epochEvent{
+		Epoch: fmt.Sprintf("%d", epoch),
+	})
+}
+
+// OnFinalityUpdated is called when finality has been updated in the database.
+func (s *Service) OnFinalityUpdated(_ context.Context, epoch phase0.Epoch) {
+	s.publish(TopicFinality, &epochEvent{
+		Epoch: fmt.Sprintf("%d", epoch),
+	})
+}
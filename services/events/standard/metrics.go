@@ -0,0 +1,92 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_events"
+
+var subscribersGauge prometheus.Gauge
+var eventsCounter *prometheus.CounterVec
+var droppedSubscribersCounter prometheus.Counter
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if subscribersGauge != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	subscribersGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "subscribers",
+		Help:      "Number of connected subscribers",
+	})
+	if err := prometheus.Register(subscribersGauge); err != nil {
+		return errors.Wrap(err, "failed to register subscribers")
+	}
+
+	eventsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "events_total",
+		Help:      "Number of events published",
+	}, []string{"topic"})
+	if err := prometheus.Register(eventsCounter); err != nil {
+		return errors.Wrap(err, "failed to register events_total")
+	}
+
+	droppedSubscribersCounter = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "dropped_subscribers_total",
+		Help:      "Number of subscribers disconnected for falling behind",
+	})
+	if err := prometheus.Register(droppedSubscribersCounter); err != nil {
+		return errors.Wrap(err, "failed to register dropped_subscribers_total")
+	}
+
+	return nil
+}
+
+func monitorSubscribers(subscribers int) {
+	if subscribersGauge != nil {
+		subscribersGauge.Set(float64(subscribers))
+	}
+}
+
+func monitorEventPublished(topic string) {
+	if eventsCounter != nil {
+		eventsCounter.WithLabelValues(topic).Inc()
+	}
+}
+
+func monitorSubscriberDropped() {
+	if droppedSubscribersCounter != nil {
+		droppedSubscribersCounter.Inc()
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/wealdtech/chaind/util"
+)
+
+// progressEvent is the data for a progress event.
+// Lag is the number of slots or epochs between the latest processed and
+// the current slot or epoch of the chain.
+type progressEvent struct {
+	Service string `json:"service"`
+	Slot    string `json:"slot,omitempty"`
+	Epoch   string `json:"epoch,omitempty"`
+	Lag     string `json:"lag"`
+}
+
+// pollProgress periodically checks service progress, publishing events on change.
+func (s *Service) pollProgress(ctx context.Context) {
+	ticker := time.NewTicker(s.progressInterval)
+	defer ticker.Stop()
+	for {
+		s.updateProgress(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// updateProgress fetches the progress of each service, publishing events for those that have changed.
+func (s *Service) updateProgress(ctx context.Context) {
+	progresses, err := util.ServiceProgress(ctx, s.chainDB)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain service progress")
+		return
+	}
+
+	for _, serviceProgress := range progresses {
+		progress := &progressEvent{
+			Service: serviceProgress.Source.Service,
+		}
+		var current uint64
+		if serviceProgress.Source.Slots {
+			progress.Slot = fmt.Sprintf("%d", serviceProgress.Latest)
+			current = uint64(s.chainTime.CurrentSlot())
+		} else {
+			progress.Epoch = fmt.Sprintf("%d", serviceProgress.Latest)
+			current = uint64(s.chainTime.CurrentEpoch())
+		}
+		lag := uint64(0)
+		if current > serviceProgress.Latest {
+			lag = current - serviceProgress.Latest
+		}
+		progress.Lag = fmt.Sprintf("%d", lag)
+
+		s.progressMu.Lock()
+		previous, exists := s.progress[progress.Service]
+		changed := !exists || *previous != *progress
+		if changed {
+			s.progress[progress.Service] = progress
+		}
+		s.progressMu.Unlock()
+
+		if changed {
+			s.publish(TopicProgress, progress)
+		}
+	}
+}
+
+// currentProgress returns progress events for the current state of all services.
+func (s *Service) currentProgress() []*event {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	services := make([]string, 0, len(s.progress))
+	for service := range s.progress {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	res := make([]*event, 0, len(services))
+	for _, service := range services {
+		ev, err := newEvent(TopicProgress, s.progress[service])
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to create progress event")
+			continue
+		}
+		res = append(res, ev)
+	}
+	return res
+}
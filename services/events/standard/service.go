@@ -0,0 +1,108 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is an events service.
+type Service struct {
+	chainDB          chaindb.Service
+	chainTime        chaintime.Service
+	bufferSize       int
+	progressInterval time.Duration
+	mutex            sync.Mutex
+	subscribers      map[*subscriber]struct{}
+	progressMu       sync.Mutex
+	progress         map[string]*progressEvent
+	upgrader         websocket.Upgrader
+	server           *http.Server
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "events").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		chainDB:          parameters.chainDB,
+		chainTime:        parameters.chainTime,
+		bufferSize:       parameters.bufferSize,
+		progressInterval: parameters.progressInterval,
+		subscribers:      make(map[*subscriber]struct{}),
+		progress:         make(map[string]*progressEvent),
+		upgrader: websocket.Upgrader{
+			// Events are public read-only data, so allow connections from any origin.
+			CheckOrigin: func(_ *http.Request) bool { return true },
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/events", s.handleSubscribe)
+	mux.HandleFunc("/v1/progress", s.handleProgress)
+	s.server = &http.Server{
+		Addr:              parameters.listenAddress,
+		Handler:           parameters.authenticator.Require(util.RoleRead, mux),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		log.Info().Str("listen_address", parameters.listenAddress).Msg("Starting events server")
+		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Str("listen_address", parameters.listenAddress).Err(err).Msg("Failed to run events server")
+		}
+	}()
+
+	go s.pollProgress(ctx)
+
+	go func() {
+		<-ctx.Done()
+		log.Trace().Msg("Context done; shutting down events server")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down events server")
+		}
+		s.unsubscribeAll()
+	}()
+
+	return s, nil
+}
@@ -0,0 +1,73 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// handleProgress streams progress events to the client as server-sent events.
+func (s *Service) handleProgress(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sub := &subscriber{
+		remote: r.RemoteAddr,
+		topics: map[string]bool{TopicProgress: true},
+		events: make(chan *event, s.bufferSize),
+	}
+	s.addSubscriber(sub)
+	defer s.unsubscribe(sub)
+	log.Trace().Str("remote", sub.remote).Msg("Progress subscriber connected")
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			log.Trace().Str("remote", sub.remote).Msg("Progress subscriber disconnected")
+			return
+		case ev, ok := <-sub.events:
+			if !ok {
+				// Unsubscribed.
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Topic, ev.Data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			// Comment to keep the connection alive through proxies.
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
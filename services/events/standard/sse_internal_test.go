@@ -0,0 +1,81 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(mockchaindb.New()),
+		WithChainTime(mockchaintime.New()),
+		WithListenAddress("localhost:0"),
+	)
+	require.NoError(t, err)
+
+	// Progress known before the subscriber connects is sent on connection.
+	s.progressMu.Lock()
+	s.progress["blocks"] = &progressEvent{Service: "blocks", Slot: "100", Lag: "2"}
+	s.progressMu.Unlock()
+
+	server := httptest.NewServer(http.HandlerFunc(s.handleProgress))
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	readEvent := func() string {
+		res := ""
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line == "\n" {
+				return res
+			}
+			res += line
+		}
+	}
+	require.Equal(t, "event: progress\ndata: {\"service\":\"blocks\",\"slot\":\"100\",\"lag\":\"2\"}\n", readEvent())
+
+	// Wait for the subscription to be registered.
+	require.Eventually(t, func() bool {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return len(s.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.publish(TopicBlock, &blockEvent{Slot: "101"})
+	s.publish(TopicProgress, &progressEvent{Service: "finalizer", Epoch: "3", Lag: "2"})
+	require.Equal(t, "event: progress\ndata: {\"service\":\"finalizer\",\"epoch\":\"3\",\"lag\":\"2\"}\n", readEvent())
+}
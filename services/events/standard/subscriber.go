@@ -0,0 +1,192 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeTimeout is the time allowed to write a message to a subscriber.
+	writeTimeout = 10 * time.Second
+	// pongTimeout is the time allowed between pongs from a subscriber.
+	pongTimeout = 60 * time.Second
+	// pingInterval is the interval at which pings are sent to a subscriber.
+	pingInterval = 30 * time.Second
+)
+
+// subscriber is a connected WebSocket client.
+type subscriber struct {
+	remote string
+	topics map[string]bool
+	events chan *event
+}
+
+// handleSubscribe upgrades the connection to a WebSocket and streams events to it.
+func (s *Service) handleSubscribe(w http.ResponseWriter, r *http.Request) {
+	subscribedTopics, err := parseTopics(r.URL.Query().Get("topics"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response.
+		log.Debug().Err(err).Msg("Failed to upgrade connection")
+		return
+	}
+
+	sub := &subscriber{
+		remote: r.RemoteAddr,
+		topics: subscribedTopics,
+		events: make(chan *event, s.bufferSize),
+	}
+	s.addSubscriber(sub)
+	log.Trace().Str("remote", sub.remote).Msg("Subscriber connected")
+
+	go s.readLoop(conn, sub)
+	s.writeLoop(conn, sub)
+}
+
+// parseTopics parses a comma-separated list of topics, defaulting to all topics.
+func parseTopics(input string) (map[string]bool, error) {
+	res := make(map[string]bool)
+	if input == "" {
+		for topic := range topics {
+			res[topic] = true
+		}
+		return res, nil
+	}
+	for _, topic := range strings.Split(input, ",") {
+		topic = strings.TrimSpace(topic)
+		if !topics[topic] {
+			return nil, fmt.Errorf("unknown topic %q", topic)
+		}
+		res[topic] = true
+	}
+	return res, nil
+}
+
+// readLoop reads from the connection to process control messages, and
+// unsubscribes when the connection is closed.
+func (s *Service) readLoop(conn *websocket.Conn, sub *subscriber) {
+	defer s.unsubscribe(sub)
+
+	conn.SetReadLimit(512)
+	if err := conn.SetReadDeadline(time.Now().Add(pongTimeout)); err != nil {
+		return
+	}
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+	for {
+		// Subscribers do not send messages, so anything other than control messages is discarded.
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// writeLoop sends events and pings to the subscriber until it is unsubscribed.
+func (s *Service) writeLoop(conn *websocket.Conn, sub *subscriber) {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		if err := conn.Close(); err != nil {
+			log.Trace().Err(err).Msg("Failed to close connection")
+		}
+		log.Trace().Str("remote", sub.remote).Msg("Subscriber disconnected")
+	}()
+
+	for {
+		select {
+		case ev, ok := <-sub.events:
+			if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+			if !ok {
+				// Unsubscribed.
+				_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+			msg, err := json.Marshal(ev)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to marshal event")
+				s.unsubscribe(sub)
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				s.unsubscribe(sub)
+				return
+			}
+		case <-ticker.C:
+			if err := conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				s.unsubscribe(sub)
+				return
+			}
+		}
+	}
+}
+
+func (s *Service) addSubscriber(sub *subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subscribers[sub] = struct{}{}
+	monitorSubscribers(len(s.subscribers))
+
+	if sub.topics[TopicProgress] {
+		// Send current progress so that the subscriber does not have to wait for changes.
+		for _, ev := range s.currentProgress() {
+			select {
+			case sub.events <- ev:
+			default:
+			}
+		}
+	}
+}
+
+func (s *Service) unsubscribe(sub *subscriber) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.removeSubscriber(sub)
+}
+
+func (s *Service) unsubscribeAll() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for sub := range s.subscribers {
+		s.removeSubscriber(sub)
+	}
+}
+
+// removeSubscriber removes a subscriber, closing its events channel.
+// This requires the mutex to be held.
+func (s *Service) removeSubscriber(sub *subscriber) {
+	if _, exists := s.subscribers[sub]; !exists {
+		return
+	}
+	delete(s.subscribers, sub)
+	close(sub.events)
+	monitorSubscribers(len(s.subscribers))
+}
@@ -0,0 +1,78 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(mockchaindb.New()),
+		WithChainTime(mockchaintime.New()),
+		WithListenAddress("localhost:0"),
+	)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(s.handleSubscribe))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	// Unknown topics are rejected.
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?topics=unknown", nil)
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url+"?topics=block,finality", nil)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	defer conn.Close()
+
+	// Wait for the subscription to be registered.
+	require.Eventually(t, func() bool {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		return len(s.subscribers) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	s.OnEpochIndexed(ctx, 5)
+	s.OnBlockIndexed(ctx, 12, phase0.Root{0x01})
+	s.OnFinalityUpdated(ctx, 3)
+
+	// The epoch event is not subscribed, so should not be received.
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, `{"topic":"block","data":{"slot":"12","root":"0x0100000000000000000000000000000000000000000000000000000000000000"}}`, string(msg))
+	_, msg, err = conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, `{"topic":"finality","data":{"epoch":"3"}}`, string(msg))
+}
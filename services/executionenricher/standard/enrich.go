@@ -0,0 +1,174 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
+)
+
+// slotsPerBatch is the number of slots of blocks enriched in a single transaction.
+const slotsPerBatch = 32
+
+// enrich enriches the execution payloads of canonical blocks from the last
+// enriched slot up to the latest canonical block.
+func (s *Service) enrich(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	latestSlot, err := s.blocksProvider.LatestCanonicalBlock(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain latest canonical block")
+	}
+
+	startSlot := md.LatestSlot + 1
+	if startSlot < s.startSlot {
+		startSlot = s.startSlot
+	}
+	if startSlot > latestSlot {
+		log.Trace().Uint64("latest_slot", uint64(latestSlot)).Msg("No new canonical blocks to enrich")
+		return nil
+	}
+	log.Trace().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(latestSlot)).Msg("Enriching execution payloads")
+
+	for slot := startSlot; slot <= latestSlot; slot += slotsPerBatch {
+		end := slot + slotsPerBatch
+		if end > latestSlot+1 {
+			end = latestSlot + 1
+		}
+		if err := s.enrichSlots(ctx, md, slot, end); err != nil {
+			return err
+		}
+	}
+
+	// Ensure that progress through slots without execution payloads is recorded.
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		return s.setMetadata(ctx, md)
+	})
+}
+
+// enrichSlots enriches the execution payloads of the canonical blocks in the
+// given slot range, exclusive of end, and stores them along with the updated
+// metadata in a single transaction.
+func (s *Service) enrichSlots(ctx context.Context, md *metadata, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, startSlot, endSlot)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	enrichments := make([]*enrichment, 0, len(blocks))
+	transactions := 0
+	for _, block := range blocks {
+		if block.Canonical == nil || !*block.Canonical {
+			continue
+		}
+		if block.ExecutionPayload == nil || block.ExecutionPayload.BlockHash == [32]byte{} {
+			// Pre-merge block.
+			continue
+		}
+		blockEnrichment, err := s.fetchEnrichment(ctx, block.ExecutionPayload.BlockHash)
+		if err != nil {
+			return errors.Wrapf(err, "failed to obtain execution block for slot %d", block.Slot)
+		}
+		if blockEnrichment.summary.BlockNumber != block.ExecutionPayload.BlockNumber {
+			return fmt.Errorf("execution block for slot %d has number %d, expected %d", block.Slot, blockEnrichment.summary.BlockNumber, block.ExecutionPayload.BlockNumber)
+		}
+		enrichments = append(enrichments, blockEnrichment)
+		transactions += len(blockEnrichment.transactions)
+	}
+
+	if len(enrichments) == 0 {
+		// Nothing to store; metadata is updated along with the next enrichment.
+		md.LatestSlot = endSlot - 1
+		monitorLatestSlot(md.LatestSlot)
+		return nil
+	}
+
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		for _, e := range enrichments {
+			if err := s.transactionsSetter.SetExecutionTransactions(ctx, e.transactions); err != nil {
+				return errors.Wrap(err, "failed to set execution transactions")
+			}
+			if err := s.summariesSetter.SetExecutionBlockSummary(ctx, e.summary); err != nil {
+				return errors.Wrap(err, "failed to set execution block summary")
+			}
+		}
+		md.LatestSlot = endSlot - 1
+		return s.setMetadata(ctx, md)
+	}); err != nil {
+		return err
+	}
+	monitorEnriched(len(enrichments), transactions)
+	monitorLatestSlot(endSlot - 1)
+
+	return nil
+}
+
+// fetchEnrichment fetches the execution block with the given hash, along with
+// the receipts for its transactions.
+func (s *Service) fetchEnrichment(ctx context.Context, blockHash [32]byte) (*enrichment, error) {
+	result, err := s.call(ctx, "eth_getBlockByHash", fmt.Sprintf("%#x", blockHash), true)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block")
+	}
+	if isNull(result) {
+		return nil, fmt.Errorf("execution client does not have block %#x", blockHash)
+	}
+	var block executionBlockJSON
+	if err := json.Unmarshal(result, &block); err != nil {
+		return nil, errors.Wrap(err, "invalid block")
+	}
+
+	params := make([][]interface{}, len(block.Transactions))
+	for i, transaction := range block.Transactions {
+		params[i] = []interface{}{transaction.Hash}
+	}
+	results, err := s.batchCall(ctx, "eth_getTransactionReceipt", params)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain receipts")
+	}
+	receipts := make([]*executionReceiptJSON, len(results))
+	for i := range results {
+		if isNull(results[i]) {
+			return nil, fmt.Errorf("execution client does not have receipt for transaction %s", block.Transactions[i].Hash)
+		}
+		receipts[i] = &executionReceiptJSON{}
+		if err := json.Unmarshal(results[i], receipts[i]); err != nil {
+			return nil, errors.Wrap(err, "invalid receipt")
+		}
+	}
+
+	res, err := buildEnrichment(&block, receipts)
+	if err != nil {
+		return nil, err
+	}
+	if res.summary.BlockHash != blockHash {
+		return nil, fmt.Errorf("execution client returned block %#x for %#x", res.summary.BlockHash, blockHash)
+	}
+
+	return res, nil
+}
+
+// isNull returns true if the JSON-RPC result is missing or null.
+func isNull(result json.RawMessage) bool {
+	return len(result) == 0 || bytes.Equal(result, []byte("null"))
+}
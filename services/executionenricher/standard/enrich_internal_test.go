@@ -0,0 +1,165 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testBlock = `{
+  "hash": "0x0101010101010101010101010101010101010101010101010101010101010101",
+  "number": "0xed14f2",
+  "gasUsed": "0x7a120",
+  "baseFeePerGas": "0x3b9aca00",
+  "transactions": [
+    {
+      "hash": "0x0202020202020202020202020202020202020202020202020202020202020202",
+      "transactionIndex": "0x0",
+      "type": "0x2",
+      "from": "0x0303030303030303030303030303030303030303",
+      "to": null,
+      "nonce": "0x5",
+      "value": "0xde0b6b3a7640000",
+      "gas": "0x493e0",
+      "maxFeePerGas": "0x77359400",
+      "maxPriorityFeePerGas": "0x3b9aca00"
+    },
+    {
+      "hash": "0x0404040404040404040404040404040404040404040404040404040404040404",
+      "transactionIndex": "0x1",
+      "type": "0x0",
+      "from": "0x0505050505050505050505050505050505050505",
+      "to": "0x0606060606060606060606060606060606060606",
+      "nonce": "0x0",
+      "value": "0x0",
+      "gas": "0x186a0",
+      "gasPrice": "0x4a817c800"
+    }
+  ]
+}`
+
+var testReceipts = map[string]string{
+	"0x0202020202020202020202020202020202020202020202020202020202020202": `{
+  "transactionHash": "0x0202020202020202020202020202020202020202020202020202020202020202",
+  "status": "0x1",
+  "gasUsed": "0x61a80",
+  "contractAddress": "0x0707070707070707070707070707070707070707",
+  "logs": [{}, {}]
+}`,
+	"0x0404040404040404040404040404040404040404040404040404040404040404": `{
+  "transactionHash": "0x0404040404040404040404040404040404040404040404040404040404040404",
+  "status": "0x0",
+  "gasUsed": "0x186a0",
+  "effectiveGasPrice": "0x4a817c800",
+  "contractAddress": null,
+  "logs": []
+}`,
+}
+
+// testExecutionClient serves the test block and its receipts, returning batched
+// responses in reverse order.
+func testExecutionClient(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var request rpcRequest
+		if err := json.Unmarshal(body, &request); err == nil {
+			require.Equal(t, "eth_getBlockByHash", request.Method)
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, request.ID, testBlock)
+			return
+		}
+
+		var requests []*rpcRequest
+		require.NoError(t, json.Unmarshal(body, &requests))
+		responses := make([]string, 0, len(requests))
+		for i := len(requests) - 1; i >= 0; i-- {
+			require.Equal(t, "eth_getTransactionReceipt", requests[i].Method)
+			receipt, exists := testReceipts[requests[i].Params[0].(string)]
+			if !exists {
+				receipt = "null"
+			}
+			responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, requests[i].ID, receipt))
+		}
+		fmt.Fprint(w, "[")
+		for i, response := range responses {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprint(w, response)
+		}
+		fmt.Fprint(w, "]")
+	}))
+}
+
+func TestFetchEnrichment(t *testing.T) {
+	ctx := context.Background()
+
+	server := testExecutionClient(t)
+	defer server.Close()
+	base, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	s := &Service{
+		base:    base,
+		client:  server.Client(),
+		timeout: time.Second,
+	}
+
+	blockHash := [32]byte{}
+	for i := range blockHash {
+		blockHash[i] = 0x01
+	}
+
+	_, err = s.fetchEnrichment(ctx, [32]byte{})
+	require.EqualError(t, err, "execution client returned block 0x0101010101010101010101010101010101010101010101010101010101010101 for 0x0000000000000000000000000000000000000000000000000000000000000000")
+
+	res, err := s.fetchEnrichment(ctx, blockHash)
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(15537394), res.summary.BlockNumber)
+	require.Equal(t, 2, res.summary.Transactions)
+	require.Equal(t, 1, res.summary.FailedTransactions)
+	require.Equal(t, 1, res.summary.ContractCreations)
+	require.Equal(t, 2, res.summary.Logs)
+	require.Equal(t, uint64(500000), res.summary.GasUsed)
+	// 400000 gas at 2 gwei plus 100000 gas at 20 gwei.
+	require.Equal(t, big.NewInt(2800000000000000), res.summary.TotalFees)
+	// 500000 gas at 1 gwei.
+	require.Equal(t, big.NewInt(500000000000000), res.summary.BurntFees)
+
+	require.Len(t, res.transactions, 2)
+	require.Nil(t, res.transactions[0].To)
+	require.NotNil(t, res.transactions[0].ContractAddress)
+	require.Equal(t, uint64(2), res.transactions[0].Type)
+	require.Equal(t, big.NewInt(2000000000), res.transactions[0].EffectiveGasPrice)
+	require.Equal(t, uint64(1), res.transactions[0].Status)
+	require.NotNil(t, res.transactions[1].To)
+	require.Nil(t, res.transactions[1].ContractAddress)
+	require.Equal(t, uint64(1), res.transactions[1].Index)
+	require.Equal(t, uint64(0), res.transactions[1].Status)
+}
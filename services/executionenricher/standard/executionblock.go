@@ -0,0 +1,274 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// executionBlockJSON is the JSON representation of an execution block with
+// full transactions, as returned by eth_getBlockByHash.
+type executionBlockJSON struct {
+	Hash          string                      `json:"hash"`
+	Number        string                      `json:"number"`
+	GasUsed       string                      `json:"gasUsed"`
+	BaseFeePerGas string                      `json:"baseFeePerGas"`
+	Transactions  []*executionTransactionJSON `json:"transactions"`
+}
+
+// executionTransactionJSON is the JSON representation of an execution transaction.
+type executionTransactionJSON struct {
+	Hash                 string `json:"hash"`
+	TransactionIndex     string `json:"transactionIndex"`
+	Type                 string `json:"type"`
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Nonce                string `json:"nonce"`
+	Value                string `json:"value"`
+	Gas                  string `json:"gas"`
+	GasPrice             string `json:"gasPrice"`
+	MaxFeePerGas         string `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas string `json:"maxPriorityFeePerGas"`
+}
+
+// executionReceiptJSON is the JSON representation of an execution transaction
+// receipt, as returned by eth_getTransactionReceipt.
+type executionReceiptJSON struct {
+	TransactionHash   string            `json:"transactionHash"`
+	Status            string            `json:"status"`
+	GasUsed           string            `json:"gasUsed"`
+	EffectiveGasPrice string            `json:"effectiveGasPrice"`
+	ContractAddress   string            `json:"contractAddress"`
+	Logs              []json.RawMessage `json:"logs"`
+}
+
+// enrichment is the information obtained about an execution block.
+type enrichment struct {
+	summary      *chaindb.ExecutionBlockSummary
+	transactions []*chaindb.ExecutionTransaction
+}
+
+// buildEnrichment combines an execution block and the receipts of its
+// transactions, in the same order, to provide its enrichment.
+func buildEnrichment(block *executionBlockJSON, receipts []*executionReceiptJSON) (*enrichment, error) {
+	if len(receipts) != len(block.Transactions) {
+		return nil, fmt.Errorf("%d receipts for %d transactions", len(receipts), len(block.Transactions))
+	}
+
+	blockHash, err := parseHash(block.Hash)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid block hash")
+	}
+	blockNumber, err := parseUint64(block.Number)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid block number")
+	}
+	gasUsed, err := parseUint64(block.GasUsed)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid gas used")
+	}
+	baseFeePerGas := big.NewInt(0)
+	if block.BaseFeePerGas != "" {
+		baseFeePerGas, err = parseBigInt(block.BaseFeePerGas)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid base fee per gas")
+		}
+	}
+
+	res := &enrichment{
+		summary: &chaindb.ExecutionBlockSummary{
+			BlockHash:   blockHash,
+			BlockNumber: blockNumber,
+			GasUsed:     gasUsed,
+			TotalFees:   big.NewInt(0),
+			BurntFees:   new(big.Int).Mul(baseFeePerGas, new(big.Int).SetUint64(gasUsed)),
+		},
+		transactions: make([]*chaindb.ExecutionTransaction, 0, len(block.Transactions)),
+	}
+
+	for i := range block.Transactions {
+		transaction, err := buildTransaction(blockHash, blockNumber, baseFeePerGas, block.Transactions[i], receipts[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid transaction %d", i)
+		}
+		res.transactions = append(res.transactions, transaction)
+
+		res.summary.Transactions++
+		if transaction.Status == 0 {
+			res.summary.FailedTransactions++
+		}
+		if transaction.ContractAddress != nil {
+			res.summary.ContractCreations++
+		}
+		res.summary.Logs += transaction.Logs
+		res.summary.TotalFees.Add(res.summary.TotalFees, new(big.Int).Mul(transaction.EffectiveGasPrice, new(big.Int).SetUint64(transaction.GasUsed)))
+	}
+
+	return res, nil
+}
+
+// buildTransaction combines an execution transaction and its receipt.
+func buildTransaction(blockHash [32]byte,
+	blockNumber uint64,
+	baseFeePerGas *big.Int,
+	tx *executionTransactionJSON,
+	receipt *executionReceiptJSON,
+) (
+	*chaindb.ExecutionTransaction,
+	error,
+) {
+	if !strings.EqualFold(tx.Hash, receipt.TransactionHash) {
+		return nil, fmt.Errorf("receipt for %s does not match transaction %s", receipt.TransactionHash, tx.Hash)
+	}
+
+	var err error
+	transaction := &chaindb.ExecutionTransaction{
+		BlockHash:   blockHash,
+		BlockNumber: blockNumber,
+		Logs:        len(receipt.Logs),
+	}
+	if transaction.Hash, err = parseHash(tx.Hash); err != nil {
+		return nil, errors.Wrap(err, "invalid hash")
+	}
+	if transaction.Index, err = parseUint64(tx.TransactionIndex); err != nil {
+		return nil, errors.Wrap(err, "invalid index")
+	}
+	if tx.Type != "" {
+		if transaction.Type, err = parseUint64(tx.Type); err != nil {
+			return nil, errors.Wrap(err, "invalid type")
+		}
+	}
+	if transaction.From, err = parseAddress(tx.From); err != nil {
+		return nil, errors.Wrap(err, "invalid from")
+	}
+	if tx.To != "" {
+		to, err := parseAddress(tx.To)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid to")
+		}
+		transaction.To = &to
+	}
+	if transaction.Nonce, err = parseUint64(tx.Nonce); err != nil {
+		return nil, errors.Wrap(err, "invalid nonce")
+	}
+	if transaction.Value, err = parseBigInt(tx.Value); err != nil {
+		return nil, errors.Wrap(err, "invalid value")
+	}
+	if transaction.GasLimit, err = parseUint64(tx.Gas); err != nil {
+		return nil, errors.Wrap(err, "invalid gas")
+	}
+	if tx.GasPrice != "" {
+		if transaction.GasPrice, err = parseBigInt(tx.GasPrice); err != nil {
+			return nil, errors.Wrap(err, "invalid gas price")
+		}
+	}
+	if tx.MaxFeePerGas != "" {
+		if transaction.MaxFeePerGas, err = parseBigInt(tx.MaxFeePerGas); err != nil {
+			return nil, errors.Wrap(err, "invalid max fee per gas")
+		}
+	}
+	if tx.MaxPriorityFeePerGas != "" {
+		if transaction.MaxPriorityFeePerGas, err = parseBigInt(tx.MaxPriorityFeePerGas); err != nil {
+			return nil, errors.Wrap(err, "invalid max priority fee per gas")
+		}
+	}
+
+	if transaction.GasUsed, err = parseUint64(receipt.GasUsed); err != nil {
+		return nil, errors.Wrap(err, "invalid gas used")
+	}
+	if transaction.Status, err = parseUint64(receipt.Status); err != nil {
+		return nil, errors.Wrap(err, "invalid status")
+	}
+	if receipt.ContractAddress != "" {
+		contractAddress, err := parseAddress(receipt.ContractAddress)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid contract address")
+		}
+		transaction.ContractAddress = &contractAddress
+	}
+	switch {
+	case receipt.EffectiveGasPrice != "":
+		if transaction.EffectiveGasPrice, err = parseBigInt(receipt.EffectiveGasPrice); err != nil {
+			return nil, errors.Wrap(err, "invalid effective gas price")
+		}
+	case transaction.MaxFeePerGas != nil && transaction.MaxPriorityFeePerGas != nil:
+		// Older clients do not supply the effective gas price, so calculate it.
+		transaction.EffectiveGasPrice = new(big.Int).Add(baseFeePerGas, transaction.MaxPriorityFeePerGas)
+		if transaction.EffectiveGasPrice.Cmp(transaction.MaxFeePerGas) > 0 {
+			transaction.EffectiveGasPrice.Set(transaction.MaxFeePerGas)
+		}
+	case transaction.GasPrice != nil:
+		transaction.EffectiveGasPrice = new(big.Int).Set(transaction.GasPrice)
+	default:
+		return nil, errors.New("no gas price")
+	}
+
+	return transaction, nil
+}
+
+// parseUint64 parses a hex quantity as a uint64.
+func parseUint64(input string) (uint64, error) {
+	if input == "" {
+		return 0, errors.New("missing")
+	}
+	return strconv.ParseUint(strings.TrimPrefix(input, "0x"), 16, 64)
+}
+
+// parseBigInt parses a hex quantity as a big integer.
+func parseBigInt(input string) (*big.Int, error) {
+	if input == "" {
+		return nil, errors.New("missing")
+	}
+	val, success := new(big.Int).SetString(strings.TrimPrefix(input, "0x"), 16)
+	if !success {
+		return nil, fmt.Errorf("invalid quantity %s", input)
+	}
+	return val, nil
+}
+
+// parseHash parses hex data as a 32-byte hash.
+func parseHash(input string) ([32]byte, error) {
+	var res [32]byte
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return res, err
+	}
+	if len(data) != len(res) {
+		return res, fmt.Errorf("incorrect length %d", len(data))
+	}
+	copy(res[:], data)
+	return res, nil
+}
+
+// parseAddress parses hex data as a 20-byte address.
+func parseAddress(input string) ([20]byte, error) {
+	var res [20]byte
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return res, err
+	}
+	if len(data) != len(res) {
+		return res, fmt.Errorf("incorrect length %d", len(data))
+	}
+	copy(res[:], data)
+	return res, nil
+}
@@ -0,0 +1,156 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// rpcRequest is a JSON-RPC request.
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
+// rpcResponse is a JSON-RPC response.
+type rpcResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// rpcError is a JSON-RPC error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// call makes a single JSON-RPC call to the execution client, returning the result.
+func (s *Service) call(ctx context.Context, method string, params ...interface{}) (json.RawMessage, error) {
+	reqBody, err := json.Marshal(&rpcRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      1,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	respBody, err := s.post(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var response rpcResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, errors.Wrap(err, "invalid response")
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("%s failed with code %d: %s", method, response.Error.Code, response.Error.Message)
+	}
+
+	return response.Result, nil
+}
+
+// batchCall makes a batch of JSON-RPC calls of the same method to the
+// execution client, returning the results in the order of the parameters.
+func (s *Service) batchCall(ctx context.Context, method string, params [][]interface{}) ([]json.RawMessage, error) {
+	if len(params) == 0 {
+		return []json.RawMessage{}, nil
+	}
+
+	requests := make([]*rpcRequest, len(params))
+	for i := range params {
+		requests[i] = &rpcRequest{
+			JSONRPC: "2.0",
+			Method:  method,
+			Params:  params[i],
+			ID:      i,
+		}
+	}
+	reqBody, err := json.Marshal(requests)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal request")
+	}
+
+	respBody, err := s.post(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var responses []*rpcResponse
+	if err := json.Unmarshal(respBody, &responses); err != nil {
+		return nil, errors.Wrap(err, "invalid batch response")
+	}
+	if len(responses) != len(requests) {
+		return nil, fmt.Errorf("batch returned %d responses for %d requests", len(responses), len(requests))
+	}
+
+	// Responses can be returned in any order.
+	results := make([]json.RawMessage, len(requests))
+	for _, response := range responses {
+		if response.ID < 0 || response.ID >= len(requests) {
+			return nil, fmt.Errorf("batch response has unknown ID %d", response.ID)
+		}
+		if response.Error != nil {
+			return nil, fmt.Errorf("%s failed with code %d: %s", method, response.Error.Code, response.Error.Message)
+		}
+		results[response.ID] = response.Result
+	}
+
+	return results, nil
+}
+
+// post sends an HTTP post request to the execution client and returns the body.
+func (s *Service) post(ctx context.Context, body []byte) ([]byte, error) {
+	log.Trace().Str("body", string(body)).Msg("POST request")
+
+	opCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(opCtx, http.MethodPost, s.base.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create POST request")
+	}
+	req.Header.Set("Content-type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call POST endpoint")
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read POST response")
+	}
+
+	statusFamily := resp.StatusCode / 100
+	if statusFamily != 2 {
+		return nil, fmt.Errorf("POST failed with status %d: %s", resp.StatusCode, string(data))
+	}
+
+	log.Trace().Str("response", string(data)).Msg("POST response")
+
+	return data, nil
+}
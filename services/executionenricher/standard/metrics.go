@@ -0,0 +1,88 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_executionenricher"
+
+var enrichedBlocks prometheus.Counter
+var enrichedTransactions prometheus.Counter
+var latestSlot prometheus.Gauge
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if enrichedBlocks != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	enrichedBlocks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "blocks_total",
+		Help:      "Number of execution blocks enriched",
+	})
+	if err := prometheus.Register(enrichedBlocks); err != nil {
+		return errors.Wrap(err, "failed to register blocks_total")
+	}
+
+	enrichedTransactions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "transactions_total",
+		Help:      "Number of execution transactions stored",
+	})
+	if err := prometheus.Register(enrichedTransactions); err != nil {
+		return errors.Wrap(err, "failed to register transactions_total")
+	}
+
+	latestSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_slot",
+		Help:      "Latest slot for which execution payloads have been enriched",
+	})
+	if err := prometheus.Register(latestSlot); err != nil {
+		return errors.Wrap(err, "failed to register latest_slot")
+	}
+
+	return nil
+}
+
+func monitorEnriched(blocks int, transactions int) {
+	if enrichedBlocks != nil {
+		enrichedBlocks.Add(float64(blocks))
+		enrichedTransactions.Add(float64(transactions))
+	}
+}
+
+func monitorLatestSlot(slot phase0.Slot) {
+	if latestSlot != nil {
+		latestSlot.Set(float64(slot))
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Service is an execution enrichment service, periodically fetching the
+// transactions and receipts of the execution payloads of canonical blocks
+// from an execution client.
+type Service struct {
+	chainDB            chaindb.Service
+	blocksProvider     chaindb.BlocksProvider
+	transactionsSetter chaindb.ExecutionTransactionsSetter
+	summariesSetter    chaindb.ExecutionBlockSummariesSetter
+	base               *url.URL
+	client             *http.Client
+	timeout            time.Duration
+	interval           time.Duration
+	startSlot          phase0.Slot
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "executionenricher").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	transactionsSetter, isSetter := parameters.chainDB.(chaindb.ExecutionTransactionsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support execution transaction setting")
+	}
+
+	summariesSetter, isSetter := parameters.chainDB.(chaindb.ExecutionBlockSummariesSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support execution block summary setting")
+	}
+
+	connectionURL := parameters.connectionURL
+	if !strings.HasPrefix(connectionURL, "http") {
+		connectionURL = fmt.Sprintf("http://%s", parameters.connectionURL)
+	}
+	base, err := url.Parse(connectionURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid URL")
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConns:        64,
+			MaxIdleConnsPerHost: 64,
+			IdleConnTimeout:     384 * time.Second,
+		},
+	}
+
+	s := &Service{
+		chainDB:            parameters.chainDB,
+		blocksProvider:     blocksProvider,
+		transactionsSetter: transactionsSetter,
+		summariesSetter:    summariesSetter,
+		base:               base,
+		client:             client,
+		timeout:            parameters.timeout,
+		interval:           parameters.interval,
+		startSlot:          parameters.startSlot,
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically enriches blocks.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.enrich(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to enrich execution payloads")
+		}
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping execution enrichment")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -17,13 +17,16 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sync"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/attestantio/go-eth2-client/spec"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // OnFinalityCheckpointReceived receives finality checkpoint notifications.
@@ -39,6 +42,11 @@ func (s *Service) OnFinalityCheckpointReceived(
 		Str("state_root", fmt.Sprintf("%#x", stateRoot)).
 		Msg("Handler called")
 
+	if s.halt.Halted() {
+		log.Debug().Msg("Service halted; ignoring")
+		return
+	}
+
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
@@ -50,6 +58,7 @@ func (s *Service) OnFinalityCheckpointReceived(
 	// Find the latest canonicalized slot.
 	md, err := s.getMetadata(ctx)
 	if err != nil {
+		monitorError(err)
 		log.Error().Err(err).Msg("Failed to obtain finalizer metadata")
 		return
 	}
@@ -66,6 +75,7 @@ func (s *Service) OnFinalityCheckpointReceived(
 	for {
 		finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, state)
 		if err != nil {
+			monitorError(err)
 			log.Error().Err(err).Msg("Failed to obtain finality for state")
 			break
 		}
@@ -85,6 +95,8 @@ func (s *Service) OnFinalityCheckpointReceived(
 		}
 	}
 
+	// Keep track of the earliest epoch affected by any reorg.
+	var reorgEpoch *phase0.Epoch
 	for {
 		if len(rootStack) == 0 {
 			break
@@ -97,109 +109,256 @@ func (s *Service) OnFinalityCheckpointReceived(
 		epoch := epochStack[epochIndex]
 		epochStack = epochStack[:epochIndex]
 
-		if err := s.runFinalityTransaction(ctx, root, epoch); err != nil {
+		firstReorgEpoch, err := s.runFinalityTransaction(ctx, root, epoch)
+		if err != nil {
+			monitorError(err)
 			log.Error().Err(err).Msg("Failed to run finality transaction")
 			break
 		}
+		if firstReorgEpoch != nil && (reorgEpoch == nil || *firstReorgEpoch < *reorgEpoch) {
+			reorgEpoch = firstReorgEpoch
+		}
+	}
+
+	// Confirm that blocks already canonicalized match the finalized chain.
+	repairEpoch, err := s.verifyCanonicalBlocks(ctx, epoch)
+	if err != nil {
+		monitorError(err)
+		log.Error().Err(err).Msg("Failed to verify canonical blocks")
+	}
+	if repairEpoch != nil && (reorgEpoch == nil || *repairEpoch < *reorgEpoch) {
+		reorgEpoch = repairEpoch
+	}
 
+	if s.workers > 1 {
+		// Attestations are updated once the canonical blocks have been committed.
+		// This happens for every checkpoint, whether or not it canonicalized any
+		// blocks, so that epochs that previously failed are retried.
+		log.Trace().Msg("Updating attestation votes on finality with workers")
+		if err := s.updateAttestationsToCanonical(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to update attestations on finality; will retry next finality update")
+		}
 	}
 
 	monitorEpochProcessed(epoch)
 	log.Trace().Msg("Finished handling finality checkpoint")
 
+	if reorgEpoch != nil {
+		// Notify that previously finalized data has changed.  This is carried out
+		// synchronously so that handlers have discarded their derived data before
+		// they are informed of the updated finality.
+		for _, finalityHandler := range s.finalityHandlers {
+			if reorgHandler, isReorgHandler := finalityHandler.(handlers.ReorgHandler); isReorgHandler {
+				reorgHandler.OnReorg(ctx, *reorgEpoch)
+			}
+		}
+	}
+
 	// Notify that finality has been updated.
 	for _, finalityHandler := range s.finalityHandlers {
 		go finalityHandler.OnFinalityUpdated(ctx, epoch)
 	}
 }
 
+// onEventsResubscribed catches up with any finality checkpoint missed while the
+// event stream was down.
+func (s *Service) onEventsResubscribed(ctx context.Context) {
+	finality, err := s.eth2Client.(eth2client.FinalityProvider).Finality(ctx, "head")
+	if err != nil {
+		monitorError(err)
+		log.Error().Err(err).Msg("Failed to obtain finality after resubscribing to events")
+		return
+	}
+	s.OnFinalityCheckpointReceived(ctx, finality.Finalized.Epoch, finality.Finalized.Root, phase0.Root{})
+}
+
 func (s *Service) runFinalityTransaction(
 	ctx context.Context,
 	root phase0.Root,
 	epoch phase0.Epoch,
-) error {
-
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "Failed to start transaction on finality")
+) (
+	*phase0.Epoch,
+	error,
+) {
+	var blocks map[phase0.Root]*chaindb.Block
+	if s.workers > 1 {
+		log.Trace().Msg("Canonicalizing blocks on finality with workers")
+		var err error
+		blocks, err = s.precanonicalizeBlocks(ctx, root)
+		if err != nil {
+			monitorWorkerFailure()
+			log.Warn().Err(err).Msg("Failed to canonicalize blocks with workers; canonicalizing in turn")
+		}
 	}
 
-	log.Trace().Msg("Updating canonical blocks on finality")
-	if err := s.updateCanonicalBlocks(ctx, root); err != nil {
-		return errors.Wrap(err, "Failed to update canonical blocks on finality")
-	}
+	var reorgEpoch *phase0.Epoch
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		log.Trace().Msg("Updating canonical blocks on finality")
+		var err error
+		reorgEpoch, err = s.updateCanonicalBlocks(ctx, root, blocks)
+		if err != nil {
+			return errors.Wrap(err, "Failed to update canonical blocks on finality")
+		}
 
-	log.Trace().Msg("Updating attestation votes on finality")
-	// We have canonicalized blocks up to the justified root, which is usually the
-	// first slot of the epoch following the finalized epoch.  Because it is possible
-	// for attestations for the finalized epoch to be in blocks beyond this (specifically
-	// in the other 31 slots of the epoch containing the justified root) we update
-	// attestations for the epoch prior to the finalized epoch.
-	if err := s.updateAttestations(ctx, epoch-1); err != nil {
-		// It is possible for a finalized block to arrive after block finalization has
-		// completed, in which case we will receive an error here (because the block is
-		// not marked as finalized).  As such we do not log this error as a problem; the
-		// block and related attestations will be finalized again next time around.
-		log.Debug().Err(err).Msg("Failed to update attestations on finality; will retry next finality update")
+		if s.workers > 1 {
+			// Attestations are updated concurrently once the canonical blocks for
+			// the checkpoint have been committed, as they are read from separate
+			// transactions.
+			return nil
+		}
+
+		log.Trace().Msg("Updating attestation votes on finality")
+		// We have canonicalized blocks up to the justified root, which is usually the
+		// first slot of the epoch following the finalized epoch.  Because it is possible
+		// for attestations for the finalized epoch to be in blocks beyond this (specifically
+		// in the other 31 slots of the epoch containing the justified root) we update
+		// attestations for the epoch prior to the finalized epoch.
+		if err := s.updateAttestations(ctx, epoch-1); err != nil {
+			// It is possible for a finalized block to arrive after block finalization has
+			// completed, in which case we will receive an error here (because the block is
+			// not marked as finalized).  As such we do not log this error as a problem; the
+			// block and related attestations will be finalized again next time around.
+			log.Debug().Err(err).Msg("Failed to update attestations on finality; will retry next finality update")
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
 	}
 
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "Failed to commit transaction on finality")
+	if reorgEpoch != nil {
+		monitorReorg()
 	}
 
-	return nil
+	return reorgEpoch, nil
 }
 
 // updateCanonicalBlocks updates all canonical blocks given a canonical block root.
-func (s *Service) updateCanonicalBlocks(ctx context.Context, root phase0.Root) error {
+// Blocks are obtained from the supplied blocks where present.
+// If this results in previously canonical blocks becoming non-canonical it returns
+// the first epoch affected by the reorg.
+func (s *Service) updateCanonicalBlocks(ctx context.Context,
+	root phase0.Root,
+	blocks map[phase0.Root]*chaindb.Block,
+) (
+	*phase0.Epoch,
+	error,
+) {
 	md, err := s.getMetadata(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain metadata on finality")
+		return nil, errors.Wrap(err, "failed to obtain metadata on finality")
 	}
 
 	// Fetch the block from either the database or the chain.
 	block, err := s.fetchBlock(ctx, root)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain block")
+		return nil, errors.Wrap(err, "failed to obtain block")
 	}
 	if block == nil {
-		return errors.New("missing canonical block")
+		return nil, errors.New("missing canonical block")
 	}
 
-	if err := s.canonicalizeBlocks(ctx, root, md.LatestCanonicalSlot); err != nil {
-		return errors.Wrap(err, "failed to update canonical blocks from canonical root")
+	ancestorSlot, canonicalRoots, err := s.canonicalizeBlocks(ctx, root, md.LatestCanonicalSlot, blocks)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to update canonical blocks from canonical root")
+	}
+
+	var reorgEpoch *phase0.Epoch
+	if md.LatestCanonicalSlot != 0 && ancestorSlot < md.LatestCanonicalSlot {
+		// The new canonical chain branched off before our previous canonical slot,
+		// so blocks we had marked as canonical may no longer be so.
+		reorged, err := s.decanonicalizeBlocks(ctx, ancestorSlot+1, md.LatestCanonicalSlot, canonicalRoots)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to update non-canonical blocks from canonical root")
+		}
+		if reorged > 0 {
+			firstEpoch := s.chainTime.SlotToEpoch(ancestorSlot + 1)
+			log.Warn().
+				Uint64("common_ancestor_slot", uint64(ancestorSlot)).
+				Uint64("previous_canonical_slot", uint64(md.LatestCanonicalSlot)).
+				Int("reorged_blocks", reorged).
+				Uint64("depth", uint64(md.LatestCanonicalSlot-ancestorSlot)).
+				Msg("Canonical chain reorganized; updating previously finalized data")
+			// Attestations for the epoch prior to the reorg can be included in reorged
+			// blocks, so rewind to before that epoch to ensure they are updated.
+			if firstEpoch < 2 {
+				md.LastFinalizedEpoch = 0
+			} else if md.LastFinalizedEpoch > firstEpoch-2 {
+				md.LastFinalizedEpoch = firstEpoch - 2
+			}
+			reorgEpoch = &firstEpoch
+		}
 	}
 
 	if err := s.updateIndeterminateBlocks(ctx, block.Slot); err != nil {
-		return errors.Wrap(err, "failed to update indeterminate blocks from canonical root")
+		return nil, errors.Wrap(err, "failed to update indeterminate blocks from canonical root")
 	}
 
 	md.LatestCanonicalSlot = block.Slot
 	if err := s.setMetadata(ctx, md); err != nil {
-		return errors.Wrap(err, "failed to update metadata on finality")
+		return nil, errors.Wrap(err, "failed to update metadata on finality")
 	}
 
-	return nil
+	return reorgEpoch, nil
 }
 
-// canonicalizeBlocks marks the given block and all its parents as canonical.
-func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limit phase0.Slot) error {
+// canonicalizeBlocks marks the given block and all its parents as canonical, working
+// back until it reaches a block at or before the limit that is already canonical (or
+// any block at or before the limit, if there is no canonical block at the limit).
+// Blocks are obtained from the supplied blocks where present.
+// It returns the slot of that common ancestor, along with the roots of the blocks
+// on the canonical chain after it.
+func (s *Service) canonicalizeBlocks(ctx context.Context,
+	root phase0.Root,
+	limit phase0.Slot,
+	blocks map[phase0.Root]*chaindb.Block,
+) (
+	phase0.Slot,
+	map[phase0.Root]bool,
+	error,
+) {
 	log.Trace().Str("root", fmt.Sprintf("%#x", root)).Uint64("limit", uint64(limit)).Msg("Canonicalizing blocks")
 
+	// If there is no canonical block at the limit, for example because we were
+	// given a start slot, there is no chain to compare against and we stop once
+	// we reach the limit.
+	limitCanonical := false
+	if limit != 0 {
+		limitBlocks, err := s.blocksProvider.BlocksBySlot(ctx, limit)
+		if err != nil {
+			return 0, nil, errors.Wrap(err, "failed to obtain blocks at limit")
+		}
+		for _, limitBlock := range limitBlocks {
+			if limitBlock.Canonical != nil && *limitBlock.Canonical {
+				limitCanonical = true
+				break
+			}
+		}
+	}
+
+	canonicalRoots := make(map[phase0.Root]bool)
 	for {
-		block, err := s.fetchBlock(ctx, root)
+		block, err := s.fetchBlockFrom(ctx, blocks, root)
 		if err != nil {
-			return err
+			return 0, nil, err
 		}
 
 		if block == nil {
 			log.Error().Str("block_root", fmt.Sprintf("%#x", root)).Msg("Block not found for root")
-			return errors.New("block not found for root")
+			return 0, nil, errors.New("block not found for root")
 		}
 
-		if limit != 0 && block.Slot == limit {
-			break
+		if limit != 0 && block.Slot <= limit {
+			if !limitCanonical {
+				return block.Slot, canonicalRoots, nil
+			}
+			if block.Canonical != nil && *block.Canonical {
+				// Reached the common ancestor of the old and new canonical chains; done.
+				return block.Slot, canonicalRoots, nil
+			}
+			// The block was not canonical, which means that the chain has reorganized
+			// past the limit; keep going.
+			log.Debug().Uint64("slot", uint64(block.Slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Block prior to limit is now canonical")
 		}
 
 		// Update if the current status is either indeterminate or non-canonical.
@@ -207,21 +366,223 @@ func (s *Service) canonicalizeBlocks(ctx context.Context, root phase0.Root, limi
 			canonical := true
 			block.Canonical = &canonical
 			if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
-				return errors.Wrap(err, "failed to set block to canonical")
+				return 0, nil, errors.Wrap(err, "failed to set block to canonical")
 			}
 			log.Trace().Uint64("slot", uint64(block.Slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Block is canonical")
 		}
+		canonicalRoots[block.Root] = true
 
 		if block.Slot == 0 {
 			// Reached the genesis block; done.
-			break
+			return 0, canonicalRoots, nil
 		}
 
 		// Loop for parent.
 		root = block.ParentRoot
 	}
+}
 
-	return nil
+// slotsPerWorker is the number of slots of blocks fetched, or blocks canonicalized,
+// by a worker at a time.
+const slotsPerWorker = 256
+
+// precanonicalizeBlocks marks the blocks on the chain ending at the given root
+// that are after the latest canonical slot as canonical, spreading the work over
+// the workers.  Blocks are fetched in ranges of slots concurrently, the chain is
+// followed back through parent roots in memory, and the blocks found to be on it
+// are marked as canonical in ranges concurrently, each range in its own
+// transaction.  As these blocks are all after the latest canonical slot no block
+// previously marked as canonical is altered, and the chain is followed again when
+// the finalized state is committed, so any range that fails is marked as canonical
+// at that point instead.
+// It returns the blocks fetched, by root, with their canonical state as stored.
+func (s *Service) precanonicalizeBlocks(ctx context.Context, root phase0.Root) (map[phase0.Root]*chaindb.Block, error) {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	block, err := s.fetchBlock(ctx, root)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block")
+	}
+	if block == nil {
+		return nil, errors.New("missing canonical block")
+	}
+	if block.Slot <= md.LatestCanonicalSlot {
+		return nil, nil
+	}
+
+	blocks, err := s.fetchBlocksWithWorkers(ctx, md.LatestCanonicalSlot, block.Slot+1)
+	if err != nil {
+		return nil, err
+	}
+
+	// Follow the chain back to the latest canonical slot.
+	pending := make([]*chaindb.Block, 0)
+	for cur := blocks[root]; cur != nil && cur.Slot > md.LatestCanonicalSlot; cur = blocks[cur.ParentRoot] {
+		if cur.Canonical == nil || !*cur.Canonical {
+			pending = append(pending, cur)
+		}
+	}
+	log.Trace().Int("blocks", len(pending)).Msg("Marking blocks as canonical with workers")
+
+	batches := make(chan []*chaindb.Block)
+	go func() {
+		defer close(batches)
+		for start := 0; start < len(pending); start += slotsPerWorker {
+			end := start + slotsPerWorker
+			if end > len(pending) {
+				end = len(pending)
+			}
+			select {
+			case batches <- pending[start:end]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+					for _, block := range batch {
+						blockCopy := *block
+						canonical := true
+						blockCopy.Canonical = &canonical
+						if err := s.blocksSetter.SetBlock(ctx, &blockCopy); err != nil {
+							return errors.Wrap(err, "failed to set block to canonical")
+						}
+					}
+					return nil
+				}); err != nil {
+					monitorWorkerFailure()
+					log.Warn().Uint64("start_slot", uint64(batch[len(batch)-1].Slot)).Uint64("end_slot", uint64(batch[0].Slot)).Err(err).Msg("Failed to mark blocks as canonical; will mark when committing finality")
+					continue
+				}
+				for _, block := range batch {
+					canonical := true
+					block.Canonical = &canonical
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return blocks, nil
+}
+
+// fetchBlocksWithWorkers fetches the blocks in the given slot range, exclusive of
+// end, a range of slots at a time across the workers.
+func (s *Service) fetchBlocksWithWorkers(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	map[phase0.Root]*chaindb.Block,
+	error,
+) {
+	ranges := make(chan phase0.Slot)
+	go func() {
+		defer close(ranges)
+		for start := startSlot; start < endSlot; start += slotsPerWorker {
+			select {
+			case ranges <- start:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	blocks := make(map[phase0.Root]*chaindb.Block)
+	var fetchErr error
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range ranges {
+				end := start + slotsPerWorker
+				if end > endSlot {
+					end = endSlot
+				}
+				rangeBlocks, err := s.blocksProvider.BlocksForSlotRange(ctx, start, end)
+				mu.Lock()
+				if err != nil {
+					monitorWorkerFailure()
+					log.Warn().Uint64("start_slot", uint64(start)).Uint64("end_slot", uint64(end)).Err(err).Msg("Failed to fetch blocks")
+					if fetchErr == nil {
+						fetchErr = errors.Wrapf(err, "failed to obtain blocks from slot %d", start)
+					}
+				}
+				for _, block := range rangeBlocks {
+					blocks[block.Root] = block
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	return blocks, nil
+}
+
+// fetchBlockFrom fetches a copy of the block with the given root from the supplied
+// blocks if present, otherwise from either the database or the chain.
+func (s *Service) fetchBlockFrom(ctx context.Context,
+	blocks map[phase0.Root]*chaindb.Block,
+	root phase0.Root,
+) (
+	*chaindb.Block,
+	error,
+) {
+	if block, exists := blocks[root]; exists {
+		// Copy the block, as the caller can update it in a transaction that is not committed.
+		blockCopy := *block
+		return &blockCopy, nil
+	}
+	return s.fetchBlock(ctx, root)
+}
+
+// decanonicalizeBlocks marks all canonical blocks in the given inclusive slot range that
+// are not in the supplied set of canonical roots as non-canonical.  It returns the number
+// of blocks updated.
+func (s *Service) decanonicalizeBlocks(ctx context.Context,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+	canonicalRoots map[phase0.Root]bool,
+) (
+	int,
+	error,
+) {
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, startSlot, endSlot+1)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	updated := 0
+	for _, block := range blocks {
+		if block.Canonical == nil || !*block.Canonical || canonicalRoots[block.Root] {
+			continue
+		}
+		canonical := false
+		block.Canonical = &canonical
+		if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
+			return 0, errors.Wrap(err, "failed to set block to non-canonical")
+		}
+		log.Trace().Uint64("slot", uint64(block.Slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Msg("Block is no longer canonical")
+		updated++
+	}
+
+	return updated, nil
 }
 
 // updateIndeterminateBlocks marks all indeterminate blocks before the given slot as canonical
@@ -263,17 +624,139 @@ func (s *Service) updateAttestations(ctx context.Context, epoch phase0.Epoch) er
 		return errors.Wrap(err, "failed to obtain metadata")
 	}
 
+	ready, err := s.readyToUpdateAttestations(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return nil
+	}
+
+	// First epoch is last finalized epoch + 1, unless it's 0 because we don't know the
+	// difference between actually 0 and undefined.
+	firstEpoch := md.LastFinalizedEpoch
+	if firstEpoch != 0 {
+		firstEpoch++
+	}
+
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("latest_epoch", uint64(epoch)).Msg("Epochs over which to update attestations")
+	for curEpoch := firstEpoch; curEpoch <= epoch; curEpoch++ {
+		if err := s.updateAttestationsForEpoch(ctx, curEpoch); err != nil {
+			return errors.Wrap(err, "failed to update attestations in epoch")
+		}
+		md.LastFinalizedEpoch = curEpoch
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to update metadata for epoch")
+		}
+	}
+
+	return nil
+}
+
+// updateAttestationsToCanonical updates attestations with workers up to the epoch
+// prior to that of the latest canonical block.
+func (s *Service) updateAttestationsToCanonical(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+	epoch := s.chainTime.SlotToEpoch(md.LatestCanonicalSlot)
+	if epoch == 0 {
+		// Nothing canonicalized yet.
+		return nil
+	}
+
+	return s.updateAttestationsWithWorkers(ctx, epoch-1)
+}
+
+// updateAttestationsWithWorkers updates attestations up to the given epoch, a batch
+// of epochs at a time, with the epochs in a batch updated concurrently in their own
+// transactions.  The blocks up to the epoch must already have been canonicalized in
+// a committed transaction.  As epochs can complete out of order, the last finalized
+// epoch is only advanced over epochs for which all earlier epochs have completed.
+func (s *Service) updateAttestationsWithWorkers(ctx context.Context, epoch phase0.Epoch) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	ready, err := s.readyToUpdateAttestations(ctx, epoch)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return nil
+	}
+
+	// First epoch is last finalized epoch + 1, unless it's 0 because we don't know the
+	// difference between actually 0 and undefined.
+	firstEpoch := md.LastFinalizedEpoch
+	if firstEpoch != 0 {
+		firstEpoch++
+	}
+
+	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("latest_epoch", uint64(epoch)).Msg("Epochs over which to update attestations")
+	for batchStart := firstEpoch; batchStart <= epoch; {
+		batchEnd := batchStart + phase0.Epoch(s.workers) - 1
+		if batchEnd > epoch {
+			batchEnd = epoch
+		}
+
+		errs := make([]error, batchEnd-batchStart+1)
+		var wg sync.WaitGroup
+		for curEpoch := batchStart; curEpoch <= batchEnd; curEpoch++ {
+			wg.Add(1)
+			go func(curEpoch phase0.Epoch) {
+				defer wg.Done()
+				err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+					return s.updateAttestationsForEpoch(ctx, curEpoch)
+				})
+				if err != nil {
+					monitorWorkerFailure()
+					log.Warn().Uint64("epoch", uint64(curEpoch)).Err(err).Msg("Failed to update attestations for epoch")
+				}
+				errs[curEpoch-batchStart] = err
+			}(curEpoch)
+		}
+		wg.Wait()
+
+		// Advance the last finalized epoch over the epochs updated without a gap.
+		completed := 0
+		for _, err := range errs {
+			if err != nil {
+				break
+			}
+			completed++
+		}
+		if completed > 0 {
+			if err := s.setLastFinalizedEpoch(ctx, batchStart+phase0.Epoch(completed)-1); err != nil {
+				return err
+			}
+		}
+		if completed < len(errs) {
+			return errors.Wrapf(errs[completed], "failed to update attestations in epoch %d", batchStart+phase0.Epoch(completed))
+		}
+
+		batchStart = batchEnd + 1
+	}
+
+	return nil
+}
+
+// readyToUpdateAttestations returns true if the blocks service has stored enough
+// blocks for attestations to be updated up to the given epoch.
+func (s *Service) readyToUpdateAttestations(ctx context.Context, epoch phase0.Epoch) (bool, error) {
 	// We need to ensure the finalizer is not running ahead of the blocks service.  To do so, we compare the slot of the block
 	// we fetched with the highest known slot in the database.  If our block is higher than that already stored it means that
 	// we are waiting on the blocks service, so bow out.
 	latestBlocks, err := s.blocksProvider.LatestBlocks(ctx)
 	if err != nil {
-		return errors.Wrap(err, "failed to obtain latest blocks")
+		return false, errors.Wrap(err, "failed to obtain latest blocks")
 	}
 	if len(latestBlocks) == 0 {
 		// No blocks yet; bow out but no error.
 		log.Trace().Msg("No blocks in database")
-		return nil
+		return false, nil
 	}
 	earliestAllowableSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	if earliestAllowableSlot < 1024 {
@@ -284,28 +767,25 @@ func (s *Service) updateAttestations(ctx context.Context, epoch phase0.Epoch) er
 	if latestBlocks[0].Slot < earliestAllowableSlot {
 		// Bow out, but no error.
 		log.Trace().Msg("Not enough blocks in the database; not updating attestations")
-		return nil
+		return false, nil
 	}
 
-	// First epoch is last finalized epoch + 1, unless it's 0 because we don't know the
-	// difference between actually 0 and undefined.
-	firstEpoch := md.LastFinalizedEpoch
-	if firstEpoch != 0 {
-		firstEpoch++
-	}
+	return true, nil
+}
 
-	log.Trace().Uint64("first_epoch", uint64(firstEpoch)).Uint64("latest_epoch", uint64(epoch)).Msg("Epochs over which to update attestations")
-	for curEpoch := firstEpoch; curEpoch <= epoch; curEpoch++ {
-		if err := s.updateAttestationsForEpoch(ctx, curEpoch); err != nil {
-			return errors.Wrap(err, "failed to update attestations in epoch")
+// setLastFinalizedEpoch sets the last finalized epoch in the metadata in its own transaction.
+func (s *Service) setLastFinalizedEpoch(ctx context.Context, epoch phase0.Epoch) error {
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain metadata")
 		}
-		md.LastFinalizedEpoch = curEpoch
+		md.LastFinalizedEpoch = epoch
 		if err := s.setMetadata(ctx, md); err != nil {
 			return errors.Wrap(err, "failed to update metadata for epoch")
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
 
 // updateAttestationsForEpoch updates the attestations for the given epoch.
@@ -334,27 +814,129 @@ func (s *Service) updateAttestationsForEpoch(ctx context.Context, epoch phase0.E
 		if err := s.updateCanonical(ctx, attestation, blockCanonicals); err != nil {
 			return errors.Wrap(err, "failed to update canonical state")
 		}
+		if err := s.updateAttestationSourceCorrect(ctx, attestation, epochRoots); err != nil {
+			return errors.Wrap(err, "failed to update attestation source vote state")
+		}
 		if err := s.updateAttestationTargetCorrect(ctx, attestation, epochRoots); err != nil {
 			return errors.Wrap(err, "failed to update attestation target vote state")
 		}
 		if err := s.updateAttestationHeadCorrect(ctx, attestation, headRoots); err != nil {
 			return errors.Wrap(err, "failed to update attestation head vote state")
 		}
-		if err := s.chainDB.(chaindb.AttestationsSetter).SetAttestation(ctx, attestation); err != nil {
-			return errors.Wrap(err, "failed to update attestation")
+	}
+
+	// Duplicate and conflicting state relies on the canonical state of all
+	// attestations, so is updated once that is known.
+	updateAttestationsDuplicates(attestations)
+
+	if len(attestations) > 0 {
+		if err := s.chainDB.(chaindb.AttestationsSetter).SetAttestations(ctx, attestations); err != nil {
+			return errors.Wrap(err, "failed to update attestations")
 		}
+	}
+	for _, attestation := range attestations {
 		log.Trace().
 			Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).
 			Uint64("inclusion_index", attestation.InclusionIndex).
 			Bool("canonical", *attestation.Canonical).
+			Bool("source_correct", *attestation.SourceCorrect).
 			Bool("target_correct", *attestation.TargetCorrect).
 			Bool("head_correct", *attestation.HeadCorrect).
+			Bool("duplicate", *attestation.Duplicate).
+			Bool("conflicting", *attestation.Conflicting).
 			Msg("Updated attestation")
 	}
 
 	return nil
 }
 
+// attestationVote is the data of an attestation that a validator votes on.
+type attestationVote struct {
+	slot            phase0.Slot
+	committeeIndex  phase0.CommitteeIndex
+	beaconBlockRoot phase0.Root
+	sourceEpoch     phase0.Epoch
+	sourceRoot      phase0.Root
+	targetEpoch     phase0.Epoch
+	targetRoot      phase0.Root
+}
+
+// validatorTarget is a validator's vote for a target epoch.
+type validatorTarget struct {
+	index       phase0.ValidatorIndex
+	targetEpoch phase0.Epoch
+}
+
+// validatorVote is a validator's vote.
+type validatorVote struct {
+	index phase0.ValidatorIndex
+	vote  attestationVote
+}
+
+// updateAttestationsDuplicates updates the attestations, which must be in order
+// of inclusion and have their canonical state set, to mark those that only
+// contain votes already included on the canonical chain as duplicate, and those
+// that contain votes from validators that have canonical attestations with
+// different data for the same target as conflicting.
+func updateAttestationsDuplicates(attestations []*chaindb.Attestation) {
+	// Find the canonical votes for each validator, and those that conflict.
+	votes := make(map[validatorTarget]attestationVote)
+	conflicts := make(map[validatorTarget]bool)
+	for _, attestation := range attestations {
+		if attestation.Canonical == nil || !*attestation.Canonical {
+			continue
+		}
+		vote := voteForAttestation(attestation)
+		for _, index := range attestation.AggregationIndices {
+			target := validatorTarget{index: index, targetEpoch: attestation.TargetEpoch}
+			existing, exists := votes[target]
+			if !exists {
+				votes[target] = vote
+				continue
+			}
+			if existing != vote {
+				conflicts[target] = true
+			}
+		}
+	}
+
+	included := make(map[validatorVote]bool)
+	for _, attestation := range attestations {
+		vote := voteForAttestation(attestation)
+		duplicate := len(attestation.AggregationIndices) > 0
+		conflicting := false
+		for _, index := range attestation.AggregationIndices {
+			if !included[validatorVote{index: index, vote: vote}] {
+				duplicate = false
+			}
+			if conflicts[validatorTarget{index: index, targetEpoch: attestation.TargetEpoch}] {
+				conflicting = true
+			}
+		}
+		attestation.Duplicate = &duplicate
+		attestation.Conflicting = &conflicting
+
+		if attestation.Canonical != nil && *attestation.Canonical {
+			for _, index := range attestation.AggregationIndices {
+				included[validatorVote{index: index, vote: vote}] = true
+			}
+		}
+	}
+}
+
+// voteForAttestation returns the vote data for an attestation.
+func voteForAttestation(attestation *chaindb.Attestation) attestationVote {
+	return attestationVote{
+		slot:            attestation.Slot,
+		committeeIndex:  attestation.CommitteeIndex,
+		beaconBlockRoot: attestation.BeaconBlockRoot,
+		sourceEpoch:     attestation.SourceEpoch,
+		sourceRoot:      attestation.SourceRoot,
+		targetEpoch:     attestation.TargetEpoch,
+		targetRoot:      attestation.TargetRoot,
+	}
+}
+
 // updateCanonical updates the attestation to confirm if it is canonical.
 // An attestation is canonical if it is in a canonical block.
 func (s *Service) updateCanonical(ctx context.Context, attestation *chaindb.Attestation, blockCanonicals map[phase0.Slot]bool) error {
@@ -379,46 +961,69 @@ func (s *Service) updateCanonical(ctx context.Context, attestation *chaindb.Atte
 	return nil
 }
 
+// updateAttestationSourceCorrect updates the attestation to confirm if its source vote is correct.
+// An attestation has a correct source vote if it matches the root of the latest canonical block
+// since the start of the source epoch.  The source of epoch 0 is the genesis checkpoint, which
+// has a zero root.
+func (s *Service) updateAttestationSourceCorrect(ctx context.Context, attestation *chaindb.Attestation, epochRoots map[phase0.Epoch]phase0.Root) error {
+	sourceCorrect := false
+	if attestation.SourceEpoch == 0 && attestation.SourceRoot == (phase0.Root{}) {
+		sourceCorrect = true
+	} else {
+		epochRoot, err := s.canonicalEpochRoot(ctx, attestation.SourceEpoch, epochRoots)
+		if err != nil {
+			return err
+		}
+		sourceCorrect = bytes.Equal(attestation.SourceRoot[:], epochRoot[:])
+	}
+
+	attestation.SourceCorrect = &sourceCorrect
+
+	return nil
+}
+
 // updateAttestationTargetCorrect updates the attestation to confirm if its target vote is correct.
 // An attestation has a correct target vote if it matches the root of the latest canonical block
 // since the start of the target epoch.
 func (s *Service) updateAttestationTargetCorrect(ctx context.Context, attestation *chaindb.Attestation, epochRoots map[phase0.Epoch]phase0.Root) error {
-	targetCorrect := false
-	if epochRoot, exists := epochRoots[attestation.TargetEpoch]; exists {
-		targetCorrect = bytes.Equal(attestation.TargetRoot[:], epochRoot[:])
-	} else {
-		// Start with first slot of the target epoch.
-		startSlot := s.chainTime.FirstSlotOfEpoch(attestation.TargetEpoch)
+	epochRoot, err := s.canonicalEpochRoot(ctx, attestation.TargetEpoch, epochRoots)
+	if err != nil {
+		return err
+	}
+	targetCorrect := bytes.Equal(attestation.TargetRoot[:], epochRoot[:])
 
-		// Work backwards until we find a canonical block.
-		canonicalBlockFound := false
-		for slot := startSlot; !canonicalBlockFound; slot-- {
-			log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
-			blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
-			if err != nil {
-				return errors.Wrap(err, "failed to obtain block")
-			}
-			for _, block := range blocks {
-				if block.Canonical != nil && *block.Canonical {
-					log.Trace().Uint64("target_epoch", uint64(attestation.TargetEpoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
-					canonicalBlockFound = true
-					epochRoots[attestation.TargetEpoch] = block.Root
-					targetCorrect = bytes.Equal(attestation.TargetRoot[:], block.Root[:])
-					break
-				}
-			}
-			if slot == 0 {
-				break
+	attestation.TargetCorrect = &targetCorrect
+
+	return nil
+}
+
+// canonicalEpochRoot returns the root of the latest canonical block since the start of the
+// given epoch, caching the result in epochRoots.
+func (s *Service) canonicalEpochRoot(ctx context.Context, epoch phase0.Epoch, epochRoots map[phase0.Epoch]phase0.Root) (phase0.Root, error) {
+	if epochRoot, exists := epochRoots[epoch]; exists {
+		return epochRoot, nil
+	}
+
+	// Start with first slot of the epoch, and work backwards until we find a canonical block.
+	for slot := s.chainTime.FirstSlotOfEpoch(epoch); ; slot-- {
+		log.Trace().Uint64("slot", uint64(slot)).Msg("Fetching blocks at slot")
+		blocks, err := s.chainDB.(chaindb.BlocksProvider).BlocksBySlot(ctx, slot)
+		if err != nil {
+			return phase0.Root{}, errors.Wrap(err, "failed to obtain block")
+		}
+		for _, block := range blocks {
+			if block.Canonical != nil && *block.Canonical {
+				log.Trace().Uint64("epoch", uint64(epoch)).Uint64("slot", uint64(block.Slot)).Msg("Found canonical block")
+				epochRoots[epoch] = block.Root
+				return block.Root, nil
 			}
 		}
-		if !canonicalBlockFound {
-			return errors.New("failed to obtain canonical block")
+		if slot == 0 {
+			break
 		}
 	}
 
-	attestation.TargetCorrect = &targetCorrect
-
-	return nil
+	return phase0.Root{}, errors.New("failed to obtain canonical block")
 }
 
 // updateAttestationHeadCorrect updates the attestation to confirm if its head vote is correct.
@@ -15,19 +15,30 @@ package standard
 
 import (
 	"context"
+	"errors"
 	"os"
+	"sort"
+	"sync"
 	"testing"
+	"time"
 
 	autoeth2client "github.com/attestantio/go-eth2-client/auto"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 	standardblocks "github.com/wealdtech/chaind/services/blocks/standard"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
 	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
 	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/testing/mock"
 )
 
 func TestUpdateAttestationHeadCorrect(t *testing.T) {
+	if os.Getenv("CHAINDB_URL") == "" || os.Getenv("ETH2CLIENT_ADDRESS") == "" {
+		t.Skip("CHAINDB_URL and ETH2CLIENT_ADDRESS required")
+	}
+
 	ctx := context.Background()
 
 	chainDB, err := postgresqlchaindb.New(ctx,
@@ -96,3 +107,604 @@ func TestUpdateAttestationHeadCorrect(t *testing.T) {
 		})
 	}
 }
+
+// memBlocks is a minimal in-memory block store.
+type memBlocks struct {
+	chaindb.BlocksProvider
+	blocks map[phase0.Root]*chaindb.Block
+}
+
+func (m *memBlocks) BlockByRoot(_ context.Context, root phase0.Root) (*chaindb.Block, error) {
+	block, exists := m.blocks[root]
+	if !exists {
+		return nil, nil
+	}
+	res := *block
+	return &res, nil
+}
+
+func (m *memBlocks) BlocksBySlot(ctx context.Context, slot phase0.Slot) ([]*chaindb.Block, error) {
+	return m.BlocksForSlotRange(ctx, slot, slot+1)
+}
+
+func (m *memBlocks) BlocksForSlotRange(_ context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.Block, error) {
+	res := make([]*chaindb.Block, 0)
+	for _, block := range m.blocks {
+		if block.Slot >= startSlot && block.Slot < endSlot {
+			blockCopy := *block
+			res = append(res, &blockCopy)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Slot < res[j].Slot })
+	return res, nil
+}
+
+func (m *memBlocks) SetBlock(_ context.Context, block *chaindb.Block) error {
+	m.blocks[block.Root] = block
+	return nil
+}
+
+func (m *memBlocks) add(slot phase0.Slot, root byte, parentRoot byte, canonical *bool) {
+	m.blocks[phase0.Root{root}] = &chaindb.Block{
+		Slot:       slot,
+		Root:       phase0.Root{root},
+		ParentRoot: phase0.Root{parentRoot},
+		Canonical:  canonical,
+	}
+}
+
+// memChainDB is a minimal chain database backed by an in-memory block store.
+type memChainDB struct {
+	chaindb.Service
+	*memBlocks
+}
+
+func TestUpdateAttestationVotesCorrect(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 4, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider([]*phase0.Fork{{}})),
+	)
+	require.NoError(t, err)
+
+	canonical := true
+	nonCanonical := false
+	store := &memBlocks{blocks: make(map[phase0.Root]*chaindb.Block)}
+	store.add(0, 0x10, 0x00, &canonical)
+	store.add(3, 0x13, 0x10, &canonical)
+	// Slot 4, the start of epoch 1, is missed on the canonical chain.
+	store.add(4, 0x24, 0x13, &nonCanonical)
+	store.add(8, 0x18, 0x13, &canonical)
+
+	s := &Service{
+		chainDB:   &memChainDB{memBlocks: store},
+		chainTime: chainTime,
+	}
+
+	tests := []struct {
+		name          string
+		attestation   *chaindb.Attestation
+		sourceCorrect bool
+		targetCorrect bool
+	}{
+		{
+			name: "Genesis",
+			attestation: &chaindb.Attestation{
+				SourceEpoch: 0,
+				TargetEpoch: 0,
+				TargetRoot:  phase0.Root{0x10},
+			},
+			sourceCorrect: true,
+			targetCorrect: true,
+		},
+		{
+			name: "MissedEpochStart",
+			attestation: &chaindb.Attestation{
+				SourceEpoch: 1,
+				SourceRoot:  phase0.Root{0x13},
+				TargetEpoch: 2,
+				TargetRoot:  phase0.Root{0x18},
+			},
+			sourceCorrect: true,
+			targetCorrect: true,
+		},
+		{
+			name: "NonCanonical",
+			attestation: &chaindb.Attestation{
+				SourceEpoch: 1,
+				SourceRoot:  phase0.Root{0x24},
+				TargetEpoch: 2,
+				TargetRoot:  phase0.Root{0x24},
+			},
+			sourceCorrect: false,
+			targetCorrect: false,
+		},
+		{
+			name: "GenesisBlockSource",
+			attestation: &chaindb.Attestation{
+				SourceEpoch: 0,
+				SourceRoot:  phase0.Root{0x10},
+				TargetEpoch: 1,
+				TargetRoot:  phase0.Root{0x10},
+			},
+			sourceCorrect: true,
+			targetCorrect: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			epochRoots := make(map[phase0.Epoch]phase0.Root)
+			require.NoError(t, s.updateAttestationSourceCorrect(ctx, test.attestation, epochRoots))
+			require.NoError(t, s.updateAttestationTargetCorrect(ctx, test.attestation, epochRoots))
+			require.NotNil(t, test.attestation.SourceCorrect)
+			require.Equal(t, test.sourceCorrect, *test.attestation.SourceCorrect)
+			require.NotNil(t, test.attestation.TargetCorrect)
+			require.Equal(t, test.targetCorrect, *test.attestation.TargetCorrect)
+		})
+	}
+}
+
+func TestCanonicalizeBlocksReorg(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	canonical := true
+	nonCanonical := false
+	store := &memBlocks{blocks: make(map[phase0.Root]*chaindb.Block)}
+	// Common chain.
+	store.add(0, 0x10, 0x00, &canonical)
+	store.add(1, 0x11, 0x10, &canonical)
+	// Old chain, previously finalized to slot 4.
+	store.add(2, 0x12, 0x11, &canonical)
+	store.add(3, 0x13, 0x12, &canonical)
+	store.add(4, 0x14, 0x13, &canonical)
+	// New chain.
+	store.add(3, 0x23, 0x11, &nonCanonical)
+	store.add(4, 0x24, 0x23, nil)
+	store.add(5, 0x25, 0x24, nil)
+
+	s := &Service{
+		blocksProvider: store,
+		blocksSetter:   store,
+	}
+
+	ancestorSlot, canonicalRoots, err := s.canonicalizeBlocks(ctx, phase0.Root{0x25}, 4, nil)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Slot(1), ancestorSlot)
+	require.Len(t, canonicalRoots, 3)
+
+	updated, err := s.decanonicalizeBlocks(ctx, ancestorSlot+1, 4, canonicalRoots)
+	require.NoError(t, err)
+	require.Equal(t, 3, updated)
+
+	for root, expected := range map[byte]bool{
+		0x10: true,
+		0x11: true,
+		0x12: false,
+		0x13: false,
+		0x14: false,
+		0x23: true,
+		0x24: true,
+		0x25: true,
+	} {
+		block := store.blocks[phase0.Root{root}]
+		require.NotNil(t, block.Canonical, "%#x", root)
+		require.Equal(t, expected, *block.Canonical, "%#x", root)
+	}
+}
+
+func TestRepairCanonicalBlocks(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	canonical := true
+	nonCanonical := false
+	store := &memBlocks{blocks: make(map[phase0.Root]*chaindb.Block)}
+	// Verified chain.
+	store.add(0, 0x10, 0x00, &canonical)
+	// Finalized chain, with a missed slot at 3.
+	store.add(1, 0x11, 0x10, &canonical)
+	store.add(2, 0x12, 0x11, &nonCanonical)
+	store.add(4, 0x14, 0x12, &canonical)
+	// Fork, incorrectly marked as canonical or left indeterminate.
+	store.add(2, 0x22, 0x11, &canonical)
+	store.add(3, 0x23, 0x22, nil)
+
+	s := &Service{
+		blocksProvider: store,
+		blocksSetter:   store,
+	}
+
+	repaired, firstSlot, err := s.repairCanonicalBlocks(ctx, &phase0.Root{0x14}, 1, 4)
+	require.NoError(t, err)
+	require.Equal(t, 3, repaired)
+	require.NotNil(t, firstSlot)
+	require.Equal(t, phase0.Slot(2), *firstSlot)
+
+	for root, expected := range map[byte]bool{
+		0x10: true,
+		0x11: true,
+		0x12: true,
+		0x14: true,
+		0x22: false,
+		0x23: false,
+	} {
+		block := store.blocks[phase0.Root{root}]
+		require.NotNil(t, block.Canonical, "%#x", root)
+		require.Equal(t, expected, *block.Canonical, "%#x", root)
+	}
+
+	// A second pass finds nothing to repair.
+	repaired, firstSlot, err = s.repairCanonicalBlocks(ctx, &phase0.Root{0x14}, 1, 4)
+	require.NoError(t, err)
+	require.Equal(t, 0, repaired)
+	require.Nil(t, firstSlot)
+
+	// Indeterminate blocks marked as non-canonical do not affect derived data.
+	store.add(4, 0x24, 0x23, nil)
+	repaired, firstSlot, err = s.repairCanonicalBlocks(ctx, &phase0.Root{0x14}, 1, 4)
+	require.NoError(t, err)
+	require.Equal(t, 1, repaired)
+	require.Nil(t, firstSlot)
+
+	// All slots missed.
+	repaired, firstSlot, err = s.repairCanonicalBlocks(ctx, nil, 5, 6)
+	require.NoError(t, err)
+	require.Equal(t, 0, repaired)
+	require.Nil(t, firstSlot)
+
+	// Canonical block missing.
+	_, _, err = s.repairCanonicalBlocks(ctx, &phase0.Root{0x99}, 1, 4)
+	require.EqualError(t, err, "missing canonical block 0x9900000000000000000000000000000000000000000000000000000000000000")
+}
+
+// memMissedSlots is a minimal in-memory missed slot store.
+type memMissedSlots struct {
+	missedSlots map[phase0.Slot]*chaindb.MissedSlot
+}
+
+func (m *memMissedSlots) MissedSlotsForSlotRange(_ context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.MissedSlot, error) {
+	res := make([]*chaindb.MissedSlot, 0)
+	for slot, missedSlot := range m.missedSlots {
+		if slot >= startSlot && slot < endSlot {
+			res = append(res, missedSlot)
+		}
+	}
+	return res, nil
+}
+
+func (m *memMissedSlots) MissedSlotsForProposer(_ context.Context, _ phase0.ValidatorIndex) ([]*chaindb.MissedSlot, error) {
+	return nil, nil
+}
+
+func (m *memMissedSlots) SetMissedSlot(_ context.Context, missedSlot *chaindb.MissedSlot) error {
+	m.missedSlots[missedSlot.Slot] = missedSlot
+	return nil
+}
+
+func (m *memMissedSlots) DeleteMissedSlot(_ context.Context, slot phase0.Slot) error {
+	delete(m.missedSlots, slot)
+	return nil
+}
+
+// memProposerDuties is a minimal in-memory proposer duty store.
+type memProposerDuties []*chaindb.ProposerDuty
+
+func (m memProposerDuties) ProposerDutiesForSlotRange(_ context.Context, startSlot phase0.Slot, endSlot phase0.Slot) ([]*chaindb.ProposerDuty, error) {
+	res := make([]*chaindb.ProposerDuty, 0)
+	for _, duty := range m {
+		if duty.Slot >= startSlot && duty.Slot < endSlot {
+			res = append(res, duty)
+		}
+	}
+	return res, nil
+}
+
+func (m memProposerDuties) ProposerDutiesForValidator(_ context.Context, _ phase0.ValidatorIndex) ([]*chaindb.ProposerDuty, error) {
+	return nil, nil
+}
+
+func TestRecordMissedSlots(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 4, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider([]*phase0.Fork{{}})),
+	)
+	require.NoError(t, err)
+
+	canonical := true
+	nonCanonical := false
+	store := &memBlocks{blocks: make(map[phase0.Root]*chaindb.Block)}
+	store.add(1, 0x11, 0x10, &canonical)
+	store.add(2, 0x12, 0x11, &nonCanonical)
+	store.add(4, 0x14, 0x11, &canonical)
+	missedSlots := &memMissedSlots{missedSlots: make(map[phase0.Slot]*chaindb.MissedSlot)}
+	confirmedAt := time.Unix(1600000000, 0)
+	// Slot 4 was previously recorded as missed, but now has a canonical block.
+	missedSlots.missedSlots[4] = &chaindb.MissedSlot{Slot: 4, Epoch: 1, ConfirmedAt: confirmedAt}
+	// Slot 5 was previously recorded as missed, and remains so.
+	missedSlots.missedSlots[5] = &chaindb.MissedSlot{Slot: 5, Epoch: 1, ConfirmedAt: confirmedAt}
+
+	s := &Service{
+		blocksProvider:      store,
+		missedSlotsProvider: missedSlots,
+		missedSlotsSetter:   missedSlots,
+		proposerDutiesProvider: memProposerDuties{
+			{Slot: 2, ValidatorIndex: 22},
+			{Slot: 3, ValidatorIndex: 33},
+		},
+		chainTime: chainTime,
+	}
+
+	require.NoError(t, s.recordMissedSlots(ctx, 1, 5))
+	require.Len(t, missedSlots.missedSlots, 3)
+
+	require.Contains(t, missedSlots.missedSlots, phase0.Slot(2))
+	require.Equal(t, phase0.Epoch(0), missedSlots.missedSlots[2].Epoch)
+	require.NotNil(t, missedSlots.missedSlots[2].Proposer)
+	require.Equal(t, phase0.ValidatorIndex(22), *missedSlots.missedSlots[2].Proposer)
+
+	require.Contains(t, missedSlots.missedSlots, phase0.Slot(3))
+	require.NotNil(t, missedSlots.missedSlots[3].Proposer)
+	require.Equal(t, phase0.ValidatorIndex(33), *missedSlots.missedSlots[3].Proposer)
+
+	require.Contains(t, missedSlots.missedSlots, phase0.Slot(5))
+	require.Nil(t, missedSlots.missedSlots[5].Proposer)
+	require.Equal(t, confirmedAt, missedSlots.missedSlots[5].ConfirmedAt)
+
+	// Without proposer duties missed slots are recorded without a proposer.
+	s.proposerDutiesProvider = nil
+	require.NoError(t, s.recordMissedSlots(ctx, 6, 6))
+	require.Contains(t, missedSlots.missedSlots, phase0.Slot(6))
+	require.Equal(t, phase0.Epoch(1), missedSlots.missedSlots[6].Epoch)
+	require.Nil(t, missedSlots.missedSlots[6].Proposer)
+}
+
+func TestUpdateAttestationsDuplicates(t *testing.T) {
+	canonical := true
+	nonCanonical := false
+	attestation := func(inclusionSlot phase0.Slot, canonical *bool, beaconBlockRoot byte, indices ...phase0.ValidatorIndex) *chaindb.Attestation {
+		return &chaindb.Attestation{
+			InclusionSlot:      inclusionSlot,
+			Slot:               1,
+			AggregationIndices: indices,
+			BeaconBlockRoot:    phase0.Root{beaconBlockRoot},
+			Canonical:          canonical,
+		}
+	}
+
+	attestations := []*chaindb.Attestation{
+		// First inclusion of votes from 1 and 2.
+		attestation(2, &canonical, 0x01, 1, 2),
+		// Non-canonical inclusion of the vote from 3.
+		attestation(2, &nonCanonical, 0x01, 3),
+		// Repeat of the vote from 1.
+		attestation(3, &canonical, 0x01, 1),
+		// Repeat of the vote from 2, with the first inclusion of the vote from 3.
+		attestation(3, &canonical, 0x01, 2, 3),
+		// Conflicting vote from 4.
+		attestation(4, &canonical, 0x01, 4),
+		attestation(4, &canonical, 0x02, 4, 5),
+		// Non-canonical conflicting vote from 5 does not mark 5 as conflicting.
+		attestation(5, &nonCanonical, 0x01, 5),
+	}
+	updateAttestationsDuplicates(attestations)
+
+	expected := []struct {
+		duplicate   bool
+		conflicting bool
+	}{
+		{duplicate: false, conflicting: false},
+		{duplicate: false, conflicting: false},
+		{duplicate: true, conflicting: false},
+		{duplicate: false, conflicting: false},
+		{duplicate: false, conflicting: true},
+		{duplicate: false, conflicting: true},
+		{duplicate: false, conflicting: false},
+	}
+	for i := range attestations {
+		require.NotNil(t, attestations[i].Duplicate, "attestation %d", i)
+		require.Equal(t, expected[i].duplicate, *attestations[i].Duplicate, "attestation %d", i)
+		require.NotNil(t, attestations[i].Conflicting, "attestation %d", i)
+		require.Equal(t, expected[i].conflicting, *attestations[i].Conflicting, "attestation %d", i)
+	}
+}
+
+// blocksDB is a chain database that provides and sets blocks.
+type blocksDB interface {
+	chaindb.Service
+	chaindb.AttestationsProvider
+	chaindb.BlocksProvider
+	chaindb.BlocksSetter
+}
+
+// memMetadataDB is a chain database that holds metadata in memory, and fails
+// to provide attestations from a given slot.
+type memMetadataDB struct {
+	blocksDB
+	mu       sync.Mutex
+	metadata map[string][]byte
+	failSlot phase0.Slot
+}
+
+func (m *memMetadataDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (m *memMetadataDB) Metadata(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.metadata[key], nil
+}
+
+func (m *memMetadataDB) SetMetadata(_ context.Context, key string, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadata[key] = value
+	return nil
+}
+
+func (m *memMetadataDB) LatestBlocks(_ context.Context) ([]*chaindb.Block, error) {
+	return []*chaindb.Block{{Slot: 1000}}, nil
+}
+
+func (m *memMetadataDB) AttestationsForSlotRange(_ context.Context, startSlot phase0.Slot, _ phase0.Slot) ([]*chaindb.Attestation, error) {
+	if m.failSlot != 0 && startSlot == m.failSlot {
+		return nil, errors.New("failed")
+	}
+	return nil, nil
+}
+
+func TestUpdateAttestationsWithWorkers(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 4, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider([]*phase0.Fork{{}})),
+	)
+	require.NoError(t, err)
+
+	chainDB := &memMetadataDB{
+		blocksDB: mockchaindb.New().(blocksDB),
+		metadata: make(map[string][]byte),
+	}
+	s := &Service{
+		chainDB:        chainDB,
+		blocksProvider: chainDB,
+		chainTime:      chainTime,
+		workers:        4,
+	}
+
+	require.NoError(t, s.updateAttestationsWithWorkers(ctx, 10))
+	md, err := s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(10), md.LastFinalizedEpoch)
+
+	// The last finalized epoch is not advanced past an epoch that fails, even if
+	// later epochs in the same batch succeed.
+	chainDB.failSlot = chainTime.FirstSlotOfEpoch(14)
+	require.EqualError(t, s.updateAttestationsWithWorkers(ctx, 20), "failed to update attestations in epoch 14: failed to obtain attestations for epoch: failed")
+	md, err = s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(13), md.LastFinalizedEpoch)
+
+	// Carries on from the failed epoch once it succeeds.
+	chainDB.failSlot = 0
+	require.NoError(t, s.updateAttestationsWithWorkers(ctx, 20))
+	md, err = s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(20), md.LastFinalizedEpoch)
+}
+
+func TestUpdateAttestationsToCanonical(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 4, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider([]*phase0.Fork{{}})),
+	)
+	require.NoError(t, err)
+
+	chainDB := &memMetadataDB{
+		blocksDB: mockchaindb.New().(blocksDB),
+		metadata: make(map[string][]byte),
+	}
+	s := &Service{
+		chainDB:        chainDB,
+		blocksProvider: chainDB,
+		chainTime:      chainTime,
+		workers:        4,
+	}
+
+	// Nothing is updated before any blocks are canonical.
+	require.NoError(t, s.updateAttestationsToCanonical(ctx))
+	md, err := s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(0), md.LastFinalizedEpoch)
+
+	// Blocks are canonical to the start of epoch 11, so attestations are updated
+	// to epoch 10; a failure in epoch 6 holds the last finalized epoch at 5.
+	require.NoError(t, s.setMetadata(ctx, &metadata{LatestCanonicalSlot: chainTime.FirstSlotOfEpoch(11)}))
+	chainDB.failSlot = chainTime.FirstSlotOfEpoch(6)
+	require.Error(t, s.updateAttestationsToCanonical(ctx))
+	md, err = s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(5), md.LastFinalizedEpoch)
+
+	// The failed epoch is retried without any further blocks being canonicalized.
+	chainDB.failSlot = 0
+	require.NoError(t, s.updateAttestationsToCanonical(ctx))
+	md, err = s.getMetadata(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Epoch(10), md.LastFinalizedEpoch)
+}
+
+func TestPrecanonicalizeBlocks(t *testing.T) {
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	canonical := true
+	store := &memBlocks{blocks: make(map[phase0.Root]*chaindb.Block)}
+	// Previously finalized to slot 1.
+	store.add(0, 0x10, 0x00, &canonical)
+	store.add(1, 0x11, 0x10, &canonical)
+	// Chain to be finalized, spanning multiple worker ranges.
+	parent := byte(0x11)
+	for slot := phase0.Slot(2); slot < 2*slotsPerWorker; slot += 16 {
+		root := byte(0x20 + slot/16)
+		store.add(slot, root, parent, nil)
+		parent = root
+	}
+	// Fork off the chain to be finalized.
+	store.add(3, 0x03, 0x11, nil)
+
+	chainDB := &memMetadataDB{
+		blocksDB: mockchaindb.New().(blocksDB),
+		metadata: make(map[string][]byte),
+	}
+	s := &Service{
+		chainDB:        chainDB,
+		blocksProvider: store,
+		blocksSetter:   store,
+		workers:        4,
+	}
+	require.NoError(t, s.setMetadata(ctx, &metadata{LatestCanonicalSlot: 1}))
+
+	blocks, err := s.precanonicalizeBlocks(ctx, phase0.Root{parent})
+	require.NoError(t, err)
+	require.Len(t, blocks, len(store.blocks)-1)
+
+	// Blocks on the chain are canonical, both as stored and as returned; the fork is untouched.
+	for root, block := range store.blocks {
+		if root == (phase0.Root{0x03}) {
+			require.Nil(t, block.Canonical)
+			continue
+		}
+		require.NotNil(t, block.Canonical, "%#x", root)
+		require.True(t, *block.Canonical, "%#x", root)
+		if block.Slot > 1 {
+			require.True(t, *blocks[root].Canonical, "%#x", root)
+		}
+	}
+
+	// Committing finality finds nothing further to mark as canonical, and no reorg.
+	ancestorSlot, canonicalRoots, err := s.canonicalizeBlocks(ctx, phase0.Root{parent}, 1, blocks)
+	require.NoError(t, err)
+	require.Equal(t, phase0.Slot(1), ancestorSlot)
+	require.Len(t, canonicalRoots, len(store.blocks)-3)
+}
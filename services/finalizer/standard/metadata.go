@@ -23,9 +23,10 @@ import (
 
 // metadata stored about this service.
 type metadata struct {
-	LastFinalizedEpoch  phase0.Epoch   `json:"latest_epoch"`
-	LatestCanonicalSlot phase0.Slot    `json:"latest_canonical_slot"`
-	MissedEpochs        []phase0.Epoch `json:"missed_epochs,omitempty"`
+	LastFinalizedEpoch   phase0.Epoch   `json:"latest_epoch"`
+	LatestCanonicalSlot  phase0.Slot    `json:"latest_canonical_slot"`
+	MissedEpochs         []phase0.Epoch `json:"missed_epochs,omitempty"`
+	NextVerificationSlot phase0.Slot    `json:"next_verification_slot,omitempty"`
 }
 
 // metadataKey is the key for the metadata.
@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/util"
 )
 
 var metricsNamespace = "chaind_finalizer"
@@ -27,6 +28,12 @@ var metricsNamespace = "chaind_finalizer"
 var highestEpoch phase0.Epoch
 var latestEpoch prometheus.Gauge
 var epochsProcessed prometheus.Gauge
+var reorgs prometheus.Counter
+var verifiedSlot prometheus.Gauge
+var repairedBlocks prometheus.Counter
+var workerFailures prometheus.Counter
+var errorsTotal *prometheus.CounterVec
+var halted prometheus.Gauge
 
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
 	if latestEpoch != nil {
@@ -62,6 +69,63 @@ func registerPrometheusMetrics(ctx context.Context) error {
 		return errors.Wrap(err, "failed to register epochs_processed")
 	}
 
+	reorgs = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reorgs_total",
+		Help:      "Number of reorgs that altered previously finalized blocks",
+	})
+	if err := prometheus.Register(reorgs); err != nil {
+		return errors.Wrap(err, "failed to register reorgs_total")
+	}
+
+	verifiedSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "verified_slot",
+		Help:      "Latest slot for which canonical blocks have been verified",
+	})
+	if err := prometheus.Register(verifiedSlot); err != nil {
+		return errors.Wrap(err, "failed to register verified_slot")
+	}
+
+	repairedBlocks = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "repaired_blocks_total",
+		Help:      "Number of blocks whose canonical state was repaired by verification",
+	})
+	if err := prometheus.Register(repairedBlocks); err != nil {
+		return errors.Wrap(err, "failed to register repaired_blocks_total")
+	}
+
+	workerFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "worker_failures_total",
+		Help:      "Number of units of work that a worker failed to complete",
+	})
+	if err := prometheus.Register(workerFailures); err != nil {
+		return errors.Wrap(err, "failed to register worker_failures_total")
+	}
+
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "errors_total",
+		Help:      "Number of errors, by type",
+	}, []string{"type"})
+	if err := prometheus.Register(errorsTotal); err != nil {
+		return errors.Wrap(err, "failed to register errors_total")
+	}
+	for _, errorClass := range util.ErrorClasses {
+		errorsTotal.WithLabelValues(errorClass)
+	}
+
+	halted = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "halted",
+		Help:      "1 if the service has halted due to an inconsistency in strict mode",
+	})
+	if err := prometheus.Register(halted); err != nil {
+		return errors.Wrap(err, "failed to register halted")
+	}
+
 	return nil
 }
 
@@ -83,3 +147,37 @@ func monitorEpochProcessed(epoch phase0.Epoch) {
 		}
 	}
 }
+
+func monitorReorg() {
+	if reorgs != nil {
+		reorgs.Inc()
+	}
+}
+
+func monitorVerification(slot phase0.Slot, repaired int) {
+	if verifiedSlot != nil {
+		verifiedSlot.Set(float64(slot))
+	}
+	if repairedBlocks != nil {
+		repairedBlocks.Add(float64(repaired))
+	}
+}
+
+func monitorWorkerFailure() {
+	if workerFailures != nil {
+		workerFailures.Inc()
+	}
+}
+
+// monitorError counts an error by its type.
+func monitorError(err error) {
+	if errorsTotal != nil && err != nil {
+		errorsTotal.WithLabelValues(util.ClassifyError(err)).Inc()
+	}
+}
+
+func monitorHalted() {
+	if halted != nil {
+		halted.Set(1)
+	}
+}
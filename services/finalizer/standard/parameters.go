@@ -15,6 +15,7 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
@@ -27,14 +28,19 @@ import (
 )
 
 type parameters struct {
-	logLevel         zerolog.Level
-	monitor          metrics.Service
-	eth2Client       eth2client.Service
-	chainDB          chaindb.Service
-	chainTime        chaintime.Service
-	blocks           blocks.Service
-	finalityHandlers []handlers.FinalityHandler
-	activitySem      *semaphore.Weighted
+	logLevel           zerolog.Level
+	logLevelSampler    zerolog.Sampler
+	monitor            metrics.Service
+	eth2Client         eth2client.Service
+	chainDB            chaindb.Service
+	chainTime          chaintime.Service
+	blocks             blocks.Service
+	finalityHandlers   []handlers.FinalityHandler
+	activitySem        *semaphore.Weighted
+	startSlot          int64
+	eventsStallTimeout time.Duration
+	strict             bool
+	workers            int
 }
 
 // Parameter is the interface for service parameters.
@@ -55,6 +61,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -104,10 +118,43 @@ func WithActivitySem(sem *semaphore.Weighted) Parameter {
 	})
 }
 
+// WithStartSlot sets the slot from which to start canonicalizing blocks.
+func WithStartSlot(startSlot int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.startSlot = startSlot
+	})
+}
+
+// WithEventsStallTimeout sets the time without events after which the event
+// stream is considered lost and is re-established.  0 disables the check.
+func WithEventsStallTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eventsStallTimeout = timeout
+	})
+}
+
+// WithStrict sets whether an inconsistency in the data halts the service,
+// rather than being logged.
+func WithStrict(strict bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.strict = strict
+	})
+}
+
+// WithWorkers sets the number of concurrent workers used to fetch and
+// canonicalize blocks, and to update attestations, on finality.
+func WithWorkers(workers int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.workers = workers
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:  zerolog.GlobalLevel(),
+		startSlot: -1,
+		workers:   1,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -130,6 +177,9 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.activitySem == nil {
 		return nil, errors.New("no activity semaphore specified")
 	}
+	if parameters.workers < 1 {
+		return nil, errors.New("workers must be at least 1")
+	}
 
 	return &parameters, nil
 }
@@ -15,9 +15,11 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
@@ -25,19 +27,26 @@ import (
 	"github.com/wealdtech/chaind/services/blocks"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a finalizer service.
 type Service struct {
-	eth2Client       eth2client.Service
-	chainDB          chaindb.Service
-	blocksProvider   chaindb.BlocksProvider
-	blocksSetter     chaindb.BlocksSetter
-	chainTime        chaintime.Service
-	blocks           blocks.Service
-	finalityHandlers []handlers.FinalityHandler
-	activitySem      *semaphore.Weighted
+	eth2Client             eth2client.Service
+	chainDB                chaindb.Service
+	blocksProvider         chaindb.BlocksProvider
+	blocksSetter           chaindb.BlocksSetter
+	missedSlotsProvider    chaindb.MissedSlotsProvider
+	missedSlotsSetter      chaindb.MissedSlotsSetter
+	proposerDutiesProvider chaindb.ProposerDutiesProvider
+	chainTime              chaintime.Service
+	blocks                 blocks.Service
+	finalityHandlers       []handlers.FinalityHandler
+	activitySem            *semaphore.Weighted
+	eventsStallTimeout     time.Duration
+	halt                   *util.Halt
+	workers                int
 }
 
 // module-wide log.
@@ -52,6 +61,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "finalizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -67,27 +79,56 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support block setting")
 	}
 
+	missedSlotsProvider, isMissedSlotsProvider := parameters.chainDB.(chaindb.MissedSlotsProvider)
+	if !isMissedSlotsProvider {
+		return nil, errors.New("chain DB does not support missed slot providing")
+	}
+
+	missedSlotsSetter, isMissedSlotsSetter := parameters.chainDB.(chaindb.MissedSlotsSetter)
+	if !isMissedSlotsSetter {
+		return nil, errors.New("chain DB does not support missed slot setting")
+	}
+
+	// Proposer duties are optional; if not available missed slots are recorded
+	// without their proposer.
+	proposerDutiesProvider, _ := parameters.chainDB.(chaindb.ProposerDutiesProvider)
+
 	s := &Service{
-		eth2Client:       parameters.eth2Client,
-		chainDB:          parameters.chainDB,
-		blocksProvider:   blocksProvider,
-		blocksSetter:     blocksSetter,
-		chainTime:        parameters.chainTime,
-		blocks:           parameters.blocks,
-		finalityHandlers: parameters.finalityHandlers,
-		activitySem:      parameters.activitySem,
+		eth2Client:             parameters.eth2Client,
+		chainDB:                parameters.chainDB,
+		blocksProvider:         blocksProvider,
+		blocksSetter:           blocksSetter,
+		missedSlotsProvider:    missedSlotsProvider,
+		missedSlotsSetter:      missedSlotsSetter,
+		proposerDutiesProvider: proposerDutiesProvider,
+		chainTime:              parameters.chainTime,
+		blocks:                 parameters.blocks,
+		finalityHandlers:       parameters.finalityHandlers,
+		activitySem:            parameters.activitySem,
+		eventsStallTimeout:     parameters.eventsStallTimeout,
+		halt:                   util.NewHalt(parameters.strict),
+		workers:                parameters.workers,
 	}
 
-	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"finalized_checkpoint"}, func(event *api.Event) {
-		if event.Data == nil {
-			// Happens when the channel shuts down, nothing to worry about.
+	if parameters.startSlot >= 0 {
+		// Explicit requirement to start at a given slot.
+		if err := s.setStartSlot(ctx, phase0.Slot(parameters.startSlot)); err != nil {
+			return nil, errors.Wrap(err, "failed to set start slot")
+		}
+	}
+
+	// Set up the handler for finality checkpoints.  Head events are also
+	// subscribed to, as finality checkpoints are too infrequent to show that
+	// the event stream is still alive.
+	if err := util.SubscribeEvents(ctx, log, s.eth2Client.(eth2client.EventsProvider), []string{"finalized_checkpoint", "head"}, s.eventsStallTimeout, func(event *api.Event) {
+		eventData, isFinalizedCheckpoint := event.Data.(*api.FinalizedCheckpointEvent)
+		if !isFinalizedCheckpoint {
+			// Either a head event or the channel shutting down, nothing to worry about.
 			return
 		}
-		eventData := event.Data.(*api.FinalizedCheckpointEvent)
 		log.Trace().Str("event", eventData.String()).Msg("Received event")
 		s.OnFinalityCheckpointReceived(ctx, eventData.Epoch, eventData.Block, eventData.State)
-	}); err != nil {
+	}, s.onEventsResubscribed); err != nil {
 		return nil, errors.Wrap(err, "failed to add finality checkpoint received handler")
 	}
 
@@ -100,3 +141,22 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	return s, nil
 }
+
+// setStartSlot sets the slot from which to start canonicalizing blocks.  Blocks
+// before this slot are not expected to be in the database, and attestations are
+// updated from the epoch after that containing the slot.
+func (s *Service) setStartSlot(ctx context.Context, slot phase0.Slot) error {
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain metadata")
+		}
+		md.LatestCanonicalSlot = slot
+		md.LastFinalizedEpoch = s.chainTime.SlotToEpoch(slot)
+		md.NextVerificationSlot = slot
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+}
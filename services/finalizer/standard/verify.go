@@ -0,0 +1,296 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// verificationSlots is the maximum number of slots verified on each finality
+// update, so that verifying a large database does not hold up finalization.
+const verificationSlots = 8192
+
+// verifyCanonicalBlocks verifies that the canonical state of the blocks in the
+// database up to the given finalized epoch matches the finalized chain of the
+// beacon node, repairing any blocks that do not match.  After verification each
+// slot has a single canonical block, or none if the slot was missed in which
+// case the slot is recorded as missed.
+// In strict mode a mismatch is not repaired; instead the service halts.
+// Verification continues from where it left off, and covers at most
+// verificationSlots slots each time it is called.
+// If any previously canonical blocks are found to be non-canonical, or the
+// reverse, it returns the first epoch affected.
+func (s *Service) verifyCanonicalBlocks(ctx context.Context, finalizedEpoch phase0.Epoch) (*phase0.Epoch, error) {
+	var repairEpoch *phase0.Epoch
+	var startSlot phase0.Slot
+	var endSlot phase0.Slot
+	var repaired int
+	verified := false
+	err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		repairEpoch = nil
+		verified = false
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain metadata")
+		}
+
+		startSlot = md.NextVerificationSlot
+		if startSlot == 0 {
+			// Verification has not started.  The database does not necessarily
+			// start at genesis, so start from its earliest block; the earliest
+			// block itself is only verified if it is the genesis block, as its
+			// parent is not in the database.
+			earliestBlocks, err := s.blocksProvider.Blocks(ctx, &chaindb.BlockFilter{
+				Limit: 1,
+				Order: chaindb.OrderEarliest,
+			})
+			if err != nil {
+				return errors.Wrap(err, "failed to obtain earliest block")
+			}
+			if len(earliestBlocks) == 0 {
+				return nil
+			}
+			if earliestBlocks[0].Slot > 0 {
+				startSlot = earliestBlocks[0].Slot + 1
+			}
+		}
+		// Only blocks that have already been canonicalized are verified.
+		endSlot = s.chainTime.FirstSlotOfEpoch(finalizedEpoch)
+		if md.LatestCanonicalSlot < endSlot {
+			endSlot = md.LatestCanonicalSlot
+		}
+		if startSlot > endSlot {
+			return nil
+		}
+		if endSlot-startSlot >= verificationSlots {
+			endSlot = startSlot + verificationSlots - 1
+		}
+		log := log.With().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Logger()
+		log.Trace().Msg("Verifying canonical blocks")
+
+		root, err := s.canonicalRoot(ctx, startSlot, endSlot)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain canonical root")
+		}
+
+		var firstSlot *phase0.Slot
+		repaired, firstSlot, err = s.repairCanonicalBlocks(ctx, root, startSlot, endSlot)
+		if err != nil {
+			return errors.Wrap(err, "failed to repair canonical blocks")
+		}
+
+		if repaired > 0 && s.halt.Inconsistent() {
+			// Leave the database untouched for investigation.
+			log.Error().Int("divergent_blocks", repaired).Msg("Canonical state of blocks does not match finalized chain; halting")
+			monitorHalted()
+			return util.ErrHalted
+		}
+
+		if repaired > 0 {
+			log.Warn().Int("repaired_blocks", repaired).Msg("Canonical state of blocks did not match finalized chain; repaired")
+		}
+		if firstSlot != nil {
+			firstEpoch := s.chainTime.SlotToEpoch(*firstSlot)
+			// As with a reorg, attestations for the epoch prior to the repair can be
+			// included in repaired blocks, so rewind to before that epoch to ensure
+			// they are updated.
+			if firstEpoch < 2 {
+				md.LastFinalizedEpoch = 0
+			} else if md.LastFinalizedEpoch > firstEpoch-2 {
+				md.LastFinalizedEpoch = firstEpoch - 2
+			}
+			repairEpoch = &firstEpoch
+		}
+
+		if err := s.recordMissedSlots(ctx, startSlot, endSlot); err != nil {
+			return errors.Wrap(err, "failed to record missed slots")
+		}
+
+		md.NextVerificationSlot = endSlot + 1
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		verified = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		return nil, nil
+	}
+	monitorVerification(endSlot, repaired)
+	log.Trace().Uint64("start_slot", uint64(startSlot)).Uint64("end_slot", uint64(endSlot)).Int("repaired_blocks", repaired).Msg("Verified canonical blocks")
+
+	return repairEpoch, nil
+}
+
+// canonicalRoot obtains the root of the latest block in the given inclusive
+// slot range on the beacon node's canonical chain.  It returns nil if all of
+// the slots in the range were missed.
+func (s *Service) canonicalRoot(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) (*phase0.Root, error) {
+	for slot := endSlot; ; slot-- {
+		signedBlock, err := s.eth2Client.(eth2client.SignedBeaconBlockProvider).SignedBeaconBlock(ctx, fmt.Sprintf("%d", slot))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain block from chain")
+		}
+		if signedBlock != nil {
+			root, err := signedBlock.Root()
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to obtain block root")
+			}
+			return &root, nil
+		}
+		if slot == startSlot {
+			return nil, nil
+		}
+	}
+}
+
+// repairCanonicalBlocks marks the blocks in the given inclusive slot range that
+// are on the chain ending with the given root as canonical, and all other
+// blocks in the range as non-canonical.  It returns the number of blocks that
+// were updated, and the slot of the first block that was either canonical and
+// is no longer, or is now canonical and was not.  Indeterminate blocks that
+// are marked as non-canonical do not alter derived data, so are not included.
+func (s *Service) repairCanonicalBlocks(ctx context.Context,
+	root *phase0.Root,
+	startSlot phase0.Slot,
+	endSlot phase0.Slot,
+) (
+	int,
+	*phase0.Slot,
+	error,
+) {
+	// Walk back through the chain to find the canonical blocks in the range.
+	// Blocks on the chain that are missing from the database are fetched from
+	// the beacon node.
+	canonicalRoots := make(map[phase0.Root]bool)
+	for root != nil {
+		block, err := s.fetchBlock(ctx, *root)
+		if err != nil {
+			return 0, nil, err
+		}
+		if block == nil {
+			return 0, nil, fmt.Errorf("missing canonical block %#x", *root)
+		}
+		if block.Slot < startSlot {
+			break
+		}
+		canonicalRoots[block.Root] = true
+		if block.Slot == 0 {
+			break
+		}
+		root = &block.ParentRoot
+	}
+
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, startSlot, endSlot+1)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "failed to obtain blocks")
+	}
+
+	repaired := 0
+	var firstSlot *phase0.Slot
+	for _, block := range blocks {
+		canonical := canonicalRoots[block.Root]
+		if block.Canonical != nil && *block.Canonical == canonical {
+			continue
+		}
+		if canonical || block.Canonical != nil {
+			if firstSlot == nil || block.Slot < *firstSlot {
+				slot := block.Slot
+				firstSlot = &slot
+			}
+		}
+		log.Debug().Uint64("slot", uint64(block.Slot)).Str("root", fmt.Sprintf("%#x", block.Root)).Bool("canonical", canonical).Msg("Repairing canonical state of block")
+		block.Canonical = &canonical
+		if err := s.blocksSetter.SetBlock(ctx, block); err != nil {
+			return 0, nil, errors.Wrap(err, "failed to set block")
+		}
+		repaired++
+	}
+
+	return repaired, firstSlot, nil
+}
+
+// recordMissedSlots records the slots in the given inclusive slot range without
+// a canonical block as missed, and removes the record for any previously missed
+// slot that now has a canonical block.
+func (s *Service) recordMissedSlots(ctx context.Context, startSlot phase0.Slot, endSlot phase0.Slot) error {
+	blocks, err := s.blocksProvider.BlocksForSlotRange(ctx, startSlot, endSlot+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain blocks")
+	}
+	canonicalSlots := make(map[phase0.Slot]bool)
+	for _, block := range blocks {
+		if block.Canonical != nil && *block.Canonical {
+			canonicalSlots[block.Slot] = true
+		}
+	}
+
+	missedSlots, err := s.missedSlotsProvider.MissedSlotsForSlotRange(ctx, startSlot, endSlot+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain missed slots")
+	}
+	recordedSlots := make(map[phase0.Slot]bool)
+	for _, missedSlot := range missedSlots {
+		if canonicalSlots[missedSlot.Slot] {
+			log.Debug().Uint64("slot", uint64(missedSlot.Slot)).Msg("Previously missed slot has canonical block; removing")
+			if err := s.missedSlotsSetter.DeleteMissedSlot(ctx, missedSlot.Slot); err != nil {
+				return errors.Wrap(err, "failed to delete missed slot")
+			}
+			continue
+		}
+		recordedSlots[missedSlot.Slot] = true
+	}
+
+	proposers := make(map[phase0.Slot]phase0.ValidatorIndex)
+	if s.proposerDutiesProvider != nil {
+		proposerDuties, err := s.proposerDutiesProvider.ProposerDutiesForSlotRange(ctx, startSlot, endSlot+1)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain proposer duties")
+		}
+		for _, proposerDuty := range proposerDuties {
+			proposers[proposerDuty.Slot] = proposerDuty.ValidatorIndex
+		}
+	}
+
+	confirmedAt := time.Now()
+	for slot := startSlot; slot <= endSlot; slot++ {
+		if canonicalSlots[slot] || recordedSlots[slot] {
+			continue
+		}
+		missedSlot := &chaindb.MissedSlot{
+			Slot:        slot,
+			Epoch:       s.chainTime.SlotToEpoch(slot),
+			ConfirmedAt: confirmedAt,
+		}
+		if proposer, exists := proposers[slot]; exists {
+			missedSlot.Proposer = &proposer
+		}
+		if err := s.missedSlotsSetter.SetMissedSlot(ctx, missedSlot); err != nil {
+			return errors.Wrap(err, "failed to set missed slot")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_gaps"
+
+var missingEpochs *prometheus.GaugeVec
+var repairs *prometheus.CounterVec
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if missingEpochs != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	missingEpochs = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "missing_epochs",
+		Help:      "Number of epochs with data missing from the database at the latest scan",
+	}, []string{"dataset"})
+	if err := prometheus.Register(missingEpochs); err != nil {
+		return errors.Wrap(err, "failed to register missing_epochs")
+	}
+
+	repairs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "repairs_total",
+		Help:      "Number of ranges of epochs repaired",
+	}, []string{"dataset", "result"})
+	if err := prometheus.Register(repairs); err != nil {
+		return errors.Wrap(err, "failed to register repairs_total")
+	}
+
+	return nil
+}
+
+func monitorMissingEpochs(dataset string, count int) {
+	if missingEpochs != nil {
+		missingEpochs.WithLabelValues(dataset).Set(float64(count))
+	}
+}
+
+func monitorRepair(dataset string, succeeded bool) {
+	if repairs != nil {
+		if succeeded {
+			repairs.WithLabelValues(dataset, "succeeded").Inc()
+		} else {
+			repairs.WithLabelValues(dataset, "failed").Inc()
+		}
+	}
+}
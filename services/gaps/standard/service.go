@@ -0,0 +1,223 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/admin"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+)
+
+// Service is a gap detection and repair service, periodically scanning the
+// chain database for epochs with missing data and re-fetching them.
+type Service struct {
+	chainTime    chaintime.Service
+	gapsProvider chaindb.GapsProvider
+	interval     time.Duration
+	maxRepairs   uint64
+	reindexers   map[string]admin.EpochReindexer
+	scheduler    scheduler.Service
+}
+
+// dataset is a set of data that is checked for gaps.
+type dataset struct {
+	name string
+	// missingEpochs returns the epochs up to and including maxEpoch with data missing.
+	missingEpochs func(ctx context.Context, maxEpoch phase0.Epoch) ([]phase0.Epoch, error)
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "gaps").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	gapsProvider, isProvider := parameters.chainDB.(chaindb.GapsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide gaps")
+	}
+
+	s := &Service{
+		chainTime:    parameters.chainTime,
+		gapsProvider: gapsProvider,
+		interval:     parameters.interval,
+		maxRepairs:   parameters.maxRepairs,
+		reindexers:   parameters.reindexers,
+		scheduler:    parameters.scheduler,
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically scans for and repairs gaps.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.scan(ctx)
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping gap detection")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// datasets returns the datasets that are checked for gaps.
+func (s *Service) datasets() []*dataset {
+	return []*dataset{
+		{
+			name: "beaconcommittees",
+			missingEpochs: func(ctx context.Context, maxEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+				slots, err := s.gapsProvider.SlotsWithoutBeaconCommittees(ctx, 0, s.chainTime.FirstSlotOfEpoch(maxEpoch+1)-1)
+				if err != nil {
+					return nil, err
+				}
+				return s.slotsToEpochs(slots), nil
+			},
+		},
+		{
+			name: "proposerduties",
+			missingEpochs: func(ctx context.Context, maxEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+				slots, err := s.gapsProvider.SlotsWithoutProposerDuties(ctx, 0, s.chainTime.FirstSlotOfEpoch(maxEpoch+1)-1)
+				if err != nil {
+					return nil, err
+				}
+				return s.slotsToEpochs(slots), nil
+			},
+		},
+		{
+			name: "validators.balances",
+			missingEpochs: func(ctx context.Context, maxEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+				return s.gapsProvider.EpochsWithoutValidatorBalances(ctx, 0, maxEpoch)
+			},
+		},
+	}
+}
+
+// scan scans each dataset for gaps up to the last completed epoch, and
+// repairs those that it can.  Failure to scan one dataset does not stop the
+// others from being scanned.
+func (s *Service) scan(ctx context.Context) {
+	currentEpoch := s.chainTime.CurrentEpoch()
+	if currentEpoch == 0 {
+		log.Trace().Msg("No completed epochs; not scanning")
+		return
+	}
+	maxEpoch := currentEpoch - 1
+
+	for _, dataset := range s.datasets() {
+		log := log.With().Str("dataset", dataset.name).Logger()
+		epochs, err := dataset.missingEpochs(ctx, maxEpoch)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to scan for gaps")
+			continue
+		}
+		monitorMissingEpochs(dataset.name, len(epochs))
+		if len(epochs) == 0 {
+			log.Trace().Msg("No gaps")
+			continue
+		}
+		log.Info().Int("missing_epochs", len(epochs)).Msg("Found gaps")
+
+		reindexer, exists := s.reindexers[dataset.name]
+		if !exists {
+			log.Debug().Msg("No reindexer for dataset; not repairing gaps")
+			continue
+		}
+		s.repair(ctx, dataset.name, reindexer, epochs)
+	}
+}
+
+// repair re-fetches the given epochs, up to the maximum number of repairs.
+func (s *Service) repair(ctx context.Context,
+	name string,
+	reindexer admin.EpochReindexer,
+	epochs []phase0.Epoch,
+) {
+	gaps := util.EpochRanges{}
+	for _, epoch := range epochs {
+		gaps.Add(epoch)
+	}
+
+	remaining := s.maxRepairs
+	for _, gap := range gaps {
+		if remaining == 0 {
+			log.Debug().Str("dataset", name).Msg("Reached maximum repairs for this scan")
+			return
+		}
+		end := gap.End
+		if uint64(end-gap.Start)+1 > remaining {
+			end = gap.Start + phase0.Epoch(remaining) - 1
+		}
+		remaining -= uint64(end-gap.Start) + 1
+
+		log := log.With().Str("dataset", name).Uint64("start_epoch", uint64(gap.Start)).Uint64("end_epoch", uint64(end)).Logger()
+		start := gap.Start
+		var err error
+		if schedErr := s.scheduler.Run(ctx, "repair "+name, scheduler.PriorityRepair, func(ctx context.Context) {
+			err = reindexer.ReindexEpochs(ctx, start, end)
+		}); schedErr != nil {
+			log.Warn().Err(schedErr).Msg("Failed to schedule gap repair")
+			return
+		}
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to repair gap")
+			monitorRepair(name, false)
+			continue
+		}
+		log.Info().Msg("Repaired gap")
+		monitorRepair(name, true)
+	}
+}
+
+// slotsToEpochs returns the distinct epochs of the given ordered slots.
+func (s *Service) slotsToEpochs(slots []phase0.Slot) []phase0.Epoch {
+	epochs := make([]phase0.Epoch, 0)
+	for _, slot := range slots {
+		epoch := s.chainTime.SlotToEpoch(slot)
+		if len(epochs) > 0 && epochs[len(epochs)-1] == epoch {
+			continue
+		}
+		epochs = append(epochs, epoch)
+	}
+
+	return epochs
+}
@@ -0,0 +1,173 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/admin"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	mockscheduler "github.com/wealdtech/chaind/services/scheduler/mock"
+)
+
+// testChainTime is a chain time service with 32 slots per epoch.
+type testChainTime struct {
+	chaintime.Service
+	currentEpoch phase0.Epoch
+}
+
+func (c *testChainTime) CurrentEpoch() phase0.Epoch {
+	return c.currentEpoch
+}
+
+func (c *testChainTime) SlotToEpoch(slot phase0.Slot) phase0.Epoch {
+	return phase0.Epoch(slot / 32)
+}
+
+func (c *testChainTime) FirstSlotOfEpoch(epoch phase0.Epoch) phase0.Slot {
+	return phase0.Slot(epoch * 32)
+}
+
+// testGapsProvider returns fixed gaps.
+type testGapsProvider struct {
+	committeeSlots []phase0.Slot
+	dutySlots      []phase0.Slot
+	balanceEpochs  []phase0.Epoch
+}
+
+func (p *testGapsProvider) SlotsWithoutBeaconCommittees(_ context.Context, _ phase0.Slot, _ phase0.Slot) ([]phase0.Slot, error) {
+	return p.committeeSlots, nil
+}
+
+func (p *testGapsProvider) SlotsWithoutProposerDuties(_ context.Context, _ phase0.Slot, _ phase0.Slot) ([]phase0.Slot, error) {
+	return nil, errors.New("failed")
+}
+
+func (p *testGapsProvider) EpochsWithoutValidatorBalances(_ context.Context, _ phase0.Epoch, _ phase0.Epoch) ([]phase0.Epoch, error) {
+	return p.balanceEpochs, nil
+}
+
+// testReindexer records the ranges it is asked to reindex.
+type testReindexer struct {
+	ranges [][2]phase0.Epoch
+	fail   bool
+}
+
+func (r *testReindexer) ReindexEpochs(_ context.Context, start phase0.Epoch, end phase0.Epoch) error {
+	r.ranges = append(r.ranges, [2]phase0.Epoch{start, end})
+	if r.fail {
+		return errors.New("failed")
+	}
+	return nil
+}
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+
+	committees := &testReindexer{}
+	duties := &testReindexer{}
+	s := &Service{
+		chainTime: &testChainTime{currentEpoch: 20},
+		gapsProvider: &testGapsProvider{
+			committeeSlots: []phase0.Slot{64, 65, 95, 96, 320},
+			balanceEpochs:  []phase0.Epoch{5},
+		},
+		maxRepairs: 100,
+		scheduler:  mockscheduler.New(),
+		reindexers: map[string]admin.EpochReindexer{
+			"beaconcommittees": committees,
+			"proposerduties":   duties,
+		},
+	}
+	s.scan(ctx)
+
+	require.Equal(t, [][2]phase0.Epoch{{2, 3}, {10, 10}}, committees.ranges)
+	// Proposer duties failed to scan, so should not be repaired.
+	require.Empty(t, duties.ranges)
+}
+
+func TestScanNoCompletedEpochs(t *testing.T) {
+	ctx := context.Background()
+
+	committees := &testReindexer{}
+	s := &Service{
+		chainTime: mockchaintime.New(),
+		gapsProvider: &testGapsProvider{
+			committeeSlots: []phase0.Slot{0},
+		},
+		maxRepairs: 100,
+		scheduler:  mockscheduler.New(),
+		reindexers: map[string]admin.EpochReindexer{
+			"beaconcommittees": committees,
+		},
+	}
+	s.scan(ctx)
+
+	require.Empty(t, committees.ranges)
+}
+
+func TestRepair(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		epochs     []phase0.Epoch
+		maxRepairs uint64
+		fail       bool
+		expected   [][2]phase0.Epoch
+	}{
+		{
+			name:       "Single",
+			epochs:     []phase0.Epoch{5},
+			maxRepairs: 10,
+			expected:   [][2]phase0.Epoch{{5, 5}},
+		},
+		{
+			name:       "Ranges",
+			epochs:     []phase0.Epoch{1, 2, 3, 7, 9, 10},
+			maxRepairs: 10,
+			expected:   [][2]phase0.Epoch{{1, 3}, {7, 7}, {9, 10}},
+		},
+		{
+			name:       "MaxRepairs",
+			epochs:     []phase0.Epoch{1, 2, 3, 7, 8, 9, 10},
+			maxRepairs: 5,
+			expected:   [][2]phase0.Epoch{{1, 3}, {7, 8}},
+		},
+		{
+			name:       "Failures",
+			epochs:     []phase0.Epoch{1, 3},
+			maxRepairs: 10,
+			fail:       true,
+			expected:   [][2]phase0.Epoch{{1, 1}, {3, 3}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reindexer := &testReindexer{fail: test.fail}
+			s := &Service{
+				maxRepairs: test.maxRepairs,
+				scheduler:  mockscheduler.New(),
+			}
+			s.repair(ctx, "test", reindexer, test.epochs)
+			require.Equal(t, test.expected, reindexer.ranges)
+		})
+	}
+}
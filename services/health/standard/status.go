@@ -0,0 +1,160 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/util"
+)
+
+// statusJSON is the response for the health endpoints.
+type statusJSON struct {
+	Status       string               `json:"status"`
+	CurrentEpoch string               `json:"current_epoch"`
+	Services     []*serviceStatusJSON `json:"services"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// serviceStatusJSON is the status of an individual service.
+// Lag is the number of epochs between the latest processed and the current
+// epoch of the chain.
+type serviceStatusJSON struct {
+	Service string `json:"service"`
+	Epoch   string `json:"epoch,omitempty"`
+	Lag     string `json:"lag,omitempty"`
+	Ready   bool   `json:"ready"`
+	Stalled bool   `json:"stalled"`
+}
+
+// handleHealthz handles /healthz, which fails if any service has stalled.
+func (s *Service) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	status := s.status(r.Context(), time.Now())
+	healthy := status.Error == ""
+	for _, service := range status.Services {
+		if service.Stalled {
+			healthy = false
+		}
+	}
+	if healthy {
+		status.Status = "ok"
+	} else {
+		status.Status = "unhealthy"
+	}
+	writeStatus(w, status, healthy)
+}
+
+// handleReadyz handles /readyz, which fails if any service is lagging the chain.
+func (s *Service) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	status := s.status(r.Context(), time.Now())
+	ready := status.Error == ""
+	for _, service := range status.Services {
+		if !service.Ready {
+			ready = false
+		}
+	}
+	if ready {
+		status.Status = "ok"
+	} else {
+		status.Status = "not_ready"
+	}
+	writeStatus(w, status, ready)
+}
+
+// status calculates the status of the services.
+func (s *Service) status(ctx context.Context, now time.Time) *statusJSON {
+	currentEpoch := s.chainTime.CurrentEpoch()
+	res := &statusJSON{
+		CurrentEpoch: fmt.Sprintf("%d", currentEpoch),
+		Services:     make([]*serviceStatusJSON, 0),
+	}
+
+	progresses, err := util.ServiceProgress(ctx, s.chainDB)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to obtain service progress")
+		res.Error = "failed to obtain service progress"
+		return res
+	}
+
+	progressMap := make(map[string]*util.Progress, len(progresses))
+	for _, progress := range progresses {
+		progressMap[progress.Source.Service] = progress
+	}
+	services := s.services
+	if len(services) == 0 {
+		// Check all services that have made progress.
+		for _, progress := range progresses {
+			services = append(services, progress.Source.Service)
+		}
+	}
+
+	s.advancesMu.Lock()
+	defer s.advancesMu.Unlock()
+	for _, service := range services {
+		progress, exists := progressMap[service]
+		if !exists {
+			// Service has not yet run; it is stalled if it has not done so within the timeout.
+			res.Services = append(res.Services, &serviceStatusJSON{
+				Service: service,
+				Stalled: now.Sub(s.started) > s.stallTimeout,
+			})
+			continue
+		}
+
+		previous, exists := s.advances[service]
+		if !exists || previous.latest != progress.Latest {
+			previous = &advance{
+				latest: progress.Latest,
+				seen:   now,
+			}
+			s.advances[service] = previous
+		}
+
+		epoch := phase0.Epoch(progress.Latest)
+		if progress.Source.Slots {
+			epoch = s.chainTime.SlotToEpoch(phase0.Slot(progress.Latest))
+		}
+		lag := uint64(0)
+		if currentEpoch > epoch {
+			lag = uint64(currentEpoch - epoch)
+		}
+		res.Services = append(res.Services, &serviceStatusJSON{
+			Service: service,
+			Epoch:   fmt.Sprintf("%d", epoch),
+			Lag:     fmt.Sprintf("%d", lag),
+			Ready:   lag <= s.maxLag,
+			Stalled: lag > s.maxLag && now.Sub(previous.seen) > s.stallTimeout,
+		})
+	}
+
+	return res
+}
+
+// writeStatus writes the status, with a status code reflecting success.
+func writeStatus(w http.ResponseWriter, status *statusJSON, success bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if success {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		log.Debug().Err(err).Msg("Failed to write status")
+	}
+}
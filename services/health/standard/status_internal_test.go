@@ -0,0 +1,78 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+)
+
+func TestStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(mockchaindb.New()),
+		WithChainTime(mockchaintime.New()),
+		WithListenAddress("127.0.0.1:0"),
+		WithServices([]string{"blocks"}),
+		WithStallTimeout(time.Minute),
+	)
+	require.NoError(t, err)
+
+	// Service has not yet run, so is not ready but not yet stalled.
+	status := s.status(ctx, s.started.Add(time.Second))
+	require.Len(t, status.Services, 1)
+	require.Equal(t, "blocks", status.Services[0].Service)
+	require.False(t, status.Services[0].Ready)
+	require.False(t, status.Services[0].Stalled)
+
+	// Service has failed to run within the stall timeout.
+	status = s.status(ctx, s.started.Add(2*time.Minute))
+	require.True(t, status.Services[0].Stalled)
+}
+
+func TestHandlers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithChainDB(mockchaindb.New()),
+		WithChainTime(mockchaintime.New()),
+		WithListenAddress("127.0.0.1:0"),
+		WithServices([]string{"blocks"}),
+		WithStallTimeout(time.Minute),
+	)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	s.handleHealthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"status":"ok"`)
+
+	rec = httptest.NewRecorder()
+	s.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Contains(t, rec.Body.String(), `"status":"not_ready"`)
+}
@@ -20,8 +20,9 @@ import (
 )
 
 type parameters struct {
-	logLevel zerolog.Level
-	address  string
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	address         string
 }
 
 // Parameter is the interface for service parameters.
@@ -42,6 +43,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithAddress sets the address.
 func WithAddress(address string) Parameter {
 	return parameterFunc(func(p *parameters) {
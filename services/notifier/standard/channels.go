@@ -0,0 +1,187 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	channelWebhook   = "webhook"
+	channelSlack     = "slack"
+	channelPagerDuty = "pagerduty"
+	channelEmail     = "email"
+)
+
+// channels are the types of destination to which notifications can be sent.
+// An empty type is a generic webhook.
+var channels = map[string]bool{
+	"":               true,
+	channelWebhook:   true,
+	channelSlack:     true,
+	channelPagerDuty: true,
+	channelEmail:     true,
+}
+
+// defaultPagerDutyURL is the URL of the PagerDuty events API, used if a
+// PagerDuty destination does not supply its own URL.
+const defaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// deliver sends a notification to a single destination.
+func (s *Service) deliver(ctx context.Context, webhook *Webhook, notification *notification) error {
+	switch webhook.Type {
+	case channelSlack:
+		return s.post(ctx, webhook.URL, map[string]string{
+			"text": notification.String(),
+		})
+	case channelPagerDuty:
+		pagerDutyURL := webhook.URL
+		if pagerDutyURL == "" {
+			pagerDutyURL = defaultPagerDutyURL
+		}
+		return s.post(ctx, pagerDutyURL, pagerDutyEvent(webhook.RoutingKey, notification))
+	case channelEmail:
+		return s.sendEmail(ctx, webhook, notification)
+	default:
+		return s.post(ctx, webhook.URL, notification)
+	}
+}
+
+// post POSTs data as JSON to a URL.
+func (s *Service) post(ctx context.Context, url string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal notification")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// pagerDutyEvent creates a PagerDuty events API v2 trigger for a notification.
+// The deduplication key ensures that repeated notifications of the same event
+// are grouped in to a single incident.
+func pagerDutyEvent(routingKey string, notification *notification) map[string]interface{} {
+	severity := "warning"
+	if notification.Event == eventSlashed || notification.Event == eventFinalityDelay {
+		severity = "critical"
+	}
+
+	return map[string]interface{}{
+		"routing_key":  routingKey,
+		"event_action": "trigger",
+		"dedup_key":    fmt.Sprintf("chaind-%s-%s-%s", notification.Event, notification.ValidatorIndex, notification.Epoch),
+		"payload": map[string]interface{}{
+			"summary":        notification.String(),
+			"source":         "chaind",
+			"severity":       severity,
+			"custom_details": notification,
+		},
+	}
+}
+
+// sendEmail sends a notification by email through the SMTP server in the
+// destination's URL, using STARTTLS if the server supports it.
+func (s *Service) sendEmail(ctx context.Context, webhook *Webhook, notification *notification) error {
+	server, err := url.Parse(webhook.URL)
+	if err != nil {
+		return errors.Wrap(err, "invalid SMTP URL")
+	}
+
+	dialer := &net.Dialer{Timeout: s.client.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", server.Host)
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to SMTP server")
+	}
+	if err := conn.SetDeadline(time.Now().Add(s.client.Timeout)); err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to set SMTP deadline")
+	}
+	client, err := smtp.NewClient(conn, server.Hostname())
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "failed to start SMTP session")
+	}
+	defer client.Close()
+
+	if supported, _ := client.Extension("STARTTLS"); supported {
+		if err := client.StartTLS(&tls.Config{ServerName: server.Hostname(), MinVersion: tls.VersionTLS12}); err != nil {
+			return errors.Wrap(err, "failed to start TLS")
+		}
+	}
+	if server.User != nil {
+		password, _ := server.User.Password()
+		if err := client.Auth(smtp.PlainAuth("", server.User.Username(), password, server.Hostname())); err != nil {
+			return errors.Wrap(err, "failed to authenticate with SMTP server")
+		}
+	}
+	if err := client.Mail(webhook.From); err != nil {
+		return errors.Wrap(err, "failed to set sender")
+	}
+	for _, to := range webhook.To {
+		if err := client.Rcpt(to); err != nil {
+			return errors.Wrapf(err, "failed to set recipient %s", to)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return errors.Wrap(err, "failed to start message")
+	}
+	if _, err := w.Write(emailMessage(webhook, notification)); err != nil {
+		return errors.Wrap(err, "failed to write message")
+	}
+	if err := w.Close(); err != nil {
+		return errors.Wrap(err, "failed to send message")
+	}
+
+	return client.Quit()
+}
+
+// emailMessage creates the email message for a notification.
+func emailMessage(webhook *Webhook, notification *notification) []byte {
+	var msg strings.Builder
+	msg.WriteString(fmt.Sprintf("From: %s\r\n", webhook.From))
+	msg.WriteString(fmt.Sprintf("To: %s\r\n", strings.Join(webhook.To, ", ")))
+	msg.WriteString(fmt.Sprintf("Subject: chaind: %s\r\n", notification.String()))
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(notification.String())
+	msg.WriteString("\r\n")
+
+	return []byte(msg.String())
+}
@@ -0,0 +1,232 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const (
+	eventSlashed                      = "slashed"
+	eventMissedProposal               = "missed_proposal"
+	eventOffline                      = "offline"
+	eventWithdrawalCredentialsChanged = "withdrawal_credentials_changed"
+	eventLowEffectiveness             = "low_effectiveness"
+	eventFinalityDelay                = "finality_delay"
+)
+
+// events are the events for which notifications can be sent.
+var events = map[string]bool{
+	eventSlashed:                      true,
+	eventMissedProposal:               true,
+	eventOffline:                      true,
+	eventWithdrawalCredentialsChanged: true,
+	eventLowEffectiveness:             true,
+	eventFinalityDelay:                true,
+}
+
+// notification is the body sent to a webhook.
+type notification struct {
+	Event                         string `json:"event"`
+	ValidatorIndex                string `json:"validator_index,omitempty"`
+	Epoch                         string `json:"epoch,omitempty"`
+	OfflineEpochs                 string `json:"offline_epochs,omitempty"`
+	PreviousWithdrawalCredentials string `json:"previous_withdrawal_credentials,omitempty"`
+	WithdrawalCredentials         string `json:"withdrawal_credentials,omitempty"`
+	Effectiveness                 string `json:"effectiveness,omitempty"`
+	FinalizedEpoch                string `json:"finalized_epoch,omitempty"`
+}
+
+// String provides a human-readable description of the notification, for
+// channels that present text rather than structured data.
+func (n *notification) String() string {
+	switch n.Event {
+	case eventSlashed:
+		return fmt.Sprintf("Validator %s has been slashed", n.ValidatorIndex)
+	case eventMissedProposal:
+		return fmt.Sprintf("Validator %s missed a block proposal in epoch %s", n.ValidatorIndex, n.Epoch)
+	case eventOffline:
+		return fmt.Sprintf("Validator %s has not had an attestation included for %s epochs, as of epoch %s", n.ValidatorIndex, n.OfflineEpochs, n.Epoch)
+	case eventWithdrawalCredentialsChanged:
+		return fmt.Sprintf("Withdrawal credentials of validator %s changed from %s to %s", n.ValidatorIndex, n.PreviousWithdrawalCredentials, n.WithdrawalCredentials)
+	case eventLowEffectiveness:
+		return fmt.Sprintf("Attestation effectiveness of validator %s was %s%% in epoch %s", n.ValidatorIndex, n.Effectiveness, n.Epoch)
+	case eventFinalityDelay:
+		return fmt.Sprintf("The chain has not finalized since epoch %s, as of epoch %s", n.FinalizedEpoch, n.Epoch)
+	default:
+		return n.Event
+	}
+}
+
+// checkValidators updates the state of the validators in the metadata, returning
+// notifications for any changes of interest.  Validators seen for the first time
+// do not generate notifications.
+func (s *Service) checkValidators(md *metadata,
+	validators map[phase0.ValidatorIndex]*chaindb.Validator,
+) []*notification {
+	notifications := make([]*notification, 0)
+	for _, index := range s.validators {
+		validator, exists := validators[index]
+		if !exists {
+			continue
+		}
+		withdrawalCredentials := ""
+		if validator.WithdrawalCredentials != nil {
+			withdrawalCredentials = fmt.Sprintf("%#x", validator.WithdrawalCredentials)
+		}
+
+		state, exists := md.Validators[index]
+		if !exists {
+			md.Validators[index] = &validatorState{
+				Slashed:               validator.Slashed,
+				WithdrawalCredentials: withdrawalCredentials,
+			}
+			continue
+		}
+
+		if validator.Slashed && !state.Slashed {
+			notifications = append(notifications, &notification{
+				Event:          eventSlashed,
+				ValidatorIndex: fmt.Sprintf("%d", index),
+			})
+		}
+		state.Slashed = validator.Slashed
+
+		if withdrawalCredentials != "" && withdrawalCredentials != state.WithdrawalCredentials {
+			// Credentials not previously known are not a change.
+			if state.WithdrawalCredentials != "" {
+				notifications = append(notifications, &notification{
+					Event:                         eventWithdrawalCredentialsChanged,
+					ValidatorIndex:                fmt.Sprintf("%d", index),
+					PreviousWithdrawalCredentials: state.WithdrawalCredentials,
+					WithdrawalCredentials:         withdrawalCredentials,
+				})
+			}
+			state.WithdrawalCredentials = withdrawalCredentials
+		}
+	}
+
+	return notifications
+}
+
+// checkSummaries updates the metadata with the given validator epoch summaries, which
+// must be in epoch order, returning notifications for any events of interest.
+func (s *Service) checkSummaries(md *metadata,
+	summaries []*chaindb.ValidatorEpochSummary,
+) []*notification {
+	notifications := make([]*notification, 0)
+	for _, summary := range summaries {
+		state, exists := md.Validators[summary.Index]
+		if !exists {
+			state = &validatorState{}
+			md.Validators[summary.Index] = state
+		}
+
+		if summary.ProposalsIncluded < summary.ProposerDuties {
+			notifications = append(notifications, &notification{
+				Event:          eventMissedProposal,
+				ValidatorIndex: fmt.Sprintf("%d", summary.Index),
+				Epoch:          fmt.Sprintf("%d", summary.Epoch),
+			})
+		}
+
+		if summary.AttestationIncluded {
+			state.OfflineEpochs = 0
+			// Effectiveness is the percentage of the best possible inclusion delay of 1.
+			if s.minEffectiveness > 0 && summary.AttestationInclusionDelay != nil && *summary.AttestationInclusionDelay > 0 {
+				effectiveness := uint64(100 / *summary.AttestationInclusionDelay)
+				if effectiveness < s.minEffectiveness {
+					notifications = append(notifications, &notification{
+						Event:          eventLowEffectiveness,
+						ValidatorIndex: fmt.Sprintf("%d", summary.Index),
+						Epoch:          fmt.Sprintf("%d", summary.Epoch),
+						Effectiveness:  fmt.Sprintf("%d", effectiveness),
+					})
+				}
+			}
+		} else {
+			state.OfflineEpochs++
+			// Only notify when the validator first reaches the threshold.
+			if state.OfflineEpochs == s.offlineEpochs {
+				notifications = append(notifications, &notification{
+					Event:          eventOffline,
+					ValidatorIndex: fmt.Sprintf("%d", summary.Index),
+					Epoch:          fmt.Sprintf("%d", summary.Epoch),
+					OfflineEpochs:  fmt.Sprintf("%d", state.OfflineEpochs),
+				})
+			}
+		}
+
+		if summary.Epoch > md.LatestEpoch {
+			md.LatestEpoch = summary.Epoch
+		}
+	}
+
+	return notifications
+}
+
+// checkFinality updates the metadata with the finality of the chain, returning
+// a notification if finality has become delayed.  The notification is sent once
+// until the chain finalizes within the allowed delay again.
+func (s *Service) checkFinality(md *metadata,
+	currentEpoch phase0.Epoch,
+	finalizedEpoch phase0.Epoch,
+) []*notification {
+	notifications := make([]*notification, 0)
+	delayed := uint64(currentEpoch) > uint64(finalizedEpoch)+s.finalityDelayEpochs
+	if delayed && !md.FinalityDelayed {
+		notifications = append(notifications, &notification{
+			Event:          eventFinalityDelay,
+			Epoch:          fmt.Sprintf("%d", currentEpoch),
+			FinalizedEpoch: fmt.Sprintf("%d", finalizedEpoch),
+		})
+	}
+	md.FinalityDelayed = delayed
+
+	return notifications
+}
+
+// notify sends a notification to the webhooks that want it.
+func (s *Service) notify(ctx context.Context, notification *notification) {
+	for _, webhook := range s.webhooks {
+		if !wantsEvent(webhook, notification.Event) {
+			continue
+		}
+		log := log.With().Str("type", webhook.Type).Str("event", notification.Event).Str("validator_index", notification.ValidatorIndex).Logger()
+		if err := s.deliver(ctx, webhook, notification); err != nil {
+			log.Warn().Err(err).Msg("Failed to send notification")
+			monitorNotification(notification.Event, "failed")
+			continue
+		}
+		log.Trace().Msg("Sent notification")
+		monitorNotification(notification.Event, "succeeded")
+	}
+}
+
+// wantsEvent returns true if the webhook should receive the event.
+func wantsEvent(webhook *Webhook, event string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, webhookEvent := range webhook.Events {
+		if webhookEvent == event {
+			return true
+		}
+	}
+	return false
+}
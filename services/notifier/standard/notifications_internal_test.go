@@ -0,0 +1,192 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestCheckValidators(t *testing.T) {
+	s := &Service{
+		validators: []phase0.ValidatorIndex{1, 2},
+	}
+	md := &metadata{
+		Validators: make(map[phase0.ValidatorIndex]*validatorState),
+	}
+
+	// First sighting records state without notifying.
+	notifications := s.checkValidators(md, map[phase0.ValidatorIndex]*chaindb.Validator{
+		1: {Index: 1, WithdrawalCredentials: []byte{0x00, 0x01}},
+		2: {Index: 2},
+	})
+	require.Len(t, notifications, 0)
+
+	// Slashing and changed credentials notify.
+	notifications = s.checkValidators(md, map[phase0.ValidatorIndex]*chaindb.Validator{
+		1: {Index: 1, WithdrawalCredentials: []byte{0x01, 0x02}},
+		2: {Index: 2, Slashed: true, WithdrawalCredentials: []byte{0x00, 0x03}},
+	})
+	require.Len(t, notifications, 2)
+	require.Equal(t, eventWithdrawalCredentialsChanged, notifications[0].Event)
+	require.Equal(t, "0x0001", notifications[0].PreviousWithdrawalCredentials)
+	require.Equal(t, "0x0102", notifications[0].WithdrawalCredentials)
+	require.Equal(t, eventSlashed, notifications[1].Event)
+	require.Equal(t, "2", notifications[1].ValidatorIndex)
+
+	// No change does not notify.
+	notifications = s.checkValidators(md, map[phase0.ValidatorIndex]*chaindb.Validator{
+		1: {Index: 1, WithdrawalCredentials: []byte{0x01, 0x02}},
+		2: {Index: 2, Slashed: true, WithdrawalCredentials: []byte{0x00, 0x03}},
+	})
+	require.Len(t, notifications, 0)
+}
+
+func TestCheckSummaries(t *testing.T) {
+	s := &Service{
+		validators:    []phase0.ValidatorIndex{1},
+		offlineEpochs: 2,
+	}
+	md := &metadata{
+		LatestEpoch: 9,
+		Validators:  make(map[phase0.ValidatorIndex]*validatorState),
+	}
+
+	notifications := s.checkSummaries(md, []*chaindb.ValidatorEpochSummary{
+		{Index: 1, Epoch: 10, ProposerDuties: 1, ProposalsIncluded: 0, AttestationIncluded: true},
+		{Index: 1, Epoch: 11},
+		{Index: 1, Epoch: 12},
+		{Index: 1, Epoch: 13},
+		{Index: 1, Epoch: 14, AttestationIncluded: true},
+	})
+	require.Len(t, notifications, 2)
+	require.Equal(t, eventMissedProposal, notifications[0].Event)
+	require.Equal(t, "10", notifications[0].Epoch)
+	require.Equal(t, eventOffline, notifications[1].Event)
+	require.Equal(t, "12", notifications[1].Epoch)
+	require.Equal(t, phase0.Epoch(14), md.LatestEpoch)
+	require.Equal(t, uint64(0), md.Validators[1].OfflineEpochs)
+}
+
+func TestCheckSummariesEffectiveness(t *testing.T) {
+	s := &Service{
+		validators:       []phase0.ValidatorIndex{1},
+		offlineEpochs:    2,
+		minEffectiveness: 50,
+	}
+	md := &metadata{
+		Validators: make(map[phase0.ValidatorIndex]*validatorState),
+	}
+
+	delay := func(delay int) *int { return &delay }
+	notifications := s.checkSummaries(md, []*chaindb.ValidatorEpochSummary{
+		{Index: 1, Epoch: 10, AttestationIncluded: true, AttestationInclusionDelay: delay(1)},
+		{Index: 1, Epoch: 11, AttestationIncluded: true, AttestationInclusionDelay: delay(2)},
+		{Index: 1, Epoch: 12, AttestationIncluded: true, AttestationInclusionDelay: delay(3)},
+	})
+	require.Len(t, notifications, 1)
+	require.Equal(t, eventLowEffectiveness, notifications[0].Event)
+	require.Equal(t, "12", notifications[0].Epoch)
+	require.Equal(t, "33", notifications[0].Effectiveness)
+}
+
+func TestCheckFinality(t *testing.T) {
+	s := &Service{
+		finalityDelayEpochs: 4,
+	}
+	md := &metadata{}
+
+	require.Len(t, s.checkFinality(md, 100, 97), 0)
+	require.False(t, md.FinalityDelayed)
+
+	// Delayed finality notifies once.
+	notifications := s.checkFinality(md, 102, 97)
+	require.Len(t, notifications, 1)
+	require.Equal(t, eventFinalityDelay, notifications[0].Event)
+	require.Equal(t, "97", notifications[0].FinalizedEpoch)
+	require.Equal(t, "", notifications[0].ValidatorIndex)
+	require.Len(t, s.checkFinality(md, 103, 97), 0)
+
+	// Recovery allows a later delay to notify again.
+	require.Len(t, s.checkFinality(md, 104, 102), 0)
+	require.False(t, md.FinalityDelayed)
+	require.Len(t, s.checkFinality(md, 110, 102), 1)
+}
+
+func TestNotify(t *testing.T) {
+	received := make(chan *notification, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := &notification{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(notification))
+		received <- notification
+	}))
+	defer server.Close()
+
+	s := &Service{
+		webhooks: []*Webhook{
+			{URL: server.URL, Events: []string{eventSlashed}},
+		},
+		client: &http.Client{Timeout: time.Second},
+	}
+
+	s.notify(context.Background(), &notification{Event: eventOffline, ValidatorIndex: "1"})
+	s.notify(context.Background(), &notification{Event: eventSlashed, ValidatorIndex: "2"})
+	require.Len(t, received, 1)
+	require.Equal(t, "2", (<-received).ValidatorIndex)
+}
+
+func TestNotifyChannels(t *testing.T) {
+	received := make(chan map[string]interface{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := make(map[string]interface{})
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		received <- body
+	}))
+	defer server.Close()
+
+	s := &Service{
+		webhooks: []*Webhook{
+			{Type: channelSlack, URL: server.URL},
+			{Type: channelPagerDuty, URL: server.URL, RoutingKey: "key"},
+		},
+		client: &http.Client{Timeout: time.Second},
+	}
+
+	s.notify(context.Background(), &notification{Event: eventSlashed, ValidatorIndex: "2"})
+	require.Len(t, received, 2)
+	require.Equal(t, map[string]interface{}{"text": "Validator 2 has been slashed"}, <-received)
+	pagerDuty := <-received
+	require.Equal(t, "key", pagerDuty["routing_key"])
+	require.Equal(t, "trigger", pagerDuty["event_action"])
+	require.Equal(t, "critical", pagerDuty["payload"].(map[string]interface{})["severity"])
+}
+
+func TestEmailMessage(t *testing.T) {
+	msg := emailMessage(&Webhook{From: "chaind@example.com", To: []string{"a@example.com", "b@example.com"}},
+		&notification{Event: eventMissedProposal, ValidatorIndex: "3", Epoch: "10"})
+	require.Equal(t, "From: chaind@example.com\r\n"+
+		"To: a@example.com, b@example.com\r\n"+
+		"Subject: chaind: Validator 3 missed a block proposal in epoch 10\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"\r\n"+
+		"Validator 3 missed a block proposal in epoch 10\r\n", string(msg))
+}
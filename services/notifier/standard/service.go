@@ -0,0 +1,201 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+)
+
+// maxEpochsPerUpdate is the maximum number of epochs checked for events in a single update.
+const maxEpochsPerUpdate = 32
+
+// Service is a notifier service, sending notifications to webhooks when
+// indexed data shows events of interest for watched validators.
+type Service struct {
+	chainDB                         chaindb.Service
+	validatorsProvider              chaindb.ValidatorsProvider
+	validatorSet                    validatorset.Service
+	validatorEpochSummariesProvider chaindb.ValidatorEpochSummariesProvider
+	validators                      []phase0.ValidatorIndex
+	webhooks                        []*Webhook
+	offlineEpochs                   uint64
+	interval                        time.Duration
+	client                          *http.Client
+	finalityProvider                eth2client.FinalityProvider
+	chainTime                       chaintime.Service
+	minEffectiveness                uint64
+	finalityDelayEpochs             uint64
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "notifier").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorSet = validatorsProvider
+	}
+
+	validatorEpochSummariesProvider, isProvider := parameters.chainDB.(chaindb.ValidatorEpochSummariesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validator epoch summaries")
+	}
+
+	s := &Service{
+		chainDB:                         parameters.chainDB,
+		validatorsProvider:              validatorsProvider,
+		validatorSet:                    validatorSet,
+		validatorEpochSummariesProvider: validatorEpochSummariesProvider,
+		validators:                      parameters.validators,
+		webhooks:                        parameters.webhooks,
+		offlineEpochs:                   parameters.offlineEpochs,
+		interval:                        parameters.interval,
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+		chainTime:           parameters.chainTime,
+		minEffectiveness:    parameters.minEffectiveness,
+		finalityDelayEpochs: parameters.finalityDelayEpochs,
+	}
+	if parameters.eth2Client != nil {
+		s.finalityProvider = parameters.eth2Client.(eth2client.FinalityProvider)
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically checks for events.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.update(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to check for events")
+		}
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping notifier")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update checks the database for events since the last update, and sends
+// notifications for any that are found.  Notifications are sent after the
+// progress of the service has been stored, so will not be repeated if sending fails.
+func (s *Service) update(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	validators, err := s.validatorSet.ValidatorsByIndex(ctx, s.validators)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validators")
+	}
+
+	notifications := make([]*notification, 0)
+	firstRun := md.Validators == nil
+	if firstRun {
+		// First run; record the current state without notifying of historical events.
+		md.Validators = make(map[phase0.ValidatorIndex]*validatorState)
+		s.checkValidators(md, validators)
+		summaries, err := s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+			Limit:            1,
+			Order:            chaindb.OrderLatest,
+			ValidatorIndices: &s.validators,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain latest validator summary")
+		}
+		if len(summaries) > 0 {
+			md.LatestEpoch = summaries[0].Epoch
+		}
+		log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Watching validators from epoch")
+	} else {
+		notifications = append(notifications, s.checkValidators(md, validators)...)
+
+		from := md.LatestEpoch + 1
+		to := from + maxEpochsPerUpdate - 1
+		summaries, err := s.validatorEpochSummariesProvider.ValidatorSummaries(ctx, &chaindb.ValidatorSummaryFilter{
+			Limit:            uint32(maxEpochsPerUpdate * len(s.validators)),
+			Order:            chaindb.OrderEarliest,
+			From:             &from,
+			To:               &to,
+			ValidatorIndices: &s.validators,
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain validator summaries")
+		}
+		notifications = append(notifications, s.checkSummaries(md, summaries)...)
+	}
+
+	if s.finalityProvider != nil {
+		finality, err := s.finalityProvider.Finality(ctx, "head")
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain finality")
+		}
+		finalityNotifications := s.checkFinality(md, s.chainTime.CurrentEpoch(), finality.Finalized.Epoch)
+		if !firstRun {
+			notifications = append(notifications, finalityNotifications...)
+		}
+	}
+
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		return s.setMetadata(ctx, md)
+	}); err != nil {
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	for _, notification := range notifications {
+		s.notify(ctx, notification)
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/notifier/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	validators := []phase0.ValidatorIndex{1, 2}
+	webhooks := []*standard.Webhook{{URL: "http://localhost:1234/"}}
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithValidators(validators),
+				standard.WithWebhooks(webhooks),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ValidatorsMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithWebhooks(webhooks),
+			},
+			err: "problem with parameters: no validators specified",
+		},
+		{
+			name: "WebhooksMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+			},
+			err: "problem with parameters: no webhooks specified",
+		},
+		{
+			name: "WebhookURLMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks([]*standard.Webhook{{}}),
+			},
+			err: "problem with parameters: webhook URL missing",
+		},
+		{
+			name: "WebhookEventUnknown",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks([]*standard.Webhook{{URL: "http://localhost:1234/", Events: []string{"unknown"}}}),
+			},
+			err: `problem with parameters: unknown webhook event "unknown"`,
+		},
+		{
+			name: "WebhookTypeUnknown",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks([]*standard.Webhook{{Type: "unknown", URL: "http://localhost:1234/"}}),
+			},
+			err: `problem with parameters: unknown webhook type "unknown"`,
+		},
+		{
+			name: "PagerDutyRoutingKeyMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks([]*standard.Webhook{{Type: "pagerduty"}}),
+			},
+			err: "problem with parameters: PagerDuty routing key missing",
+		},
+		{
+			name: "EmailURLInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks([]*standard.Webhook{{Type: "email", URL: "http://localhost:1234/", From: "chaind@example.com", To: []string{"ops@example.com"}}}),
+			},
+			err: "problem with parameters: email URL must be an smtp:// URL",
+		},
+		{
+			name: "EmailRecipientsMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks([]*standard.Webhook{{Type: "email", URL: "smtp://localhost:25", From: "chaind@example.com"}}),
+			},
+			err: "problem with parameters: email recipients missing",
+		},
+		{
+			name: "MinEffectivenessTooHigh",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks(webhooks),
+				standard.WithMinEffectiveness(101),
+			},
+			err: "problem with parameters: minimum effectiveness cannot be greater than 100",
+		},
+		{
+			name: "OfflineEpochsZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks(webhooks),
+				standard.WithOfflineEpochs(0),
+			},
+			err: "problem with parameters: offline epochs must be greater than 0",
+		},
+		{
+			name: "IntervalZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks(webhooks),
+				standard.WithInterval(0),
+			},
+			err: "problem with parameters: interval must be greater than 0",
+		},
+		{
+			name: "TimeoutZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks(webhooks),
+				standard.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithValidators(validators),
+				standard.WithWebhooks(webhooks),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
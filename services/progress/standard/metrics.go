@@ -0,0 +1,107 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_progress"
+
+var remaining *prometheus.GaugeVec
+var rate *prometheus.GaugeVec
+var eta *prometheus.GaugeVec
+var lagAlert *prometheus.GaugeVec
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if remaining != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	remaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "remaining",
+		Help:      "Number of slots (blocks) or epochs (other services) the service is behind the chain",
+	}, []string{"service"})
+	if err := prometheus.Register(remaining); err != nil {
+		return errors.Wrap(err, "failed to register remaining")
+	}
+
+	rate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "rate",
+		Help:      "Number of slots (blocks) or epochs (other services) processed per second",
+	}, []string{"service"})
+	if err := prometheus.Register(rate); err != nil {
+		return errors.Wrap(err, "failed to register rate")
+	}
+
+	eta = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "eta_seconds",
+		Help:      "Estimated number of seconds until the service has caught up with the chain; -1 if unknown",
+	}, []string{"service"})
+	if err := prometheus.Register(eta); err != nil {
+		return errors.Wrap(err, "failed to register eta_seconds")
+	}
+
+	lagAlert = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "lag_alert",
+		Help:      "1 if the service lags the chain by more than its threshold, otherwise 0",
+	}, []string{"service"})
+	if err := prometheus.Register(lagAlert); err != nil {
+		return errors.Wrap(err, "failed to register lag_alert")
+	}
+
+	return nil
+}
+
+func monitorProgress(service string, remainingCount uint64, processRate float64, timeToCatchup time.Duration) {
+	if remaining != nil {
+		remaining.WithLabelValues(service).Set(float64(remainingCount))
+		rate.WithLabelValues(service).Set(processRate)
+		if timeToCatchup < 0 {
+			eta.WithLabelValues(service).Set(-1)
+		} else {
+			eta.WithLabelValues(service).Set(timeToCatchup.Seconds())
+		}
+	}
+}
+
+func monitorLagAlert(service string, lagging bool) {
+	if lagAlert != nil {
+		if lagging {
+			lagAlert.WithLabelValues(service).Set(1)
+		} else {
+			lagAlert.WithLabelValues(service).Set(0)
+		}
+	}
+}
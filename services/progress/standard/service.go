@@ -0,0 +1,232 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+// rateSmoothing is the weight given to the latest sample when smoothing rates.
+const rateSmoothing = 0.3
+
+// Service is a progress service, periodically reporting how far each service
+// is behind the chain, how quickly it is catching up and when it is expected
+// to have caught up.  It also raises an alert for any service that lags the
+// chain by more than its threshold.
+type Service struct {
+	chainDB       chaindb.Service
+	chainTime     chaintime.Service
+	interval      time.Duration
+	lagThresholds map[string]time.Duration
+	samples       map[string]*sample
+}
+
+// sample is the progress of a service when last checked.
+type sample struct {
+	latest    uint64
+	remaining uint64
+	seen      time.Time
+	// rate is the smoothed rate at which the service processes slots or epochs.
+	rate float64
+	// catchupRate is the smoothed rate at which the remaining slots or epochs fall,
+	// taking in to account the advance of the chain.
+	catchupRate float64
+	// rated is true if the rates have been calculated.
+	rated    bool
+	caughtUp bool
+	// lagging is true if the service lags the chain by more than its threshold.
+	lagging bool
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "progress").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	lagThresholds := make(map[string]time.Duration, len(parameters.lagThresholds))
+	for _, threshold := range parameters.lagThresholds {
+		lagThresholds[threshold.Service] = threshold.Threshold
+	}
+
+	s := &Service{
+		chainDB:       parameters.chainDB,
+		chainTime:     parameters.chainTime,
+		interval:      parameters.interval,
+		lagThresholds: lagThresholds,
+		samples:       make(map[string]*sample),
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically reports progress.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		s.update(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping progress reporting")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update obtains the progress of each service and reports it.
+func (s *Service) update(ctx context.Context, now time.Time) {
+	progresses, err := util.ServiceProgress(ctx, s.chainDB)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain service progress")
+		return
+	}
+
+	for _, progress := range progresses {
+		s.updateService(progress, now)
+	}
+}
+
+// updateService updates and reports the progress of a single service.
+func (s *Service) updateService(progress *util.Progress, now time.Time) {
+	service := progress.Source.Service
+	unit := "epochs"
+	current := uint64(s.chainTime.CurrentEpoch())
+	latestEpoch := phase0.Epoch(progress.Latest)
+	lag := s.chainTime.StartOfEpoch(s.chainTime.CurrentEpoch()).Sub(s.chainTime.StartOfEpoch(latestEpoch))
+	if progress.Source.Slots {
+		unit = "slots"
+		current = uint64(s.chainTime.CurrentSlot())
+		latestEpoch = s.chainTime.SlotToEpoch(phase0.Slot(progress.Latest))
+		lag = s.chainTime.StartOfSlot(s.chainTime.CurrentSlot()).Sub(s.chainTime.StartOfSlot(phase0.Slot(progress.Latest)))
+	}
+	remaining := uint64(0)
+	if current > progress.Latest {
+		remaining = current - progress.Latest
+	}
+
+	latest := &sample{
+		latest:    progress.Latest,
+		remaining: remaining,
+		seen:      now,
+		caughtUp:  latestEpoch+1 >= s.chainTime.CurrentEpoch(),
+	}
+	previous, exists := s.samples[service]
+	s.samples[service] = latest
+	s.checkLag(service, lag, latest, previous)
+	if !exists {
+		// Need two samples to obtain rates.
+		monitorProgress(service, remaining, 0, -1)
+		return
+	}
+
+	elapsed := now.Sub(previous.seen).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	rate := 0.0
+	if progress.Latest > previous.latest {
+		rate = float64(progress.Latest-previous.latest) / elapsed
+	}
+	catchupRate := (float64(previous.remaining) - float64(remaining)) / elapsed
+	latest.rate = smooth(previous.rate, rate, !previous.rated)
+	latest.catchupRate = smooth(previous.catchupRate, catchupRate, !previous.rated)
+	latest.rated = true
+
+	eta := time.Duration(-1)
+	if latest.caughtUp {
+		eta = 0
+	} else if latest.catchupRate > 0 {
+		eta = time.Duration(float64(remaining) / latest.catchupRate * float64(time.Second))
+	}
+	monitorProgress(service, remaining, latest.rate, eta)
+
+	switch {
+	case latest.caughtUp && !previous.caughtUp:
+		log.Info().Str("service", service).Msg("Caught up with chain")
+	case latest.caughtUp:
+		log.Trace().Str("service", service).Uint64("remaining", remaining).Str("unit", unit).Msg("Following chain")
+	default:
+		e := log.Info().
+			Str("service", service).
+			Uint64("remaining", remaining).
+			Str("unit", unit).
+			Float64("rate", latest.rate)
+		if eta >= 0 {
+			e = e.Str("eta", eta.Round(time.Minute).String())
+		} else {
+			e = e.Str("eta", "unknown")
+		}
+		e.Msg("Catchup progress")
+	}
+}
+
+// checkLag raises or clears the lag alert for a service if it has a lag threshold.
+func (s *Service) checkLag(service string, lag time.Duration, latest *sample, previous *sample) {
+	threshold, exists := s.lagThresholds[service]
+	if !exists {
+		return
+	}
+
+	latest.lagging = lag > threshold
+	wasLagging := previous != nil && previous.lagging
+	monitorLagAlert(service, latest.lagging)
+	switch {
+	case latest.lagging && !wasLagging:
+		log.Error().
+			Str("service", service).
+			Str("lag", lag.Round(time.Second).String()).
+			Str("threshold", threshold.String()).
+			Msg("Service lags the chain by more than its threshold")
+	case !latest.lagging && wasLagging:
+		log.Info().
+			Str("service", service).
+			Str("lag", lag.Round(time.Second).String()).
+			Msg("Service lag back within its threshold")
+	}
+}
+
+// smooth combines a new rate with the previous smoothed rate.
+func smooth(previous float64, latest float64, first bool) float64 {
+	if first {
+		return latest
+	}
+	return rateSmoothing*latest + (1-rateSmoothing)*previous
+}
@@ -0,0 +1,102 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaintime"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/util"
+)
+
+// testChainTime is a chain time service with a settable current epoch.
+type testChainTime struct {
+	chaintime.Service
+	currentEpoch phase0.Epoch
+}
+
+func (c *testChainTime) CurrentEpoch() phase0.Epoch {
+	return c.currentEpoch
+}
+
+func (*testChainTime) StartOfEpoch(epoch phase0.Epoch) time.Time {
+	return time.Unix(1606824023, 0).Add(time.Duration(epoch) * 384 * time.Second)
+}
+
+func TestUpdateService(t *testing.T) {
+	chainTime := &testChainTime{
+		Service:      mockchaintime.New(),
+		currentEpoch: 1000,
+	}
+	s := &Service{
+		chainTime: chainTime,
+		samples:   make(map[string]*sample),
+	}
+	source := &util.ProgressSource{Service: "validators"}
+	start := time.Now()
+
+	// First sample has no rate.
+	s.updateService(&util.Progress{Source: source, Latest: 100}, start)
+	require.Equal(t, uint64(900), s.samples["validators"].remaining)
+	require.False(t, s.samples["validators"].rated)
+
+	// 100 epochs in 10 seconds, with the chain advancing by 1 epoch.
+	chainTime.currentEpoch = 1001
+	s.updateService(&util.Progress{Source: source, Latest: 200}, start.Add(10*time.Second))
+	latest := s.samples["validators"]
+	require.Equal(t, uint64(801), latest.remaining)
+	require.InDelta(t, 10.0, latest.rate, 0.001)
+	require.InDelta(t, 9.9, latest.catchupRate, 0.001)
+	require.False(t, latest.caughtUp)
+
+	// Rates are smoothed.
+	s.updateService(&util.Progress{Source: source, Latest: 400}, start.Add(20*time.Second))
+	latest = s.samples["validators"]
+	require.InDelta(t, 0.3*20+0.7*10, latest.rate, 0.001)
+
+	// Caught up.
+	s.updateService(&util.Progress{Source: source, Latest: 1000}, start.Add(30*time.Second))
+	require.True(t, s.samples["validators"].caughtUp)
+}
+
+func TestUpdateServiceLag(t *testing.T) {
+	chainTime := &testChainTime{
+		Service:      mockchaintime.New(),
+		currentEpoch: 1000,
+	}
+	s := &Service{
+		chainTime:     chainTime,
+		lagThresholds: map[string]time.Duration{"validators": time.Hour},
+		samples:       make(map[string]*sample),
+	}
+	source := &util.ProgressSource{Service: "validators"}
+	start := time.Now()
+
+	// 10 epochs is 64 minutes behind.
+	s.updateService(&util.Progress{Source: source, Latest: 990}, start)
+	require.True(t, s.samples["validators"].lagging)
+
+	// 9 epochs is within the threshold.
+	s.updateService(&util.Progress{Source: source, Latest: 991}, start.Add(time.Minute))
+	require.False(t, s.samples["validators"].lagging)
+
+	// Services without a threshold never lag.
+	other := &util.ProgressSource{Service: "proposerduties"}
+	s.updateService(&util.Progress{Source: other, Latest: 0}, start)
+	require.False(t, s.samples["proposerduties"].lagging)
+}
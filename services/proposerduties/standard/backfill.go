@@ -0,0 +1,111 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// followFromHead returns true if the service should follow the chain from the
+// current epoch and backfill earlier epochs, rather than catch up in order.  This
+// is the case if backfill is enabled, or if the service is at least the head-first
+// distance behind the chain.
+func (s *Service) followFromHead(md *metadata) bool {
+	if s.backfill {
+		return true
+	}
+	if s.headFirstDistance == 0 {
+		return false
+	}
+	if s.chainTime.CurrentEpoch() < md.LatestEpoch+s.headFirstDistance {
+		return false
+	}
+	log.Info().Uint64("latest_epoch", uint64(md.LatestEpoch)).Msg("Far behind the chain; following the chain and backfilling earlier epochs")
+
+	return true
+}
+
+// backfillEpochs processes the epochs before the epoch from which the chain is
+// followed that have not yet been processed, most recent first, so that recent
+// data is available as soon as possible.  Epochs that cannot be fetched are left
+// unprocessed, to be fetched again on the next backfill.
+func (s *Service) backfillEpochs(ctx context.Context) {
+	if s.followEpoch == 0 {
+		return
+	}
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		monitorError(err)
+		log.Error().Err(err).Msg("Failed to obtain metadata for backfill")
+		return
+	}
+
+	gaps := md.ProcessedEpochs.Gaps(s.firstEpoch, s.followEpoch-1)
+	log.Info().Uint64("start_epoch", uint64(s.firstEpoch)).Uint64("end_epoch", uint64(s.followEpoch-1)).Int("gaps", len(gaps)).Msg("Backfilling epochs")
+	for i := len(gaps) - 1; i >= 0; i-- {
+		gap := gaps[i]
+		for end := gap.End; ; {
+			// Fetch a batch of epochs at a time, to spread the requests across beacon nodes.
+			start := gap.Start
+			if uint64(end-start)+1 > uint64(len(s.catchupClients)) {
+				start = end - phase0.Epoch(len(s.catchupClients)) + 1
+			}
+			if err := s.throttle.Wait(ctx); err != nil {
+				return
+			}
+			batch := s.fetchProposerDutiesBatch(ctx, start, end)
+			if err := s.storeBackfillBatch(ctx, batch); err != nil {
+				monitorError(err)
+				log.Error().Err(err).Msg("Failed to store backfilled proposer duties")
+				return
+			}
+			if start == gap.Start {
+				break
+			}
+			end = start - 1
+		}
+	}
+	log.Info().Msg("Backfill complete")
+}
+
+// storeBackfillBatch stores a batch of backfilled proposer duties, most recent first.
+// Fetching takes place outside of the activity semaphore, so that following the
+// chain is held up only for as long as it takes to store the batch.
+func (s *Service) storeBackfillBatch(ctx context.Context, batch []*fetchedProposerDuties) error {
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return err
+	}
+	defer s.activitySem.Release(1)
+
+	// Metadata is obtained afresh, as it may have been updated while following the chain.
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(batch) - 1; i >= 0; i-- {
+		fetched := batch[i]
+		if fetched.err != nil {
+			log.Warn().Uint64("epoch", uint64(fetched.epoch)).Err(fetched.err).Msg("Failed to fetch proposer duties; will refetch later")
+			monitorEpochMissed()
+			continue
+		}
+		if err := s.storeFetched(ctx, md, fetched); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fetchedProposerDuties are the proposer duties fetched for an epoch.
+type fetchedProposerDuties struct {
+	epoch      phase0.Epoch
+	duties     []*api.ProposerDuty
+	provenance *chaindb.Provenance
+	err        error
+}
+
+// fetchProposerDutiesBatch fetches proposer duties for up to one epoch per
+// catchup client, starting at startEpoch and going no further than endEpoch.
+// Results are returned in epoch order.
+func (s *Service) fetchProposerDutiesBatch(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+) []*fetchedProposerDuties {
+	batchSize := len(s.catchupClients)
+	if uint64(endEpoch-startEpoch)+1 < uint64(batchSize) {
+		batchSize = int(endEpoch-startEpoch) + 1
+	}
+
+	batch := make([]*fetchedProposerDuties, batchSize)
+	var wg sync.WaitGroup
+	for i := 0; i < batchSize; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			epoch := startEpoch + phase0.Epoch(i)
+			duties, provenance, err := s.fetchProposerDutiesWithFallback(ctx, s.catchupClients[i], epoch)
+			batch[i] = &fetchedProposerDuties{
+				epoch:      epoch,
+				duties:     duties,
+				provenance: provenance,
+				err:        err,
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return batch
+}
+
+// fetchProposerDutiesWithFallback fetches the proposer duties for an epoch
+// from the given client, retrying according to the retry policy.  If the client
+// fails the epoch is refetched from the main client.  The provenance returned
+// is that of the client that supplied the proposer duties.
+func (s *Service) fetchProposerDutiesWithFallback(ctx context.Context,
+	client eth2client.Service,
+	epoch phase0.Epoch,
+) (
+	[]*api.ProposerDuty,
+	*chaindb.Provenance,
+	error,
+) {
+	var duties []*api.ProposerDuty
+	var provenance *chaindb.Provenance
+	err := s.retryPolicy.Do(ctx, func() error {
+		var err error
+		duties, provenance, err = s.fetchProposerDuties(ctx, client, epoch)
+		if err != nil && client != s.eth2Client {
+			log.Debug().Uint64("epoch", uint64(epoch)).Str("address", client.Address()).Err(err).Msg("Failed to fetch proposer duties from catchup client; trying main client")
+			duties, provenance, err = s.fetchProposerDuties(ctx, s.eth2Client, epoch)
+		}
+		return err
+	})
+
+	return duties, provenance, err
+}
+
+// catchupGap catches up the epochs in a gap with multiple workers, each of which
+// fetches and stores a number of epochs at a time in their own transaction.
+// Epochs that cannot be fetched are left unprocessed, to be refetched later.
+func (s *Service) catchupGap(ctx context.Context, md *metadata, gap util.EpochRange) error {
+	err := util.ProcessGapWithWorkers(ctx, gap, s.catchupWorkers, s.catchupEpochsPerTx,
+		func(ctx context.Context, worker int, epochRange util.EpochRange) []*util.EpochResult {
+			client := s.catchupClients[worker%len(s.catchupClients)]
+			var results []*util.EpochResult
+			err := s.throttle.Wait(util.WithEpochHeadDistance(ctx, s.chainTime, epochRange.End))
+			if err == nil {
+				err = s.scheduler.Run(ctx, "catchup epochs", scheduler.PriorityCatchup, func(ctx context.Context) {
+					results = s.catchupEpochsWithClient(ctx, client, epochRange)
+				})
+			}
+			if err != nil {
+				results = make([]*util.EpochResult, 0)
+				for epoch := epochRange.Start; epoch <= epochRange.End; epoch++ {
+					results = append(results, &util.EpochResult{Epoch: epoch, FetchErr: err})
+				}
+			}
+			for _, result := range results {
+				if result.FetchErr != nil {
+					// Leave the epoch unprocessed and carry on, rather than stalling.
+					log.Warn().Uint64("epoch", uint64(result.Epoch)).Err(result.FetchErr).Msg("Failed to fetch proposer duties; will refetch later")
+					monitorEpochMissed()
+				}
+			}
+			return results
+		},
+		func(ctx context.Context, epochs ...phase0.Epoch) error {
+			return s.recordProcessed(ctx, md, epochs...)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "failed to catch up proposer duties")
+	}
+
+	return nil
+}
+
+// catchupEpochsWithClient fetches the proposer duties for a range of epochs from the
+// given client and stores those fetched in a single transaction.
+func (s *Service) catchupEpochsWithClient(ctx context.Context, client eth2client.Service, epochRange util.EpochRange) []*util.EpochResult {
+	ctx, span := tracer.Start(ctx, "catchupEpochsWithClient", trace.WithAttributes(
+		attribute.Int64("start_epoch", int64(epochRange.Start)),
+		attribute.Int64("end_epoch", int64(epochRange.End)),
+	))
+	defer span.End()
+
+	// Epochs that have been completed since the gap was obtained are not fetched again.
+	completed := make(map[phase0.Epoch]bool)
+	completedEpochs, err := s.epochCompletionsProvider.CompletedEpochs(ctx, metadataKey, epochRange.Start, epochRange.End)
+	if err != nil {
+		log.Debug().Err(err).Msg("Failed to obtain completed epochs; fetching all epochs")
+	}
+	for _, epoch := range completedEpochs {
+		completed[epoch] = true
+	}
+
+	results := make([]*util.EpochResult, 0, int(epochRange.End-epochRange.Start)+1)
+	fetched := make([]*fetchedProposerDuties, 0, int(epochRange.End-epochRange.Start)+1)
+	for epoch := epochRange.Start; epoch <= epochRange.End; epoch++ {
+		if completed[epoch] {
+			results = append(results, &util.EpochResult{Epoch: epoch})
+			continue
+		}
+		duties, provenance, err := s.fetchProposerDutiesWithFallback(ctx, client, epoch)
+		if err != nil {
+			results = append(results, &util.EpochResult{Epoch: epoch, FetchErr: err})
+			continue
+		}
+		fetched = append(fetched, &fetchedProposerDuties{
+			epoch:      epoch,
+			duties:     duties,
+			provenance: provenance,
+		})
+	}
+	if len(fetched) == 0 {
+		return results
+	}
+
+	ctx = util.WithEpochHeadDistance(ctx, s.chainTime, fetched[len(fetched)-1].epoch)
+	err = util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		for _, f := range fetched {
+			if err := s.storeProposerDuties(ctx, f.epoch, f.duties, f.provenance); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for _, f := range fetched {
+		results = append(results, &util.EpochResult{Epoch: f.epoch, StoreErr: err})
+	}
+
+	return results
+}
+
+// recordProcessed marks epochs as processed and stores the metadata in its own transaction.
+func (s *Service) recordProcessed(ctx context.Context, md *metadata, epochs ...phase0.Epoch) error {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		if err := s.setProcessed(ctx, md, epochs...); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+}
@@ -17,9 +17,11 @@ import (
 	"context"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // OnBeaconChainHeadUpdated receives beacon chain head updated notifications.
@@ -36,10 +38,10 @@ func (s *Service) OnBeaconChainHeadUpdated(
 		return
 	}
 
-	// Only allow 1 handler to be active.
+	// Only allow a limited number of handlers to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
-		log.Debug().Msg("Another handler running")
+		log.Debug().Msg("Too many handlers running")
 		return
 	}
 
@@ -49,20 +51,128 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	md, err := s.getMetadata(ctx)
 	if err != nil {
 		s.activitySem.Release(1)
+		monitorError(err)
 		log.Error().Err(err).Msg("Failed to obtain metadata")
 		return
 	}
 
+	if !s.catchupMu.TryLock() {
+		// Another handler is catching up; update just this epoch rather than wait for it.
+		s.updateHeadEpoch(ctx, md, epoch)
+		s.activitySem.Release(1)
+		return
+	}
 	s.catchup(ctx, md)
+	s.catchupMu.Unlock()
 	s.activitySem.Release(1)
 }
 
+// onEventsResubscribed catches up with any epochs missed while the event stream was down.
+func (s *Service) onEventsResubscribed(ctx context.Context) {
+	s.OnBeaconChainHeadUpdated(ctx, s.chainTime.CurrentSlot(), phase0.Root{}, phase0.Root{}, true)
+}
+
+// OnChainReorg receives chain reorganization notifications.
+func (s *Service) OnChainReorg(
+	ctx context.Context,
+	slot phase0.Slot,
+	depth uint64,
+) {
+	// The reorg replaced the blocks after the common ancestor of the old and new heads,
+	// which can change the proposer duties for the epochs from that point onwards.
+	firstSlot := phase0.Slot(0)
+	if uint64(slot) > depth {
+		firstSlot = slot - phase0.Slot(depth) + 1
+	}
+	firstEpoch := s.chainTime.SlotToEpoch(firstSlot)
+	lastEpoch := s.chainTime.CurrentEpoch()
+	log := log.With().Uint64("first_epoch", uint64(firstEpoch)).Uint64("last_epoch", uint64(lastEpoch)).Logger()
+	log.Trace().Msg("Reorg handler called")
+
+	// Wait for an available handler, as the reorg must not be missed.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		log.Warn().Err(err).Msg("Failed to acquire semaphore")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		monitorError(err)
+		log.Error().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+
+	log.Debug().Msg("Refetching proposer duties following chain reorg")
+	for epoch := firstEpoch; epoch <= lastEpoch; epoch++ {
+		if !md.ProcessedEpochs.Contains(epoch) {
+			// Epochs that have not yet been processed will be fetched by catchup.
+			continue
+		}
+		s.updateHeadEpoch(ctx, md, epoch)
+	}
+}
+
+// updateHeadEpoch fetches and stores the proposer duties for a single epoch.
+func (s *Service) updateHeadEpoch(ctx context.Context, md *metadata, epoch phase0.Epoch) {
+	duties, provenance, err := s.fetchProposerDutiesWithFallback(ctx, s.eth2Client, epoch)
+	if err != nil {
+		monitorError(err)
+		log.Warn().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to fetch proposer duties; will refetch later")
+		monitorEpochMissed()
+		return
+	}
+	if err := s.storeFetched(ctx, md, &fetchedProposerDuties{
+		epoch:      epoch,
+		duties:     duties,
+		provenance: provenance,
+	}); err != nil {
+		monitorError(err)
+		log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to store proposer duties")
+	}
+}
+
 func (s *Service) updateProposerDutiesForEpoch(ctx context.Context, epoch phase0.Epoch) error {
-	duties, err := s.eth2Client.(eth2client.ProposerDutiesProvider).ProposerDuties(ctx, epoch, nil)
+	var duties []*api.ProposerDuty
+	var provenance *chaindb.Provenance
+	if err := s.retryPolicy.Do(ctx, func() error {
+		var err error
+		duties, provenance, err = s.fetchProposerDuties(ctx, s.eth2Client, epoch)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return s.storeProposerDuties(ctx, epoch, duties, provenance)
+}
+
+// fetchProposerDuties fetches the proposer duties for an epoch from the given client,
+// along with the provenance of the proposer duties.
+func (s *Service) fetchProposerDuties(ctx context.Context,
+	client eth2client.Service,
+	epoch phase0.Epoch,
+) (
+	[]*api.ProposerDuty,
+	*chaindb.Provenance,
+	error,
+) {
+	duties, err := client.(eth2client.ProposerDutiesProvider).ProposerDuties(ctx, epoch, nil)
 	if err != nil {
-		return errors.Wrap(err, "failed to fetch proposer duties")
+		return nil, nil, errors.Wrap(err, "failed to fetch proposer duties")
 	}
 
+	return duties, util.Provenance(ctx, client), nil
+}
+
+// provenanceDataset is the dataset for which epoch provenance is recorded.
+const provenanceDataset = "proposerduties"
+
+// storeProposerDuties stores the proposer duties for an epoch, along with their provenance.
+func (s *Service) storeProposerDuties(ctx context.Context,
+	epoch phase0.Epoch,
+	duties []*api.ProposerDuty,
+	provenance *chaindb.Provenance,
+) error {
 	for _, duty := range duties {
 		dbProposerDuty := &chaindb.ProposerDuty{
 			Slot:           duty.Slot,
@@ -72,6 +182,11 @@ func (s *Service) updateProposerDutiesForEpoch(ctx context.Context, epoch phase0
 			return errors.Wrap(err, "failed to set proposer duty")
 		}
 	}
+	if provenance != nil {
+		if err := s.epochProvenanceSetter.SetEpochProvenance(ctx, provenanceDataset, epoch, provenance); err != nil {
+			return errors.Wrap(err, "failed to set epoch provenance")
+		}
+	}
 
 	monitorEpochProcessed(epoch)
 	return nil
@@ -0,0 +1,48 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
+)
+
+// ReindexEpochs deletes and re-fetches the proposer duties for the epochs from start to end inclusive.
+// Each epoch is deleted and re-fetched within a single transaction.
+func (s *Service) ReindexEpochs(ctx context.Context, start phase0.Epoch, end phase0.Epoch) error {
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	log.Info().Uint64("start_epoch", uint64(start)).Uint64("end_epoch", uint64(end)).Msg("Reindexing epochs")
+	for epoch := start; epoch <= end; epoch++ {
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			if err := s.rangeDeleter.DeleteProposerDuties(ctx, s.chainTime.FirstSlotOfEpoch(epoch), s.chainTime.FirstSlotOfEpoch(epoch+1)-1); err != nil {
+				return errors.Wrapf(err, "failed to delete proposer duties for epoch %d", epoch)
+			}
+			if err := s.updateProposerDutiesForEpoch(ctx, epoch); err != nil {
+				return errors.Wrapf(err, "failed to update proposer duties for epoch %d", epoch)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
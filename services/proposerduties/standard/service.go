@@ -15,6 +15,8 @@ package standard
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	api "github.com/attestantio/go-eth2-client/api/v1"
@@ -24,21 +26,45 @@ import (
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
-	eth2Client           eth2client.Service
-	chainDB              chaindb.Service
-	proposerDutiesSetter chaindb.ProposerDutiesSetter
-	chainTime            chaintime.Service
-	activitySem          *semaphore.Weighted
+	eth2Client               eth2client.Service
+	catchupClients           []eth2client.Service
+	chainDB                  chaindb.Service
+	proposerDutiesSetter     chaindb.ProposerDutiesSetter
+	epochProvenanceSetter    chaindb.EpochProvenanceSetter
+	rangeDeleter             chaindb.RangeDeleter
+	epochCompletionsSetter   chaindb.EpochCompletionsSetter
+	epochCompletionsProvider chaindb.EpochCompletionsProvider
+	chainTime                chaintime.Service
+	scheduler                scheduler.Service
+	activitySem              *semaphore.Weighted
+	retryPolicy              *util.RetryPolicy
+	firstEpoch               phase0.Epoch
+	backfill                 bool
+	headFirstDistance        phase0.Epoch
+	throttle                 *util.Throttle
+	followEpoch              phase0.Epoch
+	catchupWorkers           int
+	catchupEpochsPerTx       int
+	concurrency              int64
+	catchupMu                sync.Mutex
+	metadataMu               sync.Mutex
+	eventsStallTimeout       time.Duration
 }
 
 // module-wide log.
 var log zerolog.Logger
 
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/proposerduties/standard")
+
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -48,6 +74,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "proposerduties").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -58,16 +87,57 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support proposer duty setting")
 	}
 
+	epochProvenanceSetter, isEpochProvenanceSetter := parameters.chainDB.(chaindb.EpochProvenanceSetter)
+	if !isEpochProvenanceSetter {
+		return nil, errors.New("chain DB does not support epoch provenance setting")
+	}
+
+	rangeDeleter, isRangeDeleter := parameters.chainDB.(chaindb.RangeDeleter)
+	if !isRangeDeleter {
+		return nil, errors.New("chain DB does not support range deletion")
+	}
+
+	epochCompletionsSetter, isEpochCompletionsSetter := parameters.chainDB.(chaindb.EpochCompletionsSetter)
+	if !isEpochCompletionsSetter {
+		return nil, errors.New("chain DB does not support epoch completion setting")
+	}
+
+	epochCompletionsProvider, isEpochCompletionsProvider := parameters.chainDB.(chaindb.EpochCompletionsProvider)
+	if !isEpochCompletionsProvider {
+		return nil, errors.New("chain DB does not support epoch completion providing")
+	}
+
+	catchupClients := parameters.catchupClients
+	if len(catchupClients) == 0 {
+		catchupClients = []eth2client.Service{parameters.eth2Client}
+	}
+
 	s := &Service{
-		eth2Client:           parameters.eth2Client,
-		chainDB:              parameters.chainDB,
-		proposerDutiesSetter: proposerDutiesSetter,
-		chainTime:            parameters.chainTime,
-		activitySem:          semaphore.NewWeighted(1),
+		eth2Client:               parameters.eth2Client,
+		catchupClients:           catchupClients,
+		chainDB:                  parameters.chainDB,
+		proposerDutiesSetter:     proposerDutiesSetter,
+		epochProvenanceSetter:    epochProvenanceSetter,
+		rangeDeleter:             rangeDeleter,
+		epochCompletionsSetter:   epochCompletionsSetter,
+		epochCompletionsProvider: epochCompletionsProvider,
+		chainTime:                parameters.chainTime,
+		scheduler:                parameters.scheduler,
+		retryPolicy:              parameters.retryPolicy,
+		activitySem:              semaphore.NewWeighted(parameters.concurrency),
+		eventsStallTimeout:       parameters.eventsStallTimeout,
+		backfill:                 parameters.backfill,
+		headFirstDistance:        parameters.headFirstDistance,
+		throttle:                 parameters.throttle,
+		catchupWorkers:           parameters.catchupWorkers,
+		catchupEpochsPerTx:       parameters.catchupEpochsPerTx,
+		concurrency:              parameters.concurrency,
 	}
 
 	// Update to current epoch before starting (in the background).
-	go s.updateAfterRestart(ctx, parameters.startEpoch)
+	if !parameters.passive {
+		go s.updateAfterRestart(ctx, parameters.startEpoch)
+	}
 
 	return s, nil
 }
@@ -79,103 +149,221 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
 	if startEpoch >= 0 {
-		// Explicit requirement to start at a given epoch.
-		md.LatestEpoch = phase0.Epoch(startEpoch)
-	} else if md.LatestEpoch > 0 {
-		// We have a definite hit on this being the last processed epoch; increment it to avoid duplication of work.
-		md.LatestEpoch++
+		// Explicit requirement to start at a given epoch; anything already
+		// processed from this epoch onwards is processed again.
+		s.firstEpoch = phase0.Epoch(startEpoch)
+		md.ProcessedEpochs.RemoveFrom(s.firstEpoch)
+		md.LatestEpoch, _ = md.ProcessedEpochs.Highest()
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			if err := s.setMetadata(ctx, md); err != nil {
+				return errors.Wrap(err, "failed to set metadata with start epoch")
+			}
+			if err := s.epochCompletionsSetter.DeleteEpochCompletions(ctx, metadataKey, s.firstEpoch); err != nil {
+				return errors.Wrap(err, "failed to remove epoch completions from start epoch")
+			}
+			return nil
+		}); err != nil {
+			log.Fatal().Err(err).Msg("Failed to set start epoch")
+		}
+	} else if lowestEpoch, exists := md.ProcessedEpochs.Lowest(); exists {
+		// Fill in any gaps from the first epoch that was processed.
+		s.firstEpoch = lowestEpoch
+	}
+	if err := s.applyCompletions(ctx, md); err != nil {
+		log.Fatal().Err(err).Msg("Failed to apply epoch completions")
 	}
 
-	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
-	s.catchup(ctx, md)
-	if len(md.MissedEpochs) > 0 {
-		// Need this as a []uint64 for logging only.
-		missedEpochs := make([]uint64, len(md.MissedEpochs))
-		for i := range md.MissedEpochs {
-			missedEpochs[i] = uint64(md.MissedEpochs[i])
-		}
-		log.Info().Uints64("missed_epochs", missedEpochs).Msg("Re-fetching missed epochs")
-		s.handleMissed(ctx, md)
-		// Catchup again, in case handling the missed epochs took a while.
-		log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
-		s.catchup(ctx, md)
+	if s.followFromHead(md) {
+		// Follow the chain from the current epoch, filling in earlier epochs in the background.
+		s.followEpoch = s.chainTime.CurrentEpoch()
+		go s.backfillEpochs(ctx)
 	}
+
+	log.Info().Uint64("epoch", uint64(s.catchupEpoch())).Msg("Catching up from epoch")
+	s.catchup(ctx, md)
 	log.Info().Msg("Caught up")
 
-	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
-		eventData := event.Data.(*api.HeadEvent)
-		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
-	}); err != nil {
+	// Set up the handler for new chain head updates and reorgs.
+	if err := util.SubscribeEvents(ctx, log, s.eth2Client.(eth2client.EventsProvider), []string{"head", "chain_reorg"}, s.eventsStallTimeout, func(event *api.Event) {
+		switch eventData := event.Data.(type) {
+		case *api.HeadEvent:
+			s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
+		case *api.ChainReorgEvent:
+			log.Trace().Str("event", eventData.String()).Msg("Received reorg event")
+			if err := s.scheduler.Submit(ctx, "chain reorg", scheduler.PriorityHead, func(ctx context.Context) {
+				s.OnChainReorg(ctx, eventData.Slot, eventData.Depth)
+			}); err != nil {
+				monitorError(err)
+				log.Error().Err(err).Msg("Failed to schedule reorg handling")
+			}
+		}
+	}, s.onEventsResubscribed); err != nil {
 		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 }
 
-func (s *Service) catchup(ctx context.Context, md *metadata) {
-	for epoch := md.LatestEpoch; epoch <= s.chainTime.CurrentEpoch(); epoch++ {
-		log := log.With().Uint64("epoch", uint64(epoch)).Logger()
-		// Each update goes in to its own transaction, to make the data available sooner.
-		dbCtx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
-			return
-		}
+// catchupEpoch returns the first epoch processed by catchup.
+func (s *Service) catchupEpoch() phase0.Epoch {
+	if s.followEpoch > s.firstEpoch {
+		return s.followEpoch
+	}
+	return s.firstEpoch
+}
 
-		if err := s.updateProposerDutiesForEpoch(dbCtx, epoch); err != nil {
-			log.Error().Err(err).Msg("Failed to update proposer duties")
-			cancel()
-			return
+// catchup processes the epochs from the catchup epoch to the current epoch that
+// have not yet been processed.  Epochs that cannot be fetched are left
+// unprocessed, to be fetched again on the next catchup.  Epochs that already have
+// a completion marker are not fetched again.
+func (s *Service) catchup(ctx context.Context, md *metadata) {
+	if err := s.applyCompletions(ctx, md); err != nil {
+		monitorError(err)
+		log.Error().Err(err).Msg("Failed to apply epoch completions")
+		return
+	}
+	for _, gap := range md.ProcessedEpochs.Gaps(s.catchupEpoch(), s.chainTime.CurrentEpoch()) {
+		if s.catchupWorkers > 1 {
+			if err := s.catchupGap(ctx, md, gap); err != nil {
+				monitorError(err)
+				log.Error().Err(err).Msg("Failed to catch up with workers")
+				return
+			}
+			continue
 		}
-
-		md.LatestEpoch = epoch
-		if err := s.setMetadata(dbCtx, md); err != nil {
-			log.Error().Err(err).Msg("Failed to set metadata")
-			cancel()
-			return
+		// Epochs are stored a number at a time, to amortize the cost of committing.
+		pending := make([]*fetchedProposerDuties, 0, s.catchupEpochsPerTx)
+		for epoch := gap.Start; epoch <= gap.End; {
+			next, err := s.skipCompleted(ctx, md, epoch, gap.End)
+			if err != nil {
+				monitorError(err)
+				log.Error().Uint64("epoch", uint64(epoch)).Err(err).Msg("Failed to skip completed epochs")
+				return
+			}
+			if next != epoch {
+				epoch = next
+				continue
+			}
+			// Fetch a batch of epochs at a time, to spread the requests across beacon nodes.
+			batch := s.fetchProposerDutiesBatch(ctx, epoch, gap.End)
+			for _, fetched := range batch {
+				if fetched.err != nil {
+					// Leave the epoch unprocessed and carry on, rather than stalling.
+					log.Warn().Uint64("epoch", uint64(fetched.epoch)).Err(fetched.err).Msg("Failed to fetch proposer duties; will refetch later")
+					monitorEpochMissed()
+					continue
+				}
+				pending = append(pending, fetched)
+				if len(pending) < s.catchupEpochsPerTx {
+					continue
+				}
+				if err := s.storeFetched(ctx, md, pending...); err != nil {
+					monitorError(err)
+					log.Error().Uint64("start_epoch", uint64(pending[0].epoch)).Uint64("end_epoch", uint64(fetched.epoch)).Err(err).Msg("Failed to store proposer duties")
+					return
+				}
+				pending = pending[:0]
+			}
+			epoch += phase0.Epoch(len(batch))
 		}
-
-		if err := s.chainDB.CommitTx(dbCtx); err != nil {
-			log.Error().Err(err).Msg("Failed to commit transaction")
-			cancel()
-			return
+		if len(pending) > 0 {
+			if err := s.storeFetched(ctx, md, pending...); err != nil {
+				monitorError(err)
+				log.Error().Uint64("start_epoch", uint64(pending[0].epoch)).Uint64("end_epoch", uint64(pending[len(pending)-1].epoch)).Err(err).Msg("Failed to store proposer duties")
+				return
+			}
 		}
 	}
 }
 
-func (s *Service) handleMissed(ctx context.Context, md *metadata) {
-	failed := 0
-	for i := 0; i < len(md.MissedEpochs); i++ {
-		log := log.With().Uint64("epoch", uint64(md.MissedEpochs[i])).Logger()
-		// Each update goes in to its own transaction, to make the data available sooner.
-		dbCtx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
-			return
-		}
+// storeFetched stores fetched proposer duties, in epoch order, and marks their epochs as
+// processed.  The epochs go in to a single transaction; outside of catchup this is a
+// single epoch, to make the data available sooner.
+func (s *Service) storeFetched(ctx context.Context, md *metadata, fetched ...*fetchedProposerDuties) error {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
 
-		if err := s.updateProposerDutiesForEpoch(dbCtx, md.MissedEpochs[i]); err != nil {
-			log.Warn().Err(err).Msg("Failed to update proposer duties")
-			failed++
-			cancel()
-			continue
+	ctx = util.WithEpochHeadDistance(ctx, s.chainTime, fetched[len(fetched)-1].epoch)
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		epochs := make([]phase0.Epoch, 0, len(fetched))
+		for _, f := range fetched {
+			if err := s.storeProposerDuties(ctx, f.epoch, f.duties, f.provenance); err != nil {
+				return errors.Wrap(err, "failed to update proposer duties")
+			}
+			epochs = append(epochs, f.epoch)
 		}
-		// Remove this from the list of missed epochs.
-		missedEpochs := make([]phase0.Epoch, len(md.MissedEpochs)-1)
-		copy(missedEpochs[:failed], md.MissedEpochs[:failed])
-		copy(missedEpochs[failed:], md.MissedEpochs[i+1:])
-		md.MissedEpochs = missedEpochs
-		i--
-
-		if err := s.setMetadata(dbCtx, md); err != nil {
-			log.Error().Err(err).Msg("Failed to set metadata")
-			cancel()
-			return
+		if err := s.setProcessed(ctx, md, epochs...); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
 		}
+		return nil
+	})
+}
 
-		if err := s.chainDB.CommitTx(dbCtx); err != nil {
-			log.Error().Err(err).Msg("Failed to commit transaction")
-			cancel()
-			return
+// setProcessed marks epochs as processed and stores the metadata.  If handlers can run
+// concurrently the metadata is obtained afresh, as it may have been updated by another
+// handler.  This must be called with the metadata mutex held.
+func (s *Service) setProcessed(ctx context.Context, md *metadata, epochs ...phase0.Epoch) error {
+	if s.concurrency > 1 {
+		latest, err := s.getMetadata(ctx)
+		if err != nil {
+			return err
 		}
+		*md = *latest
+	}
+	for _, epoch := range epochs {
+		md.setProcessed(epoch)
+		if err := s.epochCompletionsSetter.SetEpochComplete(ctx, metadataKey, epoch); err != nil {
+			return errors.Wrap(err, "failed to set epoch completion")
+		}
+	}
+
+	return s.setMetadata(ctx, md)
+}
+
+// skipCompleted returns the first epoch from the given epoch that does not have a
+// completion marker, marking the epochs skipped over as processed.  Epochs can be
+// completed after catchup has started, for example by a concurrent handler or by
+// backfill.  If all epochs up to the end epoch are complete it returns the epoch
+// after the end epoch.
+func (s *Service) skipCompleted(ctx context.Context, md *metadata, epoch phase0.Epoch, endEpoch phase0.Epoch) (phase0.Epoch, error) {
+	skipped, err := util.CompletedRun(ctx, s.epochCompletionsProvider, metadataKey, epoch, endEpoch)
+	if err != nil {
+		return 0, err
+	}
+	if len(skipped) == 0 {
+		return epoch, nil
 	}
+
+	log.Trace().Uint64("start_epoch", uint64(skipped[0])).Uint64("end_epoch", uint64(skipped[len(skipped)-1])).Msg("Skipping completed epochs")
+	if err := s.recordProcessed(ctx, md, skipped...); err != nil {
+		return 0, err
+	}
+
+	return epoch + phase0.Epoch(len(skipped)), nil
+}
+
+// applyCompletions marks as processed any epochs that have a completion marker but are
+// not marked as processed in the metadata.  Completion markers are written in the same
+// transaction as the data for their epoch, so are authoritative.
+func (s *Service) applyCompletions(ctx context.Context, md *metadata) error {
+	s.metadataMu.Lock()
+	defer s.metadataMu.Unlock()
+
+	epochs, err := util.CompletedInGaps(ctx, s.epochCompletionsProvider, metadataKey, md.ProcessedEpochs, s.firstEpoch, s.chainTime.CurrentEpoch())
+	if err != nil {
+		return err
+	}
+	if len(epochs) == 0 {
+		return nil
+	}
+	for _, epoch := range epochs {
+		md.setProcessed(epoch)
+	}
+
+	log.Debug().Int("epochs", len(epochs)).Msg("Marking completed epochs as processed")
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		return s.setMetadata(ctx, md)
+	}); err != nil {
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	return nil
 }
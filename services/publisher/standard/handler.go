@@ -0,0 +1,166 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	chaindv1 "github.com/wealdtech/chaind/proto/chaind/v1"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	topicBlocks       = "blocks"
+	topicAttestations = "attestations"
+	topicValidators   = "validators"
+	topicFinality     = "finality"
+)
+
+type updateKind int
+
+const (
+	updateBlock updateKind = iota
+	updateValidators
+	updateFinality
+)
+
+// update is a notification from an indexing service, queued for publishing.
+type update struct {
+	kind  updateKind
+	slot  phase0.Slot
+	root  phase0.Root
+	epoch phase0.Epoch
+}
+
+// OnBlockIndexed is called when a block has been committed to the database.
+func (s *Service) OnBlockIndexed(ctx context.Context, slot phase0.Slot, root phase0.Root) {
+	s.queue(ctx, &update{kind: updateBlock, slot: slot, root: root})
+}
+
+// OnValidatorsUpdated is called when the validators for an epoch have been committed to the database.
+func (s *Service) OnValidatorsUpdated(ctx context.Context, epoch phase0.Epoch) {
+	s.queue(ctx, &update{kind: updateValidators, epoch: epoch})
+}
+
+// OnFinalityUpdated is called when finality has been updated in the database.
+func (s *Service) OnFinalityUpdated(ctx context.Context, epoch phase0.Epoch) {
+	s.queue(ctx, &update{kind: updateFinality, epoch: epoch})
+}
+
+// queue queues an update for publishing.  If the queue is full this blocks,
+// applying backpressure to indexing rather than dropping data.
+func (s *Service) queue(ctx context.Context, update *update) {
+	select {
+	case s.updates <- update:
+	case <-ctx.Done():
+	}
+}
+
+// process publishes the data for an update.
+func (s *Service) process(ctx context.Context, update *update) {
+	var err error
+	switch update.kind {
+	case updateBlock:
+		err = s.publishBlock(ctx, update.root)
+	case updateValidators:
+		err = s.publishValidators(ctx)
+	case updateFinality:
+		err = s.publish(ctx, topicFinality, fmt.Sprintf("%d", update.epoch), &chaindv1.FinalityUpdate{
+			FinalizedEpoch: uint64(update.epoch),
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Uint64("slot", uint64(update.slot)).Uint64("epoch", uint64(update.epoch)).Msg("Failed to publish update")
+	}
+}
+
+// publishBlock publishes a block and the attestations it contains.
+func (s *Service) publishBlock(ctx context.Context, root phase0.Root) error {
+	block, err := s.blocksProvider.BlockByRoot(ctx, root)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain block")
+	}
+	if block == nil {
+		return errors.Errorf("block %#x not found", root)
+	}
+	key := fmt.Sprintf("%d", block.Slot)
+	if err := s.publish(ctx, topicBlocks, key, blockMessage(block)); err != nil {
+		return err
+	}
+
+	attestations, err := s.attestationsProvider.AttestationsInBlock(ctx, root)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain attestations")
+	}
+
+	return s.publish(ctx, topicAttestations, key, blockAttestationsMessage(block, attestations))
+}
+
+// publishValidators publishes the validators that have changed since the last update.
+// The first update records the state of the validators without publishing them.
+func (s *Service) publishValidators(ctx context.Context) error {
+	validators, err := s.validatorSet.Validators(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain validators")
+	}
+
+	first := s.validators == nil
+	if first {
+		s.validators = make(map[phase0.ValidatorIndex]*chaindb.Validator, len(validators))
+	}
+	for _, validator := range validators {
+		previous, exists := s.validators[validator.Index]
+		s.validators[validator.Index] = validator
+		if first || (exists && !validatorChanged(previous, validator)) {
+			continue
+		}
+		if err := s.publish(ctx, topicValidators, fmt.Sprintf("%d", validator.Index), validatorMessage(validator)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatorChanged returns true if the validator has changed.
+func validatorChanged(previous *chaindb.Validator, current *chaindb.Validator) bool {
+	return previous.EffectiveBalance != current.EffectiveBalance ||
+		previous.Slashed != current.Slashed ||
+		previous.ActivationEligibilityEpoch != current.ActivationEligibilityEpoch ||
+		previous.ActivationEpoch != current.ActivationEpoch ||
+		previous.ExitEpoch != current.ExitEpoch ||
+		previous.WithdrawableEpoch != current.WithdrawableEpoch ||
+		!bytes.Equal(previous.WithdrawalCredentials, current.WithdrawalCredentials)
+}
+
+// publish marshals and publishes a message.
+func (s *Service) publish(ctx context.Context, topic string, key string, msg proto.Message) error {
+	value, err := s.marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal message")
+	}
+
+	err = s.sink.Publish(ctx, fmt.Sprintf("%s.%s", s.topicPrefix, topic), []byte(key), value)
+	monitorMessagePublished(topic, err == nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to publish to %s", topic)
+	}
+
+	return nil
+}
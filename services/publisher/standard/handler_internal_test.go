@@ -0,0 +1,164 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	chaindv1 "github.com/wealdtech/chaind/proto/chaind/v1"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"google.golang.org/protobuf/proto"
+)
+
+type message struct {
+	topic string
+	key   string
+	value []byte
+}
+
+type testSink struct {
+	messages []*message
+}
+
+func (t *testSink) Publish(_ context.Context, topic string, key []byte, value []byte) error {
+	t.messages = append(t.messages, &message{topic: topic, key: string(key), value: value})
+	return nil
+}
+
+func (t *testSink) Close() error {
+	return nil
+}
+
+// testChainDB provides fixed blocks and validators.
+type testChainDB struct {
+	chaindb.BlocksProvider
+	chaindb.AttestationsProvider
+	chaindb.ValidatorsProvider
+	block        *chaindb.Block
+	attestations []*chaindb.Attestation
+	validators   []*chaindb.Validator
+}
+
+func (t *testChainDB) BlockByRoot(_ context.Context, root phase0.Root) (*chaindb.Block, error) {
+	if t.block == nil || t.block.Root != root {
+		return nil, nil
+	}
+	return t.block, nil
+}
+
+func (t *testChainDB) AttestationsInBlock(_ context.Context, _ phase0.Root) ([]*chaindb.Attestation, error) {
+	return t.attestations, nil
+}
+
+func (t *testChainDB) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	return t.validators, nil
+}
+
+func newTestChainDB() *testChainDB {
+	chainDB := mockchaindb.New()
+	return &testChainDB{
+		BlocksProvider:       chainDB.(chaindb.BlocksProvider),
+		AttestationsProvider: chainDB.(chaindb.AttestationsProvider),
+		ValidatorsProvider:   chainDB.(chaindb.ValidatorsProvider),
+	}
+}
+
+func newTestService(chainDB *testChainDB, sink sink) *Service {
+	return &Service{
+		blocksProvider:       chainDB,
+		attestationsProvider: chainDB,
+		validatorsProvider:   chainDB,
+		validatorSet:         chainDB,
+		sink:                 sink,
+		topicPrefix:          "chaind",
+		marshal:              marshaler(encodingProtobuf),
+	}
+}
+
+func TestPublishBlock(t *testing.T) {
+	ctx := context.Background()
+	chainDB := newTestChainDB()
+	chainDB.block = &chaindb.Block{
+		Slot: 12,
+		Root: phase0.Root{0x01},
+	}
+	chainDB.attestations = []*chaindb.Attestation{
+		{InclusionSlot: 12, Slot: 11},
+		{InclusionSlot: 12, Slot: 10},
+	}
+	sink := &testSink{}
+	s := newTestService(chainDB, sink)
+
+	require.EqualError(t, s.publishBlock(ctx, phase0.Root{0x02}), "block 0x0200000000000000000000000000000000000000000000000000000000000000 not found")
+	require.Len(t, sink.messages, 0)
+
+	require.NoError(t, s.publishBlock(ctx, phase0.Root{0x01}))
+	require.Len(t, sink.messages, 2)
+	require.Equal(t, "chaind.blocks", sink.messages[0].topic)
+	require.Equal(t, "12", sink.messages[0].key)
+	block := &chaindv1.Block{}
+	require.NoError(t, proto.Unmarshal(sink.messages[0].value, block))
+	require.Equal(t, uint64(12), block.Slot)
+	require.Equal(t, "chaind.attestations", sink.messages[1].topic)
+	attestations := &chaindv1.BlockAttestations{}
+	require.NoError(t, proto.Unmarshal(sink.messages[1].value, attestations))
+	require.Len(t, attestations.Attestations, 2)
+	require.Equal(t, uint64(10), attestations.Attestations[1].Slot)
+}
+
+func TestPublishValidators(t *testing.T) {
+	ctx := context.Background()
+	chainDB := newTestChainDB()
+	chainDB.validators = []*chaindb.Validator{
+		{Index: 1, EffectiveBalance: 32000000000},
+		{Index: 2, EffectiveBalance: 32000000000},
+	}
+	sink := &testSink{}
+	s := newTestService(chainDB, sink)
+
+	// First update records state only.
+	require.NoError(t, s.publishValidators(ctx))
+	require.Len(t, sink.messages, 0)
+
+	// Changed and new validators are published.
+	chainDB.validators = []*chaindb.Validator{
+		{Index: 1, EffectiveBalance: 32000000000},
+		{Index: 2, EffectiveBalance: 31000000000},
+		{Index: 3, EffectiveBalance: 32000000000},
+	}
+	require.NoError(t, s.publishValidators(ctx))
+	require.Len(t, sink.messages, 2)
+	require.Equal(t, "chaind.validators", sink.messages[0].topic)
+	require.Equal(t, "2", sink.messages[0].key)
+	require.Equal(t, "3", sink.messages[1].key)
+
+	// No changes publish nothing.
+	require.NoError(t, s.publishValidators(ctx))
+	require.Len(t, sink.messages, 2)
+}
+
+func TestProcessFinality(t *testing.T) {
+	sink := &testSink{}
+	s := newTestService(newTestChainDB(), sink)
+	s.marshal = marshaler(encodingJSON)
+
+	s.process(context.Background(), &update{kind: updateFinality, epoch: 100})
+	require.Len(t, sink.messages, 1)
+	require.Equal(t, "chaind.finality", sink.messages[0].topic)
+	require.JSONEq(t, `{"finalized_epoch":"100"}`, string(sink.messages[0].value))
+}
@@ -0,0 +1,103 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	chaindv1 "github.com/wealdtech/chaind/proto/chaind/v1"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	encodingJSON     = "json"
+	encodingProtobuf = "protobuf"
+)
+
+// marshaler returns the function used to marshal messages with the given encoding.
+func marshaler(encoding string) func(proto.Message) ([]byte, error) {
+	if encoding == encodingProtobuf {
+		return proto.Marshal
+	}
+	return protojson.MarshalOptions{UseProtoNames: true}.Marshal
+}
+
+func blockMessage(block *chaindb.Block) *chaindv1.Block {
+	return &chaindv1.Block{
+		Slot:             uint64(block.Slot),
+		ProposerIndex:    uint64(block.ProposerIndex),
+		Root:             block.Root[:],
+		Graffiti:         block.Graffiti,
+		RandaoReveal:     block.RANDAOReveal[:],
+		BodyRoot:         block.BodyRoot[:],
+		ParentRoot:       block.ParentRoot[:],
+		StateRoot:        block.StateRoot[:],
+		Canonical:        block.Canonical,
+		Eth1BlockHash:    block.ETH1BlockHash,
+		Eth1DepositCount: block.ETH1DepositCount,
+		Eth1DepositRoot:  block.ETH1DepositRoot[:],
+	}
+}
+
+func blockAttestationsMessage(block *chaindb.Block, attestations []*chaindb.Attestation) *chaindv1.BlockAttestations {
+	msg := &chaindv1.BlockAttestations{
+		Slot:         uint64(block.Slot),
+		BlockRoot:    block.Root[:],
+		Attestations: make([]*chaindv1.Attestation, len(attestations)),
+	}
+	for i := range attestations {
+		msg.Attestations[i] = attestationMessage(attestations[i])
+	}
+
+	return msg
+}
+
+func attestationMessage(attestation *chaindb.Attestation) *chaindv1.Attestation {
+	aggregationIndices := make([]uint64, len(attestation.AggregationIndices))
+	for i := range attestation.AggregationIndices {
+		aggregationIndices[i] = uint64(attestation.AggregationIndices[i])
+	}
+
+	return &chaindv1.Attestation{
+		InclusionSlot:      uint64(attestation.InclusionSlot),
+		InclusionBlockRoot: attestation.InclusionBlockRoot[:],
+		InclusionIndex:     attestation.InclusionIndex,
+		Slot:               uint64(attestation.Slot),
+		CommitteeIndex:     uint64(attestation.CommitteeIndex),
+		AggregationBits:    attestation.AggregationBits,
+		AggregationIndices: aggregationIndices,
+		BeaconBlockRoot:    attestation.BeaconBlockRoot[:],
+		SourceEpoch:        uint64(attestation.SourceEpoch),
+		SourceRoot:         attestation.SourceRoot[:],
+		TargetEpoch:        uint64(attestation.TargetEpoch),
+		TargetRoot:         attestation.TargetRoot[:],
+		Canonical:          attestation.Canonical,
+		TargetCorrect:      attestation.TargetCorrect,
+		HeadCorrect:        attestation.HeadCorrect,
+	}
+}
+
+func validatorMessage(validator *chaindb.Validator) *chaindv1.Validator {
+	return &chaindv1.Validator{
+		Index:                      uint64(validator.Index),
+		PublicKey:                  validator.PublicKey[:],
+		EffectiveBalance:           uint64(validator.EffectiveBalance),
+		Slashed:                    validator.Slashed,
+		ActivationEligibilityEpoch: uint64(validator.ActivationEligibilityEpoch),
+		ActivationEpoch:            uint64(validator.ActivationEpoch),
+		ExitEpoch:                  uint64(validator.ExitEpoch),
+		WithdrawableEpoch:          uint64(validator.WithdrawableEpoch),
+		WithdrawalCredentials:      validator.WithdrawalCredentials,
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/validatorset"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	monitor         metrics.Service
+	chainDB         chaindb.Service
+	backend         string
+	addresses       []string
+	topicPrefix     string
+	encoding        string
+	bufferSize      int
+	validatorSet    validatorset.Service
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithBackend sets the backend to which messages are published; either "kafka" or "nats".
+func WithBackend(backend string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backend = backend
+	})
+}
+
+// WithAddresses sets the addresses of the Kafka brokers or NATS servers.
+func WithAddresses(addresses []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.addresses = addresses
+	})
+}
+
+// WithTopicPrefix sets the prefix for the topics to which messages are published.
+func WithTopicPrefix(topicPrefix string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.topicPrefix = topicPrefix
+	})
+}
+
+// WithEncoding sets the encoding of published messages; either "json" or "protobuf".
+func WithEncoding(encoding string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.encoding = encoding
+	})
+}
+
+// WithBufferSize sets the number of indexing updates that can be queued for publishing.
+func WithBufferSize(bufferSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.bufferSize = bufferSize
+	})
+}
+
+// WithValidatorSet sets the validator set from which validators are obtained.
+// If not supplied validators are obtained from the chain database.
+func WithValidatorSet(validatorSet validatorset.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorSet = validatorSet
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:    zerolog.GlobalLevel(),
+		topicPrefix: "chaind",
+		encoding:    "json",
+		bufferSize:  1024,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	switch parameters.backend {
+	case "":
+		return nil, errors.New("no backend specified")
+	case backendKafka, backendNATS:
+	default:
+		return nil, fmt.Errorf("unknown backend %q", parameters.backend)
+	}
+	if len(parameters.addresses) == 0 {
+		return nil, errors.New("no addresses specified")
+	}
+	if parameters.topicPrefix == "" {
+		return nil, errors.New("no topic prefix specified")
+	}
+	switch parameters.encoding {
+	case encodingJSON, encodingProtobuf:
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", parameters.encoding)
+	}
+	if parameters.bufferSize <= 0 {
+		return nil, errors.New("buffer size must be greater than 0")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,117 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"google.golang.org/protobuf/proto"
+)
+
+// Service is a publisher service, publishing indexed data to Kafka or NATS.
+type Service struct {
+	blocksProvider       chaindb.BlocksProvider
+	attestationsProvider chaindb.AttestationsProvider
+	validatorsProvider   chaindb.ValidatorsProvider
+	validatorSet         validatorset.Service
+	sink                 sink
+	topicPrefix          string
+	marshal              func(proto.Message) ([]byte, error)
+	updates              chan *update
+	// validators is the last known state of the validators, used to
+	// publish only those validators that have changed.  It is only
+	// accessed by the publishing goroutine.
+	validators map[phase0.ValidatorIndex]*chaindb.Validator
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "publisher").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	attestationsProvider, isProvider := parameters.chainDB.(chaindb.AttestationsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide attestations")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorSet = validatorsProvider
+	}
+
+	sink, err := newSink(parameters.backend, parameters.addresses)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sink")
+	}
+
+	s := &Service{
+		blocksProvider:       blocksProvider,
+		attestationsProvider: attestationsProvider,
+		validatorsProvider:   validatorsProvider,
+		validatorSet:         validatorSet,
+		sink:                 sink,
+		topicPrefix:          parameters.topicPrefix,
+		marshal:              marshaler(parameters.encoding),
+		updates:              make(chan *update, parameters.bufferSize),
+	}
+
+	go s.run(ctx)
+
+	return s, nil
+}
+
+// run publishes updates until the context is done.
+func (s *Service) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			if err := s.sink.Close(); err != nil {
+				log.Warn().Err(err).Msg("Failed to close sink")
+			}
+			return
+		case update := <-s.updates:
+			s.process(ctx, update)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	"github.com/wealdtech/chaind/services/publisher/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	addresses := []string{"localhost:9092"}
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithBackend("kafka"),
+				standard.WithAddresses(addresses),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "BackendMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithAddresses(addresses),
+			},
+			err: "problem with parameters: no backend specified",
+		},
+		{
+			name: "BackendUnknown",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithBackend("unknown"),
+				standard.WithAddresses(addresses),
+			},
+			err: `problem with parameters: unknown backend "unknown"`,
+		},
+		{
+			name: "AddressesMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithBackend("kafka"),
+			},
+			err: "problem with parameters: no addresses specified",
+		},
+		{
+			name: "TopicPrefixMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithBackend("kafka"),
+				standard.WithAddresses(addresses),
+				standard.WithTopicPrefix(""),
+			},
+			err: "problem with parameters: no topic prefix specified",
+		},
+		{
+			name: "EncodingUnknown",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithBackend("kafka"),
+				standard.WithAddresses(addresses),
+				standard.WithEncoding("xml"),
+			},
+			err: `problem with parameters: unknown encoding "xml"`,
+		},
+		{
+			name: "BufferSizeZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithBackend("kafka"),
+				standard.WithAddresses(addresses),
+				standard.WithBufferSize(0),
+			},
+			err: "problem with parameters: buffer size must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithBackend("kafka"),
+				standard.WithAddresses(addresses),
+				standard.WithEncoding("protobuf"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
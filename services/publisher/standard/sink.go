@@ -0,0 +1,109 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	backendKafka = "kafka"
+	backendNATS  = "nats"
+)
+
+// sink is a destination for published messages.
+type sink interface {
+	// Publish publishes a message to a topic.
+	Publish(ctx context.Context, topic string, key []byte, value []byte) error
+	// Close closes the sink, flushing any pending messages.
+	Close() error
+}
+
+// newSink creates a sink for the given backend.
+func newSink(backend string, addresses []string) (sink, error) {
+	switch backend {
+	case backendKafka:
+		return newKafkaSink(addresses), nil
+	case backendNATS:
+		return newNATSSink(addresses)
+	default:
+		return nil, errors.Errorf("unknown backend %q", backend)
+	}
+}
+
+// kafkaSink publishes messages to Kafka.
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(addresses []string) *kafkaSink {
+	return &kafkaSink{
+		writer: &kafka.Writer{
+			Addr: kafka.TCP(addresses...),
+			// Hash the key so that messages for the same entity go to the same partition, and hence stay in order.
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+	}
+}
+
+// Publish publishes a message to a topic.
+func (k *kafkaSink) Publish(ctx context.Context, topic string, key []byte, value []byte) error {
+	return k.writer.WriteMessages(ctx, kafka.Message{
+		Topic: topic,
+		Key:   key,
+		Value: value,
+	})
+}
+
+// Close closes the sink, flushing any pending messages.
+func (k *kafkaSink) Close() error {
+	return k.writer.Close()
+}
+
+// natsSink publishes messages to NATS.
+type natsSink struct {
+	conn *nats.Conn
+}
+
+func newNATSSink(addresses []string) (*natsSink, error) {
+	conn, err := nats.Connect(strings.Join(addresses, ","),
+		nats.Name("chaind"),
+		nats.MaxReconnects(-1),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to NATS")
+	}
+
+	return &natsSink{
+		conn: conn,
+	}, nil
+}
+
+// Publish publishes a message to a topic.
+// NATS has no concept of a message key, so it is ignored.
+func (n *natsSink) Publish(_ context.Context, topic string, _ []byte, value []byte) error {
+	return n.conn.Publish(topic, value)
+}
+
+// Close closes the sink, flushing any pending messages.
+func (n *natsSink) Close() error {
+	return n.conn.Drain()
+}
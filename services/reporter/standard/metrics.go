@@ -0,0 +1,60 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_reporter"
+
+var reports *prometheus.CounterVec
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if reports != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(_ context.Context) error {
+	reports = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reports_total",
+		Help:      "Number of reports written to destinations",
+	}, []string{"destination", "result"})
+	if err := prometheus.Register(reports); err != nil {
+		return errors.Wrap(err, "failed to register reports_total")
+	}
+
+	return nil
+}
+
+func monitorReport(destination string, result string) {
+	if reports != nil {
+		reports.WithLabelValues(destination, result).Inc()
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	monitor         metrics.Service
+	chainDB         chaindb.Service
+	chainTime       chaintime.Service
+	period          string
+	formats         []string
+	directory       string
+	webhook         string
+	validators      []phase0.ValidatorIndex
+	interval        time.Duration
+	timeout         time.Duration
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithPeriod sets the period covered by each report: "daily" or "weekly".
+func WithPeriod(period string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.period = period
+	})
+}
+
+// WithFormats sets the formats in which reports are written to the directory:
+// any of "json", "csv" and "html".
+func WithFormats(formats []string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.formats = formats
+	})
+}
+
+// WithDirectory sets the directory to which reports are written.
+func WithDirectory(directory string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.directory = directory
+	})
+}
+
+// WithWebhook sets the URL to which reports are POSTed as JSON.
+func WithWebhook(webhook string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.webhook = webhook
+	})
+}
+
+// WithValidators sets the validators whose performance is reported.
+func WithValidators(validators []phase0.ValidatorIndex) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validators = validators
+	})
+}
+
+// WithInterval sets the interval at which the service checks for a period to report.
+func WithInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.interval = interval
+	})
+}
+
+// WithTimeout sets the timeout for sending a report to the webhook.
+func WithTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.timeout = timeout
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel: zerolog.GlobalLevel(),
+		period:   periodDaily,
+		formats:  []string{formatJSON},
+		interval: 10 * time.Minute,
+		timeout:  30 * time.Second,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if _, exists := periods[parameters.period]; !exists {
+		return nil, fmt.Errorf("unknown period %q", parameters.period)
+	}
+	for _, format := range parameters.formats {
+		if _, exists := formats[format]; !exists {
+			return nil, fmt.Errorf("unknown format %q", format)
+		}
+	}
+	if parameters.directory == "" && parameters.webhook == "" {
+		return nil, errors.New("no directory or webhook specified")
+	}
+	if parameters.directory != "" && len(parameters.formats) == 0 {
+		return nil, errors.New("no formats specified")
+	}
+	if parameters.interval == 0 {
+		return nil, errors.New("interval must be greater than 0")
+	}
+	if parameters.timeout == 0 {
+		return nil, errors.New("timeout must be greater than 0")
+	}
+
+	return &parameters, nil
+}
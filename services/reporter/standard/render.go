@@ -0,0 +1,332 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatHTML = "html"
+)
+
+// formats are the formats in which reports can be written.
+var formats = map[string]bool{
+	formatJSON: true,
+	formatCSV:  true,
+	formatHTML: true,
+}
+
+// report is a report of network and validator performance over a period.
+// The period covers the epochs that start within it; EndEpoch is the last
+// such epoch.
+type report struct {
+	Period     string             `json:"period"`
+	Start      string             `json:"start"`
+	End        string             `json:"end"`
+	StartEpoch string             `json:"start_epoch"`
+	EndEpoch   string             `json:"end_epoch"`
+	Network    *networkReport     `json:"network"`
+	Validators []*validatorReport `json:"validators,omitempty"`
+}
+
+// networkReport is the performance of the network over a period.
+type networkReport struct {
+	Epochs               string `json:"epochs"`
+	ActiveValidators     string `json:"active_validators"`
+	ParticipationRate    string `json:"participation_rate"`
+	MinParticipationRate string `json:"min_participation_rate"`
+	Slots                string `json:"slots"`
+	MissedBlocks         string `json:"missed_blocks"`
+	StartBalance         string `json:"start_balance,omitempty"`
+	EndBalance           string `json:"end_balance,omitempty"`
+}
+
+// validatorReport is the performance of a validator over a period.
+type validatorReport struct {
+	Index                     string `json:"index"`
+	Epochs                    string `json:"epochs"`
+	AttestationsIncluded      string `json:"attestations_included"`
+	AttestationsTargetCorrect string `json:"attestations_target_correct"`
+	AttestationsHeadCorrect   string `json:"attestations_head_correct"`
+	InclusionDelay            string `json:"inclusion_delay"`
+	ProposerDuties            string `json:"proposer_duties"`
+	ProposalsIncluded         string `json:"proposals_included"`
+	StartBalance              string `json:"start_balance,omitempty"`
+	EndBalance                string `json:"end_balance,omitempty"`
+	Deposits                  string `json:"deposits"`
+	Income                    string `json:"income,omitempty"`
+}
+
+// newValidatorReport creates the report for a validator from its performance.
+func newValidatorReport(performance *chaindb.ValidatorPerformance) *validatorReport {
+	res := &validatorReport{
+		Index:                     fmt.Sprintf("%d", performance.Index),
+		Epochs:                    fmt.Sprintf("%d", performance.Epochs),
+		AttestationsIncluded:      fmt.Sprintf("%d", performance.AttestationsIncluded),
+		AttestationsTargetCorrect: fmt.Sprintf("%d", performance.AttestationsTargetCorrect),
+		AttestationsHeadCorrect:   fmt.Sprintf("%d", performance.AttestationsHeadCorrect),
+		InclusionDelay:            fmt.Sprintf("%.2f", performance.InclusionDelay),
+		ProposerDuties:            fmt.Sprintf("%d", performance.ProposerDuties),
+		ProposalsIncluded:         fmt.Sprintf("%d", performance.ProposalsIncluded),
+		Deposits:                  fmt.Sprintf("%d", performance.Deposits),
+	}
+	if performance.StartBalance != nil {
+		res.StartBalance = fmt.Sprintf("%d", *performance.StartBalance)
+	}
+	if performance.EndBalance != nil {
+		res.EndBalance = fmt.Sprintf("%d", *performance.EndBalance)
+	}
+	if performance.Income != nil {
+		res.Income = fmt.Sprintf("%d", *performance.Income)
+	}
+
+	return res
+}
+
+var networkCSVHeader = []string{
+	"period",
+	"start",
+	"end",
+	"start_epoch",
+	"end_epoch",
+	"epochs",
+	"active_validators",
+	"participation_rate",
+	"min_participation_rate",
+	"slots",
+	"missed_blocks",
+	"start_balance",
+	"end_balance",
+}
+
+var validatorsCSVHeader = []string{
+	"index",
+	"epochs",
+	"attestations_included",
+	"attestations_target_correct",
+	"attestations_head_correct",
+	"inclusion_delay",
+	"proposer_duties",
+	"proposals_included",
+	"start_balance",
+	"end_balance",
+	"deposits",
+	"income",
+}
+
+// renderNetworkCSV renders the network section of a report as CSV.
+func renderNetworkCSV(report *report) ([]byte, error) {
+	network := report.Network
+	return renderCSV(networkCSVHeader, [][]string{{
+		report.Period,
+		report.Start,
+		report.End,
+		report.StartEpoch,
+		report.EndEpoch,
+		network.Epochs,
+		network.ActiveValidators,
+		network.ParticipationRate,
+		network.MinParticipationRate,
+		network.Slots,
+		network.MissedBlocks,
+		network.StartBalance,
+		network.EndBalance,
+	}})
+}
+
+// renderValidatorsCSV renders the validators section of a report as CSV.
+func renderValidatorsCSV(report *report) ([]byte, error) {
+	rows := make([][]string, 0, len(report.Validators))
+	for _, validator := range report.Validators {
+		rows = append(rows, []string{
+			validator.Index,
+			validator.Epochs,
+			validator.AttestationsIncluded,
+			validator.AttestationsTargetCorrect,
+			validator.AttestationsHeadCorrect,
+			validator.InclusionDelay,
+			validator.ProposerDuties,
+			validator.ProposalsIncluded,
+			validator.StartBalance,
+			validator.EndBalance,
+			validator.Deposits,
+			validator.Income,
+		})
+	}
+
+	return renderCSV(validatorsCSVHeader, rows)
+}
+
+func renderCSV(header []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>chaind {{.Period}} report {{.Start}}</title>
+</head>
+<body>
+<h1>chaind {{.Period}} report</h1>
+<p>{{.Start}} to {{.End}} (epochs {{.StartEpoch}} to {{.EndEpoch}})</p>
+<h2>Network</h2>
+<table>
+<tr><th>Epochs</th><td>{{.Network.Epochs}}</td></tr>
+<tr><th>Active validators</th><td>{{.Network.ActiveValidators}}</td></tr>
+<tr><th>Participation rate</th><td>{{.Network.ParticipationRate}}</td></tr>
+<tr><th>Minimum participation rate</th><td>{{.Network.MinParticipationRate}}</td></tr>
+<tr><th>Slots</th><td>{{.Network.Slots}}</td></tr>
+<tr><th>Missed blocks</th><td>{{.Network.MissedBlocks}}</td></tr>
+{{- if .Network.StartBalance}}
+<tr><th>Start balance (Gwei)</th><td>{{.Network.StartBalance}}</td></tr>
+{{- end}}
+{{- if .Network.EndBalance}}
+<tr><th>End balance (Gwei)</th><td>{{.Network.EndBalance}}</td></tr>
+{{- end}}
+</table>
+{{- if .Validators}}
+<h2>Validators</h2>
+<table>
+<tr><th>Index</th><th>Epochs</th><th>Attestations included</th><th>Target correct</th><th>Head correct</th><th>Inclusion delay</th><th>Proposer duties</th><th>Proposals included</th><th>Start balance</th><th>End balance</th><th>Deposits</th><th>Income</th></tr>
+{{- range .Validators}}
+<tr><td>{{.Index}}</td><td>{{.Epochs}}</td><td>{{.AttestationsIncluded}}</td><td>{{.AttestationsTargetCorrect}}</td><td>{{.AttestationsHeadCorrect}}</td><td>{{.InclusionDelay}}</td><td>{{.ProposerDuties}}</td><td>{{.ProposalsIncluded}}</td><td>{{.StartBalance}}</td><td>{{.EndBalance}}</td><td>{{.Deposits}}</td><td>{{.Income}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
+
+// renderHTML renders a report as HTML.
+func renderHTML(report *report) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeFiles writes a report to the directory in each of the configured formats.
+// Files are named for the period and its start date, for example
+// chaind-daily-2022-06-01.json.
+func (s *Service) writeFiles(report *report) error {
+	start, err := time.Parse(time.RFC3339, report.Start)
+	if err != nil {
+		return errors.Wrap(err, "invalid report start")
+	}
+	base := filepath.Join(s.directory, fmt.Sprintf("chaind-%s-%s", report.Period, start.Format("2006-01-02")))
+
+	for _, format := range s.formats {
+		switch format {
+		case formatJSON:
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return errors.Wrap(err, "failed to render JSON")
+			}
+			if err := writeFile(base+".json", data); err != nil {
+				return err
+			}
+		case formatCSV:
+			data, err := renderNetworkCSV(report)
+			if err != nil {
+				return errors.Wrap(err, "failed to render network CSV")
+			}
+			if err := writeFile(base+"-network.csv", data); err != nil {
+				return err
+			}
+			if len(report.Validators) > 0 {
+				data, err := renderValidatorsCSV(report)
+				if err != nil {
+					return errors.Wrap(err, "failed to render validators CSV")
+				}
+				if err := writeFile(base+"-validators.csv", data); err != nil {
+					return err
+				}
+			}
+		case formatHTML:
+			data, err := renderHTML(report)
+			if err != nil {
+				return errors.Wrap(err, "failed to render HTML")
+			}
+			if err := writeFile(base+".html", data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeFile writes data to a temporary file and renames it, so that readers
+// never see a partially-written report.
+func writeFile(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return errors.Wrapf(err, "failed to write %s", tmpPath)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return errors.Wrapf(err, "failed to rename %s", tmpPath)
+	}
+
+	return nil
+}
+
+// post POSTs a report as JSON to the webhook.
+func (s *Service) post(ctx context.Context, report *report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal report")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhook, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestPeriods(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2022, 6, 1, 15, 30, 0, 0, time.UTC)
+
+	daily := &Service{period: periodDaily}
+	start := daily.periodStart(now)
+	require.Equal(t, time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Date(2022, 6, 2, 0, 0, 0, 0, time.UTC), daily.nextPeriodStart(start))
+	require.Equal(t, time.Date(2022, 5, 31, 0, 0, 0, 0, time.UTC), daily.previousPeriodStart(start))
+
+	weekly := &Service{period: periodWeekly}
+	start = weekly.periodStart(now)
+	require.Equal(t, time.Date(2022, 5, 30, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Monday, start.Weekday())
+	require.Equal(t, time.Date(2022, 6, 6, 0, 0, 0, 0, time.UTC), weekly.nextPeriodStart(start))
+	require.Equal(t, time.Date(2022, 5, 23, 0, 0, 0, 0, time.UTC), weekly.previousPeriodStart(start))
+
+	// A Monday starts its own week.
+	require.Equal(t, start, weekly.periodStart(start))
+	// A Sunday is in the week that started the previous Monday.
+	require.Equal(t, start, weekly.periodStart(time.Date(2022, 6, 5, 23, 59, 0, 0, time.UTC)))
+}
+
+func testReport() *report {
+	startBalance := phase0.Gwei(32000000000)
+	endBalance := phase0.Gwei(32002000000)
+	income := int64(2000000)
+
+	return &report{
+		Period:     periodDaily,
+		Start:      "2022-06-01T00:00:00Z",
+		End:        "2022-06-02T00:00:00Z",
+		StartEpoch: "125550",
+		EndEpoch:   "125774",
+		Network: &networkReport{
+			Epochs:               "225",
+			ActiveValidators:     "400000",
+			ParticipationRate:    "0.9912",
+			MinParticipationRate: "0.9801",
+			Slots:                "7200",
+			MissedBlocks:         "43",
+		},
+		Validators: []*validatorReport{
+			newValidatorReport(&chaindb.ValidatorPerformance{
+				Index:                1,
+				Epochs:               225,
+				AttestationsIncluded: 224,
+				InclusionDelay:       1.0625,
+				StartBalance:         &startBalance,
+				EndBalance:           &endBalance,
+				Income:               &income,
+			}),
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	report := testReport()
+
+	data, err := renderNetworkCSV(report)
+	require.NoError(t, err)
+	require.Equal(t, "period,start,end,start_epoch,end_epoch,epochs,active_validators,participation_rate,min_participation_rate,slots,missed_blocks,start_balance,end_balance\n"+
+		"daily,2022-06-01T00:00:00Z,2022-06-02T00:00:00Z,125550,125774,225,400000,0.9912,0.9801,7200,43,,\n", string(data))
+
+	data, err = renderValidatorsCSV(report)
+	require.NoError(t, err)
+	require.Equal(t, "index,epochs,attestations_included,attestations_target_correct,attestations_head_correct,inclusion_delay,proposer_duties,proposals_included,start_balance,end_balance,deposits,income\n"+
+		"1,225,224,0,0,1.06,0,0,32000000000,32002000000,0,2000000\n", string(data))
+
+	data, err = renderHTML(report)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "<h1>chaind daily report</h1>")
+	require.Contains(t, string(data), "<td>32002000000</td>")
+	require.NotContains(t, string(data), "Start balance (Gwei)")
+}
+
+func TestWriteFiles(t *testing.T) {
+	directory := t.TempDir()
+	s := &Service{
+		directory: directory,
+		formats:   []string{formatJSON, formatCSV, formatHTML},
+	}
+	require.NoError(t, s.writeFiles(testReport()))
+
+	entries, err := os.ReadDir(directory)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	require.Equal(t, []string{
+		"chaind-daily-2022-06-01-network.csv",
+		"chaind-daily-2022-06-01-validators.csv",
+		"chaind-daily-2022-06-01.html",
+		"chaind-daily-2022-06-01.json",
+	}, names)
+
+	data, err := os.ReadFile(filepath.Join(directory, "chaind-daily-2022-06-01.json"))
+	require.NoError(t, err)
+	res := &report{}
+	require.NoError(t, json.Unmarshal(data, res))
+	require.Equal(t, testReport(), res)
+}
+
+func TestPost(t *testing.T) {
+	var received *report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = &report{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(received))
+	}))
+	defer server.Close()
+
+	s := &Service{
+		webhook: server.URL,
+		client:  server.Client(),
+	}
+	require.NoError(t, s.post(context.Background(), testReport()))
+	require.Equal(t, testReport(), received)
+
+	s.webhook = server.URL + "/missing"
+	server.Config.Handler = http.NotFoundHandler()
+	require.EqualError(t, s.post(context.Background(), testReport()), "webhook returned status 404")
+}
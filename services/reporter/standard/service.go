@@ -0,0 +1,325 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+)
+
+const (
+	periodDaily  = "daily"
+	periodWeekly = "weekly"
+)
+
+// periods are the periods that a report can cover.
+var periods = map[string]bool{
+	periodDaily:  true,
+	periodWeekly: true,
+}
+
+// Service is a reporter service, periodically rendering reports of network
+// and validator performance from the summary tables.
+type Service struct {
+	chainDB            chaindb.Service
+	aggregatesProvider chaindb.AggregatesProvider
+	blocksProvider     chaindb.BlocksProvider
+	chainTime          chaintime.Service
+	period             string
+	formats            []string
+	directory          string
+	webhook            string
+	validators         []phase0.ValidatorIndex
+	interval           time.Duration
+	client             *http.Client
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "reporter").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	aggregatesProvider, isProvider := parameters.chainDB.(chaindb.AggregatesProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide aggregates")
+	}
+	blocksProvider, isProvider := parameters.chainDB.(chaindb.BlocksProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide blocks")
+	}
+
+	s := &Service{
+		chainDB:            parameters.chainDB,
+		aggregatesProvider: aggregatesProvider,
+		blocksProvider:     blocksProvider,
+		chainTime:          parameters.chainTime,
+		period:             parameters.period,
+		formats:            parameters.formats,
+		directory:          parameters.directory,
+		webhook:            parameters.webhook,
+		validators:         parameters.validators,
+		interval:           parameters.interval,
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+	}
+
+	go s.poll(ctx)
+
+	return s, nil
+}
+
+// poll periodically checks for periods to report.
+func (s *Service) poll(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.update(ctx, time.Now()); err != nil {
+			log.Warn().Err(err).Msg("Failed to report")
+		}
+		select {
+		case <-ctx.Done():
+			log.Trace().Msg("Context done; stopping reporter")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// update reports each complete period since the last report.  On the first
+// run only the latest complete period is reported.  A period is not reported
+// until its final epoch has been summarized.
+func (s *Service) update(ctx context.Context, now time.Time) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	latestEnd := s.periodStart(now)
+	if md.LatestPeriodEnd.IsZero() {
+		md.LatestPeriodEnd = s.previousPeriodStart(latestEnd)
+	}
+
+	for start := md.LatestPeriodEnd; start.Before(latestEnd); start = md.LatestPeriodEnd {
+		end := s.nextPeriodStart(start)
+		if !end.After(s.chainTime.GenesisTime()) {
+			// Nothing to report before genesis.
+			md.LatestPeriodEnd = end
+			continue
+		}
+		report, err := s.buildReport(ctx, start, end)
+		if err != nil {
+			return errors.Wrap(err, "failed to build report")
+		}
+		if report == nil {
+			log.Debug().Time("start", start).Time("end", end).Msg("Period not yet summarized; postponing report")
+			return nil
+		}
+
+		if err := s.deliver(ctx, report); err != nil {
+			return err
+		}
+		log.Info().Str("period", s.period).Time("start", start).Msg("Reported period")
+
+		md.LatestPeriodEnd = end
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.setMetadata(ctx, md)
+		}); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+	}
+
+	return nil
+}
+
+// deliver writes a report to the directory and sends it to the webhook.
+func (s *Service) deliver(ctx context.Context, report *report) error {
+	if s.directory != "" {
+		if err := s.writeFiles(report); err != nil {
+			monitorReport("directory", "failed")
+			return errors.Wrap(err, "failed to write report")
+		}
+		monitorReport("directory", "succeeded")
+	}
+	if s.webhook != "" {
+		if err := s.post(ctx, report); err != nil {
+			monitorReport("webhook", "failed")
+			return errors.Wrap(err, "failed to send report")
+		}
+		monitorReport("webhook", "succeeded")
+	}
+
+	return nil
+}
+
+// periodStart returns the start of the period containing the given time.
+// Daily periods start at midnight UTC, weekly periods at midnight UTC on Monday.
+func (s *Service) periodStart(t time.Time) time.Time {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if s.period == periodWeekly {
+		start = start.AddDate(0, 0, -(int(start.Weekday())+6)%7)
+	}
+
+	return start
+}
+
+// nextPeriodStart returns the start of the period following that starting at the given time.
+func (s *Service) nextPeriodStart(start time.Time) time.Time {
+	if s.period == periodWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+
+	return start.AddDate(0, 0, 1)
+}
+
+// previousPeriodStart returns the start of the period preceding that starting at the given time.
+func (s *Service) previousPeriodStart(start time.Time) time.Time {
+	if s.period == periodWeekly {
+		return start.AddDate(0, 0, -7)
+	}
+
+	return start.AddDate(0, 0, -1)
+}
+
+// firstEpochFrom returns the first epoch that starts at or after the given time.
+func (s *Service) firstEpochFrom(t time.Time) phase0.Epoch {
+	if !t.After(s.chainTime.GenesisTime()) {
+		return 0
+	}
+	epoch := s.chainTime.TimestampToEpoch(t)
+	if s.chainTime.StartOfEpoch(epoch).Before(t) {
+		epoch++
+	}
+
+	return epoch
+}
+
+// buildReport builds the report for the period between start and end, covering
+// the epochs that start within the period.  It returns nil if the period has
+// not yet been summarized.
+func (s *Service) buildReport(ctx context.Context, start time.Time, end time.Time) (*report, error) {
+	startEpoch := s.firstEpochFrom(start)
+	endEpoch := s.firstEpochFrom(end)
+	if endEpoch <= startEpoch {
+		return nil, fmt.Errorf("period starting %s precedes genesis", start.Format(time.RFC3339))
+	}
+
+	participations, err := s.aggregatesProvider.EpochParticipationForEpochRange(ctx, startEpoch, endEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain participation")
+	}
+	if len(participations) == 0 || participations[len(participations)-1].Epoch != endEpoch-1 {
+		return nil, nil
+	}
+
+	network := &networkReport{
+		Epochs: fmt.Sprintf("%d", endEpoch-startEpoch),
+	}
+
+	totalRate := 0.0
+	minRate := participations[0].Rate
+	for _, participation := range participations {
+		totalRate += participation.Rate
+		if participation.Rate < minRate {
+			minRate = participation.Rate
+		}
+	}
+	network.ParticipationRate = fmt.Sprintf("%.4f", totalRate/float64(len(participations)))
+	network.MinParticipationRate = fmt.Sprintf("%.4f", minRate)
+
+	activeValidators, err := s.aggregatesProvider.ActiveValidatorCount(ctx, endEpoch-1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain active validator count")
+	}
+	network.ActiveValidators = fmt.Sprintf("%d", activeValidators)
+
+	startBalances, err := s.aggregatesProvider.TotalValidatorBalancesForEpochRange(ctx, startEpoch, startEpoch+1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain start balances")
+	}
+	if len(startBalances) > 0 {
+		network.StartBalance = fmt.Sprintf("%d", startBalances[0].Balance)
+	}
+	endBalances, err := s.aggregatesProvider.TotalValidatorBalancesForEpochRange(ctx, endEpoch-1, endEpoch)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain end balances")
+	}
+	if len(endBalances) > 0 {
+		network.EndBalance = fmt.Sprintf("%d", endBalances[0].Balance)
+	}
+
+	presence, err := s.blocksProvider.CanonicalBlockPresenceForSlotRange(ctx,
+		s.chainTime.FirstSlotOfEpoch(startEpoch),
+		s.chainTime.FirstSlotOfEpoch(endEpoch),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain block presence")
+	}
+	missed := 0
+	for _, present := range presence {
+		if !present {
+			missed++
+		}
+	}
+	network.Slots = fmt.Sprintf("%d", len(presence))
+	network.MissedBlocks = fmt.Sprintf("%d", missed)
+
+	report := &report{
+		Period:     s.period,
+		Start:      start.Format(time.RFC3339),
+		End:        end.Format(time.RFC3339),
+		StartEpoch: fmt.Sprintf("%d", startEpoch),
+		EndEpoch:   fmt.Sprintf("%d", endEpoch-1),
+		Network:    network,
+	}
+
+	if len(s.validators) > 0 {
+		performances, err := s.aggregatesProvider.ValidatorPerformanceForEpochRange(ctx, s.validators, startEpoch, endEpoch)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validator performance")
+		}
+		report.Validators = make([]*validatorReport, 0, len(performances))
+		for _, performance := range performances {
+			report.Validators = append(report.Validators, newValidatorReport(performance))
+		}
+	}
+
+	return report, nil
+}
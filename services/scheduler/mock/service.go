@@ -0,0 +1,39 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+type service struct{}
+
+// New creates a new mock scheduler service, which runs jobs immediately.
+func New() scheduler.Service {
+	return &service{}
+}
+
+// Submit runs the job in the background.
+func (*service) Submit(ctx context.Context, _ string, _ scheduler.Priority, job scheduler.JobFunc) error {
+	go job(ctx)
+	return nil
+}
+
+// Run runs the job.
+func (*service) Run(ctx context.Context, _ string, _ scheduler.Priority, job scheduler.JobFunc) error {
+	job(ctx)
+	return nil
+}
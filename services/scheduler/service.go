@@ -0,0 +1,65 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"errors"
+)
+
+// Priority is the priority of a job.  Jobs with a higher priority are started
+// before those with a lower priority.
+type Priority int
+
+const (
+	// PriorityRepair is the priority of jobs that repair or reindex existing data.
+	PriorityRepair Priority = iota
+	// PriorityCatchup is the priority of jobs that catch up with the chain.
+	PriorityCatchup
+	// PriorityHead is the priority of jobs that follow the head of the chain.
+	PriorityHead
+)
+
+// String returns the name of the priority.
+func (p Priority) String() string {
+	switch p {
+	case PriorityRepair:
+		return "repair"
+	case PriorityCatchup:
+		return "catchup"
+	case PriorityHead:
+		return "head"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrQueueFull is returned when a job cannot be submitted because too many
+// jobs are already waiting to run.
+var ErrQueueFull = errors.New("job queue full")
+
+// JobFunc is a job to be run by the scheduler.
+type JobFunc func(ctx context.Context)
+
+// Service is a scheduler service, running jobs submitted by other services
+// according to their priority and the available concurrency.
+type Service interface {
+	// Submit submits a job to be run in the background.  The job is not
+	// started if the context is done before it is due to run.
+	Submit(ctx context.Context, name string, priority Priority, job JobFunc) error
+
+	// Run submits a job and waits for it to finish, or for the context to
+	// be done.
+	Run(ctx context.Context, name string, priority Priority, job JobFunc) error
+}
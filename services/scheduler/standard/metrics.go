@@ -0,0 +1,126 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+var metricsNamespace = "chaind_scheduler"
+
+var jobsQueued *prometheus.GaugeVec
+var jobsRunning *prometheus.GaugeVec
+var jobDuration *prometheus.HistogramVec
+var jobsRefused *prometheus.CounterVec
+var maintenanceWindowActive prometheus.Gauge
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if jobsQueued != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	jobsQueued = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_queued",
+		Help:      "Number of jobs waiting to run",
+	}, []string{"priority"})
+	if err := prometheus.Register(jobsQueued); err != nil {
+		return errors.Wrap(err, "failed to register jobs_queued")
+	}
+
+	jobsRunning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_running",
+		Help:      "Number of jobs running",
+	}, []string{"priority"})
+	if err := prometheus.Register(jobsRunning); err != nil {
+		return errors.Wrap(err, "failed to register jobs_running")
+	}
+
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "job_duration_seconds",
+		Help:      "Time taken to run jobs",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{"priority"})
+	if err := prometheus.Register(jobDuration); err != nil {
+		return errors.Wrap(err, "failed to register job_duration_seconds")
+	}
+
+	jobsRefused = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "jobs_refused_total",
+		Help:      "Number of jobs refused because the queue was full",
+	}, []string{"priority"})
+	if err := prometheus.Register(jobsRefused); err != nil {
+		return errors.Wrap(err, "failed to register jobs_refused_total")
+	}
+
+	maintenanceWindowActive = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "maintenance_window",
+		Help:      "1 if a maintenance window is active, otherwise 0",
+	})
+	if err := prometheus.Register(maintenanceWindowActive); err != nil {
+		return errors.Wrap(err, "failed to register maintenance_window")
+	}
+
+	return nil
+}
+
+func monitorJobs(priority scheduler.Priority, queued int, running int) {
+	if jobsQueued != nil {
+		jobsQueued.WithLabelValues(priority.String()).Set(float64(queued))
+		jobsRunning.WithLabelValues(priority.String()).Set(float64(running))
+	}
+}
+
+func monitorJobFinished(priority scheduler.Priority, duration time.Duration) {
+	if jobDuration != nil {
+		jobDuration.WithLabelValues(priority.String()).Observe(duration.Seconds())
+	}
+}
+
+func monitorJobRefused(priority scheduler.Priority) {
+	if jobsRefused != nil {
+		jobsRefused.WithLabelValues(priority.String()).Inc()
+	}
+}
+
+func monitorMaintenanceWindow(active bool) {
+	if maintenanceWindowActive != nil {
+		if active {
+			maintenanceWindowActive.Set(1)
+		} else {
+			maintenanceWindowActive.Set(0)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+// MaintenanceWindow is a period of each day during which catchup and repair
+// jobs are throttled, to reduce load on the database.
+type MaintenanceWindow struct {
+	// Start is the time of day, in UTC, at which the window starts, as HH:MM.
+	Start string
+	// End is the time of day, in UTC, at which the window ends, as HH:MM.
+	// If it is before the start time the window ends the following day.
+	End string
+	// Days are the days, as three-letter lower-case names, on which the
+	// window starts.  If empty the window starts every day.
+	Days []string
+	// Concurrency is the maximum number of catchup and repair jobs that run
+	// at the same time during the window.  0 pauses them.
+	Concurrency int
+}
+
+type parameters struct {
+	logLevel            zerolog.Level
+	logLevelSampler     zerolog.Sampler
+	monitor             metrics.Service
+	concurrency         int
+	priorityConcurrency map[scheduler.Priority]int
+	maxQueued           int
+	maintenanceWindows  []*maintenanceWindow
+	windows             []*MaintenanceWindow
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithConcurrency sets the maximum number of jobs that run at the same time.
+func WithConcurrency(concurrency int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.concurrency = concurrency
+	})
+}
+
+// WithPriorityConcurrency sets the maximum number of jobs of a given priority
+// that run at the same time.  Priorities without a value are limited only by
+// the overall concurrency.
+func WithPriorityConcurrency(concurrency map[scheduler.Priority]int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.priorityConcurrency = concurrency
+	})
+}
+
+// WithMaxQueued sets the maximum number of jobs waiting to run, above which
+// submissions are refused.  0 does not limit the number of jobs.
+func WithMaxQueued(maxQueued int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.maxQueued = maxQueued
+	})
+}
+
+// WithMaintenanceWindows sets the windows during which catchup and repair
+// jobs are throttled.
+func WithMaintenanceWindows(windows []*MaintenanceWindow) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.windows = windows
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:            zerolog.GlobalLevel(),
+		concurrency:         4,
+		priorityConcurrency: make(map[scheduler.Priority]int),
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.concurrency < 1 {
+		return nil, errors.New("concurrency must be at least 1")
+	}
+	for priority, concurrency := range parameters.priorityConcurrency {
+		if concurrency < 1 {
+			return nil, fmt.Errorf("concurrency for %s priority must be at least 1", priority)
+		}
+	}
+	if parameters.maxQueued < 0 {
+		return nil, errors.New("max queued must not be negative")
+	}
+	for i, window := range parameters.windows {
+		maintenanceWindow, err := parseMaintenanceWindow(window)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window %d: %v", i, err)
+		}
+		parameters.maintenanceWindows = append(parameters.maintenanceWindows, maintenanceWindow)
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,254 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// priorities are the priorities of jobs, highest first.
+var priorities = []scheduler.Priority{
+	scheduler.PriorityHead,
+	scheduler.PriorityCatchup,
+	scheduler.PriorityRepair,
+}
+
+// Service is a scheduler service.  Jobs are queued by priority, and started in
+// order of priority, then submission, as long as neither the overall concurrency
+// nor that of their priority is exceeded.  During maintenance windows catchup
+// and repair jobs are further limited by the window's concurrency.
+type Service struct {
+	concurrency         int
+	priorityConcurrency map[scheduler.Priority]int
+	maxQueued           int
+	windows             []*maintenanceWindow
+
+	// mu protects the fields below.
+	mu      sync.Mutex
+	queues  map[scheduler.Priority][]*job
+	queued  int
+	running map[scheduler.Priority]int
+	total   int
+	// window is the active maintenance window, or nil if there is none.
+	window *maintenanceWindow
+}
+
+// job is a job waiting to run.
+type job struct {
+	ctx      context.Context
+	name     string
+	priority scheduler.Priority
+	fn       scheduler.JobFunc
+	started  bool
+	done     chan struct{}
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/scheduler/standard")
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "scheduler").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	s := &Service{
+		concurrency:         parameters.concurrency,
+		priorityConcurrency: parameters.priorityConcurrency,
+		maxQueued:           parameters.maxQueued,
+		windows:             parameters.maintenanceWindows,
+		queues:              make(map[scheduler.Priority][]*job),
+		running:             make(map[scheduler.Priority]int),
+	}
+
+	if len(s.windows) > 0 {
+		s.mu.Lock()
+		s.updateWindow(time.Now())
+		s.mu.Unlock()
+		go s.checkWindows(ctx)
+	}
+
+	return s, nil
+}
+
+// Submit submits a job to be run in the background.  The job is not
+// started if the context is done before it is due to run.
+func (s *Service) Submit(ctx context.Context, name string, priority scheduler.Priority, fn scheduler.JobFunc) error {
+	_, err := s.submit(ctx, name, priority, fn)
+	return err
+}
+
+// Run submits a job and waits for it to finish, or for the context to
+// be done.
+func (s *Service) Run(ctx context.Context, name string, priority scheduler.Priority, fn scheduler.JobFunc) error {
+	j, err := s.submit(ctx, name, priority, fn)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-j.done:
+		if !j.started {
+			// Context was done before the job was due to run.
+			return ctx.Err()
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// submit queues a job, and starts it if there is capacity.
+func (s *Service) submit(ctx context.Context, name string, priority scheduler.Priority, fn scheduler.JobFunc) (*job, error) {
+	j := &job{
+		ctx:      ctx,
+		name:     name,
+		priority: priority,
+		fn:       fn,
+		done:     make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxQueued > 0 && s.queued >= s.maxQueued {
+		monitorJobRefused(priority)
+		return nil, scheduler.ErrQueueFull
+	}
+	s.queues[priority] = append(s.queues[priority], j)
+	s.queued++
+	log.Trace().Str("job", name).Str("priority", priority.String()).Msg("Job queued")
+	s.dispatch()
+
+	return j, nil
+}
+
+// dispatch starts as many queued jobs as capacity allows.
+// This requires the mutex to be held.
+func (s *Service) dispatch() {
+	for _, priority := range priorities {
+		for len(s.queues[priority]) > 0 && s.total < s.concurrency {
+			if limit, exists := s.limit(priority); exists && s.running[priority] >= limit {
+				break
+			}
+			j := s.queues[priority][0]
+			s.queues[priority] = s.queues[priority][1:]
+			s.queued--
+			if j.ctx.Err() != nil {
+				log.Trace().Str("job", j.name).Msg("Context done; not running job")
+				close(j.done)
+				continue
+			}
+			j.started = true
+			s.running[priority]++
+			s.total++
+			go s.run(j)
+		}
+		monitorJobs(priority, len(s.queues[priority]), s.running[priority])
+	}
+}
+
+// run runs a job, and then starts any jobs that were waiting for it.
+func (s *Service) run(j *job) {
+	log := log.With().Str("job", j.name).Str("priority", j.priority.String()).Logger()
+	log.Trace().Msg("Job started")
+	started := time.Now()
+	ctx, span := tracer.Start(j.ctx, j.name, trace.WithAttributes(attribute.String("priority", j.priority.String())))
+	j.fn(ctx)
+	span.End()
+	monitorJobFinished(j.priority, time.Since(started))
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Job finished")
+	close(j.done)
+
+	s.mu.Lock()
+	s.running[j.priority]--
+	s.total--
+	s.dispatch()
+	s.mu.Unlock()
+}
+
+// limit returns the maximum number of running jobs of the given priority, if any.
+// This requires the mutex to be held.
+func (s *Service) limit(priority scheduler.Priority) (int, bool) {
+	limit, exists := s.priorityConcurrency[priority]
+	if s.window != nil && priority != scheduler.PriorityHead {
+		if !exists || s.window.concurrency < limit {
+			limit = s.window.concurrency
+		}
+		exists = true
+	}
+
+	return limit, exists
+}
+
+// checkWindows periodically checks if a maintenance window has started or ended.
+func (s *Service) checkWindows(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			s.updateWindow(now)
+			s.dispatch()
+			s.mu.Unlock()
+		}
+	}
+}
+
+// updateWindow sets the active maintenance window for the given time.  If more
+// than one window is active the most restrictive is used.
+// This requires the mutex to be held.
+func (s *Service) updateWindow(now time.Time) {
+	var active *maintenanceWindow
+	for _, window := range s.windows {
+		if window.contains(now) && (active == nil || window.concurrency < active.concurrency) {
+			active = window
+		}
+	}
+
+	switch {
+	case active != nil && s.window == nil:
+		log.Info().Int("concurrency", active.concurrency).Msg("Maintenance window started; throttling catchup and repair jobs")
+	case active == nil && s.window != nil:
+		log.Info().Msg("Maintenance window ended")
+	}
+	s.window = active
+	monitorMaintenanceWindow(active != nil)
+}
@@ -0,0 +1,168 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/scheduler/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ConcurrencyZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithConcurrency(0),
+			},
+			err: "problem with parameters: concurrency must be at least 1",
+		},
+		{
+			name: "PriorityConcurrencyZero",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithPriorityConcurrency(map[scheduler.Priority]int{scheduler.PriorityRepair: 0}),
+			},
+			err: "problem with parameters: concurrency for repair priority must be at least 1",
+		},
+		{
+			name: "MaxQueuedNegative",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithMaxQueued(-1),
+			},
+			err: "problem with parameters: max queued must not be negative",
+		},
+		{
+			name: "MaintenanceWindowInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithMaintenanceWindows([]*standard.MaintenanceWindow{{Start: "09:00", End: "25:00"}}),
+			},
+			err: `problem with parameters: invalid maintenance window 0: invalid end: "25:00" is not of the form HH:MM`,
+		},
+		{
+			name: "MaintenanceWindowBadDay",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithMaintenanceWindows([]*standard.MaintenanceWindow{{Start: "09:00", End: "17:00", Days: []string{"someday"}}}),
+			},
+			err: `problem with parameters: invalid maintenance window 0: unknown day "someday"`,
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithConcurrency(2),
+				standard.WithPriorityConcurrency(map[scheduler.Priority]int{scheduler.PriorityRepair: 1}),
+				standard.WithMaxQueued(10),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPriorities(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithConcurrency(1),
+	)
+	require.NoError(t, err)
+
+	// Block the scheduler while jobs are submitted.
+	release := make(chan struct{})
+	require.NoError(t, s.Submit(ctx, "blocker", scheduler.PriorityCatchup, func(context.Context) { <-release }))
+
+	var mu sync.Mutex
+	order := make([]string, 0)
+	record := func(name string) scheduler.JobFunc {
+		return func(context.Context) {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}
+	}
+	require.NoError(t, s.Submit(ctx, "repair", scheduler.PriorityRepair, record("repair")))
+	require.NoError(t, s.Submit(ctx, "catchup1", scheduler.PriorityCatchup, record("catchup1")))
+	require.NoError(t, s.Submit(ctx, "head", scheduler.PriorityHead, record("head")))
+	require.NoError(t, s.Submit(ctx, "catchup2", scheduler.PriorityCatchup, record("catchup2")))
+	close(release)
+
+	// Run waits for the job, which is started after those already queued.
+	require.NoError(t, s.Run(ctx, "last", scheduler.PriorityRepair, record("last")))
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, []string{"head", "catchup1", "catchup2", "repair", "last"}, order)
+}
+
+func TestMaxQueued(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithConcurrency(1),
+		standard.WithMaxQueued(1),
+	)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+	require.NoError(t, s.Submit(ctx, "running", scheduler.PriorityHead, func(context.Context) { <-release }))
+	require.NoError(t, s.Submit(ctx, "queued", scheduler.PriorityHead, func(context.Context) {}))
+	require.ErrorIs(t, s.Submit(ctx, "refused", scheduler.PriorityHead, func(context.Context) {}), scheduler.ErrQueueFull)
+}
+
+func TestCancelledJob(t *testing.T) {
+	ctx := context.Background()
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithConcurrency(1),
+	)
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	require.NoError(t, s.Submit(ctx, "running", scheduler.PriorityHead, func(context.Context) { <-release }))
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	ran := false
+	require.NoError(t, s.Submit(jobCtx, "cancelled", scheduler.PriorityHead, func(context.Context) { ran = true }))
+	cancel()
+	close(release)
+
+	require.NoError(t, s.Run(ctx, "after", scheduler.PriorityRepair, func(context.Context) {}))
+	require.False(t, ran)
+}
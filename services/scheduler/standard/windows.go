@@ -0,0 +1,113 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maintenanceWindow is a parsed maintenance window.
+type maintenanceWindow struct {
+	// start and end are offsets from midnight UTC.
+	start       time.Duration
+	end         time.Duration
+	days        map[time.Weekday]bool
+	concurrency int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// parseMaintenanceWindow parses and checks a maintenance window.
+func parseMaintenanceWindow(window *MaintenanceWindow) (*maintenanceWindow, error) {
+	if window == nil {
+		return nil, errors.New("no window specified")
+	}
+	start, err := parseTimeOfDay(window.Start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start: %v", err)
+	}
+	end, err := parseTimeOfDay(window.End)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end: %v", err)
+	}
+	if start == end {
+		return nil, errors.New("start and end must differ")
+	}
+	if window.Concurrency < 0 {
+		return nil, errors.New("concurrency must not be negative")
+	}
+
+	days := make(map[time.Weekday]bool)
+	for _, day := range window.Days {
+		weekday, exists := weekdays[strings.ToLower(day)]
+		if !exists {
+			return nil, fmt.Errorf("unknown day %q", day)
+		}
+		days[weekday] = true
+	}
+
+	return &maintenanceWindow{
+		start:       start,
+		end:         end,
+		days:        days,
+		concurrency: window.Concurrency,
+	}, nil
+}
+
+// parseTimeOfDay parses a time of day of the form HH:MM in to an offset from midnight.
+func parseTimeOfDay(input string) (time.Duration, error) {
+	t, err := time.Parse("15:04", input)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not of the form HH:MM", input)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains returns true if the window contains the given time.
+func (w *maintenanceWindow) contains(t time.Time) bool {
+	t = t.UTC()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := t.Sub(midnight)
+
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end && w.startsOn(t.Weekday())
+	}
+
+	// Window spans midnight.
+	if offset >= w.start {
+		return w.startsOn(t.Weekday())
+	}
+	if offset < w.end {
+		return w.startsOn((t.Weekday() + 6) % 7)
+	}
+
+	return false
+}
+
+// startsOn returns true if the window starts on the given day.
+func (w *maintenanceWindow) startsOn(day time.Weekday) bool {
+	return len(w.days) == 0 || w.days[day]
+}
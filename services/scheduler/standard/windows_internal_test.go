@@ -0,0 +1,140 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/scheduler"
+)
+
+func TestMaintenanceWindowContains(t *testing.T) {
+	// 2022-06-01 is a Wednesday.
+	at := func(day int, hour int, minute int) time.Time {
+		return time.Date(2022, 6, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		window   *MaintenanceWindow
+		time     time.Time
+		expected bool
+	}{
+		{
+			name:     "Inside",
+			window:   &MaintenanceWindow{Start: "09:00", End: "17:00"},
+			time:     at(1, 12, 0),
+			expected: true,
+		},
+		{
+			name:     "AtStart",
+			window:   &MaintenanceWindow{Start: "09:00", End: "17:00"},
+			time:     at(1, 9, 0),
+			expected: true,
+		},
+		{
+			name:   "AtEnd",
+			window: &MaintenanceWindow{Start: "09:00", End: "17:00"},
+			time:   at(1, 17, 0),
+		},
+		{
+			name:   "Before",
+			window: &MaintenanceWindow{Start: "09:00", End: "17:00"},
+			time:   at(1, 8, 59),
+		},
+		{
+			name:     "Day",
+			window:   &MaintenanceWindow{Start: "09:00", End: "17:00", Days: []string{"mon", "wed"}},
+			time:     at(1, 12, 0),
+			expected: true,
+		},
+		{
+			name:   "OtherDay",
+			window: &MaintenanceWindow{Start: "09:00", End: "17:00", Days: []string{"mon", "tue"}},
+			time:   at(1, 12, 0),
+		},
+		{
+			name:     "OvernightEvening",
+			window:   &MaintenanceWindow{Start: "22:00", End: "02:00", Days: []string{"wed"}},
+			time:     at(1, 23, 0),
+			expected: true,
+		},
+		{
+			name:     "OvernightMorning",
+			window:   &MaintenanceWindow{Start: "22:00", End: "02:00", Days: []string{"wed"}},
+			time:     at(2, 1, 0),
+			expected: true,
+		},
+		{
+			name:   "OvernightMorningOtherDay",
+			window: &MaintenanceWindow{Start: "22:00", End: "02:00", Days: []string{"wed"}},
+			time:   at(1, 1, 0),
+		},
+		{
+			name:     "OtherTimezone",
+			window:   &MaintenanceWindow{Start: "09:00", End: "17:00"},
+			time:     at(1, 12, 0).In(time.FixedZone("UTC+10", 10*60*60)),
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			window, err := parseMaintenanceWindow(test.window)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, window.contains(test.time))
+		})
+	}
+}
+
+func TestMaintenanceWindowThrottle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s, err := New(ctx,
+		WithLogLevel(zerolog.Disabled),
+		WithMaintenanceWindows([]*MaintenanceWindow{{Start: "09:00", End: "17:00"}}),
+	)
+	require.NoError(t, err)
+
+	s.mu.Lock()
+	s.updateWindow(time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC))
+	s.mu.Unlock()
+
+	// Head jobs are unaffected by the window.
+	require.NoError(t, s.Run(ctx, "head", scheduler.PriorityHead, func(context.Context) {}))
+
+	// Catchup jobs wait until the window ends.
+	ran := make(chan struct{})
+	require.NoError(t, s.Submit(ctx, "catchup", scheduler.PriorityCatchup, func(context.Context) { close(ran) }))
+	select {
+	case <-ran:
+		require.Fail(t, "job ran during maintenance window")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.mu.Lock()
+	s.updateWindow(time.Date(2022, 6, 1, 18, 0, 0, 0, time.UTC))
+	s.dispatch()
+	s.mu.Unlock()
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		require.Fail(t, "job did not run after maintenance window")
+	}
+}
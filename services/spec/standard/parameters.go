@@ -22,9 +22,10 @@ import (
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	eth2Client      eth2client.Service
+	chainDB         chaindb.Service
 }
 
 // Parameter is the interface for service parameters.
@@ -45,6 +46,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithETH2Client sets the Ethereum 2 client for this module.
 func WithETH2Client(eth2Client eth2client.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -21,6 +21,7 @@ import (
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // Service is a spec service.
@@ -47,6 +48,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if parameters.logLevel != log.GetLevel() {
 		log = log.Level(parameters.logLevel)
 	}
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	chainSpecSetter, isChainSpecSetter := parameters.chainDB.(chaindb.ChainSpecSetter)
 	if !isChainSpecSetter {
@@ -79,29 +83,20 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 }
 
 func (s *Service) updateAfterRestart(ctx context.Context) {
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to begin transaction")
-		return
-	}
-
-	if err := s.updateChainSpec(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Failed to update spec")
-	}
-
-	if err := s.updateGenesis(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Failed to update genesis")
-	}
-
-	if err := s.updateForkSchedule(ctx); err != nil {
-		log.Fatal().Err(err).Msg("Failed to update fork schedule")
-	}
-
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		log.Fatal().Err(err).Msg("Failed to commit transaction")
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		if err := s.updateChainSpec(ctx); err != nil {
+			return errors.Wrap(err, "failed to update spec")
+		}
+		if err := s.updateGenesis(ctx); err != nil {
+			return errors.Wrap(err, "failed to update genesis")
+		}
+		if err := s.updateForkSchedule(ctx); err != nil {
+			return errors.Wrap(err, "failed to update fork schedule")
+		}
+		return nil
+	}); err != nil {
+		log.Fatal().Err(err).Msg("Failed to update chain specification")
 	}
-
 }
 
 func (s *Service) updateChainSpec(ctx context.Context) error {
@@ -0,0 +1,138 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// OnFinalityUpdated is called when finality has been updated in the database.
+func (s *Service) OnFinalityUpdated(
+	ctx context.Context,
+	finalizedEpoch phase0.Epoch,
+) {
+	log := log.With().Uint64("finalized_epoch", uint64(finalizedEpoch)).Logger()
+	log.Trace().Msg("Handler called")
+
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	// The state at the start of the finalized epoch is the latest finalized state.
+	if err := s.catchup(ctx, s.chainTime.FirstSlotOfEpoch(finalizedEpoch)); err != nil {
+		monitorFailure()
+		log.Warn().Err(err).Msg("Failed to record state roots; will retry on next finality update")
+		return
+	}
+
+	log.Trace().Msg("Finished handling finality checkpoint")
+}
+
+// catchup records the state roots of the slots up to and including the given slot.
+// State roots are recorded an epoch at a time, each in its own transaction, so
+// that progress is kept if a later epoch fails.
+func (s *Service) catchup(ctx context.Context, targetSlot phase0.Slot) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	for md.NextSlot <= targetSlot {
+		lastSlot := s.chainTime.FirstSlotOfEpoch(s.chainTime.SlotToEpoch(md.NextSlot)+1) - 1
+		if lastSlot > targetSlot {
+			lastSlot = targetSlot
+		}
+
+		stateRoots := make([]*chaindb.StateRoot, 0, int(lastSlot-md.NextSlot)+1)
+		for slot := md.NextSlot; slot <= lastSlot; slot++ {
+			stateRoot, err := s.stateRoot(ctx, slot)
+			if err != nil {
+				return err
+			}
+			stateRoots = append(stateRoots, stateRoot)
+		}
+
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			if err := s.stateRootsSetter.SetStateRoots(ctx, stateRoots); err != nil {
+				return errors.Wrap(err, "failed to set state roots")
+			}
+			md.NextSlot = lastSlot + 1
+			return s.setMetadata(ctx, md)
+		}); err != nil {
+			return err
+		}
+		monitorLatestSlot(lastSlot)
+		log.Trace().Uint64("slot", uint64(lastSlot)).Msg("Recorded state roots")
+	}
+
+	return nil
+}
+
+// stateRoot obtains the state root for a slot.
+func (s *Service) stateRoot(ctx context.Context, slot phase0.Slot) (*chaindb.StateRoot, error) {
+	root, err := s.stateRootProvider.BeaconStateRoot(ctx, fmt.Sprintf("%d", slot))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to obtain state root for slot %d", slot)
+	}
+	if root == nil {
+		return nil, errors.Errorf("no state root returned for slot %d", slot)
+	}
+
+	archiveLocation, err := s.archiveLocationFor(slot, *root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chaindb.StateRoot{
+		Slot:            slot,
+		Root:            *root,
+		ArchiveLocation: archiveLocation,
+	}, nil
+}
+
+// archiveLocationFor returns the location of the archive holding the state
+// for the slot, or an empty string if the state is not archived.
+func (s *Service) archiveLocationFor(slot phase0.Slot, root phase0.Root) (string, error) {
+	if s.archiveLocation == nil || uint64(slot)%s.archiveInterval != 0 {
+		return "", nil
+	}
+
+	var location strings.Builder
+	if err := s.archiveLocation.Execute(&location, struct {
+		Slot      uint64
+		Epoch     uint64
+		Era       uint64
+		StateRoot string
+	}{
+		Slot:      uint64(slot),
+		Epoch:     uint64(s.chainTime.SlotToEpoch(slot)),
+		Era:       uint64(slot) / s.archiveInterval,
+		StateRoot: fmt.Sprintf("%#x", root),
+	}); err != nil {
+		return "", errors.Wrap(err, "failed to generate archive location")
+	}
+
+	return location.String(), nil
+}
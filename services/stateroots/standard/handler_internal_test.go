@@ -0,0 +1,123 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"text/template"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"golang.org/x/sync/semaphore"
+)
+
+// stateRootClient provides a state root derived from the slot, failing for
+// a given slot.
+type stateRootClient struct {
+	failSlot string
+}
+
+func (c *stateRootClient) BeaconStateRoot(_ context.Context, stateID string) (*phase0.Root, error) {
+	if stateID == c.failSlot {
+		return nil, errors.New("state not available")
+	}
+	root := phase0.Root{}
+	copy(root[:], stateID)
+	return &root, nil
+}
+
+// recordingDB records the state roots that are written.
+type recordingDB struct {
+	chaindb.Service
+	stateRoots []*chaindb.StateRoot
+}
+
+func (d *recordingDB) SetStateRoots(_ context.Context, stateRoots []*chaindb.StateRoot) error {
+	d.stateRoots = append(d.stateRoots, stateRoots...)
+	return nil
+}
+
+func newTestService(t *testing.T, client *stateRootClient, chainDB *recordingDB, archiveLocation string) *Service {
+	t.Helper()
+	chainTime, err := standardchaintime.New(context.Background(),
+		standardchaintime.WithGenesisTimeProvider(chainDB.Service.(eth2client.GenesisTimeProvider)),
+		standardchaintime.WithSpecProvider(chainDB.Service.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(chainDB.Service.(eth2client.ForkScheduleProvider)),
+	)
+	require.NoError(t, err)
+
+	s := &Service{
+		stateRootProvider: client,
+		chainDB:           chainDB,
+		stateRootsSetter:  chainDB,
+		chainTime:         chainTime,
+		archiveInterval:   64,
+		activitySem:       semaphore.NewWeighted(1),
+	}
+	if archiveLocation != "" {
+		s.archiveLocation = template.Must(template.New("archive-location").Parse(archiveLocation))
+	}
+	return s
+}
+
+func TestCatchup(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := &recordingDB{Service: mockchaindb.New()}
+	s := newTestService(t, &stateRootClient{}, chainDB, "/archive/{{.Era}}-{{.Slot}}.era")
+
+	require.NoError(t, s.catchup(ctx, 128))
+	require.Len(t, chainDB.stateRoots, 129)
+	for i, stateRoot := range chainDB.stateRoots {
+		require.Equal(t, phase0.Slot(i), stateRoot.Slot)
+		switch i {
+		case 0, 64, 128:
+			require.Equal(t, fmt.Sprintf("/archive/%d-%d.era", i/64, i), stateRoot.ArchiveLocation)
+		default:
+			require.Empty(t, stateRoot.ArchiveLocation)
+		}
+	}
+}
+
+func TestCatchupFailure(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := &recordingDB{Service: mockchaindb.New()}
+	s := newTestService(t, &stateRootClient{failSlot: "40"}, chainDB, "")
+
+	require.EqualError(t, s.catchup(ctx, 128), "failed to obtain state root for slot 40: state not available")
+	// The epoch before the failure is recorded.
+	require.Len(t, chainDB.stateRoots, 32)
+	require.Equal(t, phase0.Slot(31), chainDB.stateRoots[31].Slot)
+}
+
+func TestArchiveLocationFor(t *testing.T) {
+	chainDB := &recordingDB{Service: mockchaindb.New()}
+	s := newTestService(t, &stateRootClient{}, chainDB, "https://example.com/{{printf \"%05d\" .Era}}/{{.Epoch}}/{{.StateRoot}}")
+
+	location, err := s.archiveLocationFor(128, phase0.Root{0x01})
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/00002/4/0x0100000000000000000000000000000000000000000000000000000000000000", location)
+
+	location, err = s.archiveLocationFor(129, phase0.Root{0x01})
+	require.NoError(t, err)
+	require.Empty(t, location)
+}
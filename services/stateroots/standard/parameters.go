@@ -0,0 +1,153 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"errors"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+type parameters struct {
+	logLevel        zerolog.Level
+	logLevelSampler zerolog.Sampler
+	monitor         metrics.Service
+	eth2Client      eth2client.Service
+	chainDB         chaindb.Service
+	chainTime       chaintime.Service
+	specProvider    eth2client.SpecProvider
+	archiveLocation string
+	archiveInterval uint64
+	startSlot       int64
+}
+
+// Parameter is the interface for service parameters.
+type Parameter interface {
+	apply(*parameters)
+}
+
+type parameterFunc func(*parameters)
+
+func (f parameterFunc) apply(p *parameters) {
+	f(p)
+}
+
+// WithLogLevel sets the log level for the module.
+func WithLogLevel(logLevel zerolog.Level) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevel = logLevel
+	})
+}
+
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
+// WithMonitor sets the monitor for the module.
+func WithMonitor(monitor metrics.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.monitor = monitor
+	})
+}
+
+// WithETH2Client sets the Ethereum 2 client for this module.
+func WithETH2Client(eth2Client eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eth2Client = eth2Client
+	})
+}
+
+// WithChainDB sets the chain database for this module.
+func WithChainDB(chainDB chaindb.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainDB = chainDB
+	})
+}
+
+// WithChainTime sets the chain time service for this module.
+func WithChainTime(chainTime chaintime.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.chainTime = chainTime
+	})
+}
+
+// WithSpecProvider sets the spec provider for this module.
+func WithSpecProvider(provider eth2client.SpecProvider) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.specProvider = provider
+	})
+}
+
+// WithArchiveLocation sets the template for the location of state archives.
+// The template is a Go text template with the fields Slot, Epoch, Era and
+// StateRoot, for example "/archive/mainnet-{{printf \"%05d\" .Era}}.era".
+func WithArchiveLocation(archiveLocation string) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.archiveLocation = archiveLocation
+	})
+}
+
+// WithArchiveInterval sets the interval in slots between archived states.
+// If 0, the chain's SLOTS_PER_HISTORICAL_ROOT is used, matching era files.
+func WithArchiveInterval(archiveInterval uint64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.archiveInterval = archiveInterval
+	})
+}
+
+// WithStartSlot sets the slot from which to start recording state roots.
+func WithStartSlot(startSlot int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.startSlot = startSlot
+	})
+}
+
+// parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
+func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
+	parameters := parameters{
+		logLevel:  zerolog.GlobalLevel(),
+		startSlot: -1,
+	}
+	for _, p := range params {
+		if params != nil {
+			p.apply(&parameters)
+		}
+	}
+
+	if parameters.eth2Client == nil {
+		return nil, errors.New("no Ethereum 2 client specified")
+	}
+	if _, isProvider := parameters.eth2Client.(eth2client.BeaconStateRootProvider); !isProvider {
+		return nil, errors.New("Ethereum 2 client does not provide beacon state roots") // skipcq: SCC-ST1005
+	}
+	if parameters.chainDB == nil {
+		return nil, errors.New("no chain database specified")
+	}
+	if parameters.chainTime == nil {
+		return nil, errors.New("no chain time specified")
+	}
+	if parameters.specProvider == nil {
+		return nil, errors.New("no spec provider specified")
+	}
+
+	return &parameters, nil
+}
@@ -0,0 +1,114 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"text/template"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a state roots service, recording the state root of each
+// finalized slot along with the location of archives of the full state.
+type Service struct {
+	stateRootProvider eth2client.BeaconStateRootProvider
+	chainDB           chaindb.Service
+	stateRootsSetter  chaindb.StateRootsSetter
+	chainTime         chaintime.Service
+	archiveLocation   *template.Template
+	archiveInterval   uint64
+	activitySem       *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "stateroots").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	stateRootsSetter, isSetter := parameters.chainDB.(chaindb.StateRootsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support state root setting")
+	}
+
+	var archiveLocation *template.Template
+	if parameters.archiveLocation != "" {
+		archiveLocation, err = template.New("archive-location").Option("missingkey=error").Parse(parameters.archiveLocation)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid archive location")
+		}
+	}
+
+	archiveInterval := parameters.archiveInterval
+	if archiveInterval == 0 {
+		spec, err := parameters.specProvider.Spec(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain spec")
+		}
+		tmp, exists := spec["SLOTS_PER_HISTORICAL_ROOT"].(uint64)
+		if !exists || tmp == 0 {
+			return nil, errors.New("SLOTS_PER_HISTORICAL_ROOT not found in spec")
+		}
+		archiveInterval = tmp
+	}
+
+	s := &Service{
+		stateRootProvider: parameters.eth2Client.(eth2client.BeaconStateRootProvider),
+		chainDB:           parameters.chainDB,
+		stateRootsSetter:  stateRootsSetter,
+		chainTime:         parameters.chainTime,
+		archiveLocation:   archiveLocation,
+		archiveInterval:   archiveInterval,
+		activitySem:       semaphore.NewWeighted(1),
+	}
+
+	if parameters.startSlot >= 0 {
+		// Explicit requirement to start at a given slot.
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			md, err := s.getMetadata(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to obtain metadata")
+			}
+			md.NextSlot = phase0.Slot(parameters.startSlot)
+			return s.setMetadata(ctx, md)
+		}); err != nil {
+			return nil, errors.Wrap(err, "failed to set metadata with start slot")
+		}
+	}
+
+	return s, nil
+}
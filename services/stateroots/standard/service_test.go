@@ -0,0 +1,122 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/mock"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/services/stateroots/standard"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	chainDB := mockchaindb.New()
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithGenesisTimeProvider(chainDB.(eth2client.GenesisTimeProvider)),
+		standardchaintime.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+		standardchaintime.WithForkScheduleProvider(chainDB.(eth2client.ForkScheduleProvider)),
+	)
+	require.NoError(t, err)
+	eth2Client, err := mock.New(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ETH2ClientMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+			},
+			err: "problem with parameters: no Ethereum 2 client specified",
+		},
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainTime(chainTime),
+				standard.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "SpecProviderMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no spec provider specified",
+		},
+		{
+			name: "ArchiveLocationInvalid",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+				standard.WithArchiveLocation("/archive/{{.Era"),
+			},
+			err: "invalid archive location: template: archive-location:1: unclosed action",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithETH2Client(eth2Client),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+				standard.WithSpecProvider(chainDB.(eth2client.SpecProvider)),
+				standard.WithArchiveLocation("/archive/mainnet-{{printf \"%05d\" .Era}}.era"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // updateBlockSummariesForEpoch updates the block summaries for a given epoch.
@@ -37,28 +38,28 @@ func (s *Service) updateBlockSummariesForEpoch(ctx context.Context,
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch + 1)
 
-	for slot := minSlot; slot < maxSlot; slot++ {
-		if err := s.updateBlockSummaryForSlot(ctx, slot); err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to create summary for block %d", slot))
+	// All summaries for the epoch go in to a single transaction, along with the
+	// completion marker and metadata, so that the epoch is either fully summarized
+	// or not at all.
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		for slot := minSlot; slot < maxSlot; slot++ {
+			if err := s.updateBlockSummaryForSlot(ctx, slot); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to create summary for block %d", slot))
+			}
 		}
-	}
-
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction to set summarizer metadata for block")
-	}
-	md.LastBlockEpoch = epoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set summarizer metadata for block")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction to set summarizer metadata for block")
-	}
-	return nil
+		if err := s.epochCompletionsSetter.SetEpochComplete(ctx, blocksCompletionKey, epoch); err != nil {
+			return errors.Wrap(err, "failed to set epoch completion for block summaries")
+		}
+		md.setSummarized(blocksCompletionKey, epoch)
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set summarizer metadata for block")
+		}
+		return nil
+	})
 }
 
+// updateBlockSummaryForSlot updates the summary for the block at the given slot.
+// This requires the context to hold an active transaction.
 func (s *Service) updateBlockSummaryForSlot(ctx context.Context, slot phase0.Slot) error {
 	summary := &chaindb.BlockSummary{
 		Slot: slot,
@@ -97,20 +98,7 @@ func (s *Service) updateBlockSummaryForSlot(ctx context.Context, slot phase0.Slo
 		return errors.Wrap(err, "failed to calculate parent distance summary statistics for epoch")
 	}
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction to set epoch summary")
-	}
-	if err := s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, summary); err != nil {
-		cancel()
-		return err
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction to set epoch summary")
-	}
-
-	return nil
+	return s.chainDB.(chaindb.BlockSummariesSetter).SetBlockSummary(ctx, summary)
 }
 
 func (s *Service) attestationStatsForBlock(ctx context.Context,
@@ -22,6 +22,7 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // updateSummaryForEpoch updates the summary for a given epoch.
@@ -94,23 +95,21 @@ func (s *Service) updateSummaryForEpoch(ctx context.Context,
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set deposit stats")
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return false, errors.Wrap(err, "failed to begin transaction to set epoch summary")
-	}
-	if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, summary); err != nil {
-		cancel()
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		if err := s.chainDB.(chaindb.EpochSummariesSetter).SetEpochSummary(ctx, summary); err != nil {
+			return err
+		}
+		if err := s.epochCompletionsSetter.SetEpochComplete(ctx, epochsCompletionKey, epoch); err != nil {
+			return errors.Wrap(err, "failed to set epoch completion for epoch summary")
+		}
+		md.setSummarized(epochsCompletionKey, epoch)
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
+		}
+		return nil
+	}); err != nil {
 		return false, err
 	}
-	md.LastEpoch = epoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set summarizer metadata for epoch summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return false, errors.Wrap(err, "failed to set commit transaction to set epoch summary")
-	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
 
 	return true, nil
@@ -125,7 +124,7 @@ func (s *Service) validatorSummaryStatsForEpoch(ctx context.Context,
 ) {
 	activeIndices := make([]phase0.ValidatorIndex, 0)
 	// Number of validators that are active, became active, and exited in this epoch.
-	validators, err := s.validatorsProvider.Validators(ctx)
+	validators, err := s.validatorSet.Validators(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain validators")
 	}
@@ -141,15 +140,44 @@ func (s *Service) validatorSummaryStatsForEpoch(ctx context.Context,
 			validator.ExitEpoch > epoch:
 			summary.ActiveValidators++
 			activeIndices = append(activeIndices, validator.Index)
+			if validator.ExitEpoch != s.farFutureEpoch {
+				summary.ExitQueueLength++
+			}
 		case validator.ActivationEligibilityEpoch <= epoch &&
 			validator.ActivationEpoch != s.farFutureEpoch &&
 			validator.ActivationEpoch > epoch:
 			summary.ActivationQueueLength++
 		}
 	}
+
+	summary.ChurnLimit = s.churnLimit(summary.ActiveValidators)
+	summary.ActivationQueueWait = queueWait(summary.ActivationQueueLength, summary.ChurnLimit)
+	summary.ExitQueueWait = queueWait(summary.ExitQueueLength, summary.ChurnLimit)
+
 	return activeIndices, nil
 }
 
+// churnLimit returns the number of validators that can enter or leave the
+// active set in an epoch, given the number of active validators.
+func (s *Service) churnLimit(activeValidators int) int {
+	churnLimit := activeValidators / int(s.churnLimitQuotient)
+	if churnLimit < int(s.minPerEpochChurnLimit) {
+		churnLimit = int(s.minPerEpochChurnLimit)
+	}
+
+	return churnLimit
+}
+
+// queueWait returns the number of epochs required to clear a queue of the
+// given length at the given churn limit.
+func queueWait(queueLength int, churnLimit int) int {
+	if churnLimit == 0 {
+		return 0
+	}
+
+	return (queueLength + churnLimit - 1) / churnLimit
+}
+
 func (s *Service) blockStatsForEpoch(ctx context.Context,
 	epoch phase0.Epoch,
 	summary *chaindb.EpochSummary,
@@ -188,6 +216,17 @@ func (s *Service) depositStatsForEpoch(ctx context.Context,
 	return nil
 }
 
+// Flags for the attestations of a validator in an epoch.
+const (
+	attestingFlag uint8 = 1 << iota
+	targetCorrectFlag
+	headCorrectFlag
+)
+
+// attestationStatsForEpoch summarizes the attestations for an epoch.  Attestations
+// are fetched and processed a slot at a time, with the attestations of each
+// validator held as a set of flags, so that the attestations for the whole epoch
+// are never held in memory at once.
 func (s *Service) attestationStatsForEpoch(ctx context.Context,
 	epoch phase0.Epoch,
 	balances map[phase0.ValidatorIndex]*chaindb.ValidatorBalance,
@@ -196,14 +235,48 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 	minSlot := s.chainTime.FirstSlotOfEpoch(epoch)
 	maxSlot := s.chainTime.FirstSlotOfEpoch(epoch + 1)
 
-	attestationsForEpoch, err := s.attestationsProvider.AttestationsForSlotRange(ctx, minSlot, maxSlot)
+	validatorFlags := make(map[phase0.ValidatorIndex]uint8, len(balances))
+	for slot := minSlot; slot < maxSlot; slot++ {
+		if err := s.attestationStatsForSlot(ctx, slot, balances, validatorFlags, summary); err != nil {
+			return err
+		}
+	}
+
+	for index, flags := range validatorFlags {
+		effectiveBalance := balances[index].EffectiveBalance
+		if flags&attestingFlag != 0 {
+			summary.AttestingValidators++
+			summary.AttestingBalance += effectiveBalance
+		}
+		if flags&targetCorrectFlag != 0 {
+			summary.TargetCorrectValidators++
+			summary.TargetCorrectBalance += effectiveBalance
+		}
+		if flags&headCorrectFlag != 0 {
+			summary.HeadCorrectValidators++
+			summary.HeadCorrectBalance += effectiveBalance
+		}
+	}
+
+	return nil
+}
+
+// attestationStatsForSlot adds the attestations for, and in, a slot to the summary
+// of its epoch, and flags the attestations of each validator.
+func (s *Service) attestationStatsForSlot(ctx context.Context,
+	slot phase0.Slot,
+	balances map[phase0.ValidatorIndex]*chaindb.ValidatorBalance,
+	validatorFlags map[phase0.ValidatorIndex]uint8,
+	summary *chaindb.EpochSummary,
+) error {
+	attestationsForSlot, err := s.attestationsProvider.AttestationsForSlotRange(ctx, slot, slot+1)
 	if err != nil {
 		return errors.Wrap(err, "failed to obtain attestations")
 	}
-	log.Trace().Uint64("epoch", uint64(epoch)).Uint64("min_slot", uint64(minSlot)).Uint64("max_slot", uint64(maxSlot)).Int("attestations", len(attestationsForEpoch)).Msg("Obtained attestations for epoch")
-	epochAttestations := make([]*chaindb.Attestation, 0)
+	log.Trace().Uint64("slot", uint64(slot)).Int("attestations", len(attestationsForSlot)).Msg("Obtained attestations for slot")
+	// Duplicate attestations have the same slot, so only need to be found within the slot.
 	seenAttestations := make(map[phase0.Root]bool)
-	for _, attestation := range attestationsForEpoch {
+	for _, attestation := range attestationsForSlot {
 		specAttestation := &phase0.Attestation{
 			AggregationBits: attestation.AggregationBits,
 			Data: &phase0.AttestationData{
@@ -227,9 +300,7 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 		}
 		if _, exists := seenAttestations[specAttestationRoot]; exists {
 			// This is a duplicate.
-			if attestation.Slot >= minSlot && attestation.Slot < maxSlot {
-				summary.DuplicateAttestationsForEpoch++
-			}
+			summary.DuplicateAttestationsForEpoch++
 			continue
 		}
 		seenAttestations[specAttestationRoot] = true
@@ -237,49 +308,28 @@ func (s *Service) attestationStatsForEpoch(ctx context.Context,
 			log.Trace().Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Uint64("inclusion_index", attestation.InclusionIndex).Msg("Attestation is not canonical; ignoring")
 			continue
 		}
-		if attestation.Slot >= minSlot && attestation.Slot < maxSlot {
-			summary.AttestationsForEpoch++
-			epochAttestations = append(epochAttestations, attestation)
-		}
-	}
-
-	// Fetch all attestations in the epoch for a simple count.
-	attestationsInEpoch, err := s.attestationsProvider.AttestationsInSlotRange(ctx, minSlot, maxSlot)
-	if err != nil {
-		return errors.Wrap(err, "failed to obtain attestations in epoch")
-	}
-	summary.AttestationsInEpoch = len(attestationsInEpoch)
-
-	// epochAttestations contains the list of attestations we need to process.
-	attestingValidatorBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
-	targetCorrectBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
-	headCorrectBalances := make(map[phase0.ValidatorIndex]phase0.Gwei)
-	for _, attestation := range epochAttestations {
+		summary.AttestationsForEpoch++
 		for _, index := range attestation.AggregationIndices {
 			if _, exists := balances[index]; !exists {
 				return fmt.Errorf("no balance for validator %d", index)
 			}
-			attestingValidatorBalances[index] = balances[index].EffectiveBalance
+			flags := validatorFlags[index] | attestingFlag
 			if attestation.TargetCorrect != nil && *attestation.TargetCorrect {
-				targetCorrectBalances[index] = balances[index].EffectiveBalance
+				flags |= targetCorrectFlag
 			}
 			if attestation.HeadCorrect != nil && *attestation.HeadCorrect {
-				headCorrectBalances[index] = balances[index].EffectiveBalance
+				flags |= headCorrectFlag
 			}
+			validatorFlags[index] = flags
 		}
 	}
-	for _, attestingValidatorBalance := range attestingValidatorBalances {
-		summary.AttestingValidators++
-		summary.AttestingBalance += attestingValidatorBalance
-	}
-	for _, targetCorrectBalance := range targetCorrectBalances {
-		summary.TargetCorrectValidators++
-		summary.TargetCorrectBalance += targetCorrectBalance
-	}
-	for _, headCorrectBalance := range headCorrectBalances {
-		summary.HeadCorrectValidators++
-		summary.HeadCorrectBalance += headCorrectBalance
+
+	// Fetch the attestations included in the slot for a simple count.
+	attestationsInSlot, err := s.attestationsProvider.AttestationsInSlotRange(ctx, slot, slot+1)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain attestations in epoch")
 	}
+	summary.AttestationsInEpoch += len(attestationsInSlot)
 
 	return nil
 }
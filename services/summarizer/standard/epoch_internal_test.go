@@ -0,0 +1,60 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+func TestValidatorSummaryStatsQueues(t *testing.T) {
+	ctx := context.Background()
+
+	s, _ := newSummaryTestService(t, 10)
+	s.farFutureEpoch = 0xffffffffffffffff
+	s.minPerEpochChurnLimit = 2
+	s.churnLimitQuotient = 4
+
+	validators, err := s.validatorSet.Validators(ctx)
+	require.NoError(t, err)
+	// Validators 0-7 are active; 6 and 7 are exiting.
+	validators[6].ExitEpoch = 8
+	validators[7].ExitEpoch = 9
+	// Validators 8 and 9 are awaiting activation.
+	for _, index := range []int{8, 9} {
+		validators[index].ActivationEligibilityEpoch = 3
+		validators[index].ActivationEpoch = 7
+	}
+
+	summary := &chaindb.EpochSummary{Epoch: 5}
+	_, err = s.validatorSummaryStatsForEpoch(ctx, 5, summary)
+	require.NoError(t, err)
+	require.Equal(t, 8, summary.ActiveValidators)
+	require.Equal(t, 2, summary.ActivationQueueLength)
+	require.Equal(t, 2, summary.ExitQueueLength)
+	require.Equal(t, 2, summary.ChurnLimit)
+	require.Equal(t, 1, summary.ActivationQueueWait)
+	require.Equal(t, 1, summary.ExitQueueWait)
+}
+
+func TestQueueWait(t *testing.T) {
+	require.Equal(t, 0, queueWait(0, 4))
+	require.Equal(t, 1, queueWait(1, 4))
+	require.Equal(t, 1, queueWait(4, 4))
+	require.Equal(t, 2, queueWait(5, 4))
+	require.Equal(t, 0, queueWait(5, 0))
+}
@@ -19,6 +19,9 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // OnFinalityUpdated is called when finality has been updated in the database.
@@ -35,6 +38,20 @@ func (s *Service) OnFinalityUpdated(
 	log := log.With().Uint64("finalized_epoch", uint64(finalizedEpoch)).Logger()
 	log.Trace().Msg("Handler called")
 
+	// Summarizing is heavy work that can be delayed, so run it as a catchup job.
+	// The semaphore is acquired within the job so that it is not held while the
+	// job is waiting to run, as reindexing acquires it from a repair job.
+	if err := s.scheduler.Run(ctx, "summarize", scheduler.PriorityCatchup, func(ctx context.Context) {
+		s.summarize(ctx, finalizedEpoch)
+	}); err != nil {
+		log.Debug().Err(err).Msg("Failed to run summarizer")
+	}
+}
+
+// summarize updates summaries up to the given epoch.
+func (s *Service) summarize(ctx context.Context, finalizedEpoch phase0.Epoch) {
+	log := log.With().Uint64("finalized_epoch", uint64(finalizedEpoch)).Logger()
+
 	// Only allow 1 handler to be active.
 	acquired := s.activitySem.TryAcquire(1)
 	if !acquired {
@@ -43,13 +60,19 @@ func (s *Service) OnFinalityUpdated(
 	}
 	defer s.activitySem.Release(1)
 
+	ctx, span := tracer.Start(ctx, "summarize", trace.WithAttributes(attribute.Int64("finalized_epoch", int64(finalizedEpoch))))
+	defer span.End()
+
 	if err := s.onFinalityUpdatedEpochs(ctx, finalizedEpoch); err != nil {
+		monitorError(err)
 		log.Warn().Err(err).Msg("Failed to update epochs")
 	}
 	if err := s.onFinalityUpdatedBlocks(ctx, finalizedEpoch); err != nil {
+		monitorError(err)
 		log.Warn().Err(err).Msg("Failed to update blocks")
 	}
 	if err := s.onFinalityUpdatedValidators(ctx, finalizedEpoch); err != nil {
+		monitorError(err)
 		log.Warn().Err(err).Msg("Failed to update validators")
 	}
 
@@ -67,20 +90,23 @@ func (s *Service) onFinalityUpdatedEpochs(ctx context.Context, finalizedEpoch ph
 		return errors.Wrap(err, "failed to obtain metadata for epoch summarizer")
 	}
 
-	lastEpoch := md.LastEpoch
-	if lastEpoch != 0 {
-		lastEpoch++
-	}
-	log.Trace().Uint64("last_epoch", uint64(lastEpoch)).Uint64("finalized_epoch", uint64(finalizedEpoch)).Msg("Catchup bounds")
-
-	for epoch := lastEpoch; epoch <= finalizedEpoch; epoch++ {
-		updated, err := s.updateSummaryForEpoch(ctx, md, epoch)
-		if err != nil {
-			return errors.Wrapf(err, "failed to update summary for epoch %d", epoch)
-		}
-		if !updated {
-			log.Debug().Uint64("epoch", uint64(epoch)).Msg("not enough data to update summary")
-			return nil
+	log.Trace().Uint64("last_epoch", uint64(md.LastEpoch)).Uint64("finalized_epoch", uint64(finalizedEpoch)).Msg("Catchup bounds")
+
+	// Any gaps in the summarized epochs, for example following a reorg, are summarized
+	// along with the epochs since the last epoch.
+	for _, gap := range md.SummarizedEpochs.Gaps(0, finalizedEpoch) {
+		for epoch := gap.Start; epoch <= gap.End; epoch++ {
+			updated, err := s.updateSummaryForEpoch(ctx, md, epoch)
+			if err != nil {
+				return errors.Wrapf(err, "failed to update summary for epoch %d", epoch)
+			}
+			if !updated {
+				log.Debug().Uint64("epoch", uint64(epoch)).Msg("not enough data to update summary")
+				return nil
+			}
+			for _, epochHandler := range s.epochHandlers {
+				epochHandler.OnEpochIndexed(ctx, epoch)
+			}
 		}
 	}
 
@@ -99,11 +125,6 @@ func (s *Service) onFinalityUpdatedBlocks(ctx context.Context,
 		return errors.Wrap(err, "failed to obtain metadata for block finality")
 	}
 
-	lastBlockEpoch := md.LastBlockEpoch
-	if lastBlockEpoch != 0 {
-		lastBlockEpoch++
-	}
-
 	// The last epoch updated in the metadata tells us how far we can summarize,
 	// as it checks for the component data.  As such, if the finalized epoch
 	// is beyond our summarized epoch we truncate to the summarized value.
@@ -113,9 +134,11 @@ func (s *Service) onFinalityUpdatedBlocks(ctx context.Context,
 		finalizedEpoch = md.LastEpoch
 	}
 
-	for epoch := lastBlockEpoch; epoch <= finalizedEpoch; epoch++ {
-		if err := s.updateBlockSummariesForEpoch(ctx, md, epoch); err != nil {
-			return errors.Wrap(err, "failed to update block summaries for epoch")
+	for _, gap := range md.SummarizedBlockEpochs.Gaps(0, finalizedEpoch) {
+		for epoch := gap.Start; epoch <= gap.End; epoch++ {
+			if err := s.updateBlockSummariesForEpoch(ctx, md, epoch); err != nil {
+				return errors.Wrap(err, "failed to update block summaries for epoch")
+			}
 		}
 	}
 
@@ -141,15 +164,49 @@ func (s *Service) onFinalityUpdatedValidators(ctx context.Context, finalizedEpoc
 		finalizedEpoch = md.LastEpoch
 	}
 
-	lastValidatorEpoch := md.LastValidatorEpoch
-	if lastValidatorEpoch != 0 {
-		lastValidatorEpoch++
-	}
-	for epoch := lastValidatorEpoch; epoch <= finalizedEpoch; epoch++ {
-		if err := s.updateValidatorSummariesForEpoch(ctx, md, epoch); err != nil {
-			return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries for epoch %d", epoch))
+	for _, gap := range md.SummarizedValidatorEpochs.Gaps(0, finalizedEpoch) {
+		for epoch := gap.Start; epoch <= gap.End; epoch++ {
+			if err := s.updateValidatorSummariesForEpoch(ctx, md, epoch); err != nil {
+				return errors.Wrap(err, fmt.Sprintf("failed to update validator summaries for epoch %d", epoch))
+			}
 		}
 	}
 
 	return nil
 }
+
+// OnReorg is called when the canonical status of previously finalized blocks has changed.
+func (s *Service) OnReorg(
+	ctx context.Context,
+	firstEpoch phase0.Epoch,
+) {
+	log := log.With().Uint64("first_epoch", uint64(firstEpoch)).Logger()
+	log.Trace().Msg("Reorg handler called")
+
+	// Wait for any active handler, as we must not rewind underneath it.
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		log.Warn().Err(err).Msg("Failed to acquire semaphore")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		monitorError(err)
+		log.Warn().Err(err).Msg("Failed to obtain metadata")
+		return
+	}
+	if md.LastEpoch < firstEpoch && md.LastBlockEpoch < firstEpoch && md.LastValidatorEpoch < firstEpoch {
+		// Nothing summarized for the affected epochs.
+		return
+	}
+
+	// Summaries are recalculated on the next finality update, so rewind to the
+	// affected epoch rather than recalculating here.
+	if err := s.rewind(ctx, firstEpoch); err != nil {
+		monitorError(err)
+		log.Warn().Err(err).Msg("Failed to rewind summaries")
+		return
+	}
+	log.Info().Msg("Rewound summaries following reorg")
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
 )
 
 // metadata stored about this service.
@@ -26,11 +27,57 @@ type metadata struct {
 	LastValidatorEpoch phase0.Epoch `json:"latest_validator_epoch"`
 	LastBlockEpoch     phase0.Epoch `json:"latest_block_epoch"`
 	LastEpoch          phase0.Epoch `json:"latest_epoch"`
+	// SummarizedEpochs are the epochs for which epoch summaries have been made.
+	SummarizedEpochs util.EpochRanges `json:"summarized_epochs,omitempty"`
+	// SummarizedBlockEpochs are the epochs for which block summaries have been made.
+	SummarizedBlockEpochs util.EpochRanges `json:"summarized_block_epochs,omitempty"`
+	// SummarizedValidatorEpochs are the epochs for which validator summaries have been made.
+	SummarizedValidatorEpochs util.EpochRanges `json:"summarized_validator_epochs,omitempty"`
+}
+
+// summaryProgress is the progress of a type of summary.
+type summaryProgress struct {
+	// completionKey is the key for the epoch completion markers of the summary.
+	completionKey string
+	// summarized are the epochs that have been summarized.
+	summarized *util.EpochRanges
+	// last is the highest epoch that has been summarized.
+	last *phase0.Epoch
+}
+
+// progress returns the progress of each type of summary.
+func (md *metadata) progress() []*summaryProgress {
+	return []*summaryProgress{
+		{completionKey: epochsCompletionKey, summarized: &md.SummarizedEpochs, last: &md.LastEpoch},
+		{completionKey: blocksCompletionKey, summarized: &md.SummarizedBlockEpochs, last: &md.LastBlockEpoch},
+		{completionKey: validatorsCompletionKey, summarized: &md.SummarizedValidatorEpochs, last: &md.LastValidatorEpoch},
+	}
+}
+
+// setSummarized marks epochs as summarized for the type of summary with the given
+// completion key.
+func (md *metadata) setSummarized(completionKey string, epochs ...phase0.Epoch) {
+	for _, progress := range md.progress() {
+		if progress.completionKey != completionKey {
+			continue
+		}
+		for _, epoch := range epochs {
+			progress.summarized.Add(epoch)
+		}
+		*progress.last, _ = progress.summarized.Highest()
+	}
 }
 
 // metadataKey is the key for the metadata.
 var metadataKey = "summarizer.standard"
 
+// Keys for the epoch completion markers of each type of summary.
+var (
+	epochsCompletionKey     = "summarizer.standard.epochs"
+	blocksCompletionKey     = "summarizer.standard.blocks"
+	validatorsCompletionKey = "summarizer.standard.validators"
+)
+
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{}
@@ -44,6 +91,13 @@ func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	if err := json.Unmarshal(mdJSON, md); err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal metadata")
 	}
+	for _, progress := range md.progress() {
+		if *progress.summarized == nil {
+			// Metadata written by older versions of chaind, which summarized all
+			// epochs up to the last epoch.
+			*progress.summarized = util.ProcessedFromLatest(*progress.last, nil)
+		}
+	}
 	return md, nil
 }
 
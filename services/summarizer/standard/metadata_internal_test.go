@@ -0,0 +1,40 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestMetadataSetSummarized(t *testing.T) {
+	md := &metadata{
+		SummarizedEpochs: util.EpochRanges{{Start: 0, End: 5}},
+	}
+
+	md.setSummarized(epochsCompletionKey, 8, 7)
+	require.Equal(t, util.EpochRanges{{Start: 0, End: 5}, {Start: 7, End: 8}}, md.SummarizedEpochs)
+	require.Equal(t, phase0.Epoch(8), md.LastEpoch)
+
+	md.setSummarized(blocksCompletionKey, 3)
+	require.Equal(t, util.EpochRanges{{Start: 3, End: 3}}, md.SummarizedBlockEpochs)
+	require.Equal(t, phase0.Epoch(3), md.LastBlockEpoch)
+
+	// Other types of summary are unaffected.
+	require.Nil(t, md.SummarizedValidatorEpochs)
+	require.Equal(t, phase0.Epoch(0), md.LastValidatorEpoch)
+}
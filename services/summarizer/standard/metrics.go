@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/util"
 )
 
 var metricsNamespace = "chaind_summarizer"
@@ -27,6 +28,7 @@ var metricsNamespace = "chaind_summarizer"
 var highestEpoch phase0.Epoch
 var latestEpoch prometheus.Gauge
 var epochsProcessed prometheus.Gauge
+var errorsTotal *prometheus.CounterVec
 
 func registerMetrics(ctx context.Context, monitor metrics.Service) error {
 	if latestEpoch != nil {
@@ -62,6 +64,18 @@ func registerPrometheusMetrics(ctx context.Context) error {
 		return errors.Wrap(err, "failed to register epochs_processed")
 	}
 
+	errorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "errors_total",
+		Help:      "Number of errors, by type",
+	}, []string{"type"})
+	if err := prometheus.Register(errorsTotal); err != nil {
+		return errors.Wrap(err, "failed to register errors_total")
+	}
+	for _, errorClass := range util.ErrorClasses {
+		errorsTotal.WithLabelValues(errorClass)
+	}
+
 	return nil
 }
 
@@ -83,3 +97,10 @@ func monitorEpochProcessed(epoch phase0.Epoch) {
 		}
 	}
 }
+
+// monitorError counts an error by its type.
+func monitorError(err error) {
+	if errorsTotal != nil && err != nil {
+		errorsTotal.WithLabelValues(util.ClassifyError(err)).Inc()
+	}
+}
@@ -18,20 +18,29 @@ import (
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/validatorset"
 )
 
 type parameters struct {
 	logLevel           zerolog.Level
+	logLevelSampler    zerolog.Sampler
 	monitor            metrics.Service
 	eth2Client         eth2client.Service
 	chainDB            chaindb.Service
 	chainTime          chaintime.Service
+	scheduler          scheduler.Service
 	epochSummaries     bool
 	blockSummaries     bool
 	validatorSummaries bool
+	epochHandlers      []handlers.EpochHandler
+	startEpoch         int64
+	batchSize          int
+	validatorSet       validatorset.Service
 }
 
 // Parameter is the interface for service parameters.
@@ -52,6 +61,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -101,10 +118,49 @@ func WithValidatorSummaries(enabled bool) Parameter {
 	})
 }
 
+// WithEpochHandlers sets the epoch handlers for this module.
+func WithEpochHandlers(handlers []handlers.EpochHandler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.epochHandlers = handlers
+	})
+}
+
+// WithStartEpoch sets the epoch from which to start summarizing.
+func WithStartEpoch(startEpoch int64) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.startEpoch = startEpoch
+	})
+}
+
+// WithScheduler sets the scheduler for jobs run by this module.
+func WithScheduler(scheduler scheduler.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.scheduler = scheduler
+	})
+}
+
+// WithBatchSize sets the maximum number of validator epoch summaries written to
+// the database at a time, which bounds the memory used when summarizing an epoch.
+func WithBatchSize(batchSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.batchSize = batchSize
+	})
+}
+
+// WithValidatorSet sets the validator set from which validators are obtained.
+// If not supplied validators are obtained from the chain database.
+func WithValidatorSet(validatorSet validatorset.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorSet = validatorSet
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel: zerolog.GlobalLevel(),
+		logLevel:   zerolog.GlobalLevel(),
+		startEpoch: -1,
+		batchSize:  10000,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -121,6 +177,12 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.chainTime == nil {
 		return nil, errors.New("no chain time specified")
 	}
+	if parameters.scheduler == nil {
+		return nil, errors.New("no scheduler specified")
+	}
+	if parameters.batchSize <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
 
 	return &parameters, nil
 }
@@ -0,0 +1,71 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
+)
+
+// ReindexEpochs recalculates the enabled summaries for the epochs from start to end inclusive.
+func (s *Service) ReindexEpochs(ctx context.Context, start phase0.Epoch, end phase0.Epoch) error {
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	log.Info().Uint64("start_epoch", uint64(start)).Uint64("end_epoch", uint64(end)).Msg("Reindexing epochs")
+	// Summarizing updates the metadata, so use a copy.
+	reindexMD := *md
+	reindexErr := s.reindexEpochs(ctx, &reindexMD, start, end)
+
+	// Restore the metadata, as reindexing does not alter the progress of the service.
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		return s.setMetadata(ctx, md)
+	}); err != nil {
+		return errors.Wrap(err, "failed to restore metadata")
+	}
+
+	return reindexErr
+}
+
+func (s *Service) reindexEpochs(ctx context.Context, md *metadata, start phase0.Epoch, end phase0.Epoch) error {
+	for epoch := start; epoch <= end; epoch++ {
+		if s.epochSummaries {
+			updated, err := s.updateSummaryForEpoch(ctx, md, epoch)
+			if err != nil {
+				return errors.Wrapf(err, "failed to update summary for epoch %d", epoch)
+			}
+			if !updated {
+				return errors.Errorf("not enough data to update summary for epoch %d", epoch)
+			}
+		}
+		if err := s.updateBlockSummariesForEpoch(ctx, md, epoch); err != nil {
+			return errors.Wrapf(err, "failed to update block summaries for epoch %d", epoch)
+		}
+		if err := s.updateValidatorSummariesForEpoch(ctx, md, epoch); err != nil {
+			return errors.Wrapf(err, "failed to update validator summaries for epoch %d", epoch)
+		}
+	}
+
+	return nil
+}
@@ -22,8 +22,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/scheduler"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -32,14 +37,21 @@ type Service struct {
 	eth2Client                      eth2client.Service
 	chainDB                         chaindb.Service
 	farFutureEpoch                  phase0.Epoch
+	minPerEpochChurnLimit           uint64
+	churnLimitQuotient              uint64
 	proposerDutiesProvider          chaindb.ProposerDutiesProvider
 	attestationsProvider            chaindb.AttestationsProvider
 	blocksProvider                  chaindb.BlocksProvider
 	depositsProvider                chaindb.DepositsProvider
 	validatorsProvider              chaindb.ValidatorsProvider
+	validatorSet                    validatorset.Service
+	validatorLabelsProvider         chaindb.ValidatorLabelsProvider
 	attesterSlashingsProvider       chaindb.AttesterSlashingsProvider
 	proposerSlashingsProvider       chaindb.ProposerSlashingsProvider
+	epochCompletionsSetter          chaindb.EpochCompletionsSetter
+	epochCompletionsProvider        chaindb.EpochCompletionsProvider
 	chainTime                       chaintime.Service
+	scheduler                       scheduler.Service
 	maxTimelyAttestationSourceDelay uint64
 	maxTimelyAttestationTargetDelay uint64
 	maxTimelyAttestationHeadDelay   uint64
@@ -47,11 +59,16 @@ type Service struct {
 	blockSummaries                  bool
 	validatorSummaries              bool
 	activitySem                     *semaphore.Weighted
+	epochHandlers                   []handlers.EpochHandler
+	batchSize                       int
 }
 
 // module-wide log.
 var log zerolog.Logger
 
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/summarizer/standard")
+
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -61,6 +78,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "summarizer").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -90,6 +110,10 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	if !isProvider {
 		return nil, errors.New("chain DB does not provide validators")
 	}
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorSet = validatorsProvider
+	}
 
 	attesterSlashingsProvider, isProvider := parameters.chainDB.(chaindb.AttesterSlashingsProvider)
 	if !isProvider {
@@ -101,6 +125,16 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not provide proposer slashings")
 	}
 
+	epochCompletionsSetter, isSetter := parameters.chainDB.(chaindb.EpochCompletionsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support epoch completion setting")
+	}
+
+	epochCompletionsProvider, isProvider := parameters.chainDB.(chaindb.EpochCompletionsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide epoch completions")
+	}
+
 	spec, err := parameters.eth2Client.(eth2client.SpecProvider).Spec(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain spec")
@@ -124,18 +158,38 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("SLOTS_PER_EPOCH of unexpected type")
 	}
 
+	minPerEpochChurnLimit := uint64(4)
+	if tmp, exists := spec["MIN_PER_EPOCH_CHURN_LIMIT"]; exists {
+		if val, ok := tmp.(uint64); ok {
+			minPerEpochChurnLimit = val
+		}
+	}
+
+	churnLimitQuotient := uint64(65536)
+	if tmp, exists := spec["CHURN_LIMIT_QUOTIENT"]; exists {
+		if val, ok := tmp.(uint64); ok && val > 0 {
+			churnLimitQuotient = val
+		}
+	}
+
 	s := &Service{
 		eth2Client:                      parameters.eth2Client,
 		chainDB:                         parameters.chainDB,
 		farFutureEpoch:                  phase0.Epoch(0xffffffffffffffff),
+		minPerEpochChurnLimit:           minPerEpochChurnLimit,
+		churnLimitQuotient:              churnLimitQuotient,
 		proposerDutiesProvider:          proposerDutiesProvider,
 		attestationsProvider:            attestationsProvider,
 		blocksProvider:                  blocksProvider,
 		depositsProvider:                depositsProvider,
 		validatorsProvider:              validatorsProvider,
+		validatorSet:                    validatorSet,
 		attesterSlashingsProvider:       attesterSlashingsProvider,
 		proposerSlashingsProvider:       proposerSlashingsProvider,
+		epochCompletionsSetter:          epochCompletionsSetter,
+		epochCompletionsProvider:        epochCompletionsProvider,
 		chainTime:                       parameters.chainTime,
+		scheduler:                       parameters.scheduler,
 		maxTimelyAttestationSourceDelay: uint64(math.Sqrt(float64(slotsPerEpoch))),
 		maxTimelyAttestationTargetDelay: slotsPerEpoch,
 		maxTimelyAttestationHeadDelay:   minAttestationInclusionDelay,
@@ -143,6 +197,22 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		blockSummaries:                  parameters.blockSummaries,
 		validatorSummaries:              parameters.validatorSummaries,
 		activitySem:                     semaphore.NewWeighted(1),
+		epochHandlers:                   parameters.epochHandlers,
+		batchSize:                       parameters.batchSize,
+	}
+
+	// Validator labels are optional; without them no per-label summaries are written.
+	if validatorLabelsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorLabelsProvider); isProvider {
+		s.validatorLabelsProvider = validatorLabelsProvider
+	}
+
+	if parameters.startEpoch >= 0 {
+		// Explicit requirement to start at a given epoch.
+		if err := s.setStartEpoch(ctx, phase0.Epoch(parameters.startEpoch)); err != nil {
+			return nil, errors.Wrap(err, "failed to set start epoch")
+		}
+	} else if err := s.applyCompletions(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to apply epoch completions")
 	}
 
 	// Note the current highest summarized epoch for the monitor.
@@ -154,3 +224,80 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	return s, nil
 }
+
+// setStartEpoch sets the epoch from which to start summarizing, treating earlier
+// epochs as summarized.
+func (s *Service) setStartEpoch(ctx context.Context, epoch phase0.Epoch) error {
+	return s.resetFrom(ctx, epoch, func(summarized *util.EpochRanges) {
+		*summarized = make(util.EpochRanges, 0)
+		if epoch > 0 {
+			summarized.AddRange(0, epoch-1)
+		}
+	})
+}
+
+// rewind removes the summaries from the given epoch onwards from the summarized
+// epochs, so that they are summarized again.
+func (s *Service) rewind(ctx context.Context, epoch phase0.Epoch) error {
+	return s.resetFrom(ctx, epoch, func(summarized *util.EpochRanges) {
+		summarized.RemoveFrom(epoch)
+	})
+}
+
+// resetFrom updates the summarized epochs of each type of summary with the supplied
+// function, and removes completion markers from the given epoch onwards.
+func (s *Service) resetFrom(ctx context.Context, epoch phase0.Epoch, reset func(summarized *util.EpochRanges)) error {
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain metadata")
+		}
+		for _, progress := range md.progress() {
+			reset(progress.summarized)
+			*progress.last, _ = progress.summarized.Highest()
+			if err := s.epochCompletionsSetter.DeleteEpochCompletions(ctx, progress.completionKey, epoch); err != nil {
+				return errors.Wrap(err, "failed to remove epoch completions")
+			}
+		}
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+}
+
+// applyCompletions marks as summarized any epochs that have a completion marker
+// but are not marked as summarized in the metadata.  Completion markers are written
+// in the same transaction as the summaries for their epoch, so are authoritative.
+func (s *Service) applyCompletions(ctx context.Context) error {
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	applied := 0
+	for _, progress := range md.progress() {
+		epochs, err := util.CompletedInGaps(ctx, s.epochCompletionsProvider, progress.completionKey, *progress.summarized, 0, s.chainTime.CurrentEpoch())
+		if err != nil {
+			return err
+		}
+		md.setSummarized(progress.completionKey, epochs...)
+		applied += len(epochs)
+	}
+	if applied == 0 {
+		return nil
+	}
+
+	log.Debug().
+		Int("epochs", applied).
+		Uint64("last_epoch", uint64(md.LastEpoch)).
+		Uint64("last_block_epoch", uint64(md.LastBlockEpoch)).
+		Uint64("last_validator_epoch", uint64(md.LastValidatorEpoch)).
+		Msg("Marking completed epochs as summarized")
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+}
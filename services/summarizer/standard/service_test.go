@@ -28,6 +28,10 @@ import (
 )
 
 func TestService(t *testing.T) {
+	if os.Getenv("CHAINDB_URL") == "" || os.Getenv("ETH2CLIENT_ADDRESS") == "" {
+		t.Skip("CHAINDB_URL and ETH2CLIENT_ADDRESS required")
+	}
+
 	ctx := context.Background()
 
 	chainDB, err := postgresqlchaindb.New(ctx,
@@ -15,11 +15,13 @@ package standard
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
 )
 
 // updateValidatorSummariesForEpoch updates the validator summaries for a given epoch.
@@ -48,65 +50,63 @@ func (s *Service) updateValidatorSummariesForEpoch(ctx context.Context,
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched proposals")
 
-	attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, err := s.attestationsForEpoch(ctx, epoch)
+	attestations, err := s.attestationsForEpoch(ctx, epoch)
 	if err != nil {
 		return err
 	}
 	log.Trace().Dur("elapsed", time.Since(started)).Msg("Fetched attestations")
 
-	// Store the data.
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
+	labelSummaries, err := s.validatorLabelEpochSummariesForEpoch(ctx, epoch, attestations, validatorProposerDuties, validatorProposals)
 	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction to set validator epoch summary")
+		return err
 	}
-	summaries := make([]*chaindb.ValidatorEpochSummary, 0, len(attestationsIncluded))
-	for index := range attestationsIncluded {
-		summary := &chaindb.ValidatorEpochSummary{
-			Index:               index,
-			Epoch:               epoch,
-			ProposerDuties:      validatorProposerDuties[index],
-			ProposalsIncluded:   validatorProposals[index],
-			AttestationIncluded: attestationsIncluded[index],
-		}
-		if summary.AttestationIncluded {
-			attestationTargetCorrect := attestationsTargetCorrect[index]
-			summary.AttestationTargetCorrect = &attestationTargetCorrect
-			attestationHeadCorrect := attestationsHeadCorrect[index]
-			summary.AttestationHeadCorrect = &attestationHeadCorrect
-			attestationInclusionDelay := int(attestationsInclusionDelay[index])
-			summary.AttestationInclusionDelay = &attestationInclusionDelay
-			if epoch >= s.chainTime.AltairInitialEpoch() {
-				if attestationSourceTimely, exists := attestationsSourceTimely[index]; exists {
-					summary.AttestationSourceTimely = &attestationSourceTimely
-				}
-				if attestationTargetTimely, exists := attestationsTargetTimely[index]; exists {
-					summary.AttestationTargetTimely = &attestationTargetTimely
-				}
-				if attestationHeadTimely, exists := attestationsHeadTimely[index]; exists {
-					summary.AttestationHeadTimely = &attestationHeadTimely
+	log.Trace().Dur("elapsed", time.Since(started)).Msg("Built label summaries")
+
+	// Store the data.
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		// Summaries are built and written a batch at a time, so that the summaries
+		// for the whole epoch are never held in memory at once.
+		setter := s.chainDB.(chaindb.ValidatorEpochSummariesSetter)
+		summaries := make([]*chaindb.ValidatorEpochSummary, 0, s.batchSize)
+		for i := range attestations {
+			if !attestations[i].summarize {
+				continue
+			}
+			index := phase0.ValidatorIndex(i)
+			summaries = append(summaries, s.validatorEpochSummary(epoch, index, &attestations[i], validatorProposerDuties[index], validatorProposals[index]))
+			if len(summaries) == s.batchSize {
+				if err := setter.SetValidatorEpochSummaries(ctx, summaries); err != nil {
+					return err
 				}
+				summaries = make([]*chaindb.ValidatorEpochSummary, 0, s.batchSize)
+			}
+		}
+		if len(summaries) > 0 {
+			if err := setter.SetValidatorEpochSummaries(ctx, summaries); err != nil {
+				return err
 			}
 		}
-		summaries = append(summaries, summary)
-	}
-
-	if err := s.chainDB.(chaindb.ValidatorEpochSummariesSetter).SetValidatorEpochSummaries(ctx, summaries); err != nil {
-		cancel()
-		return err
-	}
 
-	log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
-	md.LastValidatorEpoch = epoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set summarizer metadata for validator epoch summary")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction to set validator epoch summary")
-	}
+		if len(labelSummaries) > 0 {
+			labelSetter, isSetter := s.chainDB.(chaindb.ValidatorLabelEpochSummariesSetter)
+			if !isSetter {
+				return errors.New("chain database does not support validator label epoch summaries")
+			}
+			if err := labelSetter.SetValidatorLabelEpochSummaries(ctx, labelSummaries); err != nil {
+				return errors.Wrap(err, "failed to set validator label epoch summaries")
+			}
+		}
 
-	return nil
+		log.Trace().Dur("elapsed", time.Since(started)).Msg("Set summary")
+		if err := s.epochCompletionsSetter.SetEpochComplete(ctx, validatorsCompletionKey, epoch); err != nil {
+			return errors.Wrap(err, "failed to set epoch completion for validator epoch summary")
+		}
+		md.setSummarized(validatorsCompletionKey, epoch)
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set summarizer metadata for validator epoch summary")
+		}
+		return nil
+	})
 }
 
 func (s *Service) validatorProposerDutiesForEpoch(ctx context.Context,
@@ -128,8 +128,8 @@ func (s *Service) validatorProposerDutiesForEpoch(ctx context.Context,
 		return nil, nil, errors.New("no proposer duties to summarize for epoch")
 	}
 	if epoch == 0 {
-		// Epoch 0 only has 31 proposer duties.  Drop in a dummy for slot 0 to avoid special cases below.
-		tmp := make([]*chaindb.ProposerDuty, 32)
+		// Epoch 0 has no proposer duty for slot 0.  Drop in a dummy for slot 0 to avoid special cases below.
+		tmp := make([]*chaindb.ProposerDuty, len(proposerDuties)+1)
 		tmp[0] = &chaindb.ProposerDuty{
 			ValidatorIndex: 0xffffffffffffffff,
 		}
@@ -182,78 +182,214 @@ func (s *Service) validatorProposalsForEpoch(ctx context.Context,
 	return validatorProposals, nil
 }
 
-func (s *Service) attestationsForEpoch(ctx context.Context,
+// validatorAttestation is the summary of a validator's attestations for an epoch.
+type validatorAttestation struct {
+	// summarize is true if a summary is to be written for the validator.
+	summarize      bool
+	included       bool
+	targetCorrect  bool
+	headCorrect    bool
+	inclusionDelay phase0.Slot
+	inclusionSlot  phase0.Slot
+	sourceTimely   bool
+	targetTimely   bool
+	headTimely     bool
+}
+
+// validatorEpochSummary builds the epoch summary for a validator.
+func (s *Service) validatorEpochSummary(epoch phase0.Epoch,
+	index phase0.ValidatorIndex,
+	attestation *validatorAttestation,
+	proposerDuties int,
+	proposalsIncluded int,
+) *chaindb.ValidatorEpochSummary {
+	summary := &chaindb.ValidatorEpochSummary{
+		Index:               index,
+		Epoch:               epoch,
+		ProposerDuties:      proposerDuties,
+		ProposalsIncluded:   proposalsIncluded,
+		AttestationIncluded: attestation.included,
+	}
+	if summary.AttestationIncluded {
+		attestationTargetCorrect := attestation.targetCorrect
+		summary.AttestationTargetCorrect = &attestationTargetCorrect
+		attestationHeadCorrect := attestation.headCorrect
+		summary.AttestationHeadCorrect = &attestationHeadCorrect
+		attestationInclusionDelay := int(attestation.inclusionDelay)
+		summary.AttestationInclusionDelay = &attestationInclusionDelay
+		attestationInclusionSlot := attestation.inclusionSlot
+		summary.AttestationInclusionSlot = &attestationInclusionSlot
+		if epoch >= s.chainTime.AltairInitialEpoch() {
+			attestationSourceTimely := attestation.sourceTimely
+			summary.AttestationSourceTimely = &attestationSourceTimely
+			attestationTargetTimely := attestation.targetTimely
+			summary.AttestationTargetTimely = &attestationTargetTimely
+			attestationHeadTimely := attestation.headTimely
+			summary.AttestationHeadTimely = &attestationHeadTimely
+		}
+	}
+
+	return summary
+}
+
+// validatorLabelEpochSummariesForEpoch builds the epoch summaries for each
+// validator label, if any validators are labeled.
+func (s *Service) validatorLabelEpochSummariesForEpoch(ctx context.Context,
 	epoch phase0.Epoch,
+	attestations []validatorAttestation,
+	validatorProposerDuties map[phase0.ValidatorIndex]int,
+	validatorProposals map[phase0.ValidatorIndex]int,
 ) (
-	map[phase0.ValidatorIndex]bool,
-	map[phase0.ValidatorIndex]bool,
-	map[phase0.ValidatorIndex]bool,
-	map[phase0.ValidatorIndex]phase0.Slot,
-	map[phase0.ValidatorIndex]bool,
-	map[phase0.ValidatorIndex]bool,
-	map[phase0.ValidatorIndex]bool,
+	[]*chaindb.ValidatorLabelEpochSummary,
 	error,
 ) {
-	// Fetch all attestations for the epoch.
-	attestations, err := s.attestationsProvider.AttestationsForSlotRange(ctx,
-		s.chainTime.FirstSlotOfEpoch(epoch),
-		s.chainTime.FirstSlotOfEpoch(epoch+1),
-	)
+	if s.validatorLabelsProvider == nil {
+		return nil, nil
+	}
+	labels, err := s.validatorLabelsProvider.ValidatorLabels(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain validator labels")
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	validators, err := s.validatorSet.Validators(ctx)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, err
+		return nil, err
 	}
-	log.Trace().Int("attestations", len(attestations)).Uint64("epoch", uint64(epoch)).Msg("Fetched attestations")
 
-	// Mark up attestations for each validator.
-	attestationsIncluded := make(map[phase0.ValidatorIndex]bool)
-	attestationsTargetCorrect := make(map[phase0.ValidatorIndex]bool)
-	attestationsHeadCorrect := make(map[phase0.ValidatorIndex]bool)
-	attestationsInclusionDelay := make(map[phase0.ValidatorIndex]phase0.Slot)
-	attestationsSourceTimely := make(map[phase0.ValidatorIndex]bool)
-	attestationsTargetTimely := make(map[phase0.ValidatorIndex]bool)
-	attestationsHeadTimely := make(map[phase0.ValidatorIndex]bool)
-	for _, attestation := range attestations {
-		if attestation.Canonical == nil || !*attestation.Canonical {
-			log.Trace().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Msg("Non-canonical attestation; ignoring")
+	return validatorLabelEpochSummaries(epoch, labels, validators, attestations, validatorProposerDuties, validatorProposals), nil
+}
+
+// validatorLabelEpochSummaries aggregates the per-validator data for an epoch
+// by validator label.  Summaries are returned ordered by label.
+func validatorLabelEpochSummaries(epoch phase0.Epoch,
+	labels map[phase0.BLSPubKey]string,
+	validators []*chaindb.Validator,
+	attestations []validatorAttestation,
+	validatorProposerDuties map[phase0.ValidatorIndex]int,
+	validatorProposals map[phase0.ValidatorIndex]int,
+) []*chaindb.ValidatorLabelEpochSummary {
+	summaries := make(map[string]*chaindb.ValidatorLabelEpochSummary)
+	for _, validator := range validators {
+		label, exists := labels[validator.PublicKey]
+		if !exists {
+			continue
+		}
+		if int(validator.Index) >= len(attestations) || !attestations[validator.Index].summarize {
+			// No summary for this validator in this epoch.
 			continue
 		}
-		inclusionDelay := attestation.InclusionSlot - attestation.Slot
-		attestationSourceTimely := uint64(inclusionDelay) <= s.maxTimelyAttestationSourceDelay
-		attestationTargetTimely := false
-		attestationHeadTimely := false
-		for _, index := range attestation.AggregationIndices {
-			attestationsIncluded[index] = true
-			if *attestation.TargetCorrect {
-				attestationsTargetCorrect[index] = true
-				attestationTargetTimely = uint64(inclusionDelay) <= s.maxTimelyAttestationTargetDelay
+		summary, exists := summaries[label]
+		if !exists {
+			summary = &chaindb.ValidatorLabelEpochSummary{
+				Label: label,
+				Epoch: epoch,
 			}
-			if *attestation.HeadCorrect {
-				attestationsHeadCorrect[index] = true
-				attestationHeadTimely = uint64(inclusionDelay) <= s.maxTimelyAttestationHeadDelay
+			summaries[label] = summary
+		}
+		summary.Validators++
+		summary.ProposerDuties += validatorProposerDuties[validator.Index]
+		summary.ProposalsIncluded += validatorProposals[validator.Index]
+		attestation := &attestations[validator.Index]
+		if attestation.included {
+			summary.AttestationsIncluded++
+			if attestation.targetCorrect {
+				summary.AttestationsTargetCorrect++
 			}
-			shortestDelay, exists := attestationsInclusionDelay[index]
-			if !exists || inclusionDelay < shortestDelay {
-				attestationsInclusionDelay[index] = inclusionDelay
-				attestationsSourceTimely[index] = attestationSourceTimely
-				attestationsTargetTimely[index] = attestationTargetTimely
-				attestationsHeadTimely[index] = attestationHeadTimely
+			if attestation.headCorrect {
+				summary.AttestationsHeadCorrect++
 			}
+			summary.AttestationsInclusionDelay += int(attestation.inclusionDelay)
 		}
 	}
 
-	// Add in any validators that did not attest.
-	validators, err := s.chainDB.(chaindb.ValidatorsProvider).Validators(ctx)
+	res := make([]*chaindb.ValidatorLabelEpochSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		res = append(res, summary)
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].Label < res[j].Label
+	})
+
+	return res
+}
+
+// attestationsForEpoch summarizes the attestations for an epoch by validator,
+// indexed by validator index.  Attestations are fetched a slot at a time, and
+// summarized in a single compact entry per validator, so that neither the
+// attestations nor per-validator maps for the whole epoch are held in memory.
+func (s *Service) attestationsForEpoch(ctx context.Context,
+	epoch phase0.Epoch,
+) (
+	[]validatorAttestation,
+	error,
+) {
+	validators, err := s.validatorSet.Validators(ctx)
 	if err != nil {
-		return nil, nil, nil, nil, nil, nil, nil, err
+		return nil, err
 	}
+	maxIndex := phase0.ValidatorIndex(0)
+	for _, validator := range validators {
+		if validator.Index > maxIndex {
+			maxIndex = validator.Index
+		}
+	}
+	attestations := make([]validatorAttestation, int(maxIndex)+1)
+
+	for slot := s.chainTime.FirstSlotOfEpoch(epoch); slot < s.chainTime.FirstSlotOfEpoch(epoch+1); slot++ {
+		slotAttestations, err := s.attestationsProvider.AttestationsForSlotRange(ctx, slot, slot+1)
+		if err != nil {
+			return nil, err
+		}
+		log.Trace().Int("attestations", len(slotAttestations)).Uint64("slot", uint64(slot)).Msg("Fetched attestations")
+
+		for _, attestation := range slotAttestations {
+			if attestation.Canonical == nil || !*attestation.Canonical {
+				log.Trace().Uint64("slot", uint64(attestation.Slot)).Uint64("inclusion_slot", uint64(attestation.InclusionSlot)).Msg("Non-canonical attestation; ignoring")
+				continue
+			}
+			inclusionDelay := attestation.InclusionSlot - attestation.Slot
+			targetCorrect := attestation.TargetCorrect != nil && *attestation.TargetCorrect
+			headCorrect := attestation.HeadCorrect != nil && *attestation.HeadCorrect
+			sourceTimely := uint64(inclusionDelay) <= s.maxTimelyAttestationSourceDelay
+			targetTimely := targetCorrect && uint64(inclusionDelay) <= s.maxTimelyAttestationTargetDelay
+			headTimely := headCorrect && uint64(inclusionDelay) <= s.maxTimelyAttestationHeadDelay
+			for _, index := range attestation.AggregationIndices {
+				if int(index) >= len(attestations) {
+					// Validator not yet in the validator set.
+					grown := make([]validatorAttestation, int(index)+1)
+					copy(grown, attestations)
+					attestations = grown
+				}
+				validatorAttestation := &attestations[index]
+				if targetCorrect {
+					validatorAttestation.targetCorrect = true
+				}
+				if headCorrect {
+					validatorAttestation.headCorrect = true
+				}
+				if !validatorAttestation.included || inclusionDelay < validatorAttestation.inclusionDelay {
+					validatorAttestation.inclusionDelay = inclusionDelay
+					validatorAttestation.inclusionSlot = attestation.InclusionSlot
+					validatorAttestation.sourceTimely = sourceTimely
+					validatorAttestation.targetTimely = targetTimely
+					validatorAttestation.headTimely = headTimely
+				}
+				validatorAttestation.included = true
+				validatorAttestation.summarize = true
+			}
+		}
+	}
+
+	// Add in any validators that did not attest.
 	for _, validator := range validators {
 		// Confirm active.
 		if validator.ActivationEpoch > epoch || validator.ExitEpoch <= epoch {
 			continue
 		}
-		if _, exists := attestationsIncluded[validator.Index]; !exists {
-			attestationsIncluded[validator.Index] = false
-		}
+		attestations[validator.Index].summarize = true
 	}
-	return attestationsIncluded, attestationsTargetCorrect, attestationsHeadCorrect, attestationsInclusionDelay, attestationsSourceTimely, attestationsTargetTimely, attestationsHeadTimely, nil
+
+	return attestations, nil
 }
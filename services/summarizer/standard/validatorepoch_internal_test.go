@@ -0,0 +1,254 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/testing/mock"
+)
+
+// summaryDB is the chain database functionality used to summarize validator epochs.
+type summaryDB interface {
+	chaindb.Service
+	chaindb.AttestationsProvider
+	chaindb.BlocksProvider
+	chaindb.EpochCompletionsSetter
+	chaindb.ProposerDutiesProvider
+	chaindb.ValidatorEpochSummariesSetter
+}
+
+// recordingSummaryDB serves attestations and proposer duties, and records the
+// validator epoch summaries written to it.
+type recordingSummaryDB struct {
+	summaryDB
+	attestations []*chaindb.Attestation
+	slotRanges   [][2]phase0.Slot
+	summaries    [][]*chaindb.ValidatorEpochSummary
+}
+
+func (d *recordingSummaryDB) AttestationsForSlotRange(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.Attestation, error) {
+	d.slotRanges = append(d.slotRanges, [2]phase0.Slot{minSlot, maxSlot})
+	res := make([]*chaindb.Attestation, 0)
+	for _, attestation := range d.attestations {
+		if attestation.Slot >= minSlot && attestation.Slot < maxSlot {
+			res = append(res, attestation)
+		}
+	}
+	return res, nil
+}
+
+func (d *recordingSummaryDB) ProposerDutiesForSlotRange(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]*chaindb.ProposerDuty, error) {
+	res := make([]*chaindb.ProposerDuty, 0)
+	for slot := minSlot; slot < maxSlot; slot++ {
+		res = append(res, &chaindb.ProposerDuty{Slot: slot, ValidatorIndex: phase0.ValidatorIndex(slot)})
+	}
+	return res, nil
+}
+
+func (d *recordingSummaryDB) CanonicalBlockPresenceForSlotRange(_ context.Context, minSlot phase0.Slot, maxSlot phase0.Slot) ([]bool, error) {
+	return make([]bool, maxSlot-minSlot), nil
+}
+
+func (d *recordingSummaryDB) SetValidatorEpochSummaries(_ context.Context, summaries []*chaindb.ValidatorEpochSummary) error {
+	d.summaries = append(d.summaries, summaries)
+	return nil
+}
+
+// staticValidatorSet is a validator set that does not change.
+type staticValidatorSet struct {
+	validators []*chaindb.Validator
+}
+
+func (v *staticValidatorSet) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	return v.validators, nil
+}
+
+func (v *staticValidatorSet) ValidatorsByPublicKey(_ context.Context, _ []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
+	return nil, nil
+}
+
+func (v *staticValidatorSet) ValidatorsByIndex(_ context.Context, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	return nil, nil
+}
+
+func testAttestation(slot phase0.Slot, inclusionSlot phase0.Slot, canonical bool, targetCorrect bool, headCorrect bool, indices ...phase0.ValidatorIndex) *chaindb.Attestation {
+	return &chaindb.Attestation{
+		Slot:               slot,
+		InclusionSlot:      inclusionSlot,
+		AggregationIndices: indices,
+		Canonical:          &canonical,
+		TargetCorrect:      &targetCorrect,
+		HeadCorrect:        &headCorrect,
+	}
+}
+
+func newSummaryTestService(t *testing.T, validators int) (*Service, *recordingSummaryDB) {
+	t.Helper()
+	ctx := context.Background()
+	log = zerolog.Nop()
+
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now())),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 4, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider([]*phase0.Fork{{}})),
+	)
+	require.NoError(t, err)
+
+	validatorSet := &staticValidatorSet{}
+	for i := 0; i < validators; i++ {
+		validatorSet.validators = append(validatorSet.validators, &chaindb.Validator{
+			Index:     phase0.ValidatorIndex(i),
+			ExitEpoch: 0xffffffffffffffff,
+		})
+	}
+
+	chainDB := &recordingSummaryDB{summaryDB: mockchaindb.New().(summaryDB)}
+	s := &Service{
+		chainDB:                         chainDB,
+		attestationsProvider:            chainDB,
+		blocksProvider:                  chainDB,
+		proposerDutiesProvider:          chainDB,
+		epochCompletionsSetter:          chainDB,
+		validatorSet:                    validatorSet,
+		chainTime:                       chainTime,
+		maxTimelyAttestationSourceDelay: 1,
+		maxTimelyAttestationTargetDelay: 1,
+		maxTimelyAttestationHeadDelay:   1,
+		validatorSummaries:              true,
+		batchSize:                       2,
+	}
+
+	return s, chainDB
+}
+
+func TestAttestationsForEpoch(t *testing.T) {
+	ctx := context.Background()
+
+	s, chainDB := newSummaryTestService(t, 5)
+	// Validator 4 has exited.
+	validators, err := s.validatorSet.Validators(ctx)
+	require.NoError(t, err)
+	validators[4].ExitEpoch = 1
+
+	chainDB.attestations = []*chaindb.Attestation{
+		testAttestation(4, 7, true, true, false, 0, 1),
+		testAttestation(5, 6, true, false, true, 1),
+		testAttestation(6, 7, false, true, true, 2),
+		// An attester beyond the validator set.
+		testAttestation(7, 8, true, false, false, 6),
+	}
+
+	attestations, err := s.attestationsForEpoch(ctx, 1)
+	require.NoError(t, err)
+
+	// Attestations are fetched a slot at a time.
+	require.Equal(t, [][2]phase0.Slot{{4, 5}, {5, 6}, {6, 7}, {7, 8}}, chainDB.slotRanges)
+
+	require.Len(t, attestations, 7)
+	require.Equal(t, validatorAttestation{summarize: true, included: true, targetCorrect: true, inclusionDelay: 3, inclusionSlot: 7}, attestations[0])
+	// Correctness is from any attestation, timeliness from the earliest included.
+	require.Equal(t, validatorAttestation{summarize: true, included: true, targetCorrect: true, headCorrect: true, inclusionDelay: 1, inclusionSlot: 6, sourceTimely: true, headTimely: true}, attestations[1])
+	// Non-canonical attestations are ignored.
+	require.Equal(t, validatorAttestation{summarize: true}, attestations[2])
+	require.Equal(t, validatorAttestation{summarize: true}, attestations[3])
+	// Exited validators are not summarized.
+	require.Equal(t, validatorAttestation{}, attestations[4])
+	require.Equal(t, validatorAttestation{}, attestations[5])
+	require.Equal(t, validatorAttestation{summarize: true, included: true, inclusionDelay: 1, inclusionSlot: 8, sourceTimely: true}, attestations[6])
+}
+
+func TestUpdateValidatorSummariesForEpochBatches(t *testing.T) {
+	ctx := context.Background()
+
+	s, chainDB := newSummaryTestService(t, 5)
+	chainDB.attestations = []*chaindb.Attestation{
+		testAttestation(4, 5, true, true, true, 0, 1, 2),
+	}
+
+	md := &metadata{}
+	require.NoError(t, s.updateValidatorSummariesForEpoch(ctx, md, 1))
+	require.Equal(t, phase0.Epoch(1), md.LastValidatorEpoch)
+
+	// Summaries are written a batch at a time, in validator order.
+	require.Len(t, chainDB.summaries, 3)
+	indices := make([]phase0.ValidatorIndex, 0)
+	for _, batch := range chainDB.summaries {
+		require.LessOrEqual(t, len(batch), 2)
+		for _, summary := range batch {
+			require.Equal(t, phase0.Epoch(1), summary.Epoch)
+			indices = append(indices, summary.Index)
+		}
+	}
+	require.Equal(t, []phase0.ValidatorIndex{0, 1, 2, 3, 4}, indices)
+	require.True(t, chainDB.summaries[0][0].AttestationIncluded)
+	require.NotNil(t, chainDB.summaries[0][0].AttestationInclusionSlot)
+	require.Equal(t, phase0.Slot(5), *chainDB.summaries[0][0].AttestationInclusionSlot)
+	require.Equal(t, 1, chainDB.summaries[2][0].ProposerDuties)
+	require.False(t, chainDB.summaries[1][1].AttestationIncluded)
+}
+
+func TestValidatorLabelEpochSummaries(t *testing.T) {
+	validators := make([]*chaindb.Validator, 4)
+	for i := range validators {
+		validators[i] = &chaindb.Validator{
+			Index:     phase0.ValidatorIndex(i),
+			PublicKey: phase0.BLSPubKey{byte(i)},
+		}
+	}
+	labels := map[phase0.BLSPubKey]string{
+		{0}: "operator b",
+		{1}: "operator a",
+		{2}: "operator b",
+		// Validator 3 is labeled but not summarized.
+		{3}: "operator c",
+	}
+	attestations := []validatorAttestation{
+		{summarize: true, included: true, targetCorrect: true, headCorrect: true, inclusionDelay: 1},
+		{summarize: true},
+		{summarize: true, included: true, targetCorrect: true, inclusionDelay: 3},
+		{},
+	}
+	proposerDuties := map[phase0.ValidatorIndex]int{0: 1, 2: 1}
+	proposals := map[phase0.ValidatorIndex]int{0: 1}
+
+	summaries := validatorLabelEpochSummaries(5, labels, validators, attestations, proposerDuties, proposals)
+	require.Equal(t, []*chaindb.ValidatorLabelEpochSummary{
+		{
+			Label:      "operator a",
+			Epoch:      5,
+			Validators: 1,
+		},
+		{
+			Label:                      "operator b",
+			Epoch:                      5,
+			Validators:                 2,
+			ProposerDuties:             2,
+			ProposalsIncluded:          1,
+			AttestationsIncluded:       2,
+			AttestationsTargetCorrect:  2,
+			AttestationsHeadCorrect:    1,
+			AttestationsInclusionDelay: 4,
+		},
+	}, summaries)
+}
@@ -20,6 +20,8 @@ import (
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // OnBeaconChainHeadUpdated receives beacon chain head updated notifications.
@@ -46,22 +48,27 @@ func (s *Service) OnBeaconChainHeadUpdated(
 
 	md, err := s.getMetadata(ctx)
 	if err != nil {
+		monitorError(err)
 		log.Error().Err(err).Msg("Failed to obtain metadata")
 		return
 	}
 
-	// Due to LatestPeriod being a uint64 we can't tell if we have already
-	// obtained sync committees for period 0.  This is inefficient, but given
-	// that no chains meet this condition we take the hit on efficiency rather
-	// than complicate the logic.
-	if period <= md.LatestPeriod && period != 0 {
+	if s.caughtUp(md, period) {
 		log.Trace().Msg("Already have sync committees for this period")
 		return
 	}
 
+	ctx, span := tracer.Start(ctx, "OnBeaconChainHeadUpdated", trace.WithAttributes(attribute.Int64("period", int64(period))))
+	defer span.End()
+
 	s.catchup(ctx, md)
 }
 
+// onEventsResubscribed catches up with any periods missed while the event stream was down.
+func (s *Service) onEventsResubscribed(ctx context.Context) {
+	s.OnBeaconChainHeadUpdated(ctx, s.chainTime.CurrentSlot())
+}
+
 func (s *Service) updateSyncCommitteeForPeriod(ctx context.Context, period uint64) error {
 	log.Trace().Uint64("period", period).Msg("Updating sync committee")
 
@@ -17,12 +17,17 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
 )
 
 // metadata stored about this service.
 type metadata struct {
 	LatestPeriod uint64 `json:"latest_period"`
+	// ProcessedEpochs are the epochs of the periods for which sync committees
+	// have been processed.
+	ProcessedEpochs util.EpochRanges `json:"processed_epochs,omitempty"`
 }
 
 // metadataKey is the key for the metadata.
@@ -41,9 +46,23 @@ func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	if err := json.Unmarshal(mdJSON, md); err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal metadata")
 	}
+	if md.ProcessedEpochs == nil && md.LatestPeriod > 0 {
+		// Metadata written by older versions of chaind, which processed all
+		// periods up to the latest period.
+		_, lastEpoch := s.periodEpochs(md.LatestPeriod)
+		md.ProcessedEpochs = util.ProcessedFromLatest(lastEpoch, nil)
+	}
 	return md, nil
 }
 
+// setProcessed marks the epochs of a period as processed.
+func (md *metadata) setProcessed(period uint64, firstEpoch phase0.Epoch, lastEpoch phase0.Epoch) {
+	md.ProcessedEpochs.AddRange(firstEpoch, lastEpoch)
+	if period > md.LatestPeriod {
+		md.LatestPeriod = period
+	}
+}
+
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	mdJSON, err := json.Marshal(md)
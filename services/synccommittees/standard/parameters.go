@@ -15,6 +15,7 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	"github.com/rs/zerolog"
@@ -24,13 +25,15 @@ import (
 )
 
 type parameters struct {
-	logLevel     zerolog.Level
-	monitor      metrics.Service
-	eth2Client   eth2client.Service
-	chainDB      chaindb.Service
-	chainTime    chaintime.Service
-	specProvider eth2client.SpecProvider
-	startPeriod  int64
+	logLevel           zerolog.Level
+	logLevelSampler    zerolog.Sampler
+	monitor            metrics.Service
+	eth2Client         eth2client.Service
+	chainDB            chaindb.Service
+	chainTime          chaintime.Service
+	specProvider       eth2client.SpecProvider
+	startPeriod        int64
+	eventsStallTimeout time.Duration
 }
 
 // Parameter is the interface for service parameters.
@@ -51,6 +54,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -93,6 +104,14 @@ func WithStartPeriod(startPeriod int64) Parameter {
 	})
 }
 
+// WithEventsStallTimeout sets the time without events after which the event
+// stream is considered lost and is re-established.  0 disables the check.
+func WithEventsStallTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eventsStallTimeout = timeout
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -15,14 +15,18 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/semaphore"
 )
 
@@ -35,11 +39,17 @@ type Service struct {
 	chainTime                    chaintime.Service
 	activitySem                  *semaphore.Weighted
 	epochsPerSyncCommitteePeriod uint64
+	// firstPeriod is the first period for which sync committees are processed.
+	firstPeriod        uint64
+	eventsStallTimeout time.Duration
 }
 
 // module-wide log.
 var log zerolog.Logger
 
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/synccommittees/standard")
+
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -49,6 +59,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "synccommittees").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -84,6 +97,7 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		syncCommitteesSetter:         syncCommitteesSetter,
 		chainTime:                    parameters.chainTime,
 		activitySem:                  semaphore.NewWeighted(1),
+		eventsStallTimeout:           parameters.eventsStallTimeout,
 		epochsPerSyncCommitteePeriod: epochsPerSyncCommitteePeriod,
 	}
 
@@ -99,67 +113,88 @@ func (s *Service) updateAfterRestart(ctx context.Context, startPeriod int64) {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
+	s.firstPeriod = s.chainTime.AltairInitialSyncCommitteePeriod()
 	if startPeriod >= 0 {
-		// Explicit requirement to start at a given epoch.
-		// N.B. start period is latest period + 1.
-		md.LatestPeriod = uint64(startPeriod) - 1
-	}
-	if s.chainTime.AltairInitialSyncCommitteePeriod() > md.LatestPeriod {
-		md.LatestPeriod = s.chainTime.AltairInitialSyncCommitteePeriod()
-		// Decrement if possible.
-		if md.LatestPeriod > 0 {
-			md.LatestPeriod--
+		// Explicit requirement to start at a given period; anything already
+		// processed from this period onwards is processed again.
+		if uint64(startPeriod) > s.firstPeriod {
+			s.firstPeriod = uint64(startPeriod)
+		}
+		firstEpoch, _ := s.periodEpochs(s.firstPeriod)
+		md.ProcessedEpochs.RemoveFrom(firstEpoch)
+		md.LatestPeriod = 0
+		if highestEpoch, exists := md.ProcessedEpochs.Highest(); exists {
+			md.LatestPeriod = s.chainTime.EpochToSyncCommitteePeriod(highestEpoch)
+		}
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			return s.setMetadata(ctx, md)
+		}); err != nil {
+			log.Fatal().Err(err).Msg("Failed to set metadata with start period")
+		}
+	} else if lowestEpoch, exists := md.ProcessedEpochs.Lowest(); exists {
+		// Fill in any gaps from the first period that was processed.
+		if lowestPeriod := s.chainTime.EpochToSyncCommitteePeriod(lowestEpoch); lowestPeriod > s.firstPeriod {
+			s.firstPeriod = lowestPeriod
 		}
 	}
 
-	log.Info().Uint64("period", md.LatestPeriod).Msg("Catching up from period")
+	log.Info().Uint64("period", s.firstPeriod).Msg("Catching up from period")
 	s.catchup(ctx, md)
 	log.Info().Msg("Caught up")
 
 	// Set up the handler for new chain head updates.
-	if err := s.eventsProvider.Events(ctx, []string{"head"}, func(event *api.Event) {
+	if err := util.SubscribeEvents(ctx, log, s.eventsProvider, []string{"head"}, s.eventsStallTimeout, func(event *api.Event) {
 		eventData := event.Data.(*api.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot)
-	}); err != nil {
+	}, s.onEventsResubscribed); err != nil {
 		log.Fatal().Err(err).Msg("Failed to add sync chain head updated handler")
 	}
 }
 
+// catchup processes the periods from the first period to the current period that
+// have not yet been processed.  Periods that fail are left unprocessed, to be
+// processed again on the next catchup.
 func (s *Service) catchup(ctx context.Context, md *metadata) {
-	// If latest period is 0 we assume it means 'no latest period' rather than
-	// 'latest period is 0', so keep period at 0.
-	period := md.LatestPeriod
-	if period > 0 {
-		// Start at the next period.
-		period++
-	}
-	for ; period <= s.chainTime.CurrentSyncCommitteePeriod(); period++ {
+	for period := s.firstPeriod; period <= s.chainTime.CurrentSyncCommitteePeriod(); period++ {
+		firstEpoch, lastEpoch := s.periodEpochs(period)
+		if md.ProcessedEpochs.Contains(firstEpoch) {
+			continue
+		}
 		log := log.With().Uint64("period", period).Logger()
 		// Each update goes in to its own transaction, to make the data available sooner.
-		ctx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to begin transaction on update after restart")
-			return
-		}
-
-		if err := s.updateSyncCommitteeForPeriod(ctx, period); err != nil {
-			log.Warn().Err(err).Msg("Failed to update sync committee")
-			cancel()
-			return
-		}
-
-		md.LatestPeriod = period
-		if err := s.setMetadata(ctx, md); err != nil {
-			log.Error().Err(err).Msg("Failed to set metadata")
-			cancel()
-			return
-		}
-
-		if err := s.chainDB.CommitTx(ctx); err != nil {
-			log.Error().Err(err).Msg("Failed to commit transaction")
-			cancel()
-			return
+		// The metadata is only updated once the transaction has been committed, as
+		// catchup carries on past a period that fails.
+		var updated metadata
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			if err := s.updateSyncCommitteeForPeriod(ctx, period); err != nil {
+				return errors.Wrap(err, "failed to update sync committee")
+			}
+			updated = *md
+			updated.setProcessed(period, firstEpoch, lastEpoch)
+			if err := s.setMetadata(ctx, &updated); err != nil {
+				return errors.Wrap(err, "failed to set metadata")
+			}
+			return nil
+		}); err != nil {
+			monitorError(err)
+			log.Warn().Err(err).Msg("Failed to update sync committee; will retry later")
+			continue
 		}
+		*md = updated
 		log.Trace().Msg("Added sync committee")
 	}
 }
+
+// caughtUp returns true if sync committees have been processed for all periods
+// from the first period up to the given period.
+func (s *Service) caughtUp(md *metadata, period uint64) bool {
+	firstEpoch, _ := s.periodEpochs(s.firstPeriod)
+	_, lastEpoch := s.periodEpochs(period)
+	return len(md.ProcessedEpochs.Gaps(firstEpoch, lastEpoch)) == 0
+}
+
+// periodEpochs returns the first and last epochs of a sync committee period.
+func (s *Service) periodEpochs(period uint64) (phase0.Epoch, phase0.Epoch) {
+	firstEpoch := s.chainTime.FirstEpochOfSyncPeriod(period)
+	return firstEpoch, firstEpoch + phase0.Epoch(s.epochsPerSyncCommitteePeriod) - 1
+}
@@ -0,0 +1,287 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlphttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Exporter is a span exporter that sends spans to an OpenTelemetry collector
+// using the JSON encoding of the OTLP/HTTP protocol.
+type Exporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new exporter.
+func New(_ context.Context, params ...Parameter) (*Exporter, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "tracing").Str("impl", "otlphttp").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	return &Exporter{
+		endpoint: parameters.endpoint,
+		headers:  parameters.headers,
+		client: &http.Client{
+			Timeout: parameters.timeout,
+		},
+	}, nil
+}
+
+// ExportSpans sends the given spans to the collector.
+func (e *Exporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(encodeSpans(spans))
+	if err != nil {
+		return errors.Wrap(err, "failed to encode spans")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "failed to send spans")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("collector returned %s: %s", resp.Status, string(data))
+	}
+	log.Trace().Int("spans", len(spans)).Msg("Exported spans")
+
+	return nil
+}
+
+// Shutdown shuts down the exporter.
+func (e *Exporter) Shutdown(_ context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+type exportRequest struct {
+	ResourceSpans []*resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   *resource     `json:"resource"`
+	ScopeSpans []*scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []*keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope *scope  `json:"scope"`
+	Spans []*span `json:"spans"`
+}
+
+type scope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type span struct {
+	TraceID           string      `json:"traceId"`
+	SpanID            string      `json:"spanId"`
+	ParentSpanID      string      `json:"parentSpanId,omitempty"`
+	Name              string      `json:"name"`
+	Kind              int         `json:"kind"`
+	StartTimeUnixNano string      `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string      `json:"endTimeUnixNano"`
+	Attributes        []*keyValue `json:"attributes,omitempty"`
+	Events            []*event    `json:"events,omitempty"`
+	Status            *status     `json:"status,omitempty"`
+}
+
+type event struct {
+	TimeUnixNano string      `json:"timeUnixNano"`
+	Name         string      `json:"name"`
+	Attributes   []*keyValue `json:"attributes,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string    `json:"key"`
+	Value *anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string     `json:"stringValue,omitempty"`
+	BoolValue   *bool       `json:"boolValue,omitempty"`
+	IntValue    *string     `json:"intValue,omitempty"`
+	DoubleValue *float64    `json:"doubleValue,omitempty"`
+	ArrayValue  *arrayValue `json:"arrayValue,omitempty"`
+}
+
+type arrayValue struct {
+	Values []*anyValue `json:"values"`
+}
+
+// encodeSpans encodes spans as an OTLP export request, grouped by resource
+// and instrumentation scope.
+func encodeSpans(spans []sdktrace.ReadOnlySpan) *exportRequest {
+	req := &exportRequest{}
+	resources := make(map[attribute.Distinct]*resourceSpans)
+	scopes := make(map[attribute.Distinct]map[string]*scopeSpans)
+	for _, s := range spans {
+		key := s.Resource().Equivalent()
+		rs, exists := resources[key]
+		if !exists {
+			rs = &resourceSpans{
+				Resource: &resource{Attributes: encodeAttributes(s.Resource().Attributes())},
+			}
+			resources[key] = rs
+			scopes[key] = make(map[string]*scopeSpans)
+			req.ResourceSpans = append(req.ResourceSpans, rs)
+		}
+		instrumentationScope := s.InstrumentationScope()
+		ss, exists := scopes[key][instrumentationScope.Name]
+		if !exists {
+			ss = &scopeSpans{
+				Scope: &scope{
+					Name:    instrumentationScope.Name,
+					Version: instrumentationScope.Version,
+				},
+			}
+			scopes[key][instrumentationScope.Name] = ss
+			rs.ScopeSpans = append(rs.ScopeSpans, ss)
+		}
+		ss.Spans = append(ss.Spans, encodeSpan(s))
+	}
+
+	return req
+}
+
+func encodeSpan(s sdktrace.ReadOnlySpan) *span {
+	res := &span{
+		TraceID:           s.SpanContext().TraceID().String(),
+		SpanID:            s.SpanContext().SpanID().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()),
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+		Attributes:        encodeAttributes(s.Attributes()),
+	}
+	if s.Parent().HasSpanID() {
+		res.ParentSpanID = s.Parent().SpanID().String()
+	}
+	for _, e := range s.Events() {
+		res.Events = append(res.Events, &event{
+			TimeUnixNano: strconv.FormatInt(e.Time.UnixNano(), 10),
+			Name:         e.Name,
+			Attributes:   encodeAttributes(e.Attributes),
+		})
+	}
+	// OTLP status codes are ordered differently from those of the API.
+	switch s.Status().Code {
+	case codes.Ok:
+		res.Status = &status{Code: 1}
+	case codes.Error:
+		res.Status = &status{Code: 2, Message: s.Status().Description}
+	}
+
+	return res
+}
+
+func encodeAttributes(attributes []attribute.KeyValue) []*keyValue {
+	res := make([]*keyValue, 0, len(attributes))
+	for _, kv := range attributes {
+		res = append(res, &keyValue{
+			Key:   string(kv.Key),
+			Value: encodeValue(kv.Value),
+		})
+	}
+
+	return res
+}
+
+func encodeValue(value attribute.Value) *anyValue {
+	switch value.Type() {
+	case attribute.BOOL:
+		v := value.AsBool()
+		return &anyValue{BoolValue: &v}
+	case attribute.INT64:
+		v := strconv.FormatInt(value.AsInt64(), 10)
+		return &anyValue{IntValue: &v}
+	case attribute.FLOAT64:
+		v := value.AsFloat64()
+		return &anyValue{DoubleValue: &v}
+	case attribute.BOOLSLICE:
+		values := make([]*anyValue, 0)
+		for _, b := range value.AsBoolSlice() {
+			values = append(values, encodeValue(attribute.BoolValue(b)))
+		}
+		return &anyValue{ArrayValue: &arrayValue{Values: values}}
+	case attribute.INT64SLICE:
+		values := make([]*anyValue, 0)
+		for _, i := range value.AsInt64Slice() {
+			values = append(values, encodeValue(attribute.Int64Value(i)))
+		}
+		return &anyValue{ArrayValue: &arrayValue{Values: values}}
+	case attribute.FLOAT64SLICE:
+		values := make([]*anyValue, 0)
+		for _, f := range value.AsFloat64Slice() {
+			values = append(values, encodeValue(attribute.Float64Value(f)))
+		}
+		return &anyValue{ArrayValue: &arrayValue{Values: values}}
+	case attribute.STRINGSLICE:
+		values := make([]*anyValue, 0)
+		for _, s := range value.AsStringSlice() {
+			values = append(values, encodeValue(attribute.StringValue(s)))
+		}
+		return &anyValue{ArrayValue: &arrayValue{Values: values}}
+	default:
+		v := value.Emit()
+		return &anyValue{StringValue: &v}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlphttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/tracing/otlphttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []otlphttp.Parameter
+		err    string
+	}{
+		{
+			name: "EndpointMissing",
+			params: []otlphttp.Parameter{
+				otlphttp.WithLogLevel(zerolog.Disabled),
+			},
+			err: "problem with parameters: no endpoint specified",
+		},
+		{
+			name: "TimeoutZero",
+			params: []otlphttp.Parameter{
+				otlphttp.WithLogLevel(zerolog.Disabled),
+				otlphttp.WithEndpoint("http://localhost:4318/v1/traces"),
+				otlphttp.WithTimeout(0),
+			},
+			err: "problem with parameters: timeout must be greater than 0",
+		},
+		{
+			name: "Good",
+			params: []otlphttp.Parameter{
+				otlphttp.WithLogLevel(zerolog.Disabled),
+				otlphttp.WithEndpoint("http://localhost:4318/v1/traces"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := otlphttp.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+
+	requests := make(chan map[string]interface{}, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.Equal(t, "secret", r.Header.Get("Authorization"))
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests <- body
+	}))
+	defer server.Close()
+
+	exporter, err := otlphttp.New(ctx,
+		otlphttp.WithLogLevel(zerolog.Disabled),
+		otlphttp.WithEndpoint(server.URL),
+		otlphttp.WithHeaders(map[string]string{"Authorization": "secret"}),
+		otlphttp.WithTimeout(time.Second),
+	)
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSyncer(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", "chaind"))),
+	)
+	defer func() {
+		require.NoError(t, provider.Shutdown(ctx))
+	}()
+	tracer := provider.Tracer("test")
+
+	parentCtx, parent := tracer.Start(ctx, "parent")
+	_, child := tracer.Start(parentCtx, "child")
+	child.SetAttributes(attribute.Int64("slot", 12345))
+	child.SetStatus(codes.Error, "failed")
+	child.RecordError(errors.New("bad"))
+	child.End()
+
+	body := <-requests
+	resourceSpans := body["resourceSpans"].([]interface{})
+	require.Len(t, resourceSpans, 1)
+	rs := resourceSpans[0].(map[string]interface{})
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "chaind"}},
+	}, rs["resource"].(map[string]interface{})["attributes"])
+	ss := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "test", ss["scope"].(map[string]interface{})["name"])
+	span := ss["spans"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "child", span["name"])
+	require.Equal(t, parent.SpanContext().TraceID().String(), span["traceId"])
+	require.Equal(t, parent.SpanContext().SpanID().String(), span["parentSpanId"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"key": "slot", "value": map[string]interface{}{"intValue": "12345"}},
+	}, span["attributes"])
+	require.Equal(t, map[string]interface{}{"code": float64(2), "message": "failed"}, span["status"])
+	require.Len(t, span["events"], 1)
+
+	parent.End()
+	body = <-requests
+	span = body["resourceSpans"].([]interface{})[0].(map[string]interface{})["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "parent", span["name"])
+	require.NotContains(t, span, "parentSpanId")
+	require.NotContains(t, span, "status")
+}
+
+func TestExportFailure(t *testing.T) {
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter, err := otlphttp.New(ctx,
+		otlphttp.WithLogLevel(zerolog.Disabled),
+		otlphttp.WithEndpoint(server.URL),
+	)
+	require.NoError(t, err)
+
+	provider := sdktrace.NewTracerProvider()
+	_, span := provider.Tracer("test").Start(ctx, "span")
+	span.End()
+	require.Error(t, exporter.ExportSpans(ctx, []sdktrace.ReadOnlySpan{span.(sdktrace.ReadOnlySpan)}))
+}
@@ -0,0 +1,181 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
+)
+
+// backfillRetryInterval is the time to wait before refetching validators that failed to backfill.
+var backfillRetryInterval = time.Minute
+
+// followFromHead returns true if the service should follow the chain from the
+// current epoch and backfill balances for earlier epochs, rather than catch up in
+// order.  This is the case if backfill is enabled, if a backfill was in progress,
+// or if balances are at least the head-first distance behind the chain.
+func (s *Service) followFromHead(md *metadata) bool {
+	if s.backfill {
+		return true
+	}
+	if latestEpoch, exists := md.BalancesEpochs.Highest(); exists &&
+		len(md.BalancesEpochs.Gaps(s.balancesFirstEpoch, latestEpoch)) > 0 {
+		log.Info().Uint64("latest_epoch", uint64(latestEpoch)).Msg("Earlier balances not yet processed; following the chain and backfilling earlier balances")
+		return true
+	}
+	if s.headFirstDistance == 0 {
+		return false
+	}
+	if s.chainTime.CurrentEpoch() < md.LatestBalancesEpoch+s.headFirstDistance {
+		return false
+	}
+	log.Info().Uint64("latest_epoch", uint64(md.LatestBalancesEpoch)).Msg("Far behind the chain; following the chain and backfilling earlier balances")
+
+	return true
+}
+
+// balancesCatchupEpoch returns the first epoch for which balances are processed
+// when following the chain.
+func (s *Service) balancesCatchupEpoch() phase0.Epoch {
+	if s.balancesFollowEpoch > s.balancesFirstEpoch {
+		return s.balancesFollowEpoch
+	}
+	return s.balancesFirstEpoch
+}
+
+// backfillBalances processes the epochs before the epoch from which the chain is
+// followed for which balances have not yet been processed, most recent first, so
+// that recent data is available as soon as possible.  Epochs that fail to store
+// are left unprocessed, and tried again once the remaining epochs have been
+// backfilled.
+func (s *Service) backfillBalances(ctx context.Context) {
+	if s.balancesFollowEpoch == 0 {
+		return
+	}
+	for {
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			monitorError(err)
+			log.Error().Err(err).Msg("Failed to obtain metadata for backfill")
+			return
+		}
+		gaps := md.BalancesEpochs.Gaps(s.balancesFirstEpoch, s.balancesFollowEpoch-1)
+		if len(gaps) == 0 {
+			log.Info().Msg("Backfill complete")
+			return
+		}
+
+		log.Info().Uint64("start_epoch", uint64(gaps[0].Start)).Uint64("end_epoch", uint64(gaps[len(gaps)-1].End)).Int("gaps", len(gaps)).Msg("Backfilling validator balances")
+		failed, err := s.backfillGaps(ctx, gaps)
+		if err != nil {
+			monitorError(err)
+			log.Error().Err(err).Msg("Failed to record backfilled validator balances")
+			return
+		}
+		if failed > 0 {
+			// Epochs failed to store; wait before trying again.
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backfillRetryInterval):
+			}
+		}
+	}
+}
+
+// backfillGaps backfills balances for the epochs in the given gaps, most recent
+// first.  It returns the number of epochs that failed to store.
+func (s *Service) backfillGaps(ctx context.Context, gaps util.EpochRanges) (int, error) {
+	failed := 0
+	for i := len(gaps) - 1; i >= 0; i-- {
+		gap := gaps[i]
+		for end := gap.End; ; {
+			// Fetch a batch of epochs at a time, to spread the requests across beacon nodes.
+			start := gap.Start
+			if uint64(end-start)+1 > uint64(len(s.catchupClients)) {
+				start = end - phase0.Epoch(len(s.catchupClients)) + 1
+			}
+			if err := s.throttle.Wait(ctx); err != nil {
+				return failed, err
+			}
+			batch := s.storeBalancesBatch(ctx, start, end, false)
+			if err := s.recordBackfillBatch(ctx, batch); err != nil {
+				return failed, err
+			}
+			for _, stored := range batch {
+				if stored.err != nil {
+					failed++
+				}
+			}
+			if start == gap.Start {
+				break
+			}
+			end = start - 1
+		}
+	}
+
+	return failed, nil
+}
+
+// recordBackfillBatch records a batch of backfilled validator balances in the metadata.
+// Fetching and storing take place outside of the activity semaphore, so that
+// following the chain is held up only for as long as it takes to record the batch.
+func (s *Service) recordBackfillBatch(ctx context.Context, batch []*storedBalances) error {
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	// Metadata is obtained afresh, as it may have been updated while following the chain.
+	md, err := s.getMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain metadata")
+	}
+
+	return s.recordBalances(ctx, md, batch)
+}
+
+// recordBalances marks the epochs in a batch for which balances were stored as
+// processed.  Epochs that failed to store are left unprocessed, to be processed
+// again later.
+// This requires the activity semaphore to be held.
+func (s *Service) recordBalances(ctx context.Context, md *metadata, batch []*storedBalances) error {
+	epochs := make([]phase0.Epoch, 0, len(batch))
+	for _, stored := range batch {
+		if stored.err != nil {
+			log.Warn().Uint64("epoch", uint64(stored.epoch)).Err(stored.err).Msg("Failed to store validator balances; will retry later")
+			continue
+		}
+		epochs = append(epochs, stored.epoch)
+	}
+	if len(epochs) == 0 {
+		return nil
+	}
+
+	md.setBalancesProcessed(epochs...)
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		return s.setMetadata(ctx, md)
+	}); err != nil {
+		return errors.Wrap(err, "failed to set metadata for validator balances")
+	}
+	for _, epoch := range epochs {
+		monitorBalancesEpochProcessed(epoch)
+	}
+
+	return nil
+}
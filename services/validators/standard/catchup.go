@@ -0,0 +1,167 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// fetchedValidators are the validators fetched for an epoch.
+type fetchedValidators struct {
+	epoch      phase0.Epoch
+	validators map[phase0.ValidatorIndex]*api.Validator
+	err        error
+}
+
+// storedBalances is the result of storing the validator balances for an epoch.
+type storedBalances struct {
+	epoch phase0.Epoch
+	err   error
+}
+
+// storeBalancesBatch fetches and stores validator balances for up to one epoch
+// per catchup client, starting at startEpoch and going no further than endEpoch.
+// Validator state is large, so fetching in parallel from separate beacon nodes is
+// significantly faster than fetching from a single beacon node.  Each epoch is
+// stored in its own transaction along with its completion marker; the metadata is
+// not updated, as that is left to the caller once the batch has been stored.  If
+// replace is true any existing balances for each epoch are deleted first.
+func (s *Service) storeBalancesBatch(ctx context.Context,
+	startEpoch phase0.Epoch,
+	endEpoch phase0.Epoch,
+	replace bool,
+) []*storedBalances {
+	batchSize := len(s.catchupClients)
+	if uint64(endEpoch-startEpoch)+1 < uint64(batchSize) {
+		batchSize = int(endEpoch-startEpoch) + 1
+	}
+
+	batch := make([]*storedBalances, batchSize)
+	var wg sync.WaitGroup
+	for i := 0; i < batchSize; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			epoch := startEpoch + phase0.Epoch(i)
+			client := s.catchupClients[i]
+			err := util.RunTx(util.WithEpochHeadDistance(ctx, s.chainTime, epoch), s.chainDB, func(ctx context.Context) error {
+				if replace {
+					if err := s.rangeDeleter.DeleteValidatorBalances(ctx, epoch, epoch); err != nil {
+						return errors.Wrap(err, "failed to delete validator balances")
+					}
+				}
+				provenance, err := s.storeEpochBalances(ctx, client, epoch)
+				if err != nil {
+					return err
+				}
+				if err := s.setBalancesProvenance(ctx, epoch, provenance); err != nil {
+					return err
+				}
+				if err := s.epochCompletionsSetter.SetEpochComplete(ctx, balancesCompletionKey, epoch); err != nil {
+					return errors.Wrap(err, "failed to set epoch completion for validator balances")
+				}
+				return nil
+			})
+			batch[i] = &storedBalances{
+				epoch: epoch,
+				err:   err,
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	return batch
+}
+
+// storeEpochBalances fetches the balances of validators at the start of an epoch
+// from the given client a batch of validators at a time, storing each batch as
+// it is fetched, so that neither the validators nor their database balances for
+// the whole epoch are held in memory at once.  It returns the provenance of the
+// balances.
+// This must be called within a transaction.
+func (s *Service) storeEpochBalances(ctx context.Context,
+	client eth2client.Service,
+	epoch phase0.Epoch,
+) (
+	*chaindb.Provenance,
+	error,
+) {
+	var provenance *chaindb.Provenance
+	indices := make([]phase0.ValidatorIndex, s.batchSize)
+	for start := phase0.ValidatorIndex(0); ; start += phase0.ValidatorIndex(s.batchSize) {
+		for i := range indices {
+			indices[i] = start + phase0.ValidatorIndex(i)
+		}
+		validators, supplier, err := s.fetchValidatorsChunk(ctx, client, epoch, indices)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain validators for validator balances")
+		}
+		if provenance == nil {
+			provenance = util.Provenance(ctx, supplier)
+		}
+		if err := s.setValidatorBalances(ctx, epoch, validators); err != nil {
+			return nil, errors.Wrap(err, "failed to set validator balances")
+		}
+		if len(validators) < len(indices) {
+			// Fewer validators than requested, so this was the last batch.
+			break
+		}
+	}
+
+	return provenance, nil
+}
+
+// fetchValidatorsChunk fetches the given validators at the start of an epoch
+// from the given client, retrying as per the retry policy and falling back to the
+// main client if the given client is a catchup client that fails.  It returns the
+// client that supplied the validators.
+func (s *Service) fetchValidatorsChunk(ctx context.Context,
+	client eth2client.Service,
+	epoch phase0.Epoch,
+	indices []phase0.ValidatorIndex,
+) (
+	map[phase0.ValidatorIndex]*api.Validator,
+	eth2client.Service,
+	error,
+) {
+	stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
+	var validators map[phase0.ValidatorIndex]*api.Validator
+	supplier := client
+	err := s.retryPolicy.Do(ctx, func() error {
+		var err error
+		supplier = client
+		log.Trace().Uint64("epoch", uint64(epoch)).Uint64("first_index", uint64(indices[0])).Str("address", client.Address()).Msg("Fetching validators")
+		validators, err = client.(eth2client.ValidatorsProvider).Validators(ctx, stateID, indices)
+		if err != nil && client != s.eth2Client {
+			log.Debug().Uint64("epoch", uint64(epoch)).Str("address", client.Address()).Err(err).Msg("Failed to fetch validators from catchup client; trying main client")
+			supplier = s.eth2Client
+			validators, err = s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, stateID, indices)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return validators, supplier, nil
+}
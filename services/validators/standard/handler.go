@@ -14,13 +14,17 @@
 package standard
 
 import (
+	"bytes"
 	"context"
-	"fmt"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // OnBeaconChainHeadUpdated receives beacon chain head updated notifications.
@@ -49,6 +53,8 @@ func (s *Service) OnBeaconChainHeadUpdated(
 	}
 
 	log.Trace().Msg("Handling epoch transition")
+	ctx, span := tracer.Start(ctx, "OnBeaconChainHeadUpdated", trace.WithAttributes(attribute.Int64("epoch", int64(epoch))))
+	defer span.End()
 
 	md, err := s.getMetadata(ctx)
 	if err != nil {
@@ -56,59 +62,133 @@ func (s *Service) OnBeaconChainHeadUpdated(
 		log.Fatal().Err(err).Msg("Failed to obtain metadata")
 	}
 
-	if err := s.onEpochTransitionValidators(ctx, md, epoch); err != nil {
-		log.Warn().Err(err).Msg("Failed to update validators")
-	}
-	if err := s.onEpochTransitionValidatorBalances(ctx, md, epoch); err != nil {
-		log.Warn().Err(err).Msg("Failed to update validators")
-	}
+	s.updateEpoch(ctx, md, epoch)
 	s.activitySem.Release(1)
 
 	monitorEpochProcessed(epoch)
 	log.Trace().Msg("Finished handling epoch transition")
 }
 
+// onEventsResubscribed catches up with any epochs missed while the event stream was down.
+func (s *Service) onEventsResubscribed(ctx context.Context) {
+	s.OnBeaconChainHeadUpdated(ctx, s.chainTime.CurrentSlot(), phase0.Root{}, phase0.Root{}, true)
+}
+
+// updateEpoch updates the validators and validator balances following a transition
+// to the given epoch.  The validators at head and the validator balances are
+// independent reads from the beacon node, so the validators are fetched in parallel
+// with the balances for the transitioned epoch.  Any earlier balances are caught up
+// first, so that the validators at head are not held in memory while catching up.
+func (s *Service) updateEpoch(ctx context.Context, md *metadata, epoch phase0.Epoch) {
+	balancesCaughtUp := true
+	if epoch > 0 {
+		if err := s.onEpochTransitionValidatorBalances(ctx, md, epoch-1); err != nil {
+			monitorError(err)
+			log.Warn().Err(err).Msg("Failed to catch up validator balances")
+			balancesCaughtUp = false
+		}
+	}
+
+	headValidators := make(chan *fetchedValidators, 1)
+	go func() {
+		// We always fetch the latest validator information regardless of epoch.
+		validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, "head", nil)
+		headValidators <- &fetchedValidators{
+			epoch:      epoch,
+			validators: validators,
+			err:        err,
+		}
+	}()
+
+	if balancesCaughtUp {
+		if err := s.onEpochTransitionValidatorBalances(ctx, md, epoch); err != nil {
+			monitorError(err)
+			log.Warn().Err(err).Msg("Failed to update validator balances")
+		}
+	}
+
+	fetched := <-headValidators
+	if fetched.err != nil {
+		monitorError(fetched.err)
+		log.Warn().Err(fetched.err).Msg("Failed to obtain validators")
+		return
+	}
+	if err := s.onEpochTransitionValidators(ctx, md, epoch, fetched.validators); err != nil {
+		monitorError(err)
+		log.Warn().Err(err).Msg("Failed to update validators")
+	}
+}
+
 func (s *Service) onEpochTransitionValidators(ctx context.Context,
 	md *metadata,
 	transitionedEpoch phase0.Epoch,
+	validators map[phase0.ValidatorIndex]*api.Validator,
 ) error {
-	// We always fetch the latest validator information regardless of epoch.
-	validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, "head", nil)
-	if err != nil {
-		return errors.Wrap(err, "failed to obtain validators")
-	}
+	// Only validators that have changed since they were last written are written,
+	// apart from periodic writes of the full validator set in case the database
+	// has been changed by something other than this module.
+	fullUpdate := !s.fullUpdated ||
+		transitionedEpoch < s.lastFullUpdateEpoch ||
+		transitionedEpoch >= s.lastFullUpdateEpoch+s.fullUpdateInterval
 
-	ctx, cancel, err := s.chainDB.BeginTx(ctx)
-	if err != nil {
-		return errors.Wrap(err, "failed to begin transaction for validators")
+	// Changes are found against the validators as stored in the database, as
+	// supplied by the validator set rather than held by this module.
+	var storedValidators map[phase0.ValidatorIndex]*chaindb.Validator
+	if !fullUpdate {
+		stored, err := s.validatorSet.Validators(ctx)
+		if err != nil {
+			log.Debug().Err(err).Msg("Failed to obtain stored validators; writing full validator set")
+			fullUpdate = true
+		} else {
+			storedValidators = make(map[phase0.ValidatorIndex]*chaindb.Validator, len(stored))
+			for _, validator := range stored {
+				storedValidators[validator.Index] = validator
+			}
+		}
 	}
-	for index, validator := range validators {
-		dbValidator := &chaindb.Validator{
-			PublicKey:                  validator.Validator.PublicKey,
-			Index:                      index,
-			EffectiveBalance:           validator.Validator.EffectiveBalance,
-			Slashed:                    validator.Validator.Slashed,
-			ActivationEligibilityEpoch: validator.Validator.ActivationEligibilityEpoch,
-			ActivationEpoch:            validator.Validator.ActivationEpoch,
-			ExitEpoch:                  validator.Validator.ExitEpoch,
-			WithdrawableEpoch:          validator.Validator.WithdrawableEpoch,
+
+	updated := 0
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		updated = 0
+		for index, validator := range validators {
+			dbValidator := &chaindb.Validator{
+				PublicKey:                  validator.Validator.PublicKey,
+				Index:                      index,
+				EffectiveBalance:           validator.Validator.EffectiveBalance,
+				Slashed:                    validator.Validator.Slashed,
+				ActivationEligibilityEpoch: validator.Validator.ActivationEligibilityEpoch,
+				ActivationEpoch:            validator.Validator.ActivationEpoch,
+				ExitEpoch:                  validator.Validator.ExitEpoch,
+				WithdrawableEpoch:          validator.Validator.WithdrawableEpoch,
+				WithdrawalCredentials:      validator.Validator.WithdrawalCredentials,
+			}
+			if !fullUpdate && validatorUnchanged(storedValidators[index], dbValidator) {
+				continue
+			}
+			if err := s.validatorsSetter.SetValidator(ctx, dbValidator); err != nil {
+				return errors.Wrap(err, "failed to set validator")
+			}
+			updated++
 		}
-		if err := s.validatorsSetter.SetValidator(ctx, dbValidator); err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to set validator")
+		md.LatestEpoch = transitionedEpoch
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata for validators")
 		}
+		return nil
+	}); err != nil {
+		return err
 	}
-	md.LatestEpoch = transitionedEpoch
-	if err := s.setMetadata(ctx, md); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set metadata for validators")
-	}
-	if err := s.chainDB.CommitTx(ctx); err != nil {
-		cancel()
-		return errors.Wrap(err, "failed to set commit transaction for validators")
+	if fullUpdate {
+		s.fullUpdated = true
+		s.lastFullUpdateEpoch = transitionedEpoch
 	}
+	log.Trace().Uint64("epoch", uint64(transitionedEpoch)).Bool("full", fullUpdate).Int("updated", updated).Msg("Updated validators")
 	monitorEpochProcessed(transitionedEpoch)
 
+	for _, validatorHandler := range s.validatorHandlers {
+		validatorHandler.OnValidatorsUpdated(ctx, transitionedEpoch)
+	}
+
 	return nil
 }
 
@@ -120,64 +200,82 @@ func (s *Service) onEpochTransitionValidatorBalances(ctx context.Context,
 		return nil
 	}
 
-	// Do not repeat the latest epoch unless it is epoch 0, as that could be the first
-	// time that we process this epoch.
-	firstEpoch := md.LatestBalancesEpoch
-	if firstEpoch > 0 {
-		firstEpoch++
-	}
-	for epoch := firstEpoch; epoch <= transitionedEpoch; epoch++ {
-		log := log.With().Uint64("epoch", uint64(epoch)).Logger()
-		stateID := fmt.Sprintf("%d", s.chainTime.FirstSlotOfEpoch(epoch))
-		log.Trace().Uint64("slot", uint64(s.chainTime.FirstSlotOfEpoch(epoch))).Msg("Fetching validators")
-		validators, err := s.eth2Client.(eth2client.ValidatorsProvider).Validators(ctx, stateID, nil)
-		if err != nil {
-			return errors.Wrap(err, "failed to obtain validators for validator balances")
-		}
-
-		dbCtx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			return errors.Wrap(err, "failed to begin transaction for validator balances")
-		}
-		if s.balances {
-			dbValidatorBalances := make([]*chaindb.ValidatorBalance, 0, len(validators))
-			for index, validator := range validators {
-				dbValidatorBalances = append(dbValidatorBalances, &chaindb.ValidatorBalance{
-					Index:            index,
-					Epoch:            epoch,
-					Balance:          validator.Balance,
-					EffectiveBalance: validator.Validator.EffectiveBalance,
-				})
+	// Epochs that fail to store are left unprocessed rather than stalling, and
+	// are processed again on the next epoch transition.
+	var firstErr error
+	for _, gap := range md.BalancesEpochs.Gaps(s.balancesCatchupEpoch(), transitionedEpoch) {
+		for epoch := gap.Start; epoch <= gap.End; {
+			// Store a batch of epochs at a time, to spread the requests across beacon nodes.
+			if err := s.throttle.Wait(util.WithEpochHeadDistance(ctx, s.chainTime, epoch)); err != nil {
+				return err
 			}
-			if err := s.validatorsSetter.SetValidatorBalances(dbCtx, dbValidatorBalances); err != nil {
-				log.Trace().Err(err).Msg("Bulk insert failed; falling back to individual insert")
-				// This error will have caused the transaction to fail, so cancel it and start a new one.
-				cancel()
-				dbCtx, cancel, err = s.chainDB.BeginTx(ctx)
-				if err != nil {
-					return errors.Wrap(err, "failed to begin transaction for validator balances (2)")
-				}
-				for _, dbValidatorBalance := range dbValidatorBalances {
-					if err := s.validatorsSetter.SetValidatorBalance(dbCtx, dbValidatorBalance); err != nil {
-						cancel()
-						return errors.Wrap(err, "failed to set validator balance")
-					}
+			batch := s.storeBalancesBatch(ctx, epoch, gap.End, false)
+			if err := s.recordBalances(ctx, md, batch); err != nil {
+				return err
+			}
+			for _, stored := range batch {
+				if stored.err != nil && firstErr == nil {
+					firstErr = errors.Wrapf(stored.err, "failed to store validator balances for epoch %d", stored.epoch)
 				}
 			}
-			md.LatestBalancesEpoch = epoch
+			epoch += phase0.Epoch(len(batch))
 		}
+	}
 
-		if err := s.setMetadata(dbCtx, md); err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to set metadata for validator balances")
-		}
+	return firstErr
+}
 
-		if err := s.chainDB.CommitTx(dbCtx); err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to set commit transaction for validator balances")
-		}
-		monitorBalancesEpochProcessed(epoch)
+// setValidatorBalances stores the balances of a batch of validators at the start of an epoch.
+func (s *Service) setValidatorBalances(ctx context.Context,
+	epoch phase0.Epoch,
+	validators map[phase0.ValidatorIndex]*api.Validator,
+) error {
+	if len(validators) == 0 {
+		return nil
+	}
+	balances := make([]*chaindb.ValidatorBalance, 0, len(validators))
+	for index, validator := range validators {
+		balances = append(balances, validatorBalance(epoch, index, validator))
+	}
+
+	return s.validatorsSetter.SetValidatorBalances(ctx, balances)
+}
+
+// provenanceDataset is the dataset for which epoch provenance is recorded.
+const provenanceDataset = "validators.balances"
+
+// setBalancesProvenance stores the provenance of the validator balances for an epoch.
+func (s *Service) setBalancesProvenance(ctx context.Context, epoch phase0.Epoch, provenance *chaindb.Provenance) error {
+	if provenance == nil {
+		return nil
+	}
+	if err := s.provenanceSetter.SetEpochProvenance(ctx, provenanceDataset, epoch, provenance); err != nil {
+		return errors.Wrap(err, "failed to set validator balances provenance")
 	}
 
 	return nil
 }
+
+// validatorBalance converts a validator at the start of an epoch to its database balance.
+func validatorBalance(epoch phase0.Epoch, index phase0.ValidatorIndex, validator *api.Validator) *chaindb.ValidatorBalance {
+	return &chaindb.ValidatorBalance{
+		Index:            index,
+		Epoch:            epoch,
+		Balance:          validator.Balance,
+		EffectiveBalance: validator.Validator.EffectiveBalance,
+	}
+}
+
+// validatorUnchanged returns true if the validator is present and the same as
+// when it was last written.
+func validatorUnchanged(stored *chaindb.Validator, validator *chaindb.Validator) bool {
+	return stored != nil &&
+		stored.PublicKey == validator.PublicKey &&
+		stored.EffectiveBalance == validator.EffectiveBalance &&
+		stored.Slashed == validator.Slashed &&
+		stored.ActivationEligibilityEpoch == validator.ActivationEligibilityEpoch &&
+		stored.ActivationEpoch == validator.ActivationEpoch &&
+		stored.ExitEpoch == validator.ExitEpoch &&
+		stored.WithdrawableEpoch == validator.WithdrawableEpoch &&
+		bytes.Equal(stored.WithdrawalCredentials, validator.WithdrawalCredentials)
+}
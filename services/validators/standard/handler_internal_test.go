@@ -0,0 +1,463 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/testing/mock"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// recorder records events in the order in which they occur.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func (r *recorder) index(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.events {
+		if r.events[i] == event {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordingClient records requests for validators.
+type recordingClient struct {
+	recorder   *recorder
+	validators map[phase0.ValidatorIndex]*api.Validator
+}
+
+func (*recordingClient) Name() string {
+	return "test"
+}
+
+func (*recordingClient) Address() string {
+	return "test"
+}
+
+func (c *recordingClient) Validators(_ context.Context, stateID string, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	c.recorder.record(fmt.Sprintf("validators %s", stateID))
+	if len(indices) == 0 {
+		return c.validators, nil
+	}
+	res := make(map[phase0.ValidatorIndex]*api.Validator)
+	for _, index := range indices {
+		if validator, exists := c.validators[index]; exists {
+			res[index] = validator
+		}
+	}
+	return res, nil
+}
+
+func (c *recordingClient) ValidatorsByPubKey(_ context.Context, _ string, _ []phase0.BLSPubKey) (map[phase0.ValidatorIndex]*api.Validator, error) {
+	return c.validators, nil
+}
+
+// recordingDB records validators and validator balances written to it.
+// It also acts as the validator set, supplying the validators written to it.
+type recordingDB struct {
+	chaindb.Service
+	recorder   *recorder
+	mu         sync.Mutex
+	written    []phase0.ValidatorIndex
+	validators map[phase0.ValidatorIndex]*chaindb.Validator
+	balances   map[phase0.Epoch][]int
+	// failBalances are the epochs for which balances fail to be written.
+	failBalances map[phase0.Epoch]bool
+	completed    map[phase0.Epoch]bool
+}
+
+func (d *recordingDB) SetEpochComplete(_ context.Context, _ string, epoch phase0.Epoch) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.completed == nil {
+		d.completed = make(map[phase0.Epoch]bool)
+	}
+	d.completed[epoch] = true
+	return nil
+}
+
+func (*recordingDB) DeleteEpochCompletions(_ context.Context, _ string, _ phase0.Epoch) error {
+	return nil
+}
+
+func (d *recordingDB) CompletedEpochs(_ context.Context, _ string, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res := make([]phase0.Epoch, 0)
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		if d.completed[epoch] {
+			res = append(res, epoch)
+		}
+	}
+	return res, nil
+}
+
+func (*recordingDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return ctx, func() {}, nil
+}
+
+func (d *recordingDB) SetValidator(_ context.Context, validator *chaindb.Validator) error {
+	d.mu.Lock()
+	d.written = append(d.written, validator.Index)
+	if d.validators == nil {
+		d.validators = make(map[phase0.ValidatorIndex]*chaindb.Validator)
+	}
+	d.validators[validator.Index] = validator
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *recordingDB) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res := make([]*chaindb.Validator, 0, len(d.validators))
+	for _, validator := range d.validators {
+		res = append(res, validator)
+	}
+	sort.Slice(res, func(i int, j int) bool {
+		return res[i].Index < res[j].Index
+	})
+	return res, nil
+}
+
+func (d *recordingDB) ValidatorsByPublicKey(_ context.Context, _ []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
+	return nil, nil
+}
+
+func (d *recordingDB) ValidatorsByIndex(_ context.Context, _ []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	return nil, nil
+}
+
+func (d *recordingDB) SetValidatorBalance(_ context.Context, balance *chaindb.ValidatorBalance) error {
+	d.recorder.record(fmt.Sprintf("balances %d", balance.Epoch))
+	return nil
+}
+
+func (d *recordingDB) SetValidatorBalances(_ context.Context, balances []*chaindb.ValidatorBalance) error {
+	if len(balances) == 0 {
+		return nil
+	}
+	d.recorder.record(fmt.Sprintf("balances %d", balances[0].Epoch))
+	d.mu.Lock()
+	if d.failBalances[balances[0].Epoch] {
+		d.mu.Unlock()
+		return errors.New("failed")
+	}
+	if d.balances == nil {
+		d.balances = make(map[phase0.Epoch][]int)
+	}
+	d.balances[balances[0].Epoch] = append(d.balances[balances[0].Epoch], len(balances))
+	d.mu.Unlock()
+	return nil
+}
+
+func (d *recordingDB) writtenValidators() []phase0.ValidatorIndex {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	res := d.written
+	d.written = nil
+	return res
+}
+
+func testValidators(count int) map[phase0.ValidatorIndex]*api.Validator {
+	validators := make(map[phase0.ValidatorIndex]*api.Validator, count)
+	for i := 0; i < count; i++ {
+		validators[phase0.ValidatorIndex(i)] = &api.Validator{
+			Index:   phase0.ValidatorIndex(i),
+			Balance: 32000000000,
+			Validator: &phase0.Validator{
+				PublicKey:                  phase0.BLSPubKey{byte(i)},
+				WithdrawalCredentials:      []byte{0x00, byte(i)},
+				EffectiveBalance:           32000000000,
+				ActivationEligibilityEpoch: 0,
+				ActivationEpoch:            0,
+				ExitEpoch:                  0xffffffffffffffff,
+				WithdrawableEpoch:          0xffffffffffffffff,
+			},
+		}
+	}
+	return validators
+}
+
+func newTestService(client *recordingClient, chainDB *recordingDB) *Service {
+	return &Service{
+		eth2Client:               client,
+		catchupClients:           []eth2client.Service{client},
+		chainDB:                  chainDB,
+		validatorsSetter:         chainDB,
+		provenanceSetter:         chainDB.Service.(chaindb.EpochProvenanceSetter),
+		epochCompletionsSetter:   chainDB,
+		epochCompletionsProvider: chainDB,
+		validatorSet:             chainDB,
+		chainTime:                mockchaintime.New(),
+		balances:                 true,
+		activitySem:              semaphore.NewWeighted(1),
+		batchSize:                100,
+		fullUpdateInterval:       10,
+	}
+}
+
+func TestUpdateEpochHeadAfterCatchup(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &recorder{}
+	client := &recordingClient{recorder: recorder, validators: testValidators(4)}
+	chainDB := &recordingDB{Service: mockchaindb.New(), recorder: recorder}
+	s := newTestService(client, chainDB)
+
+	md := &metadata{
+		LatestEpoch:         1,
+		LatestBalancesEpoch: 1,
+		BalancesEpochs:      util.EpochRanges{{Start: 0, End: 1}},
+	}
+	s.updateEpoch(ctx, md, 4)
+
+	// Balances for all epochs are written.
+	for epoch := 2; epoch <= 4; epoch++ {
+		require.NotEqual(t, -1, recorder.index(fmt.Sprintf("balances %d", epoch)))
+	}
+	require.Equal(t, phase0.Epoch(4), md.LatestBalancesEpoch)
+	require.Equal(t, phase0.Epoch(4), md.LatestEpoch)
+
+	// Validators at head are not fetched until earlier balances have been caught up.
+	require.Greater(t, recorder.index("validators head"), recorder.index("balances 3"))
+	require.Len(t, chainDB.writtenValidators(), 4)
+}
+
+func TestBalancesFailedEpoch(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &recorder{}
+	client := &recordingClient{recorder: recorder, validators: testValidators(4)}
+	chainDB := &recordingDB{Service: mockchaindb.New(), recorder: recorder, failBalances: map[phase0.Epoch]bool{2: true}}
+	s := newTestService(client, chainDB)
+
+	md := &metadata{
+		LatestBalancesEpoch: 1,
+		BalancesEpochs:      util.EpochRanges{{Start: 0, End: 1}},
+	}
+
+	// The failed epoch is left unprocessed, but does not stop later epochs.
+	require.EqualError(t, s.onEpochTransitionValidatorBalances(ctx, md, 4), "failed to store validator balances for epoch 2: failed to set validator balances: failed")
+	require.Equal(t, util.EpochRanges{{Start: 0, End: 1}, {Start: 3, End: 4}}, md.BalancesEpochs)
+	require.Equal(t, phase0.Epoch(4), md.LatestBalancesEpoch)
+
+	// The failed epoch is processed on the next transition.
+	chainDB.failBalances = nil
+	require.NoError(t, s.onEpochTransitionValidatorBalances(ctx, md, 5))
+	require.Equal(t, util.EpochRanges{{Start: 0, End: 5}}, md.BalancesEpochs)
+}
+
+func TestBalancesCompletions(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &recorder{}
+	client := &recordingClient{recorder: recorder, validators: testValidators(4)}
+	chainDB := &recordingDB{Service: mockchaindb.New(), recorder: recorder, failBalances: map[phase0.Epoch]bool{3: true}}
+	s := newTestService(client, chainDB)
+
+	// Balances are stored, but the metadata is lost, as if the process crashed
+	// before recording them.
+	require.Error(t, s.onEpochTransitionValidatorBalances(ctx, &metadata{}, 4))
+
+	// The completion markers bring the metadata up to date, other than for the failed epoch.
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(zerolog.Disabled),
+		standardchaintime.WithGenesisTimeProvider(mock.NewGenesisTimeProvider(time.Now().Add(-5*48*time.Second))),
+		standardchaintime.WithSpecProvider(mock.NewSpecProvider(12*time.Second, 4, 256)),
+		standardchaintime.WithForkScheduleProvider(mock.NewForkScheduleProvider([]*phase0.Fork{{}})),
+	)
+	require.NoError(t, err)
+	s.chainTime = chainTime
+	md := &metadata{}
+	require.NoError(t, s.applyBalancesCompletions(ctx, md))
+	require.Equal(t, util.EpochRanges{{Start: 0, End: 2}, {Start: 4, End: 4}}, md.BalancesEpochs)
+	require.Equal(t, phase0.Epoch(4), md.LatestBalancesEpoch)
+}
+
+func TestStoreEpochBalances(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &recorder{}
+	client := &recordingClient{recorder: recorder, validators: testValidators(25)}
+	chainDB := &recordingDB{Service: mockchaindb.New(), recorder: recorder}
+	s := newTestService(client, chainDB)
+	s.batchSize = 10
+
+	provenance, err := s.storeEpochBalances(ctx, client, 3)
+	require.NoError(t, err)
+	require.NotNil(t, provenance)
+
+	// Validators are fetched and stored a batch at a time.
+	require.Equal(t, []int{10, 10, 5}, chainDB.balances[3])
+
+	// An exact multiple of the batch size finishes with an empty fetch.
+	client.validators = testValidators(20)
+	_, err = s.storeEpochBalances(ctx, client, 4)
+	require.NoError(t, err)
+	require.Equal(t, []int{10, 10}, chainDB.balances[4])
+}
+
+func TestValidatorUnchanged(t *testing.T) {
+	validator := func() *chaindb.Validator {
+		return &chaindb.Validator{
+			PublicKey:                  phase0.BLSPubKey{0x01},
+			Index:                      1,
+			EffectiveBalance:           32000000000,
+			ActivationEligibilityEpoch: 1,
+			ActivationEpoch:            2,
+			ExitEpoch:                  3,
+			WithdrawableEpoch:          4,
+			WithdrawalCredentials:      []byte{0x00, 0x01},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		stored    *chaindb.Validator
+		update    func(*chaindb.Validator)
+		unchanged bool
+	}{
+		{
+			name:   "Missing",
+			update: func(*chaindb.Validator) {},
+		},
+		{
+			name:      "Unchanged",
+			stored:    validator(),
+			update:    func(*chaindb.Validator) {},
+			unchanged: true,
+		},
+		{
+			name:   "PublicKey",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.PublicKey = phase0.BLSPubKey{0x02} },
+		},
+		{
+			name:   "EffectiveBalance",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.EffectiveBalance-- },
+		},
+		{
+			name:   "Slashed",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.Slashed = true },
+		},
+		{
+			name:   "ActivationEligibilityEpoch",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.ActivationEligibilityEpoch++ },
+		},
+		{
+			name:   "ActivationEpoch",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.ActivationEpoch++ },
+		},
+		{
+			name:   "ExitEpoch",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.ExitEpoch++ },
+		},
+		{
+			name:   "WithdrawableEpoch",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.WithdrawableEpoch++ },
+		},
+		{
+			name:   "WithdrawalCredentials",
+			stored: validator(),
+			update: func(v *chaindb.Validator) { v.WithdrawalCredentials = []byte{0x01, 0x01} },
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updated := validator()
+			test.update(updated)
+			require.Equal(t, test.unchanged, validatorUnchanged(test.stored, updated))
+		})
+	}
+}
+
+func TestOnEpochTransitionValidators(t *testing.T) {
+	ctx := context.Background()
+
+	recorder := &recorder{}
+	validators := testValidators(4)
+	client := &recordingClient{recorder: recorder, validators: validators}
+	chainDB := &recordingDB{Service: mockchaindb.New(), recorder: recorder}
+	s := newTestService(client, chainDB)
+	md := &metadata{}
+
+	// The first update writes the full validator set.
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 1, validators))
+	require.ElementsMatch(t, []phase0.ValidatorIndex{0, 1, 2, 3}, chainDB.writtenValidators())
+	require.Equal(t, phase0.Epoch(1), md.LatestEpoch)
+
+	// Unchanged validators are not written.
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 2, validators))
+	require.Empty(t, chainDB.writtenValidators())
+
+	// Changed validators are written.
+	validators[2].Validator.EffectiveBalance = 31000000000
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 3, validators))
+	require.Equal(t, []phase0.ValidatorIndex{2}, chainDB.writtenValidators())
+
+	// Changes are found against the database, not a copy held by the service.
+	chainDB.mu.Lock()
+	delete(chainDB.validators, 1)
+	chainDB.mu.Unlock()
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 4, validators))
+	require.Equal(t, []phase0.ValidatorIndex{1}, chainDB.writtenValidators())
+
+	// The full validator set is written again after the full update interval.
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 10, validators))
+	require.Empty(t, chainDB.writtenValidators())
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 11, validators))
+	require.ElementsMatch(t, []phase0.ValidatorIndex{0, 1, 2, 3}, chainDB.writtenValidators())
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 12, validators))
+	require.Empty(t, chainDB.writtenValidators())
+
+	// An earlier epoch, for example on reindexing, writes the full validator set.
+	require.NoError(t, s.onEpochTransitionValidators(ctx, md, 5, validators))
+	require.ElementsMatch(t, []phase0.ValidatorIndex{0, 1, 2, 3}, chainDB.writtenValidators())
+}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
 )
 
 // metadata stored about this service.
@@ -26,11 +27,20 @@ type metadata struct {
 	LatestEpoch         phase0.Epoch   `json:"latest_epoch"`
 	LatestBalancesEpoch phase0.Epoch   `json:"latest_balances_epoch"`
 	MissedEpochs        []phase0.Epoch `json:"missed_epochs,omitempty"`
+	// BalancesEpochs are the epochs for which balances have been processed.
+	BalancesEpochs util.EpochRanges `json:"balances_epochs,omitempty"`
+	// BalancesBackfill is the range of epochs for which balances were yet to be
+	// backfilled.  This is only present in metadata written by older versions of
+	// chaind, and is converted to balances epochs when read.
+	BalancesBackfill *util.EpochRange `json:"balances_backfill,omitempty"`
 }
 
 // metadataKey is the key for the metadata.
 var metadataKey = "validators.standard"
 
+// balancesCompletionKey is the key for the epoch completion markers of validator balances.
+var balancesCompletionKey = "validators.standard.balances"
+
 // getMetadata gets metadata for this service.
 func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	md := &metadata{}
@@ -44,9 +54,38 @@ func (s *Service) getMetadata(ctx context.Context) (*metadata, error) {
 	if err := json.Unmarshal(mdJSON, md); err != nil {
 		return nil, errors.Wrap(err, "failed to unmarshal metadata")
 	}
+	if md.BalancesEpochs == nil {
+		md.upgradeBalances()
+	}
 	return md, nil
 }
 
+// upgradeBalances converts metadata written by older versions of chaind, which
+// processed balances for all epochs up to the latest balances epoch other than
+// those yet to be backfilled.
+func (md *metadata) upgradeBalances() {
+	if md.BalancesBackfill == nil {
+		md.BalancesEpochs = util.ProcessedFromLatest(md.LatestBalancesEpoch, nil)
+		return
+	}
+
+	md.BalancesEpochs = make(util.EpochRanges, 0)
+	if md.BalancesBackfill.Start > 0 {
+		md.BalancesEpochs.AddRange(0, md.BalancesBackfill.Start-1)
+	}
+	md.BalancesEpochs.AddRange(md.BalancesBackfill.End+1, md.LatestBalancesEpoch)
+	md.LatestBalancesEpoch, _ = md.BalancesEpochs.Highest()
+	md.BalancesBackfill = nil
+}
+
+// setBalancesProcessed marks epochs as having had their balances processed.
+func (md *metadata) setBalancesProcessed(epochs ...phase0.Epoch) {
+	for _, epoch := range epochs {
+		md.BalancesEpochs.Add(epoch)
+	}
+	md.LatestBalancesEpoch, _ = md.BalancesEpochs.Highest()
+}
+
 // setMetadata sets metadata for this service.
 func (s *Service) setMetadata(ctx context.Context, md *metadata) error {
 	mdJSON, err := json.Marshal(md)
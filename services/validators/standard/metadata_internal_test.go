@@ -0,0 +1,63 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestMetadataUpgradeBalances(t *testing.T) {
+	tests := []struct {
+		name     string
+		md       *metadata
+		expected util.EpochRanges
+	}{
+		{
+			name: "Empty",
+			md:   &metadata{},
+		},
+		{
+			name:     "LatestBalancesEpoch",
+			md:       &metadata{LatestBalancesEpoch: 10},
+			expected: util.EpochRanges{{Start: 0, End: 10}},
+		},
+		{
+			name: "Backfill",
+			md: &metadata{
+				LatestBalancesEpoch: 20,
+				BalancesBackfill:    &util.EpochRange{Start: 5, End: 12},
+			},
+			expected: util.EpochRanges{{Start: 0, End: 4}, {Start: 13, End: 20}},
+		},
+		{
+			name: "BackfillFromGenesis",
+			md: &metadata{
+				LatestBalancesEpoch: 20,
+				BalancesBackfill:    &util.EpochRange{Start: 0, End: 20},
+			},
+			expected: util.EpochRanges{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.md.upgradeBalances()
+			require.Equal(t, test.expected, test.md.BalancesEpochs)
+			require.Nil(t, test.md.BalancesBackfill)
+		})
+	}
+}
@@ -15,22 +15,39 @@ package standard
 
 import (
 	"errors"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
 	"github.com/wealdtech/chaind/services/metrics"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
 )
 
 type parameters struct {
-	logLevel   zerolog.Level
-	monitor    metrics.Service
-	eth2Client eth2client.Service
-	chainDB    chaindb.Service
-	chainTime  chaintime.Service
-	balances   bool
-	startEpoch int64
+	logLevel           zerolog.Level
+	logLevelSampler    zerolog.Sampler
+	monitor            metrics.Service
+	eth2Client         eth2client.Service
+	catchupClients     []eth2client.Service
+	chainDB            chaindb.Service
+	chainTime          chaintime.Service
+	validatorSet       validatorset.Service
+	balances           bool
+	startEpoch         int64
+	passive            bool
+	backfill           bool
+	headFirstDistance  phase0.Epoch
+	throttle           *util.Throttle
+	validatorHandlers  []handlers.ValidatorHandler
+	retryPolicy        *util.RetryPolicy
+	eventsStallTimeout time.Duration
+	batchSize          int
+	fullUpdateInterval phase0.Epoch
 }
 
 // Parameter is the interface for service parameters.
@@ -51,6 +68,14 @@ func WithLogLevel(logLevel zerolog.Level) Parameter {
 	})
 }
 
+// WithLogLevelSampler sets a sampler to control the log level for the module at runtime.
+// If supplied it takes precedence over the level set by WithLogLevel().
+func WithLogLevelSampler(sampler zerolog.Sampler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.logLevelSampler = sampler
+	})
+}
+
 // WithMonitor sets the monitor for the module.
 func WithMonitor(monitor metrics.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -65,6 +90,14 @@ func WithETH2Client(eth2Client eth2client.Service) Parameter {
 	})
 }
 
+// WithCatchupClients sets the Ethereum 2 clients across which balance requests
+// are spread when catching up.  If not supplied the Ethereum 2 client is used.
+func WithCatchupClients(clients []eth2client.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.catchupClients = clients
+	})
+}
+
 // WithChainDB sets the chain database for this module.
 func WithChainDB(chainDB chaindb.Service) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -86,6 +119,39 @@ func WithStartEpoch(startEpoch int64) Parameter {
 	})
 }
 
+// WithPassive sets the service to passive mode, in which it neither catches up with
+// nor follows the chain and only acts on explicit requests such as reindexing.
+func WithPassive(passive bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.passive = passive
+	})
+}
+
+// WithBackfill sets the service to follow the chain from the current epoch on start,
+// and to fill in validator balances for earlier epochs in reverse order in the background.
+func WithBackfill(backfill bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.backfill = backfill
+	})
+}
+
+// WithHeadFirstDistance sets the number of epochs the module can be behind the
+// chain on start before it follows the chain from the current epoch and backfills
+// earlier data in the background, as if backfill were enabled.  0 disables this.
+func WithHeadFirstDistance(distance phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.headFirstDistance = distance
+	})
+}
+
+// WithThrottle sets the throttle that holds back catchup and backfill while
+// the database is under load.
+func WithThrottle(throttle *util.Throttle) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.throttle = throttle
+	})
+}
+
 // WithBalances states if the module should fetch validator balances.
 func WithBalances(balances bool) Parameter {
 	return parameterFunc(func(p *parameters) {
@@ -93,12 +159,62 @@ func WithBalances(balances bool) Parameter {
 	})
 }
 
+// WithValidatorHandlers sets the validator handlers for this module.
+func WithValidatorHandlers(handlers []handlers.ValidatorHandler) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorHandlers = handlers
+	})
+}
+
+// WithRetryPolicy sets the policy for retrying failed fetches from the beacon node.
+func WithRetryPolicy(policy *util.RetryPolicy) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.retryPolicy = policy
+	})
+}
+
+// WithEventsStallTimeout sets the time without events after which the event
+// stream is considered lost and is re-established.  0 disables the check.
+func WithEventsStallTimeout(timeout time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.eventsStallTimeout = timeout
+	})
+}
+
+// WithBatchSize sets the maximum number of validators fetched from the beacon node,
+// and balances written to the database, at a time, which bounds the memory used
+// when storing an epoch.
+func WithBatchSize(batchSize int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.batchSize = batchSize
+	})
+}
+
+// WithFullUpdateInterval sets the number of epochs between writes of the full
+// validator set.  In between, only validators that have changed are written.
+// 0 writes the full validator set every epoch.
+func WithFullUpdateInterval(interval phase0.Epoch) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.fullUpdateInterval = interval
+	})
+}
+
+// WithValidatorSet sets the validator set against which changes to validators are found.
+// If not supplied validators are obtained from the chain database.
+func WithValidatorSet(validatorSet validatorset.Service) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.validatorSet = validatorSet
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
-		logLevel:   zerolog.GlobalLevel(),
-		startEpoch: -1,
-		balances:   false,
+		logLevel:           zerolog.GlobalLevel(),
+		startEpoch:         -1,
+		balances:           false,
+		batchSize:          10000,
+		fullUpdateInterval: 256,
 	}
 	for _, p := range params {
 		if params != nil {
@@ -109,12 +225,20 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.eth2Client == nil {
 		return nil, errors.New("no Ethereum 2 client specified")
 	}
+	for _, client := range parameters.catchupClients {
+		if _, isProvider := client.(eth2client.ValidatorsProvider); !isProvider {
+			return nil, errors.New("catchup Ethereum 2 client does not provide validators")
+		}
+	}
 	if parameters.chainDB == nil {
 		return nil, errors.New("no chain database specified")
 	}
 	if parameters.chainTime == nil {
 		return nil, errors.New("no chain time specified")
 	}
+	if parameters.batchSize <= 0 {
+		return nil, errors.New("batch size must be greater than 0")
+	}
 
 	return &parameters, nil
 }
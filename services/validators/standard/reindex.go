@@ -0,0 +1,48 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+)
+
+// ReindexEpochs deletes and re-fetches the validator balances for the epochs from start to end inclusive.
+// Each epoch is deleted and re-fetched within a single transaction.  Reindexing does not alter the
+// progress of the service.
+func (s *Service) ReindexEpochs(ctx context.Context, start phase0.Epoch, end phase0.Epoch) error {
+	if !s.balances {
+		return errors.New("validator balances not enabled")
+	}
+
+	if err := s.activitySem.Acquire(ctx, 1); err != nil {
+		return errors.Wrap(err, "failed to acquire semaphore")
+	}
+	defer s.activitySem.Release(1)
+
+	log.Info().Uint64("start_epoch", uint64(start)).Uint64("end_epoch", uint64(end)).Msg("Reindexing epochs")
+	for epoch := start; epoch <= end; {
+		batch := s.storeBalancesBatch(ctx, epoch, end, true)
+		for _, stored := range batch {
+			if stored.err != nil {
+				return errors.Wrapf(stored.err, "failed to reindex epoch %d", stored.epoch)
+			}
+		}
+		epoch += phase0.Epoch(len(batch))
+	}
+
+	return nil
+}
@@ -15,30 +15,64 @@ package standard
 
 import (
 	"context"
+	"time"
 
 	eth2client "github.com/attestantio/go-eth2-client"
 	api "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/attestantio/go-eth2-client/spec/phase0"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/handlers"
 	"github.com/wealdtech/chaind/services/chaindb"
 	"github.com/wealdtech/chaind/services/chaintime"
+	"github.com/wealdtech/chaind/services/validatorset"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/semaphore"
 )
 
 // Service is a chain database service.
 type Service struct {
-	eth2Client       eth2client.Service
-	chainDB          chaindb.Service
-	validatorsSetter chaindb.ValidatorsSetter
-	chainTime        chaintime.Service
-	balances         bool
-	activitySem      *semaphore.Weighted
+	eth2Client               eth2client.Service
+	catchupClients           []eth2client.Service
+	chainDB                  chaindb.Service
+	validatorsSetter         chaindb.ValidatorsSetter
+	provenanceSetter         chaindb.EpochProvenanceSetter
+	rangeDeleter             chaindb.RangeDeleter
+	epochCompletionsSetter   chaindb.EpochCompletionsSetter
+	epochCompletionsProvider chaindb.EpochCompletionsProvider
+	chainTime                chaintime.Service
+	balances                 bool
+	backfill                 bool
+	headFirstDistance        phase0.Epoch
+	throttle                 *util.Throttle
+	activitySem              *semaphore.Weighted
+	validatorHandlers        []handlers.ValidatorHandler
+	retryPolicy              *util.RetryPolicy
+	eventsStallTimeout       time.Duration
+	batchSize                int
+	fullUpdateInterval       phase0.Epoch
+
+	validatorSet validatorset.Service
+
+	// fullUpdated is true once the full validator set has been written.
+	fullUpdated bool
+	// lastFullUpdateEpoch is the epoch at which the full validator set was last written.
+	lastFullUpdateEpoch phase0.Epoch
+	// balancesFirstEpoch is the first epoch for which balances are processed.
+	balancesFirstEpoch phase0.Epoch
+	// balancesFollowEpoch is the epoch from which balances follow the chain, if
+	// balances for earlier epochs are being backfilled.
+	balancesFollowEpoch phase0.Epoch
 }
 
 // module-wide log.
 var log zerolog.Logger
 
+// module-wide tracer.
+var tracer = otel.Tracer("github.com/wealdtech/chaind/services/validators/standard")
+
 // New creates a new service.
 func New(ctx context.Context, params ...Parameter) (*Service, error) {
 	parameters, err := parseAndCheckParameters(params...)
@@ -48,6 +82,9 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 
 	// Set logging.
 	log = zerologger.With().Str("service", "validators").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
 
 	if err := registerMetrics(ctx, parameters.monitor); err != nil {
 		return nil, errors.New("failed to register metrics")
@@ -58,17 +95,67 @@ func New(ctx context.Context, params ...Parameter) (*Service, error) {
 		return nil, errors.New("chain DB does not support validator setting")
 	}
 
+	provenanceSetter, isProvenanceSetter := parameters.chainDB.(chaindb.EpochProvenanceSetter)
+	if !isProvenanceSetter {
+		return nil, errors.New("chain DB does not support epoch provenance setting")
+	}
+
+	rangeDeleter, isRangeDeleter := parameters.chainDB.(chaindb.RangeDeleter)
+	if !isRangeDeleter {
+		return nil, errors.New("chain DB does not support range deletion")
+	}
+
+	epochCompletionsSetter, isEpochCompletionsSetter := parameters.chainDB.(chaindb.EpochCompletionsSetter)
+	if !isEpochCompletionsSetter {
+		return nil, errors.New("chain DB does not support epoch completion setting")
+	}
+
+	epochCompletionsProvider, isEpochCompletionsProvider := parameters.chainDB.(chaindb.EpochCompletionsProvider)
+	if !isEpochCompletionsProvider {
+		return nil, errors.New("chain DB does not support epoch completion providing")
+	}
+
+	validatorSet := parameters.validatorSet
+	if validatorSet == nil {
+		validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+		if !isProvider {
+			return nil, errors.New("chain DB does not provide validators")
+		}
+		validatorSet = validatorsProvider
+	}
+
+	catchupClients := parameters.catchupClients
+	if len(catchupClients) == 0 {
+		catchupClients = []eth2client.Service{parameters.eth2Client}
+	}
+
 	s := &Service{
-		eth2Client:       parameters.eth2Client,
-		chainDB:          parameters.chainDB,
-		validatorsSetter: validatorsSetter,
-		chainTime:        parameters.chainTime,
-		balances:         parameters.balances,
-		activitySem:      semaphore.NewWeighted(1),
+		eth2Client:               parameters.eth2Client,
+		catchupClients:           catchupClients,
+		chainDB:                  parameters.chainDB,
+		validatorsSetter:         validatorsSetter,
+		provenanceSetter:         provenanceSetter,
+		rangeDeleter:             rangeDeleter,
+		epochCompletionsSetter:   epochCompletionsSetter,
+		epochCompletionsProvider: epochCompletionsProvider,
+		chainTime:                parameters.chainTime,
+		balances:                 parameters.balances,
+		backfill:                 parameters.backfill,
+		headFirstDistance:        parameters.headFirstDistance,
+		throttle:                 parameters.throttle,
+		activitySem:              semaphore.NewWeighted(1),
+		eventsStallTimeout:       parameters.eventsStallTimeout,
+		validatorHandlers:        parameters.validatorHandlers,
+		retryPolicy:              parameters.retryPolicy,
+		batchSize:                parameters.batchSize,
+		fullUpdateInterval:       parameters.fullUpdateInterval,
+		validatorSet:             validatorSet,
 	}
 
 	// Update to current epoch (in the background).
-	go s.updateAfterRestart(ctx, parameters.startEpoch)
+	if !parameters.passive {
+		go s.updateAfterRestart(ctx, parameters.startEpoch)
+	}
 
 	return s, nil
 }
@@ -87,41 +174,76 @@ func (s *Service) updateAfterRestart(ctx context.Context, startEpoch int64) {
 		log.Fatal().Err(err).Msg("Failed to obtain metadata before catchup")
 	}
 	if startEpoch >= 0 {
-		// Explicit requirement to start at a given epoch; update metadata accordingly.
-		ctx, cancel, err := s.chainDB.BeginTx(ctx)
-		if err != nil {
-			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to begin transaction to set start epoch")
-		}
-		if err := s.setMetadata(ctx, md); err != nil {
-			cancel()
+		// Explicit requirement to start at a given epoch; balances already
+		// processed from this epoch onwards are processed again.
+		s.balancesFirstEpoch = phase0.Epoch(startEpoch)
+		md.BalancesEpochs.RemoveFrom(s.balancesFirstEpoch)
+		md.LatestBalancesEpoch, _ = md.BalancesEpochs.Highest()
+		if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+			if err := s.setMetadata(ctx, md); err != nil {
+				return err
+			}
+			if err := s.epochCompletionsSetter.DeleteEpochCompletions(ctx, balancesCompletionKey, s.balancesFirstEpoch); err != nil {
+				return errors.Wrap(err, "failed to remove epoch completions from start epoch")
+			}
+			return nil
+		}); err != nil {
 			s.activitySem.Release(1)
 			log.Fatal().Err(err).Msg("Failed to set metadata with start epoch")
 		}
-		if err := s.chainDB.CommitTx(ctx); err != nil {
-			cancel()
+	} else if lowestEpoch, exists := md.BalancesEpochs.Lowest(); exists {
+		// Fill in any gaps from the first epoch for which balances were processed.
+		s.balancesFirstEpoch = lowestEpoch
+	}
+	if s.balances {
+		if err := s.applyBalancesCompletions(ctx, md); err != nil {
 			s.activitySem.Release(1)
-			log.Fatal().Err(err).Msg("Failed to commit transaction to set start epoch")
+			log.Fatal().Err(err).Msg("Failed to apply epoch completions")
 		}
 	}
 
+	if s.balances && s.followFromHead(md) {
+		// Follow the chain from the current epoch, filling in balances for earlier epochs in the background.
+		s.balancesFollowEpoch = s.chainTime.CurrentEpoch()
+		go s.backfillBalances(ctx)
+	}
+
 	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Catching up from epoch")
 	currentEpoch := s.chainTime.CurrentEpoch()
-	if err := s.onEpochTransitionValidators(ctx, md, currentEpoch); err != nil {
-		log.Warn().Err(err).Msg("Failed to update to head; will retry")
-	}
-	if err := s.onEpochTransitionValidatorBalances(ctx, md, currentEpoch); err != nil {
-		log.Warn().Err(err).Msg("Failed to update validators")
-	}
+	s.updateEpoch(ctx, md, currentEpoch)
 	s.activitySem.Release(1)
 
 	log.Info().Uint64("epoch", uint64(md.LatestEpoch)).Msg("Caught up")
 
 	// Set up the handler for new chain head updates.
-	if err := s.eth2Client.(eth2client.EventsProvider).Events(ctx, []string{"head"}, func(event *api.Event) {
+	if err := util.SubscribeEvents(ctx, log, s.eth2Client.(eth2client.EventsProvider), []string{"head"}, s.eventsStallTimeout, func(event *api.Event) {
 		eventData := event.Data.(*api.HeadEvent)
 		s.OnBeaconChainHeadUpdated(ctx, eventData.Slot, eventData.Block, eventData.State, eventData.EpochTransition)
-	}); err != nil {
+	}, s.onEventsResubscribed); err != nil {
 		log.Fatal().Err(err).Msg("Failed to add beacon chain head updated handler")
 	}
 }
+
+// applyBalancesCompletions marks as processed any epochs that have a balances
+// completion marker but are not marked as processed in the metadata.  Completion
+// markers are written in the same transaction as the balances for their epoch,
+// so are authoritative.
+func (s *Service) applyBalancesCompletions(ctx context.Context, md *metadata) error {
+	epochs, err := util.CompletedInGaps(ctx, s.epochCompletionsProvider, balancesCompletionKey, md.BalancesEpochs, s.balancesFirstEpoch, s.chainTime.CurrentEpoch())
+	if err != nil {
+		return err
+	}
+	if len(epochs) == 0 {
+		return nil
+	}
+
+	md.setBalancesProcessed(epochs...)
+	log.Debug().Int("epochs", len(epochs)).Msg("Marking completed balances epochs as processed")
+	if err := util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		return s.setMetadata(ctx, md)
+	}); err != nil {
+		return errors.Wrap(err, "failed to set metadata")
+	}
+
+	return nil
+}
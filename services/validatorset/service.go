@@ -0,0 +1,35 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validatorset
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// Service provides the validator set.  Its functions match those of
+// chaindb.ValidatorsProvider, so a chain database can be used in its place.
+// Validators returned by the service are shared, so must not be modified.
+type Service interface {
+	// Validators fetches all validators, ordered by index.
+	Validators(ctx context.Context) ([]*chaindb.Validator, error)
+
+	// ValidatorsByPublicKey fetches all validators matching the given public keys.
+	ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error)
+
+	// ValidatorsByIndex fetches all validators matching the given indices.
+	ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error)
+}
@@ -0,0 +1,71 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_validatorset"
+
+var validatorsCached prometheus.Gauge
+var refreshes prometheus.Counter
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if validatorsCached != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	validatorsCached = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "validators",
+		Help:      "Number of validators in the cached validator set",
+	})
+	if err := prometheus.Register(validatorsCached); err != nil {
+		return errors.Wrap(err, "failed to register validators")
+	}
+
+	refreshes = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "refreshes_total",
+		Help:      "Number of times the validator set has been loaded from the database",
+	})
+	if err := prometheus.Register(refreshes); err != nil {
+		return errors.Wrap(err, "failed to register refreshes_total")
+	}
+
+	return nil
+}
+
+func monitorRefreshed(validators int) {
+	if refreshes != nil {
+		refreshes.Inc()
+		validatorsCached.Set(float64(validators))
+	}
+}
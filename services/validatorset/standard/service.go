@@ -0,0 +1,176 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+// Service is a validator set service that holds the validator set in memory,
+// so that services do not each fetch it from the database.  The set is
+// reloaded from the database when it is next requested after the validators
+// module has updated the validators, or after the start of a new epoch.
+type Service struct {
+	validatorsProvider chaindb.ValidatorsProvider
+	chainTime          chaintime.Service
+
+	// mu protects set, and is held while the set is reloaded so that
+	// concurrent requests result in a single load.
+	mu  sync.Mutex
+	set *validatorSet
+
+	// stale is set to 1 when the validators have been updated since the set was loaded.
+	stale int32
+}
+
+// validatorSet is the validator set as loaded at an epoch.
+type validatorSet struct {
+	epoch      phase0.Epoch
+	validators []*chaindb.Validator
+	byIndex    map[phase0.ValidatorIndex]*chaindb.Validator
+	byPubKey   map[phase0.BLSPubKey]*chaindb.Validator
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "validatorset").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	validatorsProvider, isProvider := parameters.chainDB.(chaindb.ValidatorsProvider)
+	if !isProvider {
+		return nil, errors.New("chain DB does not provide validators")
+	}
+
+	s := &Service{
+		validatorsProvider: validatorsProvider,
+		chainTime:          parameters.chainTime,
+	}
+
+	return s, nil
+}
+
+// OnValidatorsUpdated is called when the validators for an epoch have been committed
+// to the database.  It marks the set as stale, so that it is reloaded when next requested.
+func (s *Service) OnValidatorsUpdated(_ context.Context, epoch phase0.Epoch) {
+	log.Trace().Uint64("epoch", uint64(epoch)).Msg("Validators updated")
+	atomic.StoreInt32(&s.stale, 1)
+}
+
+// Validators fetches all validators, ordered by index.
+func (s *Service) Validators(ctx context.Context) ([]*chaindb.Validator, error) {
+	set, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return a copy of the slice, so that callers can reorder it.
+	validators := make([]*chaindb.Validator, len(set.validators))
+	copy(validators, set.validators)
+
+	return validators, nil
+}
+
+// ValidatorsByPublicKey fetches all validators matching the given public keys.
+func (s *Service) ValidatorsByPublicKey(ctx context.Context, pubKeys []phase0.BLSPubKey) (map[phase0.BLSPubKey]*chaindb.Validator, error) {
+	set, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make(map[phase0.BLSPubKey]*chaindb.Validator, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		if validator, exists := set.byPubKey[pubKey]; exists {
+			validators[pubKey] = validator
+		}
+	}
+
+	return validators, nil
+}
+
+// ValidatorsByIndex fetches all validators matching the given indices.
+func (s *Service) ValidatorsByIndex(ctx context.Context, indices []phase0.ValidatorIndex) (map[phase0.ValidatorIndex]*chaindb.Validator, error) {
+	set, err := s.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := make(map[phase0.ValidatorIndex]*chaindb.Validator, len(indices))
+	for _, index := range indices {
+		if validator, exists := set.byIndex[index]; exists {
+			validators[index] = validator
+		}
+	}
+
+	return validators, nil
+}
+
+// current returns the current validator set, reloading it if it is out of date.
+func (s *Service) current(ctx context.Context) (*validatorSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	currentEpoch := s.chainTime.CurrentEpoch()
+	if s.set != nil && s.set.epoch >= currentEpoch && atomic.LoadInt32(&s.stale) == 0 {
+		return s.set, nil
+	}
+
+	// Clear the stale flag before loading, so that an update that arrives
+	// during the load causes a further reload.
+	atomic.StoreInt32(&s.stale, 0)
+	validators, err := s.validatorsProvider.Validators(ctx)
+	if err != nil {
+		atomic.StoreInt32(&s.stale, 1)
+		return nil, errors.Wrap(err, "failed to obtain validators")
+	}
+
+	set := &validatorSet{
+		epoch:      currentEpoch,
+		validators: validators,
+		byIndex:    make(map[phase0.ValidatorIndex]*chaindb.Validator, len(validators)),
+		byPubKey:   make(map[phase0.BLSPubKey]*chaindb.Validator, len(validators)),
+	}
+	for _, validator := range validators {
+		set.byIndex[validator.Index] = validator
+		set.byPubKey[validator.PublicKey] = validator
+	}
+	s.set = set
+	log.Trace().Uint64("epoch", uint64(currentEpoch)).Int("validators", len(validators)).Msg("Loaded validator set")
+	monitorRefreshed(len(validators))
+
+	return set, nil
+}
@@ -0,0 +1,147 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	mockchaindb "github.com/wealdtech/chaind/services/chaindb/mock"
+	mockchaintime "github.com/wealdtech/chaind/services/chaintime/mock"
+	"github.com/wealdtech/chaind/services/validatorset/standard"
+)
+
+// countingChainDB is a chain database that counts requests for all validators.
+type countingChainDB struct {
+	chaindb.Service
+	chaindb.ValidatorsProvider
+	validators []*chaindb.Validator
+	requests   int
+}
+
+func (c *countingChainDB) Validators(_ context.Context) ([]*chaindb.Validator, error) {
+	c.requests++
+	return c.validators, nil
+}
+
+func newCountingChainDB() *countingChainDB {
+	chainDB := mockchaindb.New()
+	return &countingChainDB{
+		Service:            chainDB,
+		ValidatorsProvider: chainDB.(chaindb.ValidatorsProvider),
+		validators: []*chaindb.Validator{
+			{Index: 0, PublicKey: phase0.BLSPubKey{0x01}},
+			{Index: 1, PublicKey: phase0.BLSPubKey{0x02}},
+		},
+	}
+}
+
+func TestService(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := mockchaindb.New()
+	chainTime := mockchaintime.New()
+
+	tests := []struct {
+		name   string
+		params []standard.Parameter
+		err    string
+	}{
+		{
+			name: "ChainDBMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainTime(chainTime),
+			},
+			err: "problem with parameters: no chain database specified",
+		},
+		{
+			name: "ChainTimeMissing",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+			},
+			err: "problem with parameters: no chain time specified",
+		},
+		{
+			name: "Good",
+			params: []standard.Parameter{
+				standard.WithLogLevel(zerolog.Disabled),
+				standard.WithChainDB(chainDB),
+				standard.WithChainTime(chainTime),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := standard.New(ctx, test.params...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	chainDB := newCountingChainDB()
+	s, err := standard.New(ctx,
+		standard.WithLogLevel(zerolog.Disabled),
+		standard.WithChainDB(chainDB),
+		standard.WithChainTime(mockchaintime.New()),
+	)
+	require.NoError(t, err)
+
+	validators, err := s.Validators(ctx)
+	require.NoError(t, err)
+	require.Len(t, validators, 2)
+	require.Equal(t, 1, chainDB.requests)
+
+	byIndex, err := s.ValidatorsByIndex(ctx, []phase0.ValidatorIndex{1, 5})
+	require.NoError(t, err)
+	require.Len(t, byIndex, 1)
+	require.Equal(t, phase0.BLSPubKey{0x02}, byIndex[1].PublicKey)
+
+	byPubKey, err := s.ValidatorsByPublicKey(ctx, []phase0.BLSPubKey{{0x01}})
+	require.NoError(t, err)
+	require.Len(t, byPubKey, 1)
+	require.Equal(t, phase0.ValidatorIndex(0), byPubKey[phase0.BLSPubKey{0x01}].Index)
+
+	// All of the above should be served from the cache.
+	require.Equal(t, 1, chainDB.requests)
+
+	// Reordering the returned validators should not affect the cache.
+	validators[0], validators[1] = validators[1], validators[0]
+	validators, err = s.Validators(ctx)
+	require.NoError(t, err)
+	require.Equal(t, phase0.ValidatorIndex(0), validators[0].Index)
+
+	// An update to the validators should cause a reload.
+	chainDB.validators = append(chainDB.validators, &chaindb.Validator{Index: 2, PublicKey: phase0.BLSPubKey{0x03}})
+	s.OnValidatorsUpdated(ctx, 0)
+	validators, err = s.Validators(ctx)
+	require.NoError(t, err)
+	require.Len(t, validators, 3)
+	require.Equal(t, 2, chainDB.requests)
+}
@@ -0,0 +1,90 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/util"
+)
+
+// OnFinalityUpdated is called when finality has been updated in the database.
+func (s *Service) OnFinalityUpdated(
+	ctx context.Context,
+	finalizedEpoch phase0.Epoch,
+) {
+	log := log.With().Uint64("finalized_epoch", uint64(finalizedEpoch)).Logger()
+	log.Trace().Msg("Handler called")
+
+	if len(s.views) == 0 {
+		return
+	}
+
+	// Only allow 1 handler to be active.
+	acquired := s.activitySem.TryAcquire(1)
+	if !acquired {
+		log.Debug().Msg("Another handler running")
+		return
+	}
+	defer s.activitySem.Release(1)
+
+	if err := s.refreshViews(ctx, finalizedEpoch); err != nil {
+		log.Warn().Err(err).Msg("Failed to refresh views")
+		return
+	}
+
+	monitorLatestEpoch(finalizedEpoch)
+	log.Trace().Msg("Finished handling finality checkpoint")
+}
+
+// refreshViews refreshes all views.
+// Each view is refreshed in its own transaction, so that a failure to refresh
+// one view does not stop the others from being updated.
+func (s *Service) refreshViews(ctx context.Context, finalizedEpoch phase0.Epoch) error {
+	failed := 0
+	for _, view := range s.views {
+		if err := s.refreshView(ctx, view); err != nil {
+			log.Warn().Str("view", view.Name).Err(err).Msg("Failed to refresh view")
+			monitorRefresh(view.Name, false)
+			failed++
+			continue
+		}
+		monitorRefresh(view.Name, true)
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to refresh %d views", failed)
+	}
+
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain metadata")
+		}
+		md.LatestEpoch = finalizedEpoch
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+}
+
+// refreshView refreshes a single view.
+func (s *Service) refreshView(ctx context.Context, view *View) error {
+	log.Trace().Str("view", view.Name).Msg("Refreshing view")
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		return s.viewsSetter.RefreshMaterializedView(ctx, view.Name)
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wealdtech/chaind/services/metrics"
+)
+
+var metricsNamespace = "chaind_views"
+
+var latestEpoch prometheus.Gauge
+var refreshes *prometheus.CounterVec
+
+func registerMetrics(ctx context.Context, monitor metrics.Service) error {
+	if latestEpoch != nil {
+		// Already registered.
+		return nil
+	}
+	if monitor == nil {
+		// No monitor.
+		return nil
+	}
+	if monitor.Presenter() == "prometheus" {
+		return registerPrometheusMetrics(ctx)
+	}
+	return nil
+}
+
+func registerPrometheusMetrics(ctx context.Context) error {
+	latestEpoch = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "latest_epoch",
+		Help:      "Latest finalized epoch for which views were refreshed",
+	})
+	if err := prometheus.Register(latestEpoch); err != nil {
+		return errors.Wrap(err, "failed to register latest_epoch")
+	}
+
+	refreshes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "refreshes_total",
+		Help:      "Number of view refreshes",
+	}, []string{"view", "result"})
+	if err := prometheus.Register(refreshes); err != nil {
+		return errors.Wrap(err, "failed to register refreshes_total")
+	}
+
+	return nil
+}
+
+func monitorLatestEpoch(epoch phase0.Epoch) {
+	if latestEpoch != nil {
+		latestEpoch.Set(float64(epoch))
+	}
+}
+
+func monitorRefresh(view string, succeeded bool) {
+	if refreshes != nil {
+		if succeeded {
+			refreshes.WithLabelValues(view, "succeeded").Inc()
+		} else {
+			refreshes.WithLabelValues(view, "failed").Inc()
+		}
+	}
+}
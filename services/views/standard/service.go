@@ -0,0 +1,121 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standard
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+	"golang.org/x/sync/semaphore"
+)
+
+// Service is a materialized views service.
+type Service struct {
+	chainDB     chaindb.Service
+	viewsSetter chaindb.MaterializedViewsSetter
+	views       []*View
+	activitySem *semaphore.Weighted
+}
+
+// module-wide log.
+var log zerolog.Logger
+
+// New creates a new service.
+func New(ctx context.Context, params ...Parameter) (*Service, error) {
+	parameters, err := parseAndCheckParameters(params...)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem with parameters")
+	}
+
+	// Set logging.
+	log = zerologger.With().Str("service", "views").Str("impl", "standard").Logger().Level(parameters.logLevel)
+	if parameters.logLevelSampler != nil {
+		log = log.Level(zerolog.TraceLevel).Sample(parameters.logLevelSampler)
+	}
+
+	if err := registerMetrics(ctx, parameters.monitor); err != nil {
+		return nil, errors.New("failed to register metrics")
+	}
+
+	viewsSetter, isSetter := parameters.chainDB.(chaindb.MaterializedViewsSetter)
+	if !isSetter {
+		return nil, errors.New("chain DB does not support materialized views")
+	}
+
+	s := &Service{
+		chainDB:     parameters.chainDB,
+		viewsSetter: viewsSetter,
+		views:       parameters.views,
+		activitySem: semaphore.NewWeighted(1),
+	}
+
+	if err := s.createViews(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to create views")
+	}
+
+	return s, nil
+}
+
+// createViews ensures that the database views match the configured views.
+func (s *Service) createViews(ctx context.Context) error {
+	return util.RunTx(ctx, s.chainDB, func(ctx context.Context) error {
+		md, err := s.getMetadata(ctx)
+		if err != nil {
+			return errors.Wrap(err, "failed to obtain metadata")
+		}
+		monitorLatestEpoch(md.LatestEpoch)
+
+		configured := make(map[string]bool)
+		for _, view := range s.views {
+			configured[view.Name] = true
+			query, exists := md.Queries[view.Name]
+			if exists && query == view.Query {
+				continue
+			}
+			if exists {
+				// The definition has changed, so the view needs to be recreated.
+				log.Info().Str("view", view.Name).Msg("View definition changed; recreating")
+				if err := s.viewsSetter.DropMaterializedView(ctx, view.Name); err != nil {
+					return errors.Wrapf(err, "failed to drop view %s", view.Name)
+				}
+			}
+			log.Trace().Str("view", view.Name).Msg("Creating view")
+			if err := s.viewsSetter.SetMaterializedView(ctx, view.Name, view.Query); err != nil {
+				return errors.Wrapf(err, "failed to create view %s", view.Name)
+			}
+			md.Queries[view.Name] = view.Query
+		}
+
+		// Remove views we created previously but that are no longer configured.
+		for name := range md.Queries {
+			if configured[name] {
+				continue
+			}
+			log.Info().Str("view", name).Msg("View no longer configured; dropping")
+			if err := s.viewsSetter.DropMaterializedView(ctx, name); err != nil {
+				return errors.Wrapf(err, "failed to drop view %s", name)
+			}
+			delete(md.Queries, name)
+		}
+
+		if err := s.setMetadata(ctx, md); err != nil {
+			return errors.Wrap(err, "failed to set metadata")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/util"
+)
+
+// finalizerMetadata is the subset of the finalizer's metadata required to
+// select the default snapshot epoch.
+type finalizerMetadata struct {
+	LatestEpoch phase0.Epoch `json:"latest_epoch"`
+}
+
+// exportSnapshot exports a snapshot of finalized data to a file.
+func exportSnapshot(ctx context.Context, path string) error {
+	chainDB, err := startSnapshotDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	md, err := chainDB.Metadata(ctx, "finalizer.standard")
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain finalizer metadata")
+	}
+	if md == nil {
+		return errors.New("no finalized data available to export")
+	}
+	finalizerMD := &finalizerMetadata{}
+	if err := json.Unmarshal(md, finalizerMD); err != nil {
+		return errors.Wrap(err, "failed to parse finalizer metadata")
+	}
+
+	// The export rejects epochs that are not finalized.
+	epoch := finalizerMD.LatestEpoch
+	if viper.GetInt64("snapshot-epoch") >= 0 {
+		epoch = phase0.Epoch(viper.GetInt64("snapshot-epoch"))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to create snapshot file")
+	}
+	log.Info().Uint64("epoch", uint64(epoch)).Str("path", path).Msg("Exporting snapshot")
+	if err := chainDB.ExportSnapshot(ctx, epoch, f); err != nil {
+		_ = f.Close()
+		return errors.Wrap(err, "failed to export snapshot")
+	}
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "failed to close snapshot file")
+	}
+	log.Info().Msg("Snapshot exported")
+
+	return nil
+}
+
+// importSnapshot imports a snapshot from a file.
+func importSnapshot(ctx context.Context, path string) error {
+	chainDB, err := startSnapshotDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open snapshot file")
+	}
+	defer f.Close()
+
+	log.Info().Str("path", path).Msg("Importing snapshot")
+	if err := chainDB.ImportSnapshot(ctx, f); err != nil {
+		return errors.Wrap(err, "failed to import snapshot")
+	}
+	log.Info().Msg("Snapshot imported")
+
+	return nil
+}
+
+// startSnapshotDatabase starts the chain database used for snapshots, ensuring
+// that its schema is up-to-date.
+func startSnapshotDatabase(ctx context.Context) (*postgresqlchaindb.Service, error) {
+	connectionURL, err := chainDBConnectionURL("chaindb")
+	if err != nil {
+		return nil, err
+	}
+	chainDB, err := postgresqlchaindb.New(ctx,
+		postgresqlchaindb.WithLogLevel(util.LogLevel("chaindb")),
+		postgresqlchaindb.WithConnectionURL(connectionURL),
+		postgresqlchaindb.WithPasswordFile(chainDBPasswordFile("chaindb")),
+		postgresqlchaindb.WithMaxConnections(viper.GetUint("chaindb.max-connections")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain database service")
+	}
+	if err := upgradeDatabase(ctx, chainDB); err != nil {
+		return nil, err
+	}
+
+	return chainDB, nil
+}
@@ -0,0 +1,141 @@
+// Copyright © 2020 - 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	postgresqlchaindb "github.com/wealdtech/chaind/services/chaindb/postgresql"
+	"github.com/wealdtech/chaind/services/chaintime"
+	standardchaintime "github.com/wealdtech/chaind/services/chaintime/standard"
+	"github.com/wealdtech/chaind/util"
+)
+
+// maxReportedGaps is the maximum number of gaps listed for each service.
+const maxReportedGaps = 3
+
+// runStats prints the indexing status of each service.  The status is read
+// from the database alone, so is available when chaind is not running.
+func runStats(ctx context.Context) error {
+	chainDB, err := startReadOnlyDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	chainTime, err := databaseChainTime(ctx, chainDB)
+	if err != nil {
+		return err
+	}
+	currentEpoch := chainTime.CurrentEpoch()
+
+	progresses, err := util.ServiceProgress(ctx, chainDB)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain service progress")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SERVICE\tEPOCH\tHEAD\tLAG\tGAPS\n")
+	for _, progress := range progresses {
+		epoch := phase0.Epoch(progress.Latest)
+		if progress.Source.Slots {
+			epoch = chainTime.SlotToEpoch(phase0.Slot(progress.Latest))
+		}
+		lag := uint64(0)
+		if currentEpoch > epoch {
+			lag = uint64(currentEpoch - epoch)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\n", progress.Source.Service, epoch, currentEpoch, lag, formatGaps(progress))
+	}
+
+	return w.Flush()
+}
+
+// formatGaps formats the gaps in the epochs processed by a service.
+func formatGaps(progress *util.Progress) string {
+	if progress.Source.Ranges == "" {
+		// Service does not track the epochs it has processed.
+		return "-"
+	}
+	if len(progress.Gaps) == 0 {
+		return "none"
+	}
+
+	epochs := uint64(0)
+	ranges := make([]string, 0, maxReportedGaps)
+	for i, gap := range progress.Gaps {
+		epochs += uint64(gap.End-gap.Start) + 1
+		if i >= maxReportedGaps {
+			continue
+		}
+		if gap.Start == gap.End {
+			ranges = append(ranges, fmt.Sprintf("%d", gap.Start))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", gap.Start, gap.End))
+		}
+	}
+	if len(progress.Gaps) > maxReportedGaps {
+		ranges = append(ranges, "...")
+	}
+
+	return fmt.Sprintf("%d epochs (%s)", epochs, strings.Join(ranges, ", "))
+}
+
+// databaseChainTime creates a chain time service from the genesis and spec
+// stored in the database, so that it does not require a beacon node.
+func databaseChainTime(ctx context.Context, chainDB *postgresqlchaindb.Service) (chaintime.Service, error) {
+	config := &databaseChainConfig{chainDB: chainDB}
+	chainTime, err := standardchaintime.New(ctx,
+		standardchaintime.WithLogLevel(util.LogLevel("chaintime")),
+		standardchaintime.WithGenesisTimeProvider(config),
+		standardchaintime.WithSpecProvider(config),
+		standardchaintime.WithForkScheduleProvider(config),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start chain time service; has chaind run against this database?")
+	}
+
+	return chainTime, nil
+}
+
+// databaseChainConfig provides the chain configuration stored in the database.
+type databaseChainConfig struct {
+	chainDB *postgresqlchaindb.Service
+}
+
+// GenesisTime provides the genesis time of the chain.
+func (c *databaseChainConfig) GenesisTime(ctx context.Context) (time.Time, error) {
+	genesis, err := c.chainDB.Genesis(ctx)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to obtain genesis")
+	}
+
+	return genesis.GenesisTime, nil
+}
+
+// Spec provides the spec information of the chain.
+func (c *databaseChainConfig) Spec(ctx context.Context) (map[string]interface{}, error) {
+	return c.chainDB.ChainSpec(ctx)
+}
+
+// ForkSchedule provides details of past and future changes in the chain's fork version.
+func (c *databaseChainConfig) ForkSchedule(ctx context.Context) ([]*phase0.Fork, error) {
+	return c.chainDB.ForkSchedule(ctx)
+}
@@ -0,0 +1,63 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/wealdtech/chaind/services/tracing/otlphttp"
+	"github.com/wealdtech/chaind/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+// initTracing initialises the tracing provider, if tracing is configured.
+// The returned function flushes any outstanding spans and shuts down the provider.
+func initTracing(ctx context.Context) (func(context.Context), error) {
+	tracingAddress := viper.GetString("tracing-address")
+	if tracingAddress == "" {
+		return func(context.Context) {}, nil
+	}
+
+	exporter, err := otlphttp.New(ctx,
+		otlphttp.WithLogLevel(util.LogLevel("tracing")),
+		otlphttp.WithLogLevelSampler(util.LogLevelSampler("tracing")),
+		otlphttp.WithEndpoint(tracingAddress),
+		otlphttp.WithHeaders(viper.GetStringMapString("tracing-headers")),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create tracing exporter")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(viper.GetFloat64("tracing-sample-ratio")))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceNameKey.String("chaind"),
+			semconv.ServiceVersionKey.String(ReleaseVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	log.Info().Str("tracing_address", tracingAddress).Msg("Sending traces")
+
+	return func(ctx context.Context) {
+		if err := provider.Shutdown(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to shut down tracing")
+		}
+	}, nil
+}
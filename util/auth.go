@@ -0,0 +1,191 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Role is the role of an authenticated client.
+type Role int
+
+const (
+	// RoleNone is the role of a client without valid credentials.
+	RoleNone Role = iota
+	// RoleRead allows access to data.
+	RoleRead
+	// RoleAdmin allows access to data and to administrative functions.
+	RoleAdmin
+)
+
+// Authenticator authenticates HTTP requests by API key or by JSON web token,
+// supplied as a bearer token.  It is safe for concurrent use.
+type Authenticator struct {
+	readKeys  [][]byte
+	adminKeys [][]byte
+	jwtSecret []byte
+}
+
+// NewAuthenticator creates an authenticator that accepts the given API keys
+// for the read and admin roles, and JSON web tokens signed with HS256 using
+// the given secret, if supplied.  A token's role is taken from its "role"
+// claim, which must be "read" or "admin".
+func NewAuthenticator(readKeys []string, adminKeys []string, jwtSecret string) *Authenticator {
+	a := &Authenticator{
+		readKeys:  make([][]byte, 0, len(readKeys)),
+		adminKeys: make([][]byte, 0, len(adminKeys)),
+	}
+	for _, key := range readKeys {
+		if key != "" {
+			a.readKeys = append(a.readKeys, []byte(key))
+		}
+	}
+	for _, key := range adminKeys {
+		if key != "" {
+			a.adminKeys = append(a.adminKeys, []byte(key))
+		}
+	}
+	if jwtSecret != "" {
+		a.jwtSecret = []byte(jwtSecret)
+	}
+
+	return a
+}
+
+// Role returns the role granted by the credentials of a request.
+// A nil authenticator grants no role.
+func (a *Authenticator) Role(r *http.Request) Role {
+	return a.TokenRole(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+}
+
+// TokenRole returns the role granted by a bearer token, for servers that do
+// not receive HTTP requests.  A nil authenticator grants no role.
+func (a *Authenticator) TokenRole(token string) Role {
+	if a == nil || token == "" {
+		return RoleNone
+	}
+
+	// All keys are compared, so that the time taken does not reveal which matched.
+	role := RoleNone
+	for _, key := range a.readKeys {
+		if subtle.ConstantTimeCompare([]byte(token), key) == 1 && role < RoleRead {
+			role = RoleRead
+		}
+	}
+	for _, key := range a.adminKeys {
+		if subtle.ConstantTimeCompare([]byte(token), key) == 1 {
+			role = RoleAdmin
+		}
+	}
+	if role == RoleNone && a.jwtSecret != nil {
+		role = a.jwtRole(token, time.Now())
+	}
+
+	return role
+}
+
+// Require wraps a handler, requiring requests to supply credentials with at
+// least the given role.  A nil authenticator does not require credentials.
+func (a *Authenticator) Require(role Role, handler http.Handler) http.Handler {
+	if a == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		granted := a.Role(r)
+		switch {
+		case granted == RoleNone:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		case granted < role:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		default:
+			handler.ServeHTTP(w, r)
+		}
+	})
+}
+
+// jwtHeader is the header of a JSON web token.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+// jwtClaims are the claims of a JSON web token used by the authenticator.
+type jwtClaims struct {
+	Role      string `json:"role"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// jwtRole returns the role granted by a JSON web token, or RoleNone if the
+// token is invalid, is not valid at the given time or does not grant a role.
+func (a *Authenticator) jwtRole(token string, now time.Time) Role {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return RoleNone
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return RoleNone
+	}
+	mac := hmac.New(sha256.New, a.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return RoleNone
+	}
+
+	// The algorithm is checked as well as the signature, so that tokens issued
+	// for other algorithms are not accepted.
+	var header jwtHeader
+	if !decodeJWTPart(parts[0], &header) || header.Alg != "HS256" {
+		return RoleNone
+	}
+	var claims jwtClaims
+	if !decodeJWTPart(parts[1], &claims) {
+		return RoleNone
+	}
+	if claims.ExpiresAt != nil && now.Unix() >= *claims.ExpiresAt {
+		return RoleNone
+	}
+	if claims.NotBefore != nil && now.Unix() < *claims.NotBefore {
+		return RoleNone
+	}
+
+	switch claims.Role {
+	case "read":
+		return RoleRead
+	case "admin":
+		return RoleAdmin
+	default:
+		return RoleNone
+	}
+}
+
+// decodeJWTPart decodes a base64-encoded JSON part of a JSON web token.
+func decodeJWTPart(part string, value interface{}) bool {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(data, value) == nil
+}
@@ -0,0 +1,171 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+// signJWT creates an HS256 JSON web token with the given claims.
+func signJWT(secret string, claims string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(header + "." + payload))
+	return fmt.Sprintf("%s.%s.%s", header, payload, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func TestAuthenticatorRole(t *testing.T) {
+	authenticator := util.NewAuthenticator([]string{"readkey"}, []string{"adminkey"}, "secret")
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name  string
+		token string
+		role  util.Role
+	}{
+		{
+			name: "None",
+			role: util.RoleNone,
+		},
+		{
+			name:  "ReadKey",
+			token: "readkey",
+			role:  util.RoleRead,
+		},
+		{
+			name:  "AdminKey",
+			token: "adminkey",
+			role:  util.RoleAdmin,
+		},
+		{
+			name:  "UnknownKey",
+			token: "otherkey",
+			role:  util.RoleNone,
+		},
+		{
+			name:  "JWTRead",
+			token: signJWT("secret", fmt.Sprintf(`{"role":"read","exp":%d}`, future)),
+			role:  util.RoleRead,
+		},
+		{
+			name:  "JWTAdmin",
+			token: signJWT("secret", `{"role":"admin"}`),
+			role:  util.RoleAdmin,
+		},
+		{
+			name:  "JWTExpired",
+			token: signJWT("secret", fmt.Sprintf(`{"role":"admin","exp":%d}`, past)),
+			role:  util.RoleNone,
+		},
+		{
+			name:  "JWTNotYetValid",
+			token: signJWT("secret", fmt.Sprintf(`{"role":"admin","nbf":%d}`, future)),
+			role:  util.RoleNone,
+		},
+		{
+			name:  "JWTWrongSecret",
+			token: signJWT("other", `{"role":"admin"}`),
+			role:  util.RoleNone,
+		},
+		{
+			name:  "JWTUnknownRole",
+			token: signJWT("secret", `{"role":"owner"}`),
+			role:  util.RoleNone,
+		},
+		{
+			name:  "JWTMalformed",
+			token: "a.b.c",
+			role:  util.RoleNone,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			require.Equal(t, test.role, authenticator.Role(req))
+			require.Equal(t, test.role, authenticator.TokenRole(test.token))
+		})
+	}
+}
+
+func TestAuthenticatorRequire(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// A nil authenticator does not require credentials.
+	var nilAuthenticator *util.Authenticator
+	rec := httptest.NewRecorder()
+	nilAuthenticator.Require(util.RoleAdmin, handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	authenticator := util.NewAuthenticator([]string{"readkey"}, []string{"adminkey"}, "")
+	tests := []struct {
+		name   string
+		role   util.Role
+		token  string
+		status int
+	}{
+		{
+			name:   "Missing",
+			role:   util.RoleRead,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "Read",
+			role:   util.RoleRead,
+			token:  "readkey",
+			status: http.StatusOK,
+		},
+		{
+			name:   "AdminForRead",
+			role:   util.RoleRead,
+			token:  "adminkey",
+			status: http.StatusOK,
+		},
+		{
+			name:   "ReadForAdmin",
+			role:   util.RoleAdmin,
+			token:  "readkey",
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			rec := httptest.NewRecorder()
+			authenticator.Require(test.role, handler).ServeHTTP(rec, req)
+			require.Equal(t, test.status, rec.Code)
+		})
+	}
+}
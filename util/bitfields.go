@@ -0,0 +1,86 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// maxBitfieldLength is the maximum length in bytes of a bitfield that can be expanded.
+const maxBitfieldLength = 1024 * 1024
+
+// CompressBitfield compresses a bitfield, such as the aggregation bits of an
+// attestation, by run-length encoding its bits.  Aggregation bits are mostly
+// long runs of set bits, so compress to a few bytes.
+// The compressed bitfield is the length of the bitfield in bytes followed by
+// the lengths of alternating runs of unset and set bits, starting with unset
+// bits, as unsigned varints.  Trailing unset bits are omitted.
+func CompressBitfield(bitfield []byte) []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	res := make([]byte, 0, 8)
+	res = append(res, buf[:binary.PutUvarint(buf, uint64(len(bitfield)))]...)
+
+	set := false
+	run := uint64(0)
+	for i := 0; i < len(bitfield)*8; i++ {
+		if (bitfield[i/8]&(1<<(i%8)) != 0) != set {
+			res = append(res, buf[:binary.PutUvarint(buf, run)]...)
+			set = !set
+			run = 0
+		}
+		run++
+	}
+	if set {
+		res = append(res, buf[:binary.PutUvarint(buf, run)]...)
+	}
+
+	return res
+}
+
+// ExpandBitfield expands a bitfield compressed by CompressBitfield.
+func ExpandBitfield(compressed []byte) ([]byte, error) {
+	length, n := binary.Uvarint(compressed)
+	if n <= 0 {
+		return nil, errors.New("invalid compressed bitfield length")
+	}
+	if length > maxBitfieldLength {
+		return nil, errors.New("compressed bitfield too long")
+	}
+	compressed = compressed[n:]
+
+	bitfield := make([]byte, length)
+	bits := length * 8
+	pos := uint64(0)
+	set := false
+	for len(compressed) > 0 {
+		run, n := binary.Uvarint(compressed)
+		if n <= 0 {
+			return nil, errors.New("invalid compressed bitfield run")
+		}
+		compressed = compressed[n:]
+		if run > bits-pos {
+			return nil, errors.New("compressed bitfield run exceeds its length")
+		}
+		if set {
+			for i := pos; i < pos+run; i++ {
+				bitfield[i/8] |= 1 << (i % 8)
+			}
+		}
+		pos += run
+		set = !set
+	}
+
+	return bitfield, nil
+}
@@ -0,0 +1,100 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestBitfieldCompression(t *testing.T) {
+	tests := []struct {
+		name       string
+		bitfield   []byte
+		compressed []byte
+	}{
+		{
+			name:       "Empty",
+			bitfield:   []byte{},
+			compressed: []byte{0x00},
+		},
+		{
+			name:       "Unset",
+			bitfield:   []byte{0x00, 0x00},
+			compressed: []byte{0x02},
+		},
+		{
+			name:       "Full",
+			bitfield:   []byte{0xff, 0xff, 0xff, 0xff, 0x01},
+			compressed: []byte{0x05, 0x00, 0x21},
+		},
+		{
+			name:       "Mixed",
+			bitfield:   []byte{0xfe, 0xff, 0x10},
+			compressed: []byte{0x03, 0x01, 0x0f, 0x04, 0x01},
+		},
+		{
+			name:       "Long",
+			bitfield:   append(make([]byte, 20), 0xff),
+			compressed: []byte{0x15, 0xa0, 0x01, 0x08},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			compressed := util.CompressBitfield(test.bitfield)
+			require.Equal(t, test.compressed, compressed)
+			bitfield, err := util.ExpandBitfield(compressed)
+			require.NoError(t, err)
+			require.Equal(t, test.bitfield, bitfield)
+		})
+	}
+}
+
+func TestExpandBitfieldInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		compressed []byte
+		err        string
+	}{
+		{
+			name: "Nil",
+			err:  "invalid compressed bitfield length",
+		},
+		{
+			name:       "TooLong",
+			compressed: []byte{0xff, 0xff, 0xff, 0x7f},
+			err:        "compressed bitfield too long",
+		},
+		{
+			name:       "RunInvalid",
+			compressed: []byte{0x01, 0x80},
+			err:        "invalid compressed bitfield run",
+		},
+		{
+			name:       "RunTooLong",
+			compressed: []byte{0x01, 0x00, 0x09},
+			err:        "compressed bitfield run exceeds its length",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := util.ExpandBitfield(test.compressed)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"sort"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+)
+
+// EpochRange is an inclusive range of epochs.
+type EpochRange struct {
+	Start phase0.Epoch `json:"start"`
+	End   phase0.Epoch `json:"end"`
+}
+
+// EpochRanges is a set of epochs, held as ranges that are sorted, do not
+// overlap and are not adjacent to each other.
+type EpochRanges []EpochRange
+
+// Contains returns true if the set contains the given epoch.
+func (r EpochRanges) Contains(epoch phase0.Epoch) bool {
+	i := sort.Search(len(r), func(i int) bool { return r[i].End >= epoch })
+	return i < len(r) && r[i].Start <= epoch
+}
+
+// Lowest returns the lowest epoch in the set.  It returns false if the set is empty.
+func (r EpochRanges) Lowest() (phase0.Epoch, bool) {
+	if len(r) == 0 {
+		return 0, false
+	}
+	return r[0].Start, true
+}
+
+// Highest returns the highest epoch in the set.  It returns false if the set is empty.
+func (r EpochRanges) Highest() (phase0.Epoch, bool) {
+	if len(r) == 0 {
+		return 0, false
+	}
+	return r[len(r)-1].End, true
+}
+
+// Add adds an epoch to the set.
+func (r *EpochRanges) Add(epoch phase0.Epoch) {
+	r.AddRange(epoch, epoch)
+}
+
+// AddRange adds the epochs from start to end inclusive to the set.
+func (r *EpochRanges) AddRange(start phase0.Epoch, end phase0.Epoch) {
+	if end < start {
+		return
+	}
+
+	res := make(EpochRanges, 0, len(*r)+1)
+	added := false
+	for _, existing := range *r {
+		switch {
+		case existing.End < start && start-existing.End > 1:
+			// Entirely before the new range.
+			res = append(res, existing)
+		case existing.Start > end && existing.Start-end > 1:
+			// Entirely after the new range.
+			if !added {
+				res = append(res, EpochRange{Start: start, End: end})
+				added = true
+			}
+			res = append(res, existing)
+		default:
+			// Overlaps or is adjacent to the new range; merge them.
+			if existing.Start < start {
+				start = existing.Start
+			}
+			if existing.End > end {
+				end = existing.End
+			}
+		}
+	}
+	if !added {
+		res = append(res, EpochRange{Start: start, End: end})
+	}
+	*r = res
+}
+
+// RemoveFrom removes all epochs from the given epoch onwards from the set.
+func (r *EpochRanges) RemoveFrom(epoch phase0.Epoch) {
+	res := make(EpochRanges, 0, len(*r))
+	for _, existing := range *r {
+		if existing.Start >= epoch {
+			break
+		}
+		if existing.End >= epoch {
+			existing.End = epoch - 1
+		}
+		res = append(res, existing)
+	}
+	*r = res
+}
+
+// Gaps returns the ranges of epochs from start to end inclusive that are not in the set.
+func (r EpochRanges) Gaps(start phase0.Epoch, end phase0.Epoch) EpochRanges {
+	gaps := make(EpochRanges, 0)
+	if end < start {
+		return gaps
+	}
+
+	next := start
+	for _, existing := range r {
+		if existing.End < next {
+			continue
+		}
+		if existing.Start > end {
+			break
+		}
+		if existing.Start > next {
+			gaps = append(gaps, EpochRange{Start: next, End: existing.Start - 1})
+		}
+		if existing.End >= end {
+			return gaps
+		}
+		next = existing.End + 1
+	}
+	gaps = append(gaps, EpochRange{Start: next, End: end})
+
+	return gaps
+}
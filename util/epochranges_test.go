@@ -0,0 +1,183 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestEpochRangesAdd(t *testing.T) {
+	tests := []struct {
+		name     string
+		ranges   util.EpochRanges
+		start    phase0.Epoch
+		end      phase0.Epoch
+		expected util.EpochRanges
+	}{
+		{
+			name:     "Empty",
+			start:    5,
+			end:      5,
+			expected: util.EpochRanges{{Start: 5, End: 5}},
+		},
+		{
+			name:     "Invalid",
+			ranges:   util.EpochRanges{{Start: 1, End: 2}},
+			start:    5,
+			end:      4,
+			expected: util.EpochRanges{{Start: 1, End: 2}},
+		},
+		{
+			name:     "Before",
+			ranges:   util.EpochRanges{{Start: 5, End: 6}},
+			start:    1,
+			end:      2,
+			expected: util.EpochRanges{{Start: 1, End: 2}, {Start: 5, End: 6}},
+		},
+		{
+			name:     "After",
+			ranges:   util.EpochRanges{{Start: 1, End: 2}},
+			start:    5,
+			end:      6,
+			expected: util.EpochRanges{{Start: 1, End: 2}, {Start: 5, End: 6}},
+		},
+		{
+			name:     "AdjacentAfter",
+			ranges:   util.EpochRanges{{Start: 1, End: 2}},
+			start:    3,
+			end:      3,
+			expected: util.EpochRanges{{Start: 1, End: 3}},
+		},
+		{
+			name:     "AdjacentBefore",
+			ranges:   util.EpochRanges{{Start: 4, End: 6}},
+			start:    3,
+			end:      3,
+			expected: util.EpochRanges{{Start: 3, End: 6}},
+		},
+		{
+			name:     "FillsGap",
+			ranges:   util.EpochRanges{{Start: 1, End: 2}, {Start: 4, End: 6}},
+			start:    3,
+			end:      3,
+			expected: util.EpochRanges{{Start: 1, End: 6}},
+		},
+		{
+			name:     "Contained",
+			ranges:   util.EpochRanges{{Start: 1, End: 6}},
+			start:    3,
+			end:      4,
+			expected: util.EpochRanges{{Start: 1, End: 6}},
+		},
+		{
+			name:     "Spanning",
+			ranges:   util.EpochRanges{{Start: 2, End: 3}, {Start: 5, End: 6}, {Start: 10, End: 12}},
+			start:    1,
+			end:      7,
+			expected: util.EpochRanges{{Start: 1, End: 7}, {Start: 10, End: 12}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.ranges.AddRange(test.start, test.end)
+			require.Equal(t, test.expected, test.ranges)
+		})
+	}
+}
+
+func TestEpochRangesRemoveFrom(t *testing.T) {
+	ranges := util.EpochRanges{{Start: 1, End: 3}, {Start: 5, End: 8}, {Start: 10, End: 12}}
+	ranges.RemoveFrom(6)
+	require.Equal(t, util.EpochRanges{{Start: 1, End: 3}, {Start: 5, End: 5}}, ranges)
+	ranges.RemoveFrom(5)
+	require.Equal(t, util.EpochRanges{{Start: 1, End: 3}}, ranges)
+	ranges.RemoveFrom(0)
+	require.Empty(t, ranges)
+}
+
+func TestEpochRangesGaps(t *testing.T) {
+	ranges := util.EpochRanges{{Start: 2, End: 3}, {Start: 5, End: 6}, {Start: 10, End: 12}}
+
+	tests := []struct {
+		name     string
+		start    phase0.Epoch
+		end      phase0.Epoch
+		expected util.EpochRanges
+	}{
+		{
+			name:     "Invalid",
+			start:    5,
+			end:      4,
+			expected: util.EpochRanges{},
+		},
+		{
+			name:     "All",
+			start:    0,
+			end:      15,
+			expected: util.EpochRanges{{Start: 0, End: 1}, {Start: 4, End: 4}, {Start: 7, End: 9}, {Start: 13, End: 15}},
+		},
+		{
+			name:     "Inside",
+			start:    5,
+			end:      6,
+			expected: util.EpochRanges{},
+		},
+		{
+			name:     "Partial",
+			start:    3,
+			end:      11,
+			expected: util.EpochRanges{{Start: 4, End: 4}, {Start: 7, End: 9}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, ranges.Gaps(test.start, test.end))
+		})
+	}
+}
+
+func TestEpochRangesContains(t *testing.T) {
+	ranges := util.EpochRanges{{Start: 2, End: 3}, {Start: 5, End: 6}}
+	require.False(t, ranges.Contains(1))
+	require.True(t, ranges.Contains(2))
+	require.True(t, ranges.Contains(3))
+	require.False(t, ranges.Contains(4))
+	require.True(t, ranges.Contains(6))
+	require.False(t, ranges.Contains(7))
+
+	lowest, exists := ranges.Lowest()
+	require.True(t, exists)
+	require.Equal(t, phase0.Epoch(2), lowest)
+	highest, exists := ranges.Highest()
+	require.True(t, exists)
+	require.Equal(t, phase0.Epoch(6), highest)
+}
+
+func TestEpochRangesJSON(t *testing.T) {
+	ranges := util.EpochRanges{{Start: 2, End: 3}, {Start: 5, End: 6}}
+	data, err := json.Marshal(ranges)
+	require.NoError(t, err)
+	require.Equal(t, `[{"start":2,"end":3},{"start":5,"end":6}]`, string(data))
+
+	var res util.EpochRanges
+	require.NoError(t, json.Unmarshal(data, &res))
+	require.Equal(t, ranges, res)
+}
@@ -0,0 +1,204 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// ProcessedFromLatest returns the epochs processed according to metadata written by
+// older versions of chaind, which processed all epochs up to the latest epoch other
+// than those missed.
+func ProcessedFromLatest(latest phase0.Epoch, missed []phase0.Epoch) EpochRanges {
+	if latest == 0 && len(missed) == 0 {
+		// Nothing processed.
+		return nil
+	}
+
+	missed = append([]phase0.Epoch{}, missed...)
+	sort.Slice(missed, func(i int, j int) bool { return missed[i] < missed[j] })
+	processed := make(EpochRanges, 0)
+	start := phase0.Epoch(0)
+	for _, missedEpoch := range missed {
+		if missedEpoch > start {
+			processed.AddRange(start, missedEpoch-1)
+		}
+		start = missedEpoch + 1
+	}
+	processed.AddRange(start, latest)
+
+	return processed
+}
+
+// CompletedInGaps returns the epochs from start to end inclusive that are not in the
+// processed epochs but have a completion marker for the given service.  Completion
+// markers are written in the same transaction as the data for their epoch, so are
+// authoritative.
+func CompletedInGaps(ctx context.Context,
+	provider chaindb.EpochCompletionsProvider,
+	service string,
+	processed EpochRanges,
+	start phase0.Epoch,
+	end phase0.Epoch,
+) (
+	[]phase0.Epoch,
+	error,
+) {
+	res := make([]phase0.Epoch, 0)
+	for _, gap := range processed.Gaps(start, end) {
+		epochs, err := provider.CompletedEpochs(ctx, service, gap.Start, gap.End)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain completed epochs")
+		}
+		res = append(res, epochs...)
+	}
+
+	return res, nil
+}
+
+// CompletedRun returns the run of epochs from start, going no further than end, that
+// have a completion marker for the given service.  Epochs can be completed after
+// processing has started, for example by a concurrent handler or by backfill.
+func CompletedRun(ctx context.Context,
+	provider chaindb.EpochCompletionsProvider,
+	service string,
+	start phase0.Epoch,
+	end phase0.Epoch,
+) (
+	[]phase0.Epoch,
+	error,
+) {
+	completed, err := provider.CompletedEpochs(ctx, service, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to obtain completed epochs")
+	}
+	res := make([]phase0.Epoch, 0)
+	for _, epoch := range completed {
+		if epoch != start+phase0.Epoch(len(res)) {
+			break
+		}
+		res = append(res, epoch)
+	}
+
+	return res, nil
+}
+
+// EpochResult is the result of processing an epoch.
+type EpochResult struct {
+	Epoch phase0.Epoch
+	// FetchErr is set if the data for the epoch could not be fetched.
+	FetchErr error
+	// StoreErr is set if the data for the epoch could not be stored.
+	StoreErr error
+}
+
+// ProcessGapWithWorkers processes the epochs in a gap with a number of workers, each
+// of which processes up to epochsPerRange epochs at a time with the supplied process
+// function, in its own transaction.
+// As epochs can complete out of order, record is only called once all epochs up to a
+// given epoch have completed, so that an epoch is never recorded as processed while
+// an earlier epoch is still in progress.  Epochs that cannot be fetched are left
+// unrecorded, to be fetched again later; an epoch that cannot be stored stops
+// processing of the gap.
+func ProcessGapWithWorkers(ctx context.Context,
+	gap EpochRange,
+	workers int,
+	epochsPerRange int,
+	process func(ctx context.Context, worker int, epochRange EpochRange) []*EpochResult,
+	record func(ctx context.Context, epochs ...phase0.Epoch) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	epochRanges := make(chan EpochRange)
+	go func() {
+		defer close(epochRanges)
+		for start := gap.Start; start <= gap.End; start += phase0.Epoch(epochsPerRange) {
+			end := start + phase0.Epoch(epochsPerRange) - 1
+			if end > gap.End {
+				end = gap.End
+			}
+			select {
+			case epochRanges <- EpochRange{Start: start, End: end}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan *EpochResult)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for epochRange := range epochRanges {
+				for _, result := range process(ctx, worker, epochRange) {
+					results <- result
+				}
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Completed epochs, and if they were stored, awaiting the high-water mark.
+	completed := make(map[phase0.Epoch]bool)
+	next := gap.Start
+	var err error
+	for result := range results {
+		if err != nil {
+			// Drain the results of the remaining workers.
+			continue
+		}
+		switch {
+		case result.StoreErr != nil:
+			err = errors.Wrapf(result.StoreErr, "failed to store epoch %d", result.Epoch)
+			cancel()
+			continue
+		case result.FetchErr != nil:
+			completed[result.Epoch] = false
+		default:
+			completed[result.Epoch] = true
+		}
+
+		processed := make([]phase0.Epoch, 0)
+		for {
+			stored, exists := completed[next]
+			if !exists {
+				break
+			}
+			delete(completed, next)
+			if stored {
+				processed = append(processed, next)
+			}
+			next++
+		}
+		if len(processed) > 0 {
+			if err = record(ctx, processed...); err != nil {
+				cancel()
+			}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,174 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+// completionsProvider provides a fixed set of completed epochs.
+type completionsProvider struct {
+	completed []phase0.Epoch
+}
+
+func (p *completionsProvider) CompletedEpochs(_ context.Context, _ string, startEpoch phase0.Epoch, endEpoch phase0.Epoch) ([]phase0.Epoch, error) {
+	res := make([]phase0.Epoch, 0)
+	for _, epoch := range p.completed {
+		if epoch >= startEpoch && epoch <= endEpoch {
+			res = append(res, epoch)
+		}
+	}
+	return res, nil
+}
+
+func TestProcessedFromLatest(t *testing.T) {
+	tests := []struct {
+		name     string
+		latest   phase0.Epoch
+		missed   []phase0.Epoch
+		expected util.EpochRanges
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name:     "Latest",
+			latest:   10,
+			expected: util.EpochRanges{{Start: 0, End: 10}},
+		},
+		{
+			name:     "Missed",
+			latest:   10,
+			missed:   []phase0.Epoch{7, 0, 3, 4},
+			expected: util.EpochRanges{{Start: 1, End: 2}, {Start: 5, End: 6}, {Start: 8, End: 10}},
+		},
+		{
+			name:     "MissedLatest",
+			latest:   10,
+			missed:   []phase0.Epoch{10},
+			expected: util.EpochRanges{{Start: 0, End: 9}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, util.ProcessedFromLatest(test.latest, test.missed))
+		})
+	}
+}
+
+func TestCompletedInGaps(t *testing.T) {
+	provider := &completionsProvider{completed: []phase0.Epoch{1, 2, 5, 8, 12}}
+	processed := util.EpochRanges{{Start: 0, End: 1}, {Start: 5, End: 6}}
+
+	epochs, err := util.CompletedInGaps(context.Background(), provider, "test", processed, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Epoch{2, 8}, epochs)
+}
+
+func TestCompletedRun(t *testing.T) {
+	provider := &completionsProvider{completed: []phase0.Epoch{3, 4, 5, 7, 8}}
+
+	epochs, err := util.CompletedRun(context.Background(), provider, "test", 3, 10)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Epoch{3, 4, 5}, epochs)
+
+	epochs, err = util.CompletedRun(context.Background(), provider, "test", 6, 10)
+	require.NoError(t, err)
+	require.Empty(t, epochs)
+
+	epochs, err = util.CompletedRun(context.Background(), provider, "test", 7, 7)
+	require.NoError(t, err)
+	require.Equal(t, []phase0.Epoch{7}, epochs)
+}
+
+func TestProcessGapWithWorkers(t *testing.T) {
+	tests := []struct {
+		name      string
+		fetchErrs map[phase0.Epoch]bool
+		storeErrs map[phase0.Epoch]bool
+		expected  []phase0.Epoch
+		err       string
+	}{
+		{
+			name:     "Good",
+			expected: []phase0.Epoch{10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20},
+		},
+		{
+			name:      "FetchErrors",
+			fetchErrs: map[phase0.Epoch]bool{12: true, 20: true},
+			expected:  []phase0.Epoch{10, 11, 13, 14, 15, 16, 17, 18, 19},
+		},
+		{
+			name:      "StoreError",
+			storeErrs: map[phase0.Epoch]bool{13: true},
+			err:       "failed to store epoch 13: store failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mu sync.Mutex
+			recorded := make([]phase0.Epoch, 0)
+			err := util.ProcessGapWithWorkers(context.Background(),
+				util.EpochRange{Start: 10, End: 20},
+				3,
+				2,
+				func(_ context.Context, _ int, epochRange util.EpochRange) []*util.EpochResult {
+					results := make([]*util.EpochResult, 0)
+					for epoch := epochRange.Start; epoch <= epochRange.End; epoch++ {
+						result := &util.EpochResult{Epoch: epoch}
+						switch {
+						case test.fetchErrs[epoch]:
+							result.FetchErr = errors.New("fetch failed")
+						case test.storeErrs[epoch]:
+							result.StoreErr = errors.New("store failed")
+						}
+						results = append(results, result)
+					}
+					return results
+				},
+				func(_ context.Context, epochs ...phase0.Epoch) error {
+					mu.Lock()
+					defer mu.Unlock()
+					// Epochs are recorded in order.
+					require.True(t, sort.SliceIsSorted(epochs, func(i int, j int) bool { return epochs[i] < epochs[j] }))
+					if len(recorded) > 0 {
+						require.Greater(t, epochs[0], recorded[len(recorded)-1])
+					}
+					recorded = append(recorded, epochs...)
+					return nil
+				},
+			)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				// Nothing at or beyond the failed epoch is recorded.
+				for _, epoch := range recorded {
+					require.Less(t, epoch, phase0.Epoch(13))
+				}
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expected, recorded)
+			}
+		})
+	}
+}
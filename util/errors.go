@@ -0,0 +1,112 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Error classes returned by ClassifyError.
+const (
+	ErrorClassNodeTimeout              = "node_timeout"
+	ErrorClassNodeNotFound             = "node_not_found"
+	ErrorClassNodeUnavailable          = "node_unavailable"
+	ErrorClassNodeError                = "node_error"
+	ErrorClassDecode                   = "decode"
+	ErrorClassDatabaseConstraint       = "database_constraint"
+	ErrorClassTransactionSerialization = "transaction_serialization"
+	ErrorClassDatabase                 = "database"
+	ErrorClassOther                    = "other"
+)
+
+// ErrorClasses are all of the classes returned by ClassifyError.
+var ErrorClasses = []string{
+	ErrorClassNodeTimeout,
+	ErrorClassNodeNotFound,
+	ErrorClassNodeUnavailable,
+	ErrorClassNodeError,
+	ErrorClassDecode,
+	ErrorClassDatabaseConstraint,
+	ErrorClassTransactionSerialization,
+	ErrorClassDatabase,
+	ErrorClassOther,
+}
+
+// sqlStateError is implemented by errors returned from the database, and
+// provides the SQLSTATE code of the error.
+type sqlStateError interface {
+	SQLState() string
+}
+
+// statusRegex matches the status code in errors returned by the beacon node client
+// for unsuccessful requests.
+var statusRegex = regexp.MustCompile(`failed with status (\d{3})`)
+
+// ClassifyError returns the class of an error, to allow failures to be
+// counted by their cause.
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var sqlErr sqlStateError
+	if errors.As(err, &sqlErr) {
+		code := sqlErr.SQLState()
+		switch {
+		case strings.HasPrefix(code, "23"):
+			// Integrity constraint violation.
+			return ErrorClassDatabaseConstraint
+		case code == "40001" || code == "40P01":
+			// Serialization failure or deadlock.
+			return ErrorClassTransactionSerialization
+		default:
+			return ErrorClassDatabase
+		}
+	}
+
+	if matches := statusRegex.FindStringSubmatch(err.Error()); matches != nil {
+		switch matches[1] {
+		case "404":
+			return ErrorClassNodeNotFound
+		case "503":
+			return ErrorClassNodeUnavailable
+		default:
+			return ErrorClassNodeError
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassNodeTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassNodeTimeout
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) ||
+		errors.As(err, &typeErr) ||
+		strings.Contains(err.Error(), "failed to parse") {
+		return ErrorClassDecode
+	}
+
+	return ErrorClassOther
+}
@@ -0,0 +1,122 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+type sqlError struct {
+	code string
+}
+
+func (e *sqlError) Error() string {
+	return fmt.Sprintf("SQLSTATE %s", e.code)
+}
+
+func (e *sqlError) SQLState() string {
+	return e.code
+}
+
+type timeoutError struct{}
+
+func (*timeoutError) Error() string   { return "timeout" }
+func (*timeoutError) Timeout() bool   { return true }
+func (*timeoutError) Temporary() bool { return true }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "Nil",
+			expected: "",
+		},
+		{
+			name:     "DeadlineExceeded",
+			err:      errors.Wrap(context.DeadlineExceeded, "failed to call GET endpoint"),
+			expected: util.ErrorClassNodeTimeout,
+		},
+		{
+			name:     "NetTimeout",
+			err:      errors.Wrap(&timeoutError{}, "failed to call GET endpoint"),
+			expected: util.ErrorClassNodeTimeout,
+		},
+		{
+			name:     "NotFound",
+			err:      errors.Wrap(errors.New("POST failed with status 404: not found"), "failed to obtain validators"),
+			expected: util.ErrorClassNodeNotFound,
+		},
+		{
+			name:     "Unavailable",
+			err:      errors.New("GET failed with status 503: syncing"),
+			expected: util.ErrorClassNodeUnavailable,
+		},
+		{
+			name:     "ServerError",
+			err:      errors.New("GET failed with status 500: internal error"),
+			expected: util.ErrorClassNodeError,
+		},
+		{
+			name:     "Syntax",
+			err:      errors.Wrap(&json.SyntaxError{}, "failed to decode"),
+			expected: util.ErrorClassDecode,
+		},
+		{
+			name:     "Parse",
+			err:      errors.New("failed to parse signed beacon block"),
+			expected: util.ErrorClassDecode,
+		},
+		{
+			name:     "Constraint",
+			err:      errors.Wrap(&sqlError{code: "23505"}, "failed to set block"),
+			expected: util.ErrorClassDatabaseConstraint,
+		},
+		{
+			name:     "Serialization",
+			err:      errors.Wrap(&sqlError{code: "40001"}, "failed to commit transaction"),
+			expected: util.ErrorClassTransactionSerialization,
+		},
+		{
+			name:     "Deadlock",
+			err:      &sqlError{code: "40P01"},
+			expected: util.ErrorClassTransactionSerialization,
+		},
+		{
+			name:     "Database",
+			err:      &sqlError{code: "53300"},
+			expected: util.ErrorClassDatabase,
+		},
+		{
+			name:     "Other",
+			err:      errors.New("something went wrong"),
+			expected: util.ErrorClassOther,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, util.ClassifyError(test.err))
+		})
+	}
+}
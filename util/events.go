@@ -0,0 +1,104 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/rs/zerolog"
+)
+
+// SubscribeEvents subscribes to events with the given topics, passing them to
+// the supplied handler.  If no events are received for the stall timeout the
+// event stream is considered lost, and the subscription is re-established.
+// After each resubscription onResubscribed is called, allowing the caller to
+// catch up with anything it missed while the stream was down.
+// A stall timeout of 0 subscribes without watching the event stream.
+func SubscribeEvents(ctx context.Context,
+	log zerolog.Logger,
+	provider eth2client.EventsProvider,
+	topics []string,
+	stallTimeout time.Duration,
+	handler eth2client.EventHandlerFunc,
+	onResubscribed func(ctx context.Context),
+) error {
+	if stallTimeout == 0 {
+		return provider.Events(ctx, topics, handler)
+	}
+
+	// Events from a subscription that has been replaced are dropped, so that
+	// the handler only sees events from the current subscription.
+	var generation uint64
+	received := make(chan struct{}, 1)
+	subscribe := func() (context.CancelFunc, error) {
+		current := atomic.AddUint64(&generation, 1)
+		subCtx, cancel := context.WithCancel(ctx)
+		if err := provider.Events(subCtx, topics, func(event *apiv1.Event) {
+			if atomic.LoadUint64(&generation) != current {
+				return
+			}
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+			handler(event)
+		}); err != nil {
+			cancel()
+			return nil, err
+		}
+		return cancel, nil
+	}
+
+	cancel, err := subscribe()
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		timer := time.NewTimer(stallTimeout)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				cancel()
+				return
+			case <-received:
+				if !timer.Stop() {
+					<-timer.C
+				}
+			case <-timer.C:
+				log.Warn().Strs("topics", topics).Dur("stall_timeout", stallTimeout).Msg("No events received; resubscribing to event stream")
+				cancel()
+				newCancel, err := subscribe()
+				if err != nil {
+					log.Error().Err(err).Msg("Failed to resubscribe to event stream; will retry")
+					cancel = func() {}
+				} else {
+					cancel = newCancel
+					log.Info().Strs("topics", topics).Msg("Resubscribed to event stream")
+					if onResubscribed != nil {
+						go onResubscribed(ctx)
+					}
+				}
+			}
+			timer.Reset(stallTimeout)
+		}
+	}()
+
+	return nil
+}
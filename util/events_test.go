@@ -0,0 +1,100 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	apiv1 "github.com/attestantio/go-eth2-client/api/v1"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+// eventsProvider records the handlers of its subscriptions.
+type eventsProvider struct {
+	mu       sync.Mutex
+	handlers []eth2client.EventHandlerFunc
+}
+
+func (p *eventsProvider) Events(_ context.Context, _ []string, handler eth2client.EventHandlerFunc) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+	return nil
+}
+
+func (p *eventsProvider) subscriptions() []eth2client.EventHandlerFunc {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]eth2client.EventHandlerFunc{}, p.handlers...)
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider := &eventsProvider{}
+	var mu sync.Mutex
+	handled := 0
+	resubscribed := make(chan struct{}, 1)
+	require.NoError(t, util.SubscribeEvents(ctx, zerolog.Nop(), provider, []string{"head"}, 50*time.Millisecond,
+		func(*apiv1.Event) {
+			mu.Lock()
+			handled++
+			mu.Unlock()
+		},
+		func(context.Context) {
+			resubscribed <- struct{}{}
+		},
+	))
+	require.Len(t, provider.subscriptions(), 1)
+
+	// Events within the stall timeout keep the subscription.
+	for i := 0; i < 4; i++ {
+		provider.subscriptions()[0](&apiv1.Event{Topic: "head"})
+		time.Sleep(20 * time.Millisecond)
+	}
+	require.Len(t, provider.subscriptions(), 1)
+
+	// No events within the stall timeout resubscribes.
+	select {
+	case <-resubscribed:
+	case <-time.After(time.Second):
+		require.Fail(t, "did not resubscribe")
+	}
+	subscriptions := provider.subscriptions()
+	require.GreaterOrEqual(t, len(subscriptions), 2)
+
+	// Events from the old subscription are ignored.
+	subscriptions[0](&apiv1.Event{Topic: "head"})
+	subscriptions[len(subscriptions)-1](&apiv1.Event{Topic: "head"})
+	mu.Lock()
+	require.Equal(t, 5, handled)
+	mu.Unlock()
+}
+
+func TestSubscribeEventsNoStallTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	provider := &eventsProvider{}
+	require.NoError(t, util.SubscribeEvents(ctx, zerolog.Nop(), provider, []string{"head"}, 0, func(*apiv1.Event) {}, nil))
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, provider.subscriptions(), 1)
+}
@@ -0,0 +1,58 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrHalted is returned by services that have halted due to an inconsistency.
+var ErrHalted = errors.New("halted due to inconsistency in strict mode")
+
+// Halt tracks if a service has halted due to an inconsistency in its data.  In
+// strict mode the first inconsistency halts the service, for users who prefer
+// a stopped service to one with incorrect data; otherwise inconsistencies are
+// handled by the service as it sees fit.  It is safe for concurrent use, and a
+// nil Halt is not strict.
+type Halt struct {
+	strict bool
+	halted int32
+}
+
+// NewHalt creates a new halt tracker.
+func NewHalt(strict bool) *Halt {
+	return &Halt{strict: strict}
+}
+
+// Strict returns true if inconsistencies halt the service.
+func (h *Halt) Strict() bool {
+	return h != nil && h.strict
+}
+
+// Inconsistent records an inconsistency, halting the service in strict mode.
+// It returns true if the service has halted.
+func (h *Halt) Inconsistent() bool {
+	if !h.Strict() {
+		return false
+	}
+	atomic.StoreInt32(&h.halted, 1)
+
+	return true
+}
+
+// Halted returns true if the service has halted.
+func (h *Halt) Halted() bool {
+	return h != nil && atomic.LoadInt32(&h.halted) == 1
+}
@@ -0,0 +1,41 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestHalt(t *testing.T) {
+	// A nil halt is not strict, and never halts.
+	var nilHalt *util.Halt
+	require.False(t, nilHalt.Strict())
+	require.False(t, nilHalt.Inconsistent())
+	require.False(t, nilHalt.Halted())
+
+	// Without strict mode inconsistencies do not halt.
+	lenient := util.NewHalt(false)
+	require.False(t, lenient.Inconsistent())
+	require.False(t, lenient.Halted())
+
+	// In strict mode the first inconsistency halts.
+	strict := util.NewHalt(true)
+	require.True(t, strict.Strict())
+	require.False(t, strict.Halted())
+	require.True(t, strict.Inconsistent())
+	require.True(t, strict.Halted())
+}
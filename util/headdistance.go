@@ -0,0 +1,32 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/services/chaintime"
+)
+
+// WithEpochHeadDistance returns a context recording how far the given epoch is
+// behind the current epoch, for transactions writing data for the epoch.
+func WithEpochHeadDistance(ctx context.Context, chainTime chaintime.Service, epoch phase0.Epoch) context.Context {
+	distance := phase0.Epoch(0)
+	if currentEpoch := chainTime.CurrentEpoch(); currentEpoch > epoch {
+		distance = currentEpoch - epoch
+	}
+	return chaindb.WithHeadDistance(ctx, distance)
+}
@@ -16,6 +16,8 @@ package util
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/rs/zerolog"
 	zerologger "github.com/rs/zerolog/log"
@@ -62,3 +64,49 @@ func stringToLevel(input string) zerolog.Level {
 		return zerologger.Logger.GetLevel()
 	}
 }
+
+// levelSampler drops log messages below a level that can be changed at runtime.
+// As a sampler it is consulted before an event is created, so dropped messages
+// incur no encoding cost.
+type levelSampler struct {
+	level int32
+}
+
+// Sample returns true if the level is at or above the sampler's level.
+func (s *levelSampler) Sample(level zerolog.Level) bool {
+	return level >= zerolog.Level(atomic.LoadInt32(&s.level))
+}
+
+var (
+	levelSamplersMu sync.Mutex
+	levelSamplers   = make(map[string]*levelSampler)
+)
+
+// LogLevelSampler returns a sampler that drops log messages below the best log
+// level for the path.  Unlike LogLevel(), the level follows changes to the
+// configuration when ReloadLogLevels() is called.
+func LogLevelSampler(path string) zerolog.Sampler {
+	levelSamplersMu.Lock()
+	defer levelSamplersMu.Unlock()
+
+	sampler, exists := levelSamplers[path]
+	if !exists {
+		sampler = &levelSampler{
+			level: int32(LogLevel(path)),
+		}
+		levelSamplers[path] = sampler
+	}
+
+	return sampler
+}
+
+// ReloadLogLevels updates the levels of all samplers obtained from LogLevelSampler()
+// from the current configuration.
+func ReloadLogLevels() {
+	levelSamplersMu.Lock()
+	defer levelSamplersMu.Unlock()
+
+	for path, sampler := range levelSamplers {
+		atomic.StoreInt32(&sampler.level, int32(LogLevel(path)))
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestLogLevelSampler(t *testing.T) {
+	viper.Set("test-module.log-level", "info")
+	defer viper.Set("test-module.log-level", "")
+
+	var buf bytes.Buffer
+	log := zerolog.New(&buf).Level(zerolog.TraceLevel).Sample(util.LogLevelSampler("test-module"))
+
+	log.Debug().Msg("hidden")
+	require.Empty(t, buf.String())
+
+	viper.Set("test-module.log-level", "debug")
+	util.ReloadLogLevels()
+	log.Debug().Msg("shown")
+	require.Contains(t, buf.String(), "shown")
+}
+
+type countingMarshaler struct {
+	calls int
+}
+
+func (m *countingMarshaler) MarshalZerologObject(e *zerolog.Event) {
+	m.calls++
+}
+
+func TestLogLevelSamplerNoEncoding(t *testing.T) {
+	viper.Set("test-encoding.log-level", "info")
+	defer viper.Set("test-encoding.log-level", "")
+
+	var buf bytes.Buffer
+	log := zerolog.New(&buf).Level(zerolog.TraceLevel).Sample(util.LogLevelSampler("test-encoding"))
+
+	marshaler := &countingMarshaler{}
+	log.Debug().Object("data", marshaler).Msg("hidden")
+	require.Equal(t, 0, marshaler.calls)
+	require.Empty(t, buf.String())
+
+	log.Info().Object("data", marshaler).Msg("shown")
+	require.Equal(t, 1, marshaler.calls)
+}
@@ -0,0 +1,116 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// ProgressSource is a source of indexing progress, obtained from a service's metadata.
+type ProgressSource struct {
+	// Service is the name of the service.
+	Service string
+	// Key is the metadata key of the service.
+	Key string
+	// Field is the field in the metadata that holds the latest slot or epoch processed.
+	Field string
+	// Slots is true if the field is a slot, otherwise it is an epoch.
+	Slots bool
+	// Ranges is the field in the metadata that holds the ranges of epochs
+	// processed, if the service tracks them.
+	Ranges string
+}
+
+// ProgressSources are the services for which indexing progress is available.
+var ProgressSources = []*ProgressSource{
+	{Service: "blocks", Key: "blocks.standard", Field: "latest_slot", Slots: true},
+	{Service: "finalizer", Key: "finalizer.standard", Field: "latest_epoch"},
+	{Service: "summarizer", Key: "summarizer.standard", Field: "latest_epoch", Ranges: "summarized_epochs"},
+	{Service: "validators", Key: "validators.standard", Field: "latest_epoch"},
+	{Service: "validators.balances", Key: "validators.standard", Field: "latest_balances_epoch", Ranges: "balances_epochs"},
+	{Service: "beaconcommittees", Key: "beaconcommittees.standard", Field: "latest_epoch", Ranges: "processed_epochs"},
+	{Service: "proposerduties", Key: "proposerduties.standard", Field: "latest_epoch", Ranges: "processed_epochs"},
+	{Service: "views", Key: "views.standard", Field: "latest_epoch"},
+}
+
+// Progress is the indexing progress of a service.
+type Progress struct {
+	Source *ProgressSource
+	// Latest is the latest slot or epoch processed by the service.
+	Latest uint64
+	// Gaps are the epochs between the first and latest processed by the
+	// service that have not been processed, if the service tracks them.
+	Gaps EpochRanges
+}
+
+// ServiceProgress obtains the indexing progress of each service.  Services
+// that have not yet run are not included.
+func ServiceProgress(ctx context.Context, chainDB chaindb.Service) ([]*Progress, error) {
+	metadata := make(map[string]map[string]json.RawMessage)
+	res := make([]*Progress, 0, len(ProgressSources))
+	for _, source := range ProgressSources {
+		if _, exists := metadata[source.Key]; !exists {
+			md, err := progressMetadata(ctx, chainDB, source.Key)
+			if err != nil {
+				return nil, err
+			}
+			metadata[source.Key] = md
+		}
+		data, exists := metadata[source.Key][source.Field]
+		if !exists {
+			// Service has not yet run.
+			continue
+		}
+		var latest uint64
+		if err := json.Unmarshal(data, &latest); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s for %s", source.Field, source.Key)
+		}
+		progress := &Progress{
+			Source: source,
+			Latest: latest,
+		}
+		if data, exists := metadata[source.Key][source.Ranges]; exists && source.Ranges != "" {
+			var ranges EpochRanges
+			if err := json.Unmarshal(data, &ranges); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s for %s", source.Ranges, source.Key)
+			}
+			if lowest, exists := ranges.Lowest(); exists {
+				progress.Gaps = ranges.Gaps(lowest, phase0.Epoch(latest))
+			}
+		}
+		res = append(res, progress)
+	}
+	return res, nil
+}
+
+// progressMetadata obtains the metadata for a key as a map of fields, or nil if not present.
+func progressMetadata(ctx context.Context, chainDB chaindb.Service, key string) (map[string]json.RawMessage, error) {
+	mdJSON, err := chainDB.Metadata(ctx, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to obtain metadata for %s", key)
+	}
+	if mdJSON == nil {
+		return nil, nil
+	}
+	md := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(mdJSON, &md); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal metadata for %s", key)
+	}
+	return md, nil
+}
@@ -0,0 +1,61 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	eth2client "github.com/attestantio/go-eth2-client"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+// nodeVersionTTL is the time for which the version of a beacon node is cached.
+const nodeVersionTTL = 5 * time.Minute
+
+// nodeVersion is the cached version of a beacon node.
+type nodeVersion struct {
+	version  string
+	obtained time.Time
+}
+
+var nodeVersions = make(map[string]*nodeVersion)
+var nodeVersionsMu sync.Mutex
+
+// Provenance returns the provenance of data obtained from the given beacon
+// node client.  The version of each beacon node is cached for a short time,
+// so this can be called for each item of data fetched.  If the version cannot
+// be obtained it is left empty.
+func Provenance(ctx context.Context, client eth2client.Service) *chaindb.Provenance {
+	address := client.Address()
+
+	nodeVersionsMu.Lock()
+	defer nodeVersionsMu.Unlock()
+	cached, exists := nodeVersions[address]
+	if !exists || time.Since(cached.obtained) > nodeVersionTTL {
+		cached = &nodeVersion{obtained: time.Now()}
+		if provider, isProvider := client.(eth2client.NodeVersionProvider); isProvider {
+			if version, err := provider.NodeVersion(ctx); err == nil {
+				cached.version = version
+			}
+		}
+		nodeVersions[address] = cached
+	}
+
+	return &chaindb.Provenance{
+		Address: address,
+		Version: cached.version,
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+// versionedClient is a beacon node client that counts requests for its version.
+type versionedClient struct {
+	address  string
+	version  string
+	fail     bool
+	requests int
+}
+
+func (c *versionedClient) Name() string {
+	return "versioned"
+}
+
+func (c *versionedClient) Address() string {
+	return c.address
+}
+
+func (c *versionedClient) NodeVersion(_ context.Context) (string, error) {
+	c.requests++
+	if c.fail {
+		return "", errors.New("failed")
+	}
+	return c.version, nil
+}
+
+// unversionedClient is a beacon node client that does not provide its version.
+type unversionedClient struct{}
+
+func (*unversionedClient) Name() string {
+	return "unversioned"
+}
+
+func (*unversionedClient) Address() string {
+	return "http://unversioned:5052"
+}
+
+func TestProvenance(t *testing.T) {
+	ctx := context.Background()
+
+	client := &versionedClient{address: "http://versioned:5052", version: "Test/v1.0.0"}
+	require.Equal(t, &chaindb.Provenance{Address: "http://versioned:5052", Version: "Test/v1.0.0"}, util.Provenance(ctx, client))
+
+	// The version is cached.
+	require.Equal(t, &chaindb.Provenance{Address: "http://versioned:5052", Version: "Test/v1.0.0"}, util.Provenance(ctx, client))
+	require.Equal(t, 1, client.requests)
+
+	// A failure to obtain the version leaves it empty.
+	failing := &versionedClient{address: "http://failing:5052", fail: true}
+	require.Equal(t, &chaindb.Provenance{Address: "http://failing:5052"}, util.Provenance(ctx, failing))
+
+	// A client that does not provide its version leaves it empty.
+	require.Equal(t, &chaindb.Provenance{Address: "http://unversioned:5052"}, util.Provenance(ctx, &unversionedClient{}))
+}
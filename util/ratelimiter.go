@@ -0,0 +1,97 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket that limits the rate at which operations are
+// carried out.  It is safe for concurrent use.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a rate limiter that allows the given number of
+// operations per second on average, with bursts of up to the given size.
+// A rate of 0 does not limit operations.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	l := &RateLimiter{}
+	l.Update(rate, burst)
+	return l
+}
+
+// Update changes the rate and burst size of the limiter.
+func (l *RateLimiter) Update(rate float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = float64(burst)
+	l.tokens = l.burst
+	l.last = time.Now()
+}
+
+// Wait blocks until an operation can be carried out, or the context is done.
+// A nil limiter does not limit operations.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	// Reserve a token; if there are none available the wait is until the
+	// token will have been replenished.
+	l.tokens--
+	delay := time.Duration(0)
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		// Return the reserved token.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+
+	// A nil or zero-rate limiter does not limit.
+	var nilLimiter *util.RateLimiter
+	require.NoError(t, nilLimiter.Wait(ctx))
+	unlimited := util.NewRateLimiter(0, 1)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, unlimited.Wait(ctx))
+	}
+
+	// The burst is available immediately, after which operations are spaced out.
+	limiter := util.NewRateLimiter(100, 5)
+	started := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, limiter.Wait(ctx))
+	}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, limiter.Wait(ctx))
+	}
+	require.GreaterOrEqual(t, time.Since(started), 40*time.Millisecond)
+}
+
+func TestRateLimiterContextDone(t *testing.T) {
+	limiter := util.NewRateLimiter(0.1, 1)
+	require.NoError(t, limiter.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,75 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RetryPolicy is a policy for retrying failed operations with exponential backoff.
+type RetryPolicy struct {
+	// Retries is the number of times an operation is retried after it first fails.
+	Retries int
+	// BaseDelay is the delay before the first retry; it doubles for each subsequent retry.
+	BaseDelay time.Duration
+	// MaxDelay is the maximum delay between retries.
+	MaxDelay time.Duration
+
+	// mu protects the fields above when the policy is updated while in use.
+	mu sync.RWMutex
+}
+
+// Update changes the policy, affecting operations that start after it returns.
+func (p *RetryPolicy) Update(retries int, baseDelay time.Duration, maxDelay time.Duration) {
+	p.mu.Lock()
+	p.Retries = retries
+	p.BaseDelay = baseDelay
+	p.MaxDelay = maxDelay
+	p.mu.Unlock()
+}
+
+// Do calls the supplied function until it succeeds, the retries are exhausted
+// or the context is done, returning the last error encountered.
+// A nil policy calls the function once.
+func (p *RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	err := fn()
+	if err == nil || p == nil {
+		return err
+	}
+
+	p.mu.RLock()
+	retries := p.Retries
+	delay := p.BaseDelay
+	maxDelay := p.MaxDelay
+	p.mu.RUnlock()
+
+	for retry := 0; retry < retries; retry++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+		delay *= 2
+		if maxDelay > 0 && delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+
+	return err
+}
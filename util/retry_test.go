@@ -0,0 +1,112 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		policy   *util.RetryPolicy
+		failures int
+		calls    int
+		err      string
+	}{
+		{
+			name:     "NilPolicy",
+			failures: 1,
+			calls:    1,
+			err:      "failed",
+		},
+		{
+			name:   "Success",
+			policy: &util.RetryPolicy{Retries: 3, BaseDelay: time.Millisecond},
+			calls:  1,
+		},
+		{
+			name:     "SuccessAfterRetries",
+			policy:   &util.RetryPolicy{Retries: 3, BaseDelay: time.Millisecond},
+			failures: 2,
+			calls:    3,
+		},
+		{
+			name:     "RetriesExhausted",
+			policy:   &util.RetryPolicy{Retries: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond},
+			failures: 10,
+			calls:    4,
+			err:      "failed",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			calls := 0
+			err := test.policy.Do(ctx, func() error {
+				calls++
+				if calls <= test.failures {
+					return errors.New("failed")
+				}
+				return nil
+			})
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.calls, calls)
+		})
+	}
+}
+
+func TestRetryPolicyContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	policy := &util.RetryPolicy{Retries: 3, BaseDelay: time.Hour}
+	err := policy.Do(ctx, func() error {
+		calls++
+		return errors.New("failed")
+	})
+	require.EqualError(t, err, "failed")
+	require.Equal(t, 1, calls)
+}
+
+func TestRetryPolicyUpdate(t *testing.T) {
+	ctx := context.Background()
+	policy := &util.RetryPolicy{Retries: 0}
+
+	calls := 0
+	fn := func() error {
+		calls++
+		return errors.New("failed")
+	}
+	require.EqualError(t, policy.Do(ctx, fn), "failed")
+	require.Equal(t, 1, calls)
+
+	policy.Update(2, time.Millisecond, time.Millisecond)
+	calls = 0
+	require.EqualError(t, policy.Do(ctx, fn), "failed")
+	require.Equal(t, 3, calls)
+}
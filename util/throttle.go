@@ -0,0 +1,133 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/attestantio/go-eth2-client/spec/phase0"
+	"github.com/rs/zerolog"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const (
+	// throttleCheckInterval is the interval between checks of the load on the database.
+	throttleCheckInterval = time.Second
+	// throttleMinHeadDistance is the number of epochs behind the head of the
+	// chain at which data is throttled, so that following the chain is not.
+	throttleMinHeadDistance = phase0.Epoch(2)
+)
+
+// Throttle holds back catchup and backfill while the database is under load,
+// so that they do not starve other users of the database.  It is safe for
+// concurrent use.
+type Throttle struct {
+	log                zerolog.Logger
+	provider           chaindb.DatabaseLoadProvider
+	maxCommitLatency   time.Duration
+	maxConnectionUsage float64
+	maxReplicationLag  time.Duration
+
+	// mu protects checked and reason, the result of the last check.
+	mu      sync.Mutex
+	checked time.Time
+	reason  string
+}
+
+// NewThrottle creates a throttle that holds back operations while the
+// average commit latency, fraction of connections in use or replication lag
+// of the database exceed the given limits.  A limit of 0 is not checked.
+func NewThrottle(log zerolog.Logger,
+	provider chaindb.DatabaseLoadProvider,
+	maxCommitLatency time.Duration,
+	maxConnectionUsage float64,
+	maxReplicationLag time.Duration,
+) *Throttle {
+	return &Throttle{
+		log:                log,
+		provider:           provider,
+		maxCommitLatency:   maxCommitLatency,
+		maxConnectionUsage: maxConnectionUsage,
+		maxReplicationLag:  maxReplicationLag,
+	}
+}
+
+// Wait blocks until the database is not under load, or the context is done.
+// If the context records a head distance, operations close to the head of the
+// chain are not held back.  A nil throttle does not hold back operations.
+func (t *Throttle) Wait(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	if distance, ok := chaindb.HeadDistance(ctx); ok && distance < throttleMinHeadDistance {
+		return nil
+	}
+
+	var started time.Time
+	for {
+		reason := t.overloaded(ctx)
+		if reason == "" {
+			if !started.IsZero() {
+				t.log.Debug().Dur("throttled", time.Since(started)).Msg("Database load recovered; resuming")
+			}
+			return nil
+		}
+		if started.IsZero() {
+			t.log.Debug().Str("reason", reason).Msg("Database under load; throttling")
+			started = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(throttleCheckInterval):
+		}
+	}
+}
+
+// overloaded returns the reason that the database is under load, or an empty
+// string if it is not.  The load is checked at most once per interval.
+func (t *Throttle) overloaded(ctx context.Context) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if time.Since(t.checked) < throttleCheckInterval {
+		return t.reason
+	}
+	t.checked = time.Now()
+
+	load, err := t.provider.DatabaseLoad(ctx)
+	if err != nil {
+		// Failure to obtain the load should not stop processing.
+		t.log.Debug().Err(err).Msg("Failed to obtain database load")
+		t.reason = ""
+		return t.reason
+	}
+
+	switch {
+	case t.maxCommitLatency > 0 && load.CommitLatency > t.maxCommitLatency:
+		t.reason = "commit latency"
+	case t.maxConnectionUsage > 0 && load.MaxConnections > 0 &&
+		float64(load.Connections)/float64(load.MaxConnections) > t.maxConnectionUsage:
+		t.reason = "connection usage"
+	case t.maxReplicationLag > 0 && load.ReplicationLag > t.maxReplicationLag:
+		t.reason = "replication lag"
+	default:
+		t.reason = ""
+	}
+
+	return t.reason
+}
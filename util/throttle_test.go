@@ -0,0 +1,110 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/services/chaindb"
+	"github.com/wealdtech/chaind/util"
+)
+
+type loadProvider struct {
+	mu   sync.Mutex
+	load *chaindb.DatabaseLoad
+}
+
+func (p *loadProvider) DatabaseLoad(_ context.Context) (*chaindb.DatabaseLoad, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.load, nil
+}
+
+func (p *loadProvider) setLoad(load *chaindb.DatabaseLoad) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.load = load
+}
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+
+	// A nil throttle does not hold back.
+	var nilThrottle *util.Throttle
+	require.NoError(t, nilThrottle.Wait(ctx))
+
+	// A database within its limits does not hold back.
+	provider := &loadProvider{load: &chaindb.DatabaseLoad{
+		CommitLatency:  10 * time.Millisecond,
+		Connections:    4,
+		MaxConnections: 16,
+	}}
+	throttle := util.NewThrottle(zerolog.Nop(), provider, 100*time.Millisecond, 0.9, time.Minute)
+	require.NoError(t, throttle.Wait(ctx))
+}
+
+func TestThrottleOverloaded(t *testing.T) {
+	tests := []struct {
+		name string
+		load *chaindb.DatabaseLoad
+	}{
+		{
+			name: "CommitLatency",
+			load: &chaindb.DatabaseLoad{CommitLatency: time.Second, MaxConnections: 16},
+		},
+		{
+			name: "ConnectionUsage",
+			load: &chaindb.DatabaseLoad{Connections: 16, MaxConnections: 16},
+		},
+		{
+			name: "ReplicationLag",
+			load: &chaindb.DatabaseLoad{MaxConnections: 16, ReplicationLag: time.Hour},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := &loadProvider{load: test.load}
+			throttle := util.NewThrottle(zerolog.Nop(), provider, 100*time.Millisecond, 0.9, time.Minute)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			require.ErrorIs(t, throttle.Wait(ctx), context.DeadlineExceeded)
+
+			// Data close to the head of the chain is not held back.
+			require.NoError(t, throttle.Wait(chaindb.WithHeadDistance(context.Background(), 0)))
+		})
+	}
+}
+
+func TestThrottleRecovers(t *testing.T) {
+	provider := &loadProvider{load: &chaindb.DatabaseLoad{CommitLatency: time.Second}}
+	throttle := util.NewThrottle(zerolog.Nop(), provider, 100*time.Millisecond, 0, 0)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		provider.setLoad(&chaindb.DatabaseLoad{})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	started := time.Now()
+	require.NoError(t, throttle.Wait(ctx))
+	require.GreaterOrEqual(t, time.Since(started), 100*time.Millisecond)
+}
@@ -0,0 +1,80 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/wealdtech/chaind/services/chaindb"
+)
+
+const (
+	// txAttempts is the number of times a transaction is attempted before giving up.
+	txAttempts = 5
+	// txBaseDelay is the upper bound of the delay before the first retry of a transaction.
+	txBaseDelay = 20 * time.Millisecond
+	// txMaxDelay is the maximum upper bound of the delay before a retry of a transaction.
+	txMaxDelay = time.Second
+)
+
+// RunTx runs the supplied function in a transaction on the chain database,
+// committing the transaction if the function succeeds.  If the transaction
+// fails due to a serialization failure or deadlock, which can happen when
+// transactions from different services touch the same rows, it is run again
+// after a jittered delay.  As such the function may be called more than once,
+// and should not alter state outside of the transaction.
+func RunTx(ctx context.Context, chainDB chaindb.Service, fn func(ctx context.Context) error) error {
+	delay := txBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, chainDB, fn)
+		if err == nil || attempt == txAttempts || ClassifyError(err) != ErrorClassTransactionSerialization {
+			return err
+		}
+
+		// Delay for a random time up to the current delay, so that conflicting
+		// transactions do not retry in lockstep.
+		// #nosec G404
+		jittered := time.Duration(rand.Int63n(int64(delay))) + 1
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(jittered):
+		}
+		delay *= 2
+		if delay > txMaxDelay {
+			delay = txMaxDelay
+		}
+	}
+}
+
+// runTx runs the supplied function in a single transaction.
+func runTx(ctx context.Context, chainDB chaindb.Service, fn func(ctx context.Context) error) error {
+	dbCtx, cancel, err := chainDB.BeginTx(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to begin transaction")
+	}
+	if err := fn(dbCtx); err != nil {
+		cancel()
+		return err
+	}
+	if err := chainDB.CommitTx(dbCtx); err != nil {
+		cancel()
+		return errors.Wrap(err, "failed to commit transaction")
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright © 2022 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/wealdtech/chaind/util"
+)
+
+// txChainDB is a chain database that counts its transactions.
+type txChainDB struct {
+	begun     int
+	committed int
+	cancelled int
+}
+
+func (d *txChainDB) BeginTx(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	d.begun++
+	return ctx, func() { d.cancelled++ }, nil
+}
+
+func (d *txChainDB) CommitTx(_ context.Context) error {
+	d.committed++
+	return nil
+}
+
+func (*txChainDB) SetMetadata(_ context.Context, _ string, _ []byte) error {
+	return nil
+}
+
+func (*txChainDB) Metadata(_ context.Context, _ string) ([]byte, error) {
+	return nil, nil
+}
+
+func TestRunTx(t *testing.T) {
+	ctx := context.Background()
+
+	// Success.
+	chainDB := &txChainDB{}
+	require.NoError(t, util.RunTx(ctx, chainDB, func(context.Context) error { return nil }))
+	require.Equal(t, &txChainDB{begun: 1, committed: 1}, chainDB)
+
+	// Serialization failures are retried.
+	chainDB = &txChainDB{}
+	calls := 0
+	require.NoError(t, util.RunTx(ctx, chainDB, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return &sqlError{code: "40001"}
+		}
+		return nil
+	}))
+	require.Equal(t, &txChainDB{begun: 3, committed: 1, cancelled: 2}, chainDB)
+
+	// Retries are limited.
+	chainDB = &txChainDB{}
+	require.EqualError(t, util.RunTx(ctx, chainDB, func(context.Context) error {
+		return &sqlError{code: "40P01"}
+	}), "SQLSTATE 40P01")
+	require.Equal(t, 5, chainDB.begun)
+
+	// Other errors are not retried.
+	chainDB = &txChainDB{}
+	require.EqualError(t, util.RunTx(ctx, chainDB, func(context.Context) error {
+		return errors.New("failed")
+	}), "failed")
+	require.Equal(t, &txChainDB{begun: 1, cancelled: 1}, chainDB)
+}